@@ -17,6 +17,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/go-rootcerts"
+	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/sethgrid/pester"
 	"golang.org/x/net/http2"
@@ -34,6 +35,7 @@ const EnvVaultWrapTTL = "VAULT_WRAP_TTL"
 const EnvVaultMaxRetries = "VAULT_MAX_RETRIES"
 const EnvVaultToken = "VAULT_TOKEN"
 const EnvVaultMFA = "VAULT_MFA"
+const EnvVaultNamespace = "VAULT_NAMESPACE"
 
 // WrappingLookupFunc is a function that, given an HTTP verb and a path,
 // returns an optional string duration to be used for response wrapping (e.g.
@@ -289,6 +291,7 @@ type Client struct {
 	wrappingLookupFunc WrappingLookupFunc
 	mfaCreds           []string
 	policyOverride     bool
+	namespace          string
 }
 
 // NewClient returns a new client for the given configuration.
@@ -298,7 +301,8 @@ type Client struct {
 //
 // If the environment variable `VAULT_TOKEN` is present, the token will be
 // automatically added to the client. Otherwise, you must manually call
-// `SetToken()`.
+// `SetToken()`. Likewise, the namespace is taken from `VAULT_NAMESPACE` if it
+// is set.
 func NewClient(c *Config) (*Client, error) {
 	def := DefaultConfig()
 	if def == nil {
@@ -336,6 +340,10 @@ func NewClient(c *Config) (*Client, error) {
 		client.token = token
 	}
 
+	if namespace := os.Getenv(EnvVaultNamespace); namespace != "" {
+		client.namespace = namespace
+	}
+
 	return client, nil
 }
 
@@ -426,6 +434,52 @@ func (c *Client) ClearToken() {
 	c.token = ""
 }
 
+// Namespace returns the namespace requests are sent to. It will return the
+// empty string for the root namespace.
+func (c *Client) Namespace() string {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	return c.namespace
+}
+
+// SetNamespace sets the namespace future requests are sent to, using the
+// X-Vault-Namespace header. Request paths are relative to this namespace.
+func (c *Client) SetNamespace(namespace string) {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	c.namespace = namespace
+}
+
+// ClearNamespace sends future requests to the root namespace.
+func (c *Client) ClearNamespace() {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+
+	c.namespace = ""
+}
+
+// Headers gets the current set of headers used for requests. This returns a
+// copy; to modify it make modifications locally and use SetHeaders.
+func (c *Client) Headers() http.Header {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+
+	if c.headers == nil {
+		return nil
+	}
+
+	ret := make(http.Header)
+	for k, v := range c.headers {
+		for _, val := range v {
+			ret[k] = append(ret[k], val)
+		}
+	}
+
+	return ret
+}
+
 // SetHeaders sets the headers to be used for future requests.
 func (c *Client) SetHeaders(headers http.Header) {
 	c.modifyLock.Lock()
@@ -518,6 +572,16 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 	if c.headers != nil {
 		req.Headers = c.headers
 	}
+	if c.namespace != "" {
+		// Copy the headers so that the ones shared by the client are
+		// left unmodified
+		headers := make(http.Header, len(req.Headers)+1)
+		for k, v := range req.Headers {
+			headers[k] = v
+		}
+		headers.Set(consts.NamespaceHeaderName, c.namespace)
+		req.Headers = headers
+	}
 
 	req.PolicyOverride = c.policyOverride
 
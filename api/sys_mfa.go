@@ -0,0 +1,43 @@
+package api
+
+// MFAValidate completes a login that requires multi-factor authentication.
+// The payload maps the names of the required MFA methods to the passcodes
+// for them; methods that don't use passcodes, such as push notifications,
+// take an empty list. While push notifications are waiting to be approved,
+// the returned secret holds the MFA requirement of the methods still
+// outstanding and the login is polled by calling MFAValidate again.
+func (c *Sys) MFAValidate(requestID string, payload map[string][]string) (*Secret, error) {
+	body := map[string]interface{}{
+		"mfa_request_id": requestID,
+		"mfa_payload":    payload,
+	}
+
+	r := c.c.NewRequest("PUT", "/v1/sys/mfa/validate")
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequest(r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSecret(resp.Body)
+}
+
+// MFARequirement is returned in place of a client token when a login must be
+// completed with MFAValidate
+type MFARequirement struct {
+	MFARequestID string           `json:"mfa_request_id"`
+	MFAMethods   []*MFAMethodInfo `json:"mfa_methods"`
+}
+
+// MFAMethodInfo describes an MFA method required by a login
+type MFAMethodInfo struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	UsesPasscode bool   `json:"uses_passcode"`
+}
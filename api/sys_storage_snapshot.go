@@ -0,0 +1,29 @@
+package api
+
+import "io"
+
+// StorageSnapshot writes a snapshot of Vault's storage to w
+func (c *Sys) StorageSnapshot(w io.Writer) error {
+	r := c.c.NewRequest("GET", "/v1/sys/storage/snapshot")
+	resp, err := c.c.RawRequest(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// StorageSnapshotRestore replaces the contents of Vault's storage with the
+// snapshot read from snapshot. Vault seals itself once the snapshot has been
+// restored.
+func (c *Sys) StorageSnapshotRestore(snapshot io.Reader) error {
+	r := c.c.NewRequest("PUT", "/v1/sys/storage/snapshot")
+	r.Body = snapshot
+	resp, err := c.c.RawRequest(r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// TransitDefaultMountPoint is the default path at which the transit
+	// backend is mounted
+	TransitDefaultMountPoint = "transit"
+
+	// TransitDefaultFrameSize is the default size of the plaintext of each
+	// frame of an encrypted stream
+	TransitDefaultFrameSize = 64 * 1024
+
+	// TransitMaxFrameSize is the largest frame size of an encrypted stream
+	TransitMaxFrameSize = 16 * 1024 * 1024
+
+	transitStreamMagic       = "VTS\x01"
+	transitStreamMaxKeyLen   = 64 * 1024
+	transitStreamNoncePrefix = 7
+)
+
+// Transit is used to return a client to invoke operations on the transit
+// backend.
+type Transit struct {
+	c          *Client
+	MountPoint string
+}
+
+// Transit returns the client for transit backend API calls.
+func (c *Client) Transit() *Transit {
+	return c.TransitWithMountPoint(TransitDefaultMountPoint)
+}
+
+// TransitWithMountPoint returns the client with specific transit mount point.
+func (c *Client) TransitWithMountPoint(mountPoint string) *Transit {
+	return &Transit{
+		c:          c,
+		MountPoint: mountPoint,
+	}
+}
+
+// TransitStreamOptions are the options of encrypting and decrypting streams.
+type TransitStreamOptions struct {
+	// Context is the context for key derivation, required if the key is
+	// derived. It is given unencoded.
+	Context []byte
+
+	// KeyVersion is the version of the key that encrypts the data key, or 0
+	// for the latest version. It is only used for encryption.
+	KeyVersion int
+
+	// FrameSize is the size of the plaintext of each frame, or 0 for
+	// TransitDefaultFrameSize. It is only used for encryption.
+	FrameSize int
+}
+
+// EncryptStream encrypts src to dst with a new data key, which is generated
+// and encrypted by the named key through the datakey endpoint. The payload is
+// encrypted locally and never sent to Vault.
+//
+// The output starts with a header holding the encrypted data key, followed
+// by the payload in frames encrypted with AES-256-GCM, whose order and
+// completeness are authenticated. It is decrypted with DecryptStream.
+func (c *Transit) EncryptStream(name string, dst io.Writer, src io.Reader, opts *TransitStreamOptions) error {
+	if opts == nil {
+		opts = &TransitStreamOptions{}
+	}
+	frameSize := opts.FrameSize
+	if frameSize == 0 {
+		frameSize = TransitDefaultFrameSize
+	}
+	if frameSize < 0 || frameSize > TransitMaxFrameSize {
+		return fmt.Errorf("frame size must be between 1 and %d", TransitMaxFrameSize)
+	}
+
+	data := map[string]interface{}{
+		"bits": 256,
+	}
+	if len(opts.Context) > 0 {
+		data["context"] = base64.StdEncoding.EncodeToString(opts.Context)
+	}
+	if opts.KeyVersion > 0 {
+		data["key_version"] = opts.KeyVersion
+	}
+	secret, err := c.c.Logical().Write(fmt.Sprintf("%s/datakey/plaintext/%s", c.MountPoint, name), data)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Data == nil {
+		return errors.New("no data key returned")
+	}
+	wrappedKey, ok := secret.Data["ciphertext"].(string)
+	if !ok || wrappedKey == "" {
+		return errors.New("no encrypted data key returned")
+	}
+	dataKeyB64, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return errors.New("no data key returned")
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(dataKeyB64)
+	if err != nil {
+		return fmt.Errorf("error decoding data key: %v", err)
+	}
+
+	// The header binds the frames to the data key and frame size
+	var header bytes.Buffer
+	header.WriteString(transitStreamMagic)
+	binary.Write(&header, binary.BigEndian, uint32(frameSize))
+	binary.Write(&header, binary.BigEndian, uint32(len(wrappedKey)))
+	header.WriteString(wrappedKey)
+	noncePrefix := make([]byte, transitStreamNoncePrefix)
+	if _, err := io.ReadFull(rand.Reader, noncePrefix); err != nil {
+		return err
+	}
+	header.Write(noncePrefix)
+
+	aead, err := transitStreamAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dst.Write(header.Bytes()); err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, frameSize)
+	plaintext := make([]byte, frameSize)
+	ciphertext := make([]byte, 0, frameSize+aead.Overhead())
+	for counter := uint64(0); ; counter++ {
+		if counter > math.MaxUint32 {
+			return errors.New("too many frames in stream")
+		}
+
+		n, err := io.ReadFull(r, plaintext)
+		last := false
+		switch err {
+		case nil:
+			// The frame is the last one if nothing follows it
+			if _, err := r.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return err
+		}
+
+		nonce := transitStreamNonce(noncePrefix, uint32(counter), last)
+		ciphertext = aead.Seal(ciphertext[:0], nonce, plaintext[:n], header.Bytes())
+		if _, err := dst.Write(ciphertext); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// DecryptStream decrypts src, as encrypted by EncryptStream, to dst. The data
+// key is decrypted by the named key through the decrypt endpoint.
+//
+// Frames are written to dst as soon as they are authenticated, so an error
+// may be returned after a part of the plaintext has been written, for
+// instance if the stream was truncated or tampered with.
+func (c *Transit) DecryptStream(name string, dst io.Writer, src io.Reader, opts *TransitStreamOptions) error {
+	if opts == nil {
+		opts = &TransitStreamOptions{}
+	}
+
+	r := bufio.NewReader(src)
+
+	var header bytes.Buffer
+	fixed := make([]byte, len(transitStreamMagic)+8)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return fmt.Errorf("error reading stream header: %v", err)
+	}
+	if string(fixed[:len(transitStreamMagic)]) != transitStreamMagic {
+		return errors.New("not an encrypted stream")
+	}
+	header.Write(fixed)
+	frameSize := binary.BigEndian.Uint32(fixed[len(transitStreamMagic):])
+	keyLen := binary.BigEndian.Uint32(fixed[len(transitStreamMagic)+4:])
+	if frameSize == 0 || frameSize > TransitMaxFrameSize {
+		return fmt.Errorf("invalid frame size %d", frameSize)
+	}
+	if keyLen == 0 || keyLen > transitStreamMaxKeyLen {
+		return fmt.Errorf("invalid encrypted data key length %d", keyLen)
+	}
+
+	rest := make([]byte, int(keyLen)+transitStreamNoncePrefix)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return fmt.Errorf("error reading stream header: %v", err)
+	}
+	header.Write(rest)
+	wrappedKey := string(rest[:keyLen])
+	noncePrefix := rest[keyLen:]
+
+	data := map[string]interface{}{
+		"ciphertext": wrappedKey,
+	}
+	if len(opts.Context) > 0 {
+		data["context"] = base64.StdEncoding.EncodeToString(opts.Context)
+	}
+	secret, err := c.c.Logical().Write(fmt.Sprintf("%s/decrypt/%s", c.MountPoint, name), data)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Data == nil {
+		return errors.New("no data key returned")
+	}
+	dataKeyB64, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return errors.New("no data key returned")
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(dataKeyB64)
+	if err != nil {
+		return fmt.Errorf("error decoding data key: %v", err)
+	}
+
+	aead, err := transitStreamAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	ciphertext := make([]byte, int(frameSize)+aead.Overhead())
+	plaintext := make([]byte, 0, frameSize)
+	for counter := uint64(0); ; counter++ {
+		if counter > math.MaxUint32 {
+			return errors.New("too many frames in stream")
+		}
+
+		n, err := io.ReadFull(r, ciphertext)
+		last := false
+		switch err {
+		case nil:
+			if _, err := r.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return err
+		}
+		if n < aead.Overhead() {
+			return errors.New("stream is truncated")
+		}
+
+		// A stream truncated at a frame boundary fails here, as its last
+		// frame wasn't encrypted as such
+		nonce := transitStreamNonce(noncePrefix, uint32(counter), last)
+		plaintext, err = aead.Open(plaintext[:0], nonce, ciphertext[:n], header.Bytes())
+		if err != nil {
+			return fmt.Errorf("error decrypting frame %d: %v", counter, err)
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+func transitStreamAEAD(dataKey []byte) (cipher.AEAD, error) {
+	if len(dataKey) != 32 {
+		return nil, fmt.Errorf("invalid data key length %d", len(dataKey))
+	}
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// transitStreamNonce returns the nonce of a frame, made of the random prefix
+// of the stream, the frame counter and whether the frame is the last one
+func transitStreamNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, prefix...)
+	nonce = append(nonce, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(nonce[len(prefix):], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
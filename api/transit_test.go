@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testTransitHandler fakes the datakey and decrypt endpoints, keeping the
+// data keys in memory
+type testTransitHandler struct {
+	sync.Mutex
+	t        *testing.T
+	dataKeys map[string]string
+}
+
+func (h *testTransitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Lock()
+	defer h.Unlock()
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.t.Errorf("err: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	context, _ := body["context"].(string)
+	data := map[string]interface{}{}
+	switch r.URL.Path {
+	case "/v1/transit/datakey/plaintext/test":
+		if body["bits"] != float64(256) {
+			h.t.Errorf("256-bit data key not requested: %#v", body)
+		}
+		ciphertext := fmt.Sprintf("vault:v1:%d", len(h.dataKeys))
+		key := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			h.t.Errorf("err: %s", err)
+		}
+		data["ciphertext"] = ciphertext
+		data["plaintext"] = base64.StdEncoding.EncodeToString(key)
+		h.dataKeys[ciphertext+context] = data["plaintext"].(string)
+	case "/v1/transit/decrypt/test":
+		key, ok := h.dataKeys[body["ciphertext"].(string)+context]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid ciphertext"]}`))
+			return
+		}
+		data["plaintext"] = key
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": data,
+	})
+}
+
+func testTransitClient(t *testing.T) (*Client, func()) {
+	config, ln := testHTTPServer(t, &testTransitHandler{
+		t:        t,
+		dataKeys: map[string]string{},
+	})
+	client, err := NewClient(config)
+	if err != nil {
+		ln.Close()
+		t.Fatal(err)
+	}
+	client.SetToken("foo")
+	return client, func() { ln.Close() }
+}
+
+func TestTransit_Stream(t *testing.T) {
+	client, closer := testTransitClient(t)
+	defer closer()
+
+	opts := &TransitStreamOptions{
+		Context:   []byte("backup"),
+		FrameSize: 1024,
+	}
+	for _, size := range []int{0, 1, 1023, 1024, 1025, 4096, 10000} {
+		plaintext := make([]byte, size)
+		if _, err := io.ReadFull(rand.Reader, plaintext); err != nil {
+			t.Fatal(err)
+		}
+
+		var encrypted bytes.Buffer
+		if err := client.Transit().EncryptStream("test", &encrypted, bytes.NewReader(plaintext), opts); err != nil {
+			t.Fatalf("size %d: err: %s", size, err)
+		}
+		if size >= 16 && bytes.Contains(encrypted.Bytes(), plaintext) {
+			t.Fatalf("size %d: plaintext found in encrypted stream", size)
+		}
+
+		var decrypted bytes.Buffer
+		if err := client.Transit().DecryptStream("test", &decrypted, bytes.NewReader(encrypted.Bytes()), opts); err != nil {
+			t.Fatalf("size %d: err: %s", size, err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plaintext) {
+			t.Fatalf("size %d: decrypted stream doesn't match plaintext", size)
+		}
+
+		// The data key can't be decrypted with another context
+		err := client.Transit().DecryptStream("test", ioutil.Discard, bytes.NewReader(encrypted.Bytes()), nil)
+		if err == nil {
+			t.Fatalf("size %d: expected an error decrypting without context", size)
+		}
+	}
+}
+
+func TestTransit_StreamTampering(t *testing.T) {
+	client, closer := testTransitClient(t)
+	defer closer()
+
+	opts := &TransitStreamOptions{
+		FrameSize: 1024,
+	}
+	plaintext := []byte(strings.Repeat("a", 3000))
+	var encrypted bytes.Buffer
+	if err := client.Transit().EncryptStream("test", &encrypted, bytes.NewReader(plaintext), opts); err != nil {
+		t.Fatal(err)
+	}
+	stream := encrypted.Bytes()
+	frameLen := 1024 + 16
+	headerLen := len(stream) - 2*frameLen - (3000 - 2*1024 + 16)
+
+	cases := map[string][]byte{
+		// Truncated at a frame boundary
+		"truncated frames": stream[:headerLen+2*frameLen],
+		// Truncated within a frame
+		"truncated frame": stream[:len(stream)-1],
+		// Truncated within the header
+		"truncated header": stream[:headerLen-1],
+		// Frames reordered
+		"reordered": append(append(append([]byte{}, stream[:headerLen]...),
+			stream[headerLen+frameLen:headerLen+2*frameLen]...),
+			append(append([]byte{}, stream[headerLen:headerLen+frameLen]...), stream[headerLen+2*frameLen:]...)...),
+		// Data appended
+		"appended": append(append([]byte{}, stream...), 0),
+	}
+	flipped := append([]byte{}, stream...)
+	flipped[headerLen+frameLen+10] ^= 1
+	cases["flipped"] = flipped
+	prefix := append([]byte{}, stream...)
+	prefix[headerLen-1] ^= 1
+	cases["nonce prefix"] = prefix
+
+	for name, tampered := range cases {
+		var decrypted bytes.Buffer
+		err := client.Transit().DecryptStream("test", &decrypted, bytes.NewReader(tampered), opts)
+		if err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package approle
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/hashicorp/errwrap"
@@ -82,11 +83,34 @@ func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, dat
 		},
 	}
 
+	if role.BindTokenToLoginAddress {
+		if req.Connection == nil || req.Connection.RemoteAddr == "" {
+			return logical.ErrorResponse("failed to get connection information"), nil
+		}
+		boundCIDR, err := addressCIDR(req.Connection.RemoteAddr)
+		if err != nil {
+			return nil, err
+		}
+		auth.BoundCIDRs = []string{boundCIDR}
+	}
+
 	return &logical.Response{
 		Auth: auth,
 	}, nil
 }
 
+// addressCIDR returns the CIDR block containing only the given IP address
+func addressCIDR(addr string) (string, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP address %q", addr)
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
 // Invoked when the token issued by this backend is attempting a renewal.
 func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	roleName := req.Auth.InternalData["role_name"].(string)
@@ -137,6 +137,61 @@ func TestAppRole_RoleLogin(t *testing.T) {
 	}
 }
 
+func TestAppRole_RoleLoginBindTokenToLoginAddress(t *testing.T) {
+	var resp *logical.Response
+	var err error
+	b, storage := createBackendWithStorage(t)
+
+	roleReq := &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "role/role1",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"policies":                    "a,b,c",
+			"bind_secret_id":              false,
+			"bound_cidr_list":             "10.0.0.0/8,::1/128",
+			"bind_token_to_login_address": true,
+		},
+	}
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "role/role1/role-id",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	roleID := resp.Data["role_id"]
+
+	for addr, expected := range map[string]string{
+		"10.1.2.3": "10.1.2.3/32",
+		"::1":      "::1/128",
+	} {
+		loginResp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "login",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"role_id": roleID,
+			},
+			Connection: &logical.Connection{
+				RemoteAddr: addr,
+			},
+		})
+		if err != nil || (loginResp != nil && loginResp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, loginResp)
+		}
+		if len(loginResp.Auth.BoundCIDRs) != 1 || loginResp.Auth.BoundCIDRs[0] != expected {
+			t.Fatalf("expected the token to be bound to %q, got %#v", expected, loginResp.Auth.BoundCIDRs)
+		}
+	}
+}
+
 func generateRenewRequest(s logical.Storage, auth *logical.Auth) *logical.Request {
 	renewReq := &logical.Request{
 		Operation: logical.RenewOperation,
@@ -3,6 +3,7 @@ package approle
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,6 +19,10 @@ import (
 	"github.com/hashicorp/vault/logical/framework"
 )
 
+// maxSecretIDListDetailsLimit is the largest number of SecretIDs returned by
+// a single call to the list-details endpoint
+const maxSecretIDListDetailsLimit = 1000
+
 // roleStorageEntry stores all the options that are set on an role
 type roleStorageEntry struct {
 	// UUID that uniquely represents this role. This serves as a credential
@@ -67,6 +72,10 @@ type roleStorageEntry struct {
 	// LowerCaseRoleName enforces the lower casing of role names for all the
 	// roles that get created since this field was introduced.
 	LowerCaseRoleName bool `json:"lower_case_role_name" mapstructure:"lower_case_role_name" structs:"lower_case_role_name"`
+
+	// BindTokenToLoginAddress, if set, binds the tokens issued by the role to
+	// the address of the client that logged in
+	BindTokenToLoginAddress bool `json:"bind_token_to_login_address" mapstructure:"bind_token_to_login_address" structs:"bind_token_to_login_address"`
 }
 
 // roleIDStorageEntry represents the reverse mapping from RoleID to Role
@@ -91,6 +100,7 @@ type roleIDStorageEntry struct {
 // role/<role_name>/role-id - For fetching the role_id of an role
 // role/<role_name>/secret-id - For issuing a secret_id against an role, also to list the secret_id_accessors
 // role/<role_name>/custom-secret-id - For assigning a custom SecretID against an role
+// role/<role_name>/secret-id/list-details - For reading the properties of all the secret_ids, a page at a time
 // role/<role_name>/secret-id/lookup - For reading the properties of a secret_id
 // role/<role_name>/secret-id/destroy - For deleting a secret_id
 // role/<role_name>/secret-id-accessor/lookup - For reading secret_id using accessor
@@ -163,6 +173,11 @@ TTL will be set to the value of this parameter.`,
 					Type:        framework.TypeString,
 					Description: "Identifier of the role. Defaults to a UUID.",
 				},
+				"bind_token_to_login_address": &framework.FieldSchema{
+					Type: framework.TypeBool,
+					Description: `If set, the tokens issued by the role can only be used from the
+address of the client that logged in.`,
+				},
 			},
 			ExistenceCheck: b.pathRoleExistenceCheck,
 			Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -410,6 +425,30 @@ the role.`,
 			HelpSynopsis:    strings.TrimSpace(roleHelp["role-secret-id"][0]),
 			HelpDescription: strings.TrimSpace(roleHelp["role-secret-id"][1]),
 		},
+		&framework.Path{
+			Pattern: "role/" + framework.GenericNameRegex("role_name") + "/secret-id/list-details/?$",
+			Fields: map[string]*framework.FieldSchema{
+				"role_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+				},
+				"after": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Accessor of the SecretID after which to continue the listing.",
+				},
+				"limit": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Default:     100,
+					Description: "Maximum number of SecretIDs to return. Defaults to 100, and is capped at 1000.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.pathRoleSecretIDListDetails,
+				logical.UpdateOperation: b.pathRoleSecretIDListDetails,
+			},
+			HelpSynopsis:    strings.TrimSpace(roleHelp["role-secret-id-list-details"][0]),
+			HelpDescription: strings.TrimSpace(roleHelp["role-secret-id-list-details"][1]),
+		},
 		&framework.Path{
 			Pattern: "role/" + framework.GenericNameRegex("role_name") + "/secret-id/lookup/?$",
 			Fields: map[string]*framework.FieldSchema{
@@ -628,6 +667,116 @@ func (b *backend) pathRoleSecretIDList(ctx context.Context, req *logical.Request
 	return logical.ListResponse(listItems), nil
 }
 
+// pathRoleSecretIDListDetails returns the properties of the SecretIDs issued
+// against the role, a page at a time. The SecretIDs are ordered by their
+// HMACs; 'after' continues the listing after the given accessor.
+func (b *backend) pathRoleSecretIDListDetails(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role_name"), nil
+	}
+
+	limit := data.Get("limit").(int)
+	if limit <= 0 {
+		return logical.ErrorResponse("limit must be greater than zero"), nil
+	}
+	if limit > maxSecretIDListDetailsLimit {
+		limit = maxSecretIDListDetailsLimit
+	}
+
+	lock := b.roleLock(roleName)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	// Get the role entry
+	role, err := b.roleEntry(ctx, req.Storage, strings.ToLower(roleName))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q does not exist", roleName)), nil
+	}
+
+	if role.LowerCaseRoleName {
+		roleName = strings.ToLower(roleName)
+	}
+
+	// Guard the list operation with an outer lock
+	b.secretIDListingLock.RLock()
+	defer b.secretIDListingLock.RUnlock()
+
+	roleNameHMAC, err := createHMAC(role.HMACKey, roleName)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to create HMAC of role_name: {{err}}", err)
+	}
+
+	secretIDHMACs, err := req.Storage.List(ctx, fmt.Sprintf("secret_id/%s/", roleNameHMAC))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(secretIDHMACs)
+
+	if after := data.Get("after").(string); after != "" {
+		accessorEntry, err := b.secretIDAccessorEntry(ctx, req.Storage, after)
+		if err != nil {
+			return nil, err
+		}
+		if accessorEntry == nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to find accessor entry for secret_id_accessor: %q", after)), nil
+		}
+
+		// Skip the SecretIDs up to and including the given one
+		i := sort.SearchStrings(secretIDHMACs, accessorEntry.SecretIDHMAC)
+		if i < len(secretIDHMACs) && secretIDHMACs[i] == accessorEntry.SecretIDHMAC {
+			i++
+		}
+		secretIDHMACs = secretIDHMACs[i:]
+	}
+
+	more := len(secretIDHMACs) > limit
+	if more {
+		secretIDHMACs = secretIDHMACs[:limit]
+	}
+
+	var accessors []string
+	keyInfo := make(map[string]interface{}, len(secretIDHMACs))
+	for _, secretIDHMAC := range secretIDHMACs {
+		// For sanity
+		if secretIDHMAC == "" {
+			continue
+		}
+
+		secretIDLock := b.secretIDLock(secretIDHMAC)
+		secretIDLock.RLock()
+		result, err := b.nonLockedSecretIDStorageEntry(ctx, req.Storage, roleNameHMAC, secretIDHMAC)
+		secretIDLock.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			// Removed since it was listed
+			continue
+		}
+
+		d := secretIDResponseData(*result)
+
+		// Logins using a SecretID whose CIDR blocks are no longer a subset
+		// of the role's are rejected
+		d["cidr_list_valid"] = verifyCIDRRoleSecretIDSubset(result.CIDRList, role.BoundCIDRList) == nil
+
+		accessors = append(accessors, result.SecretIDAccessor)
+		keyInfo[result.SecretIDAccessor] = d
+	}
+
+	resp := logical.ListResponseWithInfo(accessors, keyInfo)
+	resp.Data["secret_id_bound_cidrs"] = role.BoundCIDRList
+	if more && len(accessors) > 0 {
+		resp.Data["next_after"] = accessors[len(accessors)-1]
+	}
+
+	return resp, nil
+}
+
 // validateRoleConstraints checks if the role has at least one constraint
 // enabled.
 func validateRoleConstraints(role *roleStorageEntry) error {
@@ -815,6 +964,12 @@ func (b *backend) pathRoleCreateUpdate(ctx context.Context, req *logical.Request
 		}
 	}
 
+	if bindTokenRaw, ok := data.GetOk("bind_token_to_login_address"); ok {
+		role.BindTokenToLoginAddress = bindTokenRaw.(bool)
+	} else if req.Operation == logical.CreateOperation {
+		role.BindTokenToLoginAddress = data.Get("bind_token_to_login_address").(bool)
+	}
+
 	if policiesRaw, ok := data.GetOk("policies"); ok {
 		role.Policies = policyutil.ParsePolicies(policiesRaw)
 	} else if req.Operation == logical.CreateOperation {
@@ -907,15 +1062,16 @@ func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *
 	}
 
 	respData := map[string]interface{}{
-		"bind_secret_id":     role.BindSecretID,
-		"bound_cidr_list":    role.BoundCIDRList,
-		"period":             role.Period / time.Second,
-		"policies":           role.Policies,
-		"secret_id_num_uses": role.SecretIDNumUses,
-		"secret_id_ttl":      role.SecretIDTTL / time.Second,
-		"token_max_ttl":      role.TokenMaxTTL / time.Second,
-		"token_num_uses":     role.TokenNumUses,
-		"token_ttl":          role.TokenTTL / time.Second,
+		"bind_secret_id":              role.BindSecretID,
+		"bind_token_to_login_address": role.BindTokenToLoginAddress,
+		"bound_cidr_list":             role.BoundCIDRList,
+		"period":                      role.Period / time.Second,
+		"policies":                    role.Policies,
+		"secret_id_num_uses":          role.SecretIDNumUses,
+		"secret_id_ttl":               role.SecretIDTTL / time.Second,
+		"token_max_ttl":               role.TokenMaxTTL / time.Second,
+		"token_num_uses":              role.TokenNumUses,
+		"token_ttl":                   role.TokenTTL / time.Second,
 	}
 
 	resp := &logical.Response{
@@ -1063,6 +1219,22 @@ func (b *backend) secretIDCommon(ctx context.Context, s logical.Storage, entryIn
 		return nil, err
 	}
 
+	d := secretIDResponseData(result)
+
+	resp := &logical.Response{
+		Data: d,
+	}
+
+	if _, ok := d["SecretIDNumUses"]; ok {
+		resp.AddWarning("The field SecretIDNumUses is deprecated and will be removed in a future release; refer to secret_id_num_uses instead")
+	}
+
+	return resp, nil
+}
+
+// secretIDResponseData returns the properties of a SecretID as they are
+// reported by the API
+func secretIDResponseData(result secretIDStorageEntry) map[string]interface{} {
 	result.SecretIDTTL /= time.Second
 	d := structs.New(result).Map()
 
@@ -1075,15 +1247,7 @@ func (b *backend) secretIDCommon(ctx context.Context, s logical.Storage, entryIn
 	d["expiration_time"] = result.ExpirationTime.Format(time.RFC3339Nano)
 	d["last_updated_time"] = result.LastUpdatedTime.Format(time.RFC3339Nano)
 
-	resp := &logical.Response{
-		Data: d,
-	}
-
-	if _, ok := d["SecretIDNumUses"]; ok {
-		resp.AddWarning("The field SecretIDNumUses is deprecated and will be removed in a future release; refer to secret_id_num_uses instead")
-	}
-
-	return resp, nil
+	return d
 }
 
 func (b *backend) pathRoleSecretIDDestroyUpdateDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -2247,6 +2411,17 @@ just this role and none else. The properties of this SecretID will be
 based on the options set on the role. It will expire after a period
 defined by the 'secret_id_ttl' option on the role and/or the backend
 mount's maximum TTL value.`,
+	},
+	"role-secret-id-list-details": {
+		"Read the properties of the SecretIDs issued against this role.",
+		`Returns the metadata, remaining uses, expiration and CIDR restrictions
+of the SecretIDs issued against the role, keyed by their accessors. At most
+'limit' SecretIDs are returned; when more remain, 'next_after' is set and can
+be passed as 'after' to read the next page.
+
+'secret_id_bound_cidrs' holds the CIDR blocks the role enforces on all of its
+SecretIDs. A SecretID whose own CIDR blocks are no longer a subset of them has
+'cidr_list_valid' set to false; logins using it are rejected.`,
 	},
 	"role-custom-secret-id": {
 		"Assign a SecretID of choice against the role.",
@@ -835,6 +835,116 @@ func TestAppRoleRoleListSecretID(t *testing.T) {
 	}
 }
 
+func TestAppRole_RoleSecretIDListDetails(t *testing.T) {
+	var resp *logical.Response
+	var err error
+	b, storage := createBackendWithStorage(t)
+
+	roleReq := &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "role/role1",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"policies":           "a,b",
+			"secret_id_num_uses": 10,
+			"bound_cidr_list":    "127.0.0.1/24",
+		},
+	}
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	secretIDReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Storage:   storage,
+		Path:      "role/role1/secret-id",
+		Data: map[string]interface{}{
+			"metadata":  `{"env":"test"}`,
+			"cidr_list": "127.0.0.1/32",
+		},
+	}
+	for i := 0; i < 5; i++ {
+		resp, err = b.HandleRequest(context.Background(), secretIDReq)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+	}
+
+	// Read the SecretIDs two at a time
+	listReq := &logical.Request{
+		Operation: logical.ReadOperation,
+		Storage:   storage,
+		Path:      "role/role1/secret-id/list-details",
+		Data: map[string]interface{}{
+			"limit": 2,
+		},
+	}
+	seen := make(map[string]bool)
+	for page := 0; ; page++ {
+		resp, err = b.HandleRequest(context.Background(), listReq)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+
+		keys := resp.Data["keys"].([]string)
+		keyInfo := resp.Data["key_info"].(map[string]interface{})
+		for _, accessor := range keys {
+			if seen[accessor] {
+				t.Fatalf("accessor %q listed twice", accessor)
+			}
+			seen[accessor] = true
+
+			info := keyInfo[accessor].(map[string]interface{})
+			if info["secret_id_num_uses"].(int) != 10 ||
+				info["metadata"].(map[string]string)["env"] != "test" ||
+				!reflect.DeepEqual(info["cidr_list"], []string{"127.0.0.1/32"}) ||
+				info["cidr_list_valid"] != true {
+				t.Fatalf("bad: %#v", info)
+			}
+		}
+
+		nextAfter, ok := resp.Data["next_after"]
+		if !ok {
+			if page != 2 || len(keys) != 1 {
+				t.Fatalf("bad: page %d, keys %#v", page, keys)
+			}
+			break
+		}
+		if len(keys) != 2 || nextAfter != keys[1] {
+			t.Fatalf("bad: keys %#v, next_after %q", keys, nextAfter)
+		}
+		listReq.Data["after"] = nextAfter
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 SecretIDs, got %d", len(seen))
+	}
+
+	// Narrowing the role's CIDR blocks invalidates the SecretIDs
+	roleReq.Operation = logical.UpdateOperation
+	roleReq.Data = map[string]interface{}{
+		"bound_cidr_list": "10.0.0.0/8",
+	}
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	listReq.Data = nil
+	resp, err = b.HandleRequest(context.Background(), listReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["secret_id_bound_cidrs"], []string{"10.0.0.0/8"}) {
+		t.Fatalf("bad: %#v", resp.Data["secret_id_bound_cidrs"])
+	}
+	for _, info := range resp.Data["key_info"].(map[string]interface{}) {
+		if info.(map[string]interface{})["cidr_list_valid"] != false {
+			t.Fatalf("bad: %#v", info)
+		}
+	}
+}
+
 func TestAppRole_RoleList(t *testing.T) {
 	var resp *logical.Response
 	var err error
@@ -2,11 +2,15 @@ package cert
 
 import (
 	"context"
+	"net/http"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
 )
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -40,6 +44,10 @@ func Backend() *backend {
 
 	b.crlUpdateMutex = &sync.RWMutex{}
 
+	b.revocationCache = cache.New(defaultRevocationCacheTTL, 10*time.Minute)
+	b.revocationClient = cleanhttp.DefaultClient()
+	b.revocationClient.Timeout = 10 * time.Second
+
 	return &b
 }
 
@@ -49,6 +57,11 @@ type backend struct {
 
 	crls           map[string]CRLInfo
 	crlUpdateMutex *sync.RWMutex
+
+	// revocationCache holds OCSP responses and CRLs fetched from distribution
+	// points during login
+	revocationCache  *cache.Cache
+	revocationClient *http.Client
 }
 
 func (b *backend) invalidate(_ context.Context, key string) {
@@ -2,8 +2,15 @@ package cert
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 
 	"golang.org/x/net/http2"
 
@@ -34,6 +41,7 @@ import (
 	logicaltest "github.com/hashicorp/vault/logical/testing"
 	"github.com/hashicorp/vault/vault"
 	"github.com/mitchellh/mapstructure"
+	"golang.org/x/crypto/ocsp"
 )
 
 const (
@@ -1500,3 +1508,430 @@ func Test_Renew(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+// revocationTestPKI is a CA and client certificate whose OCSP responder and
+// CRL distribution point are served by test servers
+type revocationTestPKI struct {
+	caPEM      []byte
+	caCert     *x509.Certificate
+	clientCert *x509.Certificate
+
+	ocspStatus int
+	ocspFail   bool
+	crlRevoked bool
+	crlFail    bool
+
+	servers []*httptest.Server
+}
+
+func (p *revocationTestPKI) Close() {
+	for _, server := range p.servers {
+		server.Close()
+	}
+}
+
+func newRevocationTestPKI(t *testing.T) *revocationTestPKI {
+	p := &revocationTestPKI{
+		ocspStatus: ocsp.Good,
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "revocation test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	p.caCert = caCert
+
+	ocspServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.ocspFail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp, err := ocsp.CreateResponse(caCert, caCert, ocsp.Response{
+			Status:       p.ocspStatus,
+			SerialNumber: p.clientCert.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(resp)
+	}))
+	p.servers = append(p.servers, ocspServer)
+
+	crlServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.crlFail {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var revoked []pkix.RevokedCertificate
+		if p.crlRevoked {
+			revoked = append(revoked, pkix.RevokedCertificate{
+				SerialNumber:   p.clientCert.SerialNumber,
+				RevocationTime: time.Now().Add(-time.Minute),
+			})
+		}
+		crl, err := caCert.CreateCRL(rand.Reader, caKey, revoked, time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(crl)
+	}))
+	p.servers = append(p.servers, crlServer)
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		OCSPServer:            []string{ocspServer.URL},
+		CRLDistributionPoints: []string{crlServer.URL},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, caCert, clientKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.clientCert, err = x509.ParseCertificate(clientDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func TestBackend_RevocationChecks(t *testing.T) {
+	type testCase struct {
+		ocsp        bool
+		crlDP       bool
+		failOpen    bool
+		ocspStatus  int
+		ocspFail    bool
+		crlRevoked  bool
+		crlFail     bool
+		expectError bool
+	}
+
+	tests := map[string]testCase{
+		"no checks":                {ocspStatus: ocsp.Revoked, crlRevoked: true},
+		"ocsp good":                {ocsp: true, ocspStatus: ocsp.Good},
+		"ocsp revoked":             {ocsp: true, ocspStatus: ocsp.Revoked, expectError: true},
+		"ocsp unknown":             {ocsp: true, ocspStatus: ocsp.Unknown, expectError: true},
+		"ocsp unknown fail open":   {ocsp: true, ocspStatus: ocsp.Unknown, failOpen: true},
+		"crl dp good":              {crlDP: true},
+		"crl dp revoked":           {crlDP: true, crlRevoked: true, expectError: true},
+		"ocsp down, crl revoked":   {ocsp: true, crlDP: true, ocspFail: true, crlRevoked: true, expectError: true},
+		"ocsp down, crl good":      {ocsp: true, crlDP: true, ocspFail: true},
+		"ocsp good over crl":       {ocsp: true, crlDP: true, crlRevoked: true},
+		"all down fail closed":     {ocsp: true, crlDP: true, ocspFail: true, crlFail: true, expectError: true},
+		"all down fail open":       {ocsp: true, crlDP: true, ocspFail: true, crlFail: true, failOpen: true},
+		"revoked despite failopen": {ocsp: true, ocspStatus: ocsp.Revoked, failOpen: true, expectError: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := newRevocationTestPKI(t)
+			defer p.Close()
+			p.ocspStatus = tc.ocspStatus
+			p.ocspFail = tc.ocspFail
+			p.crlRevoked = tc.crlRevoked
+			p.crlFail = tc.crlFail
+
+			config := logical.TestBackendConfig()
+			storage := &logical.InmemStorage{}
+			config.StorageView = storage
+
+			b, err := Factory(context.Background(), config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := b.HandleRequest(context.Background(), &logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "certs/ca",
+				Storage:   storage,
+				Data: map[string]interface{}{
+					"certificate":                     string(p.caPEM),
+					"policies":                        "foo",
+					"ocsp_enabled":                    tc.ocsp,
+					"crl_distribution_points_enabled": tc.crlDP,
+					"revocation_fail_open":            tc.failOpen,
+				},
+			})
+			if err != nil || (resp != nil && resp.IsError()) {
+				t.Fatalf("err:%v resp:%#v", err, resp)
+			}
+
+			resp, err = b.HandleRequest(context.Background(), &logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "login",
+				Storage:   storage,
+				Connection: &logical.Connection{
+					ConnState: &tls.ConnectionState{
+						PeerCertificates: []*x509.Certificate{p.clientCert},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectError {
+				if resp == nil || !resp.IsError() {
+					t.Fatalf("expected login to fail, got %#v", resp)
+				}
+				return
+			}
+			if resp == nil || resp.IsError() || resp.Auth == nil {
+				t.Fatalf("expected login to succeed, got %#v", resp)
+			}
+		})
+	}
+}
+
+func TestBackend_CRLCacheIssuer(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	defer p.Close()
+	other := newRevocationTestPKI(t)
+	defer other.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := p.clientCert.CRLDistributionPoints[0]
+	if _, err := b.(*backend).fetchCRL(context.Background(), url, p.caCert); err != nil {
+		t.Fatal(err)
+	}
+
+	// The CRL cached for one CA must not be used for another CA with the
+	// same distribution point
+	if _, err := b.(*backend).fetchCRL(context.Background(), url, other.caCert); err == nil {
+		t.Fatal("expected CRL of another issuer to be rejected")
+	}
+}
+
+// testCAAndClientCert creates a CA and a client certificate issued by it from
+// the given template
+func testCAAndClientCert(t *testing.T, clientTemplate *x509.Certificate) ([]byte, *x509.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTemplate.NotBefore = time.Now().Add(-time.Hour)
+	clientTemplate.NotAfter = time.Now().Add(time.Hour)
+	clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, caCert, clientKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := x509.ParseCertificate(clientDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), clientCert
+}
+
+func TestBackend_NameConstraints(t *testing.T) {
+	customValue, err := asn1.Marshal("custom value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	intValue, err := asn1.Marshal(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spiffeURI, err := url.Parse("spiffe://example.com/service/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM, clientCert := testCAAndClientCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName:         "web.example.com",
+			OrganizationalUnit: []string{"engineering", "web"},
+		},
+		DNSNames:       []string{"web.example.com", "www.example.com"},
+		EmailAddresses: []string{"web@example.com"},
+		URIs:           []*url.URL{spiffeURI},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 45}, Value: customValue},
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 46}, Value: intValue},
+		},
+	})
+
+	type testCase struct {
+		data             map[string]interface{}
+		expectError      bool
+		expectedMetadata map[string]string
+	}
+
+	tests := map[string]testCase{
+		"no constraints": {
+			data: map[string]interface{}{},
+		},
+		"common name": {
+			data: map[string]interface{}{"allowed_common_names": "*.example.com"},
+		},
+		"common name mismatch": {
+			data:        map[string]interface{}{"allowed_common_names": "www.example.com"},
+			expectError: true,
+		},
+		"dns san": {
+			data:             map[string]interface{}{"allowed_dns_sans": "foo.example.com,www.*"},
+			expectedMetadata: map[string]string{"dns_san": "www.example.com"},
+		},
+		"dns san mismatch": {
+			data:        map[string]interface{}{"allowed_dns_sans": "*.example.org"},
+			expectError: true,
+		},
+		"email san": {
+			data:             map[string]interface{}{"allowed_email_sans": "*@example.com"},
+			expectedMetadata: map[string]string{"email_san": "web@example.com"},
+		},
+		"email san mismatch": {
+			data:        map[string]interface{}{"allowed_email_sans": "admin@example.com"},
+			expectError: true,
+		},
+		"uri san": {
+			data:             map[string]interface{}{"allowed_uri_sans": "spiffe://example.com/service/*"},
+			expectedMetadata: map[string]string{"uri_san": "spiffe://example.com/service/web"},
+		},
+		"uri san mismatch": {
+			data:        map[string]interface{}{"allowed_uri_sans": "spiffe://example.com/db/*"},
+			expectError: true,
+		},
+		"organizational unit": {
+			data:             map[string]interface{}{"allowed_organizational_units": "web"},
+			expectedMetadata: map[string]string{"organizational_unit": "web"},
+		},
+		"organizational unit mismatch": {
+			data:        map[string]interface{}{"allowed_organizational_units": "sales"},
+			expectError: true,
+		},
+		"combined, one failing": {
+			data: map[string]interface{}{
+				"allowed_dns_sans":             "web.example.com",
+				"allowed_organizational_units": "sales",
+			},
+			expectError: true,
+		},
+		"hex extension": {
+			data: map[string]interface{}{"required_extensions": "hex:1.2.3.46:" + hex.EncodeToString(intValue)},
+		},
+		"hex extension mismatch": {
+			data:        map[string]interface{}{"required_extensions": "hex:1.2.3.46:020101"},
+			expectError: true,
+		},
+		"metadata extensions": {
+			data:             map[string]interface{}{"allowed_metadata_extensions": "1.2.3.45,1.2.3.99"},
+			expectedMetadata: map[string]string{"1-2-3-45": "custom value"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := logical.TestBackendConfig()
+			storage := &logical.InmemStorage{}
+			config.StorageView = storage
+
+			b, err := Factory(context.Background(), config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.data["certificate"] = string(caPEM)
+			resp, err := b.HandleRequest(context.Background(), &logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "certs/ca",
+				Storage:   storage,
+				Data:      tc.data,
+			})
+			if err != nil || (resp != nil && resp.IsError()) {
+				t.Fatalf("err:%v resp:%#v", err, resp)
+			}
+
+			resp, err = b.HandleRequest(context.Background(), &logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "login",
+				Storage:   storage,
+				Connection: &logical.Connection{
+					ConnState: &tls.ConnectionState{
+						PeerCertificates: []*x509.Certificate{clientCert},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.expectError {
+				if resp == nil || !resp.IsError() {
+					t.Fatalf("expected login to fail, got %#v", resp)
+				}
+				return
+			}
+			if resp == nil || resp.IsError() || resp.Auth == nil {
+				t.Fatalf("expected login to succeed, got %#v", resp)
+			}
+
+			if !reflect.DeepEqual(resp.Auth.Alias.Metadata, tc.expectedMetadata) {
+				t.Fatalf("bad alias metadata: expected %#v, got %#v", tc.expectedMetadata, resp.Auth.Alias.Metadata)
+			}
+			for k, v := range tc.expectedMetadata {
+				if resp.Auth.Metadata[k] != v {
+					t.Fatalf("bad token metadata for %q: expected %q, got %q", k, v, resp.Auth.Metadata[k])
+				}
+			}
+		})
+	}
+}
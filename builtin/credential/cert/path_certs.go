@@ -46,13 +46,73 @@ Must be x509 PEM encoded.`,
 At least one must exist in either the Common Name or SANs. Supports globbing.`,
 			},
 
+			"allowed_common_names": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `A comma-separated list of names.
+At least one must match the Common Name. Supports globbing.`,
+			},
+
+			"allowed_dns_sans": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `A comma-separated list of DNS names.
+At least one must exist in the SANs. Supports globbing.`,
+			},
+
+			"allowed_email_sans": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `A comma-separated list of email addresses.
+At least one must exist in the SANs. Supports globbing.`,
+			},
+
+			"allowed_uri_sans": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `A comma-separated list of URIs.
+At least one must exist in the SANs. Supports globbing.`,
+			},
+
+			"allowed_organizational_units": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `A comma-separated list of Organizational Units names.
+At least one must exist in the OU field.`,
+			},
+
 			"required_extensions": &framework.FieldSchema{
 				Type: framework.TypeCommaStringSlice,
 				Description: `A comma-separated string or array of extensions
 formatted as "oid:value". Expects the extension value to be some type of ASN1 encoded string.
+To match on the hex-encoded DER value of any extension, use "hex:oid:value".
 All values much match. Supports globbing on "value".`,
 			},
 
+			"allowed_metadata_extensions": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `A comma-separated string or array of OIDs of
+extensions whose ASN1 encoded string values are added to the token and alias
+metadata, under the OID with dots replaced by dashes.`,
+			},
+
+			"ocsp_enabled": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Whether to check the revocation status of the client
+certificate and its chain with the OCSP responders listed in each
+certificate's Authority Information Access extension.`,
+			},
+
+			"crl_distribution_points_enabled": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Whether to check the client certificate and its
+chain against the CRLs published at each certificate's CRL distribution
+points. Used when OCSP is disabled or gives no answer.`,
+			},
+
+			"revocation_fail_open": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, logins are allowed when the revocation status
+of a certificate cannot be determined because no OCSP responder or CRL
+distribution point could be reached. Defaults to false, which denies such
+logins.`,
+			},
+
 			"display_name": &framework.FieldSchema{
 				Type: framework.TypeString,
 				Description: `The display name to use for clients using this
@@ -144,13 +204,22 @@ func (b *backend) pathCertRead(ctx context.Context, req *logical.Request, d *fra
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"certificate":   cert.Certificate,
-			"display_name":  cert.DisplayName,
-			"policies":      cert.Policies,
-			"ttl":           cert.TTL / time.Second,
-			"max_ttl":       cert.MaxTTL / time.Second,
-			"period":        cert.Period / time.Second,
-			"allowed_names": cert.AllowedNames,
+			"certificate":                     cert.Certificate,
+			"display_name":                    cert.DisplayName,
+			"policies":                        cert.Policies,
+			"ttl":                             cert.TTL / time.Second,
+			"max_ttl":                         cert.MaxTTL / time.Second,
+			"period":                          cert.Period / time.Second,
+			"allowed_names":                   cert.AllowedNames,
+			"allowed_common_names":            cert.AllowedCommonNames,
+			"allowed_dns_sans":                cert.AllowedDNSSANs,
+			"allowed_email_sans":              cert.AllowedEmailSANs,
+			"allowed_uri_sans":                cert.AllowedURISANs,
+			"allowed_organizational_units":    cert.AllowedOrganizationalUnits,
+			"allowed_metadata_extensions":     cert.AllowedMetadataExtensions,
+			"ocsp_enabled":                    cert.OCSPEnabled,
+			"crl_distribution_points_enabled": cert.CRLDistributionPointsEnabled,
+			"revocation_fail_open":            cert.RevocationFailOpen,
 		},
 	}, nil
 }
@@ -161,7 +230,16 @@ func (b *backend) pathCertWrite(ctx context.Context, req *logical.Request, d *fr
 	displayName := d.Get("display_name").(string)
 	policies := policyutil.ParsePolicies(d.Get("policies"))
 	allowedNames := d.Get("allowed_names").([]string)
+	allowedCommonNames := d.Get("allowed_common_names").([]string)
+	allowedDNSSANs := d.Get("allowed_dns_sans").([]string)
+	allowedEmailSANs := d.Get("allowed_email_sans").([]string)
+	allowedURISANs := d.Get("allowed_uri_sans").([]string)
+	allowedOrganizationalUnits := d.Get("allowed_organizational_units").([]string)
 	requiredExtensions := d.Get("required_extensions").([]string)
+	allowedMetadataExtensions := d.Get("allowed_metadata_extensions").([]string)
+	ocspEnabled := d.Get("ocsp_enabled").(bool)
+	crlDistributionPointsEnabled := d.Get("crl_distribution_points_enabled").(bool)
+	revocationFailOpen := d.Get("revocation_fail_open").(bool)
 
 	var resp logical.Response
 
@@ -238,6 +316,17 @@ func (b *backend) pathCertWrite(ctx context.Context, req *logical.Request, d *fr
 		TTL:                ttl,
 		MaxTTL:             maxTTL,
 		Period:             period,
+
+		AllowedCommonNames:         allowedCommonNames,
+		AllowedDNSSANs:             allowedDNSSANs,
+		AllowedEmailSANs:           allowedEmailSANs,
+		AllowedURISANs:             allowedURISANs,
+		AllowedOrganizationalUnits: allowedOrganizationalUnits,
+		AllowedMetadataExtensions:  allowedMetadataExtensions,
+
+		OCSPEnabled:                  ocspEnabled,
+		CRLDistributionPointsEnabled: crlDistributionPointsEnabled,
+		RevocationFailOpen:           revocationFailOpen,
 	}
 
 	// Store it
@@ -266,6 +355,17 @@ type CertEntry struct {
 	Period             time.Duration
 	AllowedNames       []string
 	RequiredExtensions []string
+
+	AllowedCommonNames         []string
+	AllowedDNSSANs             []string
+	AllowedEmailSANs           []string
+	AllowedURISANs             []string
+	AllowedOrganizationalUnits []string
+	AllowedMetadataExtensions  []string
+
+	OCSPEnabled                  bool
+	CRLDistributionPointsEnabled bool
+	RevocationFailOpen           bool
 }
 
 const pathCertHelpSyn = `
@@ -7,6 +7,7 @@ import (
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -78,6 +79,23 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *fra
 	skid := base64.StdEncoding.EncodeToString(clientCerts[0].SubjectKeyId)
 	akid := base64.StdEncoding.EncodeToString(clientCerts[0].AuthorityKeyId)
 
+	metadata := map[string]string{
+		"cert_name":        matched.Entry.Name,
+		"common_name":      clientCerts[0].Subject.CommonName,
+		"subject_key_id":   certutil.GetHexFormatted(clientCerts[0].SubjectKeyId, ":"),
+		"authority_key_id": certutil.GetHexFormatted(clientCerts[0].AuthorityKeyId, ":"),
+	}
+
+	// Values that satisfied the role's constraints, and extensions the role
+	// copies, are also recorded on the alias
+	aliasMetadata := certificateMetadata(clientCerts[0], matched.Entry)
+	for k, v := range aliasMetadata {
+		metadata[k] = v
+	}
+	if len(aliasMetadata) == 0 {
+		aliasMetadata = nil
+	}
+
 	resp := &logical.Response{
 		Auth: &logical.Auth{
 			Period: matched.Entry.Period,
@@ -87,19 +105,15 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *fra
 			},
 			Policies:    matched.Entry.Policies,
 			DisplayName: matched.Entry.DisplayName,
-			Metadata: map[string]string{
-				"cert_name":        matched.Entry.Name,
-				"common_name":      clientCerts[0].Subject.CommonName,
-				"subject_key_id":   certutil.GetHexFormatted(clientCerts[0].SubjectKeyId, ":"),
-				"authority_key_id": certutil.GetHexFormatted(clientCerts[0].AuthorityKeyId, ":"),
-			},
+			Metadata:    metadata,
 			LeaseOptions: logical.LeaseOptions{
 				Renewable: true,
 				TTL:       matched.Entry.TTL,
 				MaxTTL:    matched.Entry.MaxTTL,
 			},
 			Alias: &logical.Alias{
-				Name: clientCerts[0].SerialNumber.String(),
+				Name:     clientCerts[0].SerialNumber.String(),
+				Metadata: aliasMetadata,
 			},
 		},
 	}
@@ -215,12 +229,18 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, d
 
 	// Search for a ParsedCert that intersects with the validated chains and any additional constraints
 	matches := make([]*ParsedCert, 0)
+	var revocationErr error
 	for _, trust := range trusted { // For each ParsedCert in the config
 		for _, tCert := range trust.Certificates { // For each certificate in the entry
 			for _, chain := range trustedChains { // For each root chain that we matched
 				for _, cCert := range chain { // For each cert in the matched chain
 					if tCert.Equal(cCert) && // ParsedCert intersects with matched chain
 						b.matchesConstraints(clientCert, chain, trust) { // validate client cert + matched chain against the config
+						// Check the chain against the revocation sources enabled on the entry
+						if err := b.checkChainRevocation(ctx, chain, trust.Entry); err != nil {
+							revocationErr = err
+							continue
+						}
 						// Add the match to the list
 						matches = append(matches, trust)
 					}
@@ -231,6 +251,9 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, d
 
 	// Fail on no matches
 	if len(matches) == 0 {
+		if revocationErr != nil {
+			return nil, logical.ErrorResponse(fmt.Sprintf("no chain matching all constraints could be found for this login certificate: %v", revocationErr)), nil
+		}
 		return nil, logical.ErrorResponse("no chain matching all constraints could be found for this login certificate"), nil
 	}
 
@@ -241,6 +264,11 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, d
 func (b *backend) matchesConstraints(clientCert *x509.Certificate, trustedChain []*x509.Certificate, config *ParsedCert) bool {
 	return !b.checkForChainInCRLs(trustedChain) &&
 		b.matchesNames(clientCert, config) &&
+		b.matchesCommonName(clientCert, config) &&
+		b.matchesDNSSANs(clientCert, config) &&
+		b.matchesEmailSANs(clientCert, config) &&
+		b.matchesURISANs(clientCert, config) &&
+		b.matchesOrganizationalUnits(clientCert, config) &&
 		b.matchesCertificateExtensions(clientCert, config)
 }
 
@@ -272,6 +300,112 @@ func (b *backend) matchesNames(clientCert *x509.Certificate, config *ParsedCert)
 	return false
 }
 
+// matchesCommonName verifies that the certificate's Common Name matches at
+// least one configured allowed common name
+func (b *backend) matchesCommonName(clientCert *x509.Certificate, config *ParsedCert) bool {
+	_, ok := matchGlobs(config.Entry.AllowedCommonNames, []string{clientCert.Subject.CommonName})
+	return ok
+}
+
+// matchesDNSSANs verifies that at least one of the certificate's DNS SANs
+// matches at least one configured allowed DNS SAN
+func (b *backend) matchesDNSSANs(clientCert *x509.Certificate, config *ParsedCert) bool {
+	_, ok := matchGlobs(config.Entry.AllowedDNSSANs, clientCert.DNSNames)
+	return ok
+}
+
+// matchesEmailSANs verifies that at least one of the certificate's email SANs
+// matches at least one configured allowed email SAN
+func (b *backend) matchesEmailSANs(clientCert *x509.Certificate, config *ParsedCert) bool {
+	_, ok := matchGlobs(config.Entry.AllowedEmailSANs, clientCert.EmailAddresses)
+	return ok
+}
+
+// matchesURISANs verifies that at least one of the certificate's URI SANs
+// matches at least one configured allowed URI SAN
+func (b *backend) matchesURISANs(clientCert *x509.Certificate, config *ParsedCert) bool {
+	_, ok := matchGlobs(config.Entry.AllowedURISANs, uriSANs(clientCert))
+	return ok
+}
+
+// matchesOrganizationalUnits verifies that at least one of the certificate's
+// Organizational Units matches at least one configured allowed OU
+func (b *backend) matchesOrganizationalUnits(clientCert *x509.Certificate, config *ParsedCert) bool {
+	_, ok := matchGlobs(config.Entry.AllowedOrganizationalUnits, clientCert.Subject.OrganizationalUnit)
+	return ok
+}
+
+// matchGlobs returns the first value matching any of the patterns. No
+// patterns means no constraint, which always matches.
+func matchGlobs(patterns, values []string) (string, bool) {
+	if len(patterns) == 0 {
+		return "", true
+	}
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if glob.Glob(pattern, value) {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+func uriSANs(clientCert *x509.Certificate) []string {
+	uris := make([]string, 0, len(clientCert.URIs))
+	for _, uri := range clientCert.URIs {
+		uris = append(uris, uri.String())
+	}
+	return uris
+}
+
+// certificateMetadata returns the certificate values that satisfied the
+// configured name constraints, along with the values of the extensions the
+// role copies into metadata
+func certificateMetadata(clientCert *x509.Certificate, entry *CertEntry) map[string]string {
+	metadata := map[string]string{}
+
+	constraints := []struct {
+		key      string
+		patterns []string
+		values   []string
+	}{
+		{"dns_san", entry.AllowedDNSSANs, clientCert.DNSNames},
+		{"email_san", entry.AllowedEmailSANs, clientCert.EmailAddresses},
+		{"uri_san", entry.AllowedURISANs, uriSANs(clientCert)},
+		{"organizational_unit", entry.AllowedOrganizationalUnits, clientCert.Subject.OrganizationalUnit},
+	}
+	for _, c := range constraints {
+		if len(c.patterns) == 0 {
+			continue
+		}
+		if value, ok := matchGlobs(c.patterns, c.values); ok {
+			metadata[c.key] = value
+		}
+	}
+
+	if len(entry.AllowedMetadataExtensions) > 0 {
+		allowed := make(map[string]bool, len(entry.AllowedMetadataExtensions))
+		for _, oid := range entry.AllowedMetadataExtensions {
+			allowed[oid] = true
+		}
+		for _, ext := range clientCert.Extensions {
+			oid := ext.Id.String()
+			if !allowed[oid] {
+				continue
+			}
+			var value string
+			if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+				continue
+			}
+			// Metadata keys can't contain dots
+			metadata[strings.Replace(oid, ".", "-", -1)] = value
+		}
+	}
+
+	return metadata
+}
+
 // matchesCertificateExtensions verifies that the certificate matches configured
 // required extensions
 func (b *backend) matchesCertificateExtensions(clientCert *x509.Certificate, config *ParsedCert) bool {
@@ -289,15 +423,28 @@ func (b *backend) matchesCertificateExtensions(clientCert *x509.Certificate, con
 	// including its ASN.1 type tag bytes. For the sake of simplicity, assume string type
 	// and drop the tag bytes. And get the number of bytes from the tag.
 	clientExtMap := make(map[string]string, len(clientCert.Extensions))
+	hexExtMap := make(map[string]string, len(clientCert.Extensions))
 	for _, ext := range clientCert.Extensions {
 		var parsedValue string
 		asn1.Unmarshal(ext.Value, &parsedValue)
 		clientExtMap[ext.Id.String()] = parsedValue
+		hexExtMap[ext.Id.String()] = hex.EncodeToString(ext.Value)
 	}
 	// If any of the required extensions don't match the constraint fails
 	for _, requiredExt := range config.Entry.RequiredExtensions {
+		// Extensions prefixed with "hex:" are matched against the hex
+		// encoding of the raw extension value, which allows matching
+		// extensions that aren't strings
+		extMap := clientExtMap
+		if strings.HasPrefix(requiredExt, "hex:") {
+			extMap = hexExtMap
+			requiredExt = strings.ToLower(strings.TrimPrefix(requiredExt, "hex:"))
+		}
 		reqExt := strings.SplitN(requiredExt, ":", 2)
-		clientExtValue, clientExtValueOk := clientExtMap[reqExt[0]]
+		if len(reqExt) != 2 {
+			return false
+		}
+		clientExtValue, clientExtValueOk := extMap[reqExt[0]]
 		if !clientExtValueOk || !glob.Glob(reqExt[1], clientExtValue) {
 			return false
 		}
@@ -0,0 +1,241 @@
+package cert
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// defaultRevocationCacheTTL is how long revocation information is kept
+	// when the OCSP response or CRL does not say when it will next be updated
+	defaultRevocationCacheTTL = 1 * time.Hour
+
+	// maxRevocationResponseSize bounds the size of OCSP responses and CRLs
+	// fetched during login
+	maxRevocationResponseSize = 32 * 1024 * 1024
+)
+
+// errRevocationUnknown is returned when none of the revocation sources of a
+// certificate gave an answer
+var errRevocationUnknown = errors.New("revocation status could not be determined")
+
+// fetchedCRL holds the revoked serials of a CRL fetched from a distribution
+// point
+type fetchedCRL struct {
+	serials map[string]struct{}
+}
+
+// checkChainRevocation checks the certificates of a verified chain against
+// the OCSP responders and CRL distribution points they advertise, as enabled
+// on the role. The last certificate of the chain is the trust anchor and is
+// not checked.
+func (b *backend) checkChainRevocation(ctx context.Context, chain []*x509.Certificate, entry *CertEntry) error {
+	if !entry.OCSPEnabled && !entry.CRLDistributionPointsEnabled {
+		return nil
+	}
+
+	for i := 0; i+1 < len(chain); i++ {
+		revoked, err := b.checkRevocation(ctx, chain[i], chain[i+1], entry)
+		if err != nil {
+			if entry.RevocationFailOpen {
+				b.Logger().Warn("allowing certificate with unknown revocation status", "serial", chain[i].SerialNumber.String(), "error", err)
+				continue
+			}
+			return err
+		}
+		if revoked {
+			return fmt.Errorf("certificate with serial %s has been revoked", chain[i].SerialNumber.String())
+		}
+	}
+
+	return nil
+}
+
+// checkRevocation returns whether cert has been revoked by issuer. OCSP is
+// tried first; CRL distribution points are only consulted if no OCSP
+// responder gave a definitive answer.
+func (b *backend) checkRevocation(ctx context.Context, cert, issuer *x509.Certificate, entry *CertEntry) (bool, error) {
+	var sources int
+	var lastErr error
+
+	if entry.OCSPEnabled && len(cert.OCSPServer) > 0 {
+		sources++
+		revoked, err := b.checkOCSP(ctx, cert, issuer)
+		if err == nil {
+			return revoked, nil
+		}
+		lastErr = err
+	}
+
+	if entry.CRLDistributionPointsEnabled && len(cert.CRLDistributionPoints) > 0 {
+		sources++
+		revoked, err := b.checkCRLDistributionPoints(ctx, cert, issuer)
+		if err == nil {
+			return revoked, nil
+		}
+		lastErr = err
+	}
+
+	// A certificate that advertises no revocation information cannot be
+	// checked
+	if sources == 0 {
+		return false, nil
+	}
+
+	return false, errwrap.Wrapf(errRevocationUnknown.Error()+": {{err}}", lastErr)
+}
+
+func (b *backend) checkOCSP(ctx context.Context, cert, issuer *x509.Certificate) (bool, error) {
+	cacheKey := fmt.Sprintf("ocsp/%x/%s", issuer.RawSubjectPublicKeyInfo, cert.SerialNumber.String())
+	if status, ok := b.revocationCache.Get(cacheKey); ok {
+		return status.(int) == ocsp.Revoked, nil
+	}
+
+	ocspReq, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return false, errwrap.Wrapf("error creating OCSP request: {{err}}", err)
+	}
+
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		body, err := b.fetchRevocationData(ctx, http.MethodPost, server, "application/ocsp-request", ocspReq)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+		if err != nil {
+			lastErr = errwrap.Wrapf(fmt.Sprintf("invalid response from OCSP responder %q: {{err}}", server), err)
+			continue
+		}
+
+		if !resp.NextUpdate.IsZero() && resp.NextUpdate.Before(time.Now()) {
+			lastErr = fmt.Errorf("stale response from OCSP responder %q", server)
+			continue
+		}
+
+		switch resp.Status {
+		case ocsp.Good, ocsp.Revoked:
+			b.revocationCache.Set(cacheKey, resp.Status, revocationCacheTTL(resp.NextUpdate))
+			return resp.Status == ocsp.Revoked, nil
+		default:
+			lastErr = fmt.Errorf("OCSP responder %q does not know the certificate", server)
+		}
+	}
+
+	return false, lastErr
+}
+
+func (b *backend) checkCRLDistributionPoints(ctx context.Context, cert, issuer *x509.Certificate) (bool, error) {
+	var lastErr error
+	for _, url := range cert.CRLDistributionPoints {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			continue
+		}
+
+		crl, err := b.fetchCRL(ctx, url, issuer)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		_, revoked := crl.serials[cert.SerialNumber.String()]
+		return revoked, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no supported CRL distribution point")
+	}
+	return false, lastErr
+}
+
+func (b *backend) fetchCRL(ctx context.Context, url string, issuer *x509.Certificate) (*fetchedCRL, error) {
+	// The CRL was only verified against this issuer, so another CA using the
+	// same distribution point must not get the cached CRL
+	cacheKey := fmt.Sprintf("crl/%x/%s", issuer.RawSubjectPublicKeyInfo, url)
+	if crl, ok := b.revocationCache.Get(cacheKey); ok {
+		return crl.(*fetchedCRL), nil
+	}
+
+	body, err := b.fetchRevocationData(ctx, http.MethodGet, url, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	certList, err := x509.ParseCRL(body)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to parse CRL from %q: {{err}}", url), err)
+	}
+	if err := issuer.CheckCRLSignature(certList); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("CRL from %q is not signed by the certificate issuer: {{err}}", url), err)
+	}
+	if certList.HasExpired(time.Now()) {
+		return nil, fmt.Errorf("CRL from %q has expired", url)
+	}
+
+	crl := &fetchedCRL{
+		serials: make(map[string]struct{}, len(certList.TBSCertList.RevokedCertificates)),
+	}
+	for _, revokedCert := range certList.TBSCertList.RevokedCertificates {
+		crl.serials[revokedCert.SerialNumber.String()] = struct{}{}
+	}
+
+	b.revocationCache.Set(cacheKey, crl, revocationCacheTTL(certList.TBSCertList.NextUpdate))
+
+	return crl, nil
+}
+
+func (b *backend) fetchRevocationData(ctx context.Context, method, url, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := b.revocationClient.Do(req)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error contacting %q: {{err}}", url), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %q", resp.StatusCode, url)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error reading response from %q: {{err}}", url), err)
+	}
+
+	return data, nil
+}
+
+// revocationCacheTTL returns how long revocation information valid until
+// nextUpdate may be cached
+func revocationCacheTTL(nextUpdate time.Time) time.Duration {
+	if nextUpdate.IsZero() {
+		return defaultRevocationCacheTTL
+	}
+	ttl := time.Until(nextUpdate)
+	if ttl <= 0 {
+		// Stale data is rejected before caching, but a non-positive duration
+		// would never expire from the cache
+		return time.Second
+	}
+	return ttl
+}
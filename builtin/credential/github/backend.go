@@ -2,10 +2,11 @@ package github
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/hashicorp/go-cleanhttp"
-	"github.com/hashicorp/vault/helper/mfa"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 	"golang.org/x/oauth2"
@@ -40,17 +41,22 @@ func Backend() *backend {
 		Help: backendHelp,
 
 		PathsSpecial: &logical.Paths{
-			Root: mfa.MFARootPaths(),
 			Unauthenticated: []string{
 				"login",
+				"login/device",
+			},
+			SealWrapStorage: []string{
+				loginStoragePrefix,
 			},
 		},
 
 		Paths: append([]*framework.Path{
 			pathConfig(&b),
-		}, append(allPaths, mfa.MFAPaths(b.Backend, pathLogin(&b))...)...),
-		AuthRenew:   b.pathLoginRenew,
-		BackendType: logical.TypeCredential,
+			pathLoginDevice(&b),
+		}, append(allPaths, pathLogin(&b))...),
+		AuthRenew:    b.pathLoginRenew,
+		PeriodicFunc: b.periodicFunc,
+		BackendType:  logical.TypeCredential,
 	}
 
 	return &b
@@ -62,6 +68,9 @@ type backend struct {
 	TeamMap *framework.PolicyMap
 
 	UserMap *framework.PolicyMap
+
+	l                     sync.Mutex
+	lastMembershipRefresh time.Time
 }
 
 // Client returns the GitHub client to communicate to GitHub via the
@@ -88,10 +97,12 @@ func (t *tokenSource) Token() (*oauth2.Token, error) {
 const backendHelp = `
 The GitHub credential provider allows authentication via GitHub.
 
-Users provide a personal access token to log in, and the credential
-provider verifies they're part of the correct organization and then
-maps the user to a set of Vault policies according to the teams they're
-part of.
+Users provide a personal access token, or log in with the OAuth device
+flow of a GitHub App or OAuth App, and the credential provider verifies
+they're part of the correct organization and then maps the user to a set
+of Vault policies according to the teams they're part of. Optionally, the
+membership of users is checked periodically and the tokens of users who
+left the organization or one of their teams are revoked.
 
 After enabling the credential provider, use the "config" route to
 configure it.
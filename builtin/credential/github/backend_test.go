@@ -3,7 +3,10 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -199,3 +202,185 @@ func testAccLogin(t *testing.T, policies []string) logicaltest.TestStep {
 		Check: logicaltest.TestCheckAuth(policies),
 	}
 }
+
+type testRevokeSystemView struct {
+	logical.StaticSystemView
+	revoked []string
+}
+
+func (s *testRevokeSystemView) RevokeLoginsByAlias(_ context.Context, aliasName string) error {
+	s.revoked = append(s.revoked, aliasName)
+	return nil
+}
+
+func TestBackend_MembershipRefreshUnsupported(t *testing.T) {
+	// System views that can't revoke logins, like those of plugins, can't
+	// refresh memberships
+	storage := &logical.InmemStorage{}
+	b, err := Factory(context.Background(), &logical.BackendConfig{
+		System:      &logical.StaticSystemView{},
+		StorageView: storage,
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"organization":                "myorg",
+			"membership_refresh_interval": "1h",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got resp: %#v\nerr: %v", resp, err)
+	}
+}
+
+func TestBackend_DeviceFlowAndMembershipRefresh(t *testing.T) {
+	var authorized bool
+	teams := `[{"id": 2, "name": "Eng Team", "slug": "eng-team", "organization": {"id": 1, "login": "myorg"}}]`
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/device/code", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_id") != "client" {
+			w.Write([]byte(`{"error": "unauthorized_client"}`))
+			return
+		}
+		w.Write([]byte(`{"device_code": "devicecode", "user_code": "ABCD-1234", "verification_uri": "https://github.com/login/device", "expires_in": 900, "interval": 5}`))
+	})
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.FormValue("device_code") != "devicecode":
+			w.Write([]byte(`{"error": "incorrect_device_code"}`))
+		case !authorized:
+			w.Write([]byte(`{"error": "authorization_pending"}`))
+		default:
+			w.Write([]byte(`{"access_token": "accesstoken", "token_type": "bearer", "scope": "read:org"}`))
+		}
+	})
+	apiHandler := func(body func() string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer accesstoken" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"message": "Bad credentials"}`))
+				return
+			}
+			w.Write([]byte(body()))
+		}
+	}
+	mux.HandleFunc("/api/v3/user", apiHandler(func() string { return `{"login": "Alice", "id": 3}` }))
+	mux.HandleFunc("/api/v3/user/orgs", apiHandler(func() string { return `[{"login": "myorg", "id": 1}]` }))
+	mux.HandleFunc("/api/v3/user/teams", apiHandler(func() string { return teams }))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sysView := &testRevokeSystemView{
+		StaticSystemView: logical.StaticSystemView{
+			DefaultLeaseTTLVal: time.Hour,
+			MaxLeaseTTLVal:     time.Hour * 24,
+		},
+	}
+	storage := &logical.InmemStorage{}
+	b, err := Factory(context.Background(), &logical.BackendConfig{
+		System:      sysView,
+		StorageView: storage,
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+
+	handle := func(path string, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("%s: err: %v", path, err)
+		}
+		return resp
+	}
+
+	resp := handle("config", map[string]interface{}{
+		"organization":                "myorg",
+		"base_url":                    server.URL + "/api/v3/",
+		"client_id":                   "client",
+		"membership_refresh_interval": "1h",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	handle("map/teams/eng-team", map[string]interface{}{
+		"value": "engpol",
+	})
+
+	resp = handle("login/device", nil)
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Data["device_code"] != "devicecode" || resp.Data["user_code"] != "ABCD-1234" || resp.Data["interval"] != 5 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The login is pending until the user authorizes it
+	resp = handle("login", map[string]interface{}{
+		"device_code": "devicecode",
+	})
+	if resp == nil || !resp.IsError() || !strings.HasPrefix(resp.Error().Error(), "authorization_pending") {
+		t.Fatalf("expected pending authorization, got %#v", resp)
+	}
+
+	authorized = true
+	resp = handle("login", map[string]interface{}{
+		"device_code": "devicecode",
+	})
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "Alice" || resp.Auth.InternalData["token"] != "accesstoken" {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+	if !reflect.DeepEqual(resp.Auth.Policies, []string{"engpol"}) {
+		t.Fatalf("bad: policies: %#v", resp.Auth.Policies)
+	}
+	if len(resp.Auth.GroupAliases) != 2 || resp.Auth.GroupAliases[0].Name != "Eng Team" || resp.Auth.GroupAliases[1].Name != "eng-team" {
+		t.Fatalf("bad: group aliases: %#v", resp.Auth.GroupAliases)
+	}
+
+	periodic := func() {
+		if err := b.(*backend).periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing is revoked while the user is still part of the team
+	periodic()
+	if len(sysView.revoked) != 0 {
+		t.Fatalf("bad: %#v", sysView.revoked)
+	}
+
+	// The refresh only runs once per interval
+	teams = `[]`
+	periodic()
+	if len(sysView.revoked) != 0 {
+		t.Fatalf("bad: %#v", sysView.revoked)
+	}
+
+	b.(*backend).lastMembershipRefresh = time.Time{}
+	periodic()
+	if !reflect.DeepEqual(sysView.revoked, []string{"Alice"}) {
+		t.Fatalf("bad: %#v", sysView.revoked)
+	}
+	keys, err := storage.List(context.Background(), loginStoragePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected the login to be removed, got %v", keys)
+	}
+}
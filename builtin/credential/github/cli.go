@@ -1,10 +1,13 @@
 package github
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/api"
@@ -22,18 +25,28 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 		mount = "github"
 	}
 
+	// Override the output
+	stdout := h.testStdout
+	if stdout == nil {
+		stdout = os.Stderr
+	}
+
+	if device, ok := m["device"]; ok {
+		useDevice, err := strconv.ParseBool(device)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to parse \"device\": {{err}}", err)
+		}
+		if useDevice {
+			return h.deviceLogin(c, mount, stdout)
+		}
+	}
+
 	// Extract or prompt for token
 	token := m["token"]
 	if token == "" {
 		token = os.Getenv("VAULT_AUTH_GITHUB_TOKEN")
 	}
 	if token == "" {
-		// Override the output
-		stdout := h.testStdout
-		if stdout == nil {
-			stdout = os.Stderr
-		}
-
 		var err error
 		fmt.Fprintf(stdout, "GitHub Personal Access Token (will be hidden): ")
 		token, err = password.Read(os.Stdin)
@@ -66,6 +79,75 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 	return secret, nil
 }
 
+// deviceLogin starts a device flow login and polls the login path until the
+// user has authorized it in their browser
+func (h *CLIHandler) deviceLogin(c *api.Client, mount string, stdout io.Writer) (*api.Secret, error) {
+	device, err := c.Logical().Write(fmt.Sprintf("auth/%s/login/device", mount), nil)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, fmt.Errorf("empty response from credential provider")
+	}
+
+	deviceCode, _ := device.Data["device_code"].(string)
+	userCode, _ := device.Data["user_code"].(string)
+	verificationURI, _ := device.Data["verification_uri"].(string)
+	if deviceCode == "" || userCode == "" {
+		return nil, fmt.Errorf("no device code returned by the credential provider")
+	}
+
+	interval := 5 * time.Second
+	if raw, ok := device.Data["interval"]; ok {
+		if seconds, err := parseSeconds(raw); err == nil && seconds > 0 {
+			interval = time.Duration(seconds) * time.Second
+		}
+	}
+	deadline := time.Now().Add(15 * time.Minute)
+	if raw, ok := device.Data["expires_in"]; ok {
+		if seconds, err := parseSeconds(raw); err == nil && seconds > 0 {
+			deadline = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+	}
+
+	fmt.Fprintf(stdout, "To log in, open %s and enter the code: %s\n", verificationURI, userCode)
+	fmt.Fprintf(stdout, "Waiting for authorization...\n")
+
+	path := fmt.Sprintf("auth/%s/login", mount)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		secret, err := c.Logical().Write(path, map[string]interface{}{
+			"device_code": deviceCode,
+		})
+		switch {
+		case err == nil:
+			if secret == nil {
+				return nil, fmt.Errorf("empty response from credential provider")
+			}
+			return secret, nil
+		case strings.Contains(err.Error(), "authorization_pending"):
+		case strings.Contains(err.Error(), "slow_down"):
+			interval += 5 * time.Second
+		default:
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("device code expired before the login was authorized")
+}
+
+func parseSeconds(raw interface{}) (int64, error) {
+	switch raw := raw.(type) {
+	case json.Number:
+		return raw.Int64()
+	case float64:
+		return int64(raw), nil
+	default:
+		return 0, fmt.Errorf("unexpected type %T", raw)
+	}
+}
+
 func (h *CLIHandler) Help() string {
 	help := `
 Usage: vault login -method=github [CONFIG K=V...]
@@ -78,6 +160,11 @@ Usage: vault login -method=github [CONFIG K=V...]
 
       $ vault login -method=github token=abcd1234
 
+  If a GitHub App or OAuth App is configured on the auth method, users can
+  instead authorize the login in their browser using the device flow:
+
+      $ vault login -method=github device=true
+
 Configuration:
 
   mount=<string>
@@ -86,9 +173,13 @@ Configuration:
       specified here as well. If specified here, it takes precedence over the
       value for -path. The default value is "github".
 
+  device=<bool>
+      Log in with the device flow: a code is printed to enter on GitHub,
+      and Vault waits until the login is authorized. The default is false.
+
   token=<string>
       GitHub personal access token to use for authentication. If not provided,
-      Vault will prompt for the value.
+      and device is not set, Vault will prompt for the value.
 `
 
 	return strings.TrimSpace(help)
@@ -0,0 +1,153 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const loginStoragePrefix = "login/"
+
+// loginRevoker is implemented by the system view given to builtin auth
+// methods, but not to plugins
+type loginRevoker interface {
+	RevokeLoginsByAlias(ctx context.Context, aliasName string) error
+}
+
+// loginEntry records the last login of a user, so that their membership can
+// be checked again while the tokens of their logins may still be valid
+type loginEntry struct {
+	Login     string    `json:"login"`
+	Token     string    `json:"token"`
+	TeamNames []string  `json:"team_names"`
+	LastLogin time.Time `json:"last_login"`
+}
+
+func (b *backend) storeLogin(ctx context.Context, s logical.Storage, login, token string, teamNames []string) error {
+	entry, err := logical.StorageEntryJSON(loginStoragePrefix+strings.ToLower(login), &loginEntry{
+		Login:     login,
+		Token:     token,
+		TeamNames: teamNames,
+		LastLogin: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+// periodicFunc checks the membership of the users who logged in again once
+// the configured refresh interval has passed
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	config, err := b.Config(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	if config.Organization == "" || config.MembershipRefreshInterval <= 0 {
+		return nil
+	}
+
+	b.l.Lock()
+	if time.Since(b.lastMembershipRefresh) < config.MembershipRefreshInterval {
+		b.l.Unlock()
+		return nil
+	}
+	b.lastMembershipRefresh = time.Now()
+	b.l.Unlock()
+
+	return b.refreshMemberships(ctx, req, config)
+}
+
+// refreshMemberships revokes the tokens of the users who are no longer part
+// of the organization or of a team they were part of when they logged in
+func (b *backend) refreshMemberships(ctx context.Context, req *logical.Request, config *config) error {
+	revoker, ok := b.System().(loginRevoker)
+	if !ok {
+		return fmt.Errorf("tokens can't be revoked when running as a plugin")
+	}
+
+	keys, err := req.Storage.List(ctx, loginStoragePrefix)
+	if err != nil {
+		return err
+	}
+
+	// Tokens can't outlive the max TTL of the login that issued them
+	maxTTL := config.MaxTTL
+	if maxTTL == 0 {
+		maxTTL = b.System().MaxLeaseTTL()
+	}
+
+	for _, key := range keys {
+		storageEntry, err := req.Storage.Get(ctx, loginStoragePrefix+key)
+		if err != nil {
+			return err
+		}
+		if storageEntry == nil {
+			continue
+		}
+
+		var entry loginEntry
+		if err := storageEntry.DecodeJSON(&entry); err != nil {
+			return errwrap.Wrapf("error reading login entry: {{err}}", err)
+		}
+
+		if time.Since(entry.LastLogin) > maxTTL {
+			if err := req.Storage.Delete(ctx, loginStoragePrefix+key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		reason, err := b.membershipRemoved(ctx, req, &entry)
+		if err != nil {
+			b.Logger().Warn("error checking membership", "login", entry.Login, "error", err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+
+		b.Logger().Info("revoking tokens of user", "login", entry.Login, "reason", reason)
+		if err := revoker.RevokeLoginsByAlias(ctx, entry.Login); err != nil {
+			b.Logger().Error("error revoking tokens of user", "login", entry.Login, "error", err)
+			continue
+		}
+
+		if err := req.Storage.Delete(ctx, loginStoragePrefix+key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// membershipRemoved returns why the tokens of the login must be revoked, or
+// an empty string if the user is still part of the organization and teams
+func (b *backend) membershipRemoved(ctx context.Context, req *logical.Request, entry *loginEntry) (string, error) {
+	verifyResp, resp, err := b.verifyCredentials(ctx, req, entry.Token)
+	if err != nil {
+		if errResp, ok := err.(*github.ErrorResponse); ok && errResp.Response != nil && errResp.Response.StatusCode == http.StatusUnauthorized {
+			return "token is no longer valid", nil
+		}
+		return "", err
+	}
+	if resp != nil {
+		return resp.Error().Error(), nil
+	}
+
+	for _, teamName := range entry.TeamNames {
+		if !strutil.StrListContains(verifyResp.TeamNames, teamName) {
+			return fmt.Sprintf("user is no longer part of team %q", teamName), nil
+		}
+	}
+
+	return "", nil
+}
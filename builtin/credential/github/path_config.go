@@ -34,6 +34,19 @@ API-compatible authentication server.`,
 				Type:        framework.TypeString,
 				Description: `Maximum duration after which authentication will be expired`,
 			},
+			"client_id": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The client ID of the GitHub App or OAuth App
+used for device flow logins. Device flow must be
+enabled on the app.`,
+			},
+			"membership_refresh_interval": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Interval at which the organization and team
+membership of users who logged in is checked again.
+The tokens of users who left the organization or a
+team are revoked. Disabled if not set.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -76,11 +89,21 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, dat
 		}
 	}
 
+	membershipRefreshInterval := time.Duration(data.Get("membership_refresh_interval").(int)) * time.Second
+	if membershipRefreshInterval < 0 {
+		return logical.ErrorResponse("membership_refresh_interval cannot be negative"), nil
+	}
+	if _, ok := b.System().(loginRevoker); membershipRefreshInterval > 0 && !ok {
+		return logical.ErrorResponse("membership_refresh_interval is not supported when running as a plugin"), nil
+	}
+
 	entry, err := logical.StorageEntryJSON("config", config{
-		Organization: organization,
-		BaseURL:      baseURL,
-		TTL:          ttl,
-		MaxTTL:       maxTTL,
+		Organization:              organization,
+		BaseURL:                   baseURL,
+		TTL:                       ttl,
+		MaxTTL:                    maxTTL,
+		ClientID:                  data.Get("client_id").(string),
+		MembershipRefreshInterval: membershipRefreshInterval,
 	})
 
 	if err != nil {
@@ -109,10 +132,12 @@ func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data
 
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"organization": config.Organization,
-			"base_url":     config.BaseURL,
-			"ttl":          config.TTL,
-			"max_ttl":      config.MaxTTL,
+			"organization":                config.Organization,
+			"base_url":                    config.BaseURL,
+			"ttl":                         config.TTL,
+			"max_ttl":                     config.MaxTTL,
+			"client_id":                   config.ClientID,
+			"membership_refresh_interval": int64(config.MembershipRefreshInterval / time.Second),
 		},
 	}
 	return resp, nil
@@ -136,8 +161,32 @@ func (b *backend) Config(ctx context.Context, s logical.Storage) (*config, error
 }
 
 type config struct {
-	Organization string        `json:"organization" structs:"organization" mapstructure:"organization"`
-	BaseURL      string        `json:"base_url" structs:"base_url" mapstructure:"base_url"`
-	TTL          time.Duration `json:"ttl" structs:"ttl" mapstructure:"ttl"`
-	MaxTTL       time.Duration `json:"max_ttl" structs:"max_ttl" mapstructure:"max_ttl"`
+	Organization              string        `json:"organization" structs:"organization" mapstructure:"organization"`
+	BaseURL                   string        `json:"base_url" structs:"base_url" mapstructure:"base_url"`
+	TTL                       time.Duration `json:"ttl" structs:"ttl" mapstructure:"ttl"`
+	MaxTTL                    time.Duration `json:"max_ttl" structs:"max_ttl" mapstructure:"max_ttl"`
+	ClientID                  string        `json:"client_id" structs:"client_id" mapstructure:"client_id"`
+	MembershipRefreshInterval time.Duration `json:"membership_refresh_interval" structs:"membership_refresh_interval" mapstructure:"membership_refresh_interval"`
+}
+
+// webURL returns the base URL of the GitHub web host, on which the device
+// flow endpoints live; for GitHub Enterprise it is the host of base_url
+func (c *config) webURL() (*url.URL, error) {
+	if c.BaseURL == "" {
+		return url.Parse("https://github.com/")
+	}
+
+	parsedURL, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsedURL.Host == "api.github.com" {
+		return url.Parse("https://github.com/")
+	}
+
+	return &url.URL{
+		Scheme: parsedURL.Scheme,
+		Host:   parsedURL.Host,
+		Path:   "/",
+	}, nil
 }
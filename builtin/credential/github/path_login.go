@@ -21,6 +21,10 @@ func pathLogin(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "GitHub personal API token",
 			},
+			"device_code": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Device code returned by the login/device path, used instead of a token",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -31,6 +35,10 @@ func pathLogin(b *backend) *framework.Path {
 }
 
 func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	// A device code can only be exchanged once, so it is kept for the login
+	if data.Get("device_code").(string) != "" {
+		return logical.ErrorResponse("alias lookahead is not supported for device flow logins"), nil
+	}
 	token := data.Get("token").(string)
 
 	var verifyResp *verifyCredentialsResp
@@ -53,6 +61,20 @@ func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Requ
 
 func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	token := data.Get("token").(string)
+	if deviceCode := data.Get("device_code").(string); deviceCode != "" {
+		if token != "" {
+			return logical.ErrorResponse("only one of token and device_code can be given"), nil
+		}
+
+		accessToken, resp, err := b.exchangeDeviceCode(ctx, req, deviceCode)
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			return resp, nil
+		}
+		token = accessToken
+	}
 
 	var verifyResp *verifyCredentialsResp
 	if verifyResponse, resp, err := b.verifyCredentials(ctx, req, token); err != nil {
@@ -99,6 +121,12 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *fra
 		})
 	}
 
+	if config.MembershipRefreshInterval > 0 {
+		if err := b.storeLogin(ctx, req.Storage, *verifyResp.User.Login, token, verifyResp.TeamNames); err != nil {
+			return nil, err
+		}
+	}
+
 	return resp, nil
 }
 
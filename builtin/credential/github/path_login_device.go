@@ -0,0 +1,179 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// deviceFlowScope is the scope requested for OAuth Apps, needed to read
+	// the organization and team membership of the user. GitHub Apps ignore it
+	// and use the permissions of the app instead.
+	deviceFlowScope = "read:org"
+
+	deviceFlowGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+func pathLoginDevice(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "login/device",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathLoginDevice,
+		},
+
+		HelpSynopsis:    pathLoginDeviceHelpSyn,
+		HelpDescription: pathLoginDeviceHelpDesc,
+	}
+}
+
+func (b *backend) pathLoginDevice(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.Config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config.Organization == "" {
+		return logical.ErrorResponse(
+			"configure the github credential backend first"), nil
+	}
+	if config.ClientID == "" {
+		return logical.ErrorResponse("device flow logins require a client_id to be configured"), nil
+	}
+
+	var deviceResp deviceCodeResponse
+	if err := deviceFlowRequest(ctx, config, "login/device/code", url.Values{
+		"client_id": []string{config.ClientID},
+		"scope":     []string{deviceFlowScope},
+	}, &deviceResp); err != nil {
+		return nil, err
+	}
+	if deviceResp.Error != "" {
+		return logical.ErrorResponse(fmt.Sprintf("error requesting device code: %s", deviceResp.errorString())), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"device_code":      deviceResp.DeviceCode,
+			"user_code":        deviceResp.UserCode,
+			"verification_uri": deviceResp.VerificationURI,
+			"expires_in":       deviceResp.ExpiresIn,
+			"interval":         deviceResp.Interval,
+		},
+	}, nil
+}
+
+// exchangeDeviceCode returns the access token the device code was authorized
+// for. Until the user has authorized it, an error response starting with the
+// GitHub error code, such as authorization_pending or slow_down, is returned
+// for the client to poll again.
+func (b *backend) exchangeDeviceCode(ctx context.Context, req *logical.Request, deviceCode string) (string, *logical.Response, error) {
+	config, err := b.Config(ctx, req.Storage)
+	if err != nil {
+		return "", nil, err
+	}
+	if config.ClientID == "" {
+		return "", logical.ErrorResponse("device flow logins require a client_id to be configured"), nil
+	}
+
+	var tokenResp accessTokenResponse
+	if err := deviceFlowRequest(ctx, config, "login/oauth/access_token", url.Values{
+		"client_id":   []string{config.ClientID},
+		"device_code": []string{deviceCode},
+		"grant_type":  []string{deviceFlowGrantType},
+	}, &tokenResp); err != nil {
+		return "", nil, err
+	}
+	if tokenResp.Error != "" {
+		return "", logical.ErrorResponse(tokenResp.errorString()), nil
+	}
+	if tokenResp.AccessToken == "" {
+		return "", nil, fmt.Errorf("no access token returned for the device code")
+	}
+
+	return tokenResp.AccessToken, nil, nil
+}
+
+// deviceFlowRequest posts the form to the device flow endpoint at the given
+// path of the GitHub web host and decodes the response into out
+func deviceFlowRequest(ctx context.Context, config *config, path string, form url.Values, out interface{}) error {
+	webURL, err := config.webURL()
+	if err != nil {
+		return errwrap.Wrapf("successfully parsed base_url when set but failing to parse now: {{err}}", err)
+	}
+	endpoint, err := webURL.Parse(path)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest("POST", endpoint.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := cleanhttp.DefaultClient().Do(httpReq)
+	if err != nil {
+		return errwrap.Wrapf("error making device flow request: {{err}}", err)
+	}
+	defer resp.Body.Close()
+
+	// Device flow errors are reported in the body of successful responses
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, endpoint.String())
+	}
+
+	if err := jsonutil.DecodeJSONFromReader(resp.Body, out); err != nil {
+		return errwrap.Wrapf("error decoding device flow response: {{err}}", err)
+	}
+
+	return nil
+}
+
+type deviceFlowError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (e deviceFlowError) errorString() string {
+	if e.ErrorDescription == "" {
+		return e.Error
+	}
+	return fmt.Sprintf("%s: %s", e.Error, e.ErrorDescription)
+}
+
+type deviceCodeResponse struct {
+	deviceFlowError
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+type accessTokenResponse struct {
+	deviceFlowError
+	AccessToken string `json:"access_token"`
+}
+
+const pathLoginDeviceHelpSyn = `
+Start a device flow login.
+`
+
+const pathLoginDeviceHelpDesc = `
+This path requests a device code from GitHub for the configured client_id.
+The user authorizes it by entering the returned user_code at the returned
+verification_uri, while the client polls the "login" path with the
+device_code, no more often than every interval seconds, until the login
+succeeds.
+`
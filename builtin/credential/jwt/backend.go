@@ -0,0 +1,151 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
+)
+
+const (
+	configPath string = "config"
+	rolePrefix string = "role/"
+
+	// oidcStateTimeout is how long a login started with oidc/auth_url may
+	// take before its callback is rejected
+	oidcStateTimeout = 10 * time.Minute
+)
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+type jwtAuthBackend struct {
+	*framework.Backend
+
+	l            sync.RWMutex
+	provider     *oidc.Provider
+	keySet       oidc.KeySet
+	cachedConfig *jwtConfig
+	oidcStates   *cache.Cache
+
+	providerCtx       context.Context
+	providerCtxCancel context.CancelFunc
+}
+
+func backend() *jwtAuthBackend {
+	b := new(jwtAuthBackend)
+	b.providerCtx, b.providerCtxCancel = context.WithCancel(context.Background())
+	b.oidcStates = cache.New(oidcStateTimeout, 1*time.Minute)
+
+	b.Backend = &framework.Backend{
+		AuthRenew:   b.pathLoginRenew,
+		BackendType: logical.TypeCredential,
+		Invalidate:  b.invalidate,
+		Help:        backendHelp,
+		Clean:       b.cleanup,
+
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				"login",
+				"oidc/auth_url",
+				"oidc/callback",
+			},
+			SealWrapStorage: []string{
+				"config",
+			},
+		},
+
+		Paths: framework.PathAppend(
+			[]*framework.Path{
+				pathLogin(b),
+				pathRoleList(b),
+				pathRole(b),
+				pathConfig(b),
+			},
+			pathOIDC(b),
+		),
+	}
+
+	return b
+}
+
+func (b *jwtAuthBackend) cleanup(_ context.Context) {
+	b.l.Lock()
+	if b.providerCtxCancel != nil {
+		b.providerCtxCancel()
+	}
+	b.l.Unlock()
+}
+
+func (b *jwtAuthBackend) invalidate(ctx context.Context, key string) {
+	switch key {
+	case configPath:
+		b.reset()
+	}
+}
+
+// reset drops the cached configuration and the provider or key set built
+// from it, so that they are rebuilt on the next request
+func (b *jwtAuthBackend) reset() {
+	b.l.Lock()
+	b.provider = nil
+	b.keySet = nil
+	b.cachedConfig = nil
+	b.l.Unlock()
+}
+
+func (b *jwtAuthBackend) config(ctx context.Context, s logical.Storage) (*jwtConfig, error) {
+	b.l.RLock()
+	if b.cachedConfig != nil {
+		defer b.l.RUnlock()
+		return b.cachedConfig, nil
+	}
+	b.l.RUnlock()
+
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	// Check again now that we hold the write lock
+	if b.cachedConfig != nil {
+		return b.cachedConfig, nil
+	}
+
+	config, err := b.loadConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	for _, v := range config.JWTValidationPubKeys {
+		key, err := certutil.ParsePublicKeyPEM([]byte(v))
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing public key: {{err}}", err)
+		}
+		config.parsedJWTPubKeys = append(config.parsedJWTPubKeys, key)
+	}
+
+	b.cachedConfig = config
+
+	return config, nil
+}
+
+const (
+	backendHelp = `
+The JWT backend plugin allows authentication using JWTs, including OIDC ID
+tokens, and can also log in users through an OIDC provider using the
+authorization code flow.
+`
+)
@@ -0,0 +1,553 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	jose "gopkg.in/square/go-jose.v2"
+	sqjwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+const testKeyID = "test-key"
+
+func getBackend(t *testing.T) (*jwtAuthBackend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b.(*jwtAuthBackend), config.StorageView
+}
+
+func testKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	}))
+}
+
+func testSign(t *testing.T, key *ecdsa.PrivateKey, claims *sqjwt.Claims, privateClaims map[string]interface{}) string {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", testKeyID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := sqjwt.Signed(sig).Claims(claims).Claims(privateClaims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func testRequest(t *testing.T, b *jwtAuthBackend, storage logical.Storage, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: op,
+		Path:      path,
+		Storage:   storage,
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return resp
+}
+
+func testClaims(issuer string) *sqjwt.Claims {
+	return &sqjwt.Claims{
+		Issuer:    issuer,
+		Subject:   "r3qXcK2bix9eFECzsU3Sbmh0K16fatW6@clients",
+		Audience:  sqjwt.Audience{"https://vault.plugin.auth.jwt.test"},
+		NotBefore: sqjwt.NewNumericDate(time.Now().Add(-5 * time.Second)),
+		Expiry:    sqjwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+	}
+}
+
+func testPrivateClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"user":   "jeff",
+		"color":  "green",
+		"groups": []string{"foo", "bar"},
+		"nested": map[string]interface{}{
+			"team": "ops",
+		},
+	}
+}
+
+func writeTestRole(t *testing.T, b *jwtAuthBackend, storage logical.Storage) {
+	resp := testRequest(t, b, storage, logical.CreateOperation, "role/plugin-test", map[string]interface{}{
+		"role_type":       "jwt",
+		"bound_audiences": "https://vault.plugin.auth.jwt.test",
+		"bound_subject":   "r3qXcK2bix9eFECzsU3Sbmh0K16fatW6@clients",
+		"bound_claims": map[string]interface{}{
+			"color":        []interface{}{"red", "green"},
+			"/nested/team": "ops",
+		},
+		"claim_mappings": map[string]interface{}{
+			"color":        "favorite_color",
+			"/nested/team": "team",
+		},
+		"user_claim":   "user",
+		"groups_claim": "groups",
+		"policies":     "test",
+		"period":       "3s",
+		"ttl":          "1s",
+		"max_ttl":      "5s",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestConfig_Validation(t *testing.T) {
+	b, storage := getBackend(t)
+	_, pubKey := testKey(t)
+
+	for _, data := range []map[string]interface{}{
+		// No validation method
+		map[string]interface{}{},
+		// Several validation methods
+		map[string]interface{}{
+			"jwks_url":               "https://example.com/jwks",
+			"jwt_validation_pubkeys": pubKey,
+		},
+		// Invalid public key
+		map[string]interface{}{
+			"jwt_validation_pubkeys": "not a key",
+		},
+		// Client credentials without discovery
+		map[string]interface{}{
+			"jwt_validation_pubkeys": pubKey,
+			"oidc_client_id":         "abc",
+			"oidc_client_secret":     "def",
+		},
+		// Unknown signing algorithm
+		map[string]interface{}{
+			"jwt_validation_pubkeys": pubKey,
+			"jwt_supported_algs":     "HS256",
+		},
+	} {
+		resp := testRequest(t, b, storage, logical.UpdateOperation, configPath, data)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected error for %#v, got %#v", data, resp)
+		}
+	}
+
+	resp := testRequest(t, b, storage, logical.UpdateOperation, configPath, map[string]interface{}{
+		"jwt_validation_pubkeys": pubKey,
+		"bound_issuer":           "https://team-vault.auth0.com/",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, storage, logical.ReadOperation, configPath, nil)
+	if !reflect.DeepEqual(resp.Data["jwt_validation_pubkeys"], []string{strings.TrimSpace(pubKey)}) || resp.Data["bound_issuer"] != "https://team-vault.auth0.com/" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["oidc_client_secret"]; ok {
+		t.Fatal("client secret should not be returned")
+	}
+}
+
+func TestRole_Validation(t *testing.T) {
+	b, storage := getBackend(t)
+
+	for _, data := range []map[string]interface{}{
+		// Missing user claim
+		map[string]interface{}{
+			"role_type":       "jwt",
+			"bound_audiences": "foo",
+		},
+		// JWT role without any bound constraint
+		map[string]interface{}{
+			"role_type":  "jwt",
+			"user_claim": "user",
+		},
+		// OIDC role without redirect URIs
+		map[string]interface{}{
+			"user_claim": "user",
+		},
+		// Invalid bound claim value
+		map[string]interface{}{
+			"role_type":    "jwt",
+			"user_claim":   "user",
+			"bound_claims": map[string]interface{}{"foo": 12},
+		},
+		// Reserved metadata key
+		map[string]interface{}{
+			"role_type":       "jwt",
+			"user_claim":      "user",
+			"bound_audiences": "foo",
+			"claim_mappings":  map[string]interface{}{"foo": "role"},
+		},
+	} {
+		resp := testRequest(t, b, storage, logical.CreateOperation, "role/test", data)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected error for %#v, got %#v", data, resp)
+		}
+	}
+
+	writeTestRole(t, b, storage)
+	resp := testRequest(t, b, storage, logical.ReadOperation, "role/plugin-test", nil)
+	if resp.Data["role_type"] != "jwt" || resp.Data["user_claim"] != "user" || resp.Data["period"] != int64(3) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = testRequest(t, b, storage, logical.ListOperation, "role/", nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"plugin-test"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestLogin_StaticKeys(t *testing.T) {
+	b, storage := getBackend(t)
+	key, pubKey := testKey(t)
+	issuer := "https://team-vault.auth0.com/"
+
+	testRequest(t, b, storage, logical.UpdateOperation, configPath, map[string]interface{}{
+		"jwt_validation_pubkeys": pubKey,
+		"jwt_supported_algs":     "ES256",
+		"bound_issuer":           issuer,
+	})
+	writeTestRole(t, b, storage)
+
+	login := func(claims *sqjwt.Claims, privateClaims map[string]interface{}) *logical.Response {
+		return testRequest(t, b, storage, logical.UpdateOperation, "login", map[string]interface{}{
+			"role": "plugin-test",
+			"jwt":  testSign(t, key, claims, privateClaims),
+		})
+	}
+
+	resp := login(testClaims(issuer), testPrivateClaims())
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	auth := resp.Auth
+	if auth.Alias.Name != "jeff" || auth.DisplayName != "jeff" {
+		t.Fatalf("bad: %#v", auth)
+	}
+	if len(auth.GroupAliases) != 2 || auth.GroupAliases[0].Name != "foo" || auth.GroupAliases[1].Name != "bar" {
+		t.Fatalf("bad: %#v", auth.GroupAliases)
+	}
+	expMetadata := map[string]string{
+		"role":           "plugin-test",
+		"favorite_color": "green",
+		"team":           "ops",
+	}
+	if !reflect.DeepEqual(auth.Metadata, expMetadata) {
+		t.Fatalf("bad: %#v", auth.Metadata)
+	}
+	if !reflect.DeepEqual(auth.Policies, []string{"test"}) || auth.Period != 3*time.Second || auth.TTL != time.Second || auth.MaxTTL != 5*time.Second {
+		t.Fatalf("bad: %#v", auth)
+	}
+
+	// Wrong audience
+	claims := testClaims(issuer)
+	claims.Audience = sqjwt.Audience{"https://other.example.com"}
+	if resp := login(claims, testPrivateClaims()); resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	// Wrong issuer
+	if resp := login(testClaims("https://other.example.com/"), testPrivateClaims()); resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	// Expired token
+	claims = testClaims(issuer)
+	claims.Expiry = sqjwt.NewNumericDate(time.Now().Add(-10 * time.Minute))
+	if resp := login(claims, testPrivateClaims()); resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	// Bound claim mismatch
+	privateClaims := testPrivateClaims()
+	privateClaims["color"] = "blue"
+	if resp := login(testClaims(issuer), privateClaims); resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	// Missing user claim
+	privateClaims = testPrivateClaims()
+	delete(privateClaims, "user")
+	if resp := login(testClaims(issuer), privateClaims); resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	// Signed by an unknown key
+	otherKey, _ := testKey(t)
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "plugin-test",
+		"jwt":  testSign(t, otherKey, testClaims(issuer), testPrivateClaims()),
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+}
+
+func TestLogin_Renew(t *testing.T) {
+	b, storage := getBackend(t)
+	key, pubKey := testKey(t)
+
+	testRequest(t, b, storage, logical.UpdateOperation, configPath, map[string]interface{}{
+		"jwt_validation_pubkeys": pubKey,
+		"jwt_supported_algs":     "ES256",
+	})
+	writeTestRole(t, b, storage)
+
+	resp := testRequest(t, b, storage, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "plugin-test",
+		"jwt":  testSign(t, key, testClaims(""), testPrivateClaims()),
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	req := &logical.Request{
+		Operation: logical.RenewOperation,
+		Path:      "login",
+		Storage:   storage,
+		Auth:      resp.Auth,
+	}
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != nil || resp == nil || resp.Auth.Period != 3*time.Second {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// Renewal fails once the role is gone
+	testRequest(t, b, storage, logical.DeleteOperation, "role/plugin-test", nil)
+	if _, err := b.HandleRequest(context.Background(), req); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestLogin_JWKS(t *testing.T) {
+	key, _ := testKey(t)
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{
+				{Key: &key.PublicKey, KeyID: testKeyID, Algorithm: "ES256", Use: "sig"},
+			},
+		})
+	}))
+	defer s.Close()
+
+	b, storage := getBackend(t)
+	caPEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: s.Certificate().Raw,
+	}))
+	resp := testRequest(t, b, storage, logical.UpdateOperation, configPath, map[string]interface{}{
+		"jwks_url":           s.URL,
+		"jwks_ca_pem":        caPEM,
+		"jwt_supported_algs": "ES256",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	writeTestRole(t, b, storage)
+
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "plugin-test",
+		"jwt":  testSign(t, key, testClaims(""), testPrivateClaims()),
+	})
+	if resp == nil || resp.IsError() || resp.Auth.Alias.Name != "jeff" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	otherKey, _ := testKey(t)
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "plugin-test",
+		"jwt":  testSign(t, otherKey, testClaims(""), testPrivateClaims()),
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+}
+
+// testProvider is a minimal OIDC provider that issues ID tokens for any
+// authorization code
+type testProvider struct {
+	server   *httptest.Server
+	key      *ecdsa.PrivateKey
+	clientID string
+	nonce    string
+}
+
+func newTestProvider(t *testing.T, clientID string) *testProvider {
+	key, _ := testKey(t)
+	p := &testProvider{
+		key:      key,
+		clientID: clientID,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+			"issuer": %q,
+			"authorization_endpoint": %q,
+			"token_endpoint": %q,
+			"jwks_uri": %q,
+			"id_token_signing_alg_values_supported": ["ES256"]
+		}`, p.server.URL, p.server.URL+"/auth", p.server.URL+"/token", p.server.URL+"/certs")
+	})
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{
+				{Key: &key.PublicKey, KeyID: testKeyID, Algorithm: "ES256", Use: "sig"},
+			},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("code") != "abc" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		claims := testClaims(p.server.URL)
+		claims.Audience = sqjwt.Audience{p.clientID}
+		privateClaims := testPrivateClaims()
+		privateClaims["nonce"] = p.nonce
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   300,
+			"id_token":     testSign(t, key, claims, privateClaims),
+		})
+	})
+	p.server = httptest.NewTLSServer(mux)
+
+	return p
+}
+
+func TestOIDC_Login(t *testing.T) {
+	p := newTestProvider(t, "vault")
+	defer p.server.Close()
+
+	b, storage := getBackend(t)
+	caPEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: p.server.Certificate().Raw,
+	}))
+	resp := testRequest(t, b, storage, logical.UpdateOperation, configPath, map[string]interface{}{
+		"oidc_discovery_url":    p.server.URL,
+		"oidc_discovery_ca_pem": caPEM,
+		"oidc_client_id":        "vault",
+		"oidc_client_secret":    "secret",
+		"jwt_supported_algs":    "ES256",
+		"default_role":          "oidc-test",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	redirectURI := "http://localhost:8250/oidc/callback"
+	resp = testRequest(t, b, storage, logical.CreateOperation, "role/oidc-test", map[string]interface{}{
+		"user_claim":            "user",
+		"groups_claim":          "groups",
+		"oidc_scopes":           "email,profile",
+		"allowed_redirect_uris": redirectURI,
+		"policies":              "dev",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// OIDC roles can't be used to log in with a JWT
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "login", map[string]interface{}{
+		"jwt": testSign(t, p.key, testClaims(p.server.URL), testPrivateClaims()),
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	// Redirect URIs that aren't allowed don't get an authorization URL
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "oidc/auth_url", map[string]interface{}{
+		"redirect_uri": "https://evil.example.com/callback",
+	})
+	if resp.Data["auth_url"] != "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "oidc/auth_url", map[string]interface{}{
+		"redirect_uri": redirectURI,
+	})
+	authURL, err := url.Parse(resp.Data["auth_url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authURL.Query()
+	if !strings.HasPrefix(authURL.String(), p.server.URL+"/auth?") ||
+		query.Get("client_id") != "vault" ||
+		query.Get("redirect_uri") != redirectURI ||
+		query.Get("scope") != "openid email profile" {
+		t.Fatalf("bad: %s", authURL)
+	}
+	state := query.Get("state")
+
+	// The ID token must carry the nonce of the authorization request
+	p.nonce = "wrong"
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "oidc/callback", map[string]interface{}{
+		"state": state,
+		"code":  "abc",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	// States can only be used once
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "oidc/callback", map[string]interface{}{
+		"state": state,
+		"code":  "abc",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	resp = testRequest(t, b, storage, logical.UpdateOperation, "oidc/auth_url", map[string]interface{}{
+		"redirect_uri": redirectURI,
+	})
+	authURL, err = url.Parse(resp.Data["auth_url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.nonce = authURL.Query().Get("nonce")
+
+	resp = testRequest(t, b, storage, logical.ReadOperation, "oidc/callback", map[string]interface{}{
+		"state": authURL.Query().Get("state"),
+		"code":  "abc",
+	})
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "jeff" || len(resp.Auth.GroupAliases) != 2 || !reflect.DeepEqual(resp.Auth.Policies, []string{"dev"}) {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+}
@@ -0,0 +1,121 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+// getClaim returns a claim value from allClaims given a provided claim string.
+// If this string is a valid JSON Pointer, such as "/groups/admins", the
+// nested maps are walked to find the value. Otherwise the string is used as
+// the name of a top-level claim.
+func getClaim(allClaims map[string]interface{}, claim string) interface{} {
+	if !strings.HasPrefix(claim, "/") {
+		return allClaims[claim]
+	}
+
+	var val interface{} = allClaims
+	for _, token := range strings.Split(claim[1:], "/") {
+		// Unescape the reference token as described in RFC 6901
+		token = strings.Replace(token, "~1", "/", -1)
+		token = strings.Replace(token, "~0", "~", -1)
+
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if val, ok = m[token]; !ok {
+			return nil
+		}
+	}
+
+	return val
+}
+
+// extractMetadata builds a metadata map from a set of claims and claims
+// mappings. The mappings are from claim names or pointers to metadata keys,
+// and the referenced claims must be strings.
+func extractMetadata(allClaims map[string]interface{}, claimMappings map[string]string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for source, target := range claimMappings {
+		if value := getClaim(allClaims, source); value != nil {
+			strValue, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("error converting claim '%s' to string", source)
+			}
+
+			metadata[target] = strValue
+		}
+	}
+	return metadata, nil
+}
+
+// validateAudience checks whether any of the audiences in audClaim match those
+// in boundAudiences. If strict is true and there are no bound audiences, then
+// the presence of any audience in the received claim is considered an error.
+func validateAudience(boundAudiences, audClaim []string, strict bool) error {
+	if strict && len(boundAudiences) == 0 && len(audClaim) > 0 {
+		return errors.New("audience claim found in JWT but no audiences bound to the role")
+	}
+
+	if len(boundAudiences) > 0 {
+		for _, v := range boundAudiences {
+			if strutil.StrListContains(audClaim, v) {
+				return nil
+			}
+		}
+		return errors.New("aud claim does not match any bound audience")
+	}
+
+	return nil
+}
+
+// validateBoundClaims checks that all of the claim:value requirements in
+// boundClaims are met. A bound claim whose value is a list is met if the
+// claim matches any of the values in the list.
+func validateBoundClaims(boundClaims, allClaims map[string]interface{}) error {
+	for claim, expValue := range boundClaims {
+		actValue := getClaim(allClaims, claim)
+		if actValue == nil {
+			return fmt.Errorf("claim is missing: %s", claim)
+		}
+
+		expValues, err := boundClaimValues(expValue)
+		if err != nil {
+			return fmt.Errorf("invalid bound claim %s: %v", claim, err)
+		}
+
+		actString, ok := actValue.(string)
+		if !ok || !strutil.StrListContains(expValues, actString) {
+			return fmt.Errorf("claim %q does not match any associated bound claim values", claim)
+		}
+	}
+
+	return nil
+}
+
+// boundClaimValues returns the values accepted for a bound claim, which may
+// be configured either as a single string or as a list of strings
+func boundClaimValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("list values must be strings, got %T", elem)
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("value must be a string or a list of strings, got %T", value)
+	}
+}
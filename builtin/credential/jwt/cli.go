@@ -0,0 +1,205 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	defaultMount         = "oidc"
+	defaultListenAddress = "localhost"
+	defaultPort          = "8250"
+	defaultCallbackPath  = "/oidc/callback"
+
+	// loginTimeout is how long the CLI waits for the OIDC provider to
+	// redirect the browser back to it
+	loginTimeout = 2 * time.Minute
+)
+
+type CLIHandler struct {
+	// for tests
+	testStdout io.Writer
+}
+
+type loginResp struct {
+	secret *api.Secret
+	err    error
+}
+
+func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, error) {
+	// Override the output
+	stdout := h.testStdout
+	if stdout == nil {
+		stdout = os.Stderr
+	}
+
+	mount, ok := m["mount"]
+	if !ok {
+		mount = defaultMount
+	}
+
+	listenAddress, ok := m["listenaddress"]
+	if !ok {
+		listenAddress = defaultListenAddress
+	}
+
+	port, ok := m["port"]
+	if !ok {
+		port = defaultPort
+	}
+
+	role := m["role"]
+
+	redirectURI := fmt.Sprintf("http://%s:%s%s", listenAddress, port, defaultCallbackPath)
+
+	authURL, err := fetchAuthURL(c, role, mount, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(listenAddress, port))
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	// Handle the callback from the OIDC provider, completing the login with
+	// the code and state it passes back
+	doneCh := make(chan loginResp, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(defaultCallbackPath, func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		secret, err := c.Logical().Write(fmt.Sprintf("auth/%s/oidc/callback", mount), map[string]interface{}{
+			"state": query.Get("state"),
+			"code":  query.Get("code"),
+		})
+
+		if err != nil {
+			fmt.Fprintf(w, "Vault login failed: %s\n", err)
+		} else {
+			fmt.Fprintf(w, "Vault login successful. You may close this window and return to the CLI.\n")
+		}
+
+		select {
+		case doneCh <- loginResp{secret, err}:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	fmt.Fprintf(stdout, "Complete the login via your OIDC provider. Launching browser to:\n\n    %s\n\n\n", authURL)
+	if err := openURL(authURL); err != nil {
+		fmt.Fprintf(stdout, "Error attempting to automatically open browser: '%s'.\nPlease visit the authorization URL manually.\n", err)
+	}
+
+	select {
+	case s := <-doneCh:
+		if s.err != nil {
+			return nil, s.err
+		}
+		if s.secret == nil {
+			return nil, errors.New("empty response from credential provider")
+		}
+		return s.secret, nil
+	case <-time.After(loginTimeout):
+		return nil, errors.New("timed out waiting for response from provider")
+	}
+}
+
+// fetchAuthURL requests the URL of the OIDC provider to start the login at
+func fetchAuthURL(c *api.Client, role, mount, redirectURI string) (string, error) {
+	data := map[string]interface{}{
+		"role":         role,
+		"redirect_uri": redirectURI,
+	}
+
+	secret, err := c.Logical().Write(fmt.Sprintf("auth/%s/oidc/auth_url", mount), data)
+	if err != nil {
+		return "", err
+	}
+
+	var authURL string
+	if secret != nil {
+		authURL, _ = secret.Data["auth_url"].(string)
+	}
+
+	if authURL == "" {
+		return "", fmt.Errorf("Unable to authorize role %q with redirect_uri %q. Check Vault logs for more information.", role, redirectURI)
+	}
+
+	return authURL, nil
+}
+
+// openURL opens the specified URL in the default browser of the user
+func openURL(url string) error {
+	var cmd string
+	var args []string
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd = "cmd"
+		args = []string{"/c", "start"}
+		url = strings.Replace(url, "&", "^&", -1)
+	case "darwin":
+		cmd = "open"
+	default: // "linux", "freebsd", "openbsd", "netbsd"
+		cmd = "xdg-open"
+	}
+	args = append(args, url)
+	return exec.Command(cmd, args...).Start()
+}
+
+func (h *CLIHandler) Help() string {
+	help := `
+Usage: vault login -method=oidc [CONFIG K=V...]
+
+  The OIDC auth method allows users to authenticate using an OIDC provider.
+  The provider must be configured as part of a role by the operator.
+
+  Authenticate using role "engineering":
+
+      $ vault login -method=oidc role=engineering
+      Complete the login via your OIDC provider. Launching browser to:
+
+          https://accounts.google.com/o/oauth2/v2/...
+
+  The default browser will be opened for the user to complete the login.
+  Alternatively, the user may visit the provided URL directly.
+
+Configuration:
+
+  mount=<string>
+      Path where the OIDC credential method is mounted. This is usually
+      provided via the -path flag in the "vault login" command, but it can be
+      specified here as well. If specified here, it takes precedence over the
+      value for -path. The default value is "oidc".
+
+  role=<string>
+      Vault role of type "oidc" to use for authentication. If not provided,
+      the default role configured on the mount is used.
+
+  listenaddress=<string>
+      Optional address to bind the OIDC callback listener to. The default
+      value is "localhost".
+
+  port=<string>
+      Optional port to bind the OIDC callback listener to. The default value
+      is "8250".
+`
+
+	return strings.TrimSpace(help)
+}
@@ -0,0 +1,284 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/hashicorp/errwrap"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConfig(b *jwtAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: `config`,
+		Fields: map[string]*framework.FieldSchema{
+			"oidc_discovery_url": {
+				Type:        framework.TypeString,
+				Description: `OIDC Discovery URL, without any .well-known component (base path). Cannot be used with "jwks_url" or "jwt_validation_pubkeys".`,
+			},
+			"oidc_discovery_ca_pem": {
+				Type:        framework.TypeString,
+				Description: "The CA certificate or chain of certificates, in PEM format, to use to validate connections to the OIDC Discovery URL. If not set, system certificates are used.",
+			},
+			"oidc_client_id": {
+				Type:        framework.TypeString,
+				Description: "The OAuth Client ID configured with your OIDC provider.",
+			},
+			"oidc_client_secret": {
+				Type:        framework.TypeString,
+				Description: "The OAuth Client Secret configured with your OIDC provider.",
+			},
+			"jwks_url": {
+				Type:        framework.TypeString,
+				Description: `JWKS URL to use to authenticate signatures. Cannot be used with "oidc_discovery_url" or "jwt_validation_pubkeys".`,
+			},
+			"jwks_ca_pem": {
+				Type:        framework.TypeString,
+				Description: "The CA certificate or chain of certificates, in PEM format, to use to validate connections to the JWKS URL. If not set, system certificates are used.",
+			},
+			"jwt_validation_pubkeys": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `A list of PEM-encoded public keys to use to authenticate signatures locally. Cannot be used with "jwks_url" or "oidc_discovery_url".`,
+			},
+			"jwt_supported_algs": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `A list of supported signing algorithms. Defaults to RS256.`,
+			},
+			"bound_issuer": {
+				Type:        framework.TypeString,
+				Description: "The value against which to match the 'iss' claim in a JWT. Optional.",
+			},
+			"default_role": {
+				Type:        framework.TypeString,
+				Description: "The default role to use if none is provided during login. If not set, a role is required during login.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+		},
+
+		HelpSynopsis:    confHelpSyn,
+		HelpDescription: confHelpDesc,
+	}
+}
+
+func (b *jwtAuthBackend) loadConfig(ctx context.Context, s logical.Storage) (*jwtConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result jwtConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *jwtAuthBackend) pathConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"oidc_discovery_url":     config.OIDCDiscoveryURL,
+			"oidc_discovery_ca_pem":  config.OIDCDiscoveryCAPEM,
+			"oidc_client_id":         config.OIDCClientID,
+			"jwks_url":               config.JWKSURL,
+			"jwks_ca_pem":            config.JWKSCAPEM,
+			"jwt_validation_pubkeys": config.JWTValidationPubKeys,
+			"jwt_supported_algs":     config.JWTSupportedAlgs,
+			"bound_issuer":           config.BoundIssuer,
+			"default_role":           config.DefaultRole,
+		},
+	}
+
+	return resp, nil
+}
+
+func (b *jwtAuthBackend) pathConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config := &jwtConfig{
+		OIDCDiscoveryURL:     d.Get("oidc_discovery_url").(string),
+		OIDCDiscoveryCAPEM:   d.Get("oidc_discovery_ca_pem").(string),
+		OIDCClientID:         d.Get("oidc_client_id").(string),
+		OIDCClientSecret:     d.Get("oidc_client_secret").(string),
+		JWKSURL:              d.Get("jwks_url").(string),
+		JWKSCAPEM:            d.Get("jwks_ca_pem").(string),
+		JWTValidationPubKeys: d.Get("jwt_validation_pubkeys").([]string),
+		JWTSupportedAlgs:     d.Get("jwt_supported_algs").([]string),
+		BoundIssuer:          d.Get("bound_issuer").(string),
+		DefaultRole:          d.Get("default_role").(string),
+	}
+
+	// Run checks on values
+	methodCount := 0
+	if config.OIDCDiscoveryURL != "" {
+		methodCount++
+	}
+	if config.JWKSURL != "" {
+		methodCount++
+	}
+	if len(config.JWTValidationPubKeys) != 0 {
+		methodCount++
+	}
+
+	switch {
+	case methodCount != 1:
+		return logical.ErrorResponse("exactly one of 'jwt_validation_pubkeys', 'jwks_url' or 'oidc_discovery_url' must be set"), nil
+
+	case config.OIDCClientID != "" && config.OIDCClientSecret == "",
+		config.OIDCClientID == "" && config.OIDCClientSecret != "":
+		return logical.ErrorResponse("both 'oidc_client_id' and 'oidc_client_secret' must be set for OIDC"), nil
+
+	case config.OIDCClientID != "" && config.OIDCDiscoveryURL == "":
+		return logical.ErrorResponse("'oidc_client_id' and 'oidc_client_secret' require 'oidc_discovery_url' to be set"), nil
+
+	case config.OIDCDiscoveryURL != "":
+		if _, err := b.createProvider(config); err != nil {
+			return logical.ErrorResponse(errwrap.Wrapf("error checking oidc discovery URL: {{err}}", err).Error()), nil
+		}
+
+	case config.JWKSURL != "":
+		if _, err := createCAContext(b.providerCtx, config.JWKSCAPEM); err != nil {
+			return logical.ErrorResponse(errwrap.Wrapf("error checking jwks_ca_pem: {{err}}", err).Error()), nil
+		}
+
+	case len(config.JWTValidationPubKeys) != 0:
+		for _, v := range config.JWTValidationPubKeys {
+			if _, err := certutil.ParsePublicKeyPEM([]byte(v)); err != nil {
+				return logical.ErrorResponse(errwrap.Wrapf("error parsing public key: {{err}}", err).Error()), nil
+			}
+		}
+	}
+
+	for _, a := range config.JWTSupportedAlgs {
+		if !strutil.StrListContains(supportedAlgs, a) {
+			return logical.ErrorResponse("invalid jwt_supported_algs: " + a), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	b.reset()
+
+	return nil, nil
+}
+
+// createProvider creates an OIDC provider from the discovery URL in the
+// configuration, using the configured CA certificates if any
+func (b *jwtAuthBackend) createProvider(config *jwtConfig) (*oidc.Provider, error) {
+	oidcCtx, err := createCAContext(b.providerCtx, config.OIDCDiscoveryCAPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := oidc.NewProvider(oidcCtx, config.OIDCDiscoveryURL)
+	if err != nil {
+		return nil, errwrap.Wrapf("error creating provider with given values: {{err}}", err)
+	}
+
+	return provider, nil
+}
+
+// createCAContext returns a context with a custom TLS client, if caPEM is
+// set, to be used by the OIDC library to contact the provider
+func createCAContext(ctx context.Context, caPEM string) (context.Context, error) {
+	if caPEM == "" {
+		return ctx, nil
+	}
+
+	certPool := x509.NewCertPool()
+	if ok := certPool.AppendCertsFromPEM([]byte(caPEM)); !ok {
+		return nil, errors.New("could not parse 'oidc_discovery_ca_pem' or 'jwks_ca_pem' value successfully")
+	}
+
+	tr := cleanhttp.DefaultPooledTransport()
+	tr.TLSClientConfig = &tls.Config{
+		RootCAs: certPool,
+	}
+	tc := &http.Client{
+		Transport: tr,
+	}
+
+	return oidc.ClientContext(ctx, tc), nil
+}
+
+// authMethod returns how JWTs are validated for a configuration
+func (c *jwtConfig) authMethod() int {
+	switch {
+	case c.OIDCDiscoveryURL != "":
+		return OIDCDiscovery
+	case c.JWKSURL != "":
+		return JWKS
+	case len(c.JWTValidationPubKeys) != 0:
+		return StaticKeys
+	default:
+		return unconfigured
+	}
+}
+
+const (
+	unconfigured = iota
+	StaticKeys
+	JWKS
+	OIDCDiscovery
+)
+
+var supportedAlgs = []string{
+	oidc.RS256, oidc.RS384, oidc.RS512,
+	oidc.ES256, oidc.ES384, oidc.ES512,
+	oidc.PS256, oidc.PS384, oidc.PS512,
+}
+
+type jwtConfig struct {
+	OIDCDiscoveryURL     string   `json:"oidc_discovery_url"`
+	OIDCDiscoveryCAPEM   string   `json:"oidc_discovery_ca_pem"`
+	OIDCClientID         string   `json:"oidc_client_id"`
+	OIDCClientSecret     string   `json:"oidc_client_secret"`
+	JWKSURL              string   `json:"jwks_url"`
+	JWKSCAPEM            string   `json:"jwks_ca_pem"`
+	JWTValidationPubKeys []string `json:"jwt_validation_pubkeys"`
+	JWTSupportedAlgs     []string `json:"jwt_supported_algs"`
+	BoundIssuer          string   `json:"bound_issuer"`
+	DefaultRole          string   `json:"default_role"`
+
+	parsedJWTPubKeys []crypto.PublicKey
+}
+
+const (
+	confHelpSyn = `
+Configures the JWT authentication backend.
+`
+	confHelpDesc = `
+The JWT authentication backend validates JWTs (or OIDC) using the configured
+credentials. If using OIDC Discovery, the URL must be provided, along
+with (optionally) the CA cert to use for the connection. If performing JWT
+validation locally, a set of public keys must be provided.
+`
+)
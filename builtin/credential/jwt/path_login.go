@@ -0,0 +1,353 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	sqjwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func pathLogin(b *jwtAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: `login$`,
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeString,
+				Description: "The role to log in against.",
+			},
+			"jwt": {
+				Type:        framework.TypeString,
+				Description: "The signed JWT to validate.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation:         b.pathLogin,
+			logical.AliasLookaheadOperation: b.pathLogin,
+		},
+
+		HelpSynopsis:    pathLoginHelpSyn,
+		HelpDescription: pathLoginHelpDesc,
+	}
+}
+
+func (b *jwtAuthBackend) pathLogin(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	token := d.Get("jwt").(string)
+	if len(token) == 0 {
+		return logical.ErrorResponse("missing token"), nil
+	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("could not load configuration"), nil
+	}
+
+	roleName := d.Get("role").(string)
+	if roleName == "" {
+		roleName = config.DefaultRole
+	}
+	if roleName == "" {
+		return logical.ErrorResponse("missing role"), nil
+	}
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q could not be found", roleName)), nil
+	}
+
+	if role.RoleType == roleTypeOIDC {
+		return logical.ErrorResponse("role with oidc role_type is not allowed"), nil
+	}
+
+	allClaims, err := b.verifyJWT(ctx, config, role, token)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if err := validateAudience(role.BoundAudiences, claimAudiences(allClaims), true); err != nil {
+		return logical.ErrorResponse(errwrap.Wrapf("error validating claims: {{err}}", err).Error()), nil
+	}
+
+	auth, err := b.createIdentity(allClaims, role, roleName)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if req.Operation == logical.AliasLookaheadOperation {
+		return &logical.Response{
+			Auth: &logical.Auth{
+				Alias: auth.Alias,
+			},
+		}, nil
+	}
+
+	return &logical.Response{
+		Auth: auth,
+	}, nil
+}
+
+func (b *jwtAuthBackend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.Auth == nil {
+		return nil, errors.New("request auth was nil")
+	}
+
+	roleName, ok := req.Auth.InternalData["role"].(string)
+	if !ok {
+		return nil, errors.New("failed to fetch role_name during renewal")
+	}
+
+	// Ensure that the Role still exists.
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to validate role %s during renewal: {{err}}", roleName), err)
+	}
+	if role == nil {
+		return nil, fmt.Errorf("role %s does not exist during renewal", roleName)
+	}
+
+	if !policyutil.EquivalentPolicies(role.Policies, req.Auth.Policies) {
+		return nil, errors.New("policies do not match")
+	}
+
+	resp := &logical.Response{Auth: req.Auth}
+	resp.Auth.TTL = role.TTL
+	resp.Auth.MaxTTL = role.MaxTTL
+	resp.Auth.Period = role.Period
+	return resp, nil
+}
+
+// verifyJWT verifies the signature of a JWT using the configured keys, JWKS
+// URL or OIDC provider, validates its standard claims and the bound claims of
+// the role, and returns all of its claims. The audience is validated by the
+// caller since the rules differ between the JWT and OIDC login flows.
+func (b *jwtAuthBackend) verifyJWT(ctx context.Context, config *jwtConfig, role *jwtRole, token string) (map[string]interface{}, error) {
+	parsedJWT, err := sqjwt.ParseSigned(token)
+	if err != nil {
+		return nil, errwrap.Wrapf("error parsing token: {{err}}", err)
+	}
+
+	supportedAlgs := config.JWTSupportedAlgs
+	if len(supportedAlgs) == 0 {
+		supportedAlgs = []string{oidc.RS256}
+	}
+	for _, header := range parsedJWT.Headers {
+		if !strutil.StrListContains(supportedAlgs, header.Algorithm) {
+			return nil, fmt.Errorf("token is signed with unsupported algorithm %q", header.Algorithm)
+		}
+	}
+
+	claims := sqjwt.Claims{}
+	allClaims := make(map[string]interface{})
+
+	switch config.authMethod() {
+	case StaticKeys:
+		valid := false
+		for _, key := range config.parsedJWTPubKeys {
+			if err := parsedJWT.Claims(key, &claims, &allClaims); err == nil {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, errors.New("no known key successfully validated the token signature")
+		}
+
+	case JWKS:
+		keySet, err := b.getKeySet(config)
+		if err != nil {
+			return nil, errwrap.Wrapf("error fetching jwks keyset: {{err}}", err)
+		}
+
+		payload, err := keySet.VerifySignature(ctx, token)
+		if err != nil {
+			return nil, errwrap.Wrapf("error verifying token signature: {{err}}", err)
+		}
+
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			return nil, errwrap.Wrapf("error parsing token claims: {{err}}", err)
+		}
+		if err := json.Unmarshal(payload, &allClaims); err != nil {
+			return nil, errwrap.Wrapf("error parsing token claims: {{err}}", err)
+		}
+
+	case OIDCDiscovery:
+		provider, err := b.getProvider(config)
+		if err != nil {
+			return nil, errwrap.Wrapf("error getting provider for login operation: {{err}}", err)
+		}
+
+		verifier := provider.Verifier(&oidc.Config{
+			SkipClientIDCheck:    true,
+			SupportedSigningAlgs: supportedAlgs,
+		})
+
+		idToken, err := verifier.Verify(ctx, token)
+		if err != nil {
+			return nil, errwrap.Wrapf("error validating signature: {{err}}", err)
+		}
+
+		if err := idToken.Claims(&claims); err != nil {
+			return nil, errwrap.Wrapf("error parsing token claims: {{err}}", err)
+		}
+		if err := idToken.Claims(&allClaims); err != nil {
+			return nil, errwrap.Wrapf("error parsing token claims: {{err}}", err)
+		}
+
+	default:
+		return nil, errors.New("unhandled case during login")
+	}
+
+	if claims.Expiry == 0 {
+		return nil, errors.New("token is missing the exp claim")
+	}
+
+	expected := sqjwt.Expected{
+		Issuer:  config.BoundIssuer,
+		Subject: role.BoundSubject,
+		Time:    time.Now(),
+	}
+	if err := claims.ValidateWithLeeway(expected, claimDefaultLeeway*time.Second); err != nil {
+		return nil, errwrap.Wrapf("error validating claims: {{err}}", err)
+	}
+
+	if err := validateBoundClaims(role.BoundClaims, allClaims); err != nil {
+		return nil, errwrap.Wrapf("error validating claims: {{err}}", err)
+	}
+
+	return allClaims, nil
+}
+
+// createIdentity creates the auth for a login from the claims of a verified
+// token, using the user and groups claims of the role for the identity
+// aliases
+func (b *jwtAuthBackend) createIdentity(allClaims map[string]interface{}, role *jwtRole, roleName string) (*logical.Auth, error) {
+	userClaimRaw := getClaim(allClaims, role.UserClaim)
+	if userClaimRaw == nil {
+		return nil, fmt.Errorf("claim %q not found in token", role.UserClaim)
+	}
+	userName, ok := userClaimRaw.(string)
+	if !ok {
+		return nil, fmt.Errorf("claim %q could not be converted to string", role.UserClaim)
+	}
+
+	metadata, err := extractMetadata(allClaims, role.ClaimMappings)
+	if err != nil {
+		return nil, err
+	}
+	metadata["role"] = roleName
+
+	var groupAliases []*logical.Alias
+	if role.GroupsClaim != "" {
+		groupsClaimRaw := getClaim(allClaims, role.GroupsClaim)
+		if groupsClaimRaw == nil {
+			return nil, fmt.Errorf("%q claim not found in token", role.GroupsClaim)
+		}
+
+		groups, err := boundClaimValues(groupsClaimRaw)
+		if err != nil {
+			return nil, fmt.Errorf("%q claim could not be converted to a list of strings", role.GroupsClaim)
+		}
+		for _, group := range groups {
+			groupAliases = append(groupAliases, &logical.Alias{
+				Name: group,
+			})
+		}
+	}
+
+	return &logical.Auth{
+		Policies:    role.Policies,
+		DisplayName: userName,
+		Period:      role.Period,
+		NumUses:     role.NumUses,
+		Alias: &logical.Alias{
+			Name: userName,
+		},
+		GroupAliases: groupAliases,
+		InternalData: map[string]interface{}{
+			"role": roleName,
+		},
+		Metadata: metadata,
+		LeaseOptions: logical.LeaseOptions{
+			Renewable: true,
+			TTL:       role.TTL,
+			MaxTTL:    role.MaxTTL,
+		},
+	}, nil
+}
+
+// claimAudiences returns the audiences of the "aud" claim, which may be a
+// single string or a list of strings
+func claimAudiences(allClaims map[string]interface{}) []string {
+	aud, ok := allClaims["aud"]
+	if !ok {
+		return nil
+	}
+	audiences, err := boundClaimValues(aud)
+	if err != nil {
+		return nil
+	}
+	return audiences
+}
+
+// getProvider returns the OIDC provider for the configuration, creating it
+// if it hasn't been created yet
+func (b *jwtAuthBackend) getProvider(config *jwtConfig) (*oidc.Provider, error) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if b.provider != nil {
+		return b.provider, nil
+	}
+
+	provider, err := b.createProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	b.provider = provider
+	return provider, nil
+}
+
+// getKeySet returns the JWKS key set for the configuration, creating it if it
+// hasn't been created yet
+func (b *jwtAuthBackend) getKeySet(config *jwtConfig) (oidc.KeySet, error) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if b.keySet != nil {
+		return b.keySet, nil
+	}
+
+	ctx, err := createCAContext(b.providerCtx, config.JWKSCAPEM)
+	if err != nil {
+		return nil, errwrap.Wrapf("could not parse JWKS CA PEM value successfully: {{err}}", err)
+	}
+
+	b.keySet = oidc.NewRemoteKeySet(ctx, config.JWKSURL)
+
+	return b.keySet, nil
+}
+
+const (
+	pathLoginHelpSyn = `
+	Authenticates to Vault using a JWT (or OIDC) token.
+	`
+	pathLoginHelpDesc = `
+Authenticates JWTs.
+`
+)
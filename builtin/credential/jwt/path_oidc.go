@@ -0,0 +1,297 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"golang.org/x/oauth2"
+)
+
+// oidcState is the state of an OIDC login between the request for the
+// authorization URL and the callback from the provider
+type oidcState struct {
+	rolename    string
+	nonce       string
+	redirectURI string
+}
+
+func pathOIDC(b *jwtAuthBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: `oidc/callback`,
+			Fields: map[string]*framework.FieldSchema{
+				"state": {
+					Type:        framework.TypeString,
+					Description: "The state returned by the OIDC provider.",
+				},
+				"code": {
+					Type:        framework.TypeString,
+					Description: "The authorization code returned by the OIDC provider.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.pathCallback,
+				logical.UpdateOperation: b.pathCallback,
+			},
+
+			HelpSynopsis:    pathOIDCCallbackHelpSyn,
+			HelpDescription: pathOIDCCallbackHelpDesc,
+		},
+		{
+			Pattern: `oidc/auth_url`,
+			Fields: map[string]*framework.FieldSchema{
+				"role": {
+					Type:        framework.TypeString,
+					Description: "The role to issue an OIDC authorization URL against.",
+				},
+				"redirect_uri": {
+					Type:        framework.TypeString,
+					Description: "The OAuth redirect_uri to use in the authorization URL.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.authURL,
+			},
+
+			HelpSynopsis:    pathOIDCAuthURLHelpSyn,
+			HelpDescription: pathOIDCAuthURLHelpDesc,
+		},
+	}
+}
+
+func (b *jwtAuthBackend) pathCallback(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("could not load configuration"), nil
+	}
+
+	state := b.verifyState(d.Get("state").(string))
+	if state == nil {
+		return logical.ErrorResponse("expired or missing OAuth state"), nil
+	}
+
+	roleName := state.rolename
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q could not be found", roleName)), nil
+	}
+
+	code := d.Get("code").(string)
+	if code == "" {
+		return logical.ErrorResponse("OAuth code parameter not provided"), nil
+	}
+
+	provider, err := b.getProvider(config)
+	if err != nil {
+		return nil, errwrap.Wrapf("error getting provider for login operation: {{err}}", err)
+	}
+
+	oidcCtx, err := createCAContext(b.providerCtx, config.OIDCDiscoveryCAPEM)
+	if err != nil {
+		return nil, errwrap.Wrapf("error preparing context for login operation: {{err}}", err)
+	}
+
+	oauth2Config := b.oauth2Config(config, provider, role, state.redirectURI)
+
+	oauth2Token, err := oauth2Config.Exchange(oidcCtx, code)
+	if err != nil {
+		return logical.ErrorResponse(errwrap.Wrapf("error exchanging oidc code: {{err}}", err).Error()), nil
+	}
+
+	// Extract the ID Token from OAuth2 token.
+	rawToken, ok := oauth2Token.Extra("id_token").(string)
+	if !ok {
+		return logical.ErrorResponse("no id_token found in response"), nil
+	}
+
+	// The ID token must have been issued to our client, and in response to
+	// the request started by oidc/auth_url
+	verifier := provider.Verifier(&oidc.Config{
+		ClientID:             config.OIDCClientID,
+		SupportedSigningAlgs: config.JWTSupportedAlgs,
+	})
+	idToken, err := verifier.Verify(oidcCtx, rawToken)
+	if err != nil {
+		return logical.ErrorResponse(errwrap.Wrapf("error validating signature: {{err}}", err).Error()), nil
+	}
+	if idToken.Nonce != state.nonce {
+		return logical.ErrorResponse("invalid ID token nonce"), nil
+	}
+
+	allClaims, err := b.verifyJWT(oidcCtx, config, role, rawToken)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if err := validateAudience(role.BoundAudiences, claimAudiences(allClaims), false); err != nil {
+		return logical.ErrorResponse(errwrap.Wrapf("error validating claims: {{err}}", err).Error()), nil
+	}
+
+	auth, err := b.createIdentity(allClaims, role, roleName)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	return &logical.Response{
+		Auth: auth,
+	}, nil
+}
+
+// authURL returns a URL used for redirection to receive an authorization code.
+// This path requires a role name, or that a default_role has been configured.
+// Because this endpoint is unauthenticated, the response to invalid or non-OIDC
+// roles is intentionally non-descriptive and will simply be an empty string.
+func (b *jwtAuthBackend) authURL(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	logger := b.Logger()
+
+	// default response for most error/invalid conditions
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"auth_url": "",
+		},
+	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("could not load configuration"), nil
+	}
+
+	if config.authMethod() != OIDCDiscovery || config.OIDCClientID == "" {
+		return logical.ErrorResponse("OIDC login is not configured for this mount"), nil
+	}
+
+	roleName := d.Get("role").(string)
+	if roleName == "" {
+		roleName = config.DefaultRole
+	}
+	if roleName == "" {
+		return logical.ErrorResponse("missing role"), nil
+	}
+
+	redirectURI := d.Get("redirect_uri").(string)
+	if redirectURI == "" {
+		return logical.ErrorResponse("missing redirect_uri"), nil
+	}
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil || role.RoleType != roleTypeOIDC {
+		logger.Warn("invalid role for OIDC login", "role", roleName)
+		return resp, nil
+	}
+
+	if !strutil.StrListContains(role.AllowedRedirectURIs, redirectURI) {
+		logger.Warn("unauthorized redirect_uri", "redirect_uri", redirectURI)
+		return resp, nil
+	}
+
+	provider, err := b.getProvider(config)
+	if err != nil {
+		logger.Warn("error getting provider for login operation", "error", err)
+		return resp, nil
+	}
+
+	stateID, nonce, err := b.createState(roleName, redirectURI)
+	if err != nil {
+		logger.Warn("error generating OAuth state", "error", err)
+		return resp, nil
+	}
+
+	oauth2Config := b.oauth2Config(config, provider, role, redirectURI)
+
+	resp.Data["auth_url"] = oauth2Config.AuthCodeURL(stateID, oidc.Nonce(nonce))
+
+	return resp, nil
+}
+
+// oauth2Config returns the OAuth2 configuration used to request and exchange
+// authorization codes for a role
+func (b *jwtAuthBackend) oauth2Config(config *jwtConfig, provider *oidc.Provider, role *jwtRole, redirectURI string) *oauth2.Config {
+	scopes := []string{oidc.ScopeOpenID}
+	for _, scope := range role.OIDCScopes {
+		if scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &oauth2.Config{
+		ClientID:     config.OIDCClientID,
+		ClientSecret: config.OIDCClientSecret,
+		RedirectURL:  redirectURI,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+// createState makes a state and nonce for an OIDC login and caches them
+// until the callback
+func (b *jwtAuthBackend) createState(rolename, redirectURI string) (string, string, error) {
+	stateID, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", "", err
+	}
+
+	b.oidcStates.SetDefault(stateID, &oidcState{
+		rolename:    rolename,
+		nonce:       nonce,
+		redirectURI: redirectURI,
+	})
+
+	return stateID, nonce, nil
+}
+
+// verifyState tests whether the provided state ID is valid and returns the
+// associated state object if so. A nil state is returned if the ID is not
+// found or expired. The state should only ever be retrieved once and is
+// deleted as part of this request.
+func (b *jwtAuthBackend) verifyState(stateID string) *oidcState {
+	defer b.oidcStates.Delete(stateID)
+
+	if stateRaw, ok := b.oidcStates.Get(stateID); ok {
+		return stateRaw.(*oidcState)
+	}
+
+	return nil
+}
+
+const (
+	pathOIDCCallbackHelpSyn = `
+Callback endpoint to complete an OIDC login.
+`
+	pathOIDCCallbackHelpDesc = `
+Exchanges the authorization code returned by the OIDC provider for an ID
+token, validates it and returns a Vault token.
+`
+	pathOIDCAuthURLHelpSyn = `
+Request an authorization URL to start an OIDC login flow.
+`
+	pathOIDCAuthURLHelpDesc = `
+Returns the URL of the OIDC provider to redirect the user to, for the given
+role and redirect_uri. The redirect_uri must be one of the allowed redirect
+URIs of the role.
+`
+)
@@ -0,0 +1,420 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+var reservedMetadata = []string{"role"}
+
+const (
+	claimDefaultLeeway = 150
+	roleTypeJWT        = "jwt"
+	roleTypeOIDC       = "oidc"
+)
+
+func pathRoleList(b *jwtAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: strings.TrimSuffix(rolePrefix, "/") + "/?",
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+		HelpSynopsis:    strings.TrimSpace(roleHelp["role-list"][0]),
+		HelpDescription: strings.TrimSpace(roleHelp["role-list"][1]),
+	}
+}
+
+// pathRole returns the path configurations for the CRUD operations on roles
+func pathRole(b *jwtAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: rolePrefix + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+			"role_type": {
+				Type:        framework.TypeString,
+				Description: `Type of the role, either "jwt" or "oidc". Defaults to "oidc".`,
+			},
+			"policies": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "List of policies on the role.",
+			},
+			"num_uses": {
+				Type:        framework.TypeInt,
+				Description: `Number of times issued tokens can be used`,
+			},
+			"ttl": {
+				Type: framework.TypeDurationSecond,
+				Description: `Duration in seconds after which the issued token should expire. Defaults
+to 0, in which case the value will fall back to the system/mount defaults.`,
+			},
+			"max_ttl": {
+				Type: framework.TypeDurationSecond,
+				Description: `Duration in seconds after which the issued token should not be allowed to
+be renewed. Defaults to 0, in which case the value will fall back to the system/mount defaults.`,
+			},
+			"period": {
+				Type: framework.TypeDurationSecond,
+				Description: `If set, indicates that the token generated using this role
+should never expire. The token should be renewed within the
+duration specified by this value. At each renewal, the token's
+TTL will be set to the value of this parameter.`,
+			},
+			"bound_audiences": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of 'aud' claims that are valid for login; any match is sufficient`,
+			},
+			"bound_subject": {
+				Type:        framework.TypeString,
+				Description: `The 'sub' claim that is valid for login. Optional.`,
+			},
+			"bound_claims": {
+				Type:        framework.TypeMap,
+				Description: `Map of claims and values which must match for login. A value may be a single string or a list of strings, in which case any of them is accepted.`,
+			},
+			"claim_mappings": {
+				Type:        framework.TypeKVPairs,
+				Description: `Mappings of claims (key) that will be copied to a metadata field (value)`,
+			},
+			"user_claim": {
+				Type:        framework.TypeString,
+				Description: `The claim to use for the Identity entity alias name`,
+			},
+			"groups_claim": {
+				Type:        framework.TypeString,
+				Description: `The claim to use for the Identity group alias names`,
+			},
+			"oidc_scopes": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of OIDC scopes, in addition to "openid", to request during the OIDC login flow`,
+			},
+			"allowed_redirect_uris": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of allowed values for redirect_uri`,
+			},
+		},
+		ExistenceCheck: b.pathRoleExistenceCheck,
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.CreateOperation: b.pathRoleCreateUpdate,
+			logical.UpdateOperation: b.pathRoleCreateUpdate,
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+		HelpSynopsis:    strings.TrimSpace(roleHelp["role"][0]),
+		HelpDescription: strings.TrimSpace(roleHelp["role"][1]),
+	}
+}
+
+type jwtRole struct {
+	RoleType string `json:"role_type"`
+
+	// Policies that are to be required by the token to access this role
+	Policies []string `json:"policies"`
+
+	// TokenNumUses defines the number of allowed uses of the token issued
+	NumUses int `json:"num_uses"`
+
+	// Duration before which an issued token must be renewed
+	TTL time.Duration `json:"ttl"`
+
+	// Duration after which an issued token should not be allowed to be renewed
+	MaxTTL time.Duration `json:"max_ttl"`
+
+	// Period, if set, indicates that the token generated using this role
+	// should never expire. The token should be renewed within the duration
+	// specified by this value. The renewal duration will be fixed if the
+	// value is not modified on the role. If the `Period` in the role is modified,
+	// a token will pick up the new value during its next renewal.
+	Period time.Duration `json:"period"`
+
+	// Role binding properties
+	BoundAudiences      []string               `json:"bound_audiences"`
+	BoundSubject        string                 `json:"bound_subject"`
+	BoundClaims         map[string]interface{} `json:"bound_claims"`
+	ClaimMappings       map[string]string      `json:"claim_mappings"`
+	UserClaim           string                 `json:"user_claim"`
+	GroupsClaim         string                 `json:"groups_claim"`
+	OIDCScopes          []string               `json:"oidc_scopes"`
+	AllowedRedirectURIs []string               `json:"allowed_redirect_uris"`
+}
+
+// role takes a storage backend and the name and returns the role's storage
+// entry
+func (b *jwtAuthBackend) role(ctx context.Context, s logical.Storage, name string) (*jwtRole, error) {
+	raw, err := s.Get(ctx, rolePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	role := new(jwtRole)
+	if err := raw.DecodeJSON(role); err != nil {
+		return nil, err
+	}
+
+	// Roles stored without a type predate JWT-only roles
+	if role.RoleType == "" {
+		role.RoleType = roleTypeJWT
+	}
+
+	return role, nil
+}
+
+// pathRoleExistenceCheck returns whether the role with the given name exists
+// or not.
+func (b *jwtAuthBackend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+// pathRoleList is used to list all the Roles registered with the backend.
+func (b *jwtAuthBackend) pathRoleList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roles, err := req.Storage.List(ctx, rolePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(roles), nil
+}
+
+// pathRoleRead grabs a read lock and reads the options set on the role from
+// the storage
+func (b *jwtAuthBackend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	// Create a map of data to be returned
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"role_type":             role.RoleType,
+			"policies":              role.Policies,
+			"num_uses":              role.NumUses,
+			"period":                int64(role.Period.Seconds()),
+			"ttl":                   int64(role.TTL.Seconds()),
+			"max_ttl":               int64(role.MaxTTL.Seconds()),
+			"bound_audiences":       role.BoundAudiences,
+			"bound_subject":         role.BoundSubject,
+			"bound_claims":          role.BoundClaims,
+			"claim_mappings":        role.ClaimMappings,
+			"user_claim":            role.UserClaim,
+			"groups_claim":          role.GroupsClaim,
+			"oidc_scopes":           role.OIDCScopes,
+			"allowed_redirect_uris": role.AllowedRedirectURIs,
+		},
+	}
+
+	return resp, nil
+}
+
+// pathRoleDelete removes the role from storage
+func (b *jwtAuthBackend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("role name required"), nil
+	}
+
+	// Delete the role itself
+	if err := req.Storage.Delete(ctx, rolePrefix+roleName); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// pathRoleCreateUpdate registers a new role with the backend or updates the
+// options of an existing role
+func (b *jwtAuthBackend) pathRoleCreateUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role name"), nil
+	}
+
+	// Check if the role already exists
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a new entry object if this is a CreateOperation
+	if role == nil {
+		if req.Operation == logical.UpdateOperation {
+			return nil, errors.New("role entry not found during update operation")
+		}
+		role = new(jwtRole)
+	}
+
+	if roleType, ok := data.GetOk("role_type"); ok {
+		role.RoleType = roleType.(string)
+	} else if req.Operation == logical.CreateOperation {
+		role.RoleType = roleTypeOIDC
+	}
+	switch role.RoleType {
+	case roleTypeJWT, roleTypeOIDC:
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("invalid 'role_type': %s", role.RoleType)), nil
+	}
+
+	if policiesRaw, ok := data.GetOk("policies"); ok {
+		role.Policies = policyutil.ParsePolicies(policiesRaw)
+	}
+
+	periodRaw, ok := data.GetOk("period")
+	if ok {
+		role.Period = time.Duration(periodRaw.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		role.Period = time.Duration(data.Get("period").(int)) * time.Second
+	}
+	if role.Period > b.System().MaxLeaseTTL() {
+		return logical.ErrorResponse(fmt.Sprintf("'period' of '%q' is greater than the backend's maximum lease TTL of '%q'", role.Period.String(), b.System().MaxLeaseTTL().String())), nil
+	}
+
+	if tokenNumUsesRaw, ok := data.GetOk("num_uses"); ok {
+		role.NumUses = tokenNumUsesRaw.(int)
+	} else if req.Operation == logical.CreateOperation {
+		role.NumUses = data.Get("num_uses").(int)
+	}
+	if role.NumUses < 0 {
+		return logical.ErrorResponse("num_uses cannot be negative"), nil
+	}
+
+	if tokenTTLRaw, ok := data.GetOk("ttl"); ok {
+		role.TTL = time.Duration(tokenTTLRaw.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		role.TTL = time.Duration(data.Get("ttl").(int)) * time.Second
+	}
+
+	if tokenMaxTTLRaw, ok := data.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(tokenMaxTTLRaw.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		role.MaxTTL = time.Duration(data.Get("max_ttl").(int)) * time.Second
+	}
+
+	if boundAudiences, ok := data.GetOk("bound_audiences"); ok {
+		role.BoundAudiences = boundAudiences.([]string)
+	}
+
+	if boundSubject, ok := data.GetOk("bound_subject"); ok {
+		role.BoundSubject = boundSubject.(string)
+	}
+
+	if boundClaims, ok := data.GetOk("bound_claims"); ok {
+		role.BoundClaims = boundClaims.(map[string]interface{})
+		for k, v := range role.BoundClaims {
+			if _, err := boundClaimValues(v); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid value for bound_claims key %q: %v", k, err)), nil
+			}
+		}
+	}
+
+	if claimMappings, ok := data.GetOk("claim_mappings"); ok {
+		claimMappings := claimMappings.(map[string]string)
+
+		// sanity check mappings for duplicates and collision with reserved names
+		targets := make(map[string]bool)
+		for _, metadataKey := range claimMappings {
+			for _, reserved := range reservedMetadata {
+				if metadataKey == reserved {
+					return logical.ErrorResponse(
+						fmt.Sprintf("metadata key '%s' is reserved and may not be a mapping destination", reserved)), nil
+				}
+			}
+
+			if targets[metadataKey] {
+				return logical.ErrorResponse(fmt.Sprintf("multiple keys are mapped to metadata key '%s'", metadataKey)), nil
+			}
+			targets[metadataKey] = true
+		}
+
+		role.ClaimMappings = claimMappings
+	}
+
+	if userClaim, ok := data.GetOk("user_claim"); ok {
+		role.UserClaim = userClaim.(string)
+	}
+	if role.UserClaim == "" {
+		return logical.ErrorResponse("a user claim must be defined on the role"), nil
+	}
+
+	if groupsClaim, ok := data.GetOk("groups_claim"); ok {
+		role.GroupsClaim = groupsClaim.(string)
+	}
+
+	if oidcScopes, ok := data.GetOk("oidc_scopes"); ok {
+		role.OIDCScopes = oidcScopes.([]string)
+	}
+
+	if allowedRedirectURIs, ok := data.GetOk("allowed_redirect_uris"); ok {
+		role.AllowedRedirectURIs = allowedRedirectURIs.([]string)
+	}
+
+	if role.RoleType == roleTypeOIDC && len(role.AllowedRedirectURIs) == 0 {
+		return logical.ErrorResponse("'allowed_redirect_uris' must be set if 'role_type' is 'oidc'"), nil
+	}
+
+	// OIDC verification will enforce that the audience match the configured
+	// client_id. For other methods, require at least one bound constraint.
+	if role.RoleType != roleTypeOIDC &&
+		len(role.BoundAudiences) == 0 &&
+		len(role.BoundClaims) == 0 &&
+		role.BoundSubject == "" {
+		return logical.ErrorResponse("must have at least one bound constraint when creating/updating a role"), nil
+	}
+
+	resp := &logical.Response{}
+	if role.MaxTTL > b.System().MaxLeaseTTL() {
+		resp.AddWarning("max_ttl is greater than the system or backend mount's maximum TTL value; issued tokens' max TTL value will be truncated")
+	}
+
+	// Store the entry.
+	entry, err := logical.StorageEntryJSON(rolePrefix+roleName, role)
+	if err != nil {
+		return nil, err
+	}
+	if err = req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Warnings) == 0 {
+		return nil, nil
+	}
+
+	return resp, nil
+}
+
+// roleHelp maps the path names to their help text
+var roleHelp = map[string][2]string{
+	"role-list": {
+		"Lists all the roles registered with the backend.",
+		"The list will contain the names of the roles.",
+	},
+	"role": {
+		"Register a role with the backend.",
+		`A role is required to authenticate with this backend. The role binds
+		JWT token information with token policies and settings.
+		The bindings, token polices and token settings can all be configured
+		using this endpoint`,
+	},
+}
@@ -0,0 +1,72 @@
+package kerberos
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/helper/ldaputil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+type backend struct {
+	*framework.Backend
+
+	// ldapPool holds connections to the LDAP server used for group lookups
+	ldapPool ldaputil.Pool
+}
+
+func (b *backend) cleanup(ctx context.Context) {
+	b.ldapPool.Close()
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: backendHelp,
+
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				"login",
+			},
+
+			SealWrapStorage: []string{
+				"config",
+				"config/ldap",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			pathConfigLdap(&b),
+			pathGroups(&b),
+			pathGroupsList(&b),
+			pathLogin(&b),
+		},
+
+		AuthRenew:   b.pathLoginRenew,
+		Clean:       b.cleanup,
+		BackendType: logical.TypeCredential,
+	}
+
+	return &b
+}
+
+const backendHelp = `
+The Kerberos credential provider allows authentication using SPNEGO.
+
+Clients send a Kerberos service ticket for the configured service principal in
+the "Authorization: Negotiate" header of a login request. Vault validates the
+ticket against the configured keytab and, if an LDAP server is configured
+under "config/ldap", maps the user's LDAP groups to policies.
+
+Because request headers are not passed to auth methods by default, the mount
+must be tuned with "passthrough_request_headers=Authorization".
+`
@@ -0,0 +1,292 @@
+package kerberos
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/jcmturner/gokrb5/v8/types"
+)
+
+const (
+	testRealm   = "EXAMPLE.COM"
+	testService = "HTTP/vault.example.com"
+)
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b.(*backend), config.StorageView
+}
+
+func testKeytab(t *testing.T) *keytab.Keytab {
+	kt := keytab.New()
+	if err := kt.AddEntry(testService, testRealm, "service-password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96); err != nil {
+		t.Fatal(err)
+	}
+	return kt
+}
+
+func encodeKeytab(t *testing.T, kt *keytab.Keytab) string {
+	raw, err := kt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// testNegotiateHeader issues a service ticket for username directly from the
+// service keytab, standing in for a KDC, and wraps it in a SPNEGO token.
+func testNegotiateHeader(t *testing.T, kt *keytab.Keytab, username string) string {
+	return testNegotiateHeaderRealm(t, kt, username, testRealm)
+}
+
+// testNegotiateHeaderRealm is testNegotiateHeader for a user of the given
+// realm
+func testNegotiateHeaderRealm(t *testing.T, kt *keytab.Keytab, username, realm string) string {
+	cname := types.NewPrincipalName(nametype.KRB_NT_PRINCIPAL, username)
+	sname := types.NewPrincipalName(nametype.KRB_NT_SRV_INST, testService)
+
+	now := time.Now().UTC()
+	tkt, key, err := messages.NewTicket(cname, realm, sname, testRealm, types.NewKrbFlags(), kt,
+		etypeID.AES256_CTS_HMAC_SHA1_96, 1, now, now, now.Add(time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := client.NewWithPassword(username, realm, "unused", config.New())
+	negTokenInit, err := spnego.NewNegTokenInitKRB5(cl, tkt, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := spnego.SPNEGOToken{
+		Init:         true,
+		NegTokenInit: negTokenInit,
+	}
+	raw, err := token.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return negotiatePrefix + base64.StdEncoding.EncodeToString(raw)
+}
+
+func writeConfig(t *testing.T, b *backend, storage logical.Storage, kt *keytab.Keytab) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"keytab":          encodeKeytab(t, kt),
+			"service_account": testService,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+}
+
+func TestBackend_Config(t *testing.T) {
+	b, storage := getBackend(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"keytab":          base64.StdEncoding.EncodeToString([]byte("not a keytab")),
+			"service_account": testService,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected invalid keytab to be rejected, got %#v", resp)
+	}
+
+	writeConfig(t, b, storage, testKeytab(t))
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	expected := map[string]interface{}{
+		"service_account": testService,
+		"allowed_realms":  []string{},
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: expected %#v, got %#v", expected, resp.Data)
+	}
+}
+
+func TestBackend_Login(t *testing.T) {
+	b, storage := getBackend(t)
+	kt := testKeytab(t)
+	writeConfig(t, b, storage, kt)
+
+	// Missing header
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "login",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() || !strings.Contains(resp.Data["error"].(string), "passthrough_request_headers") {
+		t.Fatalf("expected missing header error, got %#v", resp)
+	}
+
+	// Ticket encrypted for a different service key
+	otherKt := keytab.New()
+	if err := otherKt.AddEntry(testService, testRealm, "other-password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "login",
+		Storage:   storage,
+		Headers: map[string][]string{
+			"Authorization": []string{testNegotiateHeader(t, otherKt, "alice")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected ticket for wrong key to be rejected, got %#v", resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "login",
+		Storage:   storage,
+		Headers: map[string][]string{
+			"authorization": []string{testNegotiateHeader(t, kt, "alice")},
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Auth == nil {
+		t.Fatal("expected auth in response")
+	}
+	if resp.Auth.Alias.Name != "alice" || resp.Auth.Metadata["domain"] != testRealm {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+}
+
+func TestBackend_LoginRealms(t *testing.T) {
+	b, storage := getBackend(t)
+	kt := testKeytab(t)
+	writeConfig(t, b, storage, kt)
+
+	login := func(realm string) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "login",
+			Storage:   storage,
+			Headers: map[string][]string{
+				"Authorization": []string{testNegotiateHeaderRealm(t, kt, "alice", realm)},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// By default only users of the service principal's realm can log in
+	if resp := login(testRealm); resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp := login("OTHER.COM"); resp == nil || !resp.IsError() {
+		t.Fatalf("expected other realm to be rejected, got %#v", resp)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"keytab":          encodeKeytab(t, kt),
+			"service_account": testService,
+			"allowed_realms":  "OTHER.COM",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	resp = login("OTHER.COM")
+	if resp == nil || resp.IsError() || resp.Auth.Metadata["domain"] != "OTHER.COM" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp := login(testRealm); resp == nil || !resp.IsError() {
+		t.Fatalf("expected realm missing from allowed_realms to be rejected, got %#v", resp)
+	}
+}
+
+func TestBackend_Groups(t *testing.T) {
+	b, storage := getBackend(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "groups/Admins",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"policies": "foo,bar",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	// Without case sensitive LDAP config, group names are lowercased
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "groups/",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"admins"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "groups/ADMINS",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["policies"], []string{"bar", "foo"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
@@ -0,0 +1,156 @@
+package kerberos
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/api"
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+type CLIHandler struct{}
+
+func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, error) {
+	mount, ok := m["mount"]
+	if !ok {
+		mount = "kerberos"
+	}
+
+	username := m["username"]
+	if username == "" {
+		return nil, fmt.Errorf("'username' must be specified")
+	}
+	realm := m["realm"]
+	if realm == "" {
+		return nil, fmt.Errorf("'realm' must be specified")
+	}
+	service := m["service"]
+	if service == "" {
+		return nil, fmt.Errorf("'service' must be specified")
+	}
+	keytabPath := m["keytab_path"]
+	if keytabPath == "" {
+		return nil, fmt.Errorf("'keytab_path' must be specified")
+	}
+	krb5ConfPath, ok := m["krb5conf_path"]
+	if !ok {
+		krb5ConfPath = "/etc/krb5.conf"
+	}
+
+	header, err := negotiateHeader(username, realm, service, keytabPath, krb5ConfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the login request carries the ticket
+	headers := c.Headers()
+	defer c.SetHeaders(headers)
+
+	loginHeaders := make(http.Header)
+	for k, v := range headers {
+		loginHeaders[k] = v
+	}
+	loginHeaders.Set("Authorization", header)
+	c.SetHeaders(loginHeaders)
+
+	path := fmt.Sprintf("auth/%s/login", mount)
+	secret, err := c.Logical().Write(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("empty response from credential provider")
+	}
+
+	return secret, nil
+}
+
+// negotiateHeader obtains a service ticket for the given service principal
+// using the keytab and returns it as a SPNEGO Authorization header value.
+func negotiateHeader(username, realm, service, keytabPath, krb5ConfPath string) (string, error) {
+	krb5Conf, err := config.Load(krb5ConfPath)
+	if err != nil {
+		return "", errwrap.Wrapf("error loading krb5.conf: {{err}}", err)
+	}
+
+	kt, err := keytab.Load(keytabPath)
+	if err != nil {
+		return "", errwrap.Wrapf("error loading keytab: {{err}}", err)
+	}
+
+	cl := client.NewWithKeytab(username, realm, kt, krb5Conf, client.DisablePAFXFAST(true))
+	defer cl.Destroy()
+	if err := cl.Login(); err != nil {
+		return "", errwrap.Wrapf("error logging in to Kerberos: {{err}}", err)
+	}
+
+	tkt, key, err := cl.GetServiceTicket(service)
+	if err != nil {
+		return "", errwrap.Wrapf("error getting service ticket: {{err}}", err)
+	}
+
+	negTokenInit, err := spnego.NewNegTokenInitKRB5(cl, tkt, key)
+	if err != nil {
+		return "", errwrap.Wrapf("error creating SPNEGO token: {{err}}", err)
+	}
+	token := spnego.SPNEGOToken{
+		Init:         true,
+		NegTokenInit: negTokenInit,
+	}
+	raw, err := token.Marshal()
+	if err != nil {
+		return "", errwrap.Wrapf("error marshaling SPNEGO token: {{err}}", err)
+	}
+
+	return negotiatePrefix + base64.StdEncoding.EncodeToString(raw), nil
+}
+
+func (h *CLIHandler) Help() string {
+	help := `
+Usage: vault login -method=kerberos [CONFIG K=V...]
+
+  The Kerberos auth method allows users to authenticate with a Kerberos
+  service ticket sent as a SPNEGO token.
+
+  Authenticate using a keytab for the user "alice":
+
+      $ vault login -method=kerberos \
+          username=alice \
+          realm=EXAMPLE.COM \
+          service=HTTP/vault.example.com \
+          keytab_path=/etc/alice.keytab
+
+Configuration:
+
+  keytab_path=<string>
+      Path to a keytab holding the user's keys. This is required.
+
+  krb5conf_path=<string>
+      Path to the Kerberos client configuration. The default is
+      "/etc/krb5.conf".
+
+  mount=<string>
+      Path where the Kerberos auth method is mounted. This is usually provided
+      via the -path flag in the "vault login" command, but it can be specified
+      here as well. If specified here, it takes precedence over the value for
+      -path. The default value is "kerberos".
+
+  realm=<string>
+      Kerberos realm of the user. This is required.
+
+  service=<string>
+      Service principal configured in the auth method, for example
+      "HTTP/vault.example.com". This is required.
+
+  username=<string>
+      Kerberos user to authenticate as. This is required.
+`
+
+	return strings.TrimSpace(help)
+}
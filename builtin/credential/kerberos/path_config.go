@@ -0,0 +1,176 @@
+package kerberos
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+)
+
+type kerberosConfig struct {
+	Keytab         string   `json:"keytab"`
+	ServiceAccount string   `json:"service_account"`
+	AllowedRealms  []string `json:"allowed_realms"`
+}
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config$",
+		Fields: map[string]*framework.FieldSchema{
+			"keytab": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Base64 encoded keytab containing the keys of the service principal.`,
+			},
+			"service_account": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Service principal used to verify incoming tickets, e.g. "HTTP/vault.example.com".`,
+			},
+			"allowed_realms": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Realms of the users allowed to log in. Users are identified by their
+username alone, so only realms sharing the same users should be allowed.
+Defaults to the realm of the service principal.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+func (b *backend) config(ctx context.Context, s logical.Storage) (*kerberosConfig, error) {
+	entry, err := s.Get(ctx, "config")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result kerberosConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, errwrap.Wrapf("error reading configuration: {{err}}", err)
+	}
+
+	return &result, nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	// The keytab holds the service principal's long-term keys and is never
+	// returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"service_account": config.ServiceAccount,
+			"allowed_realms":  config.AllowedRealms,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	serviceAccount := d.Get("service_account").(string)
+	if serviceAccount == "" {
+		return logical.ErrorResponse("service_account must be provided"), nil
+	}
+
+	encodedKeytab := d.Get("keytab").(string)
+	if encodedKeytab == "" {
+		return logical.ErrorResponse("keytab must be provided"), nil
+	}
+	kt, err := parseKeytab(encodedKeytab)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	allowedRealms := d.Get("allowed_realms").([]string)
+	if len(allowedRealms) == 0 && serviceRealm(kt, serviceAccount) == "" {
+		return logical.ErrorResponse("keytab contains no entry for the service account, allowed_realms must be provided"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config", &kerberosConfig{
+		Keytab:         encodedKeytab,
+		ServiceAccount: serviceAccount,
+		AllowedRealms:  allowedRealms,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// parseKeytab decodes a base64 encoded keytab as stored in the configuration
+func parseKeytab(encoded string) (*keytab.Keytab, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errwrap.Wrapf("could not base64 decode keytab: {{err}}", err)
+	}
+
+	kt := keytab.New()
+	if err := kt.Unmarshal(raw); err != nil {
+		return nil, errwrap.Wrapf("invalid keytab: {{err}}", err)
+	}
+	if len(kt.Entries) == 0 {
+		return nil, errors.New("keytab contains no entries")
+	}
+
+	return kt, nil
+}
+
+// serviceRealm returns the realm of the keytab entry of the service account,
+// or an empty string if the keytab has no entry for it
+func serviceRealm(kt *keytab.Keytab, serviceAccount string) string {
+	for _, entry := range kt.Entries {
+		if strings.Join(entry.Principal.Components, "/") == serviceAccount {
+			return entry.Principal.Realm
+		}
+	}
+	return ""
+}
+
+// realmAllowed returns whether users of the given realm may log in
+func (c *kerberosConfig) realmAllowed(kt *keytab.Keytab, realm string) bool {
+	if len(c.AllowedRealms) == 0 {
+		return realm != "" && realm == serviceRealm(kt, c.ServiceAccount)
+	}
+	return strutil.StrListContains(c.AllowedRealms, realm)
+}
+
+const pathConfigHelpSyn = `
+Configure the keytab and service principal used to validate Kerberos tickets.
+`
+
+const pathConfigHelpDesc = `
+The keytab must be base64 encoded and contain the keys for the configured
+service account, which is the principal clients request tickets for. For
+HTTP services this is usually of the form "HTTP/<vault hostname>".
+
+The keytab is stored seal-wrapped where available and is never returned on
+read.
+
+Only users of the realms in "allowed_realms", or of the realm of the service
+principal if it is not set, can log in. Users are identified by their username
+without the realm, so a user of one allowed realm maps to the same entity and
+LDAP user as the user with the same name in another.
+`
@@ -0,0 +1,113 @@
+package kerberos
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/helper/ldaputil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConfigLdap(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `config/ldap`,
+		Fields:  ldaputil.ConfigFields(),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigLdapRead,
+			logical.UpdateOperation: b.pathConfigLdapWrite,
+			logical.DeleteOperation: b.pathConfigLdapDelete,
+		},
+
+		HelpSynopsis:    pathConfigLdapHelpSyn,
+		HelpDescription: pathConfigLdapHelpDesc,
+	}
+}
+
+// ConfigLdap returns the stored LDAP configuration with defaults filled in,
+// or nil if group lookups have not been configured.
+func (b *backend) ConfigLdap(ctx context.Context, req *logical.Request) (*ldaputil.ConfigEntry, error) {
+	storedConfig, err := req.Storage.Get(ctx, "config/ldap")
+	if err != nil {
+		return nil, err
+	}
+	if storedConfig == nil {
+		return nil, nil
+	}
+
+	// Start from the schema defaults so fields missing from storage keep
+	// their default values
+	fd := &framework.FieldData{
+		Raw:    map[string]interface{}{},
+		Schema: ldaputil.ConfigFields(),
+	}
+	result, err := ldaputil.NewConfigEntry(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storedConfig.DecodeJSON(result); err != nil {
+		return nil, err
+	}
+	if result.CaseSensitiveNames == nil {
+		result.CaseSensitiveNames = new(bool)
+	}
+
+	return result, nil
+}
+
+func (b *backend) pathConfigLdapRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	cfg, err := b.ConfigLdap(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: cfg.PasswordlessMap(),
+	}, nil
+}
+
+func (b *backend) pathConfigLdapWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	cfg, err := ldaputil.NewConfigEntry(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if cfg.CaseSensitiveNames == nil {
+		cfg.CaseSensitiveNames = new(bool)
+	}
+
+	entry, err := logical.StorageEntryJSON("config/ldap", cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigLdapDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "config/ldap"); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+const pathConfigLdapHelpSyn = `
+Configure the LDAP server used to look up group membership.
+`
+
+const pathConfigLdapHelpDesc = `
+This endpoint accepts the same parameters as the configuration endpoint of the
+LDAP auth method. When set, the authenticated Kerberos user is located in LDAP
+using the bind DN credentials, and its groups are mapped to policies through
+the "groups/" endpoint.
+
+The user's own password is never available to this backend, so "binddn" and
+"bindpass" are required for any search beyond a direct "userdn" lookup.
+`
@@ -0,0 +1,157 @@
+package kerberos
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathGroupsList(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "groups/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathGroupList,
+		},
+
+		HelpSynopsis:    pathGroupHelpSyn,
+		HelpDescription: pathGroupHelpDesc,
+	}
+}
+
+func pathGroups(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `groups/(?P<name>.+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the LDAP group.",
+			},
+
+			"policies": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated list of policies associated to the group.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.DeleteOperation: b.pathGroupDelete,
+			logical.ReadOperation:   b.pathGroupRead,
+			logical.UpdateOperation: b.pathGroupWrite,
+		},
+
+		HelpSynopsis:    pathGroupHelpSyn,
+		HelpDescription: pathGroupHelpDesc,
+	}
+}
+
+func (b *backend) Group(ctx context.Context, s logical.Storage, n string) (*GroupEntry, error) {
+	entry, err := s.Get(ctx, "group/"+n)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result GroupEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// canonicalGroupName lowercases group names unless the LDAP configuration
+// asks for case sensitive names.
+func (b *backend) canonicalGroupName(ctx context.Context, req *logical.Request, name string) (string, error) {
+	cfg, err := b.ConfigLdap(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if cfg != nil && *cfg.CaseSensitiveNames {
+		return name, nil
+	}
+	return strings.ToLower(name), nil
+}
+
+func (b *backend) pathGroupDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	groupname, err := b.canonicalGroupName(ctx, req, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Delete(ctx, "group/"+groupname); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathGroupRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	groupname, err := b.canonicalGroupName(ctx, req, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := b.Group(ctx, req.Storage, groupname)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"policies": group.Policies,
+		},
+	}, nil
+}
+
+func (b *backend) pathGroupWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	groupname, err := b.canonicalGroupName(ctx, req, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := logical.StorageEntryJSON("group/"+groupname, &GroupEntry{
+		Policies: policyutil.ParsePolicies(d.Get("policies")),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathGroupList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	groups, err := req.Storage.List(ctx, "group/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(groups), nil
+}
+
+type GroupEntry struct {
+	Policies []string
+}
+
+const pathGroupHelpSyn = `
+Manage policies assigned to LDAP groups.
+`
+
+const pathGroupHelpDesc = `
+This endpoint allows you to create, read, update, and delete the policies
+associated with LDAP groups. Users authenticating with Kerberos receive the
+policies of every group they belong to, as found through "config/ldap".
+
+Deleting a group will not revoke auth for prior authenticated users in that
+group.
+`
@@ -0,0 +1,294 @@
+package kerberos
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/ldaputil"
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/service"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+const negotiatePrefix = "Negotiate "
+
+func pathLogin(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "login$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathLogin,
+		},
+
+		HelpSynopsis:    pathLoginSyn,
+		HelpDescription: pathLoginDesc,
+	}
+}
+
+func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("kerberos backend not configured"), nil
+	}
+
+	kt, err := parseKeytab(config.Keytab)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := verifySPNEGO(kt, config.ServiceAccount, authorizationHeader(req))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	username := creds.UserName()
+	if b.Logger().IsDebug() {
+		b.Logger().Debug("kerberos ticket verified", "username", username, "realm", creds.Realm())
+	}
+	if !config.realmAllowed(kt, creds.Realm()) {
+		return logical.ErrorResponse(fmt.Sprintf("realm %q is not allowed", creds.Realm())), nil
+	}
+
+	policies, groupNames, warnings, err := b.ldapPolicies(ctx, req, username)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	resp := &logical.Response{
+		Warnings: warnings,
+	}
+
+	sort.Strings(policies)
+
+	resp.Auth = &logical.Auth{
+		Policies: policies,
+		Metadata: map[string]string{
+			"username": username,
+			"domain":   creds.Realm(),
+		},
+		DisplayName: username,
+		LeaseOptions: logical.LeaseOptions{
+			Renewable: true,
+		},
+		Alias: &logical.Alias{
+			Name: username,
+		},
+	}
+
+	for _, groupName := range groupNames {
+		if groupName == "" {
+			continue
+		}
+		resp.Auth.GroupAliases = append(resp.Auth.GroupAliases, &logical.Alias{
+			Name: groupName,
+		})
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.Auth == nil {
+		return nil, errors.New("request auth was nil")
+	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, errors.New("kerberos backend not configured")
+	}
+
+	kt, err := parseKeytab(config.Keytab)
+	if err != nil {
+		return nil, err
+	}
+	if realm := req.Auth.Metadata["domain"]; !config.realmAllowed(kt, realm) {
+		return nil, fmt.Errorf("realm %q is no longer allowed, not renewing", realm)
+	}
+
+	// The ticket itself cannot be presented again, so renewal only checks
+	// that the user's group memberships still grant the same policies
+	username := req.Auth.Metadata["username"]
+	policies, groupNames, _, err := b.ldapPolicies(ctx, req, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if !policyutil.EquivalentPolicies(policies, req.Auth.Policies) {
+		return nil, fmt.Errorf("policies have changed, not renewing")
+	}
+
+	resp := &logical.Response{Auth: req.Auth}
+
+	// Remove old aliases
+	resp.Auth.GroupAliases = nil
+
+	for _, groupName := range groupNames {
+		if groupName == "" {
+			continue
+		}
+		resp.Auth.GroupAliases = append(resp.Auth.GroupAliases, &logical.Alias{
+			Name: groupName,
+		})
+	}
+
+	return resp, nil
+}
+
+// authorizationHeader returns the first Authorization header passed through
+// to the backend, matching the header name case-insensitively.
+func authorizationHeader(req *logical.Request) string {
+	for k, v := range req.Headers {
+		if strings.EqualFold(k, "Authorization") && len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// verifySPNEGO validates the Kerberos AP_REQ carried in a SPNEGO negotiation
+// token against the keytab, and returns the client's credentials.
+func verifySPNEGO(kt *keytab.Keytab, serviceAccount, header string) (*credentials.Credentials, error) {
+	if !strings.HasPrefix(header, negotiatePrefix) {
+		return nil, errors.New("missing or malformed Negotiate authorization header; ensure the mount is tuned with passthrough_request_headers=Authorization")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(header, negotiatePrefix)))
+	if err != nil {
+		return nil, errwrap.Wrapf("could not base64 decode SPNEGO token: {{err}}", err)
+	}
+
+	var token spnego.SPNEGOToken
+	if err := token.Unmarshal(raw); err != nil {
+		return nil, errwrap.Wrapf("invalid SPNEGO token: {{err}}", err)
+	}
+
+	var mechToken []byte
+	switch {
+	case token.Init:
+		mechToken = token.NegTokenInit.MechTokenBytes
+	case token.Resp:
+		mechToken = token.NegTokenResp.ResponseToken
+	}
+	if len(mechToken) == 0 {
+		return nil, errors.New("SPNEGO token does not contain a Kerberos mechanism token")
+	}
+
+	var krb5Token spnego.KRB5Token
+	if err := krb5Token.Unmarshal(mechToken); err != nil {
+		return nil, errwrap.Wrapf("invalid Kerberos token: {{err}}", err)
+	}
+	if !krb5Token.IsAPReq() {
+		return nil, errors.New("Kerberos token is not an AP_REQ")
+	}
+
+	settings := service.NewSettings(kt, service.KeytabPrincipal(serviceAccount), service.DecodePAC(false))
+	ok, creds, err := service.VerifyAPREQ(&krb5Token.APReq, settings)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to verify Kerberos ticket: {{err}}", err)
+	}
+	if !ok || creds == nil {
+		return nil, errors.New("failed to verify Kerberos ticket")
+	}
+
+	return creds, nil
+}
+
+// ldapPolicies looks up the user's LDAP groups, if LDAP is configured, and
+// returns the policies assigned to them along with the group names.
+func (b *backend) ldapPolicies(ctx context.Context, req *logical.Request, username string) ([]string, []string, []string, error) {
+	cfg, err := b.ConfigLdap(ctx, req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cfg == nil {
+		return nil, nil, nil, nil
+	}
+
+	ldapClient := ldaputil.Client{
+		Logger: b.Logger(),
+		Pool:   &b.ldapPool,
+	}
+
+	c, err := ldapClient.Connect(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if c == nil {
+		return nil, nil, nil, errors.New("invalid connection returned from LDAP dial")
+	}
+
+	// Hand the connection back for later logins
+	defer ldapClient.Release(cfg, c)
+
+	userBindDN, err := ldapClient.GetUserBindDN(cfg, c, username)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The user's password is not known, so all searches run as the BindDN
+	if cfg.BindDN != "" && cfg.BindPassword != "" {
+		if err := c.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			return nil, nil, nil, errwrap.Wrapf("LDAP bind (service) failed: {{err}}", err)
+		}
+	}
+
+	userDN, err := ldapClient.GetUserDN(cfg, c, userBindDN)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ldapGroups, err := ldapClient.GetLdapGroups(cfg, c, userDN, username)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if b.Logger().IsDebug() {
+		b.Logger().Debug("groups fetched from server", "num_server_groups", len(ldapGroups), "server_groups", ldapGroups)
+	}
+
+	var warnings []string
+	if len(ldapGroups) == 0 {
+		warnings = append(warnings, fmt.Sprintf("no LDAP groups found in groupDN '%s'", cfg.GroupDN))
+	}
+
+	var policies []string
+	for _, groupName := range ldapGroups {
+		if !*cfg.CaseSensitiveNames {
+			groupName = strings.ToLower(groupName)
+		}
+		group, err := b.Group(ctx, req.Storage, groupName)
+		if err == nil && group != nil {
+			policies = append(policies, group.Policies...)
+		}
+	}
+	// Policies from each group may overlap
+	policies = strutil.RemoveDuplicates(policies, true)
+
+	return policies, ldapGroups, warnings, nil
+}
+
+const pathLoginSyn = `
+Log in with a Kerberos service ticket.
+`
+
+const pathLoginDesc = `
+This endpoint authenticates using a SPNEGO token sent in the
+"Authorization: Negotiate <token>" header. The mount must be tuned with
+"passthrough_request_headers=Authorization" for the header to reach the
+backend.
+`
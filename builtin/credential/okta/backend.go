@@ -3,10 +3,12 @@ package okta
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/chrismalek/oktasdk-go/okta"
-	"github.com/hashicorp/vault/helper/mfa"
+	oidc "github.com/coreos/go-oidc"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -19,14 +21,18 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 	return b, nil
 }
 
+// pushPollInterval is how often the result of an Okta Verify push is checked
+const pushPollInterval = time.Second
+
 func Backend() *backend {
 	var b backend
+	b.providerCtx, b.providerCtxCancel = context.WithCancel(context.Background())
 	b.Backend = &framework.Backend{
-		Help: backendHelp,
+		Help:       backendHelp,
+		Clean:      b.cleanup,
+		Invalidate: b.invalidate,
 
 		PathsSpecial: &logical.Paths{
-			Root: mfa.MFARootPaths(),
-
 			Unauthenticated: []string{
 				"login/*",
 			},
@@ -35,15 +41,14 @@ func Backend() *backend {
 			},
 		},
 
-		Paths: append([]*framework.Path{
+		Paths: []*framework.Path{
 			pathConfig(&b),
 			pathUsers(&b),
 			pathGroups(&b),
 			pathUsersList(&b),
 			pathGroupsList(&b),
+			pathLogin(&b),
 		},
-			mfa.MFAPaths(b.Backend, pathLogin(&b))...,
-		),
 
 		AuthRenew:   b.pathLoginRenew,
 		BackendType: logical.TypeCredential,
@@ -54,52 +59,101 @@ func Backend() *backend {
 
 type backend struct {
 	*framework.Backend
+
+	l        sync.Mutex
+	provider *oidc.Provider
+
+	providerCtx       context.Context
+	providerCtxCancel context.CancelFunc
 }
 
-func (b *backend) Login(ctx context.Context, req *logical.Request, username string, password string) ([]string, *logical.Response, []string, error) {
-	cfg, err := b.Config(ctx, req.Storage)
-	if err != nil {
-		return nil, nil, nil, err
+func (b *backend) cleanup(_ context.Context) {
+	b.l.Lock()
+	if b.providerCtxCancel != nil {
+		b.providerCtxCancel()
 	}
-	if cfg == nil {
-		return nil, logical.ErrorResponse("Okta auth method not configured"), nil, nil
+	b.l.Unlock()
+}
+
+func (b *backend) invalidate(_ context.Context, key string) {
+	switch key {
+	case "config":
+		b.reset()
 	}
+}
 
-	client := cfg.OktaClient()
+type mfaFactor struct {
+	Id       string `json:"id"`
+	Type     string `json:"factorType"`
+	Provider string `json:"provider"`
+}
 
-	type mfaFactor struct {
-		Id       string `json:"id"`
-		Type     string `json:"factorType"`
-		Provider string `json:"provider"`
-	}
+type embeddedResult struct {
+	User    okta.User   `json:"user"`
+	Factors []mfaFactor `json:"factors"`
+}
 
-	type embeddedResult struct {
-		User    okta.User   `json:"user"`
-		Factors []mfaFactor `json:"factors"`
-	}
+type authResult struct {
+	Embedded     embeddedResult `json:"_embedded"`
+	Status       string         `json:"status"`
+	FactorResult string         `json:"factorResult"`
+	StateToken   string         `json:"stateToken"`
+	SessionToken string         `json:"sessionToken"`
+}
+
+// loginOptions are the second factor given for a login, and the state kept
+// from the login when renewing it
+type loginOptions struct {
+	// TOTP is a passcode for a TOTP factor; if not set, MFA is completed with
+	// an Okta Verify push
+	TOTP string
+
+	// Provider restricts the TOTP factors to those of the provider
+	Provider string
+
+	// Renew is set when renewing a login, in which case only the password is
+	// checked again rather than completing MFA, and groups are read with the
+	// refresh token of the login
+	Renew        bool
+	RefreshToken string
+}
 
-	type authResult struct {
-		Embedded     embeddedResult `json:"_embedded"`
-		Status       string         `json:"status"`
-		FactorResult string         `json:"factorResult"`
-		StateToken   string         `json:"stateToken"`
+// loginResult is the result of a successful login
+type loginResult struct {
+	Policies     []string
+	Groups       []string
+	RefreshToken string
+}
+
+func (b *backend) Login(ctx context.Context, req *logical.Request, username string, password string, opts *loginOptions) (*loginResult, *logical.Response, error) {
+	cfg, err := b.Config(ctx, req.Storage)
+	if err != nil {
+		return nil, nil, err
 	}
+	if cfg == nil {
+		return nil, logical.ErrorResponse("Okta auth method not configured"), nil
+	}
+	if opts == nil {
+		opts = &loginOptions{}
+	}
+
+	client := cfg.OktaClient()
 
 	authReq, err := client.NewRequest("POST", "authn", map[string]interface{}{
 		"username": username,
 		"password": password,
 	})
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, err
 	}
 
 	var result authResult
 	rsp, err := client.Do(authReq, &result)
 	if err != nil {
-		return nil, logical.ErrorResponse(fmt.Sprintf("Okta auth failed: %v", err)), nil, nil
+		return nil, logical.ErrorResponse(fmt.Sprintf("Okta auth failed: %v", err)), nil
 	}
 	if rsp == nil {
-		return nil, logical.ErrorResponse("okta auth method unexpected failure"), nil, nil
+		return nil, logical.ErrorResponse("okta auth method unexpected failure"), nil
 	}
 
 	oktaResponse := &logical.Response{
@@ -117,23 +171,23 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 		if b.Logger().IsDebug() {
 			b.Logger().Debug("user is locked out", "user", username)
 		}
-		return nil, logical.ErrorResponse("okta authentication failed"), nil, nil
+		return nil, logical.ErrorResponse("okta authentication failed"), nil
 
 	case "PASSWORD_EXPIRED":
 		if b.Logger().IsDebug() {
 			b.Logger().Debug("password is expired", "user", username)
 		}
-		return nil, logical.ErrorResponse("okta authentication failed"), nil, nil
+		return nil, logical.ErrorResponse("okta authentication failed"), nil
 
 	case "PASSWORD_WARN":
 		oktaResponse.AddWarning("Your Okta password is in warning state and needs to be changed soon.")
 
 	case "MFA_ENROLL", "MFA_ENROLL_ACTIVATE":
-		if !cfg.BypassOktaMFA {
+		if !cfg.BypassOktaMFA && !opts.Renew {
 			if b.Logger().IsDebug() {
 				b.Logger().Debug("user must enroll or complete mfa enrollment", "user", username)
 			}
-			return nil, logical.ErrorResponse("okta authentication failed: you must complete MFA enrollment to continue"), nil, nil
+			return nil, logical.ErrorResponse("okta authentication failed: you must complete MFA enrollment to continue"), nil
 		}
 
 	case "MFA_REQUIRED":
@@ -142,72 +196,16 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 		// active factor enrollment). This bypass removes visibility
 		// into the authenticating user's password expiry, but still ensures the
 		// credentials are valid and the user is not locked out.
-		if cfg.BypassOktaMFA {
+		//
+		// Renewals check the credentials the same way, since MFA was completed
+		// when logging in.
+		if cfg.BypassOktaMFA || opts.Renew {
 			result.Status = "SUCCESS"
 			break
 		}
 
-		factorAvailable := false
-
-		var selectedFactor mfaFactor
-		// only okta push is currently supported
-		for _, v := range result.Embedded.Factors {
-			if v.Type == "push" && v.Provider == "OKTA" {
-				factorAvailable = true
-				selectedFactor = v
-			}
-		}
-
-		if !factorAvailable {
-			return nil, logical.ErrorResponse("Okta Verify Push factor is required in order to perform MFA"), nil, nil
-		}
-
-		requestPath := fmt.Sprintf("authn/factors/%s/verify", selectedFactor.Id)
-		payload := map[string]interface{}{
-			"stateToken": result.StateToken,
-		}
-		verifyReq, err := client.NewRequest("POST", requestPath, payload)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-
-		rsp, err := client.Do(verifyReq, &result)
-		if err != nil {
-			return nil, logical.ErrorResponse(fmt.Sprintf("Okta auth failed: %v", err)), nil, nil
-		}
-		if rsp == nil {
-			return nil, logical.ErrorResponse("okta auth backend unexpected failure"), nil, nil
-		}
-		for result.Status == "MFA_CHALLENGE" {
-			switch result.FactorResult {
-			case "WAITING":
-				verifyReq, err := client.NewRequest("POST", requestPath, payload)
-				rsp, err := client.Do(verifyReq, &result)
-				if err != nil {
-					return nil, logical.ErrorResponse(fmt.Sprintf("Okta auth failed checking loop: %v", err)), nil, nil
-				}
-				if rsp == nil {
-					return nil, logical.ErrorResponse("okta auth backend unexpected failure"), nil, nil
-				}
-
-				select {
-				case <-time.After(500 * time.Millisecond):
-					// Continue
-				case <-ctx.Done():
-					return nil, logical.ErrorResponse("exiting pending mfa challenge"), nil, nil
-				}
-			case "REJECTED":
-				return nil, logical.ErrorResponse("multi-factor authentication denied"), nil, nil
-			case "TIMEOUT":
-				return nil, logical.ErrorResponse("failed to complete multi-factor authentication"), nil, nil
-			case "SUCCESS":
-				// Allowed
-			default:
-				if b.Logger().IsDebug() {
-					b.Logger().Debug("unhandled result status", "status", result.Status, "factorstatus", result.FactorResult)
-				}
-				return nil, logical.ErrorResponse("okta authentication failed"), nil, nil
-			}
+		if resp, err := b.verifyFactor(ctx, client, &result, opts); err != nil || resp != nil {
+			return nil, resp, err
 		}
 
 	case "SUCCESS":
@@ -217,7 +215,7 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 		if b.Logger().IsDebug() {
 			b.Logger().Debug("unhandled result status", "status", result.Status)
 		}
-		return nil, logical.ErrorResponse("okta authentication failed"), nil, nil
+		return nil, logical.ErrorResponse("okta authentication failed"), nil
 	}
 
 	// Verify result status again in case a switch case above modifies result
@@ -225,22 +223,47 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 	case result.Status == "SUCCESS",
 		result.Status == "PASSWORD_WARN",
 		result.Status == "MFA_REQUIRED" && cfg.BypassOktaMFA,
-		result.Status == "MFA_ENROLL" && cfg.BypassOktaMFA,
-		result.Status == "MFA_ENROLL_ACTIVATE" && cfg.BypassOktaMFA:
+		result.Status == "MFA_ENROLL" && (cfg.BypassOktaMFA || opts.Renew),
+		result.Status == "MFA_ENROLL_ACTIVATE" && (cfg.BypassOktaMFA || opts.Renew):
 		// Allowed
 	default:
 		if b.Logger().IsDebug() {
 			b.Logger().Debug("authentication returned a non-success status", "status", result.Status)
 		}
-		return nil, logical.ErrorResponse("okta authentication failed"), nil, nil
+		return nil, logical.ErrorResponse("okta authentication failed"), nil
 	}
 
 	var allGroups []string
+	var refreshToken string
+	switch {
+	// Read groups from the ID token if an OIDC application is configured
+	case cfg.OIDCClientID != "":
+		var oidcResp *oidcResult
+		if opts.Renew {
+			if opts.RefreshToken == "" {
+				return nil, logical.ErrorResponse("login has no refresh token to read groups with; log in again"), nil
+			}
+			oidcResp, err = b.oidcRefresh(ctx, cfg, opts.RefreshToken)
+		} else {
+			if result.SessionToken == "" {
+				return nil, logical.ErrorResponse("okta returned no session token to read groups with; MFA must be completed rather than bypassed"), nil
+			}
+			oidcResp, err = b.oidcLogin(ctx, cfg, result.SessionToken)
+		}
+		if err != nil {
+			return nil, logical.ErrorResponse(fmt.Sprintf("okta failure retrieving groups: %v", err)), nil
+		}
+		if len(oidcResp.Groups) == 0 {
+			oktaResponse.AddWarning("no Okta groups found; only policies from locally-defined groups available")
+		}
+		allGroups = append(allGroups, oidcResp.Groups...)
+		refreshToken = oidcResp.RefreshToken
+
 	// Only query the Okta API for group membership if we have a token
-	if cfg.Token != "" {
+	case cfg.Token != "":
 		oktaGroups, err := b.getOktaGroups(client, &result.Embedded.User)
 		if err != nil {
-			return nil, logical.ErrorResponse(fmt.Sprintf("okta failure retrieving groups: %v", err)), nil, nil
+			return nil, logical.ErrorResponse(fmt.Sprintf("okta failure retrieving groups: %v", err)), nil
 		}
 		if len(oktaGroups) == 0 {
 			errString := fmt.Sprintf(
@@ -290,10 +313,118 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 		}
 
 		oktaResponse.Data["error"] = errStr
-		return nil, oktaResponse, nil, nil
+		return nil, oktaResponse, nil
 	}
 
-	return policies, oktaResponse, allGroups, nil
+	return &loginResult{
+		Policies:     policies,
+		Groups:       allGroups,
+		RefreshToken: refreshToken,
+	}, oktaResponse, nil
+}
+
+// verifyFactor completes the MFA challenge of the authentication transaction,
+// with the TOTP passcode if one is given or else with an Okta Verify push. The
+// result is updated with the state of the transaction.
+func (b *backend) verifyFactor(ctx context.Context, client *okta.Client, result *authResult, opts *loginOptions) (*logical.Response, error) {
+	factor, errStr := selectFactor(result.Embedded.Factors, opts.TOTP, opts.Provider)
+	if factor == nil {
+		return logical.ErrorResponse(errStr), nil
+	}
+
+	requestPath := fmt.Sprintf("authn/factors/%s/verify", factor.Id)
+	payload := map[string]interface{}{
+		"stateToken": result.StateToken,
+	}
+	if opts.TOTP != "" {
+		payload["passCode"] = opts.TOTP
+	}
+
+	verify := func() (*logical.Response, error) {
+		verifyReq, err := client.NewRequest("POST", requestPath, payload)
+		if err != nil {
+			return nil, err
+		}
+		rsp, err := client.Do(verifyReq, result)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("Okta auth failed: %v", err)), nil
+		}
+		if rsp == nil {
+			return logical.ErrorResponse("okta auth backend unexpected failure"), nil
+		}
+		return nil, nil
+	}
+
+	if resp, err := verify(); err != nil || resp != nil {
+		return resp, err
+	}
+
+	// Push factors stay in the challenge state until the push is answered
+	for result.Status == "MFA_CHALLENGE" {
+		switch result.FactorResult {
+		case "WAITING":
+			select {
+			case <-time.After(pushPollInterval):
+				// Continue
+			case <-ctx.Done():
+				return logical.ErrorResponse("exiting pending mfa challenge"), nil
+			}
+
+			if resp, err := verify(); err != nil || resp != nil {
+				return resp, err
+			}
+		case "REJECTED":
+			return logical.ErrorResponse("multi-factor authentication denied"), nil
+		case "TIMEOUT":
+			return logical.ErrorResponse("failed to complete multi-factor authentication"), nil
+		default:
+			if b.Logger().IsDebug() {
+				b.Logger().Debug("unhandled result status", "status", result.Status, "factorstatus", result.FactorResult)
+			}
+			return logical.ErrorResponse("okta authentication failed"), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// selectFactor returns the factor to complete MFA with: a TOTP factor if a
+// passcode is given, preferring Okta Verify over other providers unless a
+// provider is given, or else an Okta Verify push factor. If there is none,
+// the error to return is given instead.
+func selectFactor(factors []mfaFactor, totp, provider string) (*mfaFactor, string) {
+	var totpFactors []mfaFactor
+	var pushFactor *mfaFactor
+	for i, v := range factors {
+		switch {
+		case v.Type == "token:software:totp":
+			if provider == "" || strings.EqualFold(v.Provider, provider) {
+				totpFactors = append(totpFactors, v)
+			}
+		case v.Type == "push" && v.Provider == "OKTA":
+			pushFactor = &factors[i]
+		}
+	}
+
+	if totp != "" {
+		for i, v := range totpFactors {
+			if v.Provider == "OKTA" {
+				return &totpFactors[i], ""
+			}
+		}
+		if len(totpFactors) > 0 {
+			return &totpFactors[0], ""
+		}
+		return nil, "no TOTP factor is enrolled to verify the passcode with"
+	}
+
+	if pushFactor != nil {
+		return pushFactor, ""
+	}
+	if len(totpFactors) > 0 {
+		return nil, "a TOTP passcode is required in order to perform MFA"
+	}
+	return nil, "Okta Verify Push or TOTP factor is required in order to perform MFA"
 }
 
 func (b *backend) getOktaGroups(client *okta.Client, user *okta.User) ([]string, error) {
@@ -317,7 +448,9 @@ func (b *backend) getOktaGroups(client *okta.Client, user *okta.User) ([]string,
 const backendHelp = `
 The Okta credential provider allows authentication querying,
 checking username and password, and associating policies.  If an api token is
-configured groups are pulled down from Okta.
+configured groups are pulled down from Okta. Alternatively, if an OIDC
+application is configured, groups are read from the ID tokens issued for the
+logins. Okta Verify push and TOTP factors can be used to complete MFA.
 
 Configuration of the connection is done through the "config" and "policies"
 endpoints by a user with root access. Authentication is then done
@@ -3,7 +3,9 @@ package okta
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -195,3 +197,89 @@ func testAccLogin(t *testing.T, user, password string, keys []string) logicaltes
 		Check: logicaltest.TestCheckAuth(keys),
 	}
 }
+
+func TestBackend_selectFactor(t *testing.T) {
+	factors := []mfaFactor{
+		{Id: "google", Type: "token:software:totp", Provider: "GOOGLE"},
+		{Id: "totp", Type: "token:software:totp", Provider: "OKTA"},
+		{Id: "push", Type: "push", Provider: "OKTA"},
+	}
+
+	cases := []struct {
+		factors  []mfaFactor
+		totp     string
+		provider string
+		expected string
+	}{
+		{factors, "", "", "push"},
+		{factors, "123456", "", "totp"},
+		{factors, "123456", "google", "google"},
+		{factors[:1], "123456", "", "google"},
+		{factors[:2], "", "", ""},
+		{factors[2:], "123456", "", ""},
+		{nil, "", "", ""},
+	}
+
+	for i, tc := range cases {
+		factor, errStr := selectFactor(tc.factors, tc.totp, tc.provider)
+		switch {
+		case tc.expected == "" && (factor != nil || errStr == ""):
+			t.Fatalf("%d: expected error, got %#v", i, factor)
+		case tc.expected != "" && (factor == nil || factor.Id != tc.expected):
+			t.Fatalf("%d: expected factor %q, got %#v: %s", i, tc.expected, factor, errStr)
+		}
+	}
+}
+
+func TestBackend_codeFromRedirect(t *testing.T) {
+	cases := []struct {
+		location string
+		code     string
+	}{
+		{"https://vault.example.com/callback?code=abc&state=state", "abc"},
+		{"https://vault.example.com/callback?code=abc&state=other", ""},
+		{"https://vault.example.com/callback?state=state", ""},
+		{"https://vault.example.com/callback?error=access_denied&error_description=denied&state=state", ""},
+	}
+
+	for _, tc := range cases {
+		location, err := url.Parse(tc.location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := codeFromRedirect(location, "state")
+		if tc.code == "" {
+			if err == nil {
+				t.Fatalf("%s: expected error", tc.location)
+			}
+			continue
+		}
+		if err != nil || code != tc.code {
+			t.Fatalf("%s: expected code %q, got %q: %v", tc.location, tc.code, code, err)
+		}
+	}
+}
+
+func TestBackend_groupsFromClaim(t *testing.T) {
+	cases := []struct {
+		claim    interface{}
+		expected []string
+		err      bool
+	}{
+		{nil, nil, false},
+		{"admins", []string{"admins"}, false},
+		{[]interface{}{"admins", "devs"}, []string{"admins", "devs"}, false},
+		{[]interface{}{"admins", 1}, nil, true},
+		{1.0, nil, true},
+	}
+
+	for _, tc := range cases {
+		groups, err := groupsFromClaim(tc.claim)
+		if (err != nil) != tc.err {
+			t.Fatalf("%#v: unexpected error result: %v", tc.claim, err)
+		}
+		if !reflect.DeepEqual(groups, tc.expected) {
+			t.Fatalf("%#v: expected %#v, got %#v", tc.claim, tc.expected, groups)
+		}
+	}
+}
@@ -38,13 +38,13 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 		"password": password,
 	}
 
-	mfa_method, ok := m["method"]
+	totp, ok := m["totp"]
 	if ok {
-		data["method"] = mfa_method
+		data["totp"] = totp
 	}
-	mfa_passcode, ok := m["passcode"]
+	provider, ok := m["provider"]
 	if ok {
-		data["passcode"] = mfa_passcode
+		data["provider"] = provider
 	}
 
 	path := fmt.Sprintf("auth/%s/login/%s", mount, username)
@@ -75,12 +75,25 @@ Usage: vault login -method=okta [CONFIG K=V...]
 
       $ vault login -method=okta username=bob password=password
 
+  If Okta requires MFA, accept the Okta Verify push sent to your device, or
+  give a TOTP passcode:
+
+      $ vault login -method=okta username=sally totp=123456
+
 Configuration:
 
   password=<string>
       Okta password to use for authentication. If not provided, the CLI will
       prompt for this on stdin.
 
+  provider=<string>
+      Provider of the TOTP factor to verify the passcode with, such as "OKTA"
+      or "GOOGLE". By default, Okta Verify is preferred.
+
+  totp=<string>
+      TOTP passcode to complete Okta MFA with. If not provided, an Okta Verify
+      push is sent.
+
   username=<string>
       Okta username to use for authentication.
 `
@@ -0,0 +1,228 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-uuid"
+	"golang.org/x/oauth2"
+)
+
+// oidcResult is the result of getting tokens for a login from the
+// authorization server
+type oidcResult struct {
+	Groups       []string
+	RefreshToken string
+}
+
+// getProvider returns the OIDC provider of the configured authorization
+// server, creating it if it hasn't been created yet
+func (b *backend) getProvider(cfg *ConfigEntry) (*oidc.Provider, error) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if b.provider != nil {
+		return b.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(b.providerCtx, cfg.oidcIssuer())
+	if err != nil {
+		return nil, errwrap.Wrapf("error creating provider with given values: {{err}}", err)
+	}
+
+	b.provider = provider
+	return provider, nil
+}
+
+// reset drops the cached OIDC provider
+func (b *backend) reset() {
+	b.l.Lock()
+	b.provider = nil
+	b.l.Unlock()
+}
+
+// oauth2Config returns the OAuth2 configuration of the Okta OIDC application
+func oauth2Config(cfg *ConfigEntry, provider *oidc.Provider) *oauth2.Config {
+	scopes := []string{oidc.ScopeOpenID, oidc.ScopeOfflineAccess}
+	for _, scope := range cfg.OIDCScopes {
+		if scope != oidc.ScopeOpenID && scope != oidc.ScopeOfflineAccess {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &oauth2.Config{
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURI,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+// oidcLogin gets tokens for the user authenticated by the session token. The
+// session token is passed to the authorization endpoint in place of the
+// browser session, and the authorization code is read from the redirect.
+func (b *backend) oidcLogin(ctx context.Context, cfg *ConfigEntry, sessionToken string) (*oidcResult, error) {
+	provider, err := b.getProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	oauth2Config := oauth2Config(cfg, provider)
+
+	state, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL := oauth2Config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.SetAuthURLParam("sessionToken", sessionToken))
+	code, err := authorizationCode(ctx, authURL, state)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth2Config.Exchange(ctx, code)
+	if err != nil {
+		return nil, errwrap.Wrapf("error exchanging oidc code: {{err}}", err)
+	}
+
+	return b.oidcResult(ctx, cfg, provider, token, nonce)
+}
+
+// oidcRefresh gets new tokens for the login with its refresh token, which
+// fails once the user can no longer sign in to the application
+func (b *backend) oidcRefresh(ctx context.Context, cfg *ConfigEntry, refreshToken string) (*oidcResult, error) {
+	provider, err := b.getProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth2Config(cfg, provider).TokenSource(ctx, &oauth2.Token{
+		RefreshToken: refreshToken,
+		Expiry:       time.Now(),
+	}).Token()
+	if err != nil {
+		return nil, errwrap.Wrapf("error refreshing oidc token: {{err}}", err)
+	}
+
+	result, err := b.oidcResult(ctx, cfg, provider, token, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// The refresh token is only rotated if the application is configured to
+	if result.RefreshToken == "" {
+		result.RefreshToken = refreshToken
+	}
+	return result, nil
+}
+
+// oidcResult verifies the ID token of the token response and reads the
+// groups claim from it
+func (b *backend) oidcResult(ctx context.Context, cfg *ConfigEntry, provider *oidc.Provider, token *oauth2.Token, nonce string) (*oidcResult, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("no id_token found in response")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{
+		ClientID: cfg.OIDCClientID,
+	}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, errwrap.Wrapf("error validating id_token: {{err}}", err)
+	}
+	if nonce != "" && idToken.Nonce != nonce {
+		return nil, fmt.Errorf("invalid ID token nonce")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	groups, err := groupsFromClaim(claims[cfg.OIDCGroupsClaim])
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error reading %q claim: {{err}}", cfg.OIDCGroupsClaim), err)
+	}
+
+	return &oidcResult{
+		Groups:       groups,
+		RefreshToken: token.RefreshToken,
+	}, nil
+}
+
+// groupsFromClaim returns the group names of a groups claim, which Okta
+// omits when the user is part of none of the groups it is filtered on
+func groupsFromClaim(raw interface{}) ([]string, error) {
+	switch raw := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{raw}, nil
+	case []interface{}:
+		groups := make([]string, 0, len(raw))
+		for _, v := range raw {
+			group, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected group value of type %T", v)
+			}
+			groups = append(groups, group)
+		}
+		return groups, nil
+	default:
+		return nil, fmt.Errorf("unexpected claim value of type %T", raw)
+	}
+}
+
+// authorizationCode requests the authorization URL and returns the code
+// Okta redirects to the redirect URI with, without following the redirect
+func authorizationCode(ctx context.Context, authURL, state string) (string, error) {
+	client := cleanhttp.DefaultClient()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	req, err := http.NewRequest("GET", authURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errwrap.Wrapf("error requesting authorization code: {{err}}", err)
+	}
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		return "", fmt.Errorf("no redirect returned by the authorization endpoint, got status code %d", resp.StatusCode)
+	}
+
+	return codeFromRedirect(location, state)
+}
+
+// codeFromRedirect returns the authorization code of the redirect, after
+// checking it is the response to the request with the given state
+func codeFromRedirect(location *url.URL, state string) (string, error) {
+	query := location.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		return "", fmt.Errorf("authorization failed: %s: %s", errCode, query.Get("error_description"))
+	}
+	if query.Get("state") != state {
+		return "", fmt.Errorf("invalid state in authorization response")
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("no code in authorization response")
+	}
+	return code, nil
+}
@@ -58,6 +58,32 @@ func pathConfig(b *backend) *framework.Path {
 				Type:        framework.TypeBool,
 				Description: `When set true, requests by Okta for a MFA check will be bypassed. This also disallows certain status checks on the account, such as whether the password is expired.`,
 			},
+			"oidc_client_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Client ID of an Okta OIDC web application. If set, groups are read from the ID token issued for the login rather than with the API token.`,
+			},
+			"oidc_client_secret": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Client secret of the Okta OIDC web application.`,
+			},
+			"oidc_redirect_uri": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `A sign-in redirect URI allowed for the Okta OIDC web application. Vault does not need to serve it.`,
+			},
+			"oidc_authorization_server": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "default",
+				Description: `ID of the Okta authorization server issuing the ID tokens. Defaults to "default".`,
+			},
+			"oidc_scopes": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: `Scopes to request in addition to "openid" and "offline_access", such as the one the groups claim is included for.`,
+			},
+			"oidc_groups_claim": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "groups",
+				Description: `Name of the ID token claim holding the groups of the user. Defaults to "groups".`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -110,6 +136,13 @@ func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, d *f
 			"bypass_okta_mfa": cfg.BypassOktaMFA,
 		},
 	}
+	if cfg.OIDCClientID != "" {
+		resp.Data["oidc_client_id"] = cfg.OIDCClientID
+		resp.Data["oidc_redirect_uri"] = cfg.OIDCRedirectURI
+		resp.Data["oidc_authorization_server"] = cfg.OIDCAuthorizationServer
+		resp.Data["oidc_scopes"] = cfg.OIDCScopes
+		resp.Data["oidc_groups_claim"] = cfg.OIDCGroupsClaim
+	}
 	if cfg.BaseURL != "" {
 		resp.Data["base_url"] = cfg.BaseURL
 	}
@@ -189,6 +222,44 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		cfg.BypassOktaMFA = bypass.(bool)
 	}
 
+	if clientID, ok := d.GetOk("oidc_client_id"); ok {
+		cfg.OIDCClientID = clientID.(string)
+	}
+	if clientSecret, ok := d.GetOk("oidc_client_secret"); ok {
+		cfg.OIDCClientSecret = clientSecret.(string)
+	}
+	if redirectURI, ok := d.GetOk("oidc_redirect_uri"); ok {
+		cfg.OIDCRedirectURI = redirectURI.(string)
+	}
+	if server, ok := d.GetOk("oidc_authorization_server"); ok {
+		cfg.OIDCAuthorizationServer = server.(string)
+	} else if req.Operation == logical.CreateOperation {
+		cfg.OIDCAuthorizationServer = d.Get("oidc_authorization_server").(string)
+	}
+	if scopes, ok := d.GetOk("oidc_scopes"); ok {
+		cfg.OIDCScopes = scopes.([]string)
+	}
+	if groupsClaim, ok := d.GetOk("oidc_groups_claim"); ok {
+		cfg.OIDCGroupsClaim = groupsClaim.(string)
+	} else if req.Operation == logical.CreateOperation {
+		cfg.OIDCGroupsClaim = d.Get("oidc_groups_claim").(string)
+	}
+	if cfg.OIDCClientID != "" {
+		if cfg.OIDCClientSecret == "" || cfg.OIDCRedirectURI == "" {
+			return logical.ErrorResponse("oidc_client_secret and oidc_redirect_uri are required with oidc_client_id"), nil
+		}
+		if _, err := url.Parse(cfg.OIDCRedirectURI); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("Error parsing given oidc_redirect_uri: %s", err)), nil
+		}
+		// Configurations from before these fields had no defaults
+		if cfg.OIDCAuthorizationServer == "" {
+			cfg.OIDCAuthorizationServer = "default"
+		}
+		if cfg.OIDCGroupsClaim == "" {
+			cfg.OIDCGroupsClaim = "groups"
+		}
+	}
+
 	ttl, ok := d.GetOk("ttl")
 	if ok {
 		cfg.TTL = time.Duration(ttl.(int)) * time.Second
@@ -211,6 +282,9 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		return nil, err
 	}
 
+	// The OIDC provider is created again for the new configuration
+	b.reset()
+
 	var resp *logical.Response
 	if cfg.BypassOktaMFA {
 		resp = new(logical.Response)
@@ -229,8 +303,8 @@ func (b *backend) pathConfigExistenceCheck(ctx context.Context, req *logical.Req
 	return cfg != nil, nil
 }
 
-// OktaClient creates a basic okta client connection
-func (c *ConfigEntry) OktaClient() *okta.Client {
+// baseURL returns the base domain of the Okta organization
+func (c *ConfigEntry) baseURL() string {
 	baseURL := defaultBaseURL
 	if c.Production != nil {
 		if !*c.Production {
@@ -240,12 +314,22 @@ func (c *ConfigEntry) OktaClient() *okta.Client {
 	if c.BaseURL != "" {
 		baseURL = c.BaseURL
 	}
+	return baseURL
+}
 
+// OktaClient creates a basic okta client connection
+func (c *ConfigEntry) OktaClient() *okta.Client {
 	// We validate config on input and errors are only returned when parsing URLs
-	client, _ := okta.NewClientWithDomain(cleanhttp.DefaultClient(), c.Org, baseURL, c.Token)
+	client, _ := okta.NewClientWithDomain(cleanhttp.DefaultClient(), c.Org, c.baseURL(), c.Token)
 	return client
 }
 
+// oidcIssuer returns the issuer of the ID tokens of the configured
+// authorization server
+func (c *ConfigEntry) oidcIssuer() string {
+	return fmt.Sprintf("https://%s.%s/oauth2/%s", c.Org, c.baseURL(), c.OIDCAuthorizationServer)
+}
+
 // ConfigEntry for Okta
 type ConfigEntry struct {
 	Org           string        `json:"organization"`
@@ -255,6 +339,13 @@ type ConfigEntry struct {
 	TTL           time.Duration `json:"ttl"`
 	MaxTTL        time.Duration `json:"max_ttl"`
 	BypassOktaMFA bool          `json:"bypass_okta_mfa"`
+
+	OIDCClientID            string   `json:"oidc_client_id"`
+	OIDCClientSecret        string   `json:"oidc_client_secret"`
+	OIDCRedirectURI         string   `json:"oidc_redirect_uri"`
+	OIDCAuthorizationServer string   `json:"oidc_authorization_server"`
+	OIDCScopes              []string `json:"oidc_scopes"`
+	OIDCGroupsClaim         string   `json:"oidc_groups_claim"`
 }
 
 const pathConfigHelp = `
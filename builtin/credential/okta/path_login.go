@@ -25,6 +25,16 @@ func pathLogin(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Password for this user.",
 			},
+
+			"totp": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "TOTP passcode to complete Okta MFA with. If not given, an Okta Verify push is sent.",
+			},
+
+			"provider": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Provider of the TOTP factor to verify the passcode with, such as "OKTA" or "GOOGLE".`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -56,7 +66,10 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
 
-	policies, resp, groupNames, err := b.Login(ctx, req, username, password)
+	result, resp, err := b.Login(ctx, req, username, password, &loginOptions{
+		TOTP:     d.Get("totp").(string),
+		Provider: d.Get("provider").(string),
+	})
 	// Handle an internal error
 	if err != nil {
 		return nil, err
@@ -70,6 +83,7 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 		resp = &logical.Response{}
 	}
 
+	policies := result.Policies
 	sort.Strings(policies)
 
 	cfg, err := b.getConfig(ctx, req)
@@ -97,7 +111,11 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 		},
 	}
 
-	for _, groupName := range groupNames {
+	if result.RefreshToken != "" {
+		resp.Auth.InternalData["refresh_token"] = result.RefreshToken
+	}
+
+	for _, groupName := range result.Groups {
 		if groupName == "" {
 			continue
 		}
@@ -113,12 +131,19 @@ func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *f
 	username := req.Auth.Metadata["username"]
 	password := req.Auth.InternalData["password"].(string)
 
-	loginPolicies, resp, groupNames, err := b.Login(ctx, req, username, password)
-	if len(loginPolicies) == 0 {
+	refreshToken, _ := req.Auth.InternalData["refresh_token"].(string)
+
+	// Group membership is checked again, with the refresh token of the login
+	// if groups are read from ID tokens
+	result, resp, err := b.Login(ctx, req, username, password, &loginOptions{
+		Renew:        true,
+		RefreshToken: refreshToken,
+	})
+	if result == nil {
 		return resp, err
 	}
 
-	if !policyutil.EquivalentPolicies(loginPolicies, req.Auth.Policies) {
+	if !policyutil.EquivalentPolicies(result.Policies, req.Auth.Policies) {
 		return nil, fmt.Errorf("policies have changed, not renewing")
 	}
 
@@ -131,10 +156,14 @@ func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *f
 	resp.Auth.TTL = cfg.TTL
 	resp.Auth.MaxTTL = cfg.MaxTTL
 
+	if result.RefreshToken != "" {
+		resp.Auth.InternalData["refresh_token"] = result.RefreshToken
+	}
+
 	// Remove old aliases
 	resp.Auth.GroupAliases = nil
 
-	for _, groupName := range groupNames {
+	for _, groupName := range result.Groups {
 		resp.Auth.GroupAliases = append(resp.Auth.GroupAliases, &logical.Alias{
 			Name: groupName,
 		})
@@ -162,5 +191,7 @@ Log in with a username and password.
 `
 
 const pathLoginDesc = `
-This endpoint authenticates using a username and password.
+This endpoint authenticates using a username and password. If Okta requires
+MFA, it is completed with the given TOTP passcode, or else with an Okta Verify
+push that the user must accept.
 `
@@ -2,8 +2,12 @@ package transit
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
 
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -24,15 +28,20 @@ func Backend(conf *logical.BackendConfig) *backend {
 			SealWrapStorage: []string{
 				"archive/",
 				"policy/",
+				"import/",
 			},
 		},
 
 		Paths: []*framework.Path{
-			// Rotate/Config needs to come before Keys
+			// Rotate/Config/Trim/Import needs to come before Keys
 			// as the handler is greedy
 			b.pathConfig(),
 			b.pathRotate(),
+			b.pathTrim(),
 			b.pathRewrap(),
+			b.pathImport(),
+			b.pathImportVersion(),
+			b.pathWrappingKey(),
 			b.pathKeys(),
 			b.pathListKeys(),
 			b.pathExportKeys(),
@@ -48,9 +57,10 @@ func Backend(conf *logical.BackendConfig) *backend {
 			b.pathRestore(),
 		},
 
-		Secrets:     []*framework.Secret{},
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		Secrets:      []*framework.Secret{},
+		Invalidate:   b.invalidate,
+		PeriodicFunc: b.periodicFunc,
+		BackendType:  logical.TypeLogical,
 	}
 
 	b.lm = keysutil.NewLockManager(conf.System.CachingDisabled())
@@ -61,6 +71,9 @@ func Backend(conf *logical.BackendConfig) *backend {
 type backend struct {
 	*framework.Backend
 	lm *keysutil.LockManager
+
+	// wrappingKeyLock guards the generation of the wrapping key
+	wrappingKeyLock sync.Mutex
 }
 
 func (b *backend) invalidate(_ context.Context, key string) {
@@ -73,3 +86,61 @@ func (b *backend) invalidate(_ context.Context, key string) {
 		b.lm.InvalidatePolicy(name)
 	}
 }
+
+// periodicFunc rotates the keys whose newest version is older than their
+// auto rotate period
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	names, err := req.Storage.List(ctx, "policy/")
+	if err != nil {
+		return err
+	}
+
+	var errs *multierror.Error
+	for _, name := range names {
+		if err := b.autoRotateKey(ctx, req.Storage, name); err != nil {
+			errs = multierror.Append(errs, errwrap.Wrapf(fmt.Sprintf("error rotating key %q: {{err}}", name), err))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// autoRotateKey rotates the named key if it needs to, in the same way as the
+// rotate endpoint, leaving the min decryption and encryption versions
+// unchanged. The new version is marked as automatically rotated, so that the
+// rotation shows when reading the key. The exclusive lock is only taken for
+// keys that need to be rotated.
+func (b *backend) autoRotateKey(ctx context.Context, s logical.Storage, name string) error {
+	p, lock, err := b.lm.GetPolicyShared(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	needsRotation := p.NeedsAutoRotation()
+	lock.RUnlock()
+	if !needsRotation {
+		return nil
+	}
+
+	p, lock, err = b.lm.GetPolicyExclusive(ctx, s, name)
+	if lock != nil {
+		defer lock.Unlock()
+	}
+	if err != nil {
+		return err
+	}
+	// Check again, as the key may have been rotated or deleted meanwhile
+	if p == nil || !p.NeedsAutoRotation() {
+		return nil
+	}
+
+	if err := p.AutoRotate(ctx, s); err != nil {
+		return err
+	}
+
+	b.Logger().Info("automatically rotated key", "name", name, "latest_version", p.LatestVersion, "min_encryption_version", p.MinEncryptionVersion, "auto_rotate_period", p.AutoRotatePeriod)
+
+	return nil
+}
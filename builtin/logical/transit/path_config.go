@@ -3,11 +3,16 @@ package transit
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
 
+// minAutoRotatePeriod is the shortest period keys can be rotated
+// automatically at
+const minAutoRotatePeriod = time.Hour
+
 func (b *backend) pathConfig() *framework.Path {
 	return &framework.Path{
 		Pattern: "keys/" + framework.GenericNameRegex("name") + "/config",
@@ -46,6 +51,14 @@ the latest version of the key is allowed.`,
 				Type:        framework.TypeBool,
 				Description: `Enables taking a backup of the named key in plaintext format. Once set, this cannot be disabled.`,
 			},
+
+			"auto_rotate_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Amount of time after which the key is rotated
+automatically, measured from the creation of its
+newest version. Must be at least one hour. If set
+to zero, automatic rotation is disabled.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -96,6 +109,10 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 				return logical.ErrorResponse(
 					fmt.Sprintf("cannot set min decryption version of %d, latest key version is %d", minDecryptionVersion, p.LatestVersion)), nil
 			}
+			if minDecryptionVersion < p.MinAvailableVersion {
+				return logical.ErrorResponse(
+					fmt.Sprintf("cannot set min decryption version of %d, key versions older than %d were trimmed", minDecryptionVersion, p.MinAvailableVersion)), nil
+			}
 			p.MinDecryptionVersion = minDecryptionVersion
 			persistNeeded = true
 		}
@@ -164,6 +181,22 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		}
 	}
 
+	autoRotatePeriodRaw, ok := d.GetOk("auto_rotate_period")
+	if ok {
+		autoRotatePeriod := time.Duration(autoRotatePeriodRaw.(int)) * time.Second
+		if autoRotatePeriod != 0 && autoRotatePeriod < minAutoRotatePeriod {
+			return logical.ErrorResponse(fmt.Sprintf("auto rotate period must be zero or at least %s", minAutoRotatePeriod)), nil
+		}
+		if autoRotatePeriod != 0 && p.Imported && !p.AllowImportedKeyRotation {
+			return logical.ErrorResponse("imported keys that don't allow rotation can't be rotated automatically"), nil
+		}
+
+		if autoRotatePeriod != p.AutoRotatePeriod {
+			p.AutoRotatePeriod = autoRotatePeriod
+			persistNeeded = true
+		}
+	}
+
 	if !persistNeeded {
 		return nil, nil
 	}
@@ -180,5 +213,7 @@ const pathConfigHelpSyn = `Configure a named encryption key`
 const pathConfigHelpDesc = `
 This path is used to configure the named key. Currently, this
 supports adjusting the minimum version of the key allowed to
-be used for decryption via the min_decryption_version parameter.
+be used for decryption via the min_decryption_version parameter,
+and rotating the key automatically via the auto_rotate_period
+parameter.
 `
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
 )
@@ -222,3 +223,181 @@ func TestTransit_ConfigSettings(t *testing.T) {
 	testHMAC(3, true)
 	testHMAC(2, false)
 }
+
+func TestTransit_AutoRotate(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	doReq := func(path string, op logical.Operation, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+		return resp
+	}
+
+	doReq("keys/foo", logical.UpdateOperation, nil)
+
+	// Periods under an hour are rejected
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo/config",
+		Data: map[string]interface{}{
+			"auto_rotate_period": "30m",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got resp: %#v\nerr: %v", resp, err)
+	}
+
+	doReq("keys/foo/config", logical.UpdateOperation, map[string]interface{}{
+		"auto_rotate_period": "24h",
+	})
+	resp = doReq("keys/foo", logical.ReadOperation, nil)
+	if resp.Data["auto_rotate_period"] != int64(86400) {
+		t.Fatalf("bad: auto_rotate_period: %#v", resp.Data["auto_rotate_period"])
+	}
+
+	latestVersion := func() int {
+		p, lock, err := b.lm.GetPolicyShared(context.Background(), s, "foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		lock.RUnlock()
+		return p.LatestVersion
+	}
+
+	// The key is not rotated before the period has passed
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: s}); err != nil {
+		t.Fatal(err)
+	}
+	if v := latestVersion(); v != 1 {
+		t.Fatalf("bad: expected latest version 1, got %d", v)
+	}
+
+	// Age the newest version past the period
+	p, lock, err := b.lm.GetPolicyExclusive(context.Background(), s, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := p.Keys["1"]
+	entry.CreationTime = time.Now().Add(-25 * time.Hour)
+	p.Keys["1"] = entry
+	if err := p.Persist(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	lock.Unlock()
+
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: s}); err != nil {
+		t.Fatal(err)
+	}
+	if v := latestVersion(); v != 2 {
+		t.Fatalf("bad: expected latest version 2, got %d", v)
+	}
+
+	// The automatic rotation is recorded on the key, and manual rotations
+	// are not
+	doReq("keys/foo/rotate", logical.UpdateOperation, nil)
+	resp = doReq("keys/foo", logical.ReadOperation, nil)
+	autoRotations, ok := resp.Data["auto_rotations"].(map[string]time.Time)
+	if !ok || len(autoRotations) != 1 {
+		t.Fatalf("bad: auto_rotations: %#v", resp.Data["auto_rotations"])
+	}
+	if rotated := autoRotations["2"]; time.Since(rotated) > time.Minute {
+		t.Fatalf("bad: auto rotation time: %v", rotated)
+	}
+
+	// The new version is not rotated again
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: s}); err != nil {
+		t.Fatal(err)
+	}
+	if v := latestVersion(); v != 3 {
+		t.Fatalf("bad: expected latest version 3, got %d", v)
+	}
+}
+
+func TestTransit_AutoRotateMinEncryptionVersion(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	doReq := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	mustReq := func(path string, data map[string]interface{}) *logical.Response {
+		resp, err := doReq(path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+		return resp
+	}
+
+	mustReq("keys/foo", nil)
+	mustReq("keys/foo/rotate", nil)
+	mustReq("keys/foo/config", map[string]interface{}{
+		"min_encryption_version": 2,
+		"auto_rotate_period":     "1h",
+	})
+
+	// Age the newest version past the period
+	p, lock, err := b.lm.GetPolicyExclusive(context.Background(), s, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := p.Keys["2"]
+	entry.CreationTime = time.Now().Add(-2 * time.Hour)
+	p.Keys["2"] = entry
+	if err := p.Persist(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	lock.Unlock()
+
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: s}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The min versions are left as they were
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.ReadOperation,
+		Path:      "keys/foo",
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Data["latest_version"] != 3 || resp.Data["min_encryption_version"] != 2 || resp.Data["min_decryption_version"] != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// New encryptions use the new version, and the versions from the min
+	// encryption version can still be requested
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	resp = mustReq("encrypt/foo", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+	if !strings.HasPrefix(resp.Data["ciphertext"].(string), "vault:v3:") {
+		t.Fatalf("bad: ciphertext: %q", resp.Data["ciphertext"])
+	}
+	resp = mustReq("encrypt/foo", map[string]interface{}{
+		"plaintext":   plaintext,
+		"key_version": 2,
+	})
+	if !strings.HasPrefix(resp.Data["ciphertext"].(string), "vault:v2:") {
+		t.Fatalf("bad: ciphertext: %q", resp.Data["ciphertext"])
+	}
+	resp, err = doReq("encrypt/foo", map[string]interface{}{
+		"plaintext":   plaintext,
+		"key_version": 1,
+	})
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatalf("expected an error encrypting with version 1, got resp: %#v", resp)
+	}
+}
@@ -0,0 +1,349 @@
+package transit
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strconv"
+
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/helper/keysutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// kwpIV is the alternative initial value of AES key wrap with padding
+// (RFC 5649)
+const kwpIV = 0xA65959A6
+
+func (b *backend) pathImport() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/import",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"type": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "aes256-gcm96",
+				Description: `
+The type of the imported key. All the key types that can be created
+through the keys path are supported. Defaults to "aes256-gcm96".
+`,
+			},
+
+			"ciphertext": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Base64 encoded key material, wrapped
+as described in the help of this path.`,
+			},
+
+			"hash_function": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "sha2-256",
+				Description: `The hash function used with RSA-OAEP
+to wrap the ephemeral AES key. Valid values are "sha1",
+"sha2-224", "sha2-256", "sha2-384" and "sha2-512".
+Defaults to "sha2-256".`,
+			},
+
+			"allow_rotation": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Allows the key to be rotated, which
+generates the new key versions. By default, new
+versions of an imported key can only be imported.`,
+			},
+
+			"derived": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables key derivation mode. This
+allows for per-transaction unique
+keys for encryption operations.`,
+			},
+
+			"convergent_encryption": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Whether to support convergent encryption.
+This is only supported when using a key with
+key derivation enabled.`,
+			},
+
+			"exportable": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables keys to be exportable.
+This allows for all the valid keys
+in the key ring to be exported.`,
+			},
+
+			"allow_plaintext_backup": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables taking a backup of the named
+key in plaintext format. Once set,
+this cannot be disabled.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportWrite,
+		},
+
+		HelpSynopsis:    pathImportHelpSyn,
+		HelpDescription: pathImportHelpDesc,
+	}
+}
+
+func (b *backend) pathImportVersion() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/import_version",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"ciphertext": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Base64 encoded key material, wrapped
+as described in the help of the import path.`,
+			},
+
+			"hash_function": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "sha2-256",
+				Description: `The hash function used with RSA-OAEP
+to wrap the ephemeral AES key. Valid values are "sha1",
+"sha2-224", "sha2-256", "sha2-384" and "sha2-512".
+Defaults to "sha2-256".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportVersionWrite,
+		},
+
+		HelpSynopsis:    pathImportVersionHelpSyn,
+		HelpDescription: pathImportVersionHelpDesc,
+	}
+}
+
+func (b *backend) pathImportWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	keyType := d.Get("type").(string)
+	derived := d.Get("derived").(bool)
+	convergent := d.Get("convergent_encryption").(bool)
+
+	if !derived && convergent {
+		return logical.ErrorResponse("convergent encryption requires derivation to be enabled"), nil
+	}
+
+	polReq := keysutil.PolicyRequest{
+		Storage:                  req.Storage,
+		Name:                     name,
+		Derived:                  derived,
+		Convergent:               convergent,
+		Exportable:               d.Get("exportable").(bool),
+		AllowPlaintextBackup:     d.Get("allow_plaintext_backup").(bool),
+		AllowImportedKeyRotation: d.Get("allow_rotation").(bool),
+	}
+	var ok bool
+	polReq.KeyType, ok = parseKeyType(keyType)
+	if !ok {
+		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
+	}
+
+	key, err := b.unwrapImportedKey(ctx, req.Storage, d)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		return nil, err
+	}
+
+	err = b.lm.ImportPolicy(ctx, polReq, key)
+	if _, ok := err.(errutil.UserError); ok {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, err
+}
+
+func (b *backend) pathImportVersionWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	p, lock, err := b.lm.GetPolicyExclusive(ctx, req.Storage, name)
+	if lock != nil {
+		defer lock.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("key not found"), logical.ErrInvalidRequest
+	}
+	if !p.Imported {
+		return logical.ErrorResponse("versions can only be imported into imported keys"), logical.ErrInvalidRequest
+	}
+
+	key, err := b.unwrapImportedKey(ctx, req.Storage, d)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		return nil, err
+	}
+
+	err = p.Import(ctx, req.Storage, key)
+	if _, ok := err.(errutil.UserError); ok {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, err
+}
+
+// unwrapImportedKey returns the key material of the request's ciphertext,
+// which is the ephemeral AES key encrypted with the wrapping key using
+// RSA-OAEP, followed by the key material wrapped with the ephemeral key using
+// AES key wrap with padding.
+func (b *backend) unwrapImportedKey(ctx context.Context, s logical.Storage, d *framework.FieldData) ([]byte, error) {
+	ciphertextB64 := d.Get("ciphertext").(string)
+	if ciphertextB64 == "" {
+		return nil, errutil.UserError{Err: "'ciphertext' must be supplied"}
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(ciphertextB64)
+	if err != nil {
+		return nil, errutil.UserError{Err: "failed to base64-decode ciphertext"}
+	}
+
+	hashFunction := d.Get("hash_function").(string)
+	var hashFunc hash.Hash
+	switch hashFunction {
+	case "sha1":
+		hashFunc = sha1.New()
+	case "sha2-224":
+		hashFunc = sha256.New224()
+	case "sha2-256":
+		hashFunc = sha256.New()
+	case "sha2-384":
+		hashFunc = sha512.New384()
+	case "sha2-512":
+		hashFunc = sha512.New()
+	default:
+		return nil, errutil.UserError{Err: fmt.Sprintf("unsupported hash function %q", hashFunction)}
+	}
+
+	wrappingKey, err := b.getWrappingKey(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey := wrappingKey.Keys[strconv.Itoa(wrappingKey.LatestVersion)].RSAKey
+
+	rsaSize := rsaKey.Size()
+	if len(ciphertext) <= rsaSize {
+		return nil, errutil.UserError{Err: "ciphertext is too short to contain a wrapped ephemeral key and key material"}
+	}
+
+	ephemeralKey, err := rsa.DecryptOAEP(hashFunc, rand.Reader, rsaKey, ciphertext[:rsaSize], nil)
+	if err != nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("failed to decrypt ephemeral key: %v", err)}
+	}
+
+	key, err := kwpUnwrap(ephemeralKey, ciphertext[rsaSize:])
+	if err != nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("failed to unwrap key material: %v", err)}
+	}
+
+	return key, nil
+}
+
+// kwpUnwrap unwraps the ciphertext with the given AES key using AES key wrap
+// with padding (RFC 5649)
+func kwpUnwrap(kek, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < 16 || len(ciphertext)%8 != 0 {
+		return nil, fmt.Errorf("invalid wrapped key length %d", len(ciphertext))
+	}
+	n := len(ciphertext)/8 - 1
+
+	var a [8]byte
+	r := make([]byte, 8*n)
+	if n == 1 {
+		// A single block is encrypted with AES directly
+		var buf [16]byte
+		block.Decrypt(buf[:], ciphertext)
+		copy(a[:], buf[:8])
+		copy(r, buf[8:])
+	} else {
+		// The unwrapping process of RFC 3394
+		copy(a[:], ciphertext[:8])
+		copy(r, ciphertext[8:])
+		var buf [16]byte
+		for j := 5; j >= 0; j-- {
+			for i := n; i >= 1; i-- {
+				t := uint64(n*j + i)
+				binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(a[:])^t)
+				copy(buf[8:], r[(i-1)*8:i*8])
+				block.Decrypt(buf[:], buf[:])
+				copy(a[:], buf[:8])
+				copy(r[(i-1)*8:i*8], buf[8:])
+			}
+		}
+	}
+
+	// Check the integrity of the initial value and the padding
+	if binary.BigEndian.Uint32(a[:4]) != kwpIV {
+		return nil, fmt.Errorf("integrity check failed")
+	}
+	mli := int(binary.BigEndian.Uint32(a[4:]))
+	if mli <= 8*(n-1) || mli > 8*n {
+		return nil, fmt.Errorf("integrity check failed")
+	}
+	var padding [8]byte
+	if subtle.ConstantTimeCompare(r[mli:], padding[:len(r)-mli]) != 1 {
+		return nil, fmt.Errorf("integrity check failed")
+	}
+
+	return r[:mli], nil
+}
+
+const pathImportHelpSyn = `Imports an externally generated key into a new named key`
+
+const pathImportHelpDesc = `
+This path is used to create a named key from key material generated outside
+of Vault. The key material must be wrapped as follows:
+
+1. Generate an ephemeral 256-bit AES key.
+2. Wrap the key material with the ephemeral key using AES key wrap with
+   padding (RFC 5649). Symmetric keys are wrapped as raw bytes, asymmetric
+   keys as PKCS #8 DER-encoded private keys.
+3. Encrypt the ephemeral key with the public key returned by the
+   "wrapping_key" path, using RSA-OAEP with the hash function given by
+   "hash_function".
+4. Concatenate the encrypted ephemeral key and the wrapped key material, and
+   base64 encode the result.
+
+Imported keys can't be rotated unless "allow_rotation" is set; new versions
+are added through the "keys/<name>/import_version" path instead.
+`
+
+const pathImportVersionHelpSyn = `Imports an externally generated key as a new version of a named key`
+
+const pathImportVersionHelpDesc = `
+This path is used to import key material as the new latest version of a key
+created through the "keys/<name>/import" path. The key material must be of the
+key's type and is wrapped the same way as for the import path.
+`
@@ -0,0 +1,384 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTransit_kwpUnwrap(t *testing.T) {
+	// Test vectors from RFC 5649
+	kek, _ := hex.DecodeString("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	testCases := []struct {
+		key     string
+		wrapped string
+	}{
+		{
+			key:     "c37b7e6492584340bed12207808941155068f738",
+			wrapped: "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		},
+		{
+			key:     "466f7250617369",
+			wrapped: "afbeb0f07dfbf5419200f2ccb50bb24f",
+		},
+	}
+
+	for _, tc := range testCases {
+		wrapped, _ := hex.DecodeString(tc.wrapped)
+		key, err := kwpUnwrap(kek, wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(key) != tc.key {
+			t.Fatalf("bad: expected %s, got %x", tc.key, key)
+		}
+
+		if !bytes.Equal(kwpWrap(t, kek, key), wrapped) {
+			t.Fatalf("bad: wrapping %s did not give the test vector", tc.key)
+		}
+
+		wrapped[len(wrapped)-1] ^= 1
+		if _, err := kwpUnwrap(kek, wrapped); err == nil {
+			t.Fatal("expected an error unwrapping a modified key")
+		}
+	}
+}
+
+func TestTransit_Import(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testImport(t, "aes128-gcm96", randomBytes(t, 16), "encrypt-decrypt")
+	testImport(t, "aes256-gcm96", randomBytes(t, 32), "encrypt-decrypt")
+	testImport(t, "chacha20-poly1305", randomBytes(t, 32), "encrypt-decrypt")
+	testImport(t, "ecdsa-p256", marshalPKCS8(t, ecKey), "sign-verify")
+	testImport(t, "ecdsa-p384", marshalPKCS8(t, p384Key), "sign-verify")
+	testImport(t, "ed25519", marshalPKCS8(t, edKey), "sign-verify")
+	testImport(t, "rsa-2048", marshalPKCS8(t, rsaKey), "encrypt-decrypt")
+	testImport(t, "rsa-2048", marshalPKCS8(t, rsaKey), "sign-verify")
+}
+
+func testImport(t *testing.T, keyType string, key []byte, feature string) {
+	b, s := createBackendWithStorage(t)
+	wrappingKey := getWrappingKey(t, b, s)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Path:      "keys/test/import",
+		Operation: logical.UpdateOperation,
+		Storage:   s,
+		Data: map[string]interface{}{
+			"type":       keyType,
+			"ciphertext": wrapKey(t, wrappingKey, key),
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("resp: %#v\nerr: %v", resp, err)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Path:      "keys/test",
+		Operation: logical.ReadOperation,
+		Storage:   s,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Data["type"] != keyType || resp.Data["imported_key"] != true || resp.Data["allow_imported_key_rotation"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	plaintextB64 := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	switch feature {
+	case "encrypt-decrypt":
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Path:      "encrypt/test",
+			Operation: logical.UpdateOperation,
+			Storage:   s,
+			Data: map[string]interface{}{
+				"plaintext": plaintextB64,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Path:      "decrypt/test",
+			Operation: logical.UpdateOperation,
+			Storage:   s,
+			Data: map[string]interface{}{
+				"ciphertext": resp.Data["ciphertext"],
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+		if resp.Data["plaintext"] != plaintextB64 {
+			t.Fatalf("bad: plaintext: expected %q, got %q", plaintextB64, resp.Data["plaintext"])
+		}
+
+	case "sign-verify":
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Path:      "sign/test",
+			Operation: logical.UpdateOperation,
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input": plaintextB64,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+
+		resp, err = b.HandleRequest(context.Background(), &logical.Request{
+			Path:      "verify/test",
+			Operation: logical.UpdateOperation,
+			Storage:   s,
+			Data: map[string]interface{}{
+				"input":     plaintextB64,
+				"signature": resp.Data["signature"],
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+		if resp.Data["valid"] != true {
+			t.Fatalf("bad: signature was not valid")
+		}
+	}
+}
+
+func TestTransit_ImportVersion(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+	wrappingKey := getWrappingKey(t, b, s)
+
+	importReq := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Path:      path,
+			Operation: logical.UpdateOperation,
+			Storage:   s,
+			Data:      data,
+		})
+	}
+
+	// Keys of the wrong size are rejected
+	resp, err := importReq("keys/test/import", map[string]interface{}{
+		"ciphertext": wrapKey(t, wrappingKey, randomBytes(t, 16)),
+	})
+	if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got resp: %#v\nerr: %v", resp, err)
+	}
+
+	// Ciphertexts that were not wrapped with the wrapping key are rejected
+	otherKey, err := rsa.GenerateKey(rand.Reader, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = importReq("keys/test/import", map[string]interface{}{
+		"ciphertext": wrapKey(t, &otherKey.PublicKey, randomBytes(t, 32)),
+	})
+	if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got resp: %#v\nerr: %v", resp, err)
+	}
+
+	// Derived ed25519 keys wouldn't sign with the imported key, so they are
+	// rejected
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = importReq("keys/test/import", map[string]interface{}{
+		"type":       "ed25519",
+		"derived":    true,
+		"ciphertext": wrapKey(t, wrappingKey, marshalPKCS8(t, edKey)),
+	})
+	if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got resp: %#v\nerr: %v", resp, err)
+	}
+
+	resp, err = importReq("keys/test/import", map[string]interface{}{
+		"ciphertext": wrapKey(t, wrappingKey, randomBytes(t, 32)),
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("resp: %#v\nerr: %v", resp, err)
+	}
+
+	// Importing into an existing key is rejected
+	resp, err = importReq("keys/test/import", map[string]interface{}{
+		"ciphertext": wrapKey(t, wrappingKey, randomBytes(t, 32)),
+	})
+	if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got resp: %#v\nerr: %v", resp, err)
+	}
+
+	// Imported keys can't be rotated by default
+	resp, err = importReq("keys/test/rotate", nil)
+	if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got resp: %#v\nerr: %v", resp, err)
+	}
+
+	resp, err = importReq("keys/test/import_version", map[string]interface{}{
+		"ciphertext": wrapKey(t, wrappingKey, randomBytes(t, 32)),
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("resp: %#v\nerr: %v", resp, err)
+	}
+
+	p, lock, err := b.lm.GetPolicyShared(context.Background(), s, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.RUnlock()
+	if p.LatestVersion != 2 {
+		t.Fatalf("bad: expected latest version 2, got %d", p.LatestVersion)
+	}
+
+	// Versions can't be imported into generated keys
+	resp, err = importReq("keys/generated", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("resp: %#v\nerr: %v", resp, err)
+	}
+	resp, err = importReq("keys/generated/import_version", map[string]interface{}{
+		"ciphertext": wrapKey(t, wrappingKey, randomBytes(t, 32)),
+	})
+	if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got resp: %#v\nerr: %v", resp, err)
+	}
+}
+
+func getWrappingKey(t *testing.T, b *backend, s logical.Storage) *rsa.PublicKey {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Path:      "wrapping_key",
+		Operation: logical.ReadOperation,
+		Storage:   s,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("resp: %#v\nerr: %v", resp, err)
+	}
+
+	block, _ := pem.Decode([]byte(resp.Data["public_key"].(string)))
+	if block == nil {
+		t.Fatal("failed to decode wrapping key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub.(*rsa.PublicKey)
+}
+
+// wrapKey wraps the key material the way the import endpoints expect it
+func wrapKey(t *testing.T, wrappingKey *rsa.PublicKey, key []byte) string {
+	ephemeralKey := randomBytes(t, 32)
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, wrappingKey, ephemeralKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(append(encryptedKey, kwpWrap(t, ephemeralKey, key)...))
+}
+
+// kwpWrap wraps the key with AES key wrap with padding (RFC 5649)
+func kwpWrap(t *testing.T, kek, key []byte) []byte {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := (len(key) + 7) / 8
+	var a [8]byte
+	binary.BigEndian.PutUint32(a[:4], kwpIV)
+	binary.BigEndian.PutUint32(a[4:], uint32(len(key)))
+	r := make([]byte, 8*n)
+	copy(r, key)
+
+	var buf [16]byte
+	if n == 1 {
+		copy(buf[:8], a[:])
+		copy(buf[8:], r)
+		block.Encrypt(buf[:], buf[:])
+		return buf[:]
+	}
+
+	for j := 0; j <= 5; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], a[:])
+			copy(buf[8:], r[(i-1)*8:i*8])
+			block.Encrypt(buf[:], buf[:])
+			binary.BigEndian.PutUint64(a[:], binary.BigEndian.Uint64(buf[:8])^uint64(n*j+i))
+			copy(r[(i-1)*8:i*8], buf[8:])
+		}
+	}
+
+	return append(a[:], r...)
+}
+
+func marshalPKCS8(t *testing.T, key interface{}) []byte {
+	if edKey, ok := key.(ed25519.PrivateKey); ok {
+		// Ed25519 keys are encoded as their seed, as in RFC 8410
+		seed, err := asn1.Marshal(edKey[:32])
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := asn1.Marshal(struct {
+			Version    int
+			Algo       pkix.AlgorithmIdentifier
+			PrivateKey []byte
+		}{
+			Algo: pkix.AlgorithmIdentifier{
+				Algorithm: asn1.ObjectIdentifier{1, 3, 101, 112},
+			},
+			PrivateKey: seed,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func randomBytes(t *testing.T, length int) []byte {
+	b, err := uuid.GenerateRandomBytes(length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
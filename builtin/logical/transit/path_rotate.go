@@ -3,6 +3,7 @@ package transit
 import (
 	"context"
 
+	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -43,6 +44,9 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 
 	// Rotate the policy
 	err = p.Rotate(ctx, req.Storage)
+	if _, ok := err.(errutil.UserError); ok {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
 
 	return nil, err
 }
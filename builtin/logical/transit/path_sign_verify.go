@@ -67,7 +67,7 @@ to the min_encryption_version configured on the key.`,
 
 			"prehashed": &framework.FieldSchema{
 				Type:        framework.TypeBool,
-				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter.`,
+				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048', 'rsa-3072' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter.`,
 			},
 			"signature_algorithm": &framework.FieldSchema{
 				Type: framework.TypeString,
@@ -140,7 +140,7 @@ Defaults to "sha2-256". Not valid for all key types.`,
 
 			"prehashed": &framework.FieldSchema{
 				Type:        framework.TypeBool,
-				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter.`,
+				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048', 'rsa-3072' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter.`,
 			},
 			"signature_algorithm": &framework.FieldSchema{
 				Type: framework.TypeString,
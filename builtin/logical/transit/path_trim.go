@@ -0,0 +1,76 @@
+package transit
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func (b *backend) pathTrim() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/trim",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"min_available_version": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The minimum version of the key to keep. Older
+versions are permanently deleted. This can't be
+greater than the minimum decryption version, nor
+the minimum encryption version if it is set.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTrimUpdate,
+		},
+
+		HelpSynopsis:    pathTrimHelpSyn,
+		HelpDescription: pathTrimHelpDesc,
+	}
+}
+
+func (b *backend) pathTrimUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	minAvailableVersionRaw, ok := d.GetOk("min_available_version")
+	if !ok {
+		return logical.ErrorResponse("'min_available_version' must be supplied"), nil
+	}
+	minAvailableVersion := minAvailableVersionRaw.(int)
+	if minAvailableVersion < 1 {
+		return logical.ErrorResponse("'min_available_version' must be at least 1"), nil
+	}
+
+	p, lock, err := b.lm.GetPolicyExclusive(ctx, req.Storage, name)
+	if lock != nil {
+		defer lock.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("key not found"), logical.ErrInvalidRequest
+	}
+
+	err = p.Trim(ctx, req.Storage, minAvailableVersion)
+	if _, ok := err.(errutil.UserError); ok {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, err
+}
+
+const pathTrimHelpSyn = `Trim key versions of a named key`
+
+const pathTrimHelpDesc = `
+This path is used to permanently delete the versions of the named key that are
+older than min_available_version from the key and its archive. Ciphertexts
+encrypted and signatures made with the deleted versions can no longer be
+decrypted or verified.
+`
@@ -0,0 +1,131 @@
+package transit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTransit_Trim(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	doReq := func(path string, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+		return resp
+	}
+	doErrReq := func(path string, data map[string]interface{}) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected an error for %s with %#v", path, data)
+		}
+	}
+
+	doReq("keys/aes", nil)
+
+	// Keep a ciphertext of each version
+	ciphertexts := map[int]string{}
+	for i := 1; i <= 10; i++ {
+		if i > 1 {
+			doReq("keys/aes/rotate", nil)
+		}
+		resp := doReq("encrypt/aes", map[string]interface{}{
+			"plaintext": "dGhlIHF1aWNrIGJyb3duIGZveA==",
+		})
+		ciphertexts[i] = resp.Data["ciphertext"].(string)
+	}
+
+	checkArchive := func(expectedLength int) {
+		p, lock, err := b.lm.GetPolicyShared(context.Background(), s, "aes")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer lock.RUnlock()
+
+		archive, err := p.LoadArchive(context.Background(), s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archive.Keys) != expectedLength {
+			t.Fatalf("bad: expected archive length %d, got %d", expectedLength, len(archive.Keys))
+		}
+	}
+	// The archive is indexed by version, starting at 0
+	checkArchive(11)
+
+	// Versions above the min decryption version can't be trimmed
+	doReq("keys/aes/config", map[string]interface{}{
+		"min_decryption_version": 5,
+	})
+	doErrReq("keys/aes/trim", map[string]interface{}{
+		"min_available_version": 6,
+	})
+	doErrReq("keys/aes/trim", map[string]interface{}{
+		"min_available_version": 0,
+	})
+
+	doReq("keys/aes/trim", map[string]interface{}{
+		"min_available_version": 5,
+	})
+	checkArchive(6)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.ReadOperation,
+		Path:      "keys/aes",
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Data["min_available_version"] != 5 {
+		t.Fatalf("bad: min_available_version: %#v", resp.Data["min_available_version"])
+	}
+
+	// Trimmed versions can't be brought back
+	doErrReq("keys/aes/trim", map[string]interface{}{
+		"min_available_version": 3,
+	})
+	doErrReq("keys/aes/config", map[string]interface{}{
+		"min_decryption_version": 4,
+	})
+
+	// Versions are still moved to and from the archive at the right index
+	doReq("keys/aes/config", map[string]interface{}{
+		"min_decryption_version": 8,
+	})
+	doReq("keys/aes/config", map[string]interface{}{
+		"min_decryption_version": 5,
+	})
+	for i := 5; i <= 10; i++ {
+		doReq("decrypt/aes", map[string]interface{}{
+			"ciphertext": ciphertexts[i],
+		})
+	}
+
+	doReq("keys/aes/rotate", nil)
+	checkArchive(7)
+
+	doReq("keys/aes/config", map[string]interface{}{
+		"min_decryption_version": 11,
+	})
+	doReq("keys/aes/trim", map[string]interface{}{
+		"min_available_version": 11,
+	})
+	checkArchive(1)
+	doErrReq("decrypt/aes", map[string]interface{}{
+		"ciphertext": ciphertexts[10],
+	})
+}
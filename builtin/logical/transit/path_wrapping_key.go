@@ -0,0 +1,93 @@
+package transit
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/keysutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// wrappingKeyName is the name of the RSA key used to wrap imported keys.
+	// It is stored under its own prefix so that it can't be used or
+	// managed through the keys endpoints.
+	wrappingKeyName          = "wrapping-key"
+	wrappingKeyStoragePrefix = "import/"
+)
+
+func (b *backend) pathWrappingKey() *framework.Path {
+	return &framework.Path{
+		Pattern: "wrapping_key",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathWrappingKeyRead,
+		},
+
+		HelpSynopsis:    pathWrappingKeyHelpSyn,
+		HelpDescription: pathWrappingKeyHelpDesc,
+	}
+}
+
+func (b *backend) pathWrappingKeyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	p, err := b.getWrappingKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(p.Keys[strconv.Itoa(p.LatestVersion)].RSAKey.Public())
+	if err != nil {
+		return nil, errwrap.Wrapf("error marshaling wrapping key: {{err}}", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	})
+	if len(pemBytes) == 0 {
+		return nil, fmt.Errorf("failed to PEM-encode wrapping key")
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": string(pemBytes),
+		},
+	}, nil
+}
+
+// getWrappingKey returns the wrapping key policy, generating it on first use
+func (b *backend) getWrappingKey(ctx context.Context, s logical.Storage) (*keysutil.Policy, error) {
+	b.wrappingKeyLock.Lock()
+	defer b.wrappingKeyLock.Unlock()
+
+	p, err := keysutil.LoadPolicy(ctx, s, wrappingKeyStoragePrefix+"policy/"+wrappingKeyName)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return p, nil
+	}
+
+	p = keysutil.NewPolicy(keysutil.PolicyConfig{
+		Name:          wrappingKeyName,
+		Type:          keysutil.KeyType_RSA4096,
+		StoragePrefix: wrappingKeyStoragePrefix,
+	})
+	if err := p.Rotate(ctx, s); err != nil {
+		return nil, errwrap.Wrapf("error generating wrapping key: {{err}}", err)
+	}
+
+	return p, nil
+}
+
+const pathWrappingKeyHelpSyn = `Returns the public key to use for wrapping imported keys`
+
+const pathWrappingKeyHelpDesc = `
+This path returns the PEM-encoded public key of the 4096-bit RSA key used to
+wrap keys imported through the "keys/<name>/import" and
+"keys/<name>/import_version" paths. The key is generated on first read.
+`
@@ -0,0 +1,512 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/command/agent/auth/approle"
+	"github.com/hashicorp/vault/command/agent/auth/aws"
+	"github.com/hashicorp/vault/command/agent/auth/cert"
+	"github.com/hashicorp/vault/command/agent/auth/kubernetes"
+	"github.com/hashicorp/vault/command/agent/cache"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/command/agent/sink/file"
+	"github.com/hashicorp/vault/command/agent/sink/inmem"
+	"github.com/hashicorp/vault/command/agent/template"
+	"github.com/hashicorp/vault/command/server"
+	gatedwriter "github.com/hashicorp/vault/helper/gated-writer"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/version"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*AgentCommand)(nil)
+var _ cli.CommandAutocomplete = (*AgentCommand)(nil)
+
+type AgentCommand struct {
+	*BaseCommand
+
+	ShutdownCh chan struct{}
+
+	logWriter io.Writer
+	logGate   *gatedwriter.Writer
+	logger    log.Logger
+
+	startedCh chan (struct{}) // for tests
+
+	flagConfigs  []string
+	flagLogLevel string
+
+	flagTestVerifyOnly bool
+	flagCombineLogs    bool
+}
+
+func (c *AgentCommand) Synopsis() string {
+	return "Start a Vault agent"
+}
+
+func (c *AgentCommand) Help() string {
+	helpText := `
+Usage: vault agent [options]
+
+  This command starts a Vault agent that can perform automatic authentication
+  in certain environments.
+
+  The agent logs in with the configured auth method, keeps the resulting token
+  renewed, logs in again if renewal fails, and writes each new token to the
+  configured sinks.
+
+  Start an agent with a configuration file:
+
+      $ vault agent -config=/etc/vault/config.hcl
+
+  For a full list of examples, please see the documentation.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AgentCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   "config",
+		Target: &c.flagConfigs,
+		Completion: complete.PredictOr(
+			complete.PredictFiles("*.hcl"),
+			complete.PredictFiles("*.json"),
+		),
+		Usage: "Path to a configuration file. This configuration file should " +
+			"contain only agent directives.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "log-level",
+		Target:     &c.flagLogLevel,
+		Default:    "info",
+		EnvVar:     "VAULT_LOG_LEVEL",
+		Completion: complete.PredictSet("trace", "debug", "info", "warn", "err"),
+		Usage: "Log verbosity level. Supported values (in order of detail) are " +
+			"\"trace\", \"debug\", \"info\", \"warn\", and \"err\".",
+	})
+
+	// Internal-only flags to follow.
+	//
+	// Why hello there little source code reader! Welcome to the Vault source
+	// code. The remaining options are intentionally undocumented and come with
+	// no warranty or backwards-compatability promise. Do not use these flags
+	// in production. Do not build automation using these flags. Unless you are
+	// developing against Vault, you should not need any of these flags.
+
+	f.BoolVar(&BoolVar{
+		Name:    "combine-logs",
+		Target:  &c.flagCombineLogs,
+		Default: false,
+		Hidden:  true,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "test-verify-only",
+		Target:  &c.flagTestVerifyOnly,
+		Default: false,
+		Hidden:  true,
+	})
+
+	// End internal-only flags.
+
+	return set
+}
+
+func (c *AgentCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *AgentCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AgentCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	// Create a logger. We wrap it in a gated writer so that it doesn't
+	// start logging too early.
+	c.logGate = &gatedwriter.Writer{Writer: os.Stderr}
+	c.logWriter = c.logGate
+	if c.flagCombineLogs {
+		c.logWriter = os.Stdout
+	}
+	var level log.Level
+	c.flagLogLevel = strings.ToLower(strings.TrimSpace(c.flagLogLevel))
+	switch c.flagLogLevel {
+	case "trace":
+		level = log.Trace
+	case "debug":
+		level = log.Debug
+	case "notice", "info", "":
+		level = log.Info
+	case "warn", "warning":
+		level = log.Warn
+	case "err", "error":
+		level = log.Error
+	default:
+		c.UI.Error(fmt.Sprintf("Unknown log level: %s", c.flagLogLevel))
+		return 1
+	}
+
+	if c.logger == nil {
+		c.logger = logging.NewVaultLoggerWithWriter(c.logWriter, level)
+	}
+
+	// Validation
+	if len(c.flagConfigs) != 1 {
+		c.UI.Error("Must specify exactly one config path using -config")
+		return 1
+	}
+
+	// Load the configuration
+	config, err := config.LoadConfig(c.flagConfigs[0])
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfigs[0], err))
+		return 1
+	}
+
+	// Ensure at least one config was found.
+	if config == nil {
+		c.UI.Output(wrapAtLength(
+			"No configuration read. Please provide the configuration with the " +
+				"-config flag."))
+		return 1
+	}
+	if config.AutoAuth == nil && config.Cache == nil {
+		c.UI.Error("No auto_auth or cache block found in config file")
+		return 1
+	}
+
+	infoKeys := make([]string, 0, 10)
+	info := make(map[string]string)
+	info["log level"] = c.flagLogLevel
+	infoKeys = append(infoKeys, "log level")
+
+	infoKeys = append(infoKeys, "version")
+	verInfo := version.GetVersion()
+	info["version"] = verInfo.FullVersionNumber(false)
+	if verInfo.Revision != "" {
+		info["version sha"] = strings.Trim(verInfo.Revision, "'")
+		infoKeys = append(infoKeys, "version sha")
+	}
+	infoKeys = append(infoKeys, "cgo")
+	info["cgo"] = "disabled"
+	if version.CgoEnabled {
+		info["cgo"] = "enabled"
+	}
+
+	// Agent configuration output
+	padding := 24
+	sort.Strings(infoKeys)
+	c.UI.Output("==> Vault agent configuration:\n")
+	for _, k := range infoKeys {
+		c.UI.Output(fmt.Sprintf(
+			"%s%s: %s",
+			strings.Repeat(" ", padding-len(k)),
+			strings.Title(k),
+			info[k]))
+	}
+	c.UI.Output("")
+
+	// Tests might not want to start an agent and just want to verify the
+	// configuration.
+	if c.flagTestVerifyOnly {
+		return 0
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf(
+			"Error fetching client: %v",
+			err))
+		return 1
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	var method auth.AuthMethod
+	var sinks []*sink.SinkConfig
+	if config.AutoAuth != nil {
+		for _, sc := range config.AutoAuth.Sinks {
+			switch sc.Type {
+			case "file":
+				config := &sink.SinkConfig{
+					Logger:  c.logger.Named("sink.file"),
+					Config:  sc.Config,
+					Client:  client,
+					WrapTTL: sc.WrapTTL,
+					DHType:  sc.DHType,
+					DHPath:  sc.DHPath,
+					AAD:     sc.AAD,
+				}
+				s, err := file.NewFileSink(config)
+				if err != nil {
+					c.UI.Error(errwrap.Wrapf("Error creating file sink: {{err}}", err).Error())
+					cancelFunc()
+					return 1
+				}
+				config.Sink = s
+				sinks = append(sinks, config)
+			default:
+				c.UI.Error(fmt.Sprintf("Unknown sink type %q", sc.Type))
+				cancelFunc()
+				return 1
+			}
+		}
+
+		authConfig := &auth.AuthConfig{
+			Logger:    c.logger.Named(fmt.Sprintf("auth.%s", config.AutoAuth.Method.Type)),
+			MountPath: config.AutoAuth.Method.MountPath,
+			Config:    config.AutoAuth.Method.Config,
+		}
+		switch config.AutoAuth.Method.Type {
+		case "approle":
+			method, err = approle.NewApproleAuthMethod(authConfig)
+		case "aws":
+			method, err = aws.NewAWSAuthMethod(authConfig)
+		case "cert":
+			method, err = cert.NewCertAuthMethod(authConfig)
+		case "kubernetes":
+			method, err = kubernetes.NewKubernetesAuthMethod(authConfig)
+		default:
+			c.UI.Error(fmt.Sprintf("Unknown auth method %q", config.AutoAuth.Method.Type))
+			cancelFunc()
+			return 1
+		}
+		if err != nil {
+			c.UI.Error(errwrap.Wrapf(fmt.Sprintf("Error creating %s auth method: {{err}}", config.AutoAuth.Method.Type), err).Error())
+			cancelFunc()
+			return 1
+		}
+	}
+
+	// The template server receives the auto-auth token as one of the sinks
+	var ts *template.Server
+	if len(config.Templates) > 0 {
+		ts, err = template.NewServer(&template.ServerConfig{
+			Logger:        c.logger.Named("template.server"),
+			Client:        client,
+			Templates:     config.Templates,
+			ExitAfterAuth: config.ExitAfterAuth,
+		})
+		if err != nil {
+			c.UI.Error(errwrap.Wrapf("Error creating template server: {{err}}", err).Error())
+			cancelFunc()
+			return 1
+		}
+		sinks = append(sinks, &sink.SinkConfig{
+			Logger: c.logger.Named("sink.template"),
+			Sink:   ts,
+		})
+	}
+
+	// Start the caching proxy, if configured
+	if config.Cache != nil {
+		cacheLogger := c.logger.Named("cache")
+
+		// Create the API proxier
+		apiProxy, err := cache.NewAPIProxy(&cache.APIProxyConfig{
+			Client: client,
+			Logger: cacheLogger.Named("apiproxy"),
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating API proxy: %v", err))
+			cancelFunc()
+			return 1
+		}
+
+		// Create the lease cache proxier and set its underlying proxier to
+		// the API proxier.
+		leaseCache, err := cache.NewLeaseCache(&cache.LeaseCacheConfig{
+			Client:      client,
+			BaseContext: ctx,
+			Proxier:     apiProxy,
+			Logger:      cacheLogger.Named("leasecache"),
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating lease cache: %v", err))
+			cancelFunc()
+			return 1
+		}
+
+		// Keep the auto-auth token in memory so it can be used for
+		// requests that don't carry a token
+		var tokenSource sink.SinkReader
+		if config.Cache.UseAutoAuthToken {
+			cacheLogger.Debug("auto-auth token is allowed to be used; configuring inmem sink")
+			inmemSink, err := inmem.New(&sink.SinkConfig{
+				Logger: cacheLogger,
+			})
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error creating inmem sink for cache: %v", err))
+				cancelFunc()
+				return 1
+			}
+			sinks = append(sinks, &sink.SinkConfig{
+				Logger: cacheLogger,
+				Sink:   inmemSink,
+			})
+			tokenSource = inmemSink.(sink.SinkReader)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/agent/v1/cache-clear", leaseCache.HandleCacheClear(ctx))
+		mux.Handle("/", cache.ProxyHandler(ctx, cacheLogger, leaseCache, tokenSource))
+
+		for _, lnConfig := range config.Listeners {
+			ln, props, _, err := server.NewListener(lnConfig.Type, lnConfig.Config, c.logWriter, c.UI)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error starting listener: %v", err))
+				cancelFunc()
+				return 1
+			}
+			defer ln.Close()
+
+			scheme := "https://"
+			if props["tls"] == "disabled" {
+				scheme = "http://"
+			}
+
+			srv := &http.Server{
+				Handler:           mux,
+				ReadHeaderTimeout: 10 * time.Second,
+				ReadTimeout:       30 * time.Second,
+				IdleTimeout:       5 * time.Minute,
+				ErrorLog:          cacheLogger.StandardLogger(nil),
+			}
+			go srv.Serve(ln)
+			c.UI.Output(fmt.Sprintf("==> Vault agent cache listening on: %s%s", scheme, ln.Addr().String()))
+		}
+	}
+
+	// Output the header that the agent has started
+	if !c.flagCombineLogs {
+		c.UI.Output("==> Vault agent started! Log data will stream in below:\n")
+	}
+
+	// Inform any tests that the agent is ready
+	select {
+	case c.startedCh <- struct{}{}:
+	default:
+	}
+
+	var ssDoneCh, ahDoneCh, tsDoneCh chan struct{}
+	if method != nil {
+		ss := sink.NewSinkServer(&sink.SinkServerConfig{
+			Logger:        c.logger.Named("sink.server"),
+			Client:        client,
+			ExitAfterAuth: config.ExitAfterAuth,
+		})
+
+		ah := auth.NewAuthHandler(&auth.AuthHandlerConfig{
+			Logger:  c.logger.Named("auth.handler"),
+			Client:  client,
+			WrapTTL: config.AutoAuth.Method.WrapTTL,
+		})
+
+		go ah.Run(ctx, method)
+		go ss.Run(ctx, ah.OutputCh, sinks)
+
+		ssDoneCh, ahDoneCh = ss.DoneCh, ah.DoneCh
+	}
+
+	if ts != nil {
+		go ts.Run(ctx)
+		tsDoneCh = ts.DoneCh
+	}
+
+	// Release the log gate.
+	c.logGate.Flush()
+
+	// Write out the PID to the file now that the agent has successfully started
+	if err := c.storePidFile(config.PidFile); err != nil {
+		c.UI.Error(fmt.Sprintf("Error storing PID: %s", err))
+		cancelFunc()
+		return 1
+	}
+
+	defer func() {
+		if err := c.removePidFile(config.PidFile); err != nil {
+			c.UI.Error(fmt.Sprintf("Error deleting the PID file: %s", err))
+		}
+	}()
+
+	select {
+	case <-ssDoneCh:
+		// This will happen if we exit-on-auth
+		if tsDoneCh != nil {
+			select {
+			case <-tsDoneCh:
+			case <-c.ShutdownCh:
+			}
+		}
+		c.UI.Output("==> Sinks finished, exiting")
+		cancelFunc()
+	case <-c.ShutdownCh:
+		c.UI.Output("==> Vault agent shutdown triggered")
+		cancelFunc()
+		if method != nil {
+			<-ahDoneCh
+			<-ssDoneCh
+		}
+		if tsDoneCh != nil {
+			<-tsDoneCh
+		}
+	}
+
+	return 0
+}
+
+// storePidFile is used to write out our PID to a file if necessary
+func (c *AgentCommand) storePidFile(pidPath string) error {
+	// Quit fast if no pidfile
+	if pidPath == "" {
+		return nil
+	}
+
+	// Open the PID file
+	pidFile, err := os.OpenFile(pidPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errwrap.Wrapf("could not open pid file: {{err}}", err)
+	}
+	defer pidFile.Close()
+
+	// Write out the PID
+	pid := os.Getpid()
+	_, err = pidFile.WriteString(fmt.Sprintf("%d", pid))
+	if err != nil {
+		return errwrap.Wrapf("could not write to pid file: {{err}}", err)
+	}
+	return nil
+}
+
+// removePidFile is used to cleanup the PID file if necessary
+func (c *AgentCommand) removePidFile(pidPath string) error {
+	if pidPath == "" {
+		return nil
+	}
+	return os.Remove(pidPath)
+}
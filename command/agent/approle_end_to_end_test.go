@@ -0,0 +1,286 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
+	"github.com/hashicorp/vault/command/agent/auth"
+	agentapprole "github.com/hashicorp/vault/command/agent/auth/approle"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/command/agent/sink/file"
+	"github.com/hashicorp/vault/helper/dhutil"
+	"github.com/hashicorp/vault/helper/logging"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestAppRoleEndToEnd(t *testing.T) {
+	logger := logging.NewVaultLogger(hclog.Trace)
+	coreConfig := &vault.CoreConfig{
+		DisableMlock: true,
+		DisableCache: true,
+		Logger:       hclog.NewNullLogger(),
+		CredentialBackends: map[string]logical.Factory{
+			"approle": credAppRole.Factory,
+		},
+	}
+
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	cores := cluster.Cores
+
+	vault.TestWaitActive(t, cores[0].Core)
+
+	client := cores[0].Client
+
+	err := client.Sys().EnableAuthWithOptions("approle", &api.EnableAuthOptions{
+		Type: "approle",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Logical().Write("auth/approle/role/test1", map[string]interface{}{
+		"bind_secret_id": "true",
+		"token_ttl":      "3s",
+		"token_max_ttl":  "10s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Logical().Write("auth/approle/role/test1/secret-id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretID := resp.Data["secret_id"].(string)
+
+	resp, err = client.Logical().Read("auth/approle/role/test1/role-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleID := resp.Data["role_id"].(string)
+
+	dir, err := ioutil.TempDir("", "auth.approle.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	role := filepath.Join(dir, "role")
+	secret := filepath.Join(dir, "secret")
+	out := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(role, []byte(roleID), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(secret, []byte(secretID), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	timer := time.AfterFunc(30*time.Second, func() {
+		cancelFunc()
+	})
+	defer timer.Stop()
+
+	am, err := agentapprole.NewApproleAuthMethod(&auth.AuthConfig{
+		Logger:    logger.Named("auth.approle"),
+		MountPath: "auth/approle",
+		Config: map[string]interface{}{
+			"role_id_file_path":   role,
+			"secret_id_file_path": secret,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ahConfig := &auth.AuthHandlerConfig{
+		Logger: logger.Named("auth.handler"),
+		Client: client,
+	}
+	ah := auth.NewAuthHandler(ahConfig)
+	go ah.Run(ctx, am)
+	defer func() {
+		<-ah.DoneCh
+	}()
+
+	config := &sink.SinkConfig{
+		Logger: logger.Named("sink.file"),
+		Config: map[string]interface{}{
+			"path": out,
+		},
+	}
+	fs, err := file.NewFileSink(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Sink = fs
+
+	ss := sink.NewSinkServer(&sink.SinkServerConfig{
+		Logger: logger.Named("sink.server"),
+		Client: client,
+	})
+	go ss.Run(ctx, ah.OutputCh, []*sink.SinkConfig{config})
+	defer func() {
+		<-ss.DoneCh
+	}()
+
+	// This has to be after the other defers so it happens first
+	defer cancelFunc()
+
+	// Check that no sink file exists
+	if _, err := os.Lstat(out); err == nil {
+		t.Fatal("expected err")
+	} else if !os.IsNotExist(err) {
+		t.Fatal("expected notexist err")
+	}
+
+	checkToken := func() string {
+		timeout := time.Now().Add(10 * time.Second)
+		for {
+			if time.Now().After(timeout) {
+				t.Fatal("did not find a written token after timeout")
+			}
+			val, err := ioutil.ReadFile(out)
+			if err == nil {
+				os.Remove(out)
+				if len(val) == 0 {
+					t.Fatal("written token was empty")
+				}
+
+				client.SetToken(string(val))
+				secret, err := client.Auth().Token().LookupSelf()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return secret.Data["entity_id"].(string)
+			}
+			time.Sleep(250 * time.Millisecond)
+		}
+	}
+	origEntity := checkToken()
+
+	// The secret ID file is removed after it is used
+	if _, err := os.Lstat(secret); !os.IsNotExist(err) {
+		t.Fatalf("expected secret ID file to be removed, got %v", err)
+	}
+
+	// Make sure the token is renewed and, once it reaches its max TTL, that
+	// the agent logs in again with the cached secret ID
+	time.Sleep(12 * time.Second)
+	if newEntity := checkToken(); newEntity != origEntity {
+		t.Fatal("did not find same entity")
+	}
+}
+
+func TestSinkServer_WrapAndEncrypt(t *testing.T) {
+	logger := logging.NewVaultLogger(hclog.Trace)
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		DisableMlock: true,
+		DisableCache: true,
+		Logger:       hclog.NewNullLogger(),
+	}, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+	client := cluster.Cores[0].Client
+
+	dir, err := ioutil.TempDir("", "sink.dh.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Write out the public key the sink should encrypt for
+	pub, pri, err := dhutil.GeneratePublicPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubInfo, err := json.Marshal(&dhutil.PublicKeyInfo{Curve25519PublicKey: pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dhPath := filepath.Join(dir, "dh")
+	if err := ioutil.WriteFile(dhPath, pubInfo, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "token")
+	config := &sink.SinkConfig{
+		Logger:  logger.Named("sink.file"),
+		WrapTTL: time.Minute,
+		DHType:  "curve25519",
+		DHPath:  dhPath,
+		AAD:     "foobar",
+		Config: map[string]interface{}{
+			"path": out,
+		},
+	}
+	fs, err := file.NewFileSink(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Sink = fs
+
+	ss := sink.NewSinkServer(&sink.SinkServerConfig{
+		Logger:        logger.Named("sink.server"),
+		Client:        client,
+		ExitAfterAuth: true,
+	})
+
+	in := make(chan string, 1)
+	in <- cluster.RootToken
+	ss.Run(context.Background(), in, []*sink.SinkConfig{config})
+
+	val, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var envelope dhutil.Envelope
+	if err := json.Unmarshal(val, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	key, err := dhutil.GenerateSharedKey(pri, envelope.Curve25519PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := dhutil.DecryptAES(key, envelope.EncryptedPayload, envelope.Nonce, []byte("foobar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wrapInfo api.SecretWrapInfo
+	if err := json.Unmarshal(plaintext, &wrapInfo); err != nil {
+		t.Fatal(err)
+	}
+	if wrapInfo.Token == "" {
+		t.Fatal("expected a wrapping token")
+	}
+
+	unwrapped, err := client.Logical().Unwrap(wrapInfo.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unwrapped.Data["token"] != cluster.RootToken {
+		t.Fatalf("bad: %#v", unwrapped.Data)
+	}
+}
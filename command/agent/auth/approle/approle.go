@@ -0,0 +1,147 @@
+package approle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+type approleMethod struct {
+	logger    log.Logger
+	mountPath string
+
+	roleIDFilePath                 string
+	secretIDFilePath               string
+	removeSecretIDFileAfterReading bool
+
+	l                sync.Mutex
+	cachedRoleID     string
+	cachedSecretID   string
+	secretIDConsumed bool
+}
+
+// NewApproleAuthMethod returns an AuthMethod that logs in with a role ID and
+// secret ID read from files. By default the secret ID file is removed once it
+// has been used, as a secret ID is usually only valid for a single login.
+func NewApproleAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("empty config data")
+	}
+
+	a := &approleMethod{
+		logger:                         conf.Logger,
+		mountPath:                      conf.MountPath,
+		removeSecretIDFileAfterReading: true,
+	}
+
+	roleIDFilePathRaw, ok := conf.Config["role_id_file_path"]
+	if !ok {
+		return nil, errors.New("missing 'role_id_file_path' value")
+	}
+	a.roleIDFilePath, ok = roleIDFilePathRaw.(string)
+	if !ok {
+		return nil, errors.New("could not convert 'role_id_file_path' config value to string")
+	}
+	if a.roleIDFilePath == "" {
+		return nil, errors.New("'role_id_file_path' value is empty")
+	}
+
+	secretIDFilePathRaw, ok := conf.Config["secret_id_file_path"]
+	if !ok {
+		return nil, errors.New("missing 'secret_id_file_path' value")
+	}
+	a.secretIDFilePath, ok = secretIDFilePathRaw.(string)
+	if !ok {
+		return nil, errors.New("could not convert 'secret_id_file_path' config value to string")
+	}
+	if a.secretIDFilePath == "" {
+		return nil, errors.New("'secret_id_file_path' value is empty")
+	}
+
+	if removeRaw, ok := conf.Config["remove_secret_id_file_after_reading"]; ok {
+		remove, err := parseutil.ParseBool(removeRaw)
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing 'remove_secret_id_file_after_reading' value: {{err}}", err)
+		}
+		a.removeSecretIDFileAfterReading = remove
+	}
+
+	return a, nil
+}
+
+func (a *approleMethod) Authenticate(ctx context.Context, client *api.Client) (string, map[string]interface{}, error) {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	roleID, err := readFile(a.roleIDFilePath)
+	switch {
+	case err != nil && a.cachedRoleID == "":
+		return "", nil, errwrap.Wrapf("error reading role ID file: {{err}}", err)
+	case err == nil && roleID != "":
+		a.cachedRoleID = roleID
+	}
+	if a.cachedRoleID == "" {
+		return "", nil, errors.New("no known role ID")
+	}
+
+	secretID, err := readFile(a.secretIDFilePath)
+	switch {
+	case err == nil && secretID != "":
+		a.cachedSecretID = secretID
+		a.secretIDConsumed = false
+	case os.IsNotExist(err) && a.cachedSecretID != "":
+		// Already read and removed, keep using the cached value
+	case err != nil && a.cachedSecretID == "":
+		return "", nil, errwrap.Wrapf("error reading secret ID file: {{err}}", err)
+	}
+	if a.cachedSecretID == "" {
+		return "", nil, errors.New("no known secret ID")
+	}
+
+	return fmt.Sprintf("%s/login", a.mountPath), map[string]interface{}{
+		"role_id":   a.cachedRoleID,
+		"secret_id": a.cachedSecretID,
+	}, nil
+}
+
+func (a *approleMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (a *approleMethod) CredSuccess() {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	if a.secretIDConsumed || !a.removeSecretIDFileAfterReading {
+		return
+	}
+	a.secretIDConsumed = true
+
+	if err := os.Remove(a.secretIDFilePath); err != nil && !os.IsNotExist(err) {
+		a.logger.Error("error removing secret ID file after successful login", "error", err)
+	}
+}
+
+func (a *approleMethod) Shutdown() {
+}
+
+func readFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
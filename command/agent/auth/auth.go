@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/jsonutil"
+)
+
+const (
+	// initialBackoff is how long to wait after a failed authentication
+	// attempt before retrying. It doubles on each consecutive failure.
+	initialBackoff = 2 * time.Second
+
+	// maxBackoff is the longest time to wait between authentication attempts
+	maxBackoff = 5 * time.Minute
+)
+
+// AuthMethod is implemented by each of the credential methods the agent can
+// use to log in
+type AuthMethod interface {
+	// Authenticate returns the path to write to and the data to write to it
+	// in order to log in
+	Authenticate(context.Context, *api.Client) (string, map[string]interface{}, error)
+
+	// NewCreds returns a channel that receives a value when the method has
+	// new credentials and the agent should log in again. It may return nil
+	// if the method never changes its credentials.
+	NewCreds() chan struct{}
+
+	// CredSuccess is called after a successful login with the credentials
+	// returned by Authenticate
+	CredSuccess()
+
+	// Shutdown releases any resources held by the method
+	Shutdown()
+}
+
+// AuthConfig is the configuration passed to each AuthMethod constructor
+type AuthConfig struct {
+	Logger    log.Logger
+	MountPath string
+	Config    map[string]interface{}
+}
+
+// AuthHandlerConfig is the configuration for an AuthHandler
+type AuthHandlerConfig struct {
+	Logger  log.Logger
+	Client  *api.Client
+	WrapTTL time.Duration
+}
+
+// AuthHandler logs in with an AuthMethod, keeps the resulting token renewed
+// and logs in again whenever renewal fails or the method has new credentials.
+// Each new token is sent on OutputCh.
+type AuthHandler struct {
+	DoneCh   chan struct{}
+	OutputCh chan string
+
+	logger  log.Logger
+	client  *api.Client
+	random  *rand.Rand
+	wrapTTL time.Duration
+}
+
+// NewAuthHandler creates a new AuthHandler
+func NewAuthHandler(conf *AuthHandlerConfig) *AuthHandler {
+	return &AuthHandler{
+		DoneCh: make(chan struct{}),
+		// This is buffered so that if we try to output after the sink server
+		// has been shut down, during agent shutdown, we won't block
+		OutputCh: make(chan string, 1),
+		logger:   conf.Logger,
+		client:   conf.Client,
+		random:   rand.New(rand.NewSource(int64(time.Now().Nanosecond()))),
+		wrapTTL:  conf.WrapTTL,
+	}
+}
+
+// Run logs in and keeps a valid token available until ctx is canceled
+func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) {
+	if am == nil {
+		panic("nil auth method")
+	}
+
+	ah.logger.Info("starting auth handler")
+	defer func() {
+		am.Shutdown()
+		close(ah.OutputCh)
+		close(ah.DoneCh)
+		ah.logger.Info("auth handler stopped")
+	}()
+
+	credCh := am.NewCreds()
+	if credCh == nil {
+		credCh = make(chan struct{})
+	}
+
+	backoff := initialBackoff
+
+	// retry waits out the current backoff and doubles it for next time. It
+	// returns false if the handler should stop.
+	retry := func() bool {
+		wait := backoff + time.Duration(ah.random.Int63n(int64(backoff)/4+1))
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(wait):
+			return true
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		// Create a fresh client so that no token is used for the login
+		clientToUse, err := ah.client.Clone()
+		if err != nil {
+			ah.logger.Error("error cloning client", "error", err)
+			if !retry() {
+				return
+			}
+			continue
+		}
+		clientToUse.ClearToken()
+		if ah.wrapTTL > 0 {
+			wrapTTL := ah.wrapTTL
+			clientToUse.SetWrappingLookupFunc(func(string, string) string {
+				return wrapTTL.String()
+			})
+		}
+
+		ah.logger.Info("authenticating")
+		path, data, err := am.Authenticate(ctx, ah.client)
+		if err != nil {
+			ah.logger.Error("error getting path or data from method", "error", err)
+			if !retry() {
+				return
+			}
+			continue
+		}
+
+		secret, err := clientToUse.Logical().Write(path, data)
+		// Check errors/sanity
+		if err != nil {
+			ah.logger.Error("error authenticating", "error", err)
+			if !retry() {
+				return
+			}
+			continue
+		}
+
+		if err := validateSecret(secret, ah.wrapTTL > 0); err != nil {
+			ah.logger.Error("authentication returned an invalid response", "error", err)
+			if !retry() {
+				return
+			}
+			continue
+		}
+
+		backoff = initialBackoff
+		am.CredSuccess()
+
+		if ah.wrapTTL > 0 {
+			// The wrapped token cannot be renewed by the agent, so it is
+			// handed out as-is and a new one is fetched when the
+			// credentials change
+			wrappedResp, err := jsonutil.EncodeJSON(secret.WrapInfo)
+			if err != nil {
+				ah.logger.Error("failed to encode wrapinfo", "error", err)
+				if !retry() {
+					return
+				}
+				continue
+			}
+			ah.logger.Info("authentication successful, sending wrapped token to sinks and pausing")
+			ah.OutputCh <- string(wrappedResp)
+
+			select {
+			case <-ctx.Done():
+				ah.logger.Info("shutdown triggered")
+				return
+			case <-credCh:
+				ah.logger.Info("auth method found new credentials, re-authenticating")
+				continue
+			}
+		}
+
+		ah.logger.Info("authentication successful, sending token to sinks")
+		ah.OutputCh <- secret.Auth.ClientToken
+
+		if !secret.Auth.Renewable {
+			if !ah.waitNonRenewable(ctx, secret, credCh) {
+				return
+			}
+			continue
+		}
+
+		renewer, err := ah.client.NewRenewer(&api.RenewerInput{
+			Secret: secret,
+		})
+		if err != nil {
+			ah.logger.Error("error creating renewer, backing off and retrying", "error", err)
+			if !retry() {
+				return
+			}
+			continue
+		}
+
+		ah.logger.Info("starting renewal process")
+		go renewer.Renew()
+
+	RenewerLoop:
+		for {
+			select {
+			case <-ctx.Done():
+				ah.logger.Info("shutdown triggered, stopping renewer")
+				renewer.Stop()
+				return
+
+			case err := <-renewer.DoneCh():
+				ah.logger.Info("renewer done channel triggered")
+				if err != nil {
+					ah.logger.Error("error renewing token", "error", err)
+				}
+				break RenewerLoop
+
+			case <-renewer.RenewCh():
+				ah.logger.Info("renewed auth token")
+
+			case <-credCh:
+				ah.logger.Info("auth method found new credentials, re-authenticating")
+				renewer.Stop()
+				break RenewerLoop
+			}
+		}
+	}
+}
+
+// waitNonRenewable waits until a token that cannot be renewed is close to
+// expiring or the method has new credentials. It returns false if the
+// handler should stop.
+func (ah *AuthHandler) waitNonRenewable(ctx context.Context, secret *api.Secret, credCh chan struct{}) bool {
+	var expireCh <-chan time.Time
+	if secret.Auth.LeaseDuration > 0 {
+		ttl := time.Duration(secret.Auth.LeaseDuration) * time.Second
+		expireCh = time.After(ttl * 2 / 3)
+		ah.logger.Info("token is not renewable, will re-authenticate before it expires", "ttl", ttl)
+	} else {
+		ah.logger.Info("token is not renewable and does not expire")
+	}
+
+	select {
+	case <-ctx.Done():
+		ah.logger.Info("shutdown triggered")
+		return false
+	case <-expireCh:
+		ah.logger.Info("token is close to expiring, re-authenticating")
+	case <-credCh:
+		ah.logger.Info("auth method found new credentials, re-authenticating")
+	}
+	return true
+}
+
+func validateSecret(secret *api.Secret, wrapped bool) error {
+	switch {
+	case secret == nil:
+		return errors.New("empty response from auth endpoint")
+	case wrapped:
+		if secret.WrapInfo == nil {
+			return errors.New("authentication returned nil wrap info")
+		}
+		if secret.WrapInfo.Token == "" {
+			return errors.New("authentication returned empty wrapped client token")
+		}
+	case secret.Auth == nil:
+		return errors.New("authentication returned nil auth info")
+	case secret.Auth.ClientToken == "":
+		return errors.New("authentication returned empty client token")
+	}
+	return nil
+}
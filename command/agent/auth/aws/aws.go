@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/api"
+	awsauth "github.com/hashicorp/vault/builtin/credential/aws"
+	"github.com/hashicorp/vault/command/agent/auth"
+)
+
+const (
+	typeEC2 = "ec2"
+	typeIAM = "iam"
+)
+
+type awsMethod struct {
+	logger    log.Logger
+	authType  string
+	mountPath string
+	role      string
+
+	// IAM options
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	headerValue  string
+
+	// The nonce presented with the EC2 identity document. The role tag on
+	// the instance is bound to the first nonce used, so it is kept for the
+	// lifetime of the agent.
+	l     sync.Mutex
+	nonce string
+}
+
+// NewAWSAuthMethod returns an AuthMethod that logs in with either the EC2
+// instance identity document or signed IAM credentials
+func NewAWSAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("empty config data")
+	}
+
+	a := &awsMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+	}
+
+	typeRaw, ok := conf.Config["type"]
+	if !ok {
+		return nil, errors.New("missing 'type' value")
+	}
+	a.authType, ok = typeRaw.(string)
+	if !ok {
+		return nil, errors.New("could not convert 'type' config value to string")
+	}
+
+	roleRaw, ok := conf.Config["role"]
+	if !ok {
+		return nil, errors.New("missing 'role' value")
+	}
+	a.role, ok = roleRaw.(string)
+	if !ok {
+		return nil, errors.New("could not convert 'role' config value to string")
+	}
+
+	switch {
+	case a.role == "":
+		return nil, errors.New("'role' value is empty")
+	case a.authType == "":
+		return nil, errors.New("'type' value is empty")
+	case a.authType != typeEC2 && a.authType != typeIAM:
+		return nil, fmt.Errorf("'type' value is invalid, must be %q or %q", typeEC2, typeIAM)
+	}
+
+	for key, target := range map[string]*string{
+		"access_key":    &a.accessKey,
+		"secret_key":    &a.secretKey,
+		"session_token": &a.sessionToken,
+		"header_value":  &a.headerValue,
+	} {
+		raw, ok := conf.Config[key]
+		if !ok {
+			continue
+		}
+		*target, ok = raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("could not convert '%s' config value to string", key)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *awsMethod) Authenticate(ctx context.Context, client *api.Client) (string, map[string]interface{}, error) {
+	a.logger.Trace("beginning authentication")
+
+	var data map[string]interface{}
+	switch a.authType {
+	case typeEC2:
+		sess, err := session.NewSession()
+		if err != nil {
+			return "", nil, errwrap.Wrapf("error creating session to probe EC2 metadata: {{err}}", err)
+		}
+		metadataSvc := ec2metadata.New(sess)
+		if !metadataSvc.Available() {
+			return "", nil, errors.New("session does not appear to be on an EC2 instance")
+		}
+
+		pkcs7, err := metadataSvc.GetDynamicData("/instance-identity/pkcs7")
+		if err != nil {
+			return "", nil, errwrap.Wrapf("error fetching PKCS #7 data from EC2 metadata: {{err}}", err)
+		}
+
+		a.l.Lock()
+		if a.nonce == "" {
+			a.nonce, err = uuid.GenerateUUID()
+		}
+		nonce := a.nonce
+		a.l.Unlock()
+		if err != nil {
+			return "", nil, errwrap.Wrapf("error generating nonce: {{err}}", err)
+		}
+
+		data = map[string]interface{}{
+			"pkcs7": strings.Replace(strings.TrimSpace(pkcs7), "\n", "", -1),
+			"nonce": nonce,
+		}
+
+	case typeIAM:
+		var err error
+		data, err = awsauth.GenerateLoginData(a.accessKey, a.secretKey, a.sessionToken, a.headerValue)
+		if err != nil {
+			return "", nil, errwrap.Wrapf("error creating login value: {{err}}", err)
+		}
+	}
+
+	data["role"] = a.role
+
+	return fmt.Sprintf("%s/login", a.mountPath), data, nil
+}
+
+func (a *awsMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (a *awsMethod) CredSuccess() {
+}
+
+func (a *awsMethod) Shutdown() {
+}
@@ -0,0 +1,63 @@
+package cert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/auth"
+)
+
+type certMethod struct {
+	logger    log.Logger
+	mountPath string
+	name      string
+}
+
+// NewCertAuthMethod returns an AuthMethod that logs in with the TLS client
+// certificate the agent's Vault client is configured with, for example via
+// VAULT_CLIENT_CERT and VAULT_CLIENT_KEY
+func NewCertAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+
+	c := &certMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+	}
+
+	if conf.Config != nil {
+		if nameRaw, ok := conf.Config["name"]; ok {
+			c.name, ok = nameRaw.(string)
+			if !ok {
+				return nil, errors.New("could not convert 'name' config value to string")
+			}
+		}
+	}
+
+	return c, nil
+}
+
+func (c *certMethod) Authenticate(ctx context.Context, client *api.Client) (string, map[string]interface{}, error) {
+	c.logger.Trace("beginning authentication")
+
+	data := make(map[string]interface{})
+	if c.name != "" {
+		data["name"] = c.name
+	}
+
+	return fmt.Sprintf("%s/login", c.mountPath), data, nil
+}
+
+func (c *certMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (c *certMethod) CredSuccess() {
+}
+
+func (c *certMethod) Shutdown() {
+}
@@ -0,0 +1,90 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/auth"
+)
+
+const (
+	// serviceAccountFile is the default path to the service account JWT
+	// mounted into every pod
+	serviceAccountFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+type kubernetesMethod struct {
+	logger    log.Logger
+	mountPath string
+
+	role      string
+	tokenPath string
+}
+
+// NewKubernetesAuthMethod returns an AuthMethod that logs in with the pod's
+// service account JWT
+func NewKubernetesAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("empty config data")
+	}
+
+	k := &kubernetesMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+		tokenPath: serviceAccountFile,
+	}
+
+	roleRaw, ok := conf.Config["role"]
+	if !ok {
+		return nil, errors.New("missing 'role' value")
+	}
+	k.role, ok = roleRaw.(string)
+	if !ok {
+		return nil, errors.New("could not convert 'role' config value to string")
+	}
+	if k.role == "" {
+		return nil, errors.New("'role' value is empty")
+	}
+
+	if tokenPathRaw, ok := conf.Config["token_path"]; ok {
+		k.tokenPath, ok = tokenPathRaw.(string)
+		if !ok {
+			return nil, errors.New("could not convert 'token_path' config value to string")
+		}
+	}
+
+	return k, nil
+}
+
+func (k *kubernetesMethod) Authenticate(ctx context.Context, client *api.Client) (string, map[string]interface{}, error) {
+	k.logger.Trace("beginning authentication")
+
+	content, err := ioutil.ReadFile(k.tokenPath)
+	if err != nil {
+		return "", nil, errwrap.Wrapf("error reading service account token: {{err}}", err)
+	}
+
+	return fmt.Sprintf("%s/login", k.mountPath), map[string]interface{}{
+		"role": k.role,
+		"jwt":  strings.TrimSpace(string(content)),
+	}, nil
+}
+
+func (k *kubernetesMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (k *kubernetesMethod) CredSuccess() {
+}
+
+func (k *kubernetesMethod) Shutdown() {
+}
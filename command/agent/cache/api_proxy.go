@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+)
+
+// APIProxy is an implementation of the proxier interface that is used to
+// forward the request to Vault and get the response.
+type APIProxy struct {
+	client *api.Client
+	logger log.Logger
+}
+
+// APIProxyConfig is the configuration for an APIProxy
+type APIProxyConfig struct {
+	Client *api.Client
+	Logger log.Logger
+}
+
+// NewAPIProxy creates an APIProxy
+func NewAPIProxy(config *APIProxyConfig) (Proxier, error) {
+	if config.Client == nil {
+		return nil, fmt.Errorf("nil API client")
+	}
+	return &APIProxy{
+		client: config.Client,
+		logger: config.Logger,
+	}, nil
+}
+
+func (ap *APIProxy) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	client, err := ap.client.Clone()
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(req.Token)
+
+	fwReq := client.NewRequest(req.Request.Method, req.Request.URL.Path)
+	fwReq.Params = req.Request.URL.Query()
+	fwReq.Headers = req.Request.Header
+	fwReq.Headers.Del("X-Vault-Token")
+
+	if len(req.RequestBody) > 0 {
+		// Vault request bodies are JSON. Setting them through SetJSONBody
+		// allows the body to be resent if the request is redirected to the
+		// active node.
+		if json.Valid(req.RequestBody) {
+			if err := fwReq.SetJSONBody(json.RawMessage(req.RequestBody)); err != nil {
+				return nil, err
+			}
+		} else {
+			fwReq.Body = bytes.NewReader(req.RequestBody)
+			fwReq.BodySize = int64(len(req.RequestBody))
+		}
+	}
+
+	// Make the request to Vault and get the response. Error responses from
+	// Vault are passed back to the caller unchanged.
+	ap.logger.Info("forwarding request", "path", req.Request.URL.Path, "method", req.Request.Method)
+	resp, err := client.RawRequest(fwReq)
+	if resp == nil && err != nil {
+		return nil, err
+	}
+
+	return NewSendResponse(resp, nil)
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/logging"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+)
+
+func setupClusterAndAgent(t *testing.T) (func(), *api.Client, *api.Client, *LeaseCache) {
+	t.Helper()
+
+	coreConfig := &vault.CoreConfig{
+		DisableMlock: true,
+		DisableCache: true,
+		Logger:       hclog.NewNullLogger(),
+	}
+
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+
+	cores := cluster.Cores
+	vault.TestWaitActive(t, cores[0].Core)
+	client := cores[0].Client
+
+	logger := logging.NewVaultLogger(hclog.Trace)
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	apiProxy, err := NewAPIProxy(&APIProxyConfig{
+		Client: client,
+		Logger: logger.Named("cache.apiproxy"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaseCache, err := NewLeaseCache(&LeaseCacheConfig{
+		Client:      client,
+		BaseContext: ctx,
+		Proxier:     apiProxy,
+		Logger:      logger.Named("cache.leasecache"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/agent/v1/cache-clear", leaseCache.HandleCacheClear(ctx))
+	mux.Handle("/", ProxyHandler(ctx, logger.Named("cache.handler"), leaseCache, nil))
+	server := &http.Server{
+		Handler: mux,
+	}
+	go server.Serve(ln)
+
+	testClient, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testClient.SetAddress("http://" + ln.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	testClient.SetToken(client.Token())
+
+	cleanup := func() {
+		cancelFunc()
+		ln.Close()
+		cluster.Cleanup()
+	}
+
+	return cleanup, client, testClient, leaseCache
+}
+
+func TestCache_TokenCreate(t *testing.T) {
+	cleanup, _, testClient, _ := setupClusterAndAgent(t)
+	defer cleanup()
+
+	// The first request is proxied, the second one is served from the cache
+	secret, err := testClient.Auth().Token().Create(&api.TokenCreateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := secret.Auth.ClientToken
+
+	secret, err = testClient.Auth().Token().Create(&api.TokenCreateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Auth.ClientToken != token {
+		t.Fatalf("expected cached token %q, got %q", token, secret.Auth.ClientToken)
+	}
+
+	// Revoking the token through the agent should evict it from the cache
+	testClient.SetToken(token)
+	if err := testClient.Auth().Token().RevokeSelf(""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCache_RevokeEvicts(t *testing.T) {
+	cleanup, client, testClient, _ := setupClusterAndAgent(t)
+	defer cleanup()
+
+	rootToken := client.Token()
+
+	secret, err := testClient.Auth().Token().Create(&api.TokenCreateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := secret.Auth.ClientToken
+
+	testClient.SetToken(token)
+	if err := testClient.Auth().Token().RevokeSelf(""); err != nil {
+		t.Fatal(err)
+	}
+
+	testClient.SetToken(rootToken)
+	secret, err = testClient.Auth().Token().Create(&api.TokenCreateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Auth.ClientToken == token {
+		t.Fatal("expected revoked token to be evicted from the cache")
+	}
+}
+
+func TestCache_CacheClear(t *testing.T) {
+	cleanup, _, testClient, _ := setupClusterAndAgent(t)
+	defer cleanup()
+
+	secret, err := testClient.Auth().Token().Create(&api.TokenCreateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := secret.Auth.ClientToken
+
+	req := testClient.NewRequest("PUT", "/agent/v1/cache-clear")
+	if err := req.SetJSONBody(map[string]interface{}{
+		"type":  "token",
+		"value": token,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := testClient.RawRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	secret, err = testClient.Auth().Token().Create(&api.TokenCreateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Auth.ClientToken == token {
+		t.Fatal("expected cleared token to be evicted from the cache")
+	}
+
+	// Clearing with an unknown type is rejected
+	req = testClient.NewRequest("PUT", "/agent/v1/cache-clear")
+	if err := req.SetJSONBody(map[string]interface{}{
+		"type": "bogus",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testClient.RawRequest(req); err == nil {
+		t.Fatal("expected error for invalid clear type")
+	}
+}
@@ -0,0 +1,103 @@
+package cachememdb
+
+import (
+	"testing"
+)
+
+func testIndexes() []*Index {
+	return []*Index{
+		&Index{
+			ID:            "token",
+			Token:         "token",
+			TokenParent:   "root",
+			TokenAccessor: "accessor",
+			RequestPath:   "/v1/auth/token/create",
+			Response:      []byte("token response"),
+		},
+		&Index{
+			ID:          "lease1",
+			TokenParent: "token",
+			Lease:       "database/creds/readonly/abc",
+			RequestPath: "/v1/database/creds/readonly",
+			Response:    []byte("lease response 1"),
+		},
+		&Index{
+			ID:          "lease2",
+			TokenParent: "token",
+			Lease:       "database/creds/admin/def",
+			RequestPath: "/v1/database/creds/admin",
+			Response:    []byte("lease response 2"),
+		},
+	}
+}
+
+func TestCacheMemDB(t *testing.T) {
+	cache, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range testIndexes() {
+		if err := cache.Set(index); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, value := range map[string]string{
+		IndexNameID:            "lease1",
+		IndexNameLease:         "database/creds/readonly/abc",
+		IndexNameRequestPath:   "/v1/database/creds/readonly",
+		IndexNameToken:         "token",
+		IndexNameTokenAccessor: "accessor",
+	} {
+		index, err := cache.Get(name, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index == nil {
+			t.Fatalf("expected index for %s %q", name, value)
+		}
+	}
+
+	if _, err := cache.Get("bad", "value"); err == nil {
+		t.Fatal("expected error for an invalid index name")
+	}
+
+	indexes, err := cache.GetByPrefix(IndexNameLease, "database/creds/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 2 {
+		t.Fatalf("expected 2 indexes, got %d", len(indexes))
+	}
+
+	indexes, err = cache.GetByPrefix(IndexNameTokenParent, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 2 {
+		t.Fatalf("expected 2 indexes, got %d", len(indexes))
+	}
+
+	if err := cache.Evict(IndexNameLease, "database/creds/admin/def"); err != nil {
+		t.Fatal(err)
+	}
+	index, err := cache.Get(IndexNameID, "lease2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != nil {
+		t.Fatalf("expected index to be evicted: %#v", index)
+	}
+
+	if err := cache.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	indexes, err = cache.GetByPrefix(IndexNameID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 0 {
+		t.Fatalf("expected cache to be empty, got %d indexes", len(indexes))
+	}
+}
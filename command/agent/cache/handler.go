@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/sink"
+)
+
+// ProxyHandler returns a handler that passes every request to proxier. If
+// tokenSource is set, requests without a token are sent with the token it
+// holds, which is the agent's auto-auth token.
+func ProxyHandler(ctx context.Context, logger log.Logger, proxier Proxier, tokenSource sink.SinkReader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("received request", "path", r.URL.Path, "method", r.Method)
+
+		token := r.Header.Get("X-Vault-Token")
+		if token == "" && tokenSource != nil {
+			logger.Debug("using auto auth token", "path", r.URL.Path, "method", r.Method)
+			token = tokenSource.Token()
+		}
+
+		// Parse and reset body.
+		reqBody, err := ioutil.ReadAll(io.LimitReader(r.Body, 32<<20))
+		if err != nil {
+			logger.Error("failed to read request body")
+			respondError(w, http.StatusInternalServerError, errwrap.Wrapf("failed to read request body: {{err}}", err))
+			return
+		}
+		if r.Body != nil {
+			r.Body.Close()
+		}
+
+		resp, err := proxier.Send(ctx, &SendRequest{
+			Token:       token,
+			Request:     r,
+			RequestBody: reqBody,
+		})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, errwrap.Wrapf("failed to get the response: {{err}}", err))
+			return
+		}
+
+		copyHeader(w.Header(), resp.Response.Header)
+		w.WriteHeader(resp.Response.StatusCode)
+		w.Write(resp.ResponseBody)
+	})
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, vv := range src {
+		for _, v := range vv {
+			dst.Add(k, v)
+		}
+	}
+}
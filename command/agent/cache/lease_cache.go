@@ -0,0 +1,600 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/cache/cachememdb"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/jsonutil"
+)
+
+const (
+	vaultPathTokenRevoke         = "/v1/auth/token/revoke"
+	vaultPathTokenRevokeSelf     = "/v1/auth/token/revoke-self"
+	vaultPathTokenRevokeAccessor = "/v1/auth/token/revoke-accessor"
+	vaultPathTokenRevokeOrphan   = "/v1/auth/token/revoke-orphan"
+	vaultPathLeaseRevoke         = "/v1/sys/leases/revoke"
+	vaultPathLeaseRevokeForce    = "/v1/sys/leases/revoke-force"
+	vaultPathLeaseRevokePrefix   = "/v1/sys/leases/revoke-prefix"
+	vaultPathRevoke              = "/v1/sys/revoke"
+	vaultPathRevokeForce         = "/v1/sys/revoke-force"
+	vaultPathRevokePrefix        = "/v1/sys/revoke-prefix"
+)
+
+var (
+	contextIndexID = contextIndex{}
+	errInvalidType = errors.New("invalid type provided")
+)
+
+type contextIndex struct{}
+
+type cacheClearRequest struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// LeaseCache is an implementation of Proxier that handles the caching of
+// responses. It passes the incoming request to an underlying Proxier
+// implementation.
+type LeaseCache struct {
+	client  *api.Client
+	proxier Proxier
+	logger  log.Logger
+	db      *cachememdb.CacheMemDB
+
+	// baseCtx is the context every cached entry is ultimately derived from.
+	// baseCtxInfo is replaced when the whole cache is cleared.
+	baseCtx     context.Context
+	baseCtxLock sync.RWMutex
+	baseCtxInfo *cachememdb.ContextInfo
+}
+
+// LeaseCacheConfig is the configuration for initializing a new
+// LeaseCache.
+type LeaseCacheConfig struct {
+	Client      *api.Client
+	BaseContext context.Context
+	Proxier     Proxier
+	Logger      log.Logger
+}
+
+// NewLeaseCache creates a new instance of a LeaseCache.
+func NewLeaseCache(conf *LeaseCacheConfig) (*LeaseCache, error) {
+	if conf == nil {
+		return nil, errors.New("nil configuration provided")
+	}
+
+	if conf.Proxier == nil || conf.Logger == nil {
+		return nil, fmt.Errorf("missing configuration required params: %v", conf)
+	}
+
+	if conf.Client == nil {
+		return nil, fmt.Errorf("nil API client")
+	}
+
+	db, err := cachememdb.New()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a base context for the lease cache layer
+	baseCtx, baseCancelFunc := context.WithCancel(conf.BaseContext)
+	baseCtxInfo := &cachememdb.ContextInfo{
+		Ctx:        baseCtx,
+		CancelFunc: baseCancelFunc,
+	}
+
+	return &LeaseCache{
+		client:      conf.Client,
+		proxier:     conf.Proxier,
+		logger:      conf.Logger,
+		db:          db,
+		baseCtx:     conf.BaseContext,
+		baseCtxInfo: baseCtxInfo,
+	}, nil
+}
+
+// Send performs a cache lookup on the incoming request. If it's a cache hit,
+// it will return the cached response, otherwise it will delegate to the
+// underlying Proxier and cache the received response.
+func (c *LeaseCache) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	// Compute the index ID
+	id, err := computeIndexID(req)
+	if err != nil {
+		c.logger.Error("failed to compute cache key", "error", err)
+		return nil, err
+	}
+
+	// Check if the response for this request is already in the cache
+	index, err := c.db.Get(cachememdb.IndexNameID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached request is found, deserialize the response and return early
+	if index != nil {
+		c.logger.Debug("returning cached response", "path", req.Request.URL.Path)
+
+		reader := bufio.NewReader(bytes.NewReader(index.Response))
+		resp, err := http.ReadResponse(reader, nil)
+		if err != nil {
+			c.logger.Error("failed to deserialize response", "error", err)
+			return nil, err
+		}
+
+		return NewSendResponse(&api.Response{Response: resp}, nil)
+	}
+
+	c.logger.Debug("forwarding request", "path", req.Request.URL.Path, "method", req.Request.Method)
+
+	// Pass the request down and get a response
+	resp, err := c.proxier.Send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Evict the cached entries of anything this request revoked
+	if resp.Response.StatusCode >= 200 && resp.Response.StatusCode < 300 {
+		if err := c.handleRevocationRequest(req); err != nil {
+			c.logger.Error("failed to evict revoked entries", "error", err)
+		}
+	}
+
+	// Only cache successful responses
+	if resp.Response.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	// Get the secret from the response so that the lease or token it holds
+	// can be cached. Responses holding neither are not cached, as they would
+	// go stale.
+	secret, err := api.ParseSecret(bytes.NewReader(resp.ResponseBody))
+	if err != nil || secret == nil {
+		return resp, nil
+	}
+
+	// Build the index to cache based on the response received
+	index = &cachememdb.Index{
+		ID:          id,
+		TokenParent: req.Token,
+		RequestPath: req.Request.URL.Path,
+	}
+
+	var renewToken string
+	switch {
+	case secret.WrapInfo != nil:
+		// Wrapped responses are single use and cannot be renewed
+		return resp, nil
+
+	case secret.LeaseID != "":
+		index.Lease = secret.LeaseID
+		renewToken = req.Token
+
+	case secret.Auth != nil:
+		if secret.Auth.ClientToken == "" {
+			return resp, nil
+		}
+		index.Token = secret.Auth.ClientToken
+		index.TokenAccessor = secret.Auth.Accessor
+		renewToken = secret.Auth.ClientToken
+
+	default:
+		c.logger.Debug("response does not hold a lease or token, not caching", "path", req.Request.URL.Path)
+		return resp, nil
+	}
+
+	// Entries created with a token that is itself cached are bound to that
+	// token's lifetime. This also evicts orphan tokens created with it, which
+	// only costs a cache miss.
+	c.baseCtxLock.RLock()
+	parentCtx := c.baseCtxInfo.Ctx
+	c.baseCtxLock.RUnlock()
+	if req.Token != "" {
+		parentIndex, err := c.db.Get(cachememdb.IndexNameToken, req.Token)
+		if err != nil {
+			return nil, err
+		}
+		if parentIndex != nil {
+			parentCtx = parentIndex.RenewCtxInfo.Ctx
+		}
+	}
+
+	// Serialize the response to store it in the cached index
+	var respBytes bytes.Buffer
+	if err := resp.Response.Write(&respBytes); err != nil {
+		c.logger.Error("failed to serialize response", "error", err)
+		return nil, err
+	}
+
+	// Reset the response body for upper layers to read
+	resp.Response.Body = ioutil.NopCloser(bytes.NewReader(resp.ResponseBody))
+
+	renewCtx, renewCancel := context.WithCancel(context.WithValue(parentCtx, contextIndexID, index.ID))
+	index.Response = respBytes.Bytes()
+	index.RenewCtxInfo = &cachememdb.ContextInfo{
+		Ctx:        renewCtx,
+		CancelFunc: renewCancel,
+		DoneCh:     make(chan struct{}),
+	}
+
+	// Store the index in the cache
+	c.logger.Debug("storing response into the cache", "path", req.Request.URL.Path)
+	if err := c.db.Set(index); err != nil {
+		renewCancel()
+		c.logger.Error("failed to cache the proxied response", "error", err)
+		return nil, err
+	}
+
+	go c.startRenewing(index, secret, renewToken)
+
+	return resp, nil
+}
+
+// startRenewing keeps the lease or token held by index renewed until it can
+// no longer be renewed, it expires, or its context is canceled. The index is
+// evicted from the cache when it returns.
+func (c *LeaseCache) startRenewing(index *cachememdb.Index, secret *api.Secret, token string) {
+	ctx := index.RenewCtxInfo.Ctx
+	defer func() {
+		index.RenewCtxInfo.CancelFunc()
+		c.evictIndex(ctx.Value(contextIndexID).(string), index)
+		close(index.RenewCtxInfo.DoneCh)
+	}()
+
+	client, err := c.client.Clone()
+	if err != nil {
+		c.logger.Error("failed to create API client in the renewer", "error", err)
+		return
+	}
+	client.SetToken(token)
+
+	renewable, ttl := secret.Renewable, secret.LeaseDuration
+	if secret.Auth != nil {
+		renewable, ttl = secret.Auth.Renewable, secret.Auth.LeaseDuration
+	}
+
+	if !renewable {
+		// Keep the entry until it expires. Tokens without a TTL are kept
+		// until they are revoked through the agent or the cache is cleared.
+		var expireCh <-chan time.Time
+		if ttl > 0 {
+			expireCh = time.After(time.Duration(ttl) * time.Second)
+		}
+		select {
+		case <-ctx.Done():
+		case <-expireCh:
+			c.logger.Debug("cached secret expired", "path", index.RequestPath)
+		}
+		return
+	}
+
+	renewer, err := client.NewRenewer(&api.RenewerInput{
+		Secret: secret,
+	})
+	if err != nil {
+		c.logger.Error("failed to create secret renewer", "error", err)
+		return
+	}
+
+	c.logger.Debug("initiating renewal", "path", index.RequestPath)
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Debug("context canceled, stopping renewer", "path", index.RequestPath)
+			return
+		case err := <-renewer.DoneCh():
+			if err != nil {
+				c.logger.Error("failed to renew secret", "error", err)
+				return
+			}
+			c.logger.Debug("renewal halted; evicting from cache", "path", index.RequestPath)
+			return
+		case <-renewer.RenewCh():
+			c.logger.Debug("secret renewed", "path", index.RequestPath)
+		}
+	}
+}
+
+// evictIndex removes index from the cache unless it has already been
+// replaced by a newer response to the same request
+func (c *LeaseCache) evictIndex(id string, index *cachememdb.Index) {
+	current, err := c.db.Get(cachememdb.IndexNameID, id)
+	if err != nil {
+		c.logger.Error("failed to look up index for eviction", "id", id, "error", err)
+		return
+	}
+	if current != index {
+		return
+	}
+
+	c.logger.Debug("evicting index from cache", "id", id, "path", index.RequestPath)
+	if err := c.db.Evict(cachememdb.IndexNameID, id); err != nil {
+		c.logger.Error("failed to evict index", "id", id, "error", err)
+	}
+}
+
+// computeIndexID results in a value that uniquely identifies a request
+// received by the agent. It does so by hashing the request method, path,
+// token, namespace, wrapping TTL and body.
+func computeIndexID(req *SendRequest) (string, error) {
+	if req == nil || req.Request == nil {
+		return "", errors.New("nil request")
+	}
+
+	h := sha256.New()
+	for _, v := range [][]byte{
+		[]byte(req.Request.Method),
+		[]byte(req.Request.URL.RequestURI()),
+		[]byte(req.Token),
+		[]byte(req.Request.Header.Get(consts.NamespaceHeaderName)),
+		[]byte(req.Request.Header.Get("X-Vault-Wrap-TTL")),
+		req.RequestBody,
+	} {
+		h.Write(v)
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// handleRevocationRequest evicts the cached entries of tokens and leases
+// revoked by a successful request
+func (c *LeaseCache) handleRevocationRequest(req *SendRequest) error {
+	path := req.Request.URL.Path
+
+	var body map[string]interface{}
+	if len(req.RequestBody) > 0 {
+		// A body that isn't a JSON object can't name anything to revoke
+		jsonutil.DecodeJSON(req.RequestBody, &body)
+	}
+	bodyString := func(key string) string {
+		v, _ := body[key].(string)
+		return v
+	}
+
+	switch {
+	case path == vaultPathTokenRevoke || path == vaultPathTokenRevokeOrphan:
+		token := bodyString("token")
+		if token == "" {
+			return nil
+		}
+		return c.evictToken(token)
+
+	case path == vaultPathTokenRevokeSelf:
+		return c.evictToken(req.Token)
+
+	case path == vaultPathTokenRevokeAccessor:
+		accessor := bodyString("accessor")
+		if accessor == "" {
+			return nil
+		}
+		index, err := c.db.Get(cachememdb.IndexNameTokenAccessor, accessor)
+		if err != nil {
+			return err
+		}
+		if index != nil {
+			return c.evictToken(index.Token)
+		}
+
+	case path == vaultPathLeaseRevoke || path == vaultPathRevoke:
+		leaseID := bodyString("lease_id")
+		if leaseID == "" {
+			return nil
+		}
+		return c.evictLease(leaseID)
+
+	case strings.HasPrefix(path, vaultPathLeaseRevokePrefix+"/"):
+		return c.evictLeasePrefix(strings.TrimPrefix(path, vaultPathLeaseRevokePrefix+"/"))
+
+	case strings.HasPrefix(path, vaultPathLeaseRevokeForce+"/"):
+		return c.evictLeasePrefix(strings.TrimPrefix(path, vaultPathLeaseRevokeForce+"/"))
+
+	case strings.HasPrefix(path, vaultPathRevokePrefix+"/"):
+		return c.evictLeasePrefix(strings.TrimPrefix(path, vaultPathRevokePrefix+"/"))
+
+	case strings.HasPrefix(path, vaultPathRevokeForce+"/"):
+		return c.evictLeasePrefix(strings.TrimPrefix(path, vaultPathRevokeForce+"/"))
+
+	case strings.HasPrefix(path, vaultPathLeaseRevoke+"/"):
+		return c.evictLease(strings.TrimPrefix(path, vaultPathLeaseRevoke+"/"))
+
+	case strings.HasPrefix(path, vaultPathRevoke+"/"):
+		return c.evictLease(strings.TrimPrefix(path, vaultPathRevoke+"/"))
+	}
+
+	return nil
+}
+
+// evictToken evicts the cached entry for token along with every entry
+// created using it
+func (c *LeaseCache) evictToken(token string) error {
+	index, err := c.db.Get(cachememdb.IndexNameToken, token)
+	if err != nil {
+		return err
+	}
+	if index != nil {
+		// Canceling the context evicts this entry and all the entries
+		// derived from it
+		index.RenewCtxInfo.CancelFunc()
+		if err := c.db.Evict(cachememdb.IndexNameID, index.ID); err != nil {
+			return err
+		}
+	}
+
+	// Leases created with a token that was not cached by the agent are not
+	// bound to a cached context, so find them by the request token instead
+	children, err := c.db.GetByPrefix(cachememdb.IndexNameTokenParent, token)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if child.TokenParent != token {
+			continue
+		}
+		child.RenewCtxInfo.CancelFunc()
+		if err := c.db.Evict(cachememdb.IndexNameID, child.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *LeaseCache) evictLease(leaseID string) error {
+	index, err := c.db.Get(cachememdb.IndexNameLease, leaseID)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		return nil
+	}
+
+	index.RenewCtxInfo.CancelFunc()
+	return c.db.Evict(cachememdb.IndexNameID, index.ID)
+}
+
+func (c *LeaseCache) evictLeasePrefix(prefix string) error {
+	indexes, err := c.db.GetByPrefix(cachememdb.IndexNameLease, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range indexes {
+		index.RenewCtxInfo.CancelFunc()
+		if err := c.db.Evict(cachememdb.IndexNameID, index.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HandleCacheClear returns a handlerFunc that can perform cache clearing
+// operations.
+func (c *LeaseCache) HandleCacheClear(ctx context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut && r.Method != http.MethodPost {
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		req := new(cacheClearRequest)
+		if err := jsonutil.DecodeJSONFromReader(io.LimitReader(r.Body, 1<<20), req); err != nil {
+			if err == io.EOF {
+				err = errors.New("empty JSON provided")
+			}
+			respondError(w, http.StatusBadRequest, errwrap.Wrapf("failed to parse JSON input: {{err}}", err))
+			return
+		}
+
+		c.logger.Debug("received cache-clear request", "type", req.Type)
+
+		if err := c.handleCacheClear(req); err != nil {
+			// Default to 500 on error, unless the user provided an invalid type,
+			// which would then be a 400.
+			httpStatus := http.StatusInternalServerError
+			if err == errInvalidType {
+				httpStatus = http.StatusBadRequest
+			}
+			respondError(w, httpStatus, errwrap.Wrapf("failed to clear cache: {{err}}", err))
+			return
+		}
+	})
+}
+
+func (c *LeaseCache) handleCacheClear(req *cacheClearRequest) error {
+	if req.Type != "all" && req.Value == "" {
+		return errors.New("a value is required for this type")
+	}
+
+	switch req.Type {
+	case "request_path":
+		indexes, err := c.db.GetByPrefix(cachememdb.IndexNameRequestPath, req.Value)
+		if err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			if index.RequestPath != req.Value {
+				continue
+			}
+			index.RenewCtxInfo.CancelFunc()
+			if err := c.db.Evict(cachememdb.IndexNameID, index.ID); err != nil {
+				return err
+			}
+		}
+
+	case "token":
+		return c.evictToken(req.Value)
+
+	case "token_accessor":
+		index, err := c.db.Get(cachememdb.IndexNameTokenAccessor, req.Value)
+		if err != nil {
+			return err
+		}
+		if index != nil {
+			return c.evictToken(index.Token)
+		}
+
+	case "lease":
+		return c.evictLease(req.Value)
+
+	case "all":
+		// Cancel the base context which triggers all the goroutines to
+		// stop and evict entries from cache.
+		c.logger.Debug("canceling base context")
+		c.baseCtxLock.Lock()
+		c.baseCtxInfo.CancelFunc()
+
+		// Reset the base context
+		baseCtx, baseCancel := context.WithCancel(c.baseCtx)
+		c.baseCtxInfo = &cachememdb.ContextInfo{
+			Ctx:        baseCtx,
+			CancelFunc: baseCancel,
+		}
+		c.baseCtxLock.Unlock()
+
+		// Reset the memdb instance
+		if err := c.db.Flush(); err != nil {
+			return err
+		}
+
+	default:
+		return errInvalidType
+	}
+
+	c.logger.Debug("successfully cleared matching cache entries")
+
+	return nil
+}
+
+func respondError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	resp := &api.ErrorResponse{Errors: make([]string, 0, 1)}
+	if err != nil {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+
+	enc := json.NewEncoder(w)
+	enc.Encode(resp)
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/consts"
+)
+
+func TestLeaseCache_ComputeIndexID(t *testing.T) {
+	indexID := func(namespace string) string {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/v1/secret/foo", nil)
+		if namespace != "" {
+			req.Header.Set(consts.NamespaceHeaderName, namespace)
+		}
+		id, err := computeIndexID(&SendRequest{
+			Token:   "token",
+			Request: req,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	if indexID("ns1/") != indexID("ns1/") {
+		t.Fatal("expected identical requests to have the same index ID")
+	}
+
+	// The same token and path in different namespaces are different requests
+	ids := map[string]string{}
+	for _, namespace := range []string{"", "ns1/", "ns2/"} {
+		id := indexID(namespace)
+		if other, ok := ids[id]; ok {
+			t.Fatalf("namespaces %q and %q have the same index ID", other, namespace)
+		}
+		ids[id] = namespace
+	}
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// SendRequest is the input for Proxier.Send.
+type SendRequest struct {
+	Token       string
+	Request     *http.Request
+	RequestBody []byte
+}
+
+// SendResponse is the output from Proxier.Send.
+type SendResponse struct {
+	Response     *api.Response
+	ResponseBody []byte
+}
+
+// Proxier is the interface implementation by different components that are
+// responsible for performing specific tasks, such as caching and proxying. All
+// these tasks combined together would serve the request received by the agent.
+type Proxier interface {
+	Send(ctx context.Context, req *SendRequest) (*SendResponse, error)
+}
+
+// NewSendResponse returns a new SendResponse object. If responseBody is nil,
+// the body of apiResponse is read and then reset so that it can be read again.
+func NewSendResponse(apiResponse *api.Response, responseBody []byte) (*SendResponse, error) {
+	resp := &SendResponse{
+		Response: apiResponse,
+	}
+
+	switch {
+	case responseBody != nil:
+		resp.ResponseBody = responseBody
+	case apiResponse.Response.Body != nil:
+		body, err := ioutil.ReadAll(apiResponse.Body)
+		if err != nil {
+			return nil, err
+		}
+		apiResponse.Body.Close()
+		resp.ResponseBody = body
+	}
+
+	resp.Response.Body = ioutil.NopCloser(bytes.NewReader(resp.ResponseBody))
+	return resp, nil
+}
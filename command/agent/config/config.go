@@ -0,0 +1,473 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+// Config is the configuration for the vault agent.
+type Config struct {
+	AutoAuth      *AutoAuth   `hcl:"auto_auth"`
+	ExitAfterAuth bool        `hcl:"exit_after_auth"`
+	PidFile       string      `hcl:"pid_file"`
+	Cache         *Cache      `hcl:"cache"`
+	Listeners     []*Listener `hcl:"listeners"`
+	Templates     []*Template `hcl:"templates"`
+}
+
+// Cache contains any configuration needed for the caching proxy
+type Cache struct {
+	UseAutoAuthToken bool `hcl:"use_auto_auth_token"`
+}
+
+// Listener contains configuration for any the caching proxy listeners
+type Listener struct {
+	Type   string
+	Config map[string]interface{}
+}
+
+// Template is a template rendered into a file using secrets read with the
+// auto-auth token
+type Template struct {
+	Source            string        `hcl:"source"`
+	Contents          string        `hcl:"contents"`
+	Destination       string        `hcl:"destination"`
+	Command           string        `hcl:"command"`
+	CommandTimeoutRaw interface{}   `hcl:"command_timeout"`
+	CommandTimeout    time.Duration `hcl:"-"`
+	PermsRaw          interface{}   `hcl:"perms"`
+	Perms             os.FileMode   `hcl:"-"`
+	LeftDelim         string        `hcl:"left_delimiter"`
+	RightDelim        string        `hcl:"right_delimiter"`
+	ErrMissingKey     bool          `hcl:"error_on_missing_key"`
+}
+
+// AutoAuth is the configured authentication method and sinks
+type AutoAuth struct {
+	Method *Method `hcl:"-"`
+	Sinks  []*Sink `hcl:"sinks"`
+}
+
+// Method represents the configuration for the authentication backend
+type Method struct {
+	Type       string
+	MountPath  string        `hcl:"mount_path"`
+	WrapTTLRaw interface{}   `hcl:"wrap_ttl"`
+	WrapTTL    time.Duration `hcl:"-"`
+	Config     map[string]interface{}
+}
+
+// Sink defines a location to write the authenticated token
+type Sink struct {
+	Type       string
+	WrapTTLRaw interface{}   `hcl:"wrap_ttl"`
+	WrapTTL    time.Duration `hcl:"-"`
+	DHType     string        `hcl:"dh_type"`
+	DHPath     string        `hcl:"dh_path"`
+	AAD        string        `hcl:"aad"`
+	AADEnvVar  string        `hcl:"aad_env_var"`
+	Config     map[string]interface{}
+}
+
+// LoadConfig loads the configuration at the given path
+func LoadConfig(path string) (*Config, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return nil, fmt.Errorf("location is a directory, not a file")
+	}
+
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseConfig(string(d))
+}
+
+// ParseConfig parses the given agent configuration
+func ParseConfig(d string) (*Config, error) {
+	obj, err := hcl.Parse(d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start building the result
+	var result Config
+	if err := hcl.DecodeObject(&result, obj); err != nil {
+		return nil, err
+	}
+
+	list, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
+	}
+
+	valid := []string{
+		"auto_auth",
+		"exit_after_auth",
+		"pid_file",
+		"cache",
+		"listener",
+		"template",
+	}
+	if err := checkHCLKeys(list, valid); err != nil {
+		return nil, err
+	}
+
+	if err := parseCache(&result, list); err != nil {
+		return nil, errwrap.Wrapf("error parsing 'cache': {{err}}", err)
+	}
+
+	if err := parseListeners(&result, list); err != nil {
+		return nil, errwrap.Wrapf("error parsing 'listener': {{err}}", err)
+	}
+
+	if err := parseTemplates(&result, list); err != nil {
+		return nil, errwrap.Wrapf("error parsing 'template': {{err}}", err)
+	}
+
+	// Auto-auth is optional when the agent only runs the caching proxy
+	if result.Cache == nil || len(list.Filter("auto_auth").Items) > 0 {
+		if err := parseAutoAuth(&result, list); err != nil {
+			return nil, errwrap.Wrapf("error parsing 'auto_auth': {{err}}", err)
+		}
+	}
+
+	if result.Cache != nil {
+		if len(result.Listeners) == 0 {
+			return nil, fmt.Errorf("at least one 'listener' block must be provided when 'cache' is configured")
+		}
+		if result.Cache.UseAutoAuthToken && result.AutoAuth == nil {
+			return nil, fmt.Errorf("'use_auto_auth_token' requires an 'auto_auth' block")
+		}
+	}
+
+	if len(result.Templates) > 0 && result.AutoAuth == nil {
+		return nil, fmt.Errorf("'template' requires an 'auto_auth' block")
+	}
+
+	return &result, nil
+}
+
+func parseCache(result *Config, list *ast.ObjectList) error {
+	name := "cache"
+
+	cacheList := list.Filter(name)
+	if len(cacheList.Items) == 0 {
+		return nil
+	}
+
+	if len(cacheList.Items) > 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	item := cacheList.Items[0]
+
+	var c Cache
+	if err := hcl.DecodeObject(&c, item.Val); err != nil {
+		return err
+	}
+
+	result.Cache = &c
+	return nil
+}
+
+func parseListeners(result *Config, list *ast.ObjectList) error {
+	name := "listener"
+
+	listenerList := list.Filter(name)
+
+	var listeners []*Listener
+	for _, item := range listenerList.Items {
+		var lnConfig map[string]interface{}
+		if err := hcl.DecodeObject(&lnConfig, item.Val); err != nil {
+			return err
+		}
+
+		var lnType string
+		switch {
+		case lnConfig["type"] != nil:
+			var ok bool
+			lnType, ok = lnConfig["type"].(string)
+			if !ok {
+				return errors.New("listener type must be a string")
+			}
+			delete(lnConfig, "type")
+		case len(item.Keys) == 1:
+			lnType = strings.ToLower(item.Keys[0].Token.Value().(string))
+		default:
+			return errors.New("listener type must be specified")
+		}
+
+		if lnType != "tcp" {
+			return fmt.Errorf("invalid listener type %q", lnType)
+		}
+
+		listeners = append(listeners, &Listener{
+			Type:   lnType,
+			Config: lnConfig,
+		})
+	}
+
+	result.Listeners = listeners
+
+	return nil
+}
+
+func parseTemplates(result *Config, list *ast.ObjectList) error {
+	name := "template"
+
+	templateList := list.Filter(name)
+
+	var templates []*Template
+	for i, item := range templateList.Items {
+		var t Template
+		if err := hcl.DecodeObject(&t, item.Val); err != nil {
+			return err
+		}
+
+		prefix := fmt.Sprintf("%s.%d:", name, i)
+
+		switch {
+		case t.Source == "" && t.Contents == "":
+			return multierror.Prefix(errors.New("one of 'source' or 'contents' must be specified"), prefix)
+		case t.Source != "" && t.Contents != "":
+			return multierror.Prefix(errors.New("only one of 'source' or 'contents' can be specified"), prefix)
+		case t.Destination == "":
+			return multierror.Prefix(errors.New("'destination' must be specified"), prefix)
+		}
+
+		if t.CommandTimeoutRaw != nil {
+			var err error
+			if t.CommandTimeout, err = parseutil.ParseDurationSecond(t.CommandTimeoutRaw); err != nil {
+				return multierror.Prefix(err, prefix)
+			}
+			t.CommandTimeoutRaw = nil
+		}
+
+		if t.PermsRaw != nil {
+			perms, err := parsePerms(t.PermsRaw)
+			if err != nil {
+				return multierror.Prefix(err, prefix)
+			}
+			t.Perms = perms
+			t.PermsRaw = nil
+		}
+
+		templates = append(templates, &t)
+	}
+
+	result.Templates = templates
+	return nil
+}
+
+// parsePerms parses a file mode given either as an octal string, such as
+// "0640", or as an integer
+func parsePerms(in interface{}) (os.FileMode, error) {
+	switch v := in.(type) {
+	case string:
+		mode, err := strconv.ParseUint(v, 8, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value for 'perms': %q", v)
+		}
+		return os.FileMode(mode), nil
+	case int:
+		return os.FileMode(v), nil
+	default:
+		return 0, fmt.Errorf("invalid value for 'perms': %v", in)
+	}
+}
+
+func parseAutoAuth(result *Config, list *ast.ObjectList) error {
+	name := "auto_auth"
+
+	autoAuthList := list.Filter(name)
+	if len(autoAuthList.Items) != 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	// Get our item
+	item := autoAuthList.Items[0]
+
+	var a AutoAuth
+	if err := hcl.DecodeObject(&a, item.Val); err != nil {
+		return err
+	}
+
+	result.AutoAuth = &a
+
+	subs, ok := item.Val.(*ast.ObjectType)
+	if !ok {
+		return fmt.Errorf("could not parse %q as an object", name)
+	}
+	subList := subs.List
+
+	if err := checkHCLKeys(subList, []string{"method", "sink"}); err != nil {
+		return err
+	}
+
+	if err := parseMethod(result, subList); err != nil {
+		return errwrap.Wrapf("error parsing 'method': {{err}}", err)
+	}
+
+	if err := parseSinks(result, subList); err != nil {
+		return errwrap.Wrapf("error parsing 'sink' stanzas: {{err}}", err)
+	}
+
+	switch {
+	case a.Method == nil:
+		return fmt.Errorf("no 'method' block found")
+	case len(a.Sinks) == 0 && !result.ExitAfterAuth && len(result.Templates) == 0:
+		return fmt.Errorf("at least one 'sink' or 'template' block must be provided when 'exit_after_auth' is false")
+	}
+
+	return nil
+}
+
+func parseMethod(result *Config, list *ast.ObjectList) error {
+	name := "method"
+
+	methodList := list.Filter(name)
+	if len(methodList.Items) != 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	// Get our item
+	item := methodList.Items[0]
+
+	var m Method
+	if err := hcl.DecodeObject(&m, item.Val); err != nil {
+		return err
+	}
+
+	if m.Type == "" {
+		if len(item.Keys) == 1 {
+			m.Type = strings.ToLower(item.Keys[0].Token.Value().(string))
+		}
+		if m.Type == "" {
+			return fmt.Errorf("method type must be specified")
+		}
+	}
+
+	// Default to Vault's default
+	if m.MountPath == "" {
+		m.MountPath = fmt.Sprintf("auth/%s", m.Type)
+	}
+	// Standardize on no trailing slash
+	m.MountPath = strings.TrimSuffix(m.MountPath, "/")
+
+	if m.WrapTTLRaw != nil {
+		var err error
+		if m.WrapTTL, err = parseutil.ParseDurationSecond(m.WrapTTLRaw); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%s.%s:", name, m.Type))
+		}
+		m.WrapTTLRaw = nil
+	}
+
+	result.AutoAuth.Method = &m
+	return nil
+}
+
+func parseSinks(result *Config, list *ast.ObjectList) error {
+	name := "sink"
+
+	sinkList := list.Filter(name)
+	if len(sinkList.Items) < 1 {
+		return nil
+	}
+
+	var ts []*Sink
+
+	for _, item := range sinkList.Items {
+		var s Sink
+		if err := hcl.DecodeObject(&s, item.Val); err != nil {
+			return err
+		}
+
+		if s.Type == "" {
+			if len(item.Keys) == 1 {
+				s.Type = strings.ToLower(item.Keys[0].Token.Value().(string))
+			}
+			if s.Type == "" {
+				return fmt.Errorf("sink type must be specified")
+			}
+		}
+
+		if s.WrapTTLRaw != nil {
+			var err error
+			if s.WrapTTL, err = parseutil.ParseDurationSecond(s.WrapTTLRaw); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("%s.%s:", name, s.Type))
+			}
+			s.WrapTTLRaw = nil
+		}
+
+		switch s.DHType {
+		case "":
+		case "curve25519":
+		default:
+			return multierror.Prefix(fmt.Errorf("invalid value for 'dh_type'"), fmt.Sprintf("%s.%s:", name, s.Type))
+		}
+
+		if s.AADEnvVar != "" {
+			s.AAD = os.Getenv(s.AADEnvVar)
+			s.AADEnvVar = ""
+		}
+
+		switch {
+		case s.DHPath == "" && s.DHType == "":
+			if s.AAD != "" {
+				return multierror.Prefix(fmt.Errorf("specifying AAD data without 'dh_type' does not make sense"), fmt.Sprintf("%s.%s:", name, s.Type))
+			}
+		case s.DHPath != "" && s.DHType != "":
+		default:
+			return multierror.Prefix(fmt.Errorf("'dh_type' and 'dh_path' must be specified together"), fmt.Sprintf("%s.%s:", name, s.Type))
+		}
+
+		ts = append(ts, &s)
+	}
+
+	result.AutoAuth.Sinks = ts
+	return nil
+}
+
+func checkHCLKeys(node ast.Node, valid []string) error {
+	var list *ast.ObjectList
+	switch n := node.(type) {
+	case *ast.ObjectList:
+		list = n
+	case *ast.ObjectType:
+		list = n.List
+	default:
+		return fmt.Errorf("cannot check HCL keys of type %T", n)
+	}
+
+	validMap := make(map[string]struct{}, len(valid))
+	for _, v := range valid {
+		validMap[v] = struct{}{}
+	}
+
+	var result error
+	for _, item := range list.Items {
+		key := item.Keys[0].Token.Value().(string)
+		if _, ok := validMap[key]; !ok {
+			result = multierror.Append(result, fmt.Errorf("invalid key %q on line %d", key, item.Assign.Line))
+		}
+	}
+
+	return result
+}
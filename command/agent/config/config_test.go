@@ -0,0 +1,227 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	if err := os.Setenv("TEST_AAD_ENV", "aad"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("TEST_AAD_ENV")
+
+	config, err := LoadConfig("./test-fixtures/config.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "aws",
+				WrapTTL:   300 * time.Second,
+				MountPath: "auth/aws",
+				Config: map[string]interface{}{
+					"role": "foobar",
+				},
+			},
+			Sinks: []*Sink{
+				&Sink{
+					Type:   "file",
+					DHType: "curve25519",
+					DHPath: "/tmp/file-foo-dhpath",
+					AAD:    "foobar",
+					Config: map[string]interface{}{
+						"path": "/tmp/file-foo",
+					},
+				},
+				&Sink{
+					Type:    "file",
+					WrapTTL: 5 * time.Minute,
+					DHType:  "curve25519",
+					DHPath:  "/tmp/file-foo-dhpath2",
+					AAD:     "aad",
+					Config: map[string]interface{}{
+						"path": "/tmp/file-bar",
+					},
+				},
+			},
+		},
+		PidFile: "./pidfile",
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config, expected)
+	}
+}
+
+func TestLoadConfigFile_Method_Wrapping(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config-method-wrapping.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "aws",
+				WrapTTL:   5 * time.Minute,
+				MountPath: "auth/aws",
+				Config: map[string]interface{}{
+					"role": "foobar",
+				},
+			},
+			Sinks: []*Sink{
+				&Sink{
+					Type: "file",
+					Config: map[string]interface{}{
+						"path": "/tmp/file-foo",
+					},
+				},
+			},
+		},
+		PidFile: "./pidfile",
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config, expected)
+	}
+}
+
+func TestLoadConfigFile_Bad_DHType(t *testing.T) {
+	_, err := LoadConfig("./test-fixtures/bad-config-dh-type.hcl")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestLoadConfigFile_Cache(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config-cache.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "aws",
+				MountPath: "auth/aws",
+				Config: map[string]interface{}{
+					"role": "foobar",
+				},
+			},
+			Sinks: []*Sink{
+				&Sink{
+					Type: "file",
+					Config: map[string]interface{}{
+						"path": "/tmp/file-foo",
+					},
+				},
+			},
+		},
+		Cache: &Cache{
+			UseAutoAuthToken: true,
+		},
+		Listeners: []*Listener{
+			&Listener{
+				Type: "tcp",
+				Config: map[string]interface{}{
+					"address":     "127.0.0.1:8300",
+					"tls_disable": true,
+				},
+			},
+			&Listener{
+				Type: "tcp",
+				Config: map[string]interface{}{
+					"address":       "127.0.0.1:8400",
+					"tls_cert_file": "/path/to/cert.pem",
+					"tls_key_file":  "/path/to/key.pem",
+				},
+			},
+		},
+		PidFile: "./pidfile",
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config, expected)
+	}
+}
+
+func TestLoadConfigFile_Cache_NoAutoAuth(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config-cache-no-auto_auth.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if config.AutoAuth != nil {
+		t.Fatalf("expected no auto_auth, got %#v", config.AutoAuth)
+	}
+	if len(config.Listeners) != 1 {
+		t.Fatalf("expected 1 listener, got %d", len(config.Listeners))
+	}
+}
+
+func TestLoadConfigFile_Bad_CacheNoListener(t *testing.T) {
+	_, err := LoadConfig("./test-fixtures/bad-config-cache-no-listener.hcl")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestLoadConfigFile_Template(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config-template.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "aws",
+				MountPath: "auth/aws",
+				Config: map[string]interface{}{
+					"role": "foobar",
+				},
+			},
+		},
+		Templates: []*Template{
+			&Template{
+				Source:         "/path/on/disk/to/template.ctmpl",
+				Destination:    "/path/on/disk/where/template/will/render.txt",
+				Command:        "restart service foo",
+				CommandTimeout: 10 * time.Second,
+				Perms:          0600,
+			},
+			&Template{
+				Contents:      `{{ with secret "secret/foo" }}{{ .Data.password }}{{ end }}`,
+				Destination:   "/path/on/disk/where/template/will/render2.txt",
+				Perms:         0640,
+				LeftDelim:     "<<",
+				RightDelim:    ">>",
+				ErrMissingKey: true,
+			},
+		},
+		PidFile: "./pidfile",
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config, expected)
+	}
+}
+
+func TestLoadConfigFile_Bad_TemplateNoAutoAuth(t *testing.T) {
+	_, err := LoadConfig("./test-fixtures/bad-config-template-no-auto_auth.hcl")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestLoadConfigFile_Bad_TemplateNoDestination(t *testing.T) {
+	_, err := LoadConfig("./test-fixtures/bad-config-template-no-destination.hcl")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,15 @@
+auto_auth {
+	method "aws" {
+		config = {
+			role = "foobar"
+		}
+	}
+
+	sink "file" {
+		dh_type = "rsa"
+		dh_path = "/tmp/file-foo-dhpath"
+		config = {
+			path = "/tmp/file-foo"
+		}
+	}
+}
@@ -0,0 +1,13 @@
+pid_file = "./pidfile"
+
+cache {}
+
+listener "tcp" {
+	address = "127.0.0.1:8300"
+	tls_disable = true
+}
+
+template {
+	contents = "{{ with secret \"secret/foo\" }}{{ .Data.password }}{{ end }}"
+	destination = "/path/on/disk/where/template/will/render.txt"
+}
@@ -0,0 +1,14 @@
+pid_file = "./pidfile"
+
+auto_auth {
+	method {
+		type = "aws"
+		config = {
+			role = "foobar"
+		}
+	}
+}
+
+template {
+	source = "/path/on/disk/to/template.ctmpl"
+}
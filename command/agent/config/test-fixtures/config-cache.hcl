@@ -0,0 +1,33 @@
+pid_file = "./pidfile"
+
+auto_auth {
+	method {
+		type = "aws"
+		config = {
+			role = "foobar"
+		}
+	}
+
+	sink {
+		type = "file"
+		config = {
+			path = "/tmp/file-foo"
+		}
+	}
+}
+
+cache {
+	use_auto_auth_token = true
+}
+
+listener "tcp" {
+	address = "127.0.0.1:8300"
+	tls_disable = true
+}
+
+listener {
+	type = "tcp"
+	address = "127.0.0.1:8400"
+	tls_cert_file = "/path/to/cert.pem"
+	tls_key_file = "/path/to/key.pem"
+}
@@ -0,0 +1,27 @@
+pid_file = "./pidfile"
+
+auto_auth {
+	method {
+		type = "aws"
+		config = {
+			role = "foobar"
+		}
+	}
+}
+
+template {
+	source = "/path/on/disk/to/template.ctmpl"
+	destination = "/path/on/disk/where/template/will/render.txt"
+	command = "restart service foo"
+	command_timeout = "10s"
+	perms = "0600"
+}
+
+template {
+	contents = "{{ with secret \"secret/foo\" }}{{ .Data.password }}{{ end }}"
+	destination = "/path/on/disk/where/template/will/render2.txt"
+	perms = 0640
+	left_delimiter = "<<"
+	right_delimiter = ">>"
+	error_on_missing_key = true
+}
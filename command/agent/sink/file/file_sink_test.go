@@ -0,0 +1,68 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/helper/logging"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-sink-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+
+	config := &sink.SinkConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Config: map[string]interface{}{
+			"path": path,
+		},
+	}
+
+	fs, err := NewFileSink(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The write check must not leave anything behind
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("expected no files after write check, got %d", len(files))
+	}
+
+	if err := fs.WriteToken("token"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode() != 0640 {
+		t.Fatalf("bad mode: %v", info.Mode())
+	}
+
+	val, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(val) != "token" {
+		t.Fatalf("bad: %q", val)
+	}
+
+	config.Config["path"] = filepath.Join(dir, "missing", "token")
+	if _, err := NewFileSink(config); err == nil {
+		t.Fatal("expected error for an unwritable path")
+	}
+}
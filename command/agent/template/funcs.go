@@ -0,0 +1,48 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"text/template"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// funcMap returns the functions available to templates. Dependencies are
+// read through w, which is nil when the templates are only being parsed.
+func funcMap(w *watcher) template.FuncMap {
+	return template.FuncMap{
+		"secret":  w.secretFunc,
+		"pkiCert": w.pkiCertFunc,
+		"env":     os.Getenv,
+		"toJSON":  toJSON,
+	}
+}
+
+// secretFunc implements the secret template function. With no arguments the
+// path is read; otherwise the "key=value" arguments are written to it.
+func (w *watcher) secretFunc(path string, args ...string) (*api.Secret, error) {
+	value, err := w.get(kindSecret, path, args)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*api.Secret), nil
+}
+
+// pkiCertFunc implements the pkiCert template function, which issues a
+// certificate by writing the "key=value" arguments to path
+func (w *watcher) pkiCertFunc(path string, args ...string) (*PKICertificate, error) {
+	value, err := w.get(kindPKICert, path, args)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*PKICertificate), nil
+}
+
+func toJSON(in interface{}) (string, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
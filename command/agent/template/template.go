@@ -0,0 +1,302 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/config"
+)
+
+const (
+	defaultPerms          os.FileMode = 0644
+	defaultCommandTimeout             = 30 * time.Second
+
+	// DefaultStaticSecretRenderInterval is how often secrets without a lease
+	// are read again to pick up changes
+	DefaultStaticSecretRenderInterval = 5 * time.Minute
+
+	initialBackoff = 2 * time.Second
+	maxBackoff     = 5 * time.Minute
+)
+
+// ServerConfig is the configuration for a template Server
+type ServerConfig struct {
+	Logger        log.Logger
+	Client        *api.Client
+	Templates     []*config.Template
+	ExitAfterAuth bool
+
+	// StaticSecretRenderInterval overrides
+	// DefaultStaticSecretRenderInterval if set
+	StaticSecretRenderInterval time.Duration
+}
+
+// Server renders templates using the token it receives through WriteToken.
+// Templates are rendered again whenever one of the secrets they use changes,
+// and with all secrets read again whenever a new token is received.
+type Server struct {
+	DoneCh chan struct{}
+
+	logger         log.Logger
+	client         *api.Client
+	templates      []*renderer
+	exitAfterAuth  bool
+	staticInterval time.Duration
+	tokenCh        chan string
+}
+
+// renderer is a parsed template along with its configuration
+type renderer struct {
+	config *config.Template
+	tmpl   *template.Template
+}
+
+// NewServer creates a new template Server. The sources of all templates are
+// read and parsed here, so that errors in them are reported at startup.
+func NewServer(conf *ServerConfig) (*Server, error) {
+	if conf == nil {
+		return nil, errors.New("nil configuration provided")
+	}
+	if conf.Logger == nil || conf.Client == nil {
+		return nil, errors.New("missing logger or client")
+	}
+
+	ts := &Server{
+		DoneCh:         make(chan struct{}),
+		logger:         conf.Logger,
+		client:         conf.Client,
+		exitAfterAuth:  conf.ExitAfterAuth,
+		staticInterval: conf.StaticSecretRenderInterval,
+		tokenCh:        make(chan string, 1),
+	}
+	if ts.staticInterval <= 0 {
+		ts.staticInterval = DefaultStaticSecretRenderInterval
+	}
+
+	for _, tc := range conf.Templates {
+		contents := tc.Contents
+		if tc.Source != "" {
+			b, err := ioutil.ReadFile(tc.Source)
+			if err != nil {
+				return nil, errwrap.Wrapf(fmt.Sprintf("error reading template %q: {{err}}", tc.Source), err)
+			}
+			contents = string(b)
+		}
+
+		tmpl := template.New(tc.Destination).
+			Delims(tc.LeftDelim, tc.RightDelim).
+			Funcs(funcMap(nil))
+		if tc.ErrMissingKey {
+			tmpl = tmpl.Option("missingkey=error")
+		}
+		tmpl, err := tmpl.Parse(contents)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error parsing template for %q: {{err}}", tc.Destination), err)
+		}
+
+		ts.templates = append(ts.templates, &renderer{
+			config: tc,
+			tmpl:   tmpl,
+		})
+	}
+
+	return ts, nil
+}
+
+// WriteToken implements sink.Sink. The token replaces any token that has not
+// been picked up by Run yet.
+func (ts *Server) WriteToken(token string) error {
+	for {
+		select {
+		case ts.tokenCh <- token:
+			return nil
+		default:
+		}
+
+		select {
+		case <-ts.tokenCh:
+		default:
+		}
+	}
+}
+
+// Run renders the templates until ctx is canceled. With ExitAfterAuth set, it
+// returns once every template has been rendered.
+func (ts *Server) Run(ctx context.Context) {
+	ts.logger.Info("starting template server")
+	defer func() {
+		ts.logger.Info("template server stopped")
+		close(ts.DoneCh)
+	}()
+
+	changeCh := make(chan struct{}, 1)
+
+	var w *watcher
+	defer func() {
+		if w != nil {
+			w.stop()
+		}
+	}()
+
+	var retryCh <-chan time.Time
+	backoff := initialBackoff
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case token := <-ts.tokenCh:
+			ts.logger.Debug("received new token, reading secrets again")
+			if w != nil {
+				w.stop()
+			}
+
+			client, err := ts.client.Clone()
+			if err != nil {
+				ts.logger.Error("error cloning client", "error", err)
+				w = nil
+				continue
+			}
+			client.SetToken(token)
+
+			w = newWatcher(ctx, &watcherConfig{
+				logger:         ts.logger.Named("watcher"),
+				client:         client,
+				staticInterval: ts.staticInterval,
+				changeCh:       changeCh,
+			})
+
+		case <-changeCh:
+			ts.logger.Debug("secrets changed, rendering templates")
+
+		case <-retryCh:
+		}
+
+		if w == nil {
+			continue
+		}
+
+		if err := ts.renderAll(ctx, w); err != nil {
+			ts.logger.Error("error rendering templates", "error", err, "backoff", backoff.Seconds())
+			retryCh = time.After(backoff)
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			continue
+		}
+		retryCh = nil
+		backoff = initialBackoff
+
+		if ts.exitAfterAuth {
+			return
+		}
+	}
+}
+
+// renderAll renders every template, continuing past failures. It returns the
+// last error seen.
+func (ts *Server) renderAll(ctx context.Context, w *watcher) error {
+	var retErr error
+	for _, r := range ts.templates {
+		if err := ts.render(ctx, w, r); err != nil {
+			retErr = errwrap.Wrapf(fmt.Sprintf("error rendering %q: {{err}}", r.config.Destination), err)
+			ts.logger.Error("error rendering template", "destination", r.config.Destination, "error", err)
+		}
+	}
+	return retErr
+}
+
+// render executes a single template and, if the result differs from the
+// contents of the destination, writes it out and runs the template's command
+func (ts *Server) render(ctx context.Context, w *watcher, r *renderer) error {
+	var buf bytes.Buffer
+	if err := r.tmpl.Funcs(funcMap(w)).Execute(&buf, nil); err != nil {
+		return err
+	}
+
+	existing, err := ioutil.ReadFile(r.config.Destination)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil && bytes.Equal(existing, buf.Bytes()) {
+		return nil
+	}
+
+	if err := writeFile(r.config.Destination, buf.Bytes(), r.config.Perms); err != nil {
+		return err
+	}
+	ts.logger.Info("rendered template", "destination", r.config.Destination)
+
+	if r.config.Command == "" {
+		return nil
+	}
+
+	timeout := r.config.CommandTimeout
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ts.logger.Debug("running template command", "destination", r.config.Destination, "command", r.config.Command)
+	if out, err := shellCommand(cmdCtx, r.config.Command).CombinedOutput(); err != nil {
+		ts.logger.Error("template command failed", "destination", r.config.Destination, "error", err, "output", string(out))
+	}
+
+	return nil
+}
+
+// writeFile atomically replaces path with contents
+func writeFile(path string, contents []byte, perms os.FileMode) error {
+	if perms == 0 {
+		perms = defaultPerms
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp.")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, perms); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return os.Rename(tmpName, path)
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
@@ -0,0 +1,223 @@
+package template
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	kv "github.com/hashicorp/vault-plugin-secrets-kv"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/pki"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/helper/logging"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func testCluster(t *testing.T) (*vault.TestCluster, *api.Client) {
+	t.Helper()
+
+	coreConfig := &vault.CoreConfig{
+		DisableMlock: true,
+		DisableCache: true,
+		Logger:       hclog.NewNullLogger(),
+		LogicalBackends: map[string]logical.Factory{
+			"kv":  kv.Factory,
+			"pki": pki.Factory,
+		},
+	}
+
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+	client := cluster.Cores[0].Client
+
+	if err := client.Sys().Mount("kv", &api.MountInput{
+		Type: "kv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return cluster, client
+}
+
+func waitForContents(t *testing.T, path string, check func(string) bool) string {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		b, err := ioutil.ReadFile(path)
+		if err == nil && check(string(b)) {
+			return string(b)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %q to be rendered", path)
+	return ""
+}
+
+func TestServer_Secret(t *testing.T) {
+	cluster, client := testCluster(t)
+	defer cluster.Cleanup()
+
+	if _, err := client.Logical().Write("kv/foo", map[string]interface{}{
+		"password": "bar",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "agent.template.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "out", "render.txt")
+	commandOut := filepath.Join(dir, "command.txt")
+
+	ts, err := NewServer(&ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Client: client,
+		Templates: []*config.Template{
+			&config.Template{
+				Contents:    `password={{ with secret "kv/foo" }}{{ .Data.password }}{{ end }}`,
+				Destination: dest,
+				Command:     "echo rendered >> " + commandOut,
+				Perms:       0600,
+			},
+		},
+		StaticSecretRenderInterval: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer func() {
+		cancelFunc()
+		<-ts.DoneCh
+	}()
+	go ts.Run(ctx)
+
+	if err := ts.WriteToken(client.Token()); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForContents(t, dest, func(s string) bool { return s == "password=bar" })
+
+	fi, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("bad mode: %o", fi.Mode().Perm())
+	}
+
+	// Changing the secret is picked up on the next read
+	if _, err := client.Logical().Write("kv/foo", map[string]interface{}{
+		"password": "baz",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	waitForContents(t, dest, func(s string) bool { return s == "password=baz" })
+
+	// The command ran once per render
+	out := waitForContents(t, commandOut, func(s string) bool { return strings.Count(s, "rendered") == 2 })
+	if strings.Count(out, "rendered") != 2 {
+		t.Fatalf("bad command output: %q", out)
+	}
+}
+
+func TestServer_PKICert(t *testing.T) {
+	cluster, client := testCluster(t)
+	defer cluster.Cleanup()
+
+	if err := client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+		Config: api.MountConfigInput{
+			MaxLeaseTTL: "87600h",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "example.com",
+		"ttl":         "87600h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("pki/roles/example", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"max_ttl":          "1h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "agent.template.test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "cert.pem")
+
+	ts, err := NewServer(&ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Client: client,
+		Templates: []*config.Template{
+			&config.Template{
+				Contents:    `{{ with pkiCert "pki/issue/example" "common_name=foo.example.com" }}{{ .Cert }}{{ .Key }}{{ end }}`,
+				Destination: dest,
+			},
+		},
+		ExitAfterAuth: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ts.WriteToken(client.Token()); err != nil {
+		t.Fatal(err)
+	}
+
+	go ts.Run(context.Background())
+	select {
+	case <-ts.DoneCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for template server to exit")
+	}
+
+	b, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "BEGIN CERTIFICATE") || !strings.Contains(string(b), "PRIVATE KEY") {
+		t.Fatalf("bad rendered certificate: %s", b)
+	}
+}
+
+func TestNewServer_BadTemplate(t *testing.T) {
+	_, err := NewServer(&ServerConfig{
+		Logger: hclog.NewNullLogger(),
+		Client: &api.Client{},
+		Templates: []*config.Template{
+			&config.Template{
+				Contents:    `{{ with secret "kv/foo" }}`,
+				Destination: "/tmp/render.txt",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
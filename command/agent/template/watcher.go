@@ -0,0 +1,337 @@
+package template
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+)
+
+type dependencyKind string
+
+const (
+	kindSecret  dependencyKind = "secret"
+	kindPKICert dependencyKind = "pkiCert"
+)
+
+// dependency is a secret a template reads, along with its current value
+type dependency struct {
+	kind dependencyKind
+	path string
+	data map[string]interface{}
+
+	secret *api.Secret
+	value  interface{}
+}
+
+type watcherConfig struct {
+	logger         log.Logger
+	client         *api.Client
+	staticInterval time.Duration
+	changeCh       chan struct{}
+}
+
+// watcher reads the secrets used by templates and keeps them current. Leased
+// secrets are renewed and read again once they can no longer be renewed,
+// certificates are issued again before they expire, and other secrets are
+// polled. A send on changeCh signals that a value has changed.
+type watcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	logger         log.Logger
+	client         *api.Client
+	staticInterval time.Duration
+	changeCh       chan struct{}
+
+	lock sync.Mutex
+	deps map[string]*dependency
+}
+
+func newWatcher(ctx context.Context, conf *watcherConfig) *watcher {
+	ctx, cancel := context.WithCancel(ctx)
+	return &watcher{
+		ctx:            ctx,
+		cancel:         cancel,
+		logger:         conf.logger,
+		client:         conf.client,
+		staticInterval: conf.staticInterval,
+		changeCh:       conf.changeCh,
+		deps:           make(map[string]*dependency),
+	}
+}
+
+// stop stops watching all dependencies and waits for the watches to return
+func (w *watcher) stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// get returns the current value of a dependency, reading it and starting to
+// watch it the first time it is requested
+func (w *watcher) get(kind dependencyKind, path string, args []string) (interface{}, error) {
+	data, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]string(nil), args...)
+	sort.Strings(sorted)
+	key := fmt.Sprintf("%s|%s|%s", kind, path, strings.Join(sorted, "&"))
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if d, ok := w.deps[key]; ok {
+		return d.value, nil
+	}
+
+	d := &dependency{
+		kind: kind,
+		path: path,
+		data: data,
+	}
+	secret, value, err := w.fetch(d)
+	if err != nil {
+		return nil, err
+	}
+	d.secret, d.value = secret, value
+	w.deps[key] = d
+
+	w.wg.Add(1)
+	go w.watch(d, secret, value)
+
+	return value, nil
+}
+
+// fetch reads the dependency from Vault. Secrets requested with arguments
+// are written to, as are certificates, which are issued on every fetch.
+func (w *watcher) fetch(d *dependency) (*api.Secret, interface{}, error) {
+	var secret *api.Secret
+	var err error
+	if d.kind == kindPKICert || len(d.data) > 0 {
+		secret, err = w.client.Logical().Write(d.path, d.data)
+	} else {
+		secret, err = w.client.Logical().Read(d.path)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret == nil {
+		return nil, nil, fmt.Errorf("no secret exists at %q", d.path)
+	}
+
+	if d.kind == kindPKICert {
+		cert, err := newPKICertificate(secret)
+		if err != nil {
+			return nil, nil, err
+		}
+		return secret, cert, nil
+	}
+
+	return secret, secret, nil
+}
+
+// watch waits until the dependency needs to be read again, reads it, and
+// signals a change if its value differs
+func (w *watcher) watch(d *dependency, secret *api.Secret, value interface{}) {
+	defer w.wg.Done()
+
+	for {
+		if err := w.wait(d, secret, value); err != nil {
+			return
+		}
+
+		w.logger.Debug("reading secret again", "path", d.path)
+
+		backoff := initialBackoff
+		var newSecret *api.Secret
+		var newValue interface{}
+		for {
+			var err error
+			newSecret, newValue, err = w.fetch(d)
+			if err == nil {
+				break
+			}
+			w.logger.Error("error reading secret", "path", d.path, "error", err, "backoff", backoff.Seconds())
+
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+
+		// A secret without a lease is only considered changed if its data is
+		// different; everything else is a new secret on every read
+		changed := d.kind == kindPKICert || isLeased(secret) || !reflect.DeepEqual(secret.Data, newSecret.Data)
+
+		w.lock.Lock()
+		d.secret, d.value = newSecret, newValue
+		w.lock.Unlock()
+
+		secret, value = newSecret, newValue
+
+		if changed {
+			select {
+			case w.changeCh <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// wait blocks until the dependency should be read again. It returns an error
+// if the watcher is stopped first.
+func (w *watcher) wait(d *dependency, secret *api.Secret, value interface{}) error {
+	var sleep time.Duration
+
+	switch {
+	case d.kind == kindPKICert:
+		// Issue a new certificate once 90% of the current one's validity
+		// has passed
+		cert := value.(*PKICertificate)
+		validity := cert.notAfter.Sub(cert.notBefore)
+		sleep = time.Until(cert.notBefore.Add(validity * 9 / 10))
+
+	case isLeased(secret) && isRenewable(secret):
+		return w.renew(d, secret)
+
+	case isLeased(secret):
+		// Read the secret again when two thirds of its lease have passed
+		sleep = time.Duration(leaseDuration(secret)) * time.Second * 2 / 3
+
+	default:
+		sleep = w.staticInterval
+	}
+
+	if sleep < 0 {
+		sleep = 0
+	}
+
+	select {
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case <-time.After(sleep):
+		return nil
+	}
+}
+
+// renew keeps the secret's lease renewed and returns once it can no longer
+// be renewed
+func (w *watcher) renew(d *dependency, secret *api.Secret) error {
+	renewer, err := w.client.NewRenewer(&api.RenewerInput{
+		Secret: secret,
+	})
+	if err != nil {
+		w.logger.Error("error creating renewer", "path", d.path, "error", err)
+		return nil
+	}
+
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		case err := <-renewer.DoneCh():
+			if err != nil {
+				w.logger.Error("error renewing secret", "path", d.path, "error", err)
+			}
+			return nil
+		case <-renewer.RenewCh():
+			w.logger.Debug("renewed secret", "path", d.path)
+		}
+	}
+}
+
+func isLeased(secret *api.Secret) bool {
+	return secret.LeaseID != "" || (secret.Auth != nil && secret.Auth.ClientToken != "")
+}
+
+func isRenewable(secret *api.Secret) bool {
+	if secret.Auth != nil {
+		return secret.Auth.Renewable
+	}
+	return secret.Renewable
+}
+
+func leaseDuration(secret *api.Secret) int {
+	if secret.Auth != nil {
+		return secret.Auth.LeaseDuration
+	}
+	return secret.LeaseDuration
+}
+
+// parseArgs turns template arguments of the form "key=value" into data to
+// write
+func parseArgs(args []string) (map[string]interface{}, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	data := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value", arg)
+		}
+		data[parts[0]] = parts[1]
+	}
+
+	return data, nil
+}
+
+// PKICertificate is the value returned by the pkiCert template function
+type PKICertificate struct {
+	Cert    string
+	Key     string
+	CA      string
+	CAChain []string
+	Serial  string
+
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+func newPKICertificate(secret *api.Secret) (*PKICertificate, error) {
+	cert := &PKICertificate{}
+	cert.Cert, _ = secret.Data["certificate"].(string)
+	cert.Key, _ = secret.Data["private_key"].(string)
+	cert.CA, _ = secret.Data["issuing_ca"].(string)
+	cert.Serial, _ = secret.Data["serial_number"].(string)
+	if chain, ok := secret.Data["ca_chain"].([]interface{}); ok {
+		for _, c := range chain {
+			if s, ok := c.(string); ok {
+				cert.CAChain = append(cert.CAChain, s)
+			}
+		}
+	}
+
+	block, _ := pem.Decode([]byte(cert.Cert))
+	if block == nil {
+		return nil, errors.New("response did not contain a PEM encoded certificate")
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	cert.notBefore, cert.notAfter = parsed.NotBefore, parsed.NotAfter
+
+	return cert, nil
+}
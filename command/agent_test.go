@@ -0,0 +1,143 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testAgentCommand(tb testing.TB) (*cli.MockUi, *AgentCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AgentCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: MakeShutdownCh(),
+	}
+}
+
+func TestAgentCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	validConfig := `
+auto_auth {
+	method "approle" {
+		config = {
+			role_id_file_path = "/tmp/role"
+			secret_id_file_path = "/tmp/secret"
+		}
+	}
+
+	sink "file" {
+		config = {
+			path = "/tmp/token"
+		}
+	}
+}
+`
+
+	noSinkConfig := `
+auto_auth {
+	method "approle" {
+		config = {
+			role_id_file_path = "/tmp/role"
+			secret_id_file_path = "/tmp/secret"
+		}
+	}
+}
+`
+
+	cases := []struct {
+		name   string
+		config string
+		args   []string
+		out    string
+		code   int
+	}{
+		{
+			"no_config",
+			"",
+			nil,
+			"Must specify exactly one config path",
+			1,
+		},
+		{
+			"missing_config",
+			"",
+			[]string{"-config", "/not/a/real/config.hcl"},
+			"Error loading configuration",
+			1,
+		},
+		{
+			"no_sink",
+			noSinkConfig,
+			nil,
+			"at least one 'sink' or 'template' block must be provided",
+			1,
+		},
+		{
+			"bad_log_level",
+			validConfig,
+			[]string{"-log-level", "nope"},
+			"Unknown log level",
+			1,
+		},
+		{
+			"verify_only",
+			validConfig,
+			[]string{"-test-verify-only"},
+			"Vault agent configuration",
+			0,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				args := tc.args
+				if tc.config != "" {
+					f, err := ioutil.TempFile("", "vault-agent")
+					if err != nil {
+						t.Fatal(err)
+					}
+					defer os.Remove(f.Name())
+					if _, err := f.WriteString(tc.config); err != nil {
+						t.Fatal(err)
+					}
+					f.Close()
+					args = append([]string{"-config", f.Name()}, args...)
+				}
+
+				ui, cmd := testAgentCommand(t)
+
+				code := cmd.Run(args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testAgentCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
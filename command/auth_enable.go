@@ -25,6 +25,7 @@ type AuthEnableCommand struct {
 	flagAuditNonHMACResponseKeys  []string
 	flagListingVisibility         string
 	flagPassthroughRequestHeaders []string
+	flagMFAMethods                []string
 	flagPluginName                string
 	flagOptions                   map[string]string
 	flagLocal                     bool
@@ -129,6 +130,13 @@ func (c *AuthEnableCommand) Flags() *FlagSets {
 			"will be sent to the backend",
 	})
 
+	f.StringSliceVar(&StringSliceVar{
+		Name:   flagNameMFAMethods,
+		Target: &c.flagMFAMethods,
+		Usage: "Comma-separated string or list of login MFA methods that must " +
+			"be passed to log in with the auth method.",
+	})
+
 	f.StringVar(&StringVar{
 		Name:       "plugin-name",
 		Target:     &c.flagPluginName,
@@ -241,6 +249,10 @@ func (c *AuthEnableCommand) Run(args []string) int {
 		if fl.Name == flagNamePassthroughRequestHeaders {
 			authOpts.Config.PassthroughRequestHeaders = c.flagPassthroughRequestHeaders
 		}
+
+		if fl.Name == flagNameMFAMethods {
+			authOpts.Config.MFAMethods = c.flagMFAMethods
+		}
 	})
 
 	if err := client.Sys().EnableAuthWithOptions(authPath, authOpts); err != nil {
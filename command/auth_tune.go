@@ -17,12 +17,14 @@ var _ cli.CommandAutocomplete = (*AuthTuneCommand)(nil)
 type AuthTuneCommand struct {
 	*BaseCommand
 
-	flagOptions                  map[string]string
-	flagDefaultLeaseTTL          time.Duration
-	flagMaxLeaseTTL              time.Duration
-	flagAuditNonHMACRequestKeys  []string
-	flagAuditNonHMACResponseKeys []string
-	flagListingVisibility        string
+	flagOptions                   map[string]string
+	flagDefaultLeaseTTL           time.Duration
+	flagMaxLeaseTTL               time.Duration
+	flagAuditNonHMACRequestKeys   []string
+	flagAuditNonHMACResponseKeys  []string
+	flagListingVisibility         string
+	flagPassthroughRequestHeaders []string
+	flagMFAMethods                []string
 }
 
 func (c *AuthTuneCommand) Synopsis() string {
@@ -101,6 +103,20 @@ func (c *AuthTuneCommand) Flags() *FlagSets {
 		Usage:  "Determines the visibility of the mount in the UI-specific listing endpoint.",
 	})
 
+	f.StringSliceVar(&StringSliceVar{
+		Name:   flagNamePassthroughRequestHeaders,
+		Target: &c.flagPassthroughRequestHeaders,
+		Usage: "Comma-separated string or list of request header values that " +
+			"will be sent to the backend",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:   flagNameMFAMethods,
+		Target: &c.flagMFAMethods,
+		Usage: "Comma-separated string or list of login MFA methods that must " +
+			"be passed to log in with the auth method.",
+	})
+
 	return set
 }
 
@@ -155,6 +171,14 @@ func (c *AuthTuneCommand) Run(args []string) int {
 		if fl.Name == flagNameListingVisibility {
 			mountConfigInput.ListingVisibility = c.flagListingVisibility
 		}
+
+		if fl.Name == flagNamePassthroughRequestHeaders {
+			mountConfigInput.PassthroughRequestHeaders = c.flagPassthroughRequestHeaders
+		}
+
+		if fl.Name == flagNameMFAMethods {
+			mountConfigInput.MFAMethods = c.flagMFAMethods
+		}
 	})
 
 	// Append /auth (since that's where auths live) and a trailing slash to
@@ -43,7 +43,8 @@ type BaseCommand struct {
 	flagFormat string
 	flagField  string
 
-	flagMFA []string
+	flagMFA       []string
+	flagNamespace string
 
 	tokenHelper token.TokenHelper
 
@@ -113,6 +114,10 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 
 	client.SetMFACreds(c.flagMFA)
 
+	if c.flagNamespace != "" {
+		client.SetNamespace(c.flagNamespace)
+	}
+
 	c.client = client
 
 	return client, nil
@@ -270,6 +275,17 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 				Completion: complete.PredictAnything,
 				Usage:      "Supply MFA credentials as part of X-Vault-MFA header.",
 			})
+
+			f.StringVar(&StringVar{
+				Name:       "namespace",
+				Target:     &c.flagNamespace,
+				Default:    "",
+				EnvVar:     api.EnvVaultNamespace,
+				Completion: complete.PredictAnything,
+				Usage: "The namespace to use for the command. Paths are relative " +
+					"to this namespace. This is sent as the X-Vault-Namespace " +
+					"header.",
+			})
 		}
 
 		if bit&(FlagSetOutputField|FlagSetOutputFormat) != 0 {
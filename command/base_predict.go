@@ -99,7 +99,10 @@ func (b *BaseCommand) PredictVaultAvailableAuths() complete.Predictor {
 		"cert",
 		"gcp",
 		"github",
+		"jwt",
+		"kerberos",
 		"ldap",
+		"oidc",
 		"okta",
 		"plugin",
 		"radius",
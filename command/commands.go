@@ -43,6 +43,8 @@ import (
 	credAws "github.com/hashicorp/vault/builtin/credential/aws"
 	credCert "github.com/hashicorp/vault/builtin/credential/cert"
 	credGitHub "github.com/hashicorp/vault/builtin/credential/github"
+	credJWT "github.com/hashicorp/vault/builtin/credential/jwt"
+	credKerberos "github.com/hashicorp/vault/builtin/credential/kerberos"
 	credLdap "github.com/hashicorp/vault/builtin/credential/ldap"
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
 	credRadius "github.com/hashicorp/vault/builtin/credential/radius"
@@ -63,6 +65,7 @@ import (
 	physMSSQL "github.com/hashicorp/vault/physical/mssql"
 	physMySQL "github.com/hashicorp/vault/physical/mysql"
 	physPostgreSQL "github.com/hashicorp/vault/physical/postgresql"
+	physRaft "github.com/hashicorp/vault/physical/raft"
 	physS3 "github.com/hashicorp/vault/physical/s3"
 	physSpanner "github.com/hashicorp/vault/physical/spanner"
 	physSwift "github.com/hashicorp/vault/physical/swift"
@@ -83,6 +86,8 @@ const (
 	flagNameListingVisibility = "listing-visibility"
 	// flagNamePassthroughRequestHeaders is the flag name used to set passthrough request headers to the backend
 	flagNamePassthroughRequestHeaders = "passthrough-request-headers"
+	// flagNameMFAMethods is the flag name used to set the login MFA methods of auth methods
+	flagNameMFAMethods = "mfa-methods"
 )
 
 var (
@@ -101,8 +106,11 @@ var (
 		"cert":       credCert.Factory,
 		"gcp":        credGcp.Factory,
 		"github":     credGitHub.Factory,
+		"jwt":        credJWT.Factory,
+		"kerberos":   credKerberos.Factory,
 		"kubernetes": credKube.Factory,
 		"ldap":       credLdap.Factory,
+		"oidc":       credJWT.Factory,
 		"okta":       credOkta.Factory,
 		"plugin":     plugin.Factory,
 		"radius":     credRadius.Factory,
@@ -149,6 +157,7 @@ var (
 		"mssql":                  physMSSQL.NewMSSQLBackend,
 		"mysql":                  physMySQL.NewMySQLBackend,
 		"postgresql":             physPostgreSQL.NewPostgreSQLBackend,
+		"raft":                   physRaft.NewRaftBackend,
 		"s3":                     physS3.NewS3Backend,
 		"spanner":                physSpanner.NewBackend,
 		"swift":                  physSwift.NewSwiftBackend,
@@ -200,7 +209,9 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 		"centrify": &credCentrify.CLIHandler{},
 		"cert":     &credCert.CLIHandler{},
 		"github":   &credGitHub.CLIHandler{},
+		"kerberos": &credKerberos.CLIHandler{},
 		"ldap":     &credLdap.CLIHandler{},
+		"oidc":     &credJWT.CLIHandler{},
 		"okta":     &credOkta.CLIHandler{},
 		"radius": &credUserpass.CLIHandler{
 			DefaultMount: "radius",
@@ -212,6 +223,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 	}
 
 	Commands = map[string]cli.CommandFactory{
+		"agent": func() (cli.Command, error) {
+			return &AgentCommand{
+				BaseCommand: &BaseCommand{
+					UI:          serverCmdUi,
+					tokenHelper: runOpts.TokenHelper,
+					flagAddress: runOpts.Address,
+				},
+				ShutdownCh: MakeShutdownCh(),
+			}, nil
+		},
 		"audit": func() (cli.Command, error) {
 			return &AuditCommand{
 				BaseCommand: &BaseCommand{
@@ -395,6 +416,15 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				},
 			}, nil
 		},
+		"operator migrate": func() (cli.Command, error) {
+			return &OperatorMigrateCommand{
+				BaseCommand: &BaseCommand{
+					UI: ui,
+				},
+				PhysicalBackends: physicalBackends,
+				ShutdownCh:       MakeShutdownCh(),
+			}, nil
+		},
 		"operator rekey": func() (cli.Command, error) {
 			return &OperatorRekeyCommand{
 				BaseCommand: &BaseCommand{
@@ -422,6 +452,31 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				},
 			}, nil
 		},
+		"operator snapshot": func() (cli.Command, error) {
+			return &OperatorSnapshotCommand{
+				BaseCommand: &BaseCommand{
+					UI: ui,
+				},
+			}, nil
+		},
+		"operator snapshot restore": func() (cli.Command, error) {
+			return &OperatorSnapshotRestoreCommand{
+				BaseCommand: &BaseCommand{
+					UI:          ui,
+					tokenHelper: runOpts.TokenHelper,
+					flagAddress: runOpts.Address,
+				},
+			}, nil
+		},
+		"operator snapshot save": func() (cli.Command, error) {
+			return &OperatorSnapshotSaveCommand{
+				BaseCommand: &BaseCommand{
+					UI:          ui,
+					tokenHelper: runOpts.TokenHelper,
+					flagAddress: runOpts.Address,
+				},
+			}, nil
+		},
 		"operator step-down": func() (cli.Command, error) {
 			return &OperatorStepDownCommand{
 				BaseCommand: &BaseCommand{
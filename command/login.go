@@ -5,11 +5,17 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/password"
 	"github.com/posener/complete"
 )
 
+// loginMFAPollInterval is how often a login waiting for push notifications to
+// be approved is polled
+const loginMFAPollInterval = 2 * time.Second
+
 // LoginHandler is the interface that any auth handlers must implement to enable
 // auth via the CLI.
 type LoginHandler interface {
@@ -252,6 +258,15 @@ func (c *LoginCommand) Run(args []string) int {
 		return 2
 	}
 
+	// Complete the login if it requires MFA
+	if secret != nil && secret.Auth != nil && secret.Auth.MFARequirement != nil {
+		secret, err = c.validateMFA(client, secret.Auth.MFARequirement)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error validating MFA: %s", err))
+			return 2
+		}
+	}
+
 	// Unset any previous token wrapping functionality. If the original request
 	// was for a wrapped token, we don't want future requests to be wrapped.
 	client.SetWrappingLookupFunc(func(string, string) string { return "" })
@@ -378,3 +393,33 @@ func (c *LoginCommand) extractToken(client *api.Client, secret *api.Secret, unwr
 		return nil, false, fmt.Errorf("no auth or wrapping info in response")
 	}
 }
+
+// validateMFA asks for the passcodes of the MFA methods required by a login
+// and completes the login
+func (c *LoginCommand) validateMFA(client *api.Client, requirement *api.MFARequirement) (*api.Secret, error) {
+	payload := make(map[string][]string, len(requirement.MFAMethods))
+	for _, method := range requirement.MFAMethods {
+		if !method.UsesPasscode {
+			c.UI.Info(fmt.Sprintf("Approve the push notification of MFA method %q to continue...", method.Name))
+			payload[method.Name] = []string{}
+			continue
+		}
+
+		fmt.Fprintf(os.Stdout, "Passcode for MFA method %q (will be hidden): ", method.Name)
+		passcode, err := password.Read(os.Stdin)
+		fmt.Fprintf(os.Stdout, "\n")
+		if err != nil {
+			return nil, err
+		}
+		payload[method.Name] = []string{strings.TrimSpace(passcode)}
+	}
+
+	// While push notifications wait to be approved, the methods still
+	// outstanding are returned and the login is validated again to poll them
+	secret, err := client.Sys().MFAValidate(requirement.MFARequestID, payload)
+	for err == nil && secret != nil && secret.Auth != nil && secret.Auth.MFARequirement != nil {
+		time.Sleep(loginMFAPollInterval)
+		secret, err = client.Sys().MFAValidate(requirement.MFARequestID, payload)
+	}
+	return secret, err
+}
@@ -0,0 +1,496 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/physical"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*OperatorMigrateCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorMigrateCommand)(nil)
+
+const (
+	// storageMigrationLock is the key written to the source storage while a
+	// migration is running. Servers refuse to start while it is present.
+	storageMigrationLock = "core/migration"
+
+	// migrationProgressInterval is how many keys are copied between updates
+	// of the progress recorded in the migration lock
+	migrationProgressInterval = 100
+)
+
+// migrationSkipKeys are never copied between backends. The HA lock belongs to
+// the source cluster and the migration lock only describes this migration.
+var migrationSkipKeys = map[string]bool{
+	"core/lock":          true,
+	storageMigrationLock: true,
+}
+
+type OperatorMigrateCommand struct {
+	*BaseCommand
+
+	PhysicalBackends map[string]physical.Factory
+	ShutdownCh       chan struct{}
+
+	flagConfig   string
+	flagStart    string
+	flagReset    bool
+	flagDryRun   bool
+	flagLogLevel string
+
+	logger log.Logger
+}
+
+// migratorConfig is the configuration read from the file given with -config
+type migratorConfig struct {
+	StorageSource      *server.Storage `hcl:"-"`
+	StorageDestination *server.Storage `hcl:"-"`
+}
+
+// StorageMigrationStatus is the value of the migration lock
+type StorageMigrationStatus struct {
+	Start   time.Time `json:"start"`
+	LastKey string    `json:"last_key"`
+}
+
+func (c *OperatorMigrateCommand) Synopsis() string {
+	return "Migrates Vault data between storage backends"
+}
+
+func (c *OperatorMigrateCommand) Help() string {
+	helpText := `
+Usage: vault operator migrate [options]
+
+  Copies every entry from one storage backend to another. This is an offline
+  operation: Vault servers using the source storage must be stopped first, and
+  will refuse to start until the migration finishes. The data is copied as-is,
+  so the destination can be used with the same unseal keys.
+
+  The source and destination are read from a configuration file containing a
+  "storage_source" and a "storage_destination" stanza. These take the same
+  options as the "storage" stanza of the server configuration:
+
+      storage_source "file" {
+        path = "/var/lib/vault"
+      }
+
+      storage_destination "consul" {
+        path = "vault"
+      }
+
+  Migrate the data:
+
+      $ vault operator migrate -config=migrate.hcl
+
+  Show what would be copied without writing anything:
+
+      $ vault operator migrate -config=migrate.hcl -dry-run
+
+  An interrupted migration resumes where it stopped the next time the command
+  is run with the same configuration.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorMigrateCommand) Flags() *FlagSets {
+	set := NewFlagSets(c.UI)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:   "config",
+		Target: &c.flagConfig,
+		Completion: complete.PredictOr(
+			complete.PredictFiles("*.hcl"),
+			complete.PredictFiles("*.json"),
+		),
+		Usage: "Path to a configuration file with the source and destination " +
+			"storage stanzas.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:   "start",
+		Target: &c.flagStart,
+		Usage: "Only copy keys that sort lexicographically at or after this " +
+			"value. This overrides the progress recorded by an interrupted " +
+			"migration.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:   "reset",
+		Target: &c.flagReset,
+		Usage: "Remove the migration lock and recorded progress from the " +
+			"source storage, then exit without copying any data.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:   "dry-run",
+		Target: &c.flagDryRun,
+		Usage: "Compare the source and destination and list the keys that " +
+			"would be created or updated, without writing to either backend.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "log-level",
+		Target:     &c.flagLogLevel,
+		Default:    "info",
+		EnvVar:     "VAULT_LOG_LEVEL",
+		Completion: complete.PredictSet("trace", "debug", "info", "warn", "error"),
+		Usage: "Log verbosity level. Supported values (in order of detail) are " +
+			"\"trace\", \"debug\", \"info\", \"warn\", and \"error\".",
+	})
+
+	return set
+}
+
+func (c *OperatorMigrateCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *OperatorMigrateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorMigrateCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if len(f.Args()) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(f.Args())))
+		return 1
+	}
+
+	if c.flagConfig == "" {
+		c.UI.Error("Must specify exactly one config path using -config")
+		return 1
+	}
+
+	level := log.LevelFromString(c.flagLogLevel)
+	if level == log.NoLevel {
+		c.UI.Error(fmt.Sprintf("Unknown log level: %s", c.flagLogLevel))
+		return 1
+	}
+	c.logger = logging.NewVaultLogger(level)
+
+	config, err := c.loadMigratorConfig(c.flagConfig)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfig, err))
+		return 1
+	}
+
+	from, err := c.newBackend(config.StorageSource)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing source storage: %s", err))
+		return 2
+	}
+
+	if c.flagReset {
+		if err := from.Delete(context.Background(), storageMigrationLock); err != nil {
+			c.UI.Error(fmt.Sprintf("Error removing migration lock: %s", err))
+			return 2
+		}
+		c.UI.Output("Success! Removed the migration lock.")
+		return 0
+	}
+
+	to, err := c.newBackend(config.StorageDestination)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing destination storage: %s", err))
+		return 2
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.ShutdownCh:
+			c.UI.Output("==> Migration shutdown triggered")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if c.flagDryRun {
+		if err := c.dryRun(ctx, from, to); err != nil {
+			c.UI.Error(fmt.Sprintf("Error comparing storage: %s", err))
+			return 2
+		}
+		return 0
+	}
+
+	if err := c.migrate(ctx, from, to); err != nil {
+		c.UI.Error(fmt.Sprintf("Error migrating storage: %s", err))
+		return 2
+	}
+
+	c.UI.Output("Success! All of the keys have been migrated.")
+	return 0
+}
+
+// migrate copies every entry from the source to the destination, recording
+// its progress in the migration lock so an interrupted run can be resumed
+func (c *OperatorMigrateCommand) migrate(ctx context.Context, from, to physical.Backend) error {
+	status, err := CheckStorageMigration(ctx, from)
+	if err != nil {
+		return errwrap.Wrapf("error reading migration lock: {{err}}", err)
+	}
+
+	start := c.flagStart
+	switch {
+	case status == nil:
+		status = &StorageMigrationStatus{
+			Start: time.Now().UTC(),
+		}
+	case start == "" && status.LastKey != "":
+		c.UI.Output(fmt.Sprintf("Resuming migration started at %s after key %q",
+			status.Start.Format(time.RFC3339), status.LastKey))
+	}
+
+	if err := SetStorageMigration(ctx, from, status); err != nil {
+		return errwrap.Wrapf("error writing migration lock: {{err}}", err)
+	}
+
+	var count int
+	err = walkSortedKeys(ctx, from, "", func(key string) error {
+		if migrationSkipKeys[key] {
+			return nil
+		}
+		if start != "" {
+			if key < start {
+				return nil
+			}
+		} else if status.LastKey != "" && key <= status.LastKey {
+			return nil
+		}
+
+		entry, err := from.Get(ctx, key)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error reading key %q: {{err}}", key), err)
+		}
+		if entry == nil {
+			// Deleted since it was listed
+			return nil
+		}
+
+		if err := to.Put(ctx, entry); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error writing key %q: {{err}}", key), err)
+		}
+		c.logger.Info("copied key", "path", key)
+
+		count++
+		status.LastKey = key
+		if count%migrationProgressInterval == 0 {
+			if err := SetStorageMigration(ctx, from, status); err != nil {
+				return errwrap.Wrapf("error recording migration progress: {{err}}", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Record how far this run got before giving up. The error from the
+		// copy is more useful than one from this write, so that is ignored.
+		SetStorageMigration(context.Background(), from, status)
+		return err
+	}
+
+	if err := from.Delete(ctx, storageMigrationLock); err != nil {
+		return errwrap.Wrapf("error removing migration lock: {{err}}", err)
+	}
+
+	return nil
+}
+
+// dryRun lists the keys a migration would create or update in the
+// destination without writing to either backend
+func (c *OperatorMigrateCommand) dryRun(ctx context.Context, from, to physical.Backend) error {
+	var created, updated, unchanged int
+	err := walkSortedKeys(ctx, from, "", func(key string) error {
+		if migrationSkipKeys[key] {
+			return nil
+		}
+		if c.flagStart != "" && key < c.flagStart {
+			return nil
+		}
+
+		entry, err := from.Get(ctx, key)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error reading key %q from source: {{err}}", key), err)
+		}
+		if entry == nil {
+			return nil
+		}
+
+		existing, err := to.Get(ctx, key)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error reading key %q from destination: {{err}}", key), err)
+		}
+
+		switch {
+		case existing == nil:
+			created++
+			c.UI.Output(fmt.Sprintf("+ %s", key))
+		case !bytes.Equal(existing.Value, entry.Value):
+			updated++
+			c.UI.Output(fmt.Sprintf("~ %s", key))
+		default:
+			unchanged++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.UI.Output(fmt.Sprintf("\nDry run: %d to create, %d to update, %d unchanged",
+		created, updated, unchanged))
+	return nil
+}
+
+// newBackend creates the physical backend described by a storage stanza
+func (c *OperatorMigrateCommand) newBackend(storage *server.Storage) (physical.Backend, error) {
+	factory, ok := c.PhysicalBackends[storage.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown storage type %s", storage.Type)
+	}
+
+	return factory(storage.Config, c.logger.ResetNamed("storage."+storage.Type))
+}
+
+// loadMigratorConfig reads and validates the migration configuration file
+func (c *OperatorMigrateCommand) loadMigratorConfig(path string) (*migratorConfig, error) {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMigratorConfig(string(d))
+}
+
+func parseMigratorConfig(d string) (*migratorConfig, error) {
+	obj, err := hcl.Parse(d)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
+	}
+
+	var result migratorConfig
+	for _, name := range []string{"storage_source", "storage_destination"} {
+		stanza := list.Filter(name)
+		switch len(stanza.Items) {
+		case 0:
+			return nil, fmt.Errorf("missing %q stanza", name)
+		case 1:
+		default:
+			return nil, fmt.Errorf("only one %q block is permitted", name)
+		}
+
+		item := stanza.Items[0]
+		if len(item.Keys) == 0 {
+			return nil, fmt.Errorf("missing storage type in %q stanza", name)
+		}
+		key := item.Keys[0].Token.Value().(string)
+
+		var m map[string]string
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return nil, multierror.Prefix(err, fmt.Sprintf("%s.%s:", name, key))
+		}
+
+		storage := &server.Storage{
+			Type:   strings.ToLower(key),
+			Config: m,
+		}
+		if name == "storage_source" {
+			result.StorageSource = storage
+		} else {
+			result.StorageDestination = storage
+		}
+	}
+
+	return &result, nil
+}
+
+// walkSortedKeys calls fn with every key under prefix in lexicographic order,
+// which lets a migration resume from the last key it copied
+func walkSortedKeys(ctx context.Context, b physical.Backend, prefix string, fn func(string) error) error {
+	keys, err := b.List(ctx, prefix)
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("error listing %q: {{err}}", prefix), err)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(key, "/") {
+			if err := walkSortedKeys(ctx, b, prefix+key, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(prefix + key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckStorageMigration returns the status of a migration using b as its
+// source, or nil if no migration is in progress
+func CheckStorageMigration(ctx context.Context, b physical.Backend) (*StorageMigrationStatus, error) {
+	entry, err := b.Get(ctx, storageMigrationLock)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var status StorageMigrationStatus
+	if err := jsonutil.DecodeJSON(entry.Value, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// SetStorageMigration writes the migration lock to b
+func SetStorageMigration(ctx context.Context, b physical.Backend, status *StorageMigrationStatus) error {
+	value, err := jsonutil.EncodeJSON(status)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(ctx, &physical.Entry{
+		Key:   storageMigrationLock,
+		Value: value,
+	})
+}
@@ -0,0 +1,366 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/physical"
+	physFile "github.com/hashicorp/vault/physical/file"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorMigrateCommand(tb testing.TB) (*cli.MockUi, *OperatorMigrateCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorMigrateCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		PhysicalBackends: map[string]physical.Factory{
+			"file": physFile.NewFileBackend,
+		},
+		ShutdownCh: MakeShutdownCh(),
+	}
+}
+
+// testMigrateDirs creates source and destination file storage directories and
+// a configuration file pointing at them
+func testMigrateDirs(tb testing.TB) (string, physical.Backend, physical.Backend, func()) {
+	tb.Helper()
+
+	dir, err := ioutil.TempDir("", "vault-migrate")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	srcPath := filepath.Join(dir, "src")
+	dstPath := filepath.Join(dir, "dst")
+	configPath := filepath.Join(dir, "migrate.hcl")
+	config := fmt.Sprintf(`
+storage_source "file" {
+  path = %q
+}
+
+storage_destination "file" {
+  path = %q
+}
+`, srcPath, dstPath)
+	if err := ioutil.WriteFile(configPath, []byte(config), 0644); err != nil {
+		os.RemoveAll(dir)
+		tb.Fatal(err)
+	}
+
+	logger := logging.NewVaultLogger(log.Error)
+	src, err := physFile.NewFileBackend(map[string]string{"path": srcPath}, logger)
+	if err != nil {
+		os.RemoveAll(dir)
+		tb.Fatal(err)
+	}
+	dst, err := physFile.NewFileBackend(map[string]string{"path": dstPath}, logger)
+	if err != nil {
+		os.RemoveAll(dir)
+		tb.Fatal(err)
+	}
+
+	return configPath, src, dst, func() { os.RemoveAll(dir) }
+}
+
+func testMigratePut(tb testing.TB, b physical.Backend, key, value string) {
+	tb.Helper()
+
+	if err := b.Put(context.Background(), &physical.Entry{Key: key, Value: []byte(value)}); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func testMigrateGet(tb testing.TB, b physical.Backend, key string) string {
+	tb.Helper()
+
+	entry, err := b.Get(context.Background(), key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if entry == nil {
+		return ""
+	}
+	return string(entry.Value)
+}
+
+func TestOperatorMigrateCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		configPath, _, _, cleanup := testMigrateDirs(t)
+		defer cleanup()
+
+		cases := []struct {
+			name string
+			args []string
+			out  string
+			code int
+		}{
+			{
+				"no_config",
+				nil,
+				"Must specify exactly one config path",
+				1,
+			},
+			{
+				"too_many_args",
+				[]string{"-config", configPath, "foo"},
+				"Too many arguments",
+				1,
+			},
+			{
+				"missing_config",
+				[]string{"-config", "/not/a/real/config.hcl"},
+				"Error loading configuration",
+				1,
+			},
+			{
+				"bad_log_level",
+				[]string{"-config", configPath, "-log-level", "nope"},
+				"Unknown log level",
+				1,
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testOperatorMigrateCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		t.Parallel()
+
+		configPath, src, dst, cleanup := testMigrateDirs(t)
+		defer cleanup()
+
+		testMigratePut(t, src, "core/keyring", "keyring")
+		testMigratePut(t, src, "core/lock", "lock")
+		testMigratePut(t, src, "logical/abc/foo", "foo")
+		testMigratePut(t, src, "logical/abc/foo/bar", "bar")
+		testMigratePut(t, dst, "logical/abc/foo", "old")
+
+		ui, cmd := testOperatorMigrateCommand(t)
+		code := cmd.Run([]string{"-config", configPath, "-log-level", "error"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		for key, value := range map[string]string{
+			"core/keyring":        "keyring",
+			"core/lock":           "",
+			"logical/abc/foo":     "foo",
+			"logical/abc/foo/bar": "bar",
+			storageMigrationLock:  "",
+		} {
+			if actual := testMigrateGet(t, dst, key); actual != value {
+				t.Errorf("expected %q to be %q for key %q", actual, value, key)
+			}
+		}
+
+		status, err := CheckStorageMigration(context.Background(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != nil {
+			t.Errorf("expected migration lock to be removed: %#v", status)
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		t.Parallel()
+
+		configPath, src, dst, cleanup := testMigrateDirs(t)
+		defer cleanup()
+
+		testMigratePut(t, src, "a", "a")
+		testMigratePut(t, src, "b", "b")
+		testMigratePut(t, src, "c/d", "d")
+
+		// Simulate a migration that was interrupted after copying "b"
+		if err := SetStorageMigration(context.Background(), src, &StorageMigrationStatus{LastKey: "b"}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorMigrateCommand(t)
+		code := cmd.Run([]string{"-config", configPath, "-log-level", "error"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := `Resuming migration started at`
+		if combined := ui.OutputWriter.String(); !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		for key, value := range map[string]string{
+			"a":   "",
+			"b":   "",
+			"c/d": "d",
+		} {
+			if actual := testMigrateGet(t, dst, key); actual != value {
+				t.Errorf("expected %q to be %q for key %q", actual, value, key)
+			}
+		}
+	})
+
+	t.Run("start", func(t *testing.T) {
+		t.Parallel()
+
+		configPath, src, dst, cleanup := testMigrateDirs(t)
+		defer cleanup()
+
+		testMigratePut(t, src, "a", "a")
+		testMigratePut(t, src, "b", "b")
+
+		ui, cmd := testOperatorMigrateCommand(t)
+		code := cmd.Run([]string{"-config", configPath, "-start", "b", "-log-level", "error"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		if actual := testMigrateGet(t, dst, "a"); actual != "" {
+			t.Errorf("expected key before start to be skipped, got %q", actual)
+		}
+		if actual := testMigrateGet(t, dst, "b"); actual != "b" {
+			t.Errorf("expected %q to be %q", actual, "b")
+		}
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		t.Parallel()
+
+		configPath, src, dst, cleanup := testMigrateDirs(t)
+		defer cleanup()
+
+		testMigratePut(t, src, "new", "new")
+		testMigratePut(t, src, "changed", "new")
+		testMigratePut(t, src, "same", "same")
+		testMigratePut(t, dst, "changed", "old")
+		testMigratePut(t, dst, "same", "same")
+
+		ui, cmd := testOperatorMigrateCommand(t)
+		code := cmd.Run([]string{"-config", configPath, "-dry-run"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		combined := ui.OutputWriter.String()
+		for _, expected := range []string{
+			"+ new",
+			"~ changed",
+			"1 to create, 1 to update, 1 unchanged",
+		} {
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+		if strings.Contains(combined, "same") {
+			t.Errorf("expected %q to not list unchanged keys", combined)
+		}
+
+		if actual := testMigrateGet(t, dst, "new"); actual != "" {
+			t.Errorf("expected dry run to not write, got %q", actual)
+		}
+		if actual := testMigrateGet(t, dst, "changed"); actual != "old" {
+			t.Errorf("expected dry run to not write, got %q", actual)
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		t.Parallel()
+
+		configPath, src, _, cleanup := testMigrateDirs(t)
+		defer cleanup()
+
+		if err := SetStorageMigration(context.Background(), src, &StorageMigrationStatus{LastKey: "b"}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorMigrateCommand(t)
+		code := cmd.Run([]string{"-config", configPath, "-reset"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		status, err := CheckStorageMigration(context.Background(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != nil {
+			t.Errorf("expected migration lock to be removed: %#v", status)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorMigrateCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestParseMigratorConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := parseMigratorConfig(`
+storage_source "File" {
+  path = "/src"
+}
+
+storage_destination "consul" {
+  path    = "vault/"
+  address = "127.0.0.1:8500"
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.StorageSource.Type != "file" || config.StorageSource.Config["path"] != "/src" {
+		t.Errorf("bad source: %#v", config.StorageSource)
+	}
+	if config.StorageDestination.Type != "consul" || config.StorageDestination.Config["address"] != "127.0.0.1:8500" {
+		t.Errorf("bad destination: %#v", config.StorageDestination)
+	}
+
+	for _, bad := range []string{
+		`storage_source "file" { path = "/src" }`,
+		`storage_destination "file" { path = "/dst" }`,
+		`storage_source "file" { path = "/a" }
+storage_source "file" { path = "/b" }
+storage_destination "file" { path = "/dst" }`,
+	} {
+		if _, err := parseMigratorConfig(bad); err == nil {
+			t.Errorf("expected error parsing %q", bad)
+		}
+	}
+}
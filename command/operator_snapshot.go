@@ -0,0 +1,45 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*OperatorSnapshotCommand)(nil)
+
+type OperatorSnapshotCommand struct {
+	*BaseCommand
+}
+
+func (c *OperatorSnapshotCommand) Synopsis() string {
+	return "Saves and restores snapshots of Vault's storage"
+}
+
+func (c *OperatorSnapshotCommand) Help() string {
+	helpText := `
+Usage: vault operator snapshot <subcommand> [options] [args]
+
+  This command groups subcommands for saving and restoring point-in-time
+  snapshots of the data in Vault's storage backend. Snapshots contain data as
+  it is stored, so they remain encrypted by Vault's barrier and can only be
+  used with the unseal keys of the cluster they were taken from. A snapshot
+  taken from one storage backend can be restored to any other.
+
+  Save a snapshot:
+
+      $ vault operator snapshot save backup.snap
+
+  Restore a snapshot:
+
+      $ vault operator snapshot restore backup.snap
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSnapshotCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
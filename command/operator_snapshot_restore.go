@@ -0,0 +1,96 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*OperatorSnapshotRestoreCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorSnapshotRestoreCommand)(nil)
+
+type OperatorSnapshotRestoreCommand struct {
+	*BaseCommand
+}
+
+func (c *OperatorSnapshotRestoreCommand) Synopsis() string {
+	return "Restores a snapshot of Vault's storage"
+}
+
+func (c *OperatorSnapshotRestoreCommand) Help() string {
+	helpText := `
+Usage: vault operator snapshot restore [options] PATH
+
+  Replaces all of the data in Vault's storage backend with the snapshot in the
+  file at PATH. The snapshot is verified before anything is written and is
+  applied in a single transaction. Vault seals itself once the snapshot is
+  restored and must then be unsealed with the unseal keys of the cluster the
+  snapshot was taken from. This requires a token with root policy or sudo
+  capability on "sys/storage/snapshot".
+
+  Restore the snapshot in backup.snap:
+
+      $ vault operator snapshot restore backup.snap
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSnapshotRestoreCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetHTTP)
+}
+
+func (c *OperatorSnapshotRestoreCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *OperatorSnapshotRestoreCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorSnapshotRestoreCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	path := strings.TrimSpace(args[0])
+
+	snapFile, err := os.Open(path)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error opening snapshot file: %s", err))
+		return 1
+	}
+	defer snapFile.Close()
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	if err := client.Sys().StorageSnapshotRestore(snapFile); err != nil {
+		c.UI.Error(fmt.Sprintf("Error restoring snapshot: %s", err))
+		return 2
+	}
+
+	c.UI.Output("Success! Restored snapshot. Vault is now sealed and must be " +
+		"unsealed with the unseal keys of the cluster the snapshot was taken from.")
+	return 0
+}
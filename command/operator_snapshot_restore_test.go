@@ -0,0 +1,184 @@
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorSnapshotRestoreCommand(tb testing.TB) (*cli.MockUi, *OperatorSnapshotRestoreCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorSnapshotRestoreCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorSnapshotRestoreCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			nil,
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"missing_file",
+			[]string{"/not/a/real/snapshot"},
+			"Error opening snapshot file",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testOperatorSnapshotRestoreCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, keys, closer := testVaultServerUnseal(t)
+		defer closer()
+
+		if _, err := client.Logical().Write("secret/foo", map[string]interface{}{
+			"value": "bar",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		var snap bytes.Buffer
+		if err := client.Sys().StorageSnapshot(&snap); err != nil {
+			t.Fatal(err)
+		}
+
+		dir, err := ioutil.TempDir("", "vault-snapshot")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "backup.snap")
+		if err := ioutil.WriteFile(path, snap.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Logical().Delete("secret/foo"); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorSnapshotRestoreCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{path})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "Success! Restored snapshot"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		status, err := client.Sys().SealStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.Sealed {
+			t.Fatal("expected vault to be sealed")
+		}
+
+		for _, key := range keys {
+			if _, err := client.Sys().Unseal(key); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		secret, err := client.Logical().Read("secret/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil || secret.Data["value"] != "bar" {
+			t.Errorf("expected restored secret, got %#v", secret)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		f, err := ioutil.TempFile("", "vault-snapshot")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		defer os.Remove(f.Name())
+
+		ui, cmd := testOperatorSnapshotRestoreCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{f.Name()})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error restoring snapshot: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorSnapshotRestoreCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
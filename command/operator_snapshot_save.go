@@ -0,0 +1,108 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*OperatorSnapshotSaveCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorSnapshotSaveCommand)(nil)
+
+type OperatorSnapshotSaveCommand struct {
+	*BaseCommand
+}
+
+func (c *OperatorSnapshotSaveCommand) Synopsis() string {
+	return "Saves a snapshot of Vault's storage"
+}
+
+func (c *OperatorSnapshotSaveCommand) Help() string {
+	helpText := `
+Usage: vault operator snapshot save [options] PATH
+
+  Saves a point-in-time snapshot of the data in Vault's storage backend to the
+  file at PATH. Writes to storage are blocked while the snapshot is taken.
+  This requires a token with root policy or sudo capability on
+  "sys/storage/snapshot".
+
+  Save a snapshot to backup.snap:
+
+      $ vault operator snapshot save backup.snap
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSnapshotSaveCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetHTTP)
+}
+
+func (c *OperatorSnapshotSaveCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *OperatorSnapshotSaveCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorSnapshotSaveCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	path := strings.TrimSpace(args[0])
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	// Write to a temporary file first so a failed snapshot never replaces
+	// an existing good one
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating snapshot file: %s", err))
+		return 2
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := client.Sys().StorageSnapshot(tmp); err != nil {
+		tmp.Close()
+		c.UI.Error(fmt.Sprintf("Error saving snapshot: %s", err))
+		return 2
+	}
+
+	if err := tmp.Close(); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing snapshot file: %s", err))
+		return 2
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing snapshot file: %s", err))
+		return 2
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Saved snapshot to: %s", path))
+	return 0
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/gated-writer"
 	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/mlock"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/reload"
@@ -46,6 +47,15 @@ import (
 	"github.com/hashicorp/vault/version"
 )
 
+const (
+	// storageMigrationCheckAttempts is the number of times the migration lock
+	// is read before the server starts without it, and
+	// storageMigrationCheckBackoff the wait before the first retry, doubled on
+	// each following one
+	storageMigrationCheckAttempts = 4
+	storageMigrationCheckBackoff  = 250 * time.Millisecond
+)
+
 var _ cli.Command = (*ServerCommand)(nil)
 var _ cli.CommandAutocomplete = (*ServerCommand)(nil)
 
@@ -396,7 +406,8 @@ func (c *ServerCommand) Run(args []string) int {
 				"in a Docker container, provide the IPC_LOCK cap to the container."))
 	}
 
-	if err := c.setupTelemetry(config); err != nil {
+	metricsHelper, err := c.setupTelemetry(config)
+	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error initializing telemetry: %s", err))
 		return 1
 	}
@@ -413,6 +424,11 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Prevent the server from using storage that is being migrated
+	if !c.flagTestVerifyOnly && c.storageMigrationActive(backend) {
+		return 1
+	}
+
 	infoKeys := make([]string, 0, 10)
 	info := make(map[string]string)
 	info["log level"] = c.flagLogLevel
@@ -453,6 +469,7 @@ func (c *ServerCommand) Run(args []string) int {
 		PluginDirectory:    config.PluginDirectory,
 		EnableUI:           config.EnableUI,
 		EnableRaw:          config.EnableRawEndpoint,
+		MetricsHelper:      metricsHelper,
 	}
 	if c.flagDev {
 		coreConfig.DevToken = c.flagDevRootTokenID
@@ -671,7 +688,7 @@ CLUSTER_SYNTHESIS_COMPLETE:
 
 	// Initialize the listeners
 	c.reloadFuncsLock.Lock()
-	lns := make([]net.Listener, 0, len(config.Listeners))
+	lns := make([]serverListener, 0, len(config.Listeners))
 	for i, lnConfig := range config.Listeners {
 		ln, props, reloadFunc, err := server.NewListener(lnConfig.Type, lnConfig.Config, c.logGate, c.UI)
 		if err != nil {
@@ -679,7 +696,17 @@ CLUSTER_SYNTHESIS_COMPLETE:
 			return 1
 		}
 
-		lns = append(lns, ln)
+		forwardedFor, err := server.ParseForwardedForConfig(lnConfig.Config)
+		if err != nil {
+			ln.Close()
+			c.UI.Error(fmt.Sprintf("Error initializing listener of type %s: %s", lnConfig.Type, err))
+			return 1
+		}
+
+		lns = append(lns, serverListener{
+			Listener:     ln,
+			forwardedFor: forwardedFor,
+		})
 
 		if reloadFunc != nil {
 			relSlice := (*c.reloadFuncs)["listener|"+lnConfig.Type]
@@ -914,8 +941,12 @@ CLUSTER_SYNTHESIS_COMPLETE:
 
 	// Initialize the HTTP servers
 	for _, ln := range lns {
+		lnHandler := handler
+		if ff := ln.forwardedFor; ff != nil {
+			lnHandler = vaulthttp.WrapForwardedForHandler(handler, ff.AuthorizedAddrs, ff.RejectNotPresent, ff.RejectNotAuthorized, ff.HopSkips)
+		}
 		server := &http.Server{
-			Handler: handler,
+			Handler: lnHandler,
 		}
 		go server.Serve(ln)
 	}
@@ -984,6 +1015,43 @@ CLUSTER_SYNTHESIS_COMPLETE:
 	return 0
 }
 
+// storageMigrationActive reports whether the server must not start because
+// backend is the source of a storage migration in progress, or because it was
+// shut down while checking. Errors reading the migration lock are retried with
+// a backoff, and the server starts without the check once the attempts run
+// out, since storage that can't be read can't be unsealed from either.
+func (c *ServerCommand) storageMigrationActive(backend physical.Backend) bool {
+	backoff := storageMigrationCheckBackoff
+	for attempt := 1; ; attempt++ {
+		status, err := CheckStorageMigration(context.Background(), backend)
+		if err == nil {
+			if status == nil {
+				return false
+			}
+			c.UI.Error(fmt.Sprintf("Storage migration in progress (started: %s). "+
+				"Vault cannot start until it completes or the migration lock is "+
+				"removed with \"vault operator migrate -reset\".",
+				status.Start.Format(time.RFC3339)))
+			return true
+		}
+
+		if attempt == storageMigrationCheckAttempts {
+			c.UI.Warn(fmt.Sprintf("Unable to check for a storage migration, "+
+				"starting anyway: %s", err))
+			return false
+		}
+		c.UI.Warn(fmt.Sprintf("Error checking for a storage migration, "+
+			"retrying in %s: %s", backoff, err))
+
+		select {
+		case <-time.After(backoff):
+		case <-c.ShutdownCh:
+			return true
+		}
+		backoff *= 2
+	}
+}
+
 func (c *ServerCommand) enableDev(core *vault.Core, coreConfig *vault.CoreConfig) (*vault.InitResult, error) {
 	var recoveryConfig *vault.SealConfig
 	barrierConfig := &vault.SealConfig{
@@ -1398,8 +1466,9 @@ func (c *ServerCommand) detectRedirect(detect physical.RedirectDetect,
 	return url.String(), nil
 }
 
-// setupTelemetry is used to setup the telemetry sub-systems
-func (c *ServerCommand) setupTelemetry(config *server.Config) error {
+// setupTelemetry is used to setup the telemetry sub-systems and returns the
+// helper that exposes the collected metrics
+func (c *ServerCommand) setupTelemetry(config *server.Config) (*metricsutil.MetricsHelper, error) {
 	/* Setup telemetry
 	Aggregate on 10 second intervals for 1 minute. Expose the
 	metrics over stderr when there is a SIGUSR1 received.
@@ -1409,7 +1478,9 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 	var telConfig *server.Telemetry
 	if config.Telemetry == nil {
-		telConfig = &server.Telemetry{}
+		telConfig = &server.Telemetry{
+			PrometheusRetentionTime: server.PrometheusDefaultRetentionTime,
+		}
 	} else {
 		telConfig = config.Telemetry
 	}
@@ -1417,12 +1488,20 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 	metricsConf := metrics.DefaultConfig("vault")
 	metricsConf.EnableHostname = !telConfig.DisableHostname
 
-	// Configure the statsite sink
 	var fanout metrics.FanoutSink
+
+	// Configure the prometheus sink
+	var prometheusSink *metricsutil.PrometheusSink
+	if telConfig.PrometheusRetentionTime != 0 {
+		prometheusSink = metricsutil.NewPrometheusSink(telConfig.PrometheusRetentionTime)
+		fanout = append(fanout, prometheusSink)
+	}
+
+	// Configure the statsite sink
 	if telConfig.StatsiteAddr != "" {
 		sink, err := metrics.NewStatsiteSink(telConfig.StatsiteAddr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fanout = append(fanout, sink)
 	}
@@ -1431,7 +1510,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 	if telConfig.StatsdAddr != "" {
 		sink, err := metrics.NewStatsdSink(telConfig.StatsdAddr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fanout = append(fanout, sink)
 	}
@@ -1467,7 +1546,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 		sink, err := circonus.NewCirconusSink(cfg)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		sink.Start()
 		fanout = append(fanout, sink)
@@ -1482,7 +1561,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 		sink, err := datadog.NewDogStatsdSink(telConfig.DogStatsDAddr, metricsConf.HostName)
 		if err != nil {
-			return errwrap.Wrapf("failed to start DogStatsD sink: {{err}}", err)
+			return nil, errwrap.Wrapf("failed to start DogStatsD sink: {{err}}", err)
 		}
 		sink.SetTags(tags)
 		fanout = append(fanout, sink)
@@ -1496,7 +1575,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 		metricsConf.EnableHostname = false
 		metrics.NewGlobal(metricsConf, inm)
 	}
-	return nil
+	return metricsutil.NewMetricsHelper(inm, prometheusSink), nil
 }
 
 func (c *ServerCommand) Reload(lock *sync.RWMutex, reloadFuncs *map[string][]reload.ReloadFunc, configPath []string) error {
@@ -1568,6 +1647,13 @@ func (c *ServerCommand) removePidFile(pidPath string) error {
 	return os.Remove(pidPath)
 }
 
+// serverListener is a listener along with the X-Forwarded-For handling
+// configured on it
+type serverListener struct {
+	net.Listener
+	forwardedFor *server.ForwardedForConfig
+}
+
 type grpclogFaker struct {
 	logger log.Logger
 	log    bool
@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"net"
 
+	sockaddr "github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/proxyutil"
 	"github.com/hashicorp/vault/helper/reload"
@@ -69,6 +70,62 @@ func listenerWrapProxy(ln net.Listener, config map[string]interface{}) (net.List
 	return newLn, nil
 }
 
+// ForwardedForConfig is the X-Forwarded-For handling configured on a
+// listener
+type ForwardedForConfig struct {
+	AuthorizedAddrs     []*sockaddr.SockAddrMarshaler
+	HopSkips            int
+	RejectNotAuthorized bool
+	RejectNotPresent    bool
+}
+
+// ParseForwardedForConfig returns the X-Forwarded-For handling configured on
+// a listener, or nil if it is not enabled.
+func ParseForwardedForConfig(config map[string]interface{}) (*ForwardedForConfig, error) {
+	authorizedAddrsRaw, ok := config["x_forwarded_for_authorized_addrs"]
+	if !ok {
+		return nil, nil
+	}
+
+	authorizedAddrs, err := parseutil.ParseAddrs(authorizedAddrsRaw)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed parsing x_forwarded_for_authorized_addrs: {{err}}", err)
+	}
+
+	result := &ForwardedForConfig{
+		AuthorizedAddrs:     authorizedAddrs,
+		RejectNotAuthorized: true,
+		RejectNotPresent:    true,
+	}
+
+	if v, ok := config["x_forwarded_for_hop_skips"]; ok {
+		hopSkips, err := parseutil.ParseInt(v)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing x_forwarded_for_hop_skips: {{err}}", err)
+		}
+		if hopSkips < 0 {
+			return nil, fmt.Errorf("x_forwarded_for_hop_skips cannot be negative, got %d", hopSkips)
+		}
+		result.HopSkips = int(hopSkips)
+	}
+
+	if v, ok := config["x_forwarded_for_reject_not_authorized"]; ok {
+		result.RejectNotAuthorized, err = parseutil.ParseBool(v)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing x_forwarded_for_reject_not_authorized: {{err}}", err)
+		}
+	}
+
+	if v, ok := config["x_forwarded_for_reject_not_present"]; ok {
+		result.RejectNotPresent, err = parseutil.ParseBool(v)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing x_forwarded_for_reject_not_present: {{err}}", err)
+		}
+	}
+
+	return result, nil
+}
+
 func listenerWrapTLS(
 	ln net.Listener,
 	props map[string]string,
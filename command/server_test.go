@@ -4,6 +4,7 @@
 package command
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -266,3 +267,50 @@ func TestServer(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_StorageMigration(t *testing.T) {
+	t.Parallel()
+
+	td, err := ioutil.TempDir("", "vault-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	backend, err := physFile.NewFileBackend(map[string]string{"path": td}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetStorageMigration(context.Background(), backend, &StorageMigrationStatus{Start: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	f.WriteString(fmt.Sprintf(`
+disable_mlock = true
+
+storage "file" {
+  path = "%s"
+}
+
+listener "tcp" {
+  address     = "127.0.0.1:%d"
+  tls_disable = "true"
+}
+`, td, testRandomPort(t)))
+	f.Close()
+	defer os.Remove(f.Name())
+
+	ui, cmd := testServerCommand(t)
+	code := cmd.Run([]string{"-config", f.Name()})
+	output := ui.ErrorWriter.String() + ui.OutputWriter.String()
+	if code != 1 {
+		t.Fatalf("expected 1 to be %d: %s", code, output)
+	}
+	if exp := "Storage migration in progress"; !strings.Contains(output, exp) {
+		t.Fatalf("expected %q to contain %q", output, exp)
+	}
+}
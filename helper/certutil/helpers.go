@@ -10,6 +10,7 @@ import (
 	"crypto/sha1"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"math/big"
 	"strconv"
@@ -273,3 +274,35 @@ func ComparePublicKeys(key1Iface, key2Iface crypto.PublicKey) (bool, error) {
 		return false, fmt.Errorf("cannot compare key with type %T", key1Iface)
 	}
 }
+
+// ParsePublicKeyPEM parses a PEM-encoded public key, which may be given either
+// as a PKIX public key or as a certificate
+func ParsePublicKeyPEM(data []byte) (interface{}, error) {
+	block, data := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("data does not contain any valid public keys")
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		return nil, errors.New("data contains more than one PEM block")
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			return key, nil
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+	}
+
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		switch cert.PublicKey.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			return cert.PublicKey, nil
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", cert.PublicKey)
+		}
+	}
+
+	return nil, errors.New("data does not contain any valid RSA or ECDSA public keys")
+}
@@ -6,4 +6,8 @@ const (
 	ExpirationRestoreWorkerCount = 64
 
 	VaultKVCLIClientHeader = "X-Vault-Kv-Client"
+
+	// NamespaceHeaderName is the header used to select the namespace of a
+	// request
+	NamespaceHeaderName = "X-Vault-Namespace"
 )
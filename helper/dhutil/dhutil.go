@@ -0,0 +1,101 @@
+// Package dhutil implements the Curve25519 Diffie-Hellman exchange used to
+// encrypt tokens written by Vault Agent sinks so that only the holder of the
+// matching private key can read them.
+package dhutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// PublicKeyInfo is the format of the file a consumer writes to let Vault
+// Agent know which public key to encrypt tokens for
+type PublicKeyInfo struct {
+	Curve25519PublicKey []byte `json:"curve25519_public_key"`
+}
+
+// Envelope is the format of an encrypted token written by Vault Agent
+type Envelope struct {
+	Curve25519PublicKey []byte `json:"curve25519_public_key"`
+	Nonce               []byte `json:"nonce"`
+	EncryptedPayload    []byte `json:"encrypted_payload"`
+}
+
+// GeneratePublicPrivateKey generates a new Curve25519 key pair
+func GeneratePublicPrivateKey() ([]byte, []byte, error) {
+	var scalar, public [32]byte
+
+	if _, err := io.ReadFull(rand.Reader, scalar[:]); err != nil {
+		return nil, nil, err
+	}
+
+	curve25519.ScalarBaseMult(&public, &scalar)
+	return public[:], scalar[:], nil
+}
+
+// GenerateSharedKey derives the key shared between the holder of ourPrivate
+// and the holder of the private key matching theirPublic
+func GenerateSharedKey(ourPrivate, theirPublic []byte) ([]byte, error) {
+	if len(ourPrivate) != 32 {
+		return nil, fmt.Errorf("invalid private key length: %d", len(ourPrivate))
+	}
+	if len(theirPublic) != 32 {
+		return nil, fmt.Errorf("invalid public key length: %d", len(theirPublic))
+	}
+
+	var scalar, pub, shared [32]byte
+	copy(scalar[:], ourPrivate)
+	copy(pub[:], theirPublic)
+
+	curve25519.ScalarMult(&shared, &scalar, &pub)
+
+	// Hash the result rather than using the curve point directly
+	key := sha256.Sum256(shared[:])
+	return key[:], nil
+}
+
+// EncryptAES encrypts plaintext with AES-GCM using the given key and
+// additional authenticated data. It returns the ciphertext and the nonce.
+func EncryptAES(key, plaintext, aad []byte) ([]byte, []byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+
+	return aead.Seal(nil, nonce, plaintext, aad), nonce, nil
+}
+
+// DecryptAES decrypts ciphertext produced by EncryptAES
+func DecryptAES(key, ciphertext, nonce, aad []byte) ([]byte, error) {
+	if len(nonce) == 0 {
+		return nil, errors.New("empty nonce")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/logical"
 )
@@ -48,6 +49,40 @@ type PolicyRequest struct {
 
 	// Whether to allow plaintext backup
 	AllowPlaintextBackup bool
+
+	// Whether to allow rotation of an imported key
+	AllowImportedKeyRotation bool
+}
+
+// checkPolicyRequest returns an error if the options of the request are not
+// supported by its key type
+func checkPolicyRequest(req PolicyRequest) error {
+	switch req.KeyType {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		if req.Convergent && !req.Derived {
+			return fmt.Errorf("convergent encryption requires derivation to be enabled")
+		}
+
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		if req.Derived || req.Convergent {
+			return fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_ED25519:
+		if req.Convergent {
+			return fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+		if req.Derived || req.Convergent {
+			return fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	default:
+		return fmt.Errorf("unsupported key type %v", req.KeyType)
+	}
+
+	return nil
 }
 
 type LockManager struct {
@@ -293,6 +328,61 @@ func (lm *LockManager) RestorePolicy(ctx context.Context, storage logical.Storag
 	return nil
 }
 
+// ImportPolicy acquires an exclusive lock on the policy name and creates the
+// policy with the given key material as its first version.
+func (lm *LockManager) ImportPolicy(ctx context.Context, req PolicyRequest, key []byte) error {
+	if err := checkPolicyRequest(req); err != nil {
+		return errutil.UserError{Err: err.Error()}
+	}
+
+	lockType := exclusive
+	lock := lm.policyLock(req.Name, lockType)
+	defer lm.UnlockPolicy(lock, lockType)
+
+	// If the policy is in cache, error out
+	if lm.CacheActive() {
+		lm.cacheMutex.RLock()
+		p := lm.cache[req.Name]
+		lm.cacheMutex.RUnlock()
+		if p != nil {
+			return errutil.UserError{Err: fmt.Sprintf("policy %q already exists", req.Name)}
+		}
+	}
+
+	// If the policy exists in storage, error out
+	p, err := lm.getStoredPolicy(ctx, req.Storage, req.Name)
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		return errutil.UserError{Err: fmt.Sprintf("policy %q already exists", req.Name)}
+	}
+
+	p = &Policy{
+		Name:                     req.Name,
+		Type:                     req.KeyType,
+		Derived:                  req.Derived,
+		Exportable:               req.Exportable,
+		AllowPlaintextBackup:     req.AllowPlaintextBackup,
+		Imported:                 true,
+		AllowImportedKeyRotation: req.AllowImportedKeyRotation,
+		versionPrefixCache:       &sync.Map{},
+	}
+	if req.Derived {
+		p.KDF = Kdf_hkdf_sha256
+		p.ConvergentEncryption = req.Convergent
+		p.ConvergentVersion = 2
+	}
+
+	if err := p.Import(ctx, req.Storage, key); err != nil {
+		return err
+	}
+
+	lm.UpdateCache(req.Name, p)
+
+	return nil
+}
+
 func (lm *LockManager) BackupPolicy(ctx context.Context, storage logical.Storage, name string) (string, error) {
 	p, lock, err := lm.GetPolicyExclusive(ctx, storage, name)
 	if lock != nil {
@@ -355,34 +445,9 @@ func (lm *LockManager) getPolicyCommon(ctx context.Context, req PolicyRequest, l
 			return nil, nil, false, errNeedExclusiveLock
 		}
 
-		switch req.KeyType {
-		case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
-			if req.Convergent && !req.Derived {
-				lm.UnlockPolicy(lock, lockType)
-				return nil, nil, false, fmt.Errorf("convergent encryption requires derivation to be enabled")
-			}
-
-		case KeyType_ECDSA_P256:
-			if req.Derived || req.Convergent {
-				lm.UnlockPolicy(lock, lockType)
-				return nil, nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		case KeyType_ED25519:
-			if req.Convergent {
-				lm.UnlockPolicy(lock, lockType)
-				return nil, nil, false, fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		case KeyType_RSA2048, KeyType_RSA4096:
-			if req.Derived || req.Convergent {
-				lm.UnlockPolicy(lock, lockType)
-				return nil, nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		default:
+		if err := checkPolicyRequest(req); err != nil {
 			lm.UnlockPolicy(lock, lockType)
-			return nil, nil, false, fmt.Errorf("unsupported key type %v", req.KeyType)
+			return nil, nil, false, err
 		}
 
 		p = &Policy{
@@ -13,6 +13,7 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/base64"
 	"encoding/json"
@@ -31,6 +32,7 @@ import (
 	"golang.org/x/crypto/hkdf"
 
 	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/jsonutil"
@@ -53,6 +55,10 @@ const (
 	KeyType_RSA2048
 	KeyType_RSA4096
 	KeyType_ChaCha20_Poly1305
+	KeyType_ECDSA_P384
+	KeyType_ECDSA_P521
+	KeyType_AES128_GCM96
+	KeyType_RSA3072
 )
 
 const (
@@ -87,7 +93,7 @@ type KeyType int
 
 func (kt KeyType) EncryptionSupported() bool {
 	switch kt {
-	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA4096:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		return true
 	}
 	return false
@@ -95,7 +101,7 @@ func (kt KeyType) EncryptionSupported() bool {
 
 func (kt KeyType) DecryptionSupported() bool {
 	switch kt {
-	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA4096:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		return true
 	}
 	return false
@@ -103,7 +109,7 @@ func (kt KeyType) DecryptionSupported() bool {
 
 func (kt KeyType) SigningSupported() bool {
 	switch kt {
-	case KeyType_ECDSA_P256, KeyType_ED25519, KeyType_RSA2048, KeyType_RSA4096:
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521, KeyType_ED25519, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		return true
 	}
 	return false
@@ -111,7 +117,7 @@ func (kt KeyType) SigningSupported() bool {
 
 func (kt KeyType) HashSignatureInput() bool {
 	switch kt {
-	case KeyType_ECDSA_P256, KeyType_RSA2048, KeyType_RSA4096:
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		return true
 	}
 	return false
@@ -119,7 +125,7 @@ func (kt KeyType) HashSignatureInput() bool {
 
 func (kt KeyType) DerivationSupported() bool {
 	switch kt {
-	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_ED25519:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_ED25519:
 		return true
 	}
 	return false
@@ -127,16 +133,24 @@ func (kt KeyType) DerivationSupported() bool {
 
 func (kt KeyType) String() string {
 	switch kt {
+	case KeyType_AES128_GCM96:
+		return "aes128-gcm96"
 	case KeyType_AES256_GCM96:
 		return "aes256-gcm96"
 	case KeyType_ChaCha20_Poly1305:
 		return "chacha20-poly1305"
 	case KeyType_ECDSA_P256:
 		return "ecdsa-p256"
+	case KeyType_ECDSA_P384:
+		return "ecdsa-p384"
+	case KeyType_ECDSA_P521:
+		return "ecdsa-p521"
 	case KeyType_ED25519:
 		return "ed25519"
 	case KeyType_RSA2048:
 		return "rsa-2048"
+	case KeyType_RSA3072:
+		return "rsa-3072"
 	case KeyType_RSA4096:
 		return "rsa-4096"
 	}
@@ -144,6 +158,39 @@ func (kt KeyType) String() string {
 	return "[unknown]"
 }
 
+// symmetricKeySize returns the size in bytes of the keys of symmetric key
+// types
+func (kt KeyType) symmetricKeySize() int {
+	if kt == KeyType_AES128_GCM96 {
+		return 16
+	}
+	return 32
+}
+
+// ecdsaCurve returns the curve of ECDSA key types
+func (kt KeyType) ecdsaCurve() elliptic.Curve {
+	switch kt {
+	case KeyType_ECDSA_P384:
+		return elliptic.P384()
+	case KeyType_ECDSA_P521:
+		return elliptic.P521()
+	default:
+		return elliptic.P256()
+	}
+}
+
+// rsaBitSize returns the size in bits of the keys of RSA key types
+func (kt KeyType) rsaBitSize() int {
+	switch kt {
+	case KeyType_RSA3072:
+		return 3072
+	case KeyType_RSA4096:
+		return 4096
+	default:
+		return 2048
+	}
+}
+
 type KeyData struct {
 	Policy       *Policy       `json:"policy"`
 	ArchivedKeys *archivedKeys `json:"archived_keys"`
@@ -172,6 +219,10 @@ type KeyEntry struct {
 	// This is deprecated (but still filled) in favor of the value above which
 	// is more precise
 	DeprecatedCreationTime int64 `json:"creation_time"`
+
+	// Whether the version was created by an automatic rotation rather than
+	// by a request
+	AutoRotated bool `json:"auto_rotated"`
 }
 
 // deprecatedKeyEntryMap is used to allow JSON marshal/unmarshal
@@ -307,6 +358,10 @@ type Policy struct {
 	// The minimum version of the key allowed to be used for encryption
 	MinEncryptionVersion int `json:"min_encryption_version"`
 
+	// The minimum version of the key that is still stored. Older versions
+	// were trimmed and are gone for good. If zero, no version was trimmed.
+	MinAvailableVersion int `json:"min_available_version"`
+
 	// The latest key version in this policy
 	LatestVersion int `json:"latest_version"`
 
@@ -343,6 +398,17 @@ type Policy struct {
 	// policy object.
 	StoragePrefix string `json:"storage_prefix"`
 
+	// Imported indicates the key material was imported rather than generated
+	Imported bool `json:"imported"`
+
+	// AllowImportedKeyRotation allows rotating an imported key, which
+	// generates the new versions
+	AllowImportedKeyRotation bool `json:"allow_imported_key_rotation"`
+
+	// AutoRotatePeriod is the period after which the key is rotated again. If
+	// zero, the key is not rotated automatically.
+	AutoRotatePeriod time.Duration `json:"auto_rotate_period"`
+
 	// versionPrefixCache stores caches of verison prefix strings and the split
 	// version template.
 	versionPrefixCache *sync.Map
@@ -422,6 +488,9 @@ func (p *Policy) handleArchiving(ctx context.Context, storage logical.Storage) e
 	case p.MinDecryptionVersion > p.LatestVersion:
 		return fmt.Errorf("minimum decryption version of %d is greater than the latest version %d",
 			p.MinDecryptionVersion, p.LatestVersion)
+	case p.MinAvailableVersion > p.MinDecryptionVersion:
+		return fmt.Errorf("minimum available version of %d is greater than the minimum decryption version %d",
+			p.MinAvailableVersion, p.MinDecryptionVersion)
 	}
 
 	archive, err := p.LoadArchive(ctx, storage)
@@ -429,10 +498,12 @@ func (p *Policy) handleArchiving(ctx context.Context, storage logical.Storage) e
 		return err
 	}
 
+	// The archive is indexed by key version, offset by the versions that
+	// were trimmed from it
 	if !keysContainsMinimum {
 		// Need to move keys *from* archive
 		for i := p.MinDecryptionVersion; i <= p.LatestVersion; i++ {
-			p.Keys[strconv.Itoa(i)] = archive.Keys[i]
+			p.Keys[strconv.Itoa(i)] = archive.Keys[i-p.MinAvailableVersion]
 		}
 
 		return nil
@@ -443,9 +514,9 @@ func (p *Policy) handleArchiving(ctx context.Context, storage logical.Storage) e
 	// We need a size that is equivalent to the latest version (number of keys)
 	// but adding one since slice numbering starts at 0 and we're indexing by
 	// key version
-	if len(archive.Keys) < p.LatestVersion+1 {
+	if len(archive.Keys)+p.MinAvailableVersion < p.LatestVersion+1 {
 		// Increase the size of the archive slice
-		newKeys := make([]KeyEntry, p.LatestVersion+1)
+		newKeys := make([]KeyEntry, p.LatestVersion-p.MinAvailableVersion+1)
 		copy(newKeys, archive.Keys)
 		archive.Keys = newKeys
 	}
@@ -453,7 +524,7 @@ func (p *Policy) handleArchiving(ctx context.Context, storage logical.Storage) e
 	// We are storing all keys in the archive, so we ensure that it is up to
 	// date up to p.LatestVersion
 	for i := p.ArchiveVersion + 1; i <= p.LatestVersion; i++ {
-		archive.Keys[i] = p.Keys[strconv.Itoa(i)]
+		archive.Keys[i-p.MinAvailableVersion] = p.Keys[strconv.Itoa(i)]
 		p.ArchiveVersion = i
 	}
 
@@ -666,22 +737,28 @@ func (p *Policy) DeriveKey(context []byte, ver int) ([]byte, error) {
 		return kdf.CounterMode(prf, prfLen, p.Keys[strconv.Itoa(ver)].Key, context, 256)
 
 	case Kdf_hkdf_sha256:
+		// Ed25519 keys are generated from a 32 byte seed
+		keySize := 32
+		if p.Type == KeyType_AES128_GCM96 {
+			keySize = 16
+		}
+
 		reader := hkdf.New(sha256.New, p.Keys[strconv.Itoa(ver)].Key, nil, context)
 		derBytes := bytes.NewBuffer(nil)
-		derBytes.Grow(32)
+		derBytes.Grow(keySize)
 		limReader := &io.LimitedReader{
 			R: reader,
-			N: 32,
+			N: int64(keySize),
 		}
 
 		switch p.Type {
-		case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
 			n, err := derBytes.ReadFrom(limReader)
 			if err != nil {
 				return nil, errutil.InternalError{Err: fmt.Sprintf("error reading returned derived bytes: %v", err)}
 			}
-			if n != 32 {
-				return nil, errutil.InternalError{Err: fmt.Sprintf("unable to read enough derived bytes, needed 32, got %d", n)}
+			if n != int64(keySize) {
+				return nil, errutil.InternalError{Err: fmt.Sprintf("unable to read enough derived bytes, needed %d, got %d", keySize, n)}
 			}
 			return derBytes.Bytes(), nil
 
@@ -728,7 +805,7 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 	var ciphertext []byte
 
 	switch p.Type {
-	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
 		// Derive the key that should be used
 		key, err := p.DeriveKey(context, ver)
 		if err != nil {
@@ -738,7 +815,7 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 		var aead cipher.AEAD
 
 		switch p.Type {
-		case KeyType_AES256_GCM96:
+		case KeyType_AES128_GCM96, KeyType_AES256_GCM96:
 			// Setup the cipher
 			aesCipher, err := aes.NewCipher(key)
 			if err != nil {
@@ -790,7 +867,7 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 			ciphertext = append(nonce, ciphertext...)
 		}
 
-	case KeyType_RSA2048, KeyType_RSA4096:
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		key := p.Keys[strconv.Itoa(ver)].RSAKey
 		ciphertext, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, plaintext, nil)
 		if err != nil {
@@ -862,7 +939,7 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 	var plain []byte
 
 	switch p.Type {
-	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
 		key, err := p.DeriveKey(context, ver)
 		if err != nil {
 			return "", err
@@ -871,7 +948,7 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 		var aead cipher.AEAD
 
 		switch p.Type {
-		case KeyType_AES256_GCM96:
+		case KeyType_AES128_GCM96, KeyType_AES256_GCM96:
 			// Setup the cipher
 			aesCipher, err := aes.NewCipher(key)
 			if err != nil {
@@ -914,7 +991,7 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 			return "", errutil.UserError{Err: "invalid ciphertext: unable to decrypt"}
 		}
 
-	case KeyType_RSA2048, KeyType_RSA4096:
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		key := p.Keys[strconv.Itoa(ver)].RSAKey
 		plain, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, key, decoded, nil)
 		if err != nil {
@@ -963,11 +1040,11 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm, sigAlgorith
 	var pubKey []byte
 	var err error
 	switch p.Type {
-	case KeyType_ECDSA_P256:
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
 		keyParams := p.Keys[strconv.Itoa(ver)]
 		key := &ecdsa.PrivateKey{
 			PublicKey: ecdsa.PublicKey{
-				Curve: elliptic.P256(),
+				Curve: p.Type.ecdsaCurve(),
 				X:     keyParams.EC_X,
 				Y:     keyParams.EC_Y,
 			},
@@ -1008,7 +1085,7 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm, sigAlgorith
 			return nil, err
 		}
 
-	case KeyType_RSA2048, KeyType_RSA4096:
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		key := p.Keys[strconv.Itoa(ver)].RSAKey
 
 		var algo crypto.Hash
@@ -1097,7 +1174,7 @@ func (p *Policy) VerifySignature(context, input []byte, sig, hashAlgorithm strin
 	}
 
 	switch p.Type {
-	case KeyType_ECDSA_P256:
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
 		var ecdsaSig ecdsaSignature
 		rest, err := asn1.Unmarshal(sigBytes, &ecdsaSig)
 		if err != nil {
@@ -1109,7 +1186,7 @@ func (p *Policy) VerifySignature(context, input []byte, sig, hashAlgorithm strin
 
 		keyParams := p.Keys[strconv.Itoa(ver)]
 		key := &ecdsa.PublicKey{
-			Curve: elliptic.P256(),
+			Curve: p.Type.ecdsaCurve(),
 			X:     keyParams.EC_X,
 			Y:     keyParams.EC_Y,
 		}
@@ -1132,7 +1209,7 @@ func (p *Policy) VerifySignature(context, input []byte, sig, hashAlgorithm strin
 
 		return ed25519.Verify(key.Public().(ed25519.PublicKey), input, sigBytes), nil
 
-	case KeyType_RSA2048, KeyType_RSA4096:
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		key := p.Keys[strconv.Itoa(ver)].RSAKey
 
 		var algo crypto.Hash
@@ -1169,7 +1246,21 @@ func (p *Policy) VerifySignature(context, input []byte, sig, hashAlgorithm strin
 	}
 }
 
-func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr error) {
+func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) error {
+	return p.rotate(ctx, storage, false)
+}
+
+// AutoRotate rotates the policy in the same way as Rotate, marking the new
+// version as created by an automatic rotation
+func (p *Policy) AutoRotate(ctx context.Context, storage logical.Storage) error {
+	return p.rotate(ctx, storage, true)
+}
+
+func (p *Policy) rotate(ctx context.Context, storage logical.Storage, auto bool) (retErr error) {
+	if p.Imported && !p.AllowImportedKeyRotation {
+		return errutil.UserError{Err: fmt.Sprintf("imported key %q does not allow rotation", p.Name)}
+	}
+
 	priorLatestVersion := p.LatestVersion
 	priorMinDecryptionVersion := p.MinDecryptionVersion
 	var priorKeys keyEntryMap
@@ -1201,6 +1292,7 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 	entry := KeyEntry{
 		CreationTime:           now,
 		DeprecatedCreationTime: now.Unix(),
+		AutoRotated:            auto,
 	}
 
 	hmacKey, err := uuid.GenerateRandomBytes(32)
@@ -1210,16 +1302,16 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 	entry.HMACKey = hmacKey
 
 	switch p.Type {
-	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
-		// Generate a 256bit key
-		newKey, err := uuid.GenerateRandomBytes(32)
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		// Generate a 128 or 256bit key
+		newKey, err := uuid.GenerateRandomBytes(p.Type.symmetricKeySize())
 		if err != nil {
 			return err
 		}
 		entry.Key = newKey
 
-	case KeyType_ECDSA_P256:
-		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		privKey, err := ecdsa.GenerateKey(p.Type.ecdsaCurve(), rand.Reader)
 		if err != nil {
 			return err
 		}
@@ -1248,13 +1340,8 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 		entry.Key = pri
 		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(pub)
 
-	case KeyType_RSA2048, KeyType_RSA4096:
-		bitSize := 2048
-		if p.Type == KeyType_RSA4096 {
-			bitSize = 4096
-		}
-
-		entry.RSAKey, err = rsa.GenerateKey(rand.Reader, bitSize)
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+		entry.RSAKey, err = rsa.GenerateKey(rand.Reader, p.Type.rsaBitSize())
 		if err != nil {
 			return err
 		}
@@ -1272,6 +1359,246 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 	return p.Persist(ctx, storage)
 }
 
+// Import adds the given key material as the new latest version of the
+// policy. Symmetric keys are given as raw bytes and asymmetric keys as PKCS #8
+// DER-encoded private keys.
+func (p *Policy) Import(ctx context.Context, storage logical.Storage, key []byte) (retErr error) {
+	entry, err := p.importKeyEntry(key)
+	if err != nil {
+		return err
+	}
+
+	priorLatestVersion := p.LatestVersion
+	priorMinDecryptionVersion := p.MinDecryptionVersion
+	var priorKeys keyEntryMap
+
+	if p.Keys != nil {
+		priorKeys = keyEntryMap{}
+		for k, v := range p.Keys {
+			priorKeys[k] = v
+		}
+	}
+
+	defer func() {
+		if retErr != nil {
+			p.LatestVersion = priorLatestVersion
+			p.MinDecryptionVersion = priorMinDecryptionVersion
+			p.Keys = priorKeys
+		}
+	}()
+
+	if p.Keys == nil {
+		p.Keys = keyEntryMap{}
+	}
+
+	p.LatestVersion += 1
+	p.Keys[strconv.Itoa(p.LatestVersion)] = *entry
+
+	if p.MinDecryptionVersion == 0 {
+		p.MinDecryptionVersion = 1
+	}
+
+	return p.Persist(ctx, storage)
+}
+
+// importKeyEntry returns a key entry for the key material, after checking it
+// is a key of the policy's type
+func (p *Policy) importKeyEntry(key []byte) (*KeyEntry, error) {
+	now := time.Now()
+	entry := &KeyEntry{
+		CreationTime:           now,
+		DeprecatedCreationTime: now.Unix(),
+	}
+
+	hmacKey, err := uuid.GenerateRandomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	entry.HMACKey = hmacKey
+
+	switch p.Type {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		if len(key) != p.Type.symmetricKeySize() {
+			return nil, errutil.UserError{Err: fmt.Sprintf("invalid key size %d bytes for key type %v, expected %d", len(key), p.Type, p.Type.symmetricKeySize())}
+		}
+		entry.Key = key
+		return entry, nil
+	}
+
+	privKey, err := parsePKCS8PrivateKey(key)
+	if err != nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("error parsing private key: %v", err)}
+	}
+
+	switch p.Type {
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		ecKey, ok := privKey.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != p.Type.ecdsaCurve() {
+			return nil, errutil.UserError{Err: fmt.Sprintf("private key is not a key of type %v", p.Type)}
+		}
+		entry.EC_D = ecKey.D
+		entry.EC_X = ecKey.X
+		entry.EC_Y = ecKey.Y
+		derBytes, err := x509.MarshalPKIXPublicKey(ecKey.Public())
+		if err != nil {
+			return nil, errwrap.Wrapf("error marshaling public key: {{err}}", err)
+		}
+		pemBytes := pem.EncodeToMemory(&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: derBytes,
+		})
+		if len(pemBytes) == 0 {
+			return nil, fmt.Errorf("error PEM-encoding public key")
+		}
+		entry.FormattedPublicKey = string(pemBytes)
+
+	case KeyType_ED25519:
+		// Derived keys sign with keys derived from the stored one, which
+		// wouldn't match the public key of the imported key
+		if p.Derived {
+			return nil, errutil.UserError{Err: "imported ed25519 keys cannot be derived"}
+		}
+		edKey, ok := privKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errutil.UserError{Err: fmt.Sprintf("private key is not a key of type %v", p.Type)}
+		}
+		entry.Key = edKey
+		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey))
+
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+		rsaKey, ok := privKey.(*rsa.PrivateKey)
+		if !ok || rsaKey.N.BitLen() != p.Type.rsaBitSize() {
+			return nil, errutil.UserError{Err: fmt.Sprintf("private key is not a key of type %v", p.Type)}
+		}
+		entry.RSAKey = rsaKey
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %v", p.Type)
+	}
+
+	return entry, nil
+}
+
+// oidEd25519 is the algorithm identifier of Ed25519 keys from RFC 8410
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+// pkcs8 is the ASN.1 structure of a PKCS #8 private key
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// parsePKCS8PrivateKey parses a PKCS #8 private key, including Ed25519 keys,
+// which the x509 package does not return as golang.org/x/crypto/ed25519 keys
+func parsePKCS8PrivateKey(der []byte) (interface{}, error) {
+	var privKey pkcs8
+	if _, err := asn1.Unmarshal(der, &privKey); err != nil {
+		return nil, err
+	}
+	if !privKey.Algo.Algorithm.Equal(oidEd25519) {
+		return x509.ParsePKCS8PrivateKey(der)
+	}
+
+	// The private key is the 32 byte seed, itself wrapped in an octet string
+	var seed []byte
+	if _, err := asn1.Unmarshal(privKey.PrivateKey, &seed); err != nil {
+		return nil, errwrap.Wrapf("error parsing ed25519 private key: {{err}}", err)
+	}
+	if len(seed) != 32 {
+		return nil, fmt.Errorf("invalid ed25519 seed size %d bytes", len(seed))
+	}
+
+	// Key generation reads the seed from the reader
+	_, edKey, err := ed25519.GenerateKey(bytes.NewReader(seed))
+	if err != nil {
+		return nil, err
+	}
+	return edKey, nil
+}
+
+// Trim permanently deletes the key versions older than the given version from
+// the policy and its archive. It should be called with an exclusive lock held
+// on the policy.
+func (p *Policy) Trim(ctx context.Context, storage logical.Storage, minAvailableVersion int) (retErr error) {
+	switch {
+	case minAvailableVersion < p.MinAvailableVersion:
+		return errutil.UserError{Err: fmt.Sprintf("minimum available version cannot be lowered from %d, the older versions were already trimmed", p.MinAvailableVersion)}
+	case minAvailableVersion == p.MinAvailableVersion:
+		return nil
+	case minAvailableVersion > p.MinDecryptionVersion:
+		return errutil.UserError{Err: fmt.Sprintf("minimum available version cannot be greater than the minimum decryption version of %d", p.MinDecryptionVersion)}
+	case p.MinEncryptionVersion > 0 && minAvailableVersion > p.MinEncryptionVersion:
+		return errutil.UserError{Err: fmt.Sprintf("minimum available version cannot be greater than the minimum encryption version of %d", p.MinEncryptionVersion)}
+	}
+
+	// Make sure the archive is up to date before trimming it
+	if err := p.Persist(ctx, storage); err != nil {
+		return err
+	}
+
+	archive, err := p.LoadArchive(ctx, storage)
+	if err != nil {
+		return err
+	}
+
+	priorMinAvailableVersion := p.MinAvailableVersion
+	var priorKeys keyEntryMap
+
+	if p.Keys != nil {
+		priorKeys = keyEntryMap{}
+		for k, v := range p.Keys {
+			priorKeys[k] = v
+		}
+	}
+
+	// Store the trimmed archive first, as persisting the policy loads it
+	trimmedArchive := &archivedKeys{
+		Keys: archive.Keys[minAvailableVersion-priorMinAvailableVersion:],
+	}
+	if err := p.storeArchive(ctx, storage, trimmedArchive); err != nil {
+		return err
+	}
+
+	defer func() {
+		if retErr != nil {
+			p.MinAvailableVersion = priorMinAvailableVersion
+			p.Keys = priorKeys
+
+			// Put back the untrimmed archive the stored policy refers to
+			if err := p.storeArchive(ctx, storage, archive); err != nil {
+				retErr = multierror.Append(retErr, errwrap.Wrapf("failed to restore the archive: {{err}}", err))
+			}
+		}
+	}()
+
+	for i := p.MinAvailableVersion; i < minAvailableVersion; i++ {
+		delete(p.Keys, strconv.Itoa(i))
+	}
+	p.MinAvailableVersion = minAvailableVersion
+
+	return p.Persist(ctx, storage)
+}
+
+// NeedsAutoRotation returns whether the newest version of the key is older
+// than the auto rotate period of the policy
+func (p *Policy) NeedsAutoRotation() bool {
+	if p.AutoRotatePeriod <= 0 {
+		return false
+	}
+	if p.Imported && !p.AllowImportedKeyRotation {
+		return false
+	}
+
+	latest := p.Keys[strconv.Itoa(p.LatestVersion)]
+	creationTime := latest.CreationTime
+	if creationTime.IsZero() {
+		creationTime = time.Unix(latest.DeprecatedCreationTime, 0)
+	}
+
+	return time.Since(creationTime) >= p.AutoRotatePeriod
+}
+
 func (p *Policy) MigrateKeyToKeysMap() {
 	now := time.Now()
 	p.Keys = keyEntryMap{
@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -576,3 +577,46 @@ func Test_BadArchive(t *testing.T) {
 		t.Fatalf("unexpected key length %d", len(p.Keys))
 	}
 }
+
+func Test_AES128Derived(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+
+	p := &Policy{
+		Name:               "test",
+		Type:               KeyType_AES128_GCM96,
+		Derived:            true,
+		KDF:                Kdf_hkdf_sha256,
+		versionPrefixCache: &sync.Map{},
+	}
+	if err := p.Rotate(ctx, storage); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Keys["1"].Key) != 16 {
+		t.Fatalf("bad: expected a 16 byte key, got %d bytes", len(p.Keys["1"].Key))
+	}
+
+	derived, err := p.DeriveKey([]byte("context"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(derived) != 16 {
+		t.Fatalf("bad: expected a 16 byte derived key, got %d bytes", len(derived))
+	}
+
+	ciphertext, err := p.Encrypt(0, []byte("context"), nil, "dGhlIHF1aWNrIGJyb3duIGZveA==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := p.Decrypt([]byte("context"), nil, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext != "dGhlIHF1aWNrIGJyb3duIGZveA==" {
+		t.Fatalf("bad: plaintext %q", plaintext)
+	}
+
+	if _, err := p.Decrypt([]byte("other context"), nil, ciphertext); err == nil {
+		t.Fatal("expected an error decrypting with another context")
+	}
+}
@@ -0,0 +1,481 @@
+package ldaputil
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-ldap/ldap"
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// inChainGroupFilter finds all the groups the user is a member of, directly or
+// through other groups, using Active Directory's LDAP_MATCHING_RULE_IN_CHAIN
+const inChainGroupFilter = "(&(objectClass=group)(member:1.2.840.113556.1.4.1941:={{.UserDN}}))"
+
+// Client looks up users and their groups in an LDAP server described by a
+// ConfigEntry
+type Client struct {
+	Logger log.Logger
+
+	// Pool, if set, lets Connect and Release reuse connections between
+	// logins and skip servers that could not be reached recently
+	Pool *Pool
+}
+
+// Connect returns a connection to one of the configured servers. If the
+// client has a pool and the configuration has a BindDN and bind password, the
+// connection is bound as the BindDN. It must be handed back with Release.
+func (c *Client) Connect(cfg *ConfigEntry) (*ldap.Conn, error) {
+	if c.Pool == nil {
+		return c.DialLDAP(cfg)
+	}
+	return c.Pool.get(c, cfg)
+}
+
+// Release hands back a connection returned by Connect, keeping it open for
+// later use if possible
+func (c *Client) Release(cfg *ConfigEntry, conn *ldap.Conn) {
+	if c.Pool == nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return
+	}
+	c.Pool.put(cfg, conn)
+}
+
+// DialLDAP connects to the first reachable server among the comma separated
+// URLs of the configuration, trying them in order
+func (c *Client) DialLDAP(cfg *ConfigEntry) (*ldap.Conn, error) {
+	return c.dialURLs(cfg, strings.Split(cfg.Url, ","), nil)
+}
+
+// dialURLs connects to the first reachable server in urls. If report is not
+// nil, it is called with the outcome of every connection attempt.
+func (c *Client) dialURLs(cfg *ConfigEntry, urls []string, report func(uut string, err error)) (*ldap.Conn, error) {
+	var retErr *multierror.Error
+	for _, uut := range urls {
+		conn, err := c.dialURL(cfg, uut)
+		if report != nil {
+			report(uut, err)
+		}
+		if err == nil {
+			if retErr != nil {
+				if c.Logger.IsDebug() {
+					c.Logger.Debug("errors connecting to some hosts", "error", retErr.Error())
+				}
+			}
+			return conn, nil
+		}
+		retErr = multierror.Append(retErr, err)
+	}
+
+	return nil, retErr.ErrorOrNil()
+}
+
+func (c *Client) dialURL(cfg *ConfigEntry, uut string) (*ldap.Conn, error) {
+	u, err := url.Parse(uut)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error parsing url %q: {{err}}", uut), err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		host = u.Host
+	}
+
+	timeout := time.Duration(cfg.ConnectionTimeout) * time.Second
+	if timeout == 0 {
+		timeout = ldap.DefaultTimeout
+	}
+
+	var conn *ldap.Conn
+	var tlsConfig *tls.Config
+	switch u.Scheme {
+	case "ldap":
+		if port == "" {
+			port = "389"
+		}
+		conn, err = dialTimeout(net.JoinHostPort(host, port), nil, timeout)
+		if err != nil {
+			break
+		}
+		if cfg.StartTLS {
+			tlsConfig, err = cfg.GetTLSConfig(host)
+			if err != nil {
+				break
+			}
+			err = conn.StartTLS(tlsConfig)
+		}
+	case "ldaps":
+		if port == "" {
+			port = "636"
+		}
+		tlsConfig, err = cfg.GetTLSConfig(host)
+		if err != nil {
+			break
+		}
+		conn, err = dialTimeout(net.JoinHostPort(host, port), tlsConfig, timeout)
+	default:
+		return nil, fmt.Errorf("invalid LDAP scheme in url %q", net.JoinHostPort(host, port))
+	}
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, errwrap.Wrapf(fmt.Sprintf("error connecting to host %q: {{err}}", uut), err)
+	}
+
+	return conn, nil
+}
+
+// dialTimeout is ldap.Dial and ldap.DialTLS with a configurable timeout
+func dialTimeout(addr string, tlsConfig *tls.Config, timeout time.Duration) (*ldap.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+	}
+
+	var netConn net.Conn
+	var err error
+	if tlsConfig != nil {
+		netConn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		netConn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, ldap.NewError(ldap.ErrorNetwork, err)
+	}
+
+	conn := ldap.NewConn(netConn, tlsConfig != nil)
+	conn.Start()
+	return conn, nil
+}
+
+// search runs the search request, using paged results if the configuration
+// sets a page size
+func (c *Client) search(cfg *ConfigEntry, conn *ldap.Conn, searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if cfg.SearchPageSize > 0 {
+		return conn.SearchWithPaging(searchRequest, uint32(cfg.SearchPageSize))
+	}
+	return conn.Search(searchRequest)
+}
+
+/*
+ * Discover and return the bind string for the user attempting to authenticate.
+ * This is handled in one of several ways:
+ *
+ * 1. If DiscoverDN is set, the user object will be searched for using userdn (base search path)
+ *    and userattr (the attribute that maps to the provided username).
+ *    The bind will either be anonymous or use binddn and bindpassword if they were provided.
+ * 2. If upndomain is set, the user dn is constructed as 'username@upndomain'. See https://msdn.microsoft.com/en-us/library/cc223499.aspx
+ *
+ */
+func (c *Client) GetUserBindDN(cfg *ConfigEntry, conn *ldap.Conn, username string) (string, error) {
+	bindDN := ""
+	if cfg.DiscoverDN || (cfg.BindDN != "" && cfg.BindPassword != "") {
+		var err error
+		if cfg.BindPassword != "" {
+			err = conn.Bind(cfg.BindDN, cfg.BindPassword)
+		} else {
+			err = conn.UnauthenticatedBind(cfg.BindDN)
+		}
+		if err != nil {
+			return bindDN, errwrap.Wrapf("LDAP bind (service) failed: {{err}}", err)
+		}
+
+		filter := fmt.Sprintf("(%s=%s)", cfg.UserAttr, ldap.EscapeFilter(username))
+		if c.Logger.IsDebug() {
+			c.Logger.Debug("discovering user", "userdn", cfg.UserDN, "filter", filter)
+		}
+		result, err := c.search(cfg, conn, &ldap.SearchRequest{
+			BaseDN:    cfg.UserDN,
+			Scope:     2, // subtree
+			Filter:    filter,
+			SizeLimit: math.MaxInt32,
+		})
+		if err != nil {
+			return bindDN, errwrap.Wrapf("LDAP search for binddn failed: {{err}}", err)
+		}
+		if len(result.Entries) != 1 {
+			return bindDN, fmt.Errorf("LDAP search for binddn 0 or not unique")
+		}
+		bindDN = result.Entries[0].DN
+	} else {
+		if cfg.UPNDomain != "" {
+			bindDN = fmt.Sprintf("%s@%s", EscapeLDAPValue(username), cfg.UPNDomain)
+		} else {
+			bindDN = fmt.Sprintf("%s=%s,%s", cfg.UserAttr, EscapeLDAPValue(username), cfg.UserDN)
+		}
+	}
+
+	return bindDN, nil
+}
+
+/*
+ * Returns the DN of the object representing the authenticated user.
+ */
+func (c *Client) GetUserDN(cfg *ConfigEntry, conn *ldap.Conn, bindDN string) (string, error) {
+	userDN := ""
+	if cfg.UPNDomain != "" {
+		// Find the distinguished name for the user if userPrincipalName used for login
+		filter := fmt.Sprintf("(userPrincipalName=%s)", ldap.EscapeFilter(bindDN))
+		if c.Logger.IsDebug() {
+			c.Logger.Debug("searching upn", "userdn", cfg.UserDN, "filter", filter)
+		}
+		result, err := c.search(cfg, conn, &ldap.SearchRequest{
+			BaseDN:    cfg.UserDN,
+			Scope:     2, // subtree
+			Filter:    filter,
+			SizeLimit: math.MaxInt32,
+		})
+		if err != nil {
+			return userDN, errwrap.Wrapf("LDAP search failed for detecting user: {{err}}", err)
+		}
+		for _, e := range result.Entries {
+			userDN = e.DN
+		}
+	} else {
+		userDN = bindDN
+	}
+
+	return userDN, nil
+}
+
+/*
+ * GetLdapGroups queries LDAP and returns a slice describing the set of groups the authenticated user is a member of.
+ *
+ * The search query is constructed according to cfg.GroupFilter, and run in context of cfg.GroupDN.
+ * Groups will be resolved from the query results by following the attribute defined in cfg.GroupAttr.
+ *
+ * cfg.GroupFilter is a go template and is compiled with the following context: [UserDN, Username]
+ *    UserDN - The DN of the authenticated user
+ *    Username - The Username of the authenticated user
+ *
+ * Example:
+ *   cfg.GroupFilter = "(&(objectClass=group)(member:1.2.840.113556.1.4.1941:={{.UserDN}}))"
+ *   cfg.GroupDN     = "OU=Groups,DC=myorg,DC=com"
+ *   cfg.GroupAttr   = "cn"
+ *
+ * NOTE - If cfg.GroupFilter is empty, no query is performed and an empty result slice is returned.
+ *
+ * Nested groups are resolved according to cfg.NestedGroups. With "in_chain", cfg.GroupFilter is replaced by
+ * a filter using LDAP_MATCHING_RULE_IN_CHAIN so that Active Directory returns all nested groups at once.
+ * With "recursive", the filter is evaluated again with UserDN set to the DN of each group found, up to
+ * cfg.NestedGroupsDepth times.
+ *
+ */
+func (c *Client) GetLdapGroups(cfg *ConfigEntry, conn *ldap.Conn, userDN string, username string) ([]string, error) {
+	// retrieve the groups in a string/bool map as a structure to avoid duplicates inside
+	ldapMap := make(map[string]bool)
+
+	groupFilter := cfg.GroupFilter
+	if cfg.NestedGroups == NestedGroupsInChain {
+		groupFilter = inChainGroupFilter
+	}
+
+	if groupFilter == "" {
+		c.Logger.Warn("groupfilter is empty, will not query server")
+		return make([]string, 0), nil
+	}
+
+	if cfg.GroupDN == "" {
+		c.Logger.Warn("groupdn is empty, will not query server")
+		return make([]string, 0), nil
+	}
+
+	// If groupfilter was defined, resolve it as a Go template and use the query for
+	// returning the user's groups
+	if c.Logger.IsDebug() {
+		c.Logger.Debug("compiling group filter", "group_filter", groupFilter)
+	}
+
+	// Parse the configuration as a template.
+	// Example template "(&(objectClass=group)(member:1.2.840.113556.1.4.1941:={{.UserDN}}))"
+	t, err := template.New("queryTemplate").Parse(groupFilter)
+	if err != nil {
+		return nil, errwrap.Wrapf("LDAP search failed due to template compilation error: {{err}}", err)
+	}
+
+	// DNs whose groups have been searched for, lowercased as DNs are case
+	// insensitive
+	searched := map[string]bool{
+		strings.ToLower(userDN): true,
+	}
+
+	memberDNs := []string{userDN}
+	for depth := 0; len(memberDNs) > 0; depth++ {
+		var groupDNs []string
+		for _, memberDN := range memberDNs {
+			entries, err := c.searchGroups(cfg, conn, t, memberDN, username)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, e := range entries {
+				// Enumerate attributes of each result, parse out CN and add as group
+				values := e.GetAttributeValues(cfg.GroupAttr)
+				var valueDNs []string
+				for _, val := range values {
+					groupCN := getCN(val)
+					ldapMap[groupCN] = true
+					if isDN(val) {
+						valueDNs = append(valueDNs, val)
+					}
+				}
+				if len(values) == 0 {
+					// If groupattr didn't resolve, use self (enumerating group objects)
+					groupCN := getCN(e.DN)
+					ldapMap[groupCN] = true
+				}
+
+				// The groups are either named by DN in the attribute, as with
+				// memberOf, or are the entries themselves
+				if len(valueDNs) > 0 {
+					groupDNs = append(groupDNs, valueDNs...)
+				} else {
+					groupDNs = append(groupDNs, e.DN)
+				}
+			}
+		}
+
+		if cfg.NestedGroups != NestedGroupsRecursive || depth >= cfg.NestedGroupsDepth {
+			break
+		}
+
+		memberDNs = nil
+		for _, groupDN := range groupDNs {
+			if searched[strings.ToLower(groupDN)] {
+				continue
+			}
+			searched[strings.ToLower(groupDN)] = true
+			memberDNs = append(memberDNs, groupDN)
+		}
+		if c.Logger.IsDebug() && len(memberDNs) > 0 {
+			c.Logger.Debug("searching nested groups", "depth", depth+1, "groups", memberDNs)
+		}
+	}
+
+	ldapGroups := make([]string, 0, len(ldapMap))
+	for key, _ := range ldapMap {
+		ldapGroups = append(ldapGroups, key)
+	}
+
+	return ldapGroups, nil
+}
+
+// searchGroups renders the group filter for the given member and returns the
+// matching entries
+func (c *Client) searchGroups(cfg *ConfigEntry, conn *ldap.Conn, t *template.Template, memberDN, username string) ([]*ldap.Entry, error) {
+	// Build context to pass to template - we will be exposing UserDn and Username.
+	context := struct {
+		UserDN   string
+		Username string
+	}{
+		ldap.EscapeFilter(memberDN),
+		ldap.EscapeFilter(username),
+	}
+
+	var renderedQuery bytes.Buffer
+	t.Execute(&renderedQuery, context)
+
+	if c.Logger.IsDebug() {
+		c.Logger.Debug("searching", "groupdn", cfg.GroupDN, "rendered_query", renderedQuery.String())
+	}
+
+	result, err := c.search(cfg, conn, &ldap.SearchRequest{
+		BaseDN: cfg.GroupDN,
+		Scope:  2, // subtree
+		Filter: renderedQuery.String(),
+		Attributes: []string{
+			cfg.GroupAttr,
+		},
+		SizeLimit: math.MaxInt32,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("LDAP search failed: {{err}}", err)
+	}
+
+	entries := make([]*ldap.Entry, 0, len(result.Entries))
+	for _, e := range result.Entries {
+		dn, err := ldap.ParseDN(e.DN)
+		if err != nil || len(dn.RDNs) == 0 {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// isDN returns whether the value is a distinguished name rather than a plain
+// name such as a CN
+func isDN(value string) bool {
+	dn, err := ldap.ParseDN(value)
+	return err == nil && len(dn.RDNs) > 0
+}
+
+/*
+ * Parses a distinguished name and returns the CN portion.
+ * Given a non-conforming string (such as an already-extracted CN),
+ * it will be returned as-is.
+ */
+func getCN(dn string) string {
+	parsedDN, err := ldap.ParseDN(dn)
+	if err != nil || len(parsedDN.RDNs) == 0 {
+		// It was already a CN, return as-is
+		return dn
+	}
+
+	for _, rdn := range parsedDN.RDNs {
+		for _, rdnAttr := range rdn.Attributes {
+			if rdnAttr.Type == "CN" {
+				return rdnAttr.Value
+			}
+		}
+	}
+
+	// Default, return self
+	return dn
+}
+
+func EscapeLDAPValue(input string) string {
+	// RFC4514 forbids un-escaped:
+	// - leading space or hash
+	// - trailing space
+	// - special characters '"', '+', ',', ';', '<', '>', '\\'
+	// - null
+	for i := 0; i < len(input); i++ {
+		escaped := false
+		if input[i] == '\\' {
+			i++
+			escaped = true
+		}
+		switch input[i] {
+		case '"', '+', ',', ';', '<', '>', '\\':
+			if !escaped {
+				input = input[0:i] + "\\" + input[i:]
+				i++
+			}
+			continue
+		}
+		if escaped {
+			input = input[0:i] + "\\" + input[i:]
+			i++
+		}
+	}
+	if input[0] == ' ' || input[0] == '#' {
+		input = "\\" + input
+	}
+	if input[len(input)-1] == ' ' {
+		input = input[0:len(input)-1] + "\\ "
+	}
+	return input
+}
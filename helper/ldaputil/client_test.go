@@ -0,0 +1,339 @@
+package ldaputil
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	"gopkg.in/asn1-ber.v1"
+)
+
+const (
+	testBindDN       = "cn=vault,ou=users,dc=example,dc=com"
+	testBindPassword = "password"
+	testUserDN       = "cn=alice,ou=users,dc=example,dc=com"
+)
+
+// testGroups maps group DNs to their members
+var testGroups = map[string][]string{
+	"cn=devs,ou=groups,dc=example,dc=com":        {testUserDN},
+	"cn=engineering,ou=groups,dc=example,dc=com": {"cn=devs,ou=groups,dc=example,dc=com"},
+	"cn=staff,ou=groups,dc=example,dc=com":       {"cn=engineering,ou=groups,dc=example,dc=com"},
+	"cn=sales,ou=groups,dc=example,dc=com":       {"cn=bob,ou=users,dc=example,dc=com"},
+}
+
+// testServer is a minimal LDAP server answering binds and "(member=<dn>)"
+// group searches against testGroups
+type testServer struct {
+	listener net.Listener
+
+	l        sync.Mutex
+	conns    int
+	binds    int
+	filters  []string
+	controls []string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{
+		listener: ln,
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.l.Lock()
+			s.conns++
+			s.l.Unlock()
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *testServer) Close() {
+	s.listener.Close()
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			s.l.Lock()
+			s.binds++
+			s.l.Unlock()
+
+			code := ldap.LDAPResultSuccess
+			if op.Children[1].Value.(string) != testBindDN || op.Children[2].Data.String() != testBindPassword {
+				code = ldap.LDAPResultInvalidCredentials
+			}
+			writeResult(conn, messageID, ldap.ApplicationBindResponse, code)
+
+		case ldap.ApplicationSearchRequest:
+			filter, err := ldap.DecompileFilter(op.Children[6])
+			if err != nil {
+				return
+			}
+			s.l.Lock()
+			s.filters = append(s.filters, filter)
+			if len(packet.Children) > 2 {
+				for _, control := range packet.Children[2].Children {
+					s.controls = append(s.controls, ldap.DecodeControl(control).GetControlType())
+				}
+			}
+			s.l.Unlock()
+
+			if strings.HasPrefix(filter, "(member=") {
+				member := strings.TrimSuffix(strings.TrimPrefix(filter, "(member="), ")")
+				for groupDN, members := range testGroups {
+					for _, m := range members {
+						if m == member {
+							writeEntry(conn, messageID, groupDN)
+						}
+					}
+				}
+			}
+			writeResult(conn, messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)
+
+		default:
+			return
+		}
+	}
+}
+
+func writeResult(conn net.Conn, messageID int64, tag ber.Tag, code int) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	writeMessage(conn, messageID, response)
+}
+
+// writeEntry returns the group with its cn attribute
+func writeEntry(conn net.Conn, messageID int64, dn string) {
+	cn := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+	cn.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, strings.TrimPrefix(strings.Split(dn, ",")[0], "cn="), "Value"))
+	attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+	attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "cn", "Type"))
+	attr.AppendChild(cn)
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	attrs.AppendChild(attr)
+
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Entry")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "DN"))
+	entry.AppendChild(attrs)
+	writeMessage(conn, messageID, entry)
+}
+
+func writeMessage(conn net.Conn, messageID int64, op *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	packet.AppendChild(op)
+	conn.Write(packet.Bytes())
+}
+
+func testConfig(url string) *ConfigEntry {
+	return &ConfigEntry{
+		Url:               url,
+		GroupDN:           "ou=groups,dc=example,dc=com",
+		GroupFilter:       "(member={{.UserDN}})",
+		GroupAttr:         "cn",
+		BindDN:            testBindDN,
+		BindPassword:      testBindPassword,
+		NestedGroups:      NestedGroupsNone,
+		NestedGroupsDepth: 10,
+		ConnectionTimeout: 5,
+	}
+}
+
+func testLogger() log.Logger {
+	return logging.NewVaultLogger(log.Trace)
+}
+
+func TestClient_GetLdapGroups(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	cases := []struct {
+		name     string
+		nested   string
+		depth    int
+		expected []string
+	}{
+		{"none", NestedGroupsNone, 10, []string{"devs"}},
+		{"recursive", NestedGroupsRecursive, 10, []string{"devs", "engineering", "staff"}},
+		{"recursive depth", NestedGroupsRecursive, 1, []string{"devs", "engineering"}},
+	}
+
+	for _, tc := range cases {
+		cfg := testConfig(server.URL())
+		cfg.NestedGroups = tc.nested
+		cfg.NestedGroupsDepth = tc.depth
+
+		client := &Client{
+			Logger: testLogger(),
+		}
+		conn, err := client.Connect(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		groups, err := client.GetLdapGroups(cfg, conn, testUserDN, "alice")
+		client.Release(cfg, conn)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		sort.Strings(groups)
+		if !reflect.DeepEqual(groups, tc.expected) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.expected, groups)
+		}
+	}
+
+	// Active Directory resolves nested groups in a single search
+	cfg := testConfig(server.URL())
+	cfg.NestedGroups = NestedGroupsInChain
+	cfg.SearchPageSize = 100
+
+	client := &Client{
+		Logger: testLogger(),
+	}
+	conn, err := client.Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Release(cfg, conn)
+
+	server.l.Lock()
+	server.filters = nil
+	server.l.Unlock()
+	if _, err := client.GetLdapGroups(cfg, conn, testUserDN, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	server.l.Lock()
+	defer server.l.Unlock()
+	if len(server.filters) != 1 || !strings.Contains(server.filters[0], "1.2.840.113556.1.4.1941") {
+		t.Fatalf("expected a single in-chain search, got %v", server.filters)
+	}
+	if len(server.controls) == 0 || server.controls[len(server.controls)-1] != ldap.ControlTypePaging {
+		t.Fatalf("expected paged search, got controls %v", server.controls)
+	}
+}
+
+func TestPool_ReuseAndFailover(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	// Find a port nothing listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	downURL := "ldap://" + ln.Addr().String()
+	ln.Close()
+
+	var pool Pool
+	defer pool.Close()
+	client := &Client{
+		Logger: testLogger(),
+		Pool:   &pool,
+	}
+
+	cfg := testConfig(downURL + "," + server.URL())
+	for i := 0; i < 3; i++ {
+		conn, err := client.Connect(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Release(cfg, conn)
+	}
+
+	server.l.Lock()
+	if server.conns != 1 || server.binds != 3 {
+		t.Fatalf("expected one connection bound three times, got %d connections and %d binds", server.conns, server.binds)
+	}
+	server.l.Unlock()
+
+	pool.l.Lock()
+	if _, ok := pool.unhealthy[downURL]; !ok {
+		t.Fatalf("expected %q to be marked unhealthy", downURL)
+	}
+	pool.l.Unlock()
+
+	// A configuration change drops the pooled connections
+	cfg = testConfig(server.URL())
+	conn, err := client.Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Release(cfg, conn)
+
+	server.l.Lock()
+	if server.conns != 2 {
+		t.Fatalf("expected a new connection after the configuration changed, got %d connections", server.conns)
+	}
+	server.l.Unlock()
+
+	// Servers that recently could not be reached are tried last
+	other := newTestServer(t)
+	defer other.Close()
+	cfg = testConfig(server.URL() + "," + other.URL())
+	cfg.BindDN = ""
+	pool.l.Lock()
+	pool.reset(cfg)
+	pool.unhealthy[server.URL()] = time.Now()
+	pool.l.Unlock()
+
+	conn, err = client.Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A round trip makes sure the server has counted the connection
+	if err := conn.Bind(testBindDN, testBindPassword); err != nil {
+		t.Fatal(err)
+	}
+	client.Release(cfg, conn)
+
+	server.l.Lock()
+	other.l.Lock()
+	if server.conns != 2 || other.conns != 1 {
+		t.Fatalf("expected unhealthy server to be skipped, got %d and %d connections", server.conns, other.conns)
+	}
+	other.l.Unlock()
+	server.l.Unlock()
+
+	// Wrong service credentials fail
+	cfg = testConfig(server.URL())
+	cfg.BindPassword = "wrong"
+	if _, err := client.Connect(cfg); err == nil {
+		t.Fatal("expected bind with wrong password to fail")
+	}
+}
@@ -0,0 +1,391 @@
+package ldaputil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/tlsutil"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// NestedGroupsNone only returns the groups matched by the group filter
+	NestedGroupsNone = "none"
+
+	// NestedGroupsInChain lets Active Directory resolve nested groups using
+	// LDAP_MATCHING_RULE_IN_CHAIN
+	NestedGroupsInChain = "in_chain"
+
+	// NestedGroupsRecursive applies the group filter again to every group
+	// found until no new groups are returned
+	NestedGroupsRecursive = "recursive"
+)
+
+// ConfigFields returns the schema of the fields used to configure the
+// connection to an LDAP server and the lookup of users and groups in it
+func ConfigFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"url": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Default:     "ldap://127.0.0.1",
+			Description: "LDAP URL to connect to (default: ldap://127.0.0.1). Multiple URLs can be specified by concatenating them with commas; they will be tried in-order.",
+		},
+
+		"userdn": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "LDAP domain to use for users (eg: ou=People,dc=example,dc=org)",
+		},
+
+		"binddn": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "LDAP DN for searching for the user DN (optional)",
+		},
+
+		"bindpass": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "LDAP password for searching for the user DN (optional)",
+		},
+
+		"groupdn": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "LDAP search base to use for group membership search (eg: ou=Groups,dc=example,dc=org)",
+		},
+
+		"groupfilter": &framework.FieldSchema{
+			Type:    framework.TypeString,
+			Default: "(|(memberUid={{.Username}})(member={{.UserDN}})(uniqueMember={{.UserDN}}))",
+			Description: `Go template for querying group membership of user (optional)
+The template can access the following context variables: UserDN, Username
+Example: (&(objectClass=group)(member:1.2.840.113556.1.4.1941:={{.UserDN}}))
+Default: (|(memberUid={{.Username}})(member={{.UserDN}})(uniqueMember={{.UserDN}}))`,
+		},
+
+		"groupattr": &framework.FieldSchema{
+			Type:    framework.TypeString,
+			Default: "cn",
+			Description: `LDAP attribute to follow on objects returned by <groupfilter>
+in order to enumerate user group membership.
+Examples: "cn" or "memberOf", etc.
+Default: cn`,
+		},
+
+		"upndomain": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Enables userPrincipalDomain login with [username]@UPNDomain (optional)",
+		},
+
+		"userattr": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Default:     "cn",
+			Description: "Attribute used for users (default: cn)",
+		},
+
+		"certificate": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "CA certificate to use when verifying LDAP server certificate, must be x509 PEM encoded (optional)",
+		},
+
+		"discoverdn": &framework.FieldSchema{
+			Type:        framework.TypeBool,
+			Description: "Use anonymous bind to discover the bind DN of a user (optional)",
+		},
+
+		"insecure_tls": &framework.FieldSchema{
+			Type:        framework.TypeBool,
+			Description: "Skip LDAP server SSL Certificate verification - VERY insecure (optional)",
+		},
+
+		"starttls": &framework.FieldSchema{
+			Type:        framework.TypeBool,
+			Description: "Issue a StartTLS command after establishing unencrypted connection (optional)",
+		},
+
+		"tls_min_version": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Default:     "tls12",
+			Description: "Minimum TLS version to use. Accepted values are 'tls10', 'tls11' or 'tls12'. Defaults to 'tls12'",
+		},
+
+		"tls_max_version": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Default:     "tls12",
+			Description: "Maximum TLS version to use. Accepted values are 'tls10', 'tls11' or 'tls12'. Defaults to 'tls12'",
+		},
+
+		"deny_null_bind": &framework.FieldSchema{
+			Type:        framework.TypeBool,
+			Default:     true,
+			Description: "Denies an unauthenticated LDAP bind request if the user's password is empty; defaults to true",
+		},
+
+		"case_sensitive_names": &framework.FieldSchema{
+			Type:        framework.TypeBool,
+			Description: "If true, case sensitivity will be used when comparing usernames and groups for matching policies.",
+		},
+
+		"nested_groups": &framework.FieldSchema{
+			Type:    framework.TypeString,
+			Default: NestedGroupsNone,
+			Description: `How to resolve groups the user is a member of through other groups.
+"none" only returns the groups found by <groupfilter>. "in_chain" asks Active
+Directory for all nested groups in a single search using
+LDAP_MATCHING_RULE_IN_CHAIN, replacing <groupfilter>. "recursive" evaluates
+<groupfilter> again for every group found, up to <nested_groups_depth> levels.
+Default: none`,
+		},
+
+		"nested_groups_depth": &framework.FieldSchema{
+			Type:        framework.TypeInt,
+			Default:     10,
+			Description: "Maximum number of levels of nesting followed when nested_groups is \"recursive\". Default: 10",
+		},
+
+		"search_page_size": &framework.FieldSchema{
+			Type:        framework.TypeInt,
+			Description: "If greater than zero, searches are paged with this many entries per page, allowing results larger than the server's size limit. Default: 0 (no paging)",
+		},
+
+		"connection_timeout": &framework.FieldSchema{
+			Type:        framework.TypeDurationSecond,
+			Default:     30,
+			Description: "Timeout, in seconds, when connecting to an LDAP server before trying the next URL. Default: 30",
+		},
+	}
+}
+
+/*
+ * Creates and initializes a ConfigEntry object with its default values,
+ * as specified by the passed schema.
+ */
+func NewConfigEntry(d *framework.FieldData) (*ConfigEntry, error) {
+	cfg := new(ConfigEntry)
+
+	url := d.Get("url").(string)
+	if url != "" {
+		cfg.Url = strings.ToLower(url)
+	}
+	userattr := d.Get("userattr").(string)
+	if userattr != "" {
+		cfg.UserAttr = strings.ToLower(userattr)
+	}
+	userdn := d.Get("userdn").(string)
+	if userdn != "" {
+		cfg.UserDN = userdn
+	}
+	groupdn := d.Get("groupdn").(string)
+	if groupdn != "" {
+		cfg.GroupDN = groupdn
+	}
+	groupfilter := d.Get("groupfilter").(string)
+	if groupfilter != "" {
+		// Validate the template before proceeding
+		_, err := template.New("queryTemplate").Parse(groupfilter)
+		if err != nil {
+			return nil, errwrap.Wrapf("invalid groupfilter: {{err}}", err)
+		}
+
+		cfg.GroupFilter = groupfilter
+	}
+	groupattr := d.Get("groupattr").(string)
+	if groupattr != "" {
+		cfg.GroupAttr = groupattr
+	}
+	upndomain := d.Get("upndomain").(string)
+	if upndomain != "" {
+		cfg.UPNDomain = upndomain
+	}
+	certificate := d.Get("certificate").(string)
+	if certificate != "" {
+		block, _ := pem.Decode([]byte(certificate))
+
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("failed to decode PEM block in the certificate")
+		}
+		_, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to parse certificate: {{err}}", err)
+		}
+		cfg.Certificate = certificate
+	}
+	insecureTLS := d.Get("insecure_tls").(bool)
+	if insecureTLS {
+		cfg.InsecureTLS = insecureTLS
+	}
+	cfg.TLSMinVersion = d.Get("tls_min_version").(string)
+	if cfg.TLSMinVersion == "" {
+		return nil, fmt.Errorf("failed to get 'tls_min_version' value")
+	}
+
+	var ok bool
+	_, ok = tlsutil.TLSLookup[cfg.TLSMinVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid 'tls_min_version'")
+	}
+
+	cfg.TLSMaxVersion = d.Get("tls_max_version").(string)
+	if cfg.TLSMaxVersion == "" {
+		return nil, fmt.Errorf("failed to get 'tls_max_version' value")
+	}
+
+	_, ok = tlsutil.TLSLookup[cfg.TLSMaxVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid 'tls_max_version'")
+	}
+	if cfg.TLSMaxVersion < cfg.TLSMinVersion {
+		return nil, fmt.Errorf("'tls_max_version' must be greater than or equal to 'tls_min_version'")
+	}
+
+	startTLS := d.Get("starttls").(bool)
+	if startTLS {
+		cfg.StartTLS = startTLS
+	}
+
+	bindDN := d.Get("binddn").(string)
+	if bindDN != "" {
+		cfg.BindDN = bindDN
+	}
+
+	bindPass := d.Get("bindpass").(string)
+	if bindPass != "" {
+		cfg.BindPassword = bindPass
+	}
+
+	denyNullBind := d.Get("deny_null_bind").(bool)
+	if denyNullBind {
+		cfg.DenyNullBind = denyNullBind
+	}
+
+	discoverDN := d.Get("discoverdn").(bool)
+	if discoverDN {
+		cfg.DiscoverDN = discoverDN
+	}
+
+	caseSensitiveNames, ok := d.GetOk("case_sensitive_names")
+	if ok {
+		cfg.CaseSensitiveNames = new(bool)
+		*cfg.CaseSensitiveNames = caseSensitiveNames.(bool)
+	}
+
+	cfg.NestedGroups = strings.ToLower(d.Get("nested_groups").(string))
+	switch cfg.NestedGroups {
+	case NestedGroupsNone, NestedGroupsInChain, NestedGroupsRecursive:
+	default:
+		return nil, fmt.Errorf("invalid 'nested_groups' %q", cfg.NestedGroups)
+	}
+
+	cfg.NestedGroupsDepth = d.Get("nested_groups_depth").(int)
+	if cfg.NestedGroupsDepth < 0 {
+		return nil, fmt.Errorf("'nested_groups_depth' cannot be negative")
+	}
+
+	cfg.SearchPageSize = d.Get("search_page_size").(int)
+	if cfg.SearchPageSize < 0 {
+		return nil, fmt.Errorf("'search_page_size' cannot be negative")
+	}
+
+	cfg.ConnectionTimeout = d.Get("connection_timeout").(int)
+	if cfg.ConnectionTimeout < 0 {
+		return nil, fmt.Errorf("'connection_timeout' cannot be negative")
+	}
+
+	return cfg, nil
+}
+
+type ConfigEntry struct {
+	Url           string `json:"url"`
+	UserDN        string `json:"userdn"`
+	GroupDN       string `json:"groupdn"`
+	GroupFilter   string `json:"groupfilter"`
+	GroupAttr     string `json:"groupattr"`
+	UPNDomain     string `json:"upndomain"`
+	UserAttr      string `json:"userattr"`
+	Certificate   string `json:"certificate"`
+	InsecureTLS   bool   `json:"insecure_tls"`
+	StartTLS      bool   `json:"starttls"`
+	BindDN        string `json:"binddn"`
+	BindPassword  string `json:"bindpass"`
+	DenyNullBind  bool   `json:"deny_null_bind"`
+	DiscoverDN    bool   `json:"discoverdn"`
+	TLSMinVersion string `json:"tls_min_version"`
+	TLSMaxVersion string `json:"tls_max_version"`
+
+	NestedGroups      string `json:"nested_groups"`
+	NestedGroupsDepth int    `json:"nested_groups_depth"`
+	SearchPageSize    int    `json:"search_page_size"`
+	ConnectionTimeout int    `json:"connection_timeout"`
+
+	// CaseSensitiveNames has always been stored under its field name, as
+	// its tag used to be malformed
+	CaseSensitiveNames *bool `json:"CaseSensitiveNames,omitempty"`
+}
+
+// PasswordlessMap returns the configuration as it is returned when read,
+// without the bind password
+func (c *ConfigEntry) PasswordlessMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"url":             c.Url,
+		"userdn":          c.UserDN,
+		"groupdn":         c.GroupDN,
+		"groupfilter":     c.GroupFilter,
+		"groupattr":       c.GroupAttr,
+		"upndomain":       c.UPNDomain,
+		"userattr":        c.UserAttr,
+		"certificate":     c.Certificate,
+		"insecure_tls":    c.InsecureTLS,
+		"starttls":        c.StartTLS,
+		"binddn":          c.BindDN,
+		"deny_null_bind":  c.DenyNullBind,
+		"discoverdn":      c.DiscoverDN,
+		"tls_min_version": c.TLSMinVersion,
+		"tls_max_version": c.TLSMaxVersion,
+
+		"nested_groups":       c.NestedGroups,
+		"nested_groups_depth": c.NestedGroupsDepth,
+		"search_page_size":    c.SearchPageSize,
+		"connection_timeout":  c.ConnectionTimeout,
+	}
+	if c.CaseSensitiveNames != nil {
+		m["case_sensitive_names"] = *c.CaseSensitiveNames
+	}
+	return m
+}
+
+func (c *ConfigEntry) GetTLSConfig(host string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: host,
+	}
+
+	if c.TLSMinVersion != "" {
+		tlsMinVersion, ok := tlsutil.TLSLookup[c.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid 'tls_min_version' in config")
+		}
+		tlsConfig.MinVersion = tlsMinVersion
+	}
+
+	if c.TLSMaxVersion != "" {
+		tlsMaxVersion, ok := tlsutil.TLSLookup[c.TLSMaxVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid 'tls_max_version' in config")
+		}
+		tlsConfig.MaxVersion = tlsMaxVersion
+	}
+
+	if c.InsecureTLS {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if c.Certificate != "" {
+		caPool := x509.NewCertPool()
+		ok := caPool.AppendCertsFromPEM([]byte(c.Certificate))
+		if !ok {
+			return nil, fmt.Errorf("could not append CA certificate")
+		}
+		tlsConfig.RootCAs = caPool
+	}
+	return tlsConfig, nil
+}
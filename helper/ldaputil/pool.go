@@ -0,0 +1,180 @@
+package ldaputil
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap"
+	"github.com/hashicorp/errwrap"
+)
+
+const (
+	// maxIdleConns is the number of connections kept open between logins
+	maxIdleConns = 8
+
+	// maxIdleTime is how long a connection may sit unused in the pool.
+	// Servers drop idle connections after a while, e.g. after 15 minutes
+	// by default with Active Directory.
+	maxIdleTime = 5 * time.Minute
+
+	// unhealthyRetryInterval is how long a server that could not be reached
+	// is only tried after all other servers
+	unhealthyRetryInterval = 30 * time.Second
+)
+
+// Pool keeps connections bound as the configured BindDN open between logins,
+// and tracks which of the configured servers could not be reached so that
+// logins fail over to the next one without waiting for it again. Servers are
+// tried in their configured order again once unhealthyRetryInterval has
+// passed. The pool follows the configuration passed to Client.Connect and
+// Client.Release: when it changes, all connections and health information are
+// dropped.
+//
+// The zero value is an empty pool.
+type Pool struct {
+	l         sync.Mutex
+	cfg       *ConfigEntry
+	idle      []*idleConn
+	unhealthy map[string]time.Time
+}
+
+type idleConn struct {
+	conn     *ldap.Conn
+	lastUsed time.Time
+}
+
+func (p *Pool) get(c *Client, cfg *ConfigEntry) (*ldap.Conn, error) {
+	p.l.Lock()
+	p.reset(cfg)
+	for pooled(cfg) && len(p.idle) > 0 {
+		ic := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.l.Unlock()
+
+		// Binding again both checks that the connection still works and
+		// undoes any bind made by its previous user
+		if time.Since(ic.lastUsed) < maxIdleTime {
+			err := ic.conn.Bind(cfg.BindDN, cfg.BindPassword)
+			if err == nil {
+				return ic.conn, nil
+			}
+			if c.Logger.IsDebug() {
+				c.Logger.Debug("discarding pooled connection", "error", err)
+			}
+		}
+		ic.conn.Close()
+
+		p.l.Lock()
+	}
+	p.l.Unlock()
+
+	conn, err := p.dial(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if pooled(cfg) {
+		if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			conn.Close()
+			return nil, errwrap.Wrapf("LDAP bind (service) failed: {{err}}", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (p *Pool) put(cfg *ConfigEntry, conn *ldap.Conn) {
+	if conn == nil {
+		return
+	}
+
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if !pooled(cfg) || !reflect.DeepEqual(p.cfg, cfg) || len(p.idle) >= maxIdleConns {
+		conn.Close()
+		return
+	}
+
+	p.idle = append(p.idle, &idleConn{
+		conn:     conn,
+		lastUsed: time.Now(),
+	})
+}
+
+// Close closes all idle connections
+func (p *Pool) Close() {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.closeIdle()
+}
+
+// reset drops the idle connections and server health if the configuration
+// has changed. It must be called with the lock held.
+func (p *Pool) reset(cfg *ConfigEntry) {
+	if reflect.DeepEqual(p.cfg, cfg) {
+		return
+	}
+
+	p.closeIdle()
+	p.unhealthy = make(map[string]time.Time)
+
+	cfgCopy := *cfg
+	if cfg.CaseSensitiveNames != nil {
+		cfgCopy.CaseSensitiveNames = new(bool)
+		*cfgCopy.CaseSensitiveNames = *cfg.CaseSensitiveNames
+	}
+	p.cfg = &cfgCopy
+}
+
+func (p *Pool) closeIdle() {
+	for _, ic := range p.idle {
+		ic.conn.Close()
+	}
+	p.idle = nil
+}
+
+// dial connects to the configured servers in order, except that servers that
+// recently could not be reached are tried last
+func (p *Pool) dial(c *Client, cfg *ConfigEntry) (*ldap.Conn, error) {
+	var healthy, unhealthy []string
+
+	p.l.Lock()
+	for _, uut := range strings.Split(cfg.Url, ",") {
+		if failed, ok := p.unhealthy[uut]; ok && time.Since(failed) < unhealthyRetryInterval {
+			unhealthy = append(unhealthy, uut)
+			continue
+		}
+		healthy = append(healthy, uut)
+	}
+	p.l.Unlock()
+
+	return c.dialURLs(cfg, append(healthy, unhealthy...), func(uut string, err error) {
+		p.report(c, uut, err)
+	})
+}
+
+// report records the outcome of connecting to a server
+func (p *Pool) report(c *Client, uut string, err error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if err == nil {
+		delete(p.unhealthy, uut)
+		return
+	}
+
+	if _, ok := p.unhealthy[uut]; !ok {
+		c.Logger.Warn("LDAP server unreachable, failing over", "url", uut, "error", err)
+	}
+	p.unhealthy[uut] = time.Now()
+}
+
+// pooled returns whether connections made with the configuration are bound as
+// a service account and can be shared between logins
+func pooled(cfg *ConfigEntry) bool {
+	return cfg.BindDN != "" && cfg.BindPassword != ""
+}
@@ -0,0 +1,88 @@
+package metricsutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// PrometheusMetricFormat selects the Prometheus text format
+	PrometheusMetricFormat = "prometheus"
+
+	// PrometheusContentType is the content type of the Prometheus text format
+	PrometheusContentType = "text/plain; version=0.0.4"
+)
+
+// MetricsHelper gives access to the metrics collected in memory, either as
+// the JSON summary of the in-memory sink or in the Prometheus text format
+type MetricsHelper struct {
+	inMemSink      *metrics.InmemSink
+	prometheusSink *PrometheusSink
+}
+
+// NewMetricsHelper creates a MetricsHelper. prometheusSink is nil when the
+// Prometheus format is disabled.
+func NewMetricsHelper(inMemSink *metrics.InmemSink, prometheusSink *PrometheusSink) *MetricsHelper {
+	return &MetricsHelper{
+		inMemSink:      inMemSink,
+		prometheusSink: prometheusSink,
+	}
+}
+
+// ResponseForFormat returns a raw response with the metrics in the requested
+// format. An empty format returns the JSON summary.
+func (m *MetricsHelper) ResponseForFormat(format string) *logical.Response {
+	switch format {
+	case PrometheusMetricFormat:
+		return m.PrometheusResponse()
+	case "":
+		return m.GenericResponse()
+	default:
+		return rawResponse(http.StatusBadRequest, "text/plain", []byte(fmt.Sprintf("metric response format %q unknown", format)))
+	}
+}
+
+// PrometheusResponse returns the metrics in the Prometheus text format
+func (m *MetricsHelper) PrometheusResponse() *logical.Response {
+	if m.prometheusSink == nil {
+		return rawResponse(http.StatusBadRequest, "text/plain", []byte("prometheus is not enabled"))
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.prometheusSink.WriteTo(&buf); err != nil {
+		return rawResponse(http.StatusInternalServerError, "text/plain", []byte(fmt.Sprintf("error formatting metrics: %s", err)))
+	}
+
+	return rawResponse(http.StatusOK, PrometheusContentType, buf.Bytes())
+}
+
+// GenericResponse returns the summary of the most recent interval of the
+// in-memory sink as JSON
+func (m *MetricsHelper) GenericResponse() *logical.Response {
+	summary, err := m.inMemSink.DisplayMetrics(nil, nil)
+	if err != nil {
+		return rawResponse(http.StatusInternalServerError, "text/plain", []byte(fmt.Sprintf("error fetching metrics: %s", err)))
+	}
+
+	content, err := json.Marshal(summary)
+	if err != nil {
+		return rawResponse(http.StatusInternalServerError, "text/plain", []byte(fmt.Sprintf("error encoding metrics: %s", err)))
+	}
+
+	return rawResponse(http.StatusOK, "application/json", content)
+}
+
+func rawResponse(status int, contentType string, body []byte) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  status,
+			logical.HTTPContentType: contentType,
+			logical.HTTPRawBody:     body,
+		},
+	}
+}
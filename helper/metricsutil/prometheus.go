@@ -0,0 +1,192 @@
+package metricsutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+)
+
+var (
+	forbiddenChars     = regexp.MustCompile("[^a-zA-Z0-9_:]")
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+type metricType string
+
+const (
+	typeGauge   metricType = "gauge"
+	typeCounter metricType = "counter"
+	typeSummary metricType = "summary"
+)
+
+// promMetric is a single series, identified by its name and labels
+type promMetric struct {
+	name    string
+	labels  string
+	value   float64
+	count   uint64
+	updated time.Time
+}
+
+// PrometheusSink is a metrics.MetricSink that keeps the values needed to
+// expose metrics in the Prometheus text format. Gauges keep their last value,
+// counters accumulate and samples are exposed as summaries with a sum and a
+// count. Series that have not been updated within the retention time are
+// dropped.
+type PrometheusSink struct {
+	retention time.Duration
+
+	lock    sync.Mutex
+	metrics map[metricType]map[string]*promMetric
+}
+
+// NewPrometheusSink creates a PrometheusSink that retains series for the
+// given duration
+func NewPrometheusSink(retention time.Duration) *PrometheusSink {
+	return &PrometheusSink{
+		retention: retention,
+		metrics: map[metricType]map[string]*promMetric{
+			typeGauge:   make(map[string]*promMetric),
+			typeCounter: make(map[string]*promMetric),
+			typeSummary: make(map[string]*promMetric),
+		},
+	}
+}
+
+func (p *PrometheusSink) SetGauge(key []string, val float32) {
+	p.SetGaugeWithLabels(key, val, nil)
+}
+
+func (p *PrometheusSink) SetGaugeWithLabels(key []string, val float32, labels []metrics.Label) {
+	p.update(typeGauge, key, labels, func(m *promMetric) {
+		m.value = float64(val)
+	})
+}
+
+// EmitKey is not supported by the Prometheus format; the values are dropped.
+func (p *PrometheusSink) EmitKey(key []string, val float32) {
+}
+
+func (p *PrometheusSink) IncrCounter(key []string, val float32) {
+	p.IncrCounterWithLabels(key, val, nil)
+}
+
+func (p *PrometheusSink) IncrCounterWithLabels(key []string, val float32, labels []metrics.Label) {
+	p.update(typeCounter, key, labels, func(m *promMetric) {
+		m.value += float64(val)
+	})
+}
+
+func (p *PrometheusSink) AddSample(key []string, val float32) {
+	p.AddSampleWithLabels(key, val, nil)
+}
+
+func (p *PrometheusSink) AddSampleWithLabels(key []string, val float32, labels []metrics.Label) {
+	p.update(typeSummary, key, labels, func(m *promMetric) {
+		m.value += float64(val)
+		m.count++
+	})
+}
+
+func (p *PrometheusSink) update(t metricType, key []string, labels []metrics.Label, f func(*promMetric)) {
+	name := flattenKey(key)
+	formattedLabels := formatLabels(labels)
+	id := name + formattedLabels
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	m, ok := p.metrics[t][id]
+	if !ok {
+		m = &promMetric{
+			name:   name,
+			labels: formattedLabels,
+		}
+		p.metrics[t][id] = m
+	}
+	f(m)
+	m.updated = time.Now()
+}
+
+// WriteTo writes all retained series to w in the Prometheus text format
+func (p *PrometheusSink) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	p.lock.Lock()
+	cutoff := time.Now().Add(-p.retention)
+
+	byName := make(map[string][]*promMetric)
+	types := make(map[string]metricType)
+	for t, series := range p.metrics {
+		for id, m := range series {
+			if m.updated.Before(cutoff) {
+				delete(series, id)
+				continue
+			}
+			copied := *m
+			byName[m.name] = append(byName[m.name], &copied)
+			types[m.name] = t
+		}
+	}
+	p.lock.Unlock()
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		series := byName[name]
+		sort.Slice(series, func(i, j int) bool {
+			return series[i].labels < series[j].labels
+		})
+
+		t := types[name]
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, t)
+		for _, m := range series {
+			switch t {
+			case typeSummary:
+				fmt.Fprintf(&buf, "%s_sum%s %s\n", name, m.labels, formatFloat(m.value))
+				fmt.Fprintf(&buf, "%s_count%s %d\n", name, m.labels, m.count)
+			default:
+				fmt.Fprintf(&buf, "%s%s %s\n", name, m.labels, formatFloat(m.value))
+			}
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+// flattenKey joins the parts of a key into a valid Prometheus metric name
+func flattenKey(parts []string) string {
+	return forbiddenChars.ReplaceAllString(strings.Join(parts, "_"), "_")
+}
+
+// formatLabels returns the labels in the Prometheus text format, sorted by
+// name, or an empty string if there are none
+func formatLabels(labels []metrics.Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	formatted := make([]string, 0, len(labels))
+	for _, l := range labels {
+		formatted = append(formatted, fmt.Sprintf("%s=\"%s\"", forbiddenChars.ReplaceAllString(l.Name, "_"), labelValueReplacer.Replace(l.Value)))
+	}
+	sort.Strings(formatted)
+
+	return "{" + strings.Join(formatted, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,51 @@
+package metricsutil
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+)
+
+func TestPrometheusSink(t *testing.T) {
+	sink := NewPrometheusSink(time.Hour)
+
+	sink.SetGauge([]string{"vault", "core", "unsealed"}, 1)
+	sink.SetGauge([]string{"vault", "core", "unsealed"}, 0)
+	sink.IncrCounter([]string{"vault", "route", "read"}, 1)
+	sink.IncrCounter([]string{"vault", "route", "read"}, 2)
+	sink.AddSampleWithLabels([]string{"vault", "barrier", "get"}, 1.5, []metrics.Label{{Name: "mount-point", Value: `a"b`}})
+	sink.AddSampleWithLabels([]string{"vault", "barrier", "get"}, 2.5, []metrics.Label{{Name: "mount-point", Value: `a"b`}})
+
+	var buf bytes.Buffer
+	if _, err := sink.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# TYPE vault_barrier_get summary
+vault_barrier_get_sum{mount_point="a\"b"} 4
+vault_barrier_get_count{mount_point="a\"b"} 2
+# TYPE vault_core_unsealed gauge
+vault_core_unsealed 0
+# TYPE vault_route_read counter
+vault_route_read 3
+`
+	if buf.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestPrometheusSink_Retention(t *testing.T) {
+	sink := NewPrometheusSink(time.Millisecond)
+	sink.SetGauge([]string{"vault", "expired"}, 1)
+	time.Sleep(10 * time.Millisecond)
+
+	var buf bytes.Buffer
+	if _, err := sink.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected expired series to be dropped, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,85 @@
+package namespace
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+const (
+	// RootNamespaceID is the ID of the root namespace, which holds all the
+	// mounts, policies and tokens that are not part of a child namespace
+	RootNamespaceID = "root"
+)
+
+var (
+	// RootNamespace is the namespace that is used when no namespace is
+	// given in a request
+	RootNamespace = &Namespace{
+		ID:   RootNamespaceID,
+		Path: "",
+	}
+
+	// ErrNoNamespace is returned when a namespace is not found
+	ErrNoNamespace = errors.New("no namespace")
+)
+
+type contextValues struct{}
+
+// Namespace describes a node in the namespace tree. Path is the full path of
+// the namespace from the root, with a trailing slash, and is empty for the
+// root namespace.
+type Namespace struct {
+	ID   string `json:"id" structs:"id" mapstructure:"id"`
+	Path string `json:"path" structs:"path" mapstructure:"path"`
+}
+
+// HasParent returns true if possibleParent is an ancestor of n or n itself
+func (n *Namespace) HasParent(possibleParent *Namespace) bool {
+	switch {
+	case possibleParent.Path == "":
+		return true
+	case n.Path == "":
+		return false
+	default:
+		return strings.HasPrefix(n.Path, possibleParent.Path)
+	}
+}
+
+// TrimmedPath returns the given path relative to the namespace
+func (n *Namespace) TrimmedPath(path string) string {
+	return strings.TrimPrefix(path, n.Path)
+}
+
+// ContextWithNamespace returns a copy of ctx that carries the namespace
+func ContextWithNamespace(ctx context.Context, ns *Namespace) context.Context {
+	return context.WithValue(ctx, contextValues{}, ns)
+}
+
+// FromContext returns the namespace attached to ctx, or the root namespace
+// if there is none
+func FromContext(ctx context.Context) *Namespace {
+	if ctx == nil {
+		return RootNamespace
+	}
+	ns, ok := ctx.Value(contextValues{}).(*Namespace)
+	if !ok || ns == nil {
+		return RootNamespace
+	}
+	return ns
+}
+
+// Canonicalize trims any leading slash and ensures a trailing slash on a
+// non-empty namespace path
+func Canonicalize(nsPath string) string {
+	nsPath = strings.TrimSpace(nsPath)
+	if nsPath == "" || nsPath == "/" {
+		return ""
+	}
+
+	nsPath = strings.TrimPrefix(nsPath, "/")
+	if !strings.HasSuffix(nsPath, "/") {
+		nsPath += "/"
+	}
+	return nsPath
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
 	"strings"
@@ -13,8 +15,10 @@ import (
 	"github.com/elazarl/go-bindata-assetfs"
 	"github.com/hashicorp/errwrap"
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	sockaddr "github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/vault"
@@ -70,6 +74,7 @@ func Handler(core *vault.Core) http.Handler {
 	mux.Handle("/v1/sys/seal-status", handleSysSealStatus(core))
 	mux.Handle("/v1/sys/seal", handleSysSeal(core))
 	mux.Handle("/v1/sys/step-down", handleRequestForwarding(core, handleSysStepDown(core)))
+	mux.Handle("/v1/sys/storage/snapshot", handleRequestForwarding(core, handleSysStorageSnapshot(core)))
 	mux.Handle("/v1/sys/unseal", handleSysUnseal(core))
 	mux.Handle("/v1/sys/leader", handleSysLeader(core))
 	mux.Handle("/v1/sys/health", handleSysHealth(core))
@@ -124,6 +129,76 @@ func wrapGenericHandler(h http.Handler) http.Handler {
 	})
 }
 
+// WrapForwardedForHandler wraps the handler with one that takes the client
+// address of requests from the authorized addresses, usually load balancers
+// or reverse proxies, from the X-Forwarded-For header. hopSkips is the
+// number of trusted proxies appending to the header after the client address.
+func WrapForwardedForHandler(h http.Handler, authorizedAddrs []*sockaddr.SockAddrMarshaler, rejectNotPresent, rejectNotAuthorized bool, hopSkips int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers := r.Header[textproto.CanonicalMIMEHeaderKey("X-Forwarded-For")]
+		if len(headers) == 0 {
+			if !rejectNotPresent {
+				h.ServeHTTP(w, r)
+				return
+			}
+			respondError(w, http.StatusBadRequest, fmt.Errorf("missing X-Forwarded-For header and configured to reject when not present"))
+			return
+		}
+
+		host, port, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, errwrap.Wrapf("error parsing client address: {{err}}", err))
+			return
+		}
+		addr, err := sockaddr.NewIPAddr(host)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, errwrap.Wrapf("error parsing client address: {{err}}", err))
+			return
+		}
+
+		var authorized bool
+		for _, authorizedAddr := range authorizedAddrs {
+			if authorizedAddr.Contains(addr) {
+				authorized = true
+				break
+			}
+		}
+		if !authorized {
+			// If not rejecting, the header is simply not trusted
+			if !rejectNotAuthorized {
+				h.ServeHTTP(w, r)
+				return
+			}
+			respondError(w, http.StatusBadRequest, fmt.Errorf("client address not authorized for X-Forwarded-For and configured to reject the request"))
+			return
+		}
+
+		// Multiple headers are equivalent to a single comma separated one
+		var chain []string
+		for _, header := range headers {
+			for _, v := range strings.Split(header, ",") {
+				chain = append(chain, strings.TrimSpace(v))
+			}
+		}
+
+		// Since the request comes from an authorized address, a chain that is
+		// shorter than configured is a misconfiguration rather than something
+		// to silently ignore
+		i := len(chain) - 1 - hopSkips
+		if i < 0 {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("X-Forwarded-For chain of length %d is too short to skip %d hops", len(chain), hopSkips))
+			return
+		}
+		if net.ParseIP(chain[i]) == nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid X-Forwarded-For address %q", chain[i]))
+			return
+		}
+
+		r.RemoteAddr = net.JoinHostPort(chain[i], port)
+		h.ServeHTTP(w, r)
+	})
+}
+
 // A lookup on a token that is about to expire returns nil, which means by the
 // time we can validate a wrapping token lookup will return nil since it will
 // be revoked after the call. So we have to do the validation here.
@@ -143,6 +218,18 @@ func wrappingVerificationFunc(core *vault.Core, req *logical.Request) error {
 	return nil
 }
 
+// namespacePath prefixes the request path with the namespace selected by the
+// X-Vault-Namespace header, if any. Namespaces can also be selected by
+// prefixing the path directly; the two forms can be combined, in which case
+// the path is relative to the namespace of the header.
+func namespacePath(r *http.Request, path string) string {
+	ns := r.Header.Get(consts.NamespaceHeaderName)
+	if ns == "" {
+		return path
+	}
+	return namespace.Canonicalize(ns) + path
+}
+
 // stripPrefix is a helper to strip a prefix from the path. It will
 // return false from the second return value if it the prefix doesn't exist.
 func stripPrefix(prefix, path string) (string, bool) {
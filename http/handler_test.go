@@ -12,6 +12,7 @@ import (
 
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/vault"
 )
@@ -408,3 +409,54 @@ func TestHandler_nonPrintableChars(t *testing.T) {
 
 	testResponseStatus(t, resp, 400)
 }
+
+func TestHandler_XForwardedFor(t *testing.T) {
+	authorizedAddrs, err := parseutil.ParseAddrs("127.0.0.1/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var remoteAddr string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	})
+
+	cases := []struct {
+		name                string
+		remoteAddr          string
+		headers             []string
+		rejectNotPresent    bool
+		rejectNotAuthorized bool
+		hopSkips            int
+		code                int
+		expected            string
+	}{
+		{"authorized", "127.0.0.1:8200", []string{"1.2.3.4"}, true, true, 0, http.StatusOK, "1.2.3.4:8200"},
+		{"multiple headers", "127.0.0.1:8200", []string{"1.2.3.4", "5.6.7.8"}, true, true, 0, http.StatusOK, "5.6.7.8:8200"},
+		{"hop skips", "127.0.0.1:8200", []string{"1.2.3.4, 5.6.7.8"}, true, true, 1, http.StatusOK, "1.2.3.4:8200"},
+		{"too many hop skips", "127.0.0.1:8200", []string{"1.2.3.4"}, true, true, 1, http.StatusBadRequest, ""},
+		{"invalid address", "127.0.0.1:8200", []string{"foo"}, true, true, 0, http.StatusBadRequest, ""},
+		{"not present", "127.0.0.1:8200", nil, false, true, 0, http.StatusOK, "127.0.0.1:8200"},
+		{"not present rejected", "127.0.0.1:8200", nil, true, true, 0, http.StatusBadRequest, ""},
+		{"not authorized", "127.0.0.2:8200", []string{"1.2.3.4"}, true, false, 0, http.StatusOK, "127.0.0.2:8200"},
+		{"not authorized rejected", "127.0.0.2:8200", []string{"1.2.3.4"}, true, true, 0, http.StatusBadRequest, ""},
+	}
+
+	for _, tc := range cases {
+		remoteAddr = ""
+		req := httptest.NewRequest("GET", "/v1/sys/health", nil)
+		req.RemoteAddr = tc.remoteAddr
+		for _, header := range tc.headers {
+			req.Header.Add("X-Forwarded-For", header)
+		}
+
+		w := httptest.NewRecorder()
+		WrapForwardedForHandler(h, authorizedAddrs, tc.rejectNotPresent, tc.rejectNotAuthorized, tc.hopSkips).ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Fatalf("%s: expected code %d, got %d", tc.name, tc.code, w.Code)
+		}
+		if remoteAddr != tc.expected {
+			t.Fatalf("%s: expected remote address %q, got %q", tc.name, tc.expected, remoteAddr)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysNamespaces(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	config := api.DefaultConfig()
+	config.Address = addr
+
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	if _, err := client.Logical().Write("sys/namespaces/team1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Select the namespace using the header
+	client.SetNamespace("team1")
+	if err := client.Sys().Mount("kv", &api.MountInput{Type: "kv"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("kv/foo", map[string]interface{}{"value": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mounts["kv/"]; !ok {
+		t.Fatalf("missing mount in namespace: %#v", mounts)
+	}
+
+	// Select the namespace using the path prefix
+	client.ClearNamespace()
+	secret, err := client.Logical().Read("team1/kv/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == nil || secret.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", secret)
+	}
+	mounts, err = client.Sys().ListMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mounts["kv/"]; ok {
+		t.Fatalf("unexpected namespace mount in root: %#v", mounts)
+	}
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func handleSysStorageSnapshot(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var op logical.Operation
+		switch r.Method {
+		case "GET":
+			op = logical.ReadOperation
+		case "PUT", "POST":
+			op = logical.UpdateOperation
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		requestID, err := uuid.GenerateUUID()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, errwrap.Wrapf("failed to generate identifier for the request: {{err}}", err))
+			return
+		}
+
+		// The body is the snapshot itself rather than JSON, so the request
+		// is built here instead of with buildLogicalRequest
+		req := requestAuth(core, r, &logical.Request{
+			ID:         requestID,
+			Operation:  op,
+			Path:       "sys/storage/snapshot",
+			Connection: getConnection(r),
+			Headers:    r.Header,
+		})
+
+		switch op {
+		case logical.ReadOperation:
+			sw := &snapshotResponseWriter{w: w}
+			err = core.StorageSnapshot(req, sw)
+			if err != nil && sw.started {
+				// The status has already been sent; the client will see a
+				// truncated snapshot that fails verification
+				core.Logger().Error("failed to write storage snapshot", "error", err)
+				return
+			}
+
+		case logical.UpdateOperation:
+			err = core.StorageSnapshotRestore(req, r.Body)
+		}
+
+		if err != nil {
+			respondSnapshotError(core, w, r, err)
+			return
+		}
+
+		if op == logical.UpdateOperation {
+			respondOk(w, nil)
+		}
+	})
+}
+
+func respondSnapshotError(core *vault.Core, w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errwrap.Contains(err, consts.ErrStandby.Error()):
+		respondStandby(core, w, r.URL)
+	case errwrap.Contains(err, logical.ErrPermissionDenied.Error()):
+		respondError(w, http.StatusForbidden, err)
+	default:
+		respondError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// snapshotResponseWriter sends the response headers on the first write so
+// that errors found before any data is written can still be reported with a
+// proper status code
+type snapshotResponseWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (s *snapshotResponseWriter) Write(p []byte) (int, error) {
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", "application/gzip")
+		s.w.WriteHeader(http.StatusOK)
+	}
+	return s.w.Write(p)
+}
@@ -69,6 +69,28 @@ type Auth struct {
 	// mappings groups for the group aliases in identity store. For all the
 	// matching groups, the entity ID of the user will be added.
 	GroupAliases []*Alias `json:"group_aliases" mapstructure:"group_aliases" structs:"group_aliases"`
+
+	// BoundCIDRs, if set, restricts the use of the issued token to requests
+	// coming from addresses within these CIDR blocks
+	BoundCIDRs []string `json:"bound_cidrs" mapstructure:"bound_cidrs" structs:"bound_cidrs"`
+
+	// MFARequirement is set instead of a client token when the login must
+	// be completed by passing multi-factor authentication
+	MFARequirement *MFARequirement `json:"mfa_requirement" mapstructure:"mfa_requirement" structs:"mfa_requirement"`
+}
+
+// MFARequirement describes the MFA methods that must be passed to complete a
+// login, and the ID of the pending login to complete
+type MFARequirement struct {
+	MFARequestID string           `json:"mfa_request_id" mapstructure:"mfa_request_id" structs:"mfa_request_id"`
+	MFAMethods   []*MFAMethodInfo `json:"mfa_methods" mapstructure:"mfa_methods" structs:"mfa_methods"`
+}
+
+// MFAMethodInfo describes an MFA method required by a login
+type MFAMethodInfo struct {
+	Name         string `json:"name" mapstructure:"name" structs:"name"`
+	Type         string `json:"type" mapstructure:"type" structs:"type"`
+	UsesPasscode bool   `json:"uses_passcode" mapstructure:"uses_passcode" structs:"uses_passcode"`
 }
 
 func (a *Auth) GoString() string {
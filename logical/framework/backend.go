@@ -360,7 +360,16 @@ func (b *Backend) handleRootHelp() (*logical.Response, error) {
 		return nil, err
 	}
 
-	return logical.HelpResponse(help, nil), nil
+	// Include the OpenAPI document of the backend's paths
+	doc := NewOASDocument()
+	if err := documentPaths(b, doc); err != nil {
+		return nil, err
+	}
+
+	resp := logical.HelpResponse(help, nil)
+	resp.Data["openapi"] = doc
+
+	return resp, nil
 }
 
 func (b *Backend) handleRevokeRenew(ctx context.Context, req *logical.Request) (*logical.Response, error) {
@@ -0,0 +1,469 @@
+package framework
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/version"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification that generated
+// documents conform to
+const OpenAPIVersion = "3.0.2"
+
+// OASDocument is an OpenAPI document describing the paths of one or more
+// backends
+type OASDocument struct {
+	Version string                  `json:"openapi"`
+	Info    OASInfo                 `json:"info"`
+	Paths   map[string]*OASPathItem `json:"paths"`
+}
+
+// OASInfo is the metadata of an OASDocument
+type OASInfo struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Version     string     `json:"version"`
+	License     OASLicense `json:"license"`
+}
+
+// OASLicense is the license of the described API
+type OASLicense struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// OASPathItem describes the operations available on a single path. The
+// x-vault extensions carry the path's authentication requirements.
+type OASPathItem struct {
+	Description     string         `json:"description,omitempty"`
+	Parameters      []OASParameter `json:"parameters,omitempty"`
+	Sudo            bool           `json:"x-vault-sudo,omitempty"`
+	Unauthenticated bool           `json:"x-vault-unauthenticated,omitempty"`
+	CreateSupported bool           `json:"x-vault-create-supported,omitempty"`
+
+	Get    *OASOperation `json:"get,omitempty"`
+	Post   *OASOperation `json:"post,omitempty"`
+	Delete *OASOperation `json:"delete,omitempty"`
+}
+
+// OASOperation is a single HTTP method on a path
+type OASOperation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []OASParameter       `json:"parameters,omitempty"`
+	RequestBody *OASRequestBody      `json:"requestBody,omitempty"`
+	Responses   map[int]*OASResponse `json:"responses"`
+}
+
+// OASParameter is a path or query parameter
+type OASParameter struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	In          string     `json:"in"`
+	Schema      *OASSchema `json:"schema,omitempty"`
+	Required    bool       `json:"required,omitempty"`
+}
+
+// OASRequestBody is the body accepted by an operation
+type OASRequestBody struct {
+	Description string     `json:"description,omitempty"`
+	Content     OASContent `json:"content"`
+}
+
+// OASContent maps media types to their schema
+type OASContent map[string]*OASMediaTypeObject
+
+// OASMediaTypeObject is the schema of a single media type
+type OASMediaTypeObject struct {
+	Schema *OASSchema `json:"schema,omitempty"`
+}
+
+// OASSchema describes the type of a parameter or body property
+type OASSchema struct {
+	Type        string                `json:"type,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Properties  map[string]*OASSchema `json:"properties,omitempty"`
+	Items       *OASSchema            `json:"items,omitempty"`
+	Format      string                `json:"format,omitempty"`
+	Default     interface{}           `json:"default,omitempty"`
+}
+
+// OASResponse is a response returned by an operation
+type OASResponse struct {
+	Description string `json:"description"`
+}
+
+// OASStdRespOK is the response documented for every operation
+var OASStdRespOK = &OASResponse{
+	Description: "OK",
+}
+
+// NewOASDocument returns an empty OpenAPI document for this version of Vault
+func NewOASDocument() *OASDocument {
+	return &OASDocument{
+		Version: OpenAPIVersion,
+		Info: OASInfo{
+			Title:       "HashiCorp Vault API",
+			Description: "HTTP API that gives you full access to Vault. All API routes are prefixed with `/v1/`.",
+			Version:     version.GetVersion().Version,
+			License: OASLicense{
+				Name: "Mozilla Public License 2.0",
+				URL:  "https://www.mozilla.org/en-US/MPL/2.0",
+			},
+		},
+		Paths: make(map[string]*OASPathItem),
+	}
+}
+
+// NewOASDocumentFromMap builds an OASDocument from the value found under the
+// "openapi" key of a root help response. Backends running as plugins return
+// it as a generic map.
+func NewOASDocumentFromMap(input interface{}) (*OASDocument, error) {
+	switch doc := input.(type) {
+	case *OASDocument:
+		return doc, nil
+	case nil:
+		return nil, errors.New("no OpenAPI document provided")
+	}
+
+	buf, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc OASDocument
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// documentPaths adds every path of the backend to doc
+func documentPaths(backend *Backend, doc *OASDocument) error {
+	for _, p := range backend.Paths {
+		if err := documentPath(p, backend.SpecialPaths(), backend.BackendType, doc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// documentPath adds a path to doc. A pattern with optional or alternative
+// parts is documented as every concrete path it can match, with named
+// captures turned into path parameters.
+func documentPath(p *Path, specialPaths *logical.Paths, backendType logical.BackendType, doc *OASDocument) error {
+	var sudoPaths, unauthPaths []string
+	if specialPaths != nil {
+		sudoPaths = specialPaths.Root
+		unauthPaths = specialPaths.Unauthenticated
+	}
+
+	var tags []string
+	switch backendType {
+	case logical.TypeLogical:
+		tags = []string{"secrets"}
+	case logical.TypeCredential:
+		tags = []string{"auth"}
+	}
+
+	paths, err := expandPattern(p.Pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		pi := &OASPathItem{
+			Description:     cleanString(p.HelpSynopsis),
+			Sudo:            specialPathMatch(path, sudoPaths),
+			Unauthenticated: specialPathMatch(path, unauthPaths),
+			CreateSupported: p.ExistenceCheck != nil,
+		}
+
+		// Parameters in the path are shared by all operations
+		pathFields := make(map[string]bool)
+		for _, name := range pathParams(path) {
+			pathFields[name] = true
+
+			schema := &OASSchema{Type: "string"}
+			description := ""
+			if field, ok := p.Fields[name]; ok {
+				schema = fieldSchema(field)
+				description = cleanString(field.Description)
+				schema.Description = ""
+			}
+
+			pi.Parameters = append(pi.Parameters, OASParameter{
+				Name:        name,
+				Description: description,
+				In:          "path",
+				Schema:      schema,
+				Required:    true,
+			})
+		}
+
+		_, hasRead := p.Callbacks[logical.ReadOperation]
+
+		// Reads are handled before lists, which share the GET method
+		for _, op := range []logical.Operation{
+			logical.ReadOperation,
+			logical.ListOperation,
+			logical.CreateOperation,
+			logical.UpdateOperation,
+			logical.DeleteOperation,
+		} {
+			if _, ok := p.Callbacks[op]; !ok {
+				continue
+			}
+
+			oasOp := &OASOperation{
+				Summary:     cleanString(p.HelpSynopsis),
+				Description: strings.TrimSpace(p.HelpDescription),
+				Tags:        tags,
+				Responses: map[int]*OASResponse{
+					200: OASStdRespOK,
+				},
+			}
+
+			switch op {
+			case logical.ReadOperation:
+				pi.Get = oasOp
+
+			case logical.ListOperation:
+				// Lists are selected with the list query parameter
+				if hasRead {
+					pi.Get.Parameters = append(pi.Get.Parameters, listParameter(false))
+					continue
+				}
+				oasOp.Parameters = append(oasOp.Parameters, listParameter(true))
+				pi.Get = oasOp
+
+			case logical.CreateOperation, logical.UpdateOperation:
+				if pi.Post != nil {
+					continue
+				}
+				oasOp.RequestBody = requestBody(p.Fields, pathFields)
+				pi.Post = oasOp
+
+			case logical.DeleteOperation:
+				pi.Delete = oasOp
+			}
+		}
+
+		doc.Paths["/"+path] = pi
+	}
+
+	return nil
+}
+
+// requestBody returns the body schema for the fields that are not path
+// parameters, or nil if there are none
+func requestBody(fields map[string]*FieldSchema, pathFields map[string]bool) *OASRequestBody {
+	props := make(map[string]*OASSchema)
+	for name, field := range fields {
+		if pathFields[name] {
+			continue
+		}
+		props[name] = fieldSchema(field)
+	}
+
+	if len(props) == 0 {
+		return nil
+	}
+
+	return &OASRequestBody{
+		Content: OASContent{
+			"application/json": &OASMediaTypeObject{
+				Schema: &OASSchema{
+					Type:       "object",
+					Properties: props,
+				},
+			},
+		},
+	}
+}
+
+func listParameter(required bool) OASParameter {
+	return OASParameter{
+		Name:        "list",
+		Description: "Return a list if `true`",
+		In:          "query",
+		Schema:      &OASSchema{Type: "string"},
+		Required:    required,
+	}
+}
+
+// fieldSchema converts a field's type into an OpenAPI schema
+func fieldSchema(field *FieldSchema) *OASSchema {
+	schema := &OASSchema{
+		Description: cleanString(field.Description),
+		Default:     field.Default,
+	}
+
+	switch field.Type {
+	case TypeString, TypeNameString:
+		schema.Type = "string"
+	case TypeInt:
+		schema.Type = "integer"
+	case TypeBool:
+		schema.Type = "boolean"
+	case TypeMap, TypeKVPairs:
+		schema.Type = "object"
+	case TypeDurationSecond:
+		schema.Type = "integer"
+		schema.Format = "seconds"
+	case TypeSlice:
+		schema.Type = "array"
+		schema.Items = &OASSchema{Type: "object"}
+	case TypeStringSlice, TypeCommaStringSlice:
+		schema.Type = "array"
+		schema.Items = &OASSchema{Type: "string"}
+	case TypeCommaIntSlice:
+		schema.Type = "array"
+		schema.Items = &OASSchema{Type: "integer"}
+	default:
+		schema.Type = "string"
+	}
+
+	return schema
+}
+
+// expandPattern returns the concrete paths a path pattern matches. Named
+// captures become {name} parameters and unnamed wildcards such as ".*" become
+// a {path} parameter. Variants that contain any other unnamed expression
+// cannot be written down as a path and are left out.
+func expandPattern(pattern string) ([]string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded, ok := expandRegexp(re)
+	if !ok {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(expanded))
+	var paths []string
+	for _, path := range expanded {
+		path = strings.TrimPrefix(path, "/")
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// expandRegexp returns every string the regexp can expand to. ok is false if
+// no variant can be expanded.
+func expandRegexp(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return []string{""}, true
+
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}, true
+
+	case syntax.OpCapture:
+		if re.Name != "" {
+			return []string{fmt.Sprintf("{%s}", re.Name)}, true
+		}
+		return expandRegexp(re.Sub[0])
+
+	case syntax.OpStar, syntax.OpPlus:
+		switch re.Sub[0].Op {
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			return []string{"{path}"}, true
+		}
+		return nil, false
+
+	case syntax.OpQuest:
+		result := []string{""}
+		if sub, ok := expandRegexp(re.Sub[0]); ok {
+			result = append(result, sub...)
+		}
+		return result, true
+
+	case syntax.OpAlternate:
+		var result []string
+		for _, sub := range re.Sub {
+			if expanded, ok := expandRegexp(sub); ok {
+				result = append(result, expanded...)
+			}
+		}
+		return result, len(result) > 0
+
+	case syntax.OpConcat:
+		result := []string{""}
+		for _, sub := range re.Sub {
+			expanded, ok := expandRegexp(sub)
+			if !ok {
+				return nil, false
+			}
+			var next []string
+			for _, prefix := range result {
+				for _, suffix := range expanded {
+					next = append(next, prefix+suffix)
+				}
+			}
+			result = next
+		}
+		return result, true
+
+	default:
+		return nil, false
+	}
+}
+
+// pathParams returns the names of the {name} parameters in path
+func pathParams(path string) []string {
+	var params []string
+	for {
+		start := strings.Index(path, "{")
+		if start == -1 {
+			return params
+		}
+		end := strings.Index(path[start:], "}")
+		if end == -1 {
+			return params
+		}
+		params = append(params, path[start+1:start+end])
+		path = path[start+end+1:]
+	}
+}
+
+// specialPathMatch checks whether path matches one of the special paths,
+// which are exact matches unless they end in a "*"
+func specialPathMatch(path string, specialPaths []string) bool {
+	for _, sp := range specialPaths {
+		if strings.HasSuffix(sp, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(sp, "*")) {
+				return true
+			}
+			continue
+		}
+		if path == sp {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cleanString collapses the whitespace of help text
+func cleanString(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,166 @@
+package framework
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestOpenAPI_ExpandPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		paths   []string
+	}{
+		{"rotate$", []string{"rotate"}},
+		{"^rotate/?$", []string{"rotate", "rotate/"}},
+		{"keys/" + GenericNameRegex("name"), []string{"keys/{name}"}},
+		{"keys/" + GenericNameRegex("name") + "/rotate$", []string{"keys/{name}/rotate"}},
+		{"(leases/)?renew" + OptionalParamRegex("url_lease_id"), []string{
+			"leases/renew",
+			"leases/renew/{url_lease_id}",
+			"renew",
+			"renew/{url_lease_id}",
+		}},
+		{"(raw/?$|raw/(?P<path>.+))", []string{"raw", "raw/", "raw/{path}"}},
+		{"leases/lookup/(?P<prefix>.+?)?", []string{"leases/lookup/", "leases/lookup/{prefix}"}},
+		{"auth/(?P<path>.+?)/tune$", []string{"auth/{path}/tune"}},
+		{"tidy/.*", []string{"tidy/{path}"}},
+		{"tidy/[a-z]+", nil},
+	}
+
+	for _, test := range tests {
+		paths, err := expandPattern(test.pattern)
+		if err != nil {
+			t.Fatalf("%s: %v", test.pattern, err)
+		}
+		if !reflect.DeepEqual(paths, test.paths) {
+			t.Fatalf("%s: expected %v, got %v", test.pattern, test.paths, paths)
+		}
+	}
+}
+
+func TestOpenAPI_SpecialPaths(t *testing.T) {
+	tests := []struct {
+		path     string
+		special  []string
+		expected bool
+	}{
+		{"foo", []string{}, false},
+		{"foo", []string{"foo"}, true},
+		{"foo/bar", []string{"foo"}, false},
+		{"foo/bar", []string{"foo/*"}, true},
+		{"foo/{name}", []string{"foo*"}, true},
+		{"bar", []string{"foo*"}, false},
+	}
+
+	for _, test := range tests {
+		if result := specialPathMatch(test.path, test.special); result != test.expected {
+			t.Fatalf("%s %v: expected %t", test.path, test.special, test.expected)
+		}
+	}
+}
+
+func TestOpenAPI_DocumentPath(t *testing.T) {
+	p := &Path{
+		Pattern: "keys/" + GenericNameRegex("name") + "/?$",
+		Fields: map[string]*FieldSchema{
+			"name": &FieldSchema{
+				Type:        TypeString,
+				Description: "Name of the key",
+			},
+			"ttl": &FieldSchema{
+				Type:        TypeDurationSecond,
+				Description: "TTL of the key",
+				Default:     60,
+			},
+			"tags": &FieldSchema{
+				Type: TypeCommaStringSlice,
+			},
+		},
+		Callbacks: map[logical.Operation]OperationFunc{
+			logical.ReadOperation:   nil,
+			logical.ListOperation:   nil,
+			logical.UpdateOperation: nil,
+			logical.DeleteOperation: nil,
+		},
+		HelpSynopsis:    "Manage keys.",
+		HelpDescription: "Manage the keys of the backend.",
+	}
+
+	doc := NewOASDocument()
+	err := documentPath(p, &logical.Paths{Root: []string{"keys/*"}}, logical.TypeLogical, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc.Paths) != 2 {
+		t.Fatalf("expected 2 paths, got: %#v", doc.Paths)
+	}
+
+	pi := doc.Paths["/keys/{name}"]
+	if pi == nil {
+		t.Fatalf("missing path: %#v", doc.Paths)
+	}
+	if !pi.Sudo || pi.Unauthenticated {
+		t.Fatalf("bad auth requirements: %#v", pi)
+	}
+
+	expectedParams := []OASParameter{
+		{
+			Name:        "name",
+			Description: "Name of the key",
+			In:          "path",
+			Schema:      &OASSchema{Type: "string"},
+			Required:    true,
+		},
+	}
+	if !reflect.DeepEqual(pi.Parameters, expectedParams) {
+		t.Fatalf("bad parameters: %#v", pi.Parameters)
+	}
+
+	if pi.Get == nil || pi.Post == nil || pi.Delete == nil {
+		t.Fatalf("missing operations: %#v", pi)
+	}
+	if pi.Get.Summary != "Manage keys." || !reflect.DeepEqual(pi.Get.Tags, []string{"secrets"}) {
+		t.Fatalf("bad operation: %#v", pi.Get)
+	}
+	if len(pi.Get.Parameters) != 1 || pi.Get.Parameters[0].Name != "list" || pi.Get.Parameters[0].Required {
+		t.Fatalf("bad list parameter: %#v", pi.Get.Parameters)
+	}
+
+	props := pi.Post.RequestBody.Content["application/json"].Schema.Properties
+	expectedProps := map[string]*OASSchema{
+		"ttl": &OASSchema{
+			Type:        "integer",
+			Format:      "seconds",
+			Description: "TTL of the key",
+			Default:     60,
+		},
+		"tags": &OASSchema{
+			Type:  "array",
+			Items: &OASSchema{Type: "string"},
+		},
+	}
+	if !reflect.DeepEqual(props, expectedProps) {
+		t.Fatalf("bad request body: %#v", props)
+	}
+
+	// The document survives the round trip through a plugin response
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(buf, &raw); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := NewOASDocumentFromMap(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Paths) != 2 || parsed.Paths["/keys/{name}"].Post == nil {
+		t.Fatalf("bad parsed document: %#v", parsed)
+	}
+}
@@ -13,4 +13,9 @@ type Alias struct {
 
 	// Name is the identifier of this identity in its authentication source
 	Name string `json:"name" structs:"name" mapstructure:"name"`
+
+	// Metadata represents the metadata associated with this alias. It is
+	// stored on the alias when it is created and updated on later logins if
+	// it changes.
+	Metadata map[string]string `json:"metadata" structs:"metadata" mapstructure:"metadata"`
 }
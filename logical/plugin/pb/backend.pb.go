@@ -15,6 +15,8 @@ It has these top-level messages:
 	Request
 	Alias
 	Auth
+	MFARequirement
+	MFAMethodInfo
 	LeaseOptions
 	Secret
 	Response
@@ -410,6 +412,8 @@ type Alias struct {
 	MountAccessor string `sentinel:"" protobuf:"bytes,2,opt,name=mount_accessor,json=mountAccessor" json:"mount_accessor,omitempty"`
 	// Name is the identifier of this identity in its authentication source
 	Name string `sentinel:"" protobuf:"bytes,3,opt,name=name" json:"name,omitempty"`
+	// Metadata represents the metadata associated with this alias
+	Metadata map[string]string `sentinel:"" protobuf:"bytes,4,rep,name=metadata" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *Alias) Reset()                    { *m = Alias{} }
@@ -438,6 +442,13 @@ func (m *Alias) GetName() string {
 	return ""
 }
 
+func (m *Alias) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
 type Auth struct {
 	LeaseOptions *LeaseOptions `sentinel:"" protobuf:"bytes,1,opt,name=lease_options,json=leaseOptions" json:"lease_options,omitempty"`
 	// InternalData is a JSON object that is stored with the auth struct.
@@ -485,6 +496,12 @@ type Auth struct {
 	// mappings groups for the group aliases in identity store. For all the
 	// matching groups, the entity ID of the user will be added.
 	GroupAliases []*Alias `sentinel:"" protobuf:"bytes,12,rep,name=group_aliases,json=groupAliases" json:"group_aliases,omitempty"`
+	// BoundCIDRs, if set, restricts the use of the issued token to requests
+	// coming from addresses within these CIDR blocks
+	BoundCidrs []string `sentinel:"" protobuf:"bytes,13,rep,name=bound_cidrs,json=boundCidrs" json:"bound_cidrs,omitempty"`
+	// MFARequirement is set instead of a client token when the login must
+	// be completed by passing multi-factor authentication
+	MfaRequirement *MFARequirement `sentinel:"" protobuf:"bytes,14,opt,name=mfa_requirement,json=mfaRequirement" json:"mfa_requirement,omitempty"`
 }
 
 func (m *Auth) Reset()                    { *m = Auth{} }
@@ -576,6 +593,79 @@ func (m *Auth) GetGroupAliases() []*Alias {
 	return nil
 }
 
+func (m *Auth) GetBoundCidrs() []string {
+	if m != nil {
+		return m.BoundCidrs
+	}
+	return nil
+}
+
+func (m *Auth) GetMfaRequirement() *MFARequirement {
+	if m != nil {
+		return m.MfaRequirement
+	}
+	return nil
+}
+
+// MFARequirement describes the MFA methods that must be passed to complete a
+// login, and the ID of the pending login to complete
+type MFARequirement struct {
+	MfaRequestID string           `sentinel:"" protobuf:"bytes,1,opt,name=mfa_request_id,json=mfaRequestId" json:"mfa_request_id,omitempty"`
+	MfaMethods   []*MFAMethodInfo `sentinel:"" protobuf:"bytes,2,rep,name=mfa_methods,json=mfaMethods" json:"mfa_methods,omitempty"`
+}
+
+func (m *MFARequirement) Reset()                    { *m = MFARequirement{} }
+func (m *MFARequirement) String() string            { return proto.CompactTextString(m) }
+func (*MFARequirement) ProtoMessage()               {}
+func (*MFARequirement) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *MFARequirement) GetMfaRequestID() string {
+	if m != nil {
+		return m.MfaRequestID
+	}
+	return ""
+}
+
+func (m *MFARequirement) GetMfaMethods() []*MFAMethodInfo {
+	if m != nil {
+		return m.MfaMethods
+	}
+	return nil
+}
+
+// MFAMethodInfo describes an MFA method required by a login
+type MFAMethodInfo struct {
+	Name         string `sentinel:"" protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Type         string `sentinel:"" protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	UsesPasscode bool   `sentinel:"" protobuf:"varint,3,opt,name=uses_passcode,json=usesPasscode" json:"uses_passcode,omitempty"`
+}
+
+func (m *MFAMethodInfo) Reset()                    { *m = MFAMethodInfo{} }
+func (m *MFAMethodInfo) String() string            { return proto.CompactTextString(m) }
+func (*MFAMethodInfo) ProtoMessage()               {}
+func (*MFAMethodInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *MFAMethodInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *MFAMethodInfo) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *MFAMethodInfo) GetUsesPasscode() bool {
+	if m != nil {
+		return m.UsesPasscode
+	}
+	return false
+}
+
 type LeaseOptions struct {
 	TTL       int64                      `sentinel:"" protobuf:"varint,1,opt,name=TTL" json:"TTL,omitempty"`
 	Renewable bool                       `sentinel:"" protobuf:"varint,2,opt,name=renewable" json:"renewable,omitempty"`
@@ -587,7 +677,7 @@ type LeaseOptions struct {
 func (m *LeaseOptions) Reset()                    { *m = LeaseOptions{} }
 func (m *LeaseOptions) String() string            { return proto.CompactTextString(m) }
 func (*LeaseOptions) ProtoMessage()               {}
-func (*LeaseOptions) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*LeaseOptions) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
 func (m *LeaseOptions) GetTTL() int64 {
 	if m != nil {
@@ -639,7 +729,7 @@ type Secret struct {
 func (m *Secret) Reset()                    { *m = Secret{} }
 func (m *Secret) String() string            { return proto.CompactTextString(m) }
 func (*Secret) ProtoMessage()               {}
-func (*Secret) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*Secret) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 func (m *Secret) GetLeaseOptions() *LeaseOptions {
 	if m != nil {
@@ -688,7 +778,7 @@ type Response struct {
 func (m *Response) Reset()                    { *m = Response{} }
 func (m *Response) String() string            { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()               {}
-func (*Response) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*Response) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
 func (m *Response) GetSecret() *Secret {
 	if m != nil {
@@ -761,7 +851,7 @@ type ResponseWrapInfo struct {
 func (m *ResponseWrapInfo) Reset()                    { *m = ResponseWrapInfo{} }
 func (m *ResponseWrapInfo) String() string            { return proto.CompactTextString(m) }
 func (*ResponseWrapInfo) ProtoMessage()               {}
-func (*ResponseWrapInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*ResponseWrapInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
 func (m *ResponseWrapInfo) GetTTL() int64 {
 	if m != nil {
@@ -841,7 +931,7 @@ type RequestWrapInfo struct {
 func (m *RequestWrapInfo) Reset()                    { *m = RequestWrapInfo{} }
 func (m *RequestWrapInfo) String() string            { return proto.CompactTextString(m) }
 func (*RequestWrapInfo) ProtoMessage()               {}
-func (*RequestWrapInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*RequestWrapInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 func (m *RequestWrapInfo) GetTTL() int64 {
 	if m != nil {
@@ -873,7 +963,7 @@ type HandleRequestArgs struct {
 func (m *HandleRequestArgs) Reset()                    { *m = HandleRequestArgs{} }
 func (m *HandleRequestArgs) String() string            { return proto.CompactTextString(m) }
 func (*HandleRequestArgs) ProtoMessage()               {}
-func (*HandleRequestArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*HandleRequestArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *HandleRequestArgs) GetStorageID() uint32 {
 	if m != nil {
@@ -898,7 +988,7 @@ type HandleRequestReply struct {
 func (m *HandleRequestReply) Reset()                    { *m = HandleRequestReply{} }
 func (m *HandleRequestReply) String() string            { return proto.CompactTextString(m) }
 func (*HandleRequestReply) ProtoMessage()               {}
-func (*HandleRequestReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*HandleRequestReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 func (m *HandleRequestReply) GetResponse() *Response {
 	if m != nil {
@@ -922,7 +1012,7 @@ type SpecialPathsReply struct {
 func (m *SpecialPathsReply) Reset()                    { *m = SpecialPathsReply{} }
 func (m *SpecialPathsReply) String() string            { return proto.CompactTextString(m) }
 func (*SpecialPathsReply) ProtoMessage()               {}
-func (*SpecialPathsReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*SpecialPathsReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
 func (m *SpecialPathsReply) GetPaths() *Paths {
 	if m != nil {
@@ -940,7 +1030,7 @@ type HandleExistenceCheckArgs struct {
 func (m *HandleExistenceCheckArgs) Reset()                    { *m = HandleExistenceCheckArgs{} }
 func (m *HandleExistenceCheckArgs) String() string            { return proto.CompactTextString(m) }
 func (*HandleExistenceCheckArgs) ProtoMessage()               {}
-func (*HandleExistenceCheckArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (*HandleExistenceCheckArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
 func (m *HandleExistenceCheckArgs) GetStorageID() uint32 {
 	if m != nil {
@@ -966,7 +1056,7 @@ type HandleExistenceCheckReply struct {
 func (m *HandleExistenceCheckReply) Reset()                    { *m = HandleExistenceCheckReply{} }
 func (m *HandleExistenceCheckReply) String() string            { return proto.CompactTextString(m) }
 func (*HandleExistenceCheckReply) ProtoMessage()               {}
-func (*HandleExistenceCheckReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (*HandleExistenceCheckReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
 
 func (m *HandleExistenceCheckReply) GetCheckFound() bool {
 	if m != nil {
@@ -999,7 +1089,7 @@ type SetupArgs struct {
 func (m *SetupArgs) Reset()                    { *m = SetupArgs{} }
 func (m *SetupArgs) String() string            { return proto.CompactTextString(m) }
 func (*SetupArgs) ProtoMessage()               {}
-func (*SetupArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+func (*SetupArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
 
 func (m *SetupArgs) GetBrokerID() uint32 {
 	if m != nil {
@@ -1030,7 +1120,7 @@ type SetupReply struct {
 func (m *SetupReply) Reset()                    { *m = SetupReply{} }
 func (m *SetupReply) String() string            { return proto.CompactTextString(m) }
 func (*SetupReply) ProtoMessage()               {}
-func (*SetupReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+func (*SetupReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
 
 func (m *SetupReply) GetErr() string {
 	if m != nil {
@@ -1047,7 +1137,7 @@ type TypeReply struct {
 func (m *TypeReply) Reset()                    { *m = TypeReply{} }
 func (m *TypeReply) String() string            { return proto.CompactTextString(m) }
 func (*TypeReply) ProtoMessage()               {}
-func (*TypeReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+func (*TypeReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
 
 func (m *TypeReply) GetType() uint32 {
 	if m != nil {
@@ -1063,7 +1153,7 @@ type InvalidateKeyArgs struct {
 func (m *InvalidateKeyArgs) Reset()                    { *m = InvalidateKeyArgs{} }
 func (m *InvalidateKeyArgs) String() string            { return proto.CompactTextString(m) }
 func (*InvalidateKeyArgs) ProtoMessage()               {}
-func (*InvalidateKeyArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+func (*InvalidateKeyArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
 
 func (m *InvalidateKeyArgs) GetKey() string {
 	if m != nil {
@@ -1081,7 +1171,7 @@ type StorageEntry struct {
 func (m *StorageEntry) Reset()                    { *m = StorageEntry{} }
 func (m *StorageEntry) String() string            { return proto.CompactTextString(m) }
 func (*StorageEntry) ProtoMessage()               {}
-func (*StorageEntry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+func (*StorageEntry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
 
 func (m *StorageEntry) GetKey() string {
 	if m != nil {
@@ -1111,7 +1201,7 @@ type StorageListArgs struct {
 func (m *StorageListArgs) Reset()                    { *m = StorageListArgs{} }
 func (m *StorageListArgs) String() string            { return proto.CompactTextString(m) }
 func (*StorageListArgs) ProtoMessage()               {}
-func (*StorageListArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+func (*StorageListArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
 
 func (m *StorageListArgs) GetPrefix() string {
 	if m != nil {
@@ -1128,7 +1218,7 @@ type StorageListReply struct {
 func (m *StorageListReply) Reset()                    { *m = StorageListReply{} }
 func (m *StorageListReply) String() string            { return proto.CompactTextString(m) }
 func (*StorageListReply) ProtoMessage()               {}
-func (*StorageListReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+func (*StorageListReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
 
 func (m *StorageListReply) GetKeys() []string {
 	if m != nil {
@@ -1151,7 +1241,7 @@ type StorageGetArgs struct {
 func (m *StorageGetArgs) Reset()                    { *m = StorageGetArgs{} }
 func (m *StorageGetArgs) String() string            { return proto.CompactTextString(m) }
 func (*StorageGetArgs) ProtoMessage()               {}
-func (*StorageGetArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+func (*StorageGetArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
 
 func (m *StorageGetArgs) GetKey() string {
 	if m != nil {
@@ -1168,7 +1258,7 @@ type StorageGetReply struct {
 func (m *StorageGetReply) Reset()                    { *m = StorageGetReply{} }
 func (m *StorageGetReply) String() string            { return proto.CompactTextString(m) }
 func (*StorageGetReply) ProtoMessage()               {}
-func (*StorageGetReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+func (*StorageGetReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
 
 func (m *StorageGetReply) GetEntry() *StorageEntry {
 	if m != nil {
@@ -1191,7 +1281,7 @@ type StoragePutArgs struct {
 func (m *StoragePutArgs) Reset()                    { *m = StoragePutArgs{} }
 func (m *StoragePutArgs) String() string            { return proto.CompactTextString(m) }
 func (*StoragePutArgs) ProtoMessage()               {}
-func (*StoragePutArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+func (*StoragePutArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
 
 func (m *StoragePutArgs) GetEntry() *StorageEntry {
 	if m != nil {
@@ -1207,7 +1297,7 @@ type StoragePutReply struct {
 func (m *StoragePutReply) Reset()                    { *m = StoragePutReply{} }
 func (m *StoragePutReply) String() string            { return proto.CompactTextString(m) }
 func (*StoragePutReply) ProtoMessage()               {}
-func (*StoragePutReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+func (*StoragePutReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
 
 func (m *StoragePutReply) GetErr() string {
 	if m != nil {
@@ -1223,7 +1313,7 @@ type StorageDeleteArgs struct {
 func (m *StorageDeleteArgs) Reset()                    { *m = StorageDeleteArgs{} }
 func (m *StorageDeleteArgs) String() string            { return proto.CompactTextString(m) }
 func (*StorageDeleteArgs) ProtoMessage()               {}
-func (*StorageDeleteArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+func (*StorageDeleteArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
 
 func (m *StorageDeleteArgs) GetKey() string {
 	if m != nil {
@@ -1239,7 +1329,7 @@ type StorageDeleteReply struct {
 func (m *StorageDeleteReply) Reset()                    { *m = StorageDeleteReply{} }
 func (m *StorageDeleteReply) String() string            { return proto.CompactTextString(m) }
 func (*StorageDeleteReply) ProtoMessage()               {}
-func (*StorageDeleteReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+func (*StorageDeleteReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
 
 func (m *StorageDeleteReply) GetErr() string {
 	if m != nil {
@@ -1255,7 +1345,7 @@ type TTLReply struct {
 func (m *TTLReply) Reset()                    { *m = TTLReply{} }
 func (m *TTLReply) String() string            { return proto.CompactTextString(m) }
 func (*TTLReply) ProtoMessage()               {}
-func (*TTLReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+func (*TTLReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
 
 func (m *TTLReply) GetTTL() int64 {
 	if m != nil {
@@ -1272,7 +1362,7 @@ type SudoPrivilegeArgs struct {
 func (m *SudoPrivilegeArgs) Reset()                    { *m = SudoPrivilegeArgs{} }
 func (m *SudoPrivilegeArgs) String() string            { return proto.CompactTextString(m) }
 func (*SudoPrivilegeArgs) ProtoMessage()               {}
-func (*SudoPrivilegeArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+func (*SudoPrivilegeArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
 
 func (m *SudoPrivilegeArgs) GetPath() string {
 	if m != nil {
@@ -1295,7 +1385,7 @@ type SudoPrivilegeReply struct {
 func (m *SudoPrivilegeReply) Reset()                    { *m = SudoPrivilegeReply{} }
 func (m *SudoPrivilegeReply) String() string            { return proto.CompactTextString(m) }
 func (*SudoPrivilegeReply) ProtoMessage()               {}
-func (*SudoPrivilegeReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+func (*SudoPrivilegeReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
 
 func (m *SudoPrivilegeReply) GetSudo() bool {
 	if m != nil {
@@ -1311,7 +1401,7 @@ type TaintedReply struct {
 func (m *TaintedReply) Reset()                    { *m = TaintedReply{} }
 func (m *TaintedReply) String() string            { return proto.CompactTextString(m) }
 func (*TaintedReply) ProtoMessage()               {}
-func (*TaintedReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+func (*TaintedReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
 
 func (m *TaintedReply) GetTainted() bool {
 	if m != nil {
@@ -1327,7 +1417,7 @@ type CachingDisabledReply struct {
 func (m *CachingDisabledReply) Reset()                    { *m = CachingDisabledReply{} }
 func (m *CachingDisabledReply) String() string            { return proto.CompactTextString(m) }
 func (*CachingDisabledReply) ProtoMessage()               {}
-func (*CachingDisabledReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+func (*CachingDisabledReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
 
 func (m *CachingDisabledReply) GetDisabled() bool {
 	if m != nil {
@@ -1343,7 +1433,7 @@ type ReplicationStateReply struct {
 func (m *ReplicationStateReply) Reset()                    { *m = ReplicationStateReply{} }
 func (m *ReplicationStateReply) String() string            { return proto.CompactTextString(m) }
 func (*ReplicationStateReply) ProtoMessage()               {}
-func (*ReplicationStateReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+func (*ReplicationStateReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
 
 func (m *ReplicationStateReply) GetState() int32 {
 	if m != nil {
@@ -1361,7 +1451,7 @@ type ResponseWrapDataArgs struct {
 func (m *ResponseWrapDataArgs) Reset()                    { *m = ResponseWrapDataArgs{} }
 func (m *ResponseWrapDataArgs) String() string            { return proto.CompactTextString(m) }
 func (*ResponseWrapDataArgs) ProtoMessage()               {}
-func (*ResponseWrapDataArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+func (*ResponseWrapDataArgs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
 
 func (m *ResponseWrapDataArgs) GetData() string {
 	if m != nil {
@@ -1392,7 +1482,7 @@ type ResponseWrapDataReply struct {
 func (m *ResponseWrapDataReply) Reset()                    { *m = ResponseWrapDataReply{} }
 func (m *ResponseWrapDataReply) String() string            { return proto.CompactTextString(m) }
 func (*ResponseWrapDataReply) ProtoMessage()               {}
-func (*ResponseWrapDataReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+func (*ResponseWrapDataReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
 
 func (m *ResponseWrapDataReply) GetWrapInfo() *ResponseWrapInfo {
 	if m != nil {
@@ -1415,7 +1505,7 @@ type MlockEnabledReply struct {
 func (m *MlockEnabledReply) Reset()                    { *m = MlockEnabledReply{} }
 func (m *MlockEnabledReply) String() string            { return proto.CompactTextString(m) }
 func (*MlockEnabledReply) ProtoMessage()               {}
-func (*MlockEnabledReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+func (*MlockEnabledReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
 
 func (m *MlockEnabledReply) GetEnabled() bool {
 	if m != nil {
@@ -1431,7 +1521,7 @@ type LocalMountReply struct {
 func (m *LocalMountReply) Reset()                    { *m = LocalMountReply{} }
 func (m *LocalMountReply) String() string            { return proto.CompactTextString(m) }
 func (*LocalMountReply) ProtoMessage()               {}
-func (*LocalMountReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+func (*LocalMountReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
 
 func (m *LocalMountReply) GetLocal() bool {
 	if m != nil {
@@ -1448,7 +1538,7 @@ type Connection struct {
 func (m *Connection) Reset()                    { *m = Connection{} }
 func (m *Connection) String() string            { return proto.CompactTextString(m) }
 func (*Connection) ProtoMessage()               {}
-func (*Connection) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+func (*Connection) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
 
 func (m *Connection) GetRemoteAddr() string {
 	if m != nil {
@@ -1465,6 +1555,8 @@ func init() {
 	proto.RegisterType((*Request)(nil), "pb.Request")
 	proto.RegisterType((*Alias)(nil), "pb.Alias")
 	proto.RegisterType((*Auth)(nil), "pb.Auth")
+	proto.RegisterType((*MFARequirement)(nil), "pb.MFARequirement")
+	proto.RegisterType((*MFAMethodInfo)(nil), "pb.MFAMethodInfo")
 	proto.RegisterType((*LeaseOptions)(nil), "pb.LeaseOptions")
 	proto.RegisterType((*Secret)(nil), "pb.Secret")
 	proto.RegisterType((*Response)(nil), "pb.Response")
@@ -2361,137 +2453,147 @@ var _SystemView_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("logical/plugin/pb/backend.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 2112 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x5b, 0x6f, 0xdb, 0xc8,
-	0x15, 0x86, 0x24, 0x4b, 0xa2, 0x8e, 0x24, 0x5f, 0x26, 0x4e, 0xca, 0x28, 0xd9, 0x5a, 0xe5, 0x22,
-	0x59, 0x6d, 0xd0, 0xc8, 0x89, 0x7a, 0xcb, 0xb6, 0xd8, 0x2d, 0x5c, 0xdb, 0x9b, 0x75, 0xd7, 0xde,
-	0x35, 0x68, 0xa7, 0xdb, 0xa2, 0x05, 0xb4, 0x63, 0xf2, 0x58, 0x26, 0x4c, 0x91, 0xec, 0x70, 0x68,
-	0x47, 0x4f, 0xfd, 0x17, 0xfd, 0x1b, 0x7d, 0xed, 0x5b, 0x5f, 0x0b, 0xf4, 0xb9, 0xbf, 0xa0, 0xef,
-	0x7d, 0xe8, 0x2f, 0x28, 0xe6, 0x42, 0x6a, 0x28, 0xc9, 0x4d, 0x0a, 0xb4, 0x6f, 0x73, 0x2e, 0x33,
-	0xe7, 0xc2, 0x73, 0xbe, 0x33, 0x43, 0xd8, 0x09, 0xe3, 0x49, 0xe0, 0xd1, 0x70, 0x37, 0x09, 0xb3,
-	0x49, 0x10, 0xed, 0x26, 0x17, 0xbb, 0x17, 0xd4, 0xbb, 0xc6, 0xc8, 0x1f, 0x26, 0x2c, 0xe6, 0x31,
-	0xa9, 0x26, 0x17, 0xbd, 0x9d, 0x49, 0x1c, 0x4f, 0x42, 0xdc, 0x95, 0x9c, 0x8b, 0xec, 0x72, 0x97,
-	0x07, 0x53, 0x4c, 0x39, 0x9d, 0x26, 0x4a, 0xc9, 0x69, 0x42, 0xfd, 0x70, 0x9a, 0xf0, 0x99, 0xd3,
-	0x87, 0xc6, 0x17, 0x48, 0x7d, 0x64, 0xe4, 0x01, 0x34, 0xae, 0xe4, 0xca, 0xae, 0xf4, 0x6b, 0x83,
-	0x96, 0xab, 0x29, 0xe7, 0xb7, 0x00, 0xa7, 0x62, 0xcf, 0x21, 0x63, 0x31, 0x23, 0x0f, 0xc1, 0x42,
-	0xc6, 0xc6, 0x7c, 0x96, 0xa0, 0x5d, 0xe9, 0x57, 0x06, 0x5d, 0xb7, 0x89, 0x8c, 0x9d, 0xcf, 0x12,
-	0x24, 0xdf, 0x01, 0xb1, 0x1c, 0x4f, 0xd3, 0x89, 0x5d, 0xed, 0x57, 0xc4, 0x09, 0xc8, 0xd8, 0x49,
-	0x3a, 0xc9, 0xf7, 0x78, 0xb1, 0x8f, 0x76, 0xad, 0x5f, 0x19, 0xd4, 0xe4, 0x9e, 0xfd, 0xd8, 0x47,
-	0xe7, 0x8f, 0x15, 0xa8, 0x9f, 0x52, 0x7e, 0x95, 0x12, 0x02, 0x6b, 0x2c, 0x8e, 0xb9, 0x36, 0x2e,
-	0xd7, 0x64, 0x00, 0x1b, 0x59, 0x44, 0x33, 0x7e, 0x85, 0x11, 0x0f, 0x3c, 0xca, 0xd1, 0xb7, 0xab,
-	0x52, 0xbc, 0xc8, 0x26, 0x1f, 0x42, 0x37, 0x8c, 0x3d, 0x1a, 0x8e, 0x53, 0x1e, 0x33, 0x3a, 0x11,
-	0x76, 0x84, 0x5e, 0x47, 0x32, 0xcf, 0x14, 0x8f, 0x3c, 0x83, 0xad, 0x14, 0x69, 0x38, 0xbe, 0x65,
-	0x34, 0x29, 0x14, 0xd7, 0xd4, 0x81, 0x42, 0xf0, 0x0d, 0xa3, 0x89, 0xd6, 0x75, 0xfe, 0xd2, 0x80,
-	0xa6, 0x8b, 0xbf, 0xcf, 0x30, 0xe5, 0x64, 0x1d, 0xaa, 0x81, 0x2f, 0xa3, 0x6d, 0xb9, 0xd5, 0xc0,
-	0x27, 0x43, 0x20, 0x2e, 0x26, 0xa1, 0x30, 0x1d, 0xc4, 0xd1, 0x7e, 0x98, 0xa5, 0x1c, 0x99, 0x8e,
-	0x79, 0x85, 0x84, 0x3c, 0x86, 0x56, 0x9c, 0x20, 0x93, 0x3c, 0x99, 0x80, 0x96, 0x3b, 0x67, 0x88,
-	0xc0, 0x13, 0xca, 0xaf, 0xec, 0x35, 0x29, 0x90, 0x6b, 0xc1, 0xf3, 0x29, 0xa7, 0x76, 0x5d, 0xf1,
-	0xc4, 0x9a, 0x38, 0xd0, 0x48, 0xd1, 0x63, 0xc8, 0xed, 0x46, 0xbf, 0x32, 0x68, 0x8f, 0x60, 0x98,
-	0x5c, 0x0c, 0xcf, 0x24, 0xc7, 0xd5, 0x12, 0xf2, 0x18, 0xd6, 0x44, 0x5e, 0xec, 0xa6, 0xd4, 0xb0,
-	0x84, 0xc6, 0x5e, 0xc6, 0xaf, 0x5c, 0xc9, 0x25, 0x23, 0x68, 0xaa, 0x6f, 0x9a, 0xda, 0x56, 0xbf,
-	0x36, 0x68, 0x8f, 0x6c, 0xa1, 0xa0, 0xa3, 0x1c, 0xaa, 0x32, 0x48, 0x0f, 0x23, 0xce, 0x66, 0x6e,
-	0xae, 0x48, 0xbe, 0x07, 0x1d, 0x2f, 0x0c, 0x30, 0xe2, 0x63, 0x1e, 0x5f, 0x63, 0x64, 0xb7, 0xa4,
-	0x47, 0x6d, 0xc5, 0x3b, 0x17, 0x2c, 0x32, 0x82, 0xfb, 0xa6, 0xca, 0x98, 0x7a, 0x1e, 0xa6, 0x69,
-	0xcc, 0x6c, 0x90, 0xba, 0xf7, 0x0c, 0xdd, 0x3d, 0x2d, 0x12, 0xc7, 0xfa, 0x41, 0x9a, 0x84, 0x74,
-	0x36, 0x8e, 0xe8, 0x14, 0xed, 0xb6, 0x3a, 0x56, 0xf3, 0xbe, 0xa2, 0x53, 0x24, 0x3b, 0xd0, 0x9e,
-	0xc6, 0x59, 0xc4, 0xc7, 0x49, 0x1c, 0x44, 0xdc, 0xee, 0x48, 0x0d, 0x90, 0xac, 0x53, 0xc1, 0x21,
-	0x1f, 0x80, 0xa2, 0x54, 0x31, 0x76, 0x55, 0x5e, 0x25, 0x47, 0x96, 0xe3, 0x13, 0x58, 0x57, 0xe2,
-	0xc2, 0x9f, 0x75, 0xa9, 0xd2, 0x95, 0xdc, 0xc2, 0x93, 0x17, 0xd0, 0x92, 0xf5, 0x10, 0x44, 0x97,
-	0xb1, 0xbd, 0x21, 0xf3, 0x76, 0xcf, 0x48, 0x8b, 0xa8, 0x89, 0xa3, 0xe8, 0x32, 0x76, 0xad, 0x5b,
-	0xbd, 0x22, 0x9f, 0xc2, 0xa3, 0x52, 0xbc, 0x0c, 0xa7, 0x34, 0x88, 0x82, 0x68, 0x32, 0xce, 0x52,
-	0x4c, 0xed, 0x4d, 0x59, 0xe1, 0xb6, 0x11, 0xb5, 0x9b, 0x2b, 0xbc, 0x49, 0x31, 0x25, 0x8f, 0xa0,
-	0x25, 0xea, 0x96, 0xcf, 0xc6, 0x81, 0x6f, 0x6f, 0x49, 0x97, 0x2c, 0xc5, 0x38, 0xf2, 0xc9, 0x47,
-	0xb0, 0x91, 0xc4, 0x61, 0xe0, 0xcd, 0xc6, 0xf1, 0x0d, 0x32, 0x16, 0xf8, 0x68, 0x93, 0x7e, 0x65,
-	0x60, 0xb9, 0xeb, 0x8a, 0xfd, 0xb5, 0xe6, 0xae, 0x6a, 0x8d, 0x7b, 0x52, 0x71, 0xa9, 0x35, 0x86,
-	0x00, 0x5e, 0x1c, 0x45, 0xe8, 0xc9, 0xf2, 0xdb, 0x96, 0x11, 0xae, 0x8b, 0x08, 0xf7, 0x0b, 0xae,
-	0x6b, 0x68, 0xf4, 0x3e, 0x87, 0x8e, 0x59, 0x0a, 0x64, 0x13, 0x6a, 0xd7, 0x38, 0xd3, 0xe5, 0x2f,
-	0x96, 0xa4, 0x0f, 0xf5, 0x1b, 0x1a, 0x66, 0x28, 0x4b, 0x5e, 0x17, 0xa2, 0xda, 0xe2, 0x2a, 0xc1,
-	0x4f, 0xab, 0xaf, 0x2a, 0x0e, 0x85, 0xfa, 0x5e, 0x18, 0xd0, 0x74, 0xe1, 0x3b, 0x55, 0xde, 0xfd,
-	0x9d, 0xaa, 0xab, 0xbe, 0x13, 0x81, 0x35, 0x59, 0x29, 0xaa, 0x7f, 0xe4, 0xda, 0xf9, 0x57, 0x0d,
-	0xd6, 0x44, 0x7d, 0x93, 0x1f, 0x41, 0x37, 0x44, 0x9a, 0xe2, 0x38, 0x4e, 0x44, 0x0c, 0xa9, 0xb4,
-	0xd2, 0x1e, 0x6d, 0x0a, 0xcf, 0x8e, 0x85, 0xe0, 0x6b, 0xc5, 0x77, 0x3b, 0xa1, 0x41, 0x09, 0xd4,
-	0x08, 0x22, 0x8e, 0x2c, 0xa2, 0xe1, 0x58, 0xf6, 0x9b, 0xb2, 0xdc, 0xc9, 0x99, 0x07, 0xa2, 0xef,
-	0x16, 0x4b, 0xb5, 0xb6, 0x5c, 0xaa, 0x3d, 0xb0, 0xe4, 0xe7, 0x09, 0x30, 0xd5, 0x78, 0x52, 0xd0,
-	0x64, 0x04, 0xd6, 0x14, 0x39, 0xd5, 0xed, 0x2c, 0xba, 0xee, 0x41, 0xde, 0x96, 0xc3, 0x13, 0x2d,
-	0x50, 0x3d, 0x57, 0xe8, 0x2d, 0x35, 0x5d, 0x63, 0xb9, 0xe9, 0x7a, 0x60, 0x15, 0xf9, 0x6a, 0xaa,
-	0x22, 0xca, 0x69, 0x81, 0xe4, 0x09, 0xb2, 0x20, 0xf6, 0x6d, 0x4b, 0xd6, 0xa2, 0xa6, 0x04, 0x0e,
-	0x47, 0xd9, 0x54, 0x55, 0x69, 0x4b, 0xe1, 0x70, 0x94, 0x4d, 0x97, 0x8b, 0x12, 0x16, 0x8a, 0x72,
-	0x07, 0xea, 0x54, 0x7c, 0x49, 0xd9, 0xa5, 0xed, 0x51, 0x4b, 0xfa, 0x2f, 0x18, 0xae, 0xe2, 0x93,
-	0x21, 0x74, 0x27, 0x2c, 0xce, 0x92, 0xb1, 0x24, 0x31, 0xb5, 0x3b, 0x32, 0x50, 0x43, 0xb1, 0x23,
-	0xe5, 0x7b, 0x4a, 0xdc, 0xfb, 0x19, 0x74, 0x4b, 0xa1, 0xaf, 0xa8, 0xb1, 0x6d, 0xb3, 0xc6, 0x5a,
-	0x66, 0x5d, 0xfd, 0xa9, 0x02, 0x1d, 0xf3, 0x9b, 0x8a, 0xcd, 0xe7, 0xe7, 0xc7, 0x72, 0x73, 0xcd,
-	0x15, 0x4b, 0x01, 0xb8, 0x0c, 0x23, 0xbc, 0xa5, 0x17, 0xa1, 0x3a, 0xc0, 0x72, 0xe7, 0x0c, 0x21,
-	0x0d, 0x22, 0x8f, 0xe1, 0x14, 0x23, 0xae, 0xe7, 0xd1, 0x9c, 0x41, 0x3e, 0x01, 0x08, 0xd2, 0x34,
-	0xc3, 0xb1, 0x18, 0x99, 0x12, 0x94, 0xdb, 0xa3, 0xde, 0x50, 0xcd, 0xd3, 0x61, 0x3e, 0x4f, 0x87,
-	0xe7, 0xf9, 0x3c, 0x75, 0x5b, 0x52, 0x5b, 0xd0, 0x22, 0xef, 0x27, 0xf4, 0xad, 0xf0, 0xa5, 0xae,
-	0xf2, 0xae, 0x28, 0xe7, 0x0f, 0xd0, 0x50, 0x38, 0xfd, 0x7f, 0xad, 0xd3, 0x87, 0x60, 0xa9, 0xb3,
-	0x03, 0x5f, 0xd7, 0x68, 0x53, 0xd2, 0x47, 0xbe, 0xf3, 0xb7, 0x0a, 0x58, 0x2e, 0xa6, 0x49, 0x1c,
-	0xa5, 0x68, 0xcc, 0x91, 0xca, 0x3b, 0xe7, 0x48, 0x75, 0xe5, 0x1c, 0xc9, 0xa7, 0x53, 0xcd, 0x98,
-	0x4e, 0x3d, 0xb0, 0x18, 0xfa, 0x01, 0x43, 0x8f, 0xeb, 0x49, 0x56, 0xd0, 0x42, 0x76, 0x4b, 0x99,
-	0x00, 0xc0, 0x54, 0xb6, 0x40, 0xcb, 0x2d, 0x68, 0xf2, 0xd2, 0x84, 0x5f, 0x35, 0xd8, 0xb6, 0x15,
-	0xfc, 0x2a, 0x77, 0x97, 0xf1, 0xd7, 0xf9, 0x6b, 0x15, 0x36, 0x17, 0xc5, 0x2b, 0x8a, 0x60, 0x1b,
-	0xea, 0xaa, 0x7b, 0x74, 0x05, 0xf1, 0xa5, 0xbe, 0xa9, 0x2d, 0xf4, 0xcd, 0xcf, 0xa1, 0xeb, 0x31,
-	0x94, 0x53, 0xf9, 0x7d, 0xbf, 0x7e, 0x27, 0xdf, 0x20, 0x0b, 0xe0, 0x63, 0xd8, 0x14, 0x5e, 0x26,
-	0xe8, 0xcf, 0xc1, 0x4c, 0x8d, 0xf0, 0x0d, 0xcd, 0x2f, 0xe0, 0xec, 0x19, 0x6c, 0xe5, 0xaa, 0xf3,
-	0xc6, 0x6b, 0x94, 0x74, 0x0f, 0xf3, 0xfe, 0x7b, 0x00, 0x8d, 0xcb, 0x98, 0x4d, 0x29, 0xd7, 0x9d,
-	0xae, 0x29, 0x51, 0x16, 0x85, 0xbf, 0xf2, 0x0a, 0x61, 0xa9, 0xb2, 0xc8, 0x99, 0xe2, 0x62, 0x25,
-	0x3a, 0xbb, 0xb8, 0xf4, 0xc8, 0xae, 0xb7, 0x5c, 0x2b, 0xbf, 0xec, 0x38, 0xbf, 0x86, 0x8d, 0x85,
-	0x39, 0xb7, 0x22, 0x91, 0x73, 0xf3, 0xd5, 0x92, 0xf9, 0xd2, 0xc9, 0xb5, 0x85, 0x93, 0x7f, 0x03,
-	0x5b, 0x5f, 0xd0, 0xc8, 0x0f, 0x51, 0x9f, 0xbf, 0xc7, 0x26, 0x72, 0x12, 0xe8, 0x6b, 0xd7, 0x58,
-	0x5f, 0xa8, 0xba, 0x6e, 0x4b, 0x73, 0x8e, 0x7c, 0xf2, 0x04, 0x9a, 0x4c, 0x69, 0xeb, 0xc2, 0x6b,
-	0x1b, 0x83, 0xd8, 0xcd, 0x65, 0xce, 0xb7, 0x40, 0x4a, 0x47, 0x8b, 0x1b, 0xd7, 0x8c, 0x0c, 0x44,
-	0x01, 0xaa, 0xa2, 0xd0, 0x85, 0xdd, 0x31, 0xeb, 0xc8, 0x2d, 0xa4, 0xa4, 0x0f, 0x35, 0x64, 0x4c,
-	0x9b, 0x90, 0x93, 0x70, 0x7e, 0xbf, 0x75, 0x85, 0xc8, 0xf9, 0x21, 0x6c, 0x9d, 0x25, 0xe8, 0x05,
-	0x34, 0x94, 0x77, 0x53, 0x65, 0x60, 0x07, 0xea, 0x22, 0xc9, 0x79, 0xcf, 0x4a, 0x70, 0x53, 0x62,
-	0xc5, 0x77, 0xbe, 0x05, 0x5b, 0xf9, 0x75, 0xf8, 0x36, 0x48, 0x39, 0x46, 0x1e, 0xee, 0x5f, 0xa1,
-	0x77, 0xfd, 0x3f, 0x8c, 0xfc, 0x06, 0x1e, 0xae, 0xb2, 0x90, 0xfb, 0xd7, 0xf6, 0x04, 0x35, 0xbe,
-	0x8c, 0xb3, 0x48, 0xd9, 0xb0, 0x5c, 0x90, 0xac, 0xcf, 0x05, 0x47, 0x7c, 0x47, 0x14, 0xfb, 0x52,
-	0x0d, 0x89, 0x9a, 0xca, 0xf3, 0x51, 0xbb, 0x3b, 0x1f, 0x7f, 0xae, 0x40, 0xeb, 0x0c, 0x79, 0x96,
-	0xc8, 0x58, 0x1e, 0x41, 0xeb, 0x82, 0xc5, 0xd7, 0xc8, 0xe6, 0xa1, 0x58, 0x8a, 0x71, 0xe4, 0x93,
-	0x97, 0xd0, 0xd8, 0x8f, 0xa3, 0xcb, 0x60, 0x22, 0x6f, 0xea, 0xed, 0xd1, 0x43, 0x85, 0x2e, 0x7a,
-	0xef, 0x50, 0xc9, 0xd4, 0xbc, 0xd3, 0x8a, 0xa4, 0x0f, 0x6d, 0xfd, 0x82, 0x79, 0xf3, 0xe6, 0xe8,
-	0x20, 0x9f, 0xaf, 0x06, 0xab, 0xf7, 0x09, 0xb4, 0x8d, 0x8d, 0xff, 0xd5, 0xb4, 0xf8, 0x2e, 0x80,
-	0xb4, 0xae, 0x72, 0xb4, 0xa9, 0x42, 0xd5, 0x3b, 0x45, 0x68, 0x3b, 0xd0, 0x12, 0xb7, 0x10, 0x25,
-	0x26, 0xb0, 0x66, 0x3c, 0x6c, 0xe4, 0xda, 0x79, 0x02, 0x5b, 0x47, 0xd1, 0x0d, 0x0d, 0x03, 0x9f,
-	0x72, 0xfc, 0x12, 0x67, 0x32, 0x05, 0x4b, 0x1e, 0x38, 0x67, 0xd0, 0xd1, 0x4f, 0x87, 0xf7, 0xf2,
-	0xb1, 0xa3, 0x7d, 0xfc, 0xcf, 0x4d, 0xf4, 0x31, 0x6c, 0xe8, 0x43, 0x8f, 0x03, 0xdd, 0x42, 0x62,
-	0xb6, 0x33, 0xbc, 0x0c, 0xde, 0xea, 0xa3, 0x35, 0xe5, 0xbc, 0x82, 0x4d, 0x43, 0xb5, 0x08, 0xe7,
-	0x1a, 0x67, 0x69, 0xfe, 0xa4, 0x12, 0xeb, 0x3c, 0x03, 0xd5, 0x79, 0x06, 0x1c, 0x58, 0xd7, 0x3b,
-	0x5f, 0x23, 0xbf, 0x23, 0xba, 0x2f, 0x0b, 0x47, 0x5e, 0xa3, 0x3e, 0xfc, 0x29, 0xd4, 0x51, 0x44,
-	0x6a, 0x8e, 0x30, 0x33, 0x03, 0xae, 0x12, 0xaf, 0x30, 0xf8, 0xaa, 0x30, 0x78, 0x9a, 0x29, 0x83,
-	0xef, 0x79, 0x96, 0xf3, 0x61, 0xe1, 0xc6, 0x69, 0xc6, 0xef, 0xfa, 0xa2, 0x4f, 0x60, 0x4b, 0x2b,
-	0x1d, 0x60, 0x88, 0x1c, 0xef, 0x08, 0xe9, 0x29, 0x90, 0x92, 0xda, 0x5d, 0xc7, 0x3d, 0x06, 0xeb,
-	0xfc, 0xfc, 0xb8, 0x90, 0x96, 0xb1, 0xd1, 0xf9, 0x14, 0xb6, 0xce, 0x32, 0x3f, 0x3e, 0x65, 0xc1,
-	0x4d, 0x10, 0xe2, 0x44, 0x19, 0xcb, 0x5f, 0x74, 0x15, 0xe3, 0x45, 0xb7, 0x72, 0x1a, 0x39, 0x03,
-	0x20, 0xa5, 0xed, 0xc5, 0x77, 0x4b, 0x33, 0x3f, 0xd6, 0x2d, 0x2c, 0xd7, 0xce, 0x00, 0x3a, 0xe7,
-	0x54, 0xcc, 0x7b, 0x5f, 0xe9, 0xd8, 0xd0, 0xe4, 0x8a, 0xd6, 0x6a, 0x39, 0xe9, 0x8c, 0x60, 0x7b,
-	0x9f, 0x7a, 0x57, 0x41, 0x34, 0x39, 0x08, 0x52, 0x71, 0xe1, 0xd1, 0x3b, 0x7a, 0x60, 0xf9, 0x9a,
-	0xa1, 0xb7, 0x14, 0xb4, 0xf3, 0x1c, 0xee, 0x1b, 0xef, 0xd6, 0x33, 0x4e, 0xf3, 0x7c, 0x6c, 0x43,
-	0x3d, 0x15, 0x94, 0xdc, 0x51, 0x77, 0x15, 0xe1, 0x7c, 0x05, 0xdb, 0xe6, 0x00, 0x16, 0xd7, 0x8f,
-	0x3c, 0x70, 0x79, 0x31, 0xa8, 0x18, 0x17, 0x03, 0x9d, 0xb3, 0xea, 0x7c, 0x9e, 0x6c, 0x42, 0xed,
-	0x97, 0xdf, 0x9c, 0xeb, 0x62, 0x17, 0x4b, 0xe7, 0x77, 0xc2, 0x7c, 0xf9, 0x3c, 0x65, 0xbe, 0x74,
-	0x3b, 0xa8, 0xbc, 0xcf, 0xed, 0x60, 0x45, 0xbd, 0x3d, 0x87, 0xad, 0x93, 0x30, 0xf6, 0xae, 0x0f,
-	0x23, 0x23, 0x1b, 0x36, 0x34, 0x31, 0x32, 0x93, 0x91, 0x93, 0xce, 0x47, 0xb0, 0x71, 0x1c, 0x7b,
-	0x34, 0x3c, 0x11, 0xcf, 0x8f, 0x22, 0x0b, 0xf2, 0x47, 0x82, 0x56, 0x55, 0x84, 0xf3, 0x1c, 0x60,
-	0xfe, 0x84, 0x12, 0xf0, 0xcb, 0x70, 0x1a, 0x73, 0x1c, 0x53, 0xdf, 0xcf, 0x2b, 0x08, 0x14, 0x6b,
-	0xcf, 0xf7, 0xd9, 0xe8, 0x9f, 0x55, 0x68, 0xfe, 0x42, 0x81, 0x1a, 0xf9, 0x0c, 0xba, 0xa5, 0x11,
-	0x46, 0xee, 0xcb, 0x37, 0xd4, 0xe2, 0xc0, 0xec, 0x3d, 0x58, 0x62, 0x2b, 0x87, 0x5e, 0x40, 0xc7,
-	0x1c, 0x50, 0x44, 0x0e, 0x23, 0xf9, 0x43, 0xa7, 0x27, 0x4f, 0x5a, 0x9e, 0x5e, 0x67, 0xb0, 0xbd,
-	0x6a, 0x74, 0x90, 0xc7, 0x73, 0x0b, 0xcb, 0x63, 0xab, 0xf7, 0xc1, 0x5d, 0xd2, 0x7c, 0xe4, 0x34,
-	0xf7, 0x43, 0xa4, 0x51, 0x96, 0x98, 0x1e, 0xcc, 0x97, 0xe4, 0x25, 0x74, 0x4b, 0xe0, 0xa9, 0xe2,
-	0x5c, 0xc2, 0x53, 0x73, 0xcb, 0x53, 0xa8, 0x4b, 0xc0, 0x26, 0xdd, 0xd2, 0xe4, 0xe8, 0xad, 0x17,
-	0xa4, 0xb2, 0xdd, 0x87, 0x35, 0xf9, 0x7c, 0x34, 0x0c, 0xcb, 0x1d, 0x05, 0x9a, 0x8f, 0xfe, 0x5e,
-	0x81, 0x66, 0xfe, 0xeb, 0xe7, 0x25, 0xac, 0x09, 0x5c, 0x24, 0xf7, 0x0c, 0x68, 0xc9, 0x31, 0xb5,
-	0xb7, 0xbd, 0xc0, 0x54, 0x06, 0x86, 0x50, 0x7b, 0x8d, 0x9c, 0x10, 0x43, 0xa8, 0x01, 0xb2, 0x77,
-	0xaf, 0xcc, 0x2b, 0xf4, 0x4f, 0xb3, 0xb2, 0xbe, 0xc6, 0xb7, 0x92, 0x7e, 0x81, 0x5c, 0x3f, 0x81,
-	0x86, 0x42, 0x1e, 0x95, 0x94, 0x25, 0xcc, 0x52, 0x1f, 0x7f, 0x19, 0xa3, 0x46, 0xff, 0xa8, 0x01,
-	0x9c, 0xcd, 0x52, 0x8e, 0xd3, 0x5f, 0x05, 0x78, 0x4b, 0x9e, 0xc1, 0xc6, 0x01, 0x5e, 0xd2, 0x2c,
-	0xe4, 0xf2, 0x05, 0x21, 0x3a, 0xcc, 0xc8, 0x89, 0xbc, 0x04, 0x15, 0x00, 0xf6, 0x14, 0xda, 0x27,
-	0xf4, 0xed, 0xbb, 0xf5, 0x3e, 0x83, 0x6e, 0x09, 0x97, 0xb4, 0x8b, 0x8b, 0x48, 0xa7, 0x5d, 0x5c,
-	0x46, 0xb0, 0xa7, 0xd0, 0xd4, 0x68, 0x65, 0xda, 0x90, 0xb8, 0x5e, 0x42, 0xb1, 0x1f, 0xc3, 0xc6,
-	0x02, 0x56, 0x99, 0xfa, 0xf2, 0xf7, 0xd4, 0x4a, 0x2c, 0x7b, 0x25, 0x5e, 0x00, 0x65, 0xbc, 0x32,
-	0x37, 0x3e, 0x54, 0x18, 0xb1, 0x0a, 0xd0, 0x5e, 0x97, 0xdf, 0x0e, 0xf2, 0xe5, 0x64, 0x2f, 0x42,
-	0x4a, 0x0e, 0x68, 0xf9, 0x41, 0xab, 0xa0, 0xe9, 0x05, 0x74, 0x4c, 0x54, 0x59, 0x6a, 0xc1, 0x65,
-	0xc8, 0xf9, 0x3e, 0xc0, 0x1c, 0x58, 0x4c, 0x7d, 0x59, 0x1e, 0x0b, 0x98, 0x73, 0xd1, 0x90, 0xaf,
-	0x8d, 0x1f, 0xfc, 0x3b, 0x00, 0x00, 0xff, 0xff, 0x3f, 0x7b, 0x3e, 0xd0, 0xf0, 0x15, 0x00, 0x00,
+	// 2266 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x5f, 0x73, 0xdb, 0xc6,
+	0x11, 0x1f, 0xfe, 0x07, 0x97, 0xa4, 0xfe, 0x9c, 0x65, 0x07, 0xa6, 0x9d, 0x8a, 0x45, 0x6a, 0x47,
+	0xf1, 0xd4, 0xb4, 0xcd, 0xf4, 0x8f, 0xd3, 0x4c, 0xd2, 0x51, 0x65, 0xd9, 0x51, 0x63, 0x25, 0x1a,
+	0x48, 0x6e, 0xda, 0x69, 0x66, 0x98, 0x13, 0x70, 0xa4, 0x50, 0x81, 0x00, 0x72, 0x38, 0xd8, 0xe6,
+	0x53, 0xbf, 0x45, 0xbf, 0x46, 0x5f, 0xfb, 0xd6, 0xd7, 0xce, 0xf4, 0xa5, 0x33, 0x9d, 0x7e, 0x82,
+	0xbe, 0xf7, 0x33, 0x74, 0x6e, 0xef, 0x00, 0x1e, 0x48, 0xaa, 0x76, 0xa7, 0xcd, 0xdb, 0xed, 0x9f,
+	0xbb, 0xbd, 0x5d, 0xec, 0xfe, 0x76, 0x0f, 0xb0, 0x1b, 0xc6, 0xd3, 0xc0, 0xa3, 0xe1, 0x83, 0x24,
+	0xcc, 0xa6, 0x41, 0xf4, 0x20, 0x39, 0x7f, 0x70, 0x4e, 0xbd, 0x4b, 0x16, 0xf9, 0xc3, 0x84, 0xc7,
+	0x22, 0x26, 0xd5, 0xe4, 0xbc, 0xbf, 0x3b, 0x8d, 0xe3, 0x69, 0xc8, 0x1e, 0x20, 0xe7, 0x3c, 0x9b,
+	0x3c, 0x10, 0xc1, 0x8c, 0xa5, 0x82, 0xce, 0x12, 0xa5, 0xe4, 0xb4, 0xa0, 0x71, 0x38, 0x4b, 0xc4,
+	0xdc, 0x19, 0x40, 0xf3, 0x33, 0x46, 0x7d, 0xc6, 0xc9, 0x0d, 0x68, 0x5e, 0xe0, 0xca, 0xae, 0x0c,
+	0x6a, 0x7b, 0x6d, 0x57, 0x53, 0xce, 0x6f, 0x01, 0x4e, 0xe4, 0x9e, 0x43, 0xce, 0x63, 0x4e, 0x6e,
+	0x82, 0xc5, 0x38, 0x1f, 0x8b, 0x79, 0xc2, 0xec, 0xca, 0xa0, 0xb2, 0xd7, 0x73, 0x5b, 0x8c, 0xf3,
+	0xb3, 0x79, 0xc2, 0xc8, 0x3b, 0x20, 0x97, 0xe3, 0x59, 0x3a, 0xb5, 0xab, 0x83, 0x8a, 0x3c, 0x81,
+	0x71, 0x7e, 0x9c, 0x4e, 0xf3, 0x3d, 0x5e, 0xec, 0x33, 0xbb, 0x36, 0xa8, 0xec, 0xd5, 0x70, 0xcf,
+	0x41, 0xec, 0x33, 0xe7, 0x0f, 0x15, 0x68, 0x9c, 0x50, 0x71, 0x91, 0x12, 0x02, 0x75, 0x1e, 0xc7,
+	0x42, 0x1b, 0xc7, 0x35, 0xd9, 0x83, 0xcd, 0x2c, 0xa2, 0x99, 0xb8, 0x60, 0x91, 0x08, 0x3c, 0x2a,
+	0x98, 0x6f, 0x57, 0x51, 0xbc, 0xcc, 0x26, 0xef, 0x41, 0x2f, 0x8c, 0x3d, 0x1a, 0x8e, 0x53, 0x11,
+	0x73, 0x3a, 0x95, 0x76, 0xa4, 0x5e, 0x17, 0x99, 0xa7, 0x8a, 0x47, 0xee, 0xc1, 0x76, 0xca, 0x68,
+	0x38, 0x7e, 0xc5, 0x69, 0x52, 0x28, 0xd6, 0xd5, 0x81, 0x52, 0xf0, 0x15, 0xa7, 0x89, 0xd6, 0x75,
+	0xfe, 0xdc, 0x84, 0x96, 0xcb, 0xbe, 0xcd, 0x58, 0x2a, 0xc8, 0x06, 0x54, 0x03, 0x1f, 0xbd, 0x6d,
+	0xbb, 0xd5, 0xc0, 0x27, 0x43, 0x20, 0x2e, 0x4b, 0x42, 0x69, 0x3a, 0x88, 0xa3, 0x83, 0x30, 0x4b,
+	0x05, 0xe3, 0xda, 0xe7, 0x35, 0x12, 0x72, 0x1b, 0xda, 0x71, 0xc2, 0x38, 0xf2, 0x30, 0x00, 0x6d,
+	0x77, 0xc1, 0x90, 0x8e, 0x27, 0x54, 0x5c, 0xd8, 0x75, 0x14, 0xe0, 0x5a, 0xf2, 0x7c, 0x2a, 0xa8,
+	0xdd, 0x50, 0x3c, 0xb9, 0x26, 0x0e, 0x34, 0x53, 0xe6, 0x71, 0x26, 0xec, 0xe6, 0xa0, 0xb2, 0xd7,
+	0x19, 0xc1, 0x30, 0x39, 0x1f, 0x9e, 0x22, 0xc7, 0xd5, 0x12, 0x72, 0x1b, 0xea, 0x32, 0x2e, 0x76,
+	0x0b, 0x35, 0x2c, 0xa9, 0xb1, 0x9f, 0x89, 0x0b, 0x17, 0xb9, 0x64, 0x04, 0x2d, 0xf5, 0x4d, 0x53,
+	0xdb, 0x1a, 0xd4, 0xf6, 0x3a, 0x23, 0x5b, 0x2a, 0x68, 0x2f, 0x87, 0x2a, 0x0d, 0xd2, 0xc3, 0x48,
+	0xf0, 0xb9, 0x9b, 0x2b, 0x92, 0xef, 0x43, 0xd7, 0x0b, 0x03, 0x16, 0x89, 0xb1, 0x88, 0x2f, 0x59,
+	0x64, 0xb7, 0xf1, 0x46, 0x1d, 0xc5, 0x3b, 0x93, 0x2c, 0x32, 0x82, 0xeb, 0xa6, 0xca, 0x98, 0x7a,
+	0x1e, 0x4b, 0xd3, 0x98, 0xdb, 0x80, 0xba, 0xd7, 0x0c, 0xdd, 0x7d, 0x2d, 0x92, 0xc7, 0xfa, 0x41,
+	0x9a, 0x84, 0x74, 0x3e, 0x8e, 0xe8, 0x8c, 0xd9, 0x1d, 0x75, 0xac, 0xe6, 0x7d, 0x41, 0x67, 0x8c,
+	0xec, 0x42, 0x67, 0x16, 0x67, 0x91, 0x18, 0x27, 0x71, 0x10, 0x09, 0xbb, 0x8b, 0x1a, 0x80, 0xac,
+	0x13, 0xc9, 0x21, 0xef, 0x82, 0xa2, 0x54, 0x32, 0xf6, 0x54, 0x5c, 0x91, 0x83, 0xe9, 0x78, 0x07,
+	0x36, 0x94, 0xb8, 0xb8, 0xcf, 0x06, 0xaa, 0xf4, 0x90, 0x5b, 0xdc, 0xe4, 0x21, 0xb4, 0x31, 0x1f,
+	0x82, 0x68, 0x12, 0xdb, 0x9b, 0x18, 0xb7, 0x6b, 0x46, 0x58, 0x64, 0x4e, 0x1c, 0x45, 0x93, 0xd8,
+	0xb5, 0x5e, 0xe9, 0x15, 0xf9, 0x04, 0x6e, 0x95, 0xfc, 0xe5, 0x6c, 0x46, 0x83, 0x28, 0x88, 0xa6,
+	0xe3, 0x2c, 0x65, 0xa9, 0xbd, 0x85, 0x19, 0x6e, 0x1b, 0x5e, 0xbb, 0xb9, 0xc2, 0x8b, 0x94, 0xa5,
+	0xe4, 0x16, 0xb4, 0x65, 0xde, 0x8a, 0xf9, 0x38, 0xf0, 0xed, 0x6d, 0xbc, 0x92, 0xa5, 0x18, 0x47,
+	0x3e, 0x79, 0x1f, 0x36, 0x93, 0x38, 0x0c, 0xbc, 0xf9, 0x38, 0x7e, 0xc9, 0x38, 0x0f, 0x7c, 0x66,
+	0x93, 0x41, 0x65, 0xcf, 0x72, 0x37, 0x14, 0xfb, 0x4b, 0xcd, 0x5d, 0x57, 0x1a, 0xd7, 0x50, 0x71,
+	0xa5, 0x34, 0x86, 0x00, 0x5e, 0x1c, 0x45, 0xcc, 0xc3, 0xf4, 0xdb, 0x41, 0x0f, 0x37, 0xa4, 0x87,
+	0x07, 0x05, 0xd7, 0x35, 0x34, 0xfa, 0x4f, 0xa1, 0x6b, 0xa6, 0x02, 0xd9, 0x82, 0xda, 0x25, 0x9b,
+	0xeb, 0xf4, 0x97, 0x4b, 0x32, 0x80, 0xc6, 0x4b, 0x1a, 0x66, 0x0c, 0x53, 0x5e, 0x27, 0xa2, 0xda,
+	0xe2, 0x2a, 0xc1, 0xcf, 0xaa, 0x8f, 0x2b, 0xce, 0xdf, 0x2b, 0xd0, 0xd8, 0x0f, 0x03, 0x9a, 0x2e,
+	0x7d, 0xa8, 0xca, 0x9b, 0x3f, 0x54, 0x75, 0xdd, 0x87, 0x22, 0x50, 0xc7, 0x54, 0x51, 0x05, 0x84,
+	0x6b, 0xf2, 0x21, 0x58, 0x33, 0x26, 0x28, 0xd6, 0x4a, 0x1d, 0x53, 0xfa, 0x1d, 0xcc, 0x79, 0x69,
+	0x76, 0x78, 0xac, 0x25, 0x2a, 0xa3, 0x0b, 0xc5, 0xfe, 0xc7, 0xd0, 0x2b, 0x89, 0xd6, 0x78, 0xb8,
+	0x63, 0x7a, 0xd8, 0x36, 0xbd, 0xfa, 0x5b, 0x1d, 0xea, 0xb2, 0xa4, 0xc8, 0x8f, 0xa1, 0x17, 0x32,
+	0x9a, 0xb2, 0x71, 0x9c, 0xc8, 0xb0, 0xa5, 0xb8, 0xbd, 0x33, 0xda, 0x92, 0xf6, 0x9f, 0x4b, 0xc1,
+	0x97, 0x8a, 0xef, 0x76, 0x43, 0x83, 0x92, 0x40, 0x15, 0x44, 0x82, 0xf1, 0x88, 0x86, 0x63, 0xbc,
+	0xb6, 0xb2, 0xd0, 0xcd, 0x99, 0x4f, 0x64, 0xa9, 0x2f, 0x57, 0x47, 0x6d, 0xb5, 0x3a, 0xfa, 0x60,
+	0x61, 0x46, 0x04, 0x2c, 0xd5, 0x10, 0x56, 0xd0, 0x64, 0x64, 0x44, 0xa5, 0x81, 0x51, 0xb9, 0x91,
+	0x23, 0xc1, 0x55, 0x41, 0x59, 0xa9, 0xf3, 0xe6, 0x6a, 0x9d, 0xf7, 0xc1, 0x2a, 0xbe, 0x50, 0x4b,
+	0xe5, 0x6d, 0x4e, 0xcb, 0xe6, 0x91, 0x30, 0x1e, 0xc4, 0xbe, 0x6d, 0x61, 0xfa, 0x6b, 0x4a, 0x42,
+	0x7f, 0x94, 0xcd, 0x54, 0x61, 0xb4, 0x15, 0xf4, 0x47, 0xd9, 0x6c, 0xb5, 0x0e, 0x60, 0xa9, 0x0e,
+	0x76, 0xa1, 0x41, 0xe5, 0x47, 0x44, 0x60, 0xe8, 0x8c, 0xda, 0xc5, 0x57, 0x75, 0x15, 0x9f, 0x0c,
+	0xa1, 0x37, 0xe5, 0x71, 0x96, 0x8c, 0x91, 0x64, 0xa9, 0xdd, 0x45, 0x47, 0x0d, 0xc5, 0x2e, 0xca,
+	0xf7, 0x95, 0x58, 0xa2, 0xc9, 0x79, 0x9c, 0x45, 0xfe, 0xd8, 0x0b, 0x7c, 0x9e, 0xda, 0x3d, 0x0c,
+	0x19, 0x20, 0xeb, 0x40, 0x72, 0xc8, 0xc7, 0xb0, 0x39, 0x9b, 0xd0, 0x31, 0x67, 0xdf, 0x66, 0x01,
+	0x67, 0x33, 0x16, 0x09, 0xc4, 0x8b, 0xce, 0x88, 0xc8, 0x23, 0x8f, 0x9f, 0xee, 0xbb, 0x0b, 0x89,
+	0xbb, 0x31, 0x9b, 0x50, 0x83, 0xfe, 0xdf, 0x52, 0xea, 0x77, 0xb0, 0x51, 0x3e, 0x9e, 0xfc, 0x00,
+	0x36, 0xf2, 0xbb, 0xb0, 0x54, 0x8c, 0x8b, 0xe6, 0xd3, 0xd5, 0x66, 0x59, 0x2a, 0x8e, 0x7c, 0x32,
+	0x82, 0x8e, 0xd4, 0x9a, 0x31, 0x71, 0x11, 0xfb, 0x29, 0x76, 0xc6, 0xce, 0x68, 0x5b, 0xdf, 0xf6,
+	0x18, 0xb9, 0x88, 0x5c, 0x30, 0x9b, 0x50, 0x45, 0xa6, 0xce, 0xd7, 0xd0, 0x2b, 0x09, 0x8b, 0xaa,
+	0xaa, 0x18, 0x55, 0x45, 0xa0, 0x8e, 0x95, 0xaa, 0x6e, 0x8a, 0x6b, 0x99, 0xb7, 0xf2, 0x23, 0x8e,
+	0x13, 0x9a, 0xa6, 0x45, 0x23, 0xb7, 0xdc, 0xae, 0x64, 0x9e, 0x68, 0x9e, 0xf3, 0xc7, 0x0a, 0x74,
+	0xcd, 0xdc, 0x97, 0x61, 0x38, 0x3b, 0x7b, 0x8e, 0x87, 0xd7, 0x5c, 0xb9, 0x94, 0xbd, 0x90, 0xb3,
+	0x88, 0xbd, 0xa2, 0xe7, 0xa1, 0x32, 0x60, 0xb9, 0x0b, 0x86, 0x94, 0x06, 0x91, 0xa7, 0xc3, 0xaf,
+	0x46, 0x85, 0x05, 0x83, 0x7c, 0x04, 0x10, 0xa4, 0x69, 0xc6, 0xc6, 0x72, 0x9a, 0xc1, 0x7e, 0xd9,
+	0x19, 0xf5, 0x87, 0x6a, 0xd4, 0x19, 0xe6, 0xa3, 0xce, 0xf0, 0x2c, 0x1f, 0x75, 0xdc, 0x36, 0x6a,
+	0x4b, 0x5a, 0xe6, 0xe7, 0x31, 0x7d, 0x2d, 0xef, 0xd2, 0x50, 0xf9, 0xa9, 0x28, 0xe7, 0xf7, 0xd0,
+	0x54, 0x2d, 0xf4, 0x3b, 0xad, 0xe7, 0x9b, 0x60, 0xa9, 0xb3, 0x03, 0x5f, 0xd7, 0x72, 0x0b, 0xe9,
+	0x23, 0xdf, 0xf9, 0x6b, 0x05, 0x2c, 0x97, 0xa5, 0x49, 0x1c, 0xa5, 0xcc, 0x68, 0xf1, 0x95, 0x37,
+	0xb6, 0xf8, 0xea, 0xda, 0x16, 0x9f, 0x0f, 0x0e, 0x35, 0x63, 0x70, 0xe8, 0x83, 0xc5, 0x99, 0x1f,
+	0x70, 0xe6, 0x09, 0x3d, 0x64, 0x14, 0xb4, 0x94, 0xbd, 0xa2, 0x5c, 0xf6, 0xa6, 0x14, 0xa1, 0xa2,
+	0xed, 0x16, 0x34, 0x79, 0x64, 0x76, 0x46, 0x35, 0x73, 0xec, 0xa8, 0xce, 0xa8, 0xae, 0xbb, 0xda,
+	0x1a, 0x9d, 0xbf, 0x54, 0x61, 0x6b, 0x59, 0xbc, 0x26, 0x09, 0x76, 0xa0, 0xa1, 0x50, 0x46, 0xd7,
+	0x82, 0x58, 0xc1, 0x97, 0xda, 0x12, 0xbe, 0xfc, 0x1c, 0x7a, 0x1e, 0x67, 0x38, 0x30, 0xbd, 0xed,
+	0xd7, 0xef, 0xe6, 0x1b, 0x30, 0x01, 0x3e, 0x80, 0x2d, 0x79, 0xcb, 0x84, 0xf9, 0x8b, 0x36, 0xa3,
+	0xa6, 0xab, 0x4d, 0xcd, 0x2f, 0x1a, 0xcd, 0x3d, 0xd8, 0xce, 0x55, 0x17, 0x00, 0xd5, 0x2c, 0xe9,
+	0x1e, 0xe6, 0x38, 0x75, 0x03, 0x9a, 0x93, 0x98, 0xcf, 0xa8, 0xd0, 0x88, 0xa8, 0x29, 0x99, 0x16,
+	0xc5, 0x7d, 0x71, 0xba, 0xb3, 0x54, 0x5a, 0xe4, 0x4c, 0x39, 0xf3, 0x4a, 0x04, 0x2c, 0xe6, 0x51,
+	0x44, 0x47, 0xcb, 0xb5, 0xf2, 0x39, 0xd4, 0xf9, 0x35, 0x6c, 0x2e, 0x8d, 0x20, 0x6b, 0x02, 0xb9,
+	0x30, 0x5f, 0x2d, 0x99, 0x2f, 0x9d, 0x5c, 0x5b, 0x3a, 0xf9, 0x37, 0xb0, 0xfd, 0x19, 0x8d, 0xfc,
+	0x90, 0xe9, 0xf3, 0xf7, 0xf9, 0x14, 0x7b, 0xb4, 0x9e, 0x88, 0x73, 0xb8, 0xe9, 0xb9, 0x6d, 0xcd,
+	0x39, 0xf2, 0xc9, 0x1d, 0x68, 0x69, 0x34, 0xd2, 0x89, 0xd7, 0x31, 0x66, 0x24, 0x37, 0x97, 0x39,
+	0xdf, 0x00, 0x29, 0x1d, 0x2d, 0x87, 0xe1, 0x39, 0xd9, 0x93, 0x09, 0xa8, 0x92, 0x42, 0x27, 0x76,
+	0xd7, 0xcc, 0x23, 0xb7, 0x90, 0x92, 0x01, 0xd4, 0x18, 0xe7, 0xda, 0x04, 0x0e, 0x29, 0x8b, 0xa7,
+	0x87, 0x2b, 0x45, 0xce, 0x8f, 0x60, 0xfb, 0x34, 0x61, 0x5e, 0x40, 0x43, 0x7c, 0x36, 0x28, 0x03,
+	0xbb, 0xd0, 0x90, 0x41, 0xce, 0x6b, 0x16, 0x9b, 0x80, 0x12, 0x2b, 0xbe, 0xf3, 0x0d, 0xd8, 0xea,
+	0x5e, 0x87, 0xaf, 0x83, 0x54, 0xb0, 0xc8, 0x63, 0x07, 0x17, 0xcc, 0xbb, 0xfc, 0x3f, 0x7a, 0xfe,
+	0x12, 0x6e, 0xae, 0xb3, 0x90, 0xdf, 0xaf, 0xe3, 0x49, 0x6a, 0x3c, 0x91, 0xfd, 0x06, 0x6d, 0x58,
+	0x2e, 0x20, 0xeb, 0xa9, 0xe4, 0xc8, 0xef, 0xc8, 0xe4, 0xbe, 0x54, 0x43, 0xa2, 0xa6, 0xf2, 0x78,
+	0xd4, 0xae, 0x8e, 0xc7, 0x9f, 0x2a, 0xd0, 0x3e, 0x65, 0x22, 0x4b, 0xd0, 0x97, 0x5b, 0xd0, 0x3e,
+	0xe7, 0xf1, 0x25, 0xe3, 0x0b, 0x57, 0x2c, 0xc5, 0x38, 0xf2, 0xc9, 0x23, 0x68, 0x1e, 0xc4, 0xd1,
+	0x24, 0x98, 0xea, 0x56, 0x71, 0x53, 0xa1, 0x8b, 0xde, 0x3b, 0x54, 0x32, 0x35, 0x17, 0x68, 0x45,
+	0x32, 0x80, 0x8e, 0x7e, 0x5c, 0xbe, 0x78, 0x71, 0xf4, 0x24, 0x9f, 0x43, 0x0c, 0x56, 0xff, 0x23,
+	0xe8, 0x18, 0x1b, 0xff, 0xab, 0xbe, 0xf7, 0x3d, 0x00, 0xb4, 0xae, 0x62, 0xb4, 0xa5, 0x5c, 0xd5,
+	0x3b, 0xa5, 0x6b, 0xbb, 0xd0, 0x96, 0xf3, 0xa1, 0x12, 0xe7, 0x3d, 0x49, 0x39, 0x85, 0x6b, 0xe7,
+	0x0e, 0x6c, 0x1f, 0x45, 0x2f, 0x69, 0x18, 0xf8, 0x54, 0xb0, 0xcf, 0xd9, 0x1c, 0x43, 0xb0, 0x72,
+	0x03, 0xe7, 0x14, 0xba, 0xfa, 0x55, 0xf7, 0x56, 0x77, 0xec, 0xea, 0x3b, 0xfe, 0xe7, 0x22, 0xfa,
+	0x00, 0x36, 0xf5, 0xa1, 0xcf, 0x03, 0x5d, 0x42, 0x72, 0x06, 0xe2, 0x6c, 0x12, 0xbc, 0xd6, 0x47,
+	0x6b, 0xca, 0x79, 0x0c, 0x5b, 0x86, 0x6a, 0xe1, 0xce, 0x25, 0x9b, 0xa7, 0xf9, 0x6b, 0x57, 0xae,
+	0xf3, 0x08, 0x54, 0x17, 0x11, 0x70, 0x60, 0x43, 0xef, 0x7c, 0xc6, 0xc4, 0x15, 0xde, 0x7d, 0x5e,
+	0x5c, 0xe4, 0x19, 0xd3, 0x87, 0xdf, 0x85, 0x06, 0x93, 0x9e, 0x9a, 0x2d, 0xcc, 0x8c, 0x80, 0xab,
+	0xc4, 0x6b, 0x0c, 0x3e, 0x2e, 0x0c, 0x9e, 0x64, 0xca, 0xe0, 0x5b, 0x9e, 0xe5, 0xbc, 0x57, 0x5c,
+	0xe3, 0x24, 0x13, 0x57, 0x7d, 0xd1, 0x3b, 0xb0, 0xad, 0x95, 0x9e, 0xb0, 0x90, 0x09, 0x76, 0x85,
+	0x4b, 0x77, 0x81, 0x94, 0xd4, 0xae, 0x3a, 0xee, 0x36, 0x58, 0x67, 0x67, 0xcf, 0x0b, 0x69, 0x19,
+	0x1b, 0x9d, 0x4f, 0x60, 0xfb, 0x34, 0xf3, 0xe3, 0x13, 0x1e, 0xbc, 0x0c, 0x42, 0x36, 0x55, 0xc6,
+	0xf2, 0xc7, 0x76, 0xc5, 0x78, 0x6c, 0xaf, 0xed, 0x46, 0xce, 0x1e, 0x90, 0xd2, 0xf6, 0xe2, 0xbb,
+	0xa5, 0x99, 0x1f, 0xeb, 0x12, 0xc6, 0xb5, 0xb3, 0x07, 0xdd, 0x33, 0x2a, 0xfb, 0xbd, 0xaf, 0x74,
+	0x6c, 0x68, 0x09, 0x45, 0x6b, 0xb5, 0x9c, 0x74, 0x46, 0xb0, 0x73, 0x40, 0xbd, 0x8b, 0x20, 0x9a,
+	0x3e, 0x09, 0x52, 0x39, 0xf0, 0xe8, 0x1d, 0x7d, 0xb0, 0x7c, 0xcd, 0xd0, 0x5b, 0x0a, 0xda, 0xb9,
+	0x0f, 0xd7, 0x8d, 0x5f, 0x0a, 0xa7, 0x82, 0xe6, 0xf1, 0xd8, 0x81, 0x46, 0x2a, 0x29, 0xdc, 0xd1,
+	0x70, 0x15, 0xe1, 0x7c, 0x01, 0x3b, 0x66, 0x03, 0x96, 0xe3, 0x47, 0xee, 0x38, 0x0e, 0x06, 0x15,
+	0x63, 0x30, 0xd0, 0x31, 0xab, 0x2e, 0xfa, 0xc9, 0x16, 0xd4, 0x7e, 0xf9, 0xd5, 0x99, 0x4e, 0x76,
+	0xb9, 0x74, 0xbe, 0x96, 0xe6, 0xcb, 0xe7, 0x29, 0xf3, 0xa5, 0xe9, 0xa0, 0xf2, 0x36, 0xd3, 0xc1,
+	0x9a, 0x7c, 0xbb, 0x0f, 0xdb, 0xc7, 0x61, 0xec, 0x5d, 0x1e, 0x46, 0x46, 0x34, 0x6c, 0x68, 0xb1,
+	0xc8, 0x0c, 0x46, 0x4e, 0x3a, 0xef, 0xc3, 0xe6, 0xf3, 0xd8, 0xa3, 0xe1, 0xb1, 0x7c, 0x18, 0x16,
+	0x51, 0xc0, 0x7f, 0x3c, 0x5a, 0x55, 0x11, 0xce, 0x7d, 0x80, 0xc5, 0xeb, 0x56, 0xc2, 0x2f, 0x67,
+	0xb3, 0x58, 0xb0, 0x31, 0xf5, 0xfd, 0x3c, 0x83, 0x40, 0xb1, 0xf6, 0x7d, 0x9f, 0x8f, 0xfe, 0x55,
+	0x85, 0xd6, 0x2f, 0x14, 0xa8, 0x91, 0x4f, 0xa1, 0x57, 0x6a, 0x61, 0xe4, 0x3a, 0x3e, 0x6f, 0x97,
+	0x1b, 0x66, 0xff, 0xc6, 0x0a, 0x5b, 0x5d, 0xe8, 0x21, 0x74, 0xcd, 0x06, 0x45, 0xb0, 0x19, 0xe1,
+	0xbf, 0xb6, 0x3e, 0x9e, 0xb4, 0xda, 0xbd, 0x4e, 0x61, 0x67, 0x5d, 0xeb, 0x20, 0xb7, 0x17, 0x16,
+	0x56, 0xdb, 0x56, 0xff, 0xdd, 0xab, 0xa4, 0x79, 0xcb, 0x69, 0x1d, 0x84, 0x8c, 0x46, 0x59, 0x62,
+	0xde, 0x60, 0xb1, 0x24, 0x8f, 0xa0, 0x57, 0x02, 0x4f, 0xe5, 0xe7, 0x0a, 0x9e, 0x9a, 0x5b, 0xee,
+	0x42, 0x03, 0x01, 0x9b, 0xf4, 0x4a, 0x9d, 0xa3, 0xbf, 0x51, 0x90, 0xca, 0xf6, 0x00, 0xea, 0xf8,
+	0xb0, 0x37, 0x0c, 0xe3, 0x8e, 0x02, 0xcd, 0x47, 0xff, 0xa8, 0x40, 0x2b, 0xff, 0x2b, 0xf7, 0x08,
+	0xea, 0x12, 0x17, 0xc9, 0x35, 0x03, 0x5a, 0x72, 0x4c, 0xed, 0xef, 0x2c, 0x31, 0x95, 0x81, 0x21,
+	0xd4, 0x9e, 0x31, 0x41, 0x88, 0x21, 0xd4, 0x00, 0xd9, 0xbf, 0x56, 0xe6, 0x15, 0xfa, 0x27, 0x59,
+	0x59, 0x5f, 0xe3, 0x5b, 0x49, 0xbf, 0x40, 0xae, 0x9f, 0x42, 0x53, 0x21, 0x8f, 0x0a, 0xca, 0x0a,
+	0x66, 0xa9, 0x8f, 0xbf, 0x8a, 0x51, 0xa3, 0x7f, 0xd6, 0x00, 0x4e, 0xe7, 0xa9, 0x60, 0xb3, 0x5f,
+	0x05, 0xec, 0x15, 0xb9, 0x07, 0x9b, 0x4f, 0xd8, 0x84, 0x66, 0xa1, 0xc0, 0x17, 0x84, 0xac, 0x30,
+	0x23, 0x26, 0x38, 0x04, 0x15, 0x00, 0x76, 0x17, 0x3a, 0xc7, 0xf4, 0xf5, 0x9b, 0xf5, 0x3e, 0x85,
+	0x5e, 0x09, 0x97, 0xf4, 0x15, 0x97, 0x91, 0x4e, 0x5f, 0x71, 0x15, 0xc1, 0xee, 0x42, 0x4b, 0xa3,
+	0x95, 0x69, 0x03, 0x71, 0xbd, 0x84, 0x62, 0x3f, 0x81, 0xcd, 0x25, 0xac, 0x32, 0xf5, 0xf1, 0xcf,
+	0xe1, 0x5a, 0x2c, 0x7b, 0x2c, 0x5f, 0x00, 0x65, 0xbc, 0x32, 0x37, 0xde, 0x54, 0x18, 0xb1, 0x0e,
+	0xd0, 0x9e, 0x95, 0xdf, 0x0e, 0xf8, 0x72, 0xb2, 0x97, 0x21, 0x25, 0x07, 0xb4, 0xfc, 0xa0, 0x75,
+	0xd0, 0xf4, 0x10, 0xba, 0x26, 0xaa, 0xac, 0x94, 0xe0, 0x2a, 0xe4, 0xfc, 0x10, 0x60, 0x01, 0x2c,
+	0xa6, 0x3e, 0xa6, 0xc7, 0x12, 0xe6, 0x9c, 0x37, 0xf1, 0xb5, 0xf1, 0xe1, 0xbf, 0x03, 0x00, 0x00,
+	0xff, 0xff, 0x38, 0x90, 0x71, 0x29, 0x8b, 0x17, 0x00, 0x00,
 }
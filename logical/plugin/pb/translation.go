@@ -472,6 +472,7 @@ func LogicalAliasToProtoAlias(a *logical.Alias) *Alias {
 		MountType:     a.MountType,
 		MountAccessor: a.MountAccessor,
 		Name:          a.Name,
+		Metadata:      a.Metadata,
 	}
 }
 
@@ -484,6 +485,47 @@ func ProtoAliasToLogicalAlias(a *Alias) *logical.Alias {
 		MountType:     a.MountType,
 		MountAccessor: a.MountAccessor,
 		Name:          a.Name,
+		Metadata:      a.Metadata,
+	}
+}
+
+func LogicalMFARequirementToProtoMFARequirement(r *logical.MFARequirement) *MFARequirement {
+	if r == nil {
+		return nil
+	}
+
+	methods := make([]*MFAMethodInfo, len(r.MFAMethods))
+	for i, m := range r.MFAMethods {
+		methods[i] = &MFAMethodInfo{
+			Name:         m.Name,
+			Type:         m.Type,
+			UsesPasscode: m.UsesPasscode,
+		}
+	}
+
+	return &MFARequirement{
+		MfaRequestID: r.MFARequestID,
+		MfaMethods:   methods,
+	}
+}
+
+func ProtoMFARequirementToLogicalMFARequirement(r *MFARequirement) *logical.MFARequirement {
+	if r == nil {
+		return nil
+	}
+
+	methods := make([]*logical.MFAMethodInfo, len(r.MfaMethods))
+	for i, m := range r.MfaMethods {
+		methods[i] = &logical.MFAMethodInfo{
+			Name:         m.GetName(),
+			Type:         m.GetType(),
+			UsesPasscode: m.GetUsesPasscode(),
+		}
+	}
+
+	return &logical.MFARequirement{
+		MFARequestID: r.MfaRequestID,
+		MFAMethods:   methods,
 	}
 }
 
@@ -508,18 +550,20 @@ func LogicalAuthToProtoAuth(a *logical.Auth) (*Auth, error) {
 	}
 
 	return &Auth{
-		LeaseOptions: lo,
-		InternalData: string(buf[:]),
-		DisplayName:  a.DisplayName,
-		Policies:     a.Policies,
-		Metadata:     a.Metadata,
-		ClientToken:  a.ClientToken,
-		Accessor:     a.Accessor,
-		Period:       int64(a.Period),
-		NumUses:      int64(a.NumUses),
-		EntityID:     a.EntityID,
-		Alias:        LogicalAliasToProtoAlias(a.Alias),
-		GroupAliases: groupAliases,
+		LeaseOptions:   lo,
+		InternalData:   string(buf[:]),
+		DisplayName:    a.DisplayName,
+		Policies:       a.Policies,
+		Metadata:       a.Metadata,
+		ClientToken:    a.ClientToken,
+		Accessor:       a.Accessor,
+		Period:         int64(a.Period),
+		NumUses:        int64(a.NumUses),
+		EntityID:       a.EntityID,
+		Alias:          LogicalAliasToProtoAlias(a.Alias),
+		GroupAliases:   groupAliases,
+		BoundCidrs:     a.BoundCIDRs,
+		MfaRequirement: LogicalMFARequirementToProtoMFARequirement(a.MFARequirement),
 	}, nil
 }
 
@@ -545,17 +589,19 @@ func ProtoAuthToLogicalAuth(a *Auth) (*logical.Auth, error) {
 	}
 
 	return &logical.Auth{
-		LeaseOptions: lo,
-		InternalData: data,
-		DisplayName:  a.DisplayName,
-		Policies:     a.Policies,
-		Metadata:     a.Metadata,
-		ClientToken:  a.ClientToken,
-		Accessor:     a.Accessor,
-		Period:       time.Duration(a.Period),
-		NumUses:      int(a.NumUses),
-		EntityID:     a.EntityID,
-		Alias:        ProtoAliasToLogicalAlias(a.Alias),
-		GroupAliases: groupAliases,
+		LeaseOptions:   lo,
+		InternalData:   data,
+		DisplayName:    a.DisplayName,
+		Policies:       a.Policies,
+		Metadata:       a.Metadata,
+		ClientToken:    a.ClientToken,
+		Accessor:       a.Accessor,
+		Period:         time.Duration(a.Period),
+		NumUses:        int(a.NumUses),
+		EntityID:       a.EntityID,
+		Alias:          ProtoAliasToLogicalAlias(a.Alias),
+		GroupAliases:   groupAliases,
+		BoundCIDRs:     a.BoundCidrs,
+		MFARequirement: ProtoMFARequirementToLogicalMFARequirement(a.MfaRequirement),
 	}, nil
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/wrapping"
 	"github.com/hashicorp/vault/logical"
@@ -127,6 +128,9 @@ func TestTranslation_Request(t *testing.T) {
 					MountType:     "type",
 					MountAccessor: "accessor",
 					Name:          "name",
+					Metadata: map[string]string{
+						"org": "test",
+					},
 				},
 				GroupAliases: []*logical.Alias{
 					&logical.Alias{
@@ -229,6 +233,9 @@ func TestTranslation_Response(t *testing.T) {
 					MountType:     "type",
 					MountAccessor: "accessor",
 					Name:          "name",
+					Metadata: map[string]string{
+						"org": "test",
+					},
 				},
 				GroupAliases: []*logical.Alias{
 					&logical.Alias{
@@ -267,3 +274,74 @@ func TestTranslation_Response(t *testing.T) {
 		}
 	}
 }
+
+func TestTranslation_Auth(t *testing.T) {
+	tCases := []*logical.Auth{
+		nil,
+		&logical.Auth{
+			InternalData: map[string]interface{}{},
+			Alias: &logical.Alias{
+				MountType:     "type",
+				MountAccessor: "accessor",
+				Name:          "name",
+				Metadata: map[string]string{
+					"org":  "test",
+					"team": "test",
+				},
+			},
+			GroupAliases: []*logical.Alias{
+				&logical.Alias{
+					Name: "group",
+					Metadata: map[string]string{
+						"org": "test",
+					},
+				},
+			},
+			BoundCIDRs: []string{"127.0.0.1/32", "10.0.0.0/8"},
+		},
+		&logical.Auth{
+			InternalData: map[string]interface{}{},
+			GroupAliases: []*logical.Alias{},
+			MFARequirement: &logical.MFARequirement{
+				MFARequestID: "id",
+				MFAMethods: []*logical.MFAMethodInfo{
+					&logical.MFAMethodInfo{
+						Name:         "totp",
+						Type:         "totp",
+						UsesPasscode: true,
+					},
+					&logical.MFAMethodInfo{
+						Name: "okta",
+						Type: "okta",
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range tCases {
+		p, err := LogicalAuthToProtoAuth(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Go through the wire encoding to catch fields missing from the
+		// message
+		buf, err := proto.Marshal(&HandleRequestReply{Response: &Response{Auth: p}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		reply := &HandleRequestReply{}
+		if err := proto.Unmarshal(buf, reply); err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := ProtoAuthToLogicalAuth(reply.Response.Auth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(c, a) {
+			t.Fatalf("Auths did not match: \n%#v, \n%#v", c, a)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/raft"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	deleteOp uint32 = 1 << iota
+	putOp
+)
+
+var (
+	dataBucketName = []byte("data")
+)
+
+// Verify FSM satisfies the correct interfaces
+var _ raft.FSM = (*FSM)(nil)
+
+// LogOperation is a single storage operation carried by a raft log entry
+type LogOperation struct {
+	OpType uint32 `json:"op_type"`
+	Key    string `json:"key"`
+	Value  []byte `json:"value,omitempty"`
+}
+
+// LogData is the payload of a raft log entry. All of the operations in a
+// single entry are applied atomically.
+type LogData struct {
+	Operations []*LogOperation `json:"operations"`
+}
+
+// FSM is the raft finite state machine. It applies committed log entries to
+// a local BoltDB file and serves reads from it.
+type FSM struct {
+	// l protects db, which is swapped out during a snapshot restore
+	l      sync.RWMutex
+	path   string
+	logger log.Logger
+	db     *bolt.DB
+}
+
+// NewFSM opens (or creates) the BoltDB file used to hold the FSM's data in
+// the given directory
+func NewFSM(path string, logger log.Logger) (*FSM, error) {
+	f := &FSM{
+		path:   path,
+		logger: logger,
+	}
+
+	if err := f.openDB(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *FSM) dbPath() string {
+	return filepath.Join(f.path, "vault.db")
+}
+
+func (f *FSM) openDB() error {
+	db, err := bolt.Open(f.dbPath(), 0600, nil)
+	if err != nil {
+		return errwrap.Wrapf("failed to open bolt file: {{err}}", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(dataBucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return errwrap.Wrapf("failed to create bolt bucket: {{err}}", err)
+	}
+
+	f.db = db
+	return nil
+}
+
+// Close closes the underlying BoltDB file
+func (f *FSM) Close() error {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	return f.db.Close()
+}
+
+// Get returns the entry stored at the given key, or nil if there is none
+func (f *FSM) Get(ctx context.Context, key string) (*physical.Entry, error) {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	var entry *physical.Entry
+	err := f.db.View(func(tx *bolt.Tx) error {
+		val := tx.Bucket(dataBucketName).Get([]byte(key))
+		if val == nil {
+			return nil
+		}
+
+		// The value is only valid for the life of the transaction so it
+		// must be copied out
+		entry = &physical.Entry{
+			Key:   key,
+			Value: make([]byte, len(val)),
+		}
+		copy(entry.Value, val)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// List returns the keys directly under the given prefix, with
+// sub-"directories" returned once with a trailing slash
+func (f *FSM) List(ctx context.Context, prefix string) ([]string, error) {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	var keys []string
+	seen := make(map[string]struct{})
+	err := f.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(dataBucketName).Cursor()
+
+		prefixBytes := []byte(prefix)
+		for k, _ := c.Seek(prefixBytes); k != nil && bytes.HasPrefix(k, prefixBytes); k, _ = c.Next() {
+			key := strings.TrimPrefix(string(k), prefix)
+			sep := strings.Index(key, "/")
+			if sep == -1 {
+				keys = append(keys, key)
+				continue
+			}
+
+			key = key[:sep+1]
+			if _, ok := seen[key]; !ok {
+				keys = append(keys, key)
+				seen[key] = struct{}{}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Apply is called once a log entry is committed by a majority of the
+// cluster. The response is either nil or an error.
+func (f *FSM) Apply(l *raft.Log) interface{} {
+	var data LogData
+	if err := jsonutil.DecodeJSON(l.Data, &data); err != nil {
+		f.logger.Error("failed to decode log entry", "index", l.Index, "error", err)
+		return err
+	}
+
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	err := f.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dataBucketName)
+		for _, op := range data.Operations {
+			var err error
+			switch op.OpType {
+			case putOp:
+				err = b.Put([]byte(op.Key), op.Value)
+			case deleteOp:
+				err = b.Delete([]byte(op.Key))
+			default:
+				err = fmt.Errorf("%d is not a supported operation type", op.OpType)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		f.logger.Error("failed to apply log entry", "index", l.Index, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// Snapshot returns a point-in-time view of the FSM. The read transaction it
+// opens is held until the snapshot is persisted or released.
+func (f *FSM) Snapshot() (raft.FSMSnapshot, error) {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	tx, err := f.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fsmSnapshot{tx: tx}, nil
+}
+
+// Restore replaces the contents of the FSM with the given snapshot
+func (f *FSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	tmp, err := ioutil.TempFile(f.path, "vault.db.restore")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, rc); err != nil {
+		tmp.Close()
+		return errwrap.Wrapf("failed to write snapshot: {{err}}", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	if err := f.db.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.dbPath()); err != nil {
+		retErr := multierror.Append(nil, errwrap.Wrapf("failed to move snapshot into place: {{err}}", err))
+
+		// Keep serving the original database
+		if err := f.openDB(); err != nil {
+			retErr = multierror.Append(retErr, errwrap.Wrapf("failed to reopen database: {{err}}", err))
+		}
+		return retErr
+	}
+
+	return f.openDB()
+}
+
+type fsmSnapshot struct {
+	tx *bolt.Tx
+}
+
+// Persist writes a consistent copy of the whole BoltDB file to the sink
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := s.tx.WriteTo(sink); err != nil {
+		sink.Cancel()
+		return err
+	}
+
+	return sink.Close()
+}
+
+// Release ends the read transaction backing the snapshot
+func (s *fsmSnapshot) Release() {
+	s.tx.Rollback()
+}
@@ -0,0 +1,657 @@
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	log "github.com/hashicorp/go-hclog"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/physical"
+)
+
+// Verify RaftBackend satisfies the correct interfaces
+var _ physical.Backend = (*RaftBackend)(nil)
+var _ physical.HABackend = (*RaftBackend)(nil)
+var _ physical.Transactional = (*RaftBackend)(nil)
+var _ physical.Lock = (*RaftLock)(nil)
+
+const (
+	// defaultAddress is the address the raft transport listens on if none
+	// is configured
+	defaultAddress = "127.0.0.1:8202"
+
+	// applyTimeout is how long a write waits to be committed
+	applyTimeout = 10 * time.Second
+
+	// membershipTimeout is how long a configuration change waits to be
+	// committed
+	membershipTimeout = 30 * time.Second
+)
+
+var (
+	// ErrNotLeader is returned when a write is attempted on a node that is
+	// not the raft leader
+	ErrNotLeader = errors.New("node is not the raft leader")
+)
+
+// RaftBackend is a physical backend that replicates its data between Vault
+// nodes using the raft consensus protocol. Each node keeps a full copy of the
+// data in a local BoltDB file, so no external storage is required. Only the
+// raft leader can accept writes, which lines up with Vault's active node
+// since the HA lock is tied to raft leadership.
+type RaftBackend struct {
+	logger log.Logger
+
+	// path is the directory holding the raft log, snapshots and FSM
+	path string
+
+	localID   raft.ServerID
+	transport *raft.NetworkTransport
+	fsm       *FSM
+	raft      *raft.Raft
+	logStore  *raftboltdb.BoltStore
+
+	permitPool *physical.PermitPool
+
+	// l protects the leadership and lock state below
+	l sync.RWMutex
+
+	// leaderLostCh is closed when this node stops being the raft leader; it
+	// is nil while the node is a follower
+	leaderLostCh chan struct{}
+
+	// changeCh is closed and replaced every time leadership changes or a
+	// lock is released, waking up lock waiters
+	changeCh chan struct{}
+
+	// heldLocks tracks the lock keys currently held on this node
+	heldLocks map[string]*RaftLock
+
+	shutdownCh chan struct{}
+}
+
+// Peer is a member of the raft cluster as reported by the raft
+// configuration
+type Peer struct {
+	ID      string `json:"node_id"`
+	Address string `json:"address"`
+	Leader  bool   `json:"leader"`
+	Voter   bool   `json:"voter"`
+}
+
+// NewRaftBackend constructs a RaftBackend using the given directory
+func NewRaftBackend(conf map[string]string, logger log.Logger) (physical.Backend, error) {
+	path, ok := conf["path"]
+	if !ok {
+		return nil, fmt.Errorf("'path' must be set")
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, errwrap.Wrapf("failed to create raft directory: {{err}}", err)
+	}
+
+	localID, err := nodeID(path, conf["node_id"])
+	if err != nil {
+		return nil, err
+	}
+
+	address := defaultAddress
+	if addr, ok := conf["address"]; ok && addr != "" {
+		address = addr
+	}
+
+	bootstrap := false
+	if bootstrapRaw, ok := conf["bootstrap"]; ok && bootstrapRaw != "" {
+		bootstrap, err = strconv.ParseBool(bootstrapRaw)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing bootstrap parameter: {{err}}", err)
+		}
+	}
+
+	maxParInt := physical.DefaultParallelOperations
+	if maxParStr, ok := conf["max_parallel"]; ok {
+		maxParInt, err = strconv.Atoi(maxParStr)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing max_parallel parameter: {{err}}", err)
+		}
+		if logger.IsDebug() {
+			logger.Debug("max_parallel set", "max_parallel", maxParInt)
+		}
+	}
+
+	stdLogger := logger.StandardLogger(&log.StandardLoggerOptions{
+		InferLevels: true,
+	})
+
+	fsm, err := NewFSM(path, logger.Named("fsm"))
+	if err != nil {
+		return nil, err
+	}
+
+	logStore, err := raftboltdb.NewBoltStore(filepath.Join(path, "raft.db"))
+	if err != nil {
+		fsm.Close()
+		return nil, errwrap.Wrapf("failed to create raft log store: {{err}}", err)
+	}
+
+	cachedLogStore, err := raft.NewLogCache(512, logStore)
+	if err != nil {
+		fsm.Close()
+		logStore.Close()
+		return nil, err
+	}
+
+	snapStore, err := raft.NewFileSnapshotStoreWithLogger(path, 2, stdLogger)
+	if err != nil {
+		fsm.Close()
+		logStore.Close()
+		return nil, errwrap.Wrapf("failed to create raft snapshot store: {{err}}", err)
+	}
+
+	// The transport advertises the address it is bound to, so the address
+	// must be one that other nodes can reach
+	transport, err := raft.NewTCPTransportWithLogger(address, nil, 3, 10*time.Second, stdLogger)
+	if err != nil {
+		fsm.Close()
+		logStore.Close()
+		return nil, errwrap.Wrapf("failed to create raft transport: {{err}}", err)
+	}
+
+	b := &RaftBackend{
+		logger:     logger,
+		path:       path,
+		localID:    localID,
+		transport:  transport,
+		fsm:        fsm,
+		logStore:   logStore,
+		permitPool: physical.NewPermitPool(maxParInt),
+		changeCh:   make(chan struct{}),
+		heldLocks:  make(map[string]*RaftLock),
+		shutdownCh: make(chan struct{}),
+	}
+
+	notifyCh := make(chan bool, 1)
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = localID
+	raftConfig.Logger = stdLogger
+	raftConfig.NotifyCh = notifyCh
+
+	if bootstrap {
+		hasState, err := raft.HasExistingState(cachedLogStore, logStore, snapStore)
+		if err != nil {
+			b.close()
+			return nil, err
+		}
+
+		if !hasState {
+			if logger.IsInfo() {
+				logger.Info("bootstrapping new raft cluster", "node_id", localID, "address", transport.LocalAddr())
+			}
+			err = raft.BootstrapCluster(raftConfig, cachedLogStore, logStore, snapStore, transport, raft.Configuration{
+				Servers: []raft.Server{
+					{
+						Suffrage: raft.Voter,
+						ID:       localID,
+						Address:  transport.LocalAddr(),
+					},
+				},
+			})
+			if err != nil {
+				b.close()
+				return nil, errwrap.Wrapf("failed to bootstrap raft cluster: {{err}}", err)
+			}
+		}
+	}
+
+	b.raft, err = raft.NewRaft(raftConfig, fsm, cachedLogStore, logStore, snapStore, transport)
+	if err != nil {
+		b.close()
+		return nil, errwrap.Wrapf("failed to create raft: {{err}}", err)
+	}
+
+	go b.monitorLeadership(notifyCh)
+
+	return b, nil
+}
+
+// nodeID returns the configured node ID, or the one persisted in the raft
+// directory, generating and persisting a new one if neither exists
+func nodeID(path, configured string) (raft.ServerID, error) {
+	if configured != "" {
+		return raft.ServerID(configured), nil
+	}
+
+	idPath := filepath.Join(path, "node-id")
+	existing, err := ioutil.ReadFile(idPath)
+	switch {
+	case err == nil:
+		return raft.ServerID(strings.TrimSpace(string(existing))), nil
+	case !os.IsNotExist(err):
+		return "", errwrap.Wrapf("failed to read node ID: {{err}}", err)
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(idPath, []byte(id), 0600); err != nil {
+		return "", errwrap.Wrapf("failed to persist node ID: {{err}}", err)
+	}
+
+	return raft.ServerID(id), nil
+}
+
+// monitorLeadership tracks raft leadership changes so that HA locks can be
+// granted and revoked
+func (b *RaftBackend) monitorLeadership(notifyCh <-chan bool) {
+	for {
+		select {
+		case isLeader := <-notifyCh:
+			b.l.Lock()
+			switch {
+			case isLeader && b.leaderLostCh == nil:
+				b.leaderLostCh = make(chan struct{})
+			case !isLeader && b.leaderLostCh != nil:
+				close(b.leaderLostCh)
+				b.leaderLostCh = nil
+				b.heldLocks = make(map[string]*RaftLock)
+			}
+			b.notifyChangeLocked()
+			b.l.Unlock()
+
+		case <-b.shutdownCh:
+			return
+		}
+	}
+}
+
+// notifyChangeLocked wakes up anything waiting on changeCh. The write lock
+// must be held.
+func (b *RaftBackend) notifyChangeLocked() {
+	close(b.changeCh)
+	b.changeCh = make(chan struct{})
+}
+
+// Shutdown stops raft and closes the underlying files. The backend cannot be
+// used afterwards.
+func (b *RaftBackend) Shutdown() error {
+	close(b.shutdownCh)
+
+	if err := b.raft.Shutdown().Error(); err != nil {
+		return err
+	}
+
+	return b.close()
+}
+
+func (b *RaftBackend) close() error {
+	b.transport.Close()
+	b.logStore.Close()
+	return b.fsm.Close()
+}
+
+// NodeID returns the raft server ID of this node
+func (b *RaftBackend) NodeID() string {
+	return string(b.localID)
+}
+
+// Address returns the address of this node's raft transport
+func (b *RaftBackend) Address() string {
+	return string(b.transport.LocalAddr())
+}
+
+// Put is used to insert or update an entry
+func (b *RaftBackend) Put(ctx context.Context, entry *physical.Entry) error {
+	b.permitPool.Acquire()
+	defer b.permitPool.Release()
+
+	return b.applyLog(ctx, []*LogOperation{
+		&LogOperation{
+			OpType: putOp,
+			Key:    entry.Key,
+			Value:  entry.Value,
+		},
+	})
+}
+
+// Get is used to fetch an entry
+func (b *RaftBackend) Get(ctx context.Context, key string) (*physical.Entry, error) {
+	b.permitPool.Acquire()
+	defer b.permitPool.Release()
+
+	return b.fsm.Get(ctx, key)
+}
+
+// Delete is used to permanently delete an entry
+func (b *RaftBackend) Delete(ctx context.Context, key string) error {
+	b.permitPool.Acquire()
+	defer b.permitPool.Release()
+
+	return b.applyLog(ctx, []*LogOperation{
+		&LogOperation{
+			OpType: deleteOp,
+			Key:    key,
+		},
+	})
+}
+
+// List is used to list all the keys under a given prefix, up to the next
+// prefix.
+func (b *RaftBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	b.permitPool.Acquire()
+	defer b.permitPool.Release()
+
+	return b.fsm.List(ctx, prefix)
+}
+
+// Transaction applies all of the given operations in a single raft log entry
+// so they are committed atomically
+func (b *RaftBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	if len(txns) == 0 {
+		return nil
+	}
+
+	ops := make([]*LogOperation, 0, len(txns))
+	for _, txn := range txns {
+		op := &LogOperation{
+			Key: txn.Entry.Key,
+		}
+		switch txn.Operation {
+		case physical.PutOperation:
+			op.OpType = putOp
+			op.Value = txn.Entry.Value
+		case physical.DeleteOperation:
+			op.OpType = deleteOp
+		default:
+			return fmt.Errorf("%q is not a supported transaction operation", txn.Operation)
+		}
+		ops = append(ops, op)
+	}
+
+	b.permitPool.Acquire()
+	defer b.permitPool.Release()
+
+	return b.applyLog(ctx, ops)
+}
+
+// applyLog commits the given operations through raft and waits for them to
+// be applied to the local FSM
+func (b *RaftBackend) applyLog(ctx context.Context, ops []*LogOperation) error {
+	if b.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+
+	cmd, err := jsonutil.EncodeJSON(&LogData{
+		Operations: ops,
+	})
+	if err != nil {
+		return err
+	}
+
+	applyFuture := b.raft.Apply(cmd, applyTimeout)
+	if err := applyFuture.Error(); err != nil {
+		return err
+	}
+
+	if resp := applyFuture.Response(); resp != nil {
+		if err, ok := resp.(error); ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Peers returns the servers in the current raft configuration
+func (b *RaftBackend) Peers(ctx context.Context) ([]Peer, error) {
+	future := b.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+
+	leader := b.raft.Leader()
+	servers := future.Configuration().Servers
+	peers := make([]Peer, 0, len(servers))
+	for _, server := range servers {
+		peers = append(peers, Peer{
+			ID:      string(server.ID),
+			Address: string(server.Address),
+			Leader:  server.Address == leader,
+			Voter:   server.Suffrage == raft.Voter,
+		})
+	}
+
+	return peers, nil
+}
+
+// AddPeer adds a new voting member to the raft cluster. It must be called on
+// the leader.
+func (b *RaftBackend) AddPeer(ctx context.Context, id, address string) error {
+	if b.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+
+	if b.logger.IsInfo() {
+		b.logger.Info("adding raft peer", "node_id", id, "address", address)
+	}
+
+	return b.raft.AddVoter(raft.ServerID(id), raft.ServerAddress(address), 0, membershipTimeout).Error()
+}
+
+// RemovePeer removes a member from the raft cluster. It must be called on
+// the leader.
+func (b *RaftBackend) RemovePeer(ctx context.Context, id string) error {
+	if b.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+
+	if b.logger.IsInfo() {
+		b.logger.Info("removing raft peer", "node_id", id)
+	}
+
+	return b.raft.RemoveServer(raft.ServerID(id), 0, membershipTimeout).Error()
+}
+
+// LockWith is used for mutual exclusion based on the given key.
+func (b *RaftBackend) LockWith(key, value string) (physical.Lock, error) {
+	return &RaftLock{
+		b:     b,
+		key:   key,
+		value: value,
+	}, nil
+}
+
+// HAEnabled indicates whether the HA functionality should be exposed.
+// Currently always returns true.
+func (b *RaftBackend) HAEnabled() bool {
+	return true
+}
+
+// lockEntry is what is stored under a lock's key. The raft address of the
+// node holding the lock is stored along with the value so that a lock left
+// behind by a previous leader is not reported as held.
+type lockEntry struct {
+	Value   string `json:"value"`
+	Address string `json:"address"`
+}
+
+// RaftLock is a physical.Lock that is only granted to the raft leader. The
+// returned leader channel is closed when raft leadership is lost.
+type RaftLock struct {
+	b     *RaftBackend
+	key   string
+	value string
+
+	l        sync.Mutex
+	held     bool
+	leaderCh chan struct{}
+	unlockCh chan struct{}
+}
+
+func (l *RaftLock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	l.l.Lock()
+	defer l.l.Unlock()
+	if l.held {
+		return nil, fmt.Errorf("lock already held")
+	}
+
+	for {
+		l.b.l.Lock()
+		leaderLostCh := l.b.leaderLostCh
+		changeCh := l.b.changeCh
+		_, taken := l.b.heldLocks[l.key]
+		if leaderLostCh != nil && !taken {
+			l.b.heldLocks[l.key] = l
+		}
+		l.b.l.Unlock()
+
+		if leaderLostCh != nil && !taken {
+			err := l.writeValue(leaderLostCh)
+			if err == nil {
+				break
+			}
+
+			l.b.l.Lock()
+			if l.b.heldLocks[l.key] == l {
+				delete(l.b.heldLocks, l.key)
+			}
+			l.b.l.Unlock()
+
+			if l.b.logger.IsDebug() {
+				l.b.logger.Debug("failed to write lock value", "key", l.key, "error", err)
+			}
+		}
+
+		select {
+		case <-changeCh:
+		case <-stopCh:
+			return nil, nil
+		case <-l.b.shutdownCh:
+			return nil, fmt.Errorf("raft backend is shut down")
+		}
+	}
+
+	l.held = true
+	l.leaderCh = make(chan struct{})
+	l.unlockCh = make(chan struct{})
+
+	leaderLostCh := l.b.currentLeaderLostCh()
+	go func(leaderCh, unlockCh chan struct{}) {
+		select {
+		case <-leaderLostCh:
+			close(leaderCh)
+		case <-unlockCh:
+		}
+	}(l.leaderCh, l.unlockCh)
+
+	return l.leaderCh, nil
+}
+
+// writeValue commits the lock value through raft. The write is abandoned if
+// leadership is lost in the meantime.
+func (l *RaftLock) writeValue(leaderLostCh chan struct{}) error {
+	value, err := jsonutil.EncodeJSON(&lockEntry{
+		Value:   l.value,
+		Address: l.b.Address(),
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-leaderLostCh:
+		return ErrNotLeader
+	default:
+	}
+
+	return l.b.Put(context.Background(), &physical.Entry{
+		Key:   l.key,
+		Value: value,
+	})
+}
+
+func (b *RaftBackend) currentLeaderLostCh() chan struct{} {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.leaderLostCh == nil {
+		// Leadership was already lost; hand back a closed channel
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	return b.leaderLostCh
+}
+
+func (l *RaftLock) Unlock() error {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	if !l.held {
+		return nil
+	}
+
+	close(l.unlockCh)
+	l.unlockCh = nil
+	l.leaderCh = nil
+	l.held = false
+
+	l.b.l.Lock()
+	owned := l.b.heldLocks[l.key] == l
+	if owned {
+		delete(l.b.heldLocks, l.key)
+	}
+	l.b.l.Unlock()
+
+	if !owned {
+		return nil
+	}
+
+	// Remove the value so followers stop reporting the lock as held. This can
+	// only succeed while still the leader, and a new leader will overwrite the
+	// value anyways, so not-leader errors are ignored.
+	err := l.b.Delete(context.Background(), l.key)
+
+	l.b.l.Lock()
+	l.b.notifyChangeLocked()
+	l.b.l.Unlock()
+
+	if err != nil && err != ErrNotLeader {
+		return err
+	}
+	return nil
+}
+
+func (l *RaftLock) Value() (bool, string, error) {
+	entry, err := l.b.Get(context.Background(), l.key)
+	if err != nil {
+		return false, "", err
+	}
+	if entry == nil {
+		return false, "", nil
+	}
+
+	var le lockEntry
+	if err := jsonutil.DecodeJSON(entry.Value, &le); err != nil {
+		return false, "", err
+	}
+
+	// A value written by a node that is no longer the leader is stale
+	if le.Address != string(l.b.raft.Leader()) {
+		return false, "", nil
+	}
+
+	return true, le.Value, nil
+}
@@ -0,0 +1,208 @@
+package raft
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/physical"
+)
+
+func getRaft(t testing.TB, bootstrap bool) (*RaftBackend, string) {
+	raftDir, err := ioutil.TempDir("", "vault-raft-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logging.NewVaultLogger(log.Debug)
+
+	conf := map[string]string{
+		"path":    raftDir,
+		"address": "127.0.0.1:0",
+	}
+	if bootstrap {
+		conf["bootstrap"] = "true"
+	}
+
+	backend, err := NewRaftBackend(conf, logger)
+	if err != nil {
+		os.RemoveAll(raftDir)
+		t.Fatal(err)
+	}
+
+	b := backend.(*RaftBackend)
+	if bootstrap {
+		waitForLeader(t, b)
+	}
+
+	return b, raftDir
+}
+
+func waitForLeader(t testing.TB, b *RaftBackend) {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if b.raft.State() == raft.Leader {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for raft leadership")
+}
+
+func TestRaft_Backend(t *testing.T) {
+	b, dir := getRaft(t, true)
+	defer os.RemoveAll(dir)
+	defer b.Shutdown()
+
+	physical.ExerciseBackend(t, b)
+	physical.ExerciseBackend_ListPrefix(t, b)
+}
+
+func TestRaft_TransactionalBackend(t *testing.T) {
+	b, dir := getRaft(t, true)
+	defer os.RemoveAll(dir)
+	defer b.Shutdown()
+
+	physical.ExerciseTransactionalBackend(t, b)
+}
+
+func TestRaft_HABackend(t *testing.T) {
+	b, dir := getRaft(t, true)
+	defer os.RemoveAll(dir)
+	defer b.Shutdown()
+
+	physical.ExerciseHABackend(t, b, b)
+}
+
+func TestRaft_Peers(t *testing.T) {
+	b1, dir1 := getRaft(t, true)
+	defer os.RemoveAll(dir1)
+	defer b1.Shutdown()
+
+	b2, dir2 := getRaft(t, false)
+	defer os.RemoveAll(dir2)
+	defer b2.Shutdown()
+
+	// Writes on a node that has not joined a cluster must fail
+	err := b2.Put(context.Background(), &physical.Entry{Key: "foo", Value: []byte("bar")})
+	if err != ErrNotLeader {
+		t.Fatalf("expected not leader error, got %v", err)
+	}
+
+	if err := b1.Put(context.Background(), &physical.Entry{Key: "foo", Value: []byte("bar")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b1.AddPeer(context.Background(), b2.NodeID(), b2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	peers, err := b1.Peers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 {
+		t.Fatalf("expected 2 peers, got %#v", peers)
+	}
+	for _, peer := range peers {
+		if peer.Leader != (peer.ID == b1.NodeID()) {
+			t.Fatalf("bad leader flag: %#v", peer)
+		}
+	}
+
+	// The existing data should be replicated to the new node
+	var entry *physical.Entry
+	deadline := time.Now().Add(10 * time.Second)
+	for entry == nil && time.Now().Before(deadline) {
+		entry, err = b2.Get(context.Background(), "foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if entry == nil || string(entry.Value) != "bar" {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	if err := b1.RemovePeer(context.Background(), b2.NodeID()); err != nil {
+		t.Fatal(err)
+	}
+
+	peers, err = b1.Peers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || peers[0].ID != b1.NodeID() {
+		t.Fatalf("bad: %#v", peers)
+	}
+}
+
+func TestRaft_SnapshotRestore(t *testing.T) {
+	b, dir := getRaft(t, true)
+	defer os.RemoveAll(dir)
+	defer b.Shutdown()
+
+	if err := b.Put(context.Background(), &physical.Entry{Key: "foo", Value: []byte("bar")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.raft.Snapshot().Error(); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := b.fsm.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &testSink{dir: dir}
+	if err := snapshot.Persist(sink); err != nil {
+		t.Fatal(err)
+	}
+	snapshot.Release()
+
+	if err := b.Delete(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(sink.f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.fsm.Restore(f); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := b.Get(context.Background(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || string(entry.Value) != "bar" {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
+
+type testSink struct {
+	dir string
+	f   *os.File
+}
+
+func (s *testSink) Write(p []byte) (int, error) {
+	if s.f == nil {
+		f, err := ioutil.TempFile(s.dir, "snap")
+		if err != nil {
+			return 0, err
+		}
+		s.f = f
+	}
+	return s.f.Write(p)
+}
+
+func (s *testSink) Close() error  { return s.f.Close() }
+func (s *testSink) ID() string    { return "test" }
+func (s *testSink) Cancel() error { return s.f.Close() }
@@ -404,7 +404,13 @@ func (c *Core) performPolicyChecks(ctx context.Context, acl *ACL, te *TokenEntry
 	// should be applied is if we are only processing EGPs against a login
 	// path in which case opts.Unauth will be set.
 	if acl != nil && !opts.Unauth {
-		ret.ACLResults = acl.AllowOperation(req)
+		// Policies address paths relative to the namespace of the token
+		nsReq, ok := c.tokenNamespaceRequest(te, req)
+		if !ok {
+			ret.ACLResults = &ACLResults{}
+			return
+		}
+		ret.ACLResults = acl.AllowOperation(nsReq)
 		ret.RootPrivs = ret.ACLResults.RootPrivs
 		// Root is always allowed; skip Sentinel/MFA checks
 		if ret.ACLResults.IsRoot {
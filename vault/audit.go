@@ -127,7 +127,7 @@ func (c *Core) disableAudit(ctx context.Context, path string) (bool, error) {
 	defer c.auditLock.Unlock()
 
 	newTable := c.audit.shallowClone()
-	entry := newTable.remove(path)
+	entry := newTable.remove(ctx, path)
 
 	// Ensure there was a match
 	if entry == nil {
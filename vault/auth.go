@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -58,8 +59,15 @@ func (c *Core) enableCredential(ctx context.Context, entry *MountEntry) error {
 	c.authLock.Lock()
 	defer c.authLock.Unlock()
 
+	ns := namespace.FromContext(ctx)
+	entry.NamespaceID = ns.ID
+	entry.namespace = ns
+
 	// Look for matching name
 	for _, ent := range c.auth.Entries {
+		if ent.Namespace().ID != ns.ID {
+			continue
+		}
 		switch {
 		// Existing is oauth/github/ new is oauth/ or
 		// existing is oauth/ and new is oauth/github/
@@ -75,9 +83,12 @@ func (c *Core) enableCredential(ctx context.Context, entry *MountEntry) error {
 		return fmt.Errorf("token credential backend cannot be instantiated")
 	}
 
-	if conflict := c.router.MountConflict(credentialRoutePrefix + entry.Path); conflict != "" {
+	if conflict := c.router.MountConflict(entry.APIPath()); conflict != "" {
 		return logical.CodedError(409, fmt.Sprintf("existing mount at %s", conflict))
 	}
+	if err := c.checkMountNamespace(ns, entry.APIPath()); err != nil {
+		return err
+	}
 
 	// Generate a new UUID and view
 	if entry.UUID == "" {
@@ -140,13 +151,12 @@ func (c *Core) enableCredential(ctx context.Context, entry *MountEntry) error {
 
 	c.auth = newTable
 
-	path := credentialRoutePrefix + entry.Path
-	if err := c.router.Mount(backend, path, entry, view); err != nil {
+	if err := c.router.Mount(backend, entry.APIPath(), entry, view); err != nil {
 		return err
 	}
 
 	if c.logger.IsInfo() {
-		c.logger.Info("enabled credential backend", "path", entry.Path, "type", entry.Type)
+		c.logger.Info("enabled credential backend", "path", entry.APIPath(), "type", entry.Type)
 	}
 	return nil
 }
@@ -165,7 +175,8 @@ func (c *Core) disableCredential(ctx context.Context, path string) error {
 	}
 
 	// Store the view for this backend
-	fullPath := credentialRoutePrefix + path
+	ns := namespace.FromContext(ctx)
+	fullPath := ns.Path + credentialRoutePrefix + path
 	view := c.router.MatchingStorageByAPIPath(fullPath)
 	if view == nil {
 		return fmt.Errorf("no matching backend %q", fullPath)
@@ -175,6 +186,9 @@ func (c *Core) disableCredential(ctx context.Context, path string) error {
 	// replication prefixes
 	backend := c.router.MatchingBackend(fullPath)
 	entry := c.router.MatchingMountEntry(fullPath)
+	if entry.Namespace().ID != ns.ID {
+		return fmt.Errorf("no matching backend %q", fullPath)
+	}
 
 	// Mark the entry as tainted
 	if err := c.taintCredEntry(ctx, path); err != nil {
@@ -228,7 +242,7 @@ func (c *Core) removeCredEntry(ctx context.Context, path string) error {
 
 	// Taint the entry from the auth table
 	newTable := c.auth.shallowClone()
-	entry := newTable.remove(path)
+	entry := newTable.remove(ctx, path)
 	if entry == nil {
 		c.logger.Error("nil entry found removing entry in auth table", "path", path)
 		return logical.CodedError(500, "failed to remove entry in auth table")
@@ -248,7 +262,7 @@ func (c *Core) removeCredEntry(ctx context.Context, path string) error {
 // unmounts and remounts the backend to pick up any changes, such as filtered
 // paths
 func (c *Core) remountCredEntryForce(ctx context.Context, path string) error {
-	fullPath := credentialRoutePrefix + path
+	fullPath := namespace.FromContext(ctx).Path + credentialRoutePrefix + path
 	me := c.router.MatchingMountEntry(fullPath)
 	if me == nil {
 		return fmt.Errorf("cannot find mount for path %q", path)
@@ -273,7 +287,7 @@ func (c *Core) taintCredEntry(ctx context.Context, path string) error {
 	// Taint the entry from the auth table
 	// We do this on the original since setting the taint operates
 	// on the entries which a shallow clone shares anyways
-	entry := c.auth.setTaint(path, true)
+	entry := c.auth.setTaint(ctx, path, true)
 
 	// Ensure there was a match
 	if entry == nil {
@@ -360,6 +374,16 @@ func (c *Core) loadCredentials(ctx context.Context) error {
 			entry.BackendAwareUUID = bUUID
 			needPersist = true
 		}
+		if entry.NamespaceID == "" {
+			entry.NamespaceID = namespace.RootNamespaceID
+			needPersist = true
+		}
+		ns := c.namespaceStore.NamespaceByID(entry.NamespaceID)
+		if ns == nil {
+			c.logger.Error("namespace of auth entry not found", "namespace_id", entry.NamespaceID, "path", entry.Path)
+			return errLoadAuthFailed
+		}
+		entry.namespace = ns
 
 		// Sync values to the cache
 		entry.SyncCache()
@@ -457,9 +481,18 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 	c.authLock.Lock()
 	defer c.authLock.Unlock()
 
+	// The token store of the root namespace is shared with the child
+	// namespaces, so their token entries are mounted once it is set up
+	var namespaceTokenEntries []*MountEntry
+
 	for _, entry := range c.auth.Entries {
 		var backend logical.Backend
 
+		if entry.Type == "token" && entry.Namespace().ID != namespace.RootNamespaceID {
+			namespaceTokenEntries = append(namespaceTokenEntries, entry)
+			continue
+		}
+
 		// Create a barrier view using the UUID
 		viewPath := credentialBarrierPrefix + entry.UUID + "/"
 		view := NewBarrierView(c.barrier, viewPath)
@@ -497,7 +530,7 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 
 	ROUTER_MOUNT:
 		// Mount the backend
-		path := credentialRoutePrefix + entry.Path
+		path := entry.APIPath()
 		err = c.router.Mount(backend, path, entry, view)
 		if err != nil {
 			c.logger.Error("failed to mount auth entry", "path", entry.Path, "error", err)
@@ -519,6 +552,13 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 		}
 	}
 
+	for _, entry := range namespaceTokenEntries {
+		if err := c.mountNamespaceTokenStore(entry); err != nil {
+			c.logger.Error("failed to mount auth entry", "path", entry.APIPath(), "error", err)
+			return errLoadAuthFailed
+		}
+	}
+
 	if persistNeeded {
 		return c.persistAuth(ctx, c.auth, false)
 	}
@@ -535,7 +575,7 @@ func (c *Core) teardownCredentials(ctx context.Context) error {
 	if c.auth != nil {
 		authTable := c.auth.shallowClone()
 		for _, e := range authTable.Entries {
-			backend := c.router.MatchingBackend(credentialRoutePrefix + e.Path)
+			backend := c.router.MatchingBackend(e.APIPath())
 			if backend != nil {
 				backend.Cleanup(ctx)
 			}
@@ -3,11 +3,15 @@ package vault
 import (
 	"context"
 	"sort"
+	"strings"
 
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 )
 
-// Capabilities is used to fetch the capabilities of the given token on the given path
+// Capabilities is used to fetch the capabilities of the given token on the
+// given path. The path is the full path of the request, including the path of
+// its namespace.
 func (c *Core) Capabilities(ctx context.Context, token, path string) ([]string, error) {
 	if path == "" {
 		return nil, &logical.StatusBadRequest{Err: "missing path"}
@@ -29,6 +33,15 @@ func (c *Core) Capabilities(ctx context.Context, token, path string) ([]string,
 		return []string{DenyCapability}, nil
 	}
 
+	// Policies are looked up in the namespace of the token and address
+	// paths relative to it
+	tokenNS := c.tokenNamespace(te)
+	if tokenNS == nil || !strings.HasPrefix(path, tokenNS.Path) {
+		return []string{DenyCapability}, nil
+	}
+	ctx = namespace.ContextWithNamespace(ctx, tokenNS)
+	path = tokenNS.TrimmedPath(path)
+
 	var policies []*Policy
 	for _, tePolicy := range te.Policies {
 		policy, err := c.policyStore.GetPolicy(ctx, tePolicy, PolicyTypeToken)
@@ -38,7 +51,7 @@ func (c *Core) Capabilities(ctx context.Context, token, path string) ([]string,
 		policies = append(policies, policy)
 	}
 
-	_, derivedPolicies, err := c.fetchEntityAndDerivedPolicies(te.EntityID)
+	_, derivedPolicies, err := c.fetchEntityAndDerivedPolicies(tokenNS, te.EntityID)
 	if err != nil {
 		return nil, err
 	}
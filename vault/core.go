@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,12 +25,15 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/mlock"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/reload"
 	"github.com/hashicorp/vault/helper/tlsutil"
 	"github.com/hashicorp/vault/logical"
@@ -168,6 +172,10 @@ type Core struct {
 	// physical backend is the un-trusted backend with durable data
 	physical physical.Backend
 
+	// underlyingPhysical is the physical backend as configured, without the
+	// cache and seal unwrapper layers
+	underlyingPhysical physical.Backend
+
 	// Our Seal, for seal configuration information
 	seal Seal
 
@@ -263,6 +271,9 @@ type Core struct {
 	// policy store is used to manage named ACL policies
 	policyStore *PolicyStore
 
+	// namespaceStore is used to manage the namespaces
+	namespaceStore *NamespaceStore
+
 	// token store is used to manage authentication tokens
 	tokenStore *TokenStore
 
@@ -353,6 +364,9 @@ type Core struct {
 	// CORS Information
 	corsConfig *CORSConfig
 
+	// loginMFA holds the logins waiting for MFA to be validated
+	loginMFA *loginMFAManager
+
 	// The active set of upstream cluster addresses; stored via the Echo
 	// mechanism, loaded by the balancer
 	atomicPrimaryClusterAddrs *atomic.Value
@@ -384,6 +398,9 @@ type Core struct {
 
 	// Stores the sealunwrapper for downgrade needs
 	sealUnwrapper physical.Backend
+
+	// metricsHelper exposes the collected metrics on sys/metrics
+	metricsHelper *metricsutil.MetricsHelper
 }
 
 // CoreConfig is used to parameterize a core
@@ -437,6 +454,9 @@ type CoreConfig struct {
 
 	ReloadFuncs     *map[string][]reload.ReloadFunc
 	ReloadFuncsLock *sync.RWMutex
+
+	// MetricsHelper gives sys/metrics access to the collected metrics
+	MetricsHelper *metricsutil.MetricsHelper
 }
 
 // NewCore is used to construct a new core
@@ -478,6 +498,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	c := &Core{
 		devToken:                         conf.DevToken,
 		physical:                         conf.Physical,
+		underlyingPhysical:               conf.Physical,
 		redirectAddr:                     conf.RedirectAddr,
 		clusterAddr:                      conf.ClusterAddr,
 		seal:                             conf.Seal,
@@ -502,6 +523,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		localClusterCert:                 new(atomic.Value),
 		localClusterParsedCert:           new(atomic.Value),
 		activeNodeReplicationState:       new(uint32),
+		metricsHelper:                    conf.MetricsHelper,
 	}
 
 	atomic.StoreUint32(c.replicationState, uint32(consts.ReplicationDRDisabled|consts.ReplicationPerformanceDisabled))
@@ -520,6 +542,8 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	// Load CORS config and provide a value for the core field.
 	c.corsConfig = &CORSConfig{core: c}
 
+	c.loginMFA = newLoginMFAManager(c)
+
 	phys := conf.Physical
 	_, txnOK := conf.Physical.(physical.Transactional)
 	if c.seal == nil {
@@ -691,16 +715,23 @@ func (c *Core) LookupToken(token string) (*TokenEntry, error) {
 // which the given entity ID is merged into will be returned. This function
 // also returns the cumulative list of policies that the entity is entitled to.
 // This list includes the policies from the entity itself and from all the
-// groups in which the given entity ID is a member of.
-func (c *Core) fetchEntityAndDerivedPolicies(entityID string) (*identity.Entity, []string, error) {
+// groups in which the given entity ID is a member of. Entities are looked up
+// in the identity store of the given namespace.
+func (c *Core) fetchEntityAndDerivedPolicies(ns *namespace.Namespace, entityID string) (*identity.Entity, []string, error) {
 	if entityID == "" {
 		return nil, nil, nil
 	}
 
+	identityStore := c.namespaceIdentityStore(ns)
+	if identityStore == nil {
+		c.logger.Error("identity store of namespace not found", "namespace", ns.Path)
+		return nil, nil, ErrInternalError
+	}
+
 	//c.logger.Debug("entity set on the token", "entity_id", te.EntityID)
 
 	// Fetch the entity
-	entity, err := c.identityStore.MemDBEntityByID(entityID, false)
+	entity, err := identityStore.MemDBEntityByID(entityID, false)
 	if err != nil {
 		c.logger.Error("failed to lookup entity using its ID", "error", err)
 		return nil, nil, err
@@ -710,7 +741,7 @@ func (c *Core) fetchEntityAndDerivedPolicies(entityID string) (*identity.Entity,
 		// If there was no corresponding entity object found, it is
 		// possible that the entity got merged into another entity. Try
 		// finding entity based on the merged entity index.
-		entity, err = c.identityStore.MemDBEntityByMergedEntityID(entityID, false)
+		entity, err = identityStore.MemDBEntityByMergedEntityID(entityID, false)
 		if err != nil {
 			c.logger.Error("failed to lookup entity in merged entity ID index", "error", err)
 			return nil, nil, err
@@ -724,7 +755,7 @@ func (c *Core) fetchEntityAndDerivedPolicies(entityID string) (*identity.Entity,
 		// Attach the policies on the entity
 		policies = append(policies, entity.Policies...)
 
-		groupPolicies, err := c.identityStore.groupPoliciesByEntityID(entity.ID)
+		groupPolicies, err := identityStore.groupPoliciesByEntityID(entity.ID)
 		if err != nil {
 			c.logger.Error("failed to fetch group policies", "error", err)
 			return nil, nil, err
@@ -737,10 +768,41 @@ func (c *Core) fetchEntityAndDerivedPolicies(entityID string) (*identity.Entity,
 	return entity, policies, err
 }
 
-func (c *Core) fetchACLTokenEntryAndEntity(clientToken string) (*ACL, *TokenEntry, *identity.Entity, error) {
+// tokenNamespace returns the namespace the given token was created in, or nil
+// if that namespace no longer exists.
+func (c *Core) tokenNamespace(te *TokenEntry) *namespace.Namespace {
+	return c.namespaceStore.NamespaceByID(te.NamespaceID)
+}
+
+// tokenNamespaceRequest returns a copy of the request whose path is relative
+// to the namespace of the token, which is how the token's policies address
+// paths. The second return value is false if the request is outside of the
+// token's namespace.
+func (c *Core) tokenNamespaceRequest(te *TokenEntry, req *logical.Request) (*logical.Request, bool) {
+	if te == nil {
+		return req, true
+	}
+	tokenNS := c.tokenNamespace(te)
+	if tokenNS == nil || !strings.HasPrefix(req.Path, tokenNS.Path) {
+		return nil, false
+	}
+	if tokenNS.ID == namespace.RootNamespaceID {
+		return req, true
+	}
+
+	nsReq := *req
+	nsReq.Path = tokenNS.TrimmedPath(req.Path)
+	return &nsReq, true
+}
+
+// fetchACLTokenEntryAndEntity looks up the client token of the request along
+// with its entity and ACL. The token must be used from within its bound CIDR
+// blocks.
+func (c *Core) fetchACLTokenEntryAndEntity(req *logical.Request) (*ACL, *TokenEntry, *identity.Entity, error) {
 	defer metrics.MeasureSince([]string{"core", "fetch_acl_and_token"}, time.Now())
 
 	// Ensure there is a client token
+	clientToken := req.ClientToken
 	if clientToken == "" {
 		return nil, nil, nil, fmt.Errorf("missing client token")
 	}
@@ -762,17 +824,40 @@ func (c *Core) fetchACLTokenEntryAndEntity(clientToken string) (*ACL, *TokenEntr
 		return nil, nil, nil, logical.ErrPermissionDenied
 	}
 
+	// Tokens whose namespace has been deleted are no longer valid
+	tokenNS := c.tokenNamespace(te)
+	if tokenNS == nil {
+		return nil, nil, nil, logical.ErrPermissionDenied
+	}
+
+	// A token bound to CIDR blocks can only be used from addresses within
+	// them
+	if len(te.BoundCIDRs) > 0 {
+		if req.Connection == nil || req.Connection.RemoteAddr == "" {
+			return nil, nil, nil, logical.ErrPermissionDenied
+		}
+		belongs, err := cidrutil.IPBelongsToCIDRBlocksSlice(req.Connection.RemoteAddr, te.BoundCIDRs)
+		if err != nil {
+			c.logger.Error("failed to verify the CIDR restrictions of the token", "error", err)
+			return nil, nil, nil, ErrInternalError
+		}
+		if !belongs {
+			return nil, nil, nil, logical.ErrPermissionDenied
+		}
+	}
+
 	tokenPolicies := te.Policies
 
-	entity, derivedPolicies, err := c.fetchEntityAndDerivedPolicies(te.EntityID)
+	entity, derivedPolicies, err := c.fetchEntityAndDerivedPolicies(tokenNS, te.EntityID)
 	if err != nil {
 		return nil, nil, nil, ErrInternalError
 	}
 
 	tokenPolicies = append(tokenPolicies, derivedPolicies...)
 
-	// Construct the corresponding ACL object
-	acl, err := c.policyStore.ACL(c.activeContext, tokenPolicies...)
+	// Construct the corresponding ACL object from the policies of the
+	// token's namespace
+	acl, err := c.policyStore.ACL(namespace.ContextWithNamespace(c.activeContext, tokenNS), tokenPolicies...)
 	if err != nil {
 		c.logger.Error("failed to construct ACL", "error", err)
 		return nil, nil, nil, ErrInternalError
@@ -793,7 +878,7 @@ func (c *Core) checkToken(ctx context.Context, req *logical.Request, unauth bool
 	// gather as much info as possible for the audit log and to e.g. control
 	// trace mode for EGPs.
 	if !unauth || (unauth && req.ClientToken != "") {
-		acl, te, entity, err = c.fetchACLTokenEntryAndEntity(req.ClientToken)
+		acl, te, entity, err = c.fetchACLTokenEntryAndEntity(req)
 		// In the unauth case we don't want to fail the command, since it's
 		// unauth, we just have no information to attach to the request, so
 		// ignore errors...this was best-effort anyways
@@ -802,6 +887,15 @@ func (c *Core) checkToken(ctx context.Context, req *logical.Request, unauth bool
 		}
 	}
 
+	// A token can only be used in its own namespace and the namespaces
+	// beneath it
+	if te != nil && !unauth {
+		tokenNS := c.tokenNamespace(te)
+		if tokenNS == nil || !namespace.FromContext(ctx).HasParent(tokenNS) {
+			return nil, te, logical.ErrPermissionDenied
+		}
+	}
+
 	// Check if this is a root protected path
 	rootPath := c.router.RootPath(req.Path)
 
@@ -1211,6 +1305,13 @@ func (c *Core) unsealPart(ctx context.Context, config *SealConfig, key []byte, u
 func (c *Core) unsealInternal(ctx context.Context, masterKey []byte) (bool, error) {
 	defer memzero(masterKey)
 
+	// Storage is only partially restored if a storage snapshot restore failed
+	// after staging the snapshot, so complete it first
+	if err := c.completeStorageRestore(ctx); err != nil {
+		c.logger.Error("failed to complete storage snapshot restore", "error", err)
+		return false, err
+	}
+
 	// Attempt to unlock
 	if err := c.barrier.Unseal(ctx, masterKey); err != nil {
 		return false, err
@@ -1320,7 +1421,7 @@ func (c *Core) sealInitCommon(ctx context.Context, req *logical.Request) (retErr
 	}
 
 	// Validate the token is a root token
-	acl, te, entity, err := c.fetchACLTokenEntryAndEntity(req.ClientToken)
+	acl, te, entity, err := c.fetchACLTokenEntryAndEntity(req)
 	if err != nil {
 		// Since there is no token store in standby nodes, sealing cannot
 		// be done. Ideally, the request has to be forwarded to leader node
@@ -1441,7 +1542,7 @@ func (c *Core) StepDown(req *logical.Request) (retErr error) {
 
 	ctx := c.activeContext
 
-	acl, te, entity, err := c.fetchACLTokenEntryAndEntity(req.ClientToken)
+	acl, te, entity, err := c.fetchACLTokenEntryAndEntity(req)
 	if err != nil {
 		retErr = multierror.Append(retErr, err)
 		return retErr
@@ -1638,6 +1739,9 @@ func (c *Core) postUnseal() (retErr error) {
 	if err := c.setupPluginCatalog(); err != nil {
 		return err
 	}
+	if err := c.setupNamespaceStore(c.activeContext); err != nil {
+		return err
+	}
 	if err := c.loadMounts(c.activeContext); err != nil {
 		return err
 	}
@@ -1656,6 +1760,9 @@ func (c *Core) postUnseal() (retErr error) {
 	if err := c.setupCredentials(c.activeContext); err != nil {
 		return err
 	}
+	if err := c.upgradeLegacyMFA(c.activeContext); err != nil {
+		return err
+	}
 	if err := c.startRollback(); err != nil {
 		return err
 	}
@@ -1724,6 +1831,9 @@ func (c *Core) preSeal() error {
 	if err := c.unloadMounts(c.activeContext); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error unloading mounts: {{err}}", err))
 	}
+	if err := c.teardownNamespaceStore(); err != nil {
+		result = multierror.Append(result, errwrap.Wrapf("error tearing down namespace store: {{err}}", err))
+	}
 	if err := enterprisePreSeal(c); err != nil {
 		result = multierror.Append(result, err)
 	}
@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"bytes"
 	"context"
 	"reflect"
 	"testing"
@@ -12,6 +13,7 @@ import (
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
 	"github.com/hashicorp/vault/physical/inmem"
@@ -731,6 +733,7 @@ func TestCore_HandleLogin_Token(t *testing.T) {
 		DisplayName:  "foo-armon",
 		TTL:          time.Hour * 24,
 		CreationTime: te.CreationTime,
+		NamespaceID:  namespace.RootNamespaceID,
 	}
 
 	if !reflect.DeepEqual(te, expect) {
@@ -1031,6 +1034,7 @@ func TestCore_HandleRequest_CreateToken_Lease(t *testing.T) {
 		DisplayName:  "token",
 		CreationTime: te.CreationTime,
 		TTL:          time.Hour * 24 * 32,
+		NamespaceID:  namespace.RootNamespaceID,
 	}
 	if !reflect.DeepEqual(te, expect) {
 		t.Fatalf("Bad: %#v expect: %#v", te, expect)
@@ -1076,6 +1080,7 @@ func TestCore_HandleRequest_CreateToken_NoDefaultPolicy(t *testing.T) {
 		DisplayName:  "token",
 		CreationTime: te.CreationTime,
 		TTL:          time.Hour * 24 * 32,
+		NamespaceID:  namespace.RootNamespaceID,
 	}
 	if !reflect.DeepEqual(te, expect) {
 		t.Fatalf("Bad: %#v expect: %#v", te, expect)
@@ -2361,3 +2366,83 @@ func TestCore_HandleRequest_Headers(t *testing.T) {
 		t.Fatalf("did not expect 'Should-Not-Passthrough' to be in the headers map")
 	}
 }
+
+func TestCore_BoundCIDRs_RootOperations(t *testing.T) {
+	logger = logging.NewVaultLogger(log.Trace)
+
+	inm, err := inmem.NewInmemHA(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, err := NewCore(&CoreConfig{
+		Physical:     inm,
+		HAPhysical:   inm.(physical.HABackend),
+		RedirectAddr: "http://127.0.0.1:8200",
+		DisableMlock: true,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	keys, _ := TestCoreInit(t, core)
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(core, TestKeyCopy(key)); err != nil {
+			t.Fatalf("unseal err: %s", err)
+		}
+	}
+	TestWaitActive(t, core)
+
+	te := &TokenEntry{
+		Path:         "auth/token/create",
+		Policies:     []string{"root"},
+		CreationTime: time.Now().Unix(),
+		BoundCIDRs:   []string{"127.0.0.1/32"},
+	}
+	if err := core.tokenStore.create(context.Background(), te); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(path, remoteAddr string) *logical.Request {
+		return &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        path,
+			ClientToken: te.ID,
+			Connection: &logical.Connection{
+				RemoteAddr: remoteAddr,
+			},
+		}
+	}
+	denied := func(err error) bool {
+		return err != nil && errwrap.Contains(err, logical.ErrPermissionDenied.Error())
+	}
+
+	// The token is rejected from outside its CIDR blocks
+	if err := core.StorageSnapshot(request("sys/storage/snapshot", "127.0.0.2"), &bytes.Buffer{}); !denied(err) {
+		t.Fatalf("snapshot: expected permission denied, got %v", err)
+	}
+	if err := core.StorageSnapshotRestore(request("sys/storage/snapshot", "127.0.0.2"), &bytes.Buffer{}); !denied(err) {
+		t.Fatalf("snapshot restore: expected permission denied, got %v", err)
+	}
+	if err := core.StepDown(request("sys/step-down", "127.0.0.2")); !denied(err) {
+		t.Fatalf("step-down: expected permission denied, got %v", err)
+	}
+	if standby, _ := core.Standby(); standby {
+		t.Fatal("should not have stepped down")
+	}
+	if err := core.SealWithRequest(request("sys/seal", "127.0.0.2")); !denied(err) {
+		t.Fatalf("seal: expected permission denied, got %v", err)
+	}
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+
+	// It can be used from within them
+	if err := core.StorageSnapshot(request("sys/storage/snapshot", "127.0.0.1"), &bytes.Buffer{}); err != nil {
+		t.Fatalf("snapshot: err: %v", err)
+	}
+	if err := core.SealWithRequest(request("sys/seal", "127.0.0.1")); err != nil {
+		t.Fatalf("seal: err: %v", err)
+	}
+	if sealed, _ := core.Sealed(); !sealed {
+		t.Fatal("should be sealed")
+	}
+}
@@ -28,6 +28,7 @@ var StdAllowedHeaders = []string{
 	"X-Vault-Wrap-TTL",
 	"X-Vault-Policy-Override",
 	consts.VaultKVCLIClientHeader,
+	consts.NamespaceHeaderName,
 }
 
 // CORSConfig stores the state of the CORS configuration.
@@ -7,6 +7,7 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/pluginutil"
 	"github.com/hashicorp/vault/helper/wrapping"
 	"github.com/hashicorp/vault/logical"
@@ -41,8 +42,14 @@ func (d dynamicSystemView) SudoPrivilege(ctx context.Context, path string, token
 		return false
 	}
 
+	tokenNS := d.core.tokenNamespace(te)
+	if tokenNS == nil {
+		d.core.logger.Error("namespace not found for given token")
+		return false
+	}
+
 	// Construct the corresponding ACL object
-	acl, err := d.core.policyStore.ACL(ctx, te.Policies...)
+	acl, err := d.core.policyStore.ACL(namespace.ContextWithNamespace(ctx, tokenNS), te.Policies...)
 	if err != nil {
 		d.core.logger.Error("failed to retrieve ACL for token's policies", "token_policies", te.Policies, "error", err)
 		return false
@@ -54,7 +61,11 @@ func (d dynamicSystemView) SudoPrivilege(ctx context.Context, path string, token
 	req := new(logical.Request)
 	req.Operation = logical.ReadOperation
 	req.Path = path
-	authResults := acl.AllowOperation(req)
+	nsReq, ok := d.core.tokenNamespaceRequest(te, req)
+	if !ok {
+		return false
+	}
+	authResults := acl.AllowOperation(nsReq)
 	return authResults.RootPrivs
 }
 
@@ -145,3 +156,17 @@ func (d dynamicSystemView) LookupPlugin(ctx context.Context, name string) (*plug
 func (d dynamicSystemView) MlockEnabled() bool {
 	return d.core.enableMlock
 }
+
+// RevokeLoginsByAlias revokes the tokens issued by logins to the auth method
+// whose alias has the given name. It isn't part of logical.SystemView, as it
+// can't be called by plugins; builtin auth methods check for it instead.
+func (d dynamicSystemView) RevokeLoginsByAlias(ctx context.Context, aliasName string) error {
+	if d.mountEntry == nil || d.mountEntry.Table != credentialTableType {
+		return fmt.Errorf("logins can only be revoked by auth methods")
+	}
+	if d.core.expiration == nil {
+		return fmt.Errorf("expiration manager is not available")
+	}
+
+	return d.core.expiration.RevokeByAlias(d.mountEntry.Accessor, aliasName)
+}
@@ -36,6 +36,10 @@ const (
 	// tokenViewPrefix is the prefix used for the token based lookup of leases.
 	tokenViewPrefix = "token/"
 
+	// aliasViewPrefix is the prefix used for the alias based lookup of the
+	// leases of logins.
+	aliasViewPrefix = "alias/"
+
 	// maxRevokeAttempts limits how many revoke attempts are made
 	maxRevokeAttempts = 6
 
@@ -60,6 +64,7 @@ type ExpirationManager struct {
 	router     *Router
 	idView     *BarrierView
 	tokenView  *BarrierView
+	aliasView  *BarrierView
 	tokenStore *TokenStore
 	logger     log.Logger
 
@@ -89,6 +94,7 @@ func NewExpirationManager(c *Core, view *BarrierView, logger log.Logger) *Expira
 		router:     c.router,
 		idView:     view.SubView(leaseViewPrefix),
 		tokenView:  view.SubView(tokenViewPrefix),
+		aliasView:  view.SubView(aliasViewPrefix),
 		tokenStore: c.tokenStore,
 		logger:     logger,
 		pending:    make(map[string]*time.Timer),
@@ -215,6 +221,12 @@ func (m *ExpirationManager) Tidy() error {
 			return
 		}
 
+		// Leases of batch tokens expire along with the token and are revoked
+		// with its parent
+		if le.ClientTokenType == TokenTypeBatch {
+			return
+		}
+
 		var isValid, ok bool
 		revokeLease := false
 		if le.ClientToken == "" {
@@ -516,7 +528,20 @@ func (m *ExpirationManager) revokeCommon(leaseID string, force, skipToken bool)
 
 	// Delete the secondary index, but only if it's a leased secret (not auth)
 	if le.Secret != nil {
-		if err := m.removeIndexByToken(le.ClientToken, le.LeaseID); err != nil {
+		indexToken, err := m.leaseIndexToken(le)
+		if err != nil {
+			return err
+		}
+		if indexToken != "" {
+			if err := m.removeIndexByToken(indexToken, le.LeaseID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Delete the alias index of logins
+	if le.Auth != nil {
+		if err := m.removeIndexByAlias(le); err != nil {
 			return err
 		}
 	}
@@ -593,6 +618,31 @@ func (m *ExpirationManager) RevokeByToken(te *TokenEntry) error {
 	return nil
 }
 
+// RevokeByAlias is used to revoke the tokens issued by logins to the auth
+// mount with the given accessor whose alias has the given name.
+func (m *ExpirationManager) RevokeByAlias(mountAccessor, aliasName string) error {
+	defer metrics.MeasureSince([]string{"expire", "revoke-by-alias"}, time.Now())
+
+	if m.inRestoreMode() {
+		m.restoreRequestLock.Lock()
+		defer m.restoreRequestLock.Unlock()
+	}
+
+	// Lookup the login leases of the alias
+	existing, err := m.lookupByAlias(mountAccessor, aliasName)
+	if err != nil {
+		return errwrap.Wrapf("failed to scan for leases: {{err}}", err)
+	}
+
+	// Revoke the login tokens of the alias
+	for idx, leaseID := range existing {
+		if err := m.revokeCommon(leaseID, false, false); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to revoke %q (%d / %d): {{err}}", leaseID, idx+1, len(existing)), err)
+		}
+	}
+	return nil
+}
+
 func (m *ExpirationManager) revokePrefixCommon(prefix string, force bool) error {
 	if m.inRestoreMode() {
 		m.restoreRequestLock.Lock()
@@ -673,6 +723,20 @@ func (m *ExpirationManager) Renew(leaseID string, increment time.Duration) (*log
 	for _, warning := range warnings {
 		resp.AddWarning(warning)
 	}
+
+	// Leases of batch tokens can't outlive the token
+	if le.ClientTokenType == TokenTypeBatch {
+		te, err := m.tokenStore.decodeBatchToken(m.quitContext, le.ClientToken)
+		if err != nil {
+			return nil, err
+		}
+		if te != nil && !te.expireTime().IsZero() {
+			if remaining := time.Until(te.expireTime()); ttl > remaining {
+				ttl = remaining
+				resp.AddWarning(fmt.Sprintf("TTL capped to %d seconds, the remaining TTL of the batch token that created the lease", int64(ttl.Seconds())))
+			}
+		}
+	}
 	resp.Secret.TTL = ttl
 
 	// Attach the LeaseID
@@ -836,6 +900,29 @@ func (m *ExpirationManager) Register(req *logical.Request, resp *logical.Respons
 
 	leaseID := path.Join(req.Path, leaseUUID)
 
+	// Leases are indexed by the token that created them so that they are
+	// revoked with it. Batch tokens can't be revoked, so their leases are
+	// indexed by their parent instead, or not at all for orphans, and can't
+	// outlive the batch token.
+	tokenType := TokenTypeService
+	indexToken := req.ClientToken
+	if isBatchToken(req.ClientToken) {
+		te, err := m.tokenStore.lookupBatchToken(m.quitContext, req.ClientToken)
+		if err != nil {
+			return "", err
+		}
+		if te == nil {
+			return "", fmt.Errorf("cannot register a lease with an invalid batch token")
+		}
+		tokenType = TokenTypeBatch
+		indexToken = te.Parent
+		if expire := te.expireTime(); !expire.IsZero() {
+			if remaining := time.Until(expire); resp.Secret.TTL == 0 || resp.Secret.TTL > remaining {
+				resp.Secret.TTL = remaining
+			}
+		}
+	}
+
 	defer func() {
 		// If there is an error we want to rollback as much as possible (note
 		// that errors here are ignored to do as much cleanup as we can). We
@@ -853,20 +940,23 @@ func (m *ExpirationManager) Register(req *logical.Request, resp *logical.Respons
 				retErr = multierror.Append(retErr, errwrap.Wrapf("an additional error was encountered deleting any lease associated with the newly-generated secret: {{err}}", err))
 			}
 
-			if err := m.removeIndexByToken(req.ClientToken, leaseID); err != nil {
-				retErr = multierror.Append(retErr, errwrap.Wrapf("an additional error was encountered removing lease indexes associated with the newly-generated secret: {{err}}", err))
+			if indexToken != "" {
+				if err := m.removeIndexByToken(indexToken, leaseID); err != nil {
+					retErr = multierror.Append(retErr, errwrap.Wrapf("an additional error was encountered removing lease indexes associated with the newly-generated secret: {{err}}", err))
+				}
 			}
 		}
 	}()
 
 	le := leaseEntry{
-		LeaseID:     leaseID,
-		ClientToken: req.ClientToken,
-		Path:        req.Path,
-		Data:        resp.Data,
-		Secret:      resp.Secret,
-		IssueTime:   time.Now(),
-		ExpireTime:  resp.Secret.ExpirationTime(),
+		LeaseID:         leaseID,
+		ClientToken:     req.ClientToken,
+		ClientTokenType: tokenType,
+		Path:            req.Path,
+		Data:            resp.Data,
+		Secret:          resp.Secret,
+		IssueTime:       time.Now(),
+		ExpireTime:      resp.Secret.ExpirationTime(),
 	}
 
 	// Encode the entry
@@ -875,8 +965,10 @@ func (m *ExpirationManager) Register(req *logical.Request, resp *logical.Respons
 	}
 
 	// Maintain secondary index by token
-	if err := m.createIndexByToken(le.ClientToken, le.LeaseID); err != nil {
-		return "", err
+	if indexToken != "" {
+		if err := m.createIndexByToken(indexToken, le.LeaseID); err != nil {
+			return "", err
+		}
 	}
 
 	// Setup revocation timer if there is a lease
@@ -920,6 +1012,11 @@ func (m *ExpirationManager) RegisterAuth(source string, auth *logical.Auth) erro
 		return err
 	}
 
+	// Maintain secondary index by alias
+	if err := m.createIndexByAlias(&le); err != nil {
+		return err
+	}
+
 	// Setup revocation timer
 	m.updatePending(&le, auth.LeaseTotal())
 	return nil
@@ -1074,13 +1171,23 @@ func (m *ExpirationManager) renewEntry(le *leaseEntry, increment time.Duration)
 	return resp, nil
 }
 
+// isTokenStorePath returns whether the path is routed to the token store of
+// any namespace
+func (m *ExpirationManager) isTokenStorePath(path string) bool {
+	if strings.HasPrefix(path, "auth/token/") {
+		return true
+	}
+	entry := m.router.MatchingMountEntry(path)
+	return entry != nil && entry.Table == credentialTableType && entry.Type == "token"
+}
+
 // renewAuthEntry is used to attempt renew of an auth entry. Only the token
 // store should get the actual token ID intact.
 func (m *ExpirationManager) renewAuthEntry(req *logical.Request, le *leaseEntry, increment time.Duration) (*logical.Response, error) {
 	auth := *le.Auth
 	auth.IssueTime = le.IssueTime
 	auth.Increment = increment
-	if strings.HasPrefix(le.Path, "auth/token/") {
+	if m.isTokenStorePath(le.Path) {
 		auth.ClientToken = le.ClientToken
 	} else {
 		auth.ClientToken = ""
@@ -1238,6 +1345,112 @@ func (m *ExpirationManager) removeIndexByToken(token, leaseID string) error {
 	return nil
 }
 
+// leaseIndexToken returns the token that a lease is indexed by. Leases of
+// batch tokens are indexed by the parent of the batch token, and orphan batch
+// tokens don't have an index.
+func (m *ExpirationManager) leaseIndexToken(le *leaseEntry) (string, error) {
+	if le.ClientTokenType != TokenTypeBatch {
+		return le.ClientToken, nil
+	}
+
+	te, err := m.tokenStore.decodeBatchToken(m.quitContext, le.ClientToken)
+	if err != nil {
+		return "", err
+	}
+	if te == nil {
+		return "", nil
+	}
+	return te.Parent, nil
+}
+
+// aliasIndexPrefix returns the prefix of the secondary index entries of the
+// login leases of an alias
+func (m *ExpirationManager) aliasIndexPrefix(mountAccessor, aliasName string) (string, error) {
+	saltedID, err := m.tokenStore.SaltID(m.quitContext, mountAccessor+"/"+aliasName)
+	if err != nil {
+		return "", err
+	}
+	return saltedID + "/", nil
+}
+
+// createIndexByAlias creates a secondary index from the alias of a login to
+// its lease entry. Logins without an alias aren't indexed.
+func (m *ExpirationManager) createIndexByAlias(le *leaseEntry) error {
+	if le.Auth == nil || le.Auth.Alias == nil || le.Auth.Alias.MountAccessor == "" {
+		return nil
+	}
+
+	prefix, err := m.aliasIndexPrefix(le.Auth.Alias.MountAccessor, le.Auth.Alias.Name)
+	if err != nil {
+		return err
+	}
+
+	leaseSaltedID, err := m.tokenStore.SaltID(m.quitContext, le.LeaseID)
+	if err != nil {
+		return err
+	}
+
+	ent := logical.StorageEntry{
+		Key:   prefix + leaseSaltedID,
+		Value: []byte(le.LeaseID),
+	}
+	if err := m.aliasView.Put(m.quitContext, &ent); err != nil {
+		return errwrap.Wrapf("failed to persist lease index entry: {{err}}", err)
+	}
+	return nil
+}
+
+// removeIndexByAlias removes the secondary index from the alias of a login to
+// its lease entry
+func (m *ExpirationManager) removeIndexByAlias(le *leaseEntry) error {
+	if le.Auth == nil || le.Auth.Alias == nil || le.Auth.Alias.MountAccessor == "" {
+		return nil
+	}
+
+	prefix, err := m.aliasIndexPrefix(le.Auth.Alias.MountAccessor, le.Auth.Alias.Name)
+	if err != nil {
+		return err
+	}
+
+	leaseSaltedID, err := m.tokenStore.SaltID(m.quitContext, le.LeaseID)
+	if err != nil {
+		return err
+	}
+
+	if err := m.aliasView.Delete(m.quitContext, prefix+leaseSaltedID); err != nil {
+		return errwrap.Wrapf("failed to delete lease index entry: {{err}}", err)
+	}
+	return nil
+}
+
+// lookupByAlias is used to lookup the leaseIDs of the logins of an alias via
+// the secondary index
+func (m *ExpirationManager) lookupByAlias(mountAccessor, aliasName string) ([]string, error) {
+	prefix, err := m.aliasIndexPrefix(mountAccessor, aliasName)
+	if err != nil {
+		return nil, err
+	}
+
+	subKeys, err := m.aliasView.List(m.quitContext, prefix)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list leases: {{err}}", err)
+	}
+
+	// Read each index entry
+	leaseIDs := make([]string, 0, len(subKeys))
+	for _, sub := range subKeys {
+		out, err := m.aliasView.Get(m.quitContext, prefix+sub)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to read lease index: {{err}}", err)
+		}
+		if out == nil {
+			continue
+		}
+		leaseIDs = append(leaseIDs, string(out.Value))
+	}
+	return leaseIDs, nil
+}
+
 // lookupByToken is used to lookup all the leaseID's via the
 func (m *ExpirationManager) lookupByToken(token string) ([]string, error) {
 	saltedID, err := m.tokenStore.SaltID(m.quitContext, token)
@@ -1289,6 +1502,7 @@ func (m *ExpirationManager) emitMetrics() {
 type leaseEntry struct {
 	LeaseID         string                 `json:"lease_id"`
 	ClientToken     string                 `json:"client_token"`
+	ClientTokenType TokenType              `json:"token_type"`
 	Path            string                 `json:"path"`
 	Data            map[string]interface{} `json:"data"`
 	Secret          *logical.Secret        `json:"secret"`
@@ -692,6 +692,74 @@ func TestExpiration_RevokePrefix(t *testing.T) {
 	}
 }
 
+func TestExpiration_RevokeByAlias(t *testing.T) {
+	exp := mockExpiration(t)
+
+	tokens := map[string]string{}
+	for _, name := range []string{"alice", "bob"} {
+		te := &TokenEntry{
+			Policies:     []string{"default"},
+			Path:         "auth/github/login",
+			DisplayName:  "github-" + name,
+			CreationTime: time.Now().Unix(),
+		}
+		if err := exp.tokenStore.create(context.Background(), te); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		tokens[name] = te.ID
+
+		auth := &logical.Auth{
+			ClientToken: te.ID,
+			LeaseOptions: logical.LeaseOptions{
+				TTL: time.Hour,
+			},
+			Alias: &logical.Alias{
+				MountAccessor: "auth_github_1234",
+				Name:          name,
+			},
+		}
+		if err := exp.RegisterAuth("auth/github/login", auth); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	if err := exp.RevokeByAlias("auth_github_1234", "alice"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for name, expectRevoked := range map[string]bool{"alice": true, "bob": false} {
+		te, err := exp.tokenStore.Lookup(context.Background(), tokens[name])
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if (te == nil) != expectRevoked {
+			t.Fatalf("%s: expected revoked %t, got token %#v", name, expectRevoked, te)
+		}
+	}
+
+	leaseIDs, err := exp.lookupByAlias("auth_github_1234", "bob")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(leaseIDs) != 1 {
+		t.Fatalf("expected one indexed lease, got %v", leaseIDs)
+	}
+
+	// Revoked logins are removed from the index, however they are revoked
+	if err := exp.tokenStore.Revoke(context.Background(), tokens["bob"]); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		leaseIDs, err := exp.lookupByAlias("auth_github_1234", name)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(leaseIDs) != 0 {
+			t.Fatalf("%s: expected no indexed leases, got %v", name, leaseIDs)
+		}
+	}
+}
+
 func TestExpiration_RevokeByToken(t *testing.T) {
 	exp := mockExpiration(t)
 	noop := &NoopBackend{}
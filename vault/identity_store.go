@@ -338,7 +338,7 @@ func (i *IdentityStore) CreateOrFetchEntity(alias *logical.Alias) (*identity.Ent
 	if err != nil {
 		return nil, err
 	}
-	if entity != nil {
+	if entity != nil && !aliasMetadataChanged(entity, alias) {
 		return entity, nil
 	}
 
@@ -347,11 +347,32 @@ func (i *IdentityStore) CreateOrFetchEntity(alias *logical.Alias) (*identity.Ent
 	defer txn.Abort()
 
 	// Check if an entity was created before acquiring the lock
-	entity, err = i.entityByAliasFactorsInTxn(txn, alias.MountAccessor, alias.Name, false)
+	entity, err = i.entityByAliasFactorsInTxn(txn, alias.MountAccessor, alias.Name, true)
 	if err != nil {
 		return nil, err
 	}
 	if entity != nil {
+		if !aliasMetadataChanged(entity, alias) {
+			return entity, nil
+		}
+
+		// The auth method reported new metadata for an existing alias
+		if err := validateMetadata(alias.Metadata); err != nil {
+			return nil, err
+		}
+		for _, entityAlias := range entity.Aliases {
+			if entityAlias.MountAccessor == alias.MountAccessor && entityAlias.Name == alias.Name {
+				entityAlias.Metadata = alias.Metadata
+			}
+		}
+
+		err = i.upsertEntityInTxn(txn, entity, nil, true, false)
+		if err != nil {
+			return nil, err
+		}
+
+		txn.Commit()
+
 		return entity, nil
 	}
 
@@ -371,6 +392,7 @@ func (i *IdentityStore) CreateOrFetchEntity(alias *logical.Alias) (*identity.Ent
 		MountAccessor: alias.MountAccessor,
 		MountPath:     mountValidationResp.MountPath,
 		MountType:     mountValidationResp.MountType,
+		Metadata:      alias.Metadata,
 	}
 
 	err = i.sanitizeAlias(newAlias)
@@ -393,3 +415,29 @@ func (i *IdentityStore) CreateOrFetchEntity(alias *logical.Alias) (*identity.Ent
 
 	return entity, nil
 }
+
+// aliasMetadataChanged returns true if the login alias carries metadata that
+// differs from what is stored on the matching alias of the entity. Aliases
+// from auth methods that don't report metadata never change it.
+func aliasMetadataChanged(entity *identity.Entity, alias *logical.Alias) bool {
+	if alias.Metadata == nil {
+		return false
+	}
+
+	for _, entityAlias := range entity.Aliases {
+		if entityAlias.MountAccessor != alias.MountAccessor || entityAlias.Name != alias.Name {
+			continue
+		}
+		if len(entityAlias.Metadata) != len(alias.Metadata) {
+			return true
+		}
+		for k, v := range alias.Metadata {
+			if existing, ok := entityAlias.Metadata[k]; !ok || existing != v {
+				return true
+			}
+		}
+		return false
+	}
+
+	return false
+}
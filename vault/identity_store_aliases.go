@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	memdb "github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -162,7 +163,7 @@ func (i *IdentityStore) pathAliasRegister() framework.OperationFunc {
 			return i.pathAliasIDUpdate()(ctx, req, d)
 		}
 
-		return i.handleAliasUpdateCommon(req, d, nil)
+		return i.handleAliasUpdateCommon(ctx, req, d, nil)
 	}
 }
 
@@ -185,12 +186,12 @@ func (i *IdentityStore) pathAliasIDUpdate() framework.OperationFunc {
 			return logical.ErrorResponse("invalid alias id"), nil
 		}
 
-		return i.handleAliasUpdateCommon(req, d, alias)
+		return i.handleAliasUpdateCommon(ctx, req, d, alias)
 	}
 }
 
 // handleAliasUpdateCommon is used to update an alias
-func (i *IdentityStore) handleAliasUpdateCommon(req *logical.Request, d *framework.FieldData, alias *identity.Alias) (*logical.Response, error) {
+func (i *IdentityStore) handleAliasUpdateCommon(ctx context.Context, req *logical.Request, d *framework.FieldData, alias *identity.Alias) (*logical.Response, error) {
 	var err error
 	var newAlias bool
 	var entity *identity.Entity
@@ -230,8 +231,9 @@ func (i *IdentityStore) handleAliasUpdateCommon(req *logical.Request, d *framewo
 		return logical.ErrorResponse("missing mount_accessor"), nil
 	}
 
+	// Aliases can only refer to mounts of the identity store's namespace
 	mountValidationResp := i.core.router.validateMountByAccessor(mountAccessor)
-	if mountValidationResp == nil {
+	if mountValidationResp == nil || mountValidationResp.namespace.ID != namespace.FromContext(ctx).ID {
 		return logical.ErrorResponse(fmt.Sprintf("invalid mount accessor %q", mountAccessor)), nil
 	}
 
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	memdb "github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -92,7 +93,7 @@ func (i *IdentityStore) pathGroupAliasRegister() framework.OperationFunc {
 		i.groupLock.Lock()
 		defer i.groupLock.Unlock()
 
-		return i.handleGroupAliasUpdateCommon(req, d, nil)
+		return i.handleGroupAliasUpdateCommon(ctx, req, d, nil)
 	}
 }
 
@@ -114,11 +115,11 @@ func (i *IdentityStore) pathGroupAliasIDUpdate() framework.OperationFunc {
 			return logical.ErrorResponse("invalid group alias ID"), nil
 		}
 
-		return i.handleGroupAliasUpdateCommon(req, d, groupAlias)
+		return i.handleGroupAliasUpdateCommon(ctx, req, d, groupAlias)
 	}
 }
 
-func (i *IdentityStore) handleGroupAliasUpdateCommon(req *logical.Request, d *framework.FieldData, groupAlias *identity.Alias) (*logical.Response, error) {
+func (i *IdentityStore) handleGroupAliasUpdateCommon(ctx context.Context, req *logical.Request, d *framework.FieldData, groupAlias *identity.Alias) (*logical.Response, error) {
 	var err error
 	var newGroupAlias bool
 	var group *identity.Group
@@ -153,8 +154,9 @@ func (i *IdentityStore) handleGroupAliasUpdateCommon(req *logical.Request, d *fr
 		return logical.ErrorResponse("missing mount_accessor"), nil
 	}
 
+	// Aliases can only refer to mounts of the identity store's namespace
 	mountValidationResp := i.core.router.validateMountByAccessor(mountAccessor)
-	if mountValidationResp == nil {
+	if mountValidationResp == nil || mountValidationResp.namespace.ID != namespace.FromContext(ctx).ID {
 		return logical.ErrorResponse(fmt.Sprintf("invalid mount accessor %q", mountAccessor)), nil
 	}
 
@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -50,6 +51,49 @@ func TestIdentityStore_CreateOrFetchEntity(t *testing.T) {
 	}
 }
 
+func TestIdentityStore_CreateOrFetchEntity_AliasMetadata(t *testing.T) {
+	is, ghAccessor, _ := testIdentityStoreWithGithubAuth(t)
+	alias := &logical.Alias{
+		MountType:     "github",
+		MountAccessor: ghAccessor,
+		Name:          "githubuser",
+		Metadata: map[string]string{
+			"team": "engineering",
+		},
+	}
+
+	entity, err := is.CreateOrFetchEntity(alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entity.Aliases[0].Metadata, alias.Metadata) {
+		t.Fatalf("bad: alias metadata; expected: %#v, actual: %#v", alias.Metadata, entity.Aliases[0].Metadata)
+	}
+
+	// New metadata reported at login replaces the stored metadata
+	alias.Metadata = map[string]string{
+		"team": "support",
+	}
+	entity, err = is.CreateOrFetchEntity(alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entity.Aliases[0].Metadata, alias.Metadata) {
+		t.Fatalf("bad: alias metadata; expected: %#v, actual: %#v", alias.Metadata, entity.Aliases[0].Metadata)
+	}
+
+	// Logins without metadata leave it untouched
+	expected := alias.Metadata
+	alias.Metadata = nil
+	entity, err = is.CreateOrFetchEntity(alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entity.Aliases[0].Metadata, expected) {
+		t.Fatalf("bad: alias metadata; expected: %#v, actual: %#v", expected, entity.Aliases[0].Metadata)
+	}
+}
+
 func TestIdentityStore_EntityByAliasFactors(t *testing.T) {
 	var err error
 	var resp *logical.Response
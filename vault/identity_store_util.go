@@ -13,30 +13,51 @@ import (
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/storagepacker"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 )
 
 func (c *Core) loadIdentityStoreArtifacts(ctx context.Context) error {
-	var err error
 	if c.identityStore == nil {
 		return fmt.Errorf("identity store is not setup")
 	}
 
-	err = c.identityStore.loadEntities(ctx)
-	if err != nil {
-		return err
+	// Every namespace has its own identity store
+	var stores []*IdentityStore
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if entry.Type != "identity" {
+			continue
+		}
+		if store := c.namespaceIdentityStore(entry.Namespace()); store != nil {
+			stores = append(stores, store)
+		}
 	}
+	c.mountsLock.RUnlock()
 
-	err = c.identityStore.loadGroups(ctx)
-	if err != nil {
-		return err
+	for _, store := range stores {
+		if err := store.loadEntities(ctx); err != nil {
+			return err
+		}
+		if err := store.loadGroups(ctx); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// namespaceIdentityStore returns the identity store of the given namespace
+func (c *Core) namespaceIdentityStore(ns *namespace.Namespace) *IdentityStore {
+	if ns.ID == namespace.RootNamespaceID {
+		return c.identityStore
+	}
+	store, _ := c.router.MatchingBackend(ns.Path + "identity/").(*IdentityStore)
+	return store
+}
+
 func (i *IdentityStore) loadGroups(ctx context.Context) error {
 	i.logger.Debug("identity loading groups")
 	existing, err := i.groupPacker.View().List(ctx, groupBucketsPrefix)
@@ -22,6 +22,7 @@ import (
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/compressutil"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/wrapping"
 	"github.com/hashicorp/vault/logical"
@@ -85,6 +86,7 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"leases/revoke-prefix/*",
 				"leases/revoke-force/*",
 				"leases/lookup/*",
+				"storage/raft/*",
 			},
 
 			Unauthenticated: []string{
@@ -122,6 +124,24 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["capabilities_accessor"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "metrics$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"format": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "Format to export metrics into. Currently accepts only \"prometheus\".",
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleMetricsQuery,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["metrics"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["metrics"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "config/cors$",
 
@@ -243,6 +263,12 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["generate-root"][1]),
 			},
 
+			&framework.Path{
+				Pattern:         "storage/snapshot$",
+				HelpSynopsis:    strings.TrimSpace(sysHelp["storage-snapshot"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["storage-snapshot"][1]),
+			},
+
 			&framework.Path{
 				Pattern:         "init$",
 				HelpSynopsis:    strings.TrimSpace(sysHelp["init"][0]),
@@ -316,6 +342,10 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 						Type:        framework.TypeCommaStringSlice,
 						Description: strings.TrimSpace(sysHelp["passthrough_request_headers"][0]),
 					},
+					"mfa_methods": &framework.FieldSchema{
+						Type:        framework.TypeCommaStringSlice,
+						Description: strings.TrimSpace(sysHelp["tune_mfa_methods"][0]),
+					},
 				},
 				Callbacks: map[logical.Operation]framework.OperationFunc{
 					logical.ReadOperation:   b.handleAuthTuneRead,
@@ -365,6 +395,10 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 						Type:        framework.TypeCommaStringSlice,
 						Description: strings.TrimSpace(sysHelp["passthrough_request_headers"][0]),
 					},
+					"mfa_methods": &framework.FieldSchema{
+						Type:        framework.TypeCommaStringSlice,
+						Description: strings.TrimSpace(sysHelp["tune_mfa_methods"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -1072,10 +1106,21 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-ui-mounts"][0]),
 				HelpDescription: strings.TrimSpace(sysHelp["internal-ui-mounts"][1]),
 			},
+			&framework.Path{
+				Pattern: "internal/specs/openapi",
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.pathInternalOpenAPI,
+				},
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-specs-openapi"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal-specs-openapi"][1]),
+			},
 		},
 	}
 
 	b.Backend.Paths = append(b.Backend.Paths, replicationPaths(b)...)
+	b.Backend.Paths = append(b.Backend.Paths, b.raftStoragePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.namespacePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.loginMFAPaths()...)
 
 	if core.rawEnabled {
 		b.Backend.Paths = append(b.Backend.Paths, &framework.Path{
@@ -1100,6 +1145,7 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 	}
 
 	b.Backend.Invalidate = b.invalidate
+	b.Backend.PeriodicFunc = b.periodicFunc
 
 	return b
 }
@@ -1113,6 +1159,17 @@ type SystemBackend struct {
 	logger log.Logger
 }
 
+// handleMetricsQuery returns the metrics collected in memory, in the JSON
+// format or in the Prometheus text format
+func (b *SystemBackend) handleMetricsQuery(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if b.Core.metricsHelper == nil {
+		return logical.ErrorResponse("metrics are not available"), logical.ErrInvalidRequest
+	}
+
+	format := d.Get("format").(string)
+	return b.Core.metricsHelper.ResponseForFormat(format), nil
+}
+
 // handleCORSRead returns the current CORS configuration
 func (b *SystemBackend) handleCORSRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	corsConf := b.Core.corsConfig
@@ -1181,6 +1238,12 @@ func (b *SystemBackend) invalidate(ctx context.Context, key string) {
 	}
 }
 
+// periodicFunc is invoked by the rollback manager and cleans up the logins
+// that have not completed MFA in time
+func (b *SystemBackend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	return b.Core.loginMFA.tidyPending(ctx)
+}
+
 func (b *SystemBackend) handlePluginCatalogList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	plugins, err := b.Core.pluginCatalog.List(ctx)
 	if err != nil {
@@ -1417,8 +1480,9 @@ func (b *SystemBackend) handleCapabilities(ctx context.Context, req *logical.Req
 		return logical.ErrorResponse("paths must be supplied"), nil
 	}
 
+	ns := namespace.FromContext(ctx)
 	for _, path := range paths {
-		pathCap, err := b.Core.Capabilities(ctx, token, path)
+		pathCap, err := b.Core.Capabilities(ctx, token, ns.Path+path)
 		if err != nil {
 			return nil, err
 		}
@@ -1516,7 +1580,13 @@ func (b *SystemBackend) handleMountTable(ctx context.Context, req *logical.Reque
 		Data: make(map[string]interface{}),
 	}
 
+	ns := namespace.FromContext(ctx)
 	for _, entry := range b.Core.mounts.Entries {
+		// Only list the mounts of the request's namespace
+		if entry.Namespace().ID != ns.ID {
+			continue
+		}
+
 		// Populate mount info
 		info := map[string]interface{}{
 			"type":        entry.Type,
@@ -1728,17 +1798,18 @@ func handleErrorNoReadOnlyForward(
 func (b *SystemBackend) handleUnmount(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	path := data.Get("path").(string)
 	path = sanitizeMountPath(path)
+	fullPath := namespace.FromContext(ctx).Path + path
 
 	repState := b.Core.ReplicationState()
-	entry := b.Core.router.MatchingMountEntry(path)
+	entry := b.Core.router.MatchingMountEntry(fullPath)
 	if entry != nil && !entry.Local && repState.HasState(consts.ReplicationPerformanceSecondary) {
 		return logical.ErrorResponse("cannot unmount a non-local mount on a replication secondary"), nil
 	}
 
 	// We return success when the mount does not exists to not expose if the
 	// mount existed or not
-	match := b.Core.router.MatchingMount(path)
-	if match == "" || path != match {
+	match := b.Core.router.MatchingMount(fullPath)
+	if match == "" || fullPath != match {
 		return nil, nil
 	}
 
@@ -1767,7 +1838,7 @@ func (b *SystemBackend) handleRemount(ctx context.Context, req *logical.Request,
 	fromPath = sanitizeMountPath(fromPath)
 	toPath = sanitizeMountPath(toPath)
 
-	entry := b.Core.router.MatchingMountEntry(fromPath)
+	entry := b.Core.router.MatchingMountEntry(namespace.FromContext(ctx).Path + fromPath)
 	if entry != nil && !entry.Local && repState.HasState(consts.ReplicationPerformanceSecondary) {
 		return logical.ErrorResponse("cannot remount a non-local mount on a replication secondary"), nil
 	}
@@ -1789,7 +1860,7 @@ func (b *SystemBackend) handleAuthTuneRead(ctx context.Context, req *logical.Req
 				"path must be specified as a string"),
 			logical.ErrInvalidRequest
 	}
-	return b.handleTuneReadCommon("auth/" + path)
+	return b.handleTuneReadCommon(ctx, "auth/"+path)
 }
 
 // handleMountTuneRead is used to get config settings on a backend
@@ -1804,21 +1875,22 @@ func (b *SystemBackend) handleMountTuneRead(ctx context.Context, req *logical.Re
 	// This call will read both logical backend's configuration as well as auth methods'.
 	// Retaining this behavior for backward compatibility. If this behavior is not desired,
 	// an error can be returned if path has a prefix of "auth/".
-	return b.handleTuneReadCommon(path)
+	return b.handleTuneReadCommon(ctx, path)
 }
 
 // handleTuneReadCommon returns the config settings of a path
-func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, error) {
+func (b *SystemBackend) handleTuneReadCommon(ctx context.Context, path string) (*logical.Response, error) {
 	path = sanitizeMountPath(path)
+	ns := namespace.FromContext(ctx)
 
-	sysView := b.Core.router.MatchingSystemView(path)
+	sysView := b.Core.router.MatchingSystemView(ns.Path + path)
 	if sysView == nil {
 		b.Backend.Logger().Error("cannot fetch sysview", "path", path)
 		return handleError(fmt.Errorf("sys: cannot fetch sysview for path %q", path))
 	}
 
-	mountEntry := b.Core.router.MatchingMountEntry(path)
-	if mountEntry == nil {
+	mountEntry := b.Core.router.MatchingMountEntry(ns.Path + path)
+	if mountEntry == nil || mountEntry.Namespace().ID != ns.ID {
 		b.Backend.Logger().Error("cannot fetch mount entry", "path", path)
 		return handleError(fmt.Errorf("sys: cannot fetch mount entry for path %q", path))
 	}
@@ -1847,6 +1919,10 @@ func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, er
 		resp.Data["passthrough_request_headers"] = rawVal.([]string)
 	}
 
+	if rawVal, ok := mountEntry.synthesizedConfigCache.Load("mfa_methods"); ok {
+		resp.Data["mfa_methods"] = rawVal.([]string)
+	}
+
 	if len(mountEntry.Options) > 0 {
 		resp.Data["options"] = mountEntry.Options
 	}
@@ -1891,8 +1967,11 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 		}
 	}
 
-	mountEntry := b.Core.router.MatchingMountEntry(path)
-	if mountEntry == nil {
+	// Mounts are looked up by their full path and can only be tuned from
+	// within their namespace
+	ns := namespace.FromContext(ctx)
+	mountEntry := b.Core.router.MatchingMountEntry(ns.Path + path)
+	if mountEntry == nil || mountEntry.Namespace().ID != ns.ID {
 		b.Backend.Logger().Error("tune failed: no mount entry found", "path", path)
 		return handleError(fmt.Errorf("tune of path %q failed: no mount entry found", path))
 	}
@@ -1912,7 +1991,7 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 	defer lock.Unlock()
 
 	// Check again after grabbing the lock
-	mountEntry = b.Core.router.MatchingMountEntry(path)
+	mountEntry = b.Core.router.MatchingMountEntry(ns.Path + path)
 	if mountEntry == nil {
 		b.Backend.Logger().Error("tune failed: no mount entry found", "path", path)
 		return handleError(fmt.Errorf("tune of path %q failed: no mount entry found", path))
@@ -2091,6 +2170,27 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 		}
 	}
 
+	if rawVal, ok := data.GetOk("mfa_methods"); ok {
+		if mountEntry.Table != credentialTableType {
+			return logical.ErrorResponse("mfa_methods can only be set on auth methods"), logical.ErrInvalidRequest
+		}
+
+		oldVal := mountEntry.Config.MFAMethods
+		mountEntry.Config.MFAMethods = rawVal.([]string)
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, mountEntry.Local); err != nil {
+			mountEntry.Config.MFAMethods = oldVal
+			return handleError(err)
+		}
+
+		mountEntry.SyncCache()
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("core: mount tuning of mfa_methods successful", "path", path)
+		}
+	}
+
 	var resp *logical.Response
 	if optionsRaw, ok := data.GetOk("options"); ok {
 		b.Core.logger.Info("core: mount tuning of options", "path", path)
@@ -2143,6 +2243,12 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 	return resp, nil
 }
 
+// leaseInNamespace returns whether the lease was issued in the request's
+// namespace or in one beneath it
+func leaseInNamespace(ctx context.Context, leaseID string) bool {
+	return strings.HasPrefix(leaseID, namespace.FromContext(ctx).Path)
+}
+
 // handleLease is use to view the metadata for a given LeaseID
 func (b *SystemBackend) handleLeaseLookup(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	leaseID := data.Get("lease_id").(string)
@@ -2150,6 +2256,9 @@ func (b *SystemBackend) handleLeaseLookup(ctx context.Context, req *logical.Requ
 		return logical.ErrorResponse("lease_id must be specified"),
 			logical.ErrInvalidRequest
 	}
+	if !leaseInNamespace(ctx, leaseID) {
+		return logical.ErrorResponse("invalid lease"), logical.ErrInvalidRequest
+	}
 
 	leaseTimes, err := b.Core.expiration.FetchLeaseTimes(leaseID)
 	if err != nil {
@@ -2188,6 +2297,9 @@ func (b *SystemBackend) handleLeaseLookupList(ctx context.Context, req *logical.
 		prefix = prefix + "/"
 	}
 
+	// Leases are listed relative to the request's namespace
+	prefix = namespace.FromContext(ctx).Path + prefix
+
 	keys, err := b.Core.expiration.idView.List(ctx, prefix)
 	if err != nil {
 		b.Backend.Logger().Error("error listing leases", "prefix", prefix, "error", err)
@@ -2207,6 +2319,9 @@ func (b *SystemBackend) handleRenew(ctx context.Context, req *logical.Request, d
 		return logical.ErrorResponse("lease_id must be specified"),
 			logical.ErrInvalidRequest
 	}
+	if !leaseInNamespace(ctx, leaseID) {
+		return logical.ErrorResponse("invalid lease"), logical.ErrInvalidRequest
+	}
 	incrementRaw := data.Get("increment").(int)
 
 	// Convert the increment
@@ -2232,6 +2347,9 @@ func (b *SystemBackend) handleRevoke(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse("lease_id must be specified"),
 			logical.ErrInvalidRequest
 	}
+	if !leaseInNamespace(ctx, leaseID) {
+		return logical.ErrorResponse("invalid lease"), logical.ErrInvalidRequest
+	}
 
 	// Invoke the expiration manager directly
 	if err := b.Core.expiration.Revoke(leaseID); err != nil {
@@ -2243,19 +2361,19 @@ func (b *SystemBackend) handleRevoke(ctx context.Context, req *logical.Request,
 
 // handleRevokePrefix is used to revoke a prefix with many LeaseIDs
 func (b *SystemBackend) handleRevokePrefix(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	return b.handleRevokePrefixCommon(req, data, false)
+	return b.handleRevokePrefixCommon(ctx, req, data, false)
 }
 
 // handleRevokeForce is used to revoke a prefix with many LeaseIDs, ignoring errors
 func (b *SystemBackend) handleRevokeForce(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	return b.handleRevokePrefixCommon(req, data, true)
+	return b.handleRevokePrefixCommon(ctx, req, data, true)
 }
 
 // handleRevokePrefixCommon is used to revoke a prefix with many LeaseIDs
-func (b *SystemBackend) handleRevokePrefixCommon(
+func (b *SystemBackend) handleRevokePrefixCommon(ctx context.Context,
 	req *logical.Request, data *framework.FieldData, force bool) (*logical.Response, error) {
-	// Get all the options
-	prefix := data.Get("prefix").(string)
+	// Get all the options; the prefix is relative to the request's namespace
+	prefix := namespace.FromContext(ctx).Path + data.Get("prefix").(string)
 
 	// Invoke the expiration manager directly
 	var err error
@@ -2279,7 +2397,13 @@ func (b *SystemBackend) handleAuthTable(ctx context.Context, req *logical.Reques
 	resp := &logical.Response{
 		Data: make(map[string]interface{}),
 	}
+	ns := namespace.FromContext(ctx)
 	for _, entry := range b.Core.auth.Entries {
+		// Only list the auth methods of the request's namespace
+		if entry.Namespace().ID != ns.ID {
+			continue
+		}
+
 		info := map[string]interface{}{
 			"type":        entry.Type,
 			"description": entry.Description,
@@ -2307,6 +2431,9 @@ func (b *SystemBackend) handleAuthTable(ctx context.Context, req *logical.Reques
 		if rawVal, ok := entry.synthesizedConfigCache.Load("passthrough_request_headers"); ok {
 			entryConfig["passthrough_request_headers"] = rawVal.([]string)
 		}
+		if rawVal, ok := entry.synthesizedConfigCache.Load("mfa_methods"); ok {
+			entryConfig["mfa_methods"] = rawVal.([]string)
+		}
 
 		info["config"] = entryConfig
 		resp.Data[entry.Path] = info
@@ -2417,6 +2544,9 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 	if len(apiConfig.PassthroughRequestHeaders) > 0 {
 		config.PassthroughRequestHeaders = apiConfig.PassthroughRequestHeaders
 	}
+	if len(apiConfig.MFAMethods) > 0 {
+		config.MFAMethods = apiConfig.MFAMethods
+	}
 
 	// Create the mount entry
 	me := &MountEntry{
@@ -2443,7 +2573,7 @@ func (b *SystemBackend) handleDisableAuth(ctx context.Context, req *logical.Requ
 	path := data.Get("path").(string)
 	path = sanitizeMountPath(path)
 
-	fullPath := credentialRoutePrefix + path
+	fullPath := namespace.FromContext(ctx).Path + credentialRoutePrefix + path
 
 	repState := b.Core.ReplicationState()
 	entry := b.Core.router.MatchingMountEntry(fullPath)
@@ -3072,7 +3202,7 @@ func (b *SystemBackend) responseWrappingUnwrap(ctx context.Context, token string
 
 	cubbyReq := &logical.Request{
 		Operation:   logical.ReadOperation,
-		Path:        "cubbyhole/response",
+		Path:        b.Core.wrappingCubbyholePath(ctx, token, "response"),
 		ClientToken: token,
 	}
 	cubbyResp, err := b.Core.router.Route(ctx, cubbyReq)
@@ -3114,7 +3244,7 @@ func (b *SystemBackend) handleWrappingLookup(ctx context.Context, req *logical.R
 
 	cubbyReq := &logical.Request{
 		Operation:   logical.ReadOperation,
-		Path:        "cubbyhole/wrapinfo",
+		Path:        b.Core.wrappingCubbyholePath(ctx, token, "wrapinfo"),
 		ClientToken: token,
 	}
 	cubbyResp, err := b.Core.router.Route(ctx, cubbyReq)
@@ -3183,7 +3313,7 @@ func (b *SystemBackend) handleWrappingRewrap(ctx context.Context, req *logical.R
 	// Fetch the original TTL
 	cubbyReq := &logical.Request{
 		Operation:   logical.ReadOperation,
-		Path:        "cubbyhole/wrapinfo",
+		Path:        b.Core.wrappingCubbyholePath(ctx, token, "wrapinfo"),
 		ClientToken: token,
 	}
 	cubbyResp, err := b.Core.router.Route(ctx, cubbyReq)
@@ -3220,7 +3350,7 @@ func (b *SystemBackend) handleWrappingRewrap(ctx context.Context, req *logical.R
 	// Fetch the original response and return it as the data for the new response
 	cubbyReq = &logical.Request{
 		Operation:   logical.ReadOperation,
-		Path:        "cubbyhole/response",
+		Path:        b.Core.wrappingCubbyholePath(ctx, token, "response"),
 		ClientToken: token,
 	}
 	cubbyResp, err = b.Core.router.Route(ctx, cubbyReq)
@@ -3368,7 +3498,11 @@ func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logic
 	resp.Data["secret"] = secretMounts
 	resp.Data["auth"] = authMounts
 
+	ns := namespace.FromContext(ctx)
 	for _, entry := range b.Core.mounts.Entries {
+		if entry.Namespace().ID != ns.ID {
+			continue
+		}
 		if entry.Config.ListingVisibility == ListingVisibilityUnauth {
 			info := map[string]interface{}{
 				"type":        entry.Type,
@@ -3379,6 +3513,9 @@ func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logic
 	}
 
 	for _, entry := range b.Core.auth.Entries {
+		if entry.Namespace().ID != ns.ID {
+			continue
+		}
 		if entry.Config.ListingVisibility == ListingVisibilityUnauth {
 			info := map[string]interface{}{
 				"type":        entry.Type,
@@ -3391,6 +3528,93 @@ func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logic
 	return resp, nil
 }
 
+// pathInternalOpenAPI returns an OpenAPI document covering the paths of every
+// mounted secrets engine and auth method, prefixed with their mount paths
+func (b *SystemBackend) pathInternalOpenAPI(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	type mount struct {
+		prefix string
+		tag    string
+	}
+
+	// Collect the mounts of the request's namespace first so that the
+	// tables aren't locked while the backends are queried
+	ns := namespace.FromContext(ctx)
+	var mounts []mount
+	b.Core.mountsLock.RLock()
+	for _, entry := range b.Core.mounts.Entries {
+		if entry.Namespace().ID != ns.ID {
+			continue
+		}
+		tag := "secrets"
+		switch entry.Type {
+		case "system":
+			tag = "system"
+		case "identity":
+			tag = "identity"
+		}
+		mounts = append(mounts, mount{prefix: entry.Path, tag: tag})
+	}
+	b.Core.mountsLock.RUnlock()
+
+	b.Core.authLock.RLock()
+	for _, entry := range b.Core.auth.Entries {
+		if entry.Namespace().ID != ns.ID {
+			continue
+		}
+		mounts = append(mounts, mount{prefix: credentialRoutePrefix + entry.Path, tag: "auth"})
+	}
+	b.Core.authLock.RUnlock()
+
+	doc := framework.NewOASDocument()
+	for _, m := range mounts {
+		backend := b.Core.router.MatchingBackend(ns.Path + m.prefix)
+		if backend == nil {
+			continue
+		}
+
+		resp, err := backend.HandleRequest(ctx, &logical.Request{
+			Operation: logical.HelpOperation,
+			Storage:   b.Core.router.MatchingStorageByAPIPath(ns.Path + m.prefix),
+		})
+		if err != nil || resp == nil || resp.Data["openapi"] == nil {
+			// Not every backend can describe its paths
+			continue
+		}
+
+		backendDoc, err := framework.NewOASDocumentFromMap(resp.Data["openapi"])
+		if err != nil {
+			b.logger.Warn("error parsing OpenAPI document", "mount", m.prefix, "error", err)
+			continue
+		}
+
+		for path, item := range backendDoc.Paths {
+			// Child namespaces only have access to some of the system paths
+			if m.tag == "system" && ns.ID != namespace.RootNamespaceID && !namespaceSysPathAllowed(m.prefix+strings.TrimPrefix(path, "/")) {
+				continue
+			}
+			for _, op := range []*framework.OASOperation{item.Get, item.Post, item.Delete} {
+				if op != nil {
+					op.Tags = []string{m.tag}
+				}
+			}
+			doc.Paths["/"+m.prefix+strings.TrimPrefix(path, "/")] = item
+		}
+	}
+
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  200,
+			logical.HTTPRawBody:     buf,
+			logical.HTTPContentType: "application/json",
+		},
+	}, nil
+}
+
 func sanitizeMountPath(path string) string {
 	if !strings.HasSuffix(path, "/") {
 		path += "/"
@@ -3422,6 +3646,14 @@ as well as perform core operations.
 
 // sysHelp is all the help text for the sys backend.
 var sysHelp = map[string][2]string{
+	"metrics": {
+		"Export the metrics aggregated for telemetry purpose.",
+		`
+This path returns the metrics collected by this node. By default the summary
+of the most recent interval is returned as JSON. With the "format" parameter
+set to "prometheus", the metrics are returned in the Prometheus text format.
+		`,
+	},
 	"config/cors": {
 		"Configures or returns the current configuration of CORS settings.",
 		`
@@ -3862,6 +4094,78 @@ Enable a new audit backend or disable an existing backend.
 		The path will be searched for a path match in all the policies associated with the client token.`,
 	},
 
+	"namespaces-list": {
+		"List the child namespaces of the namespace.",
+		`
+This path lists the direct children of the namespace of the request. Each key
+is the path of a child namespace relative to its parent.
+		`,
+	},
+
+	"namespaces": {
+		"Create, read or delete a child namespace.",
+		`
+This path manages the direct children of the namespace of the request. A new
+namespace has its own mounts, policies, tokens and entities. Deleting a
+namespace revokes all of its leases and removes its mounts and policies; it
+must not have any child namespaces.
+		`,
+	},
+
+	"namespaces-path": {
+		"The name of the child namespace.",
+		"",
+	},
+
+	"mfa-method-list": {
+		"List the login MFA methods.",
+		`
+This path lists the configured login MFA methods, along with their type.
+		`,
+	},
+
+	"mfa-method": {
+		"Configure a login MFA method.",
+		`
+This path configures a TOTP, Duo or Okta Verify method that logins can be
+required to pass. Methods are required by listing their name in the
+mfa_methods of a policy or of an auth method, tuned through
+sys/auth/<path>/tune. A login requiring MFA does not return a token but an
+MFA request ID, to be passed along with the passcodes of the methods to
+sys/mfa/validate.
+		`,
+	},
+
+	"mfa-method-name": {
+		"The name of the MFA method.",
+		"",
+	},
+
+	"mfa-totp-generate": {
+		"Generate a TOTP secret for the entity of the token.",
+		`
+This path generates a TOTP secret for the entity of the requesting token and
+returns it as an otpauth URL and a QR code. A secret can only be generated
+once; an administrator must destroy it before a new one can be generated.
+		`,
+	},
+
+	"mfa-totp-admin-generate": {
+		"Generate a TOTP secret for an entity.",
+		`
+This path generates a TOTP secret for the given entity and returns it as an
+otpauth URL and a QR code.
+		`,
+	},
+
+	"mfa-totp-admin-destroy": {
+		"Destroy the TOTP secret of an entity.",
+		`
+This path destroys the TOTP secret of the given entity, so that a new one can
+be generated.
+		`,
+	},
+
 	"capabilities_accessor": {
 		"Fetches the capabilities of the token associated with the given token, on the given path.",
 		`When there is no access to the token, token accessor can be used to fetch the token's capabilities
@@ -4005,4 +4309,41 @@ This path responds to the following HTTP methods.
 	"passthrough_request_headers": {
 		"A list of headers to whitelist and pass from the request to the backend.",
 	},
+	"tune_mfa_methods": {
+		"A list of login MFA methods that must be passed to log in with the auth method.",
+	},
+	"storage-snapshot": {
+		"Saves or restores a snapshot of the storage backend.",
+		`
+		A GET returns a gzipped, point-in-time copy of every entry in the
+		storage backend. Values are copied as stored, so they remain encrypted
+		by the barrier. A PUT or POST with a snapshot as the request body
+		replaces the contents of the storage backend with the snapshot in a
+		single transaction and then seals Vault. Requires a token with root
+		policy or sudo capability on the path.
+		`,
+	},
+	"raft-join": {
+		"Adds a node to the raft storage cluster.",
+		`
+		Adds the node with the given raft node ID and address to the raft
+		storage cluster as a voting member. The node must be running with raft
+		storage and must not have bootstrapped its own cluster. Once it has
+		caught up it can be unsealed with the cluster's unseal keys.
+		`,
+	},
+	"raft-configuration": {
+		"Returns the raft storage cluster configuration.",
+		`
+		Returns the node ID, address, leadership and voting status of every
+		member of the raft storage cluster.
+		`,
+	},
+	"raft-remove-peer": {
+		"Removes a node from the raft storage cluster.",
+		`
+		Removes the node with the given raft node ID from the raft storage
+		cluster.
+		`,
+	},
 }
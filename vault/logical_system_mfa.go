@@ -0,0 +1,490 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	otplib "github.com/pquerna/otp"
+)
+
+// loginMFAPaths returns the paths used to configure the login MFA methods and
+// to generate the TOTP secrets of entities
+func (b *SystemBackend) loginMFAPaths() []*framework.Path {
+	totpEntityFields := map[string]*framework.FieldSchema{
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-name"][0]),
+		},
+		"entity_id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the entity.",
+		},
+	}
+
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "mfa/method/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleMFAMethodList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-method-list"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-method-list"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "mfa/method/(?P<type>" + strings.Join(mfaMethodTypes, "|") + ")/" + framework.GenericNameRegex("name") + "$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"type": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Type of the MFA method: totp, duo or okta.",
+				},
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mfa-method-name"][0]),
+				},
+				"username_format": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Default:     loginMFADefaultUsernameFormat,
+					Description: `Format of the username sent to Duo or Okta. "{{alias.name}}", "{{entity.name}}", "{{alias.metadata.<key>}}" and "{{entity.metadata.<key>}}" are replaced with the values of the alias and entity of the user.`,
+				},
+				"mount_accessor": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Duo and Okta: the accessor of the auth mount whose alias of the entity fills in the username format. Defaults to the alias the user logged in with.",
+				},
+				"issuer": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "TOTP: the name of the issuer shown in authenticator apps.",
+				},
+				"period": &framework.FieldSchema{
+					Type:        framework.TypeDurationSecond,
+					Default:     30,
+					Description: "TOTP: the length of time a passcode is valid for.",
+				},
+				"digits": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Default:     6,
+					Description: "TOTP: the number of digits of passcodes, 6 or 8.",
+				},
+				"algorithm": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Default:     "SHA1",
+					Description: "TOTP: the hashing algorithm used, SHA1, SHA256 or SHA512.",
+				},
+				"key_size": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Default:     20,
+					Description: "TOTP: the size in bytes of generated keys.",
+				},
+				"skew": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Default:     1,
+					Description: "TOTP: the number of periods before and after the current one passcodes are accepted for, 0 or 1.",
+				},
+				"qr_size": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Default:     200,
+					Description: "TOTP: the pixel size of generated QR codes. 0 disables QR codes.",
+				},
+				"integration_key": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Duo: the integration key.",
+				},
+				"secret_key": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Duo: the secret key.",
+				},
+				"api_hostname": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Duo: the API hostname.",
+				},
+				"push_info": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Duo: URL-encoded key/value pairs shown in push notifications.",
+				},
+				"use_passcode": &framework.FieldSchema{
+					Type:        framework.TypeBool,
+					Description: "Duo: require a passcode instead of sending a push notification.",
+				},
+				"org_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Okta: the name of the organization.",
+				},
+				"api_token": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Okta: the API token.",
+				},
+				"base_url": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: `Okta: the base domain of the API. Defaults to "okta.com".`,
+				},
+				"primary_email": &framework.FieldSchema{
+					Type:        framework.TypeBool,
+					Description: "Okta: match the username against the primary email of users instead of their login.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleMFAMethodRead,
+				logical.UpdateOperation: b.handleMFAMethodWrite,
+				logical.DeleteOperation: b.handleMFAMethodDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-method"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-method"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "mfa/method/totp/" + framework.GenericNameRegex("name") + "/generate$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mfa-method-name"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleMFATOTPGenerate,
+				logical.UpdateOperation: b.handleMFATOTPGenerate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-totp-generate"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-totp-generate"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "mfa/method/totp/" + framework.GenericNameRegex("name") + "/admin-generate$",
+
+			Fields: totpEntityFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleMFATOTPAdminGenerate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-totp-admin-generate"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-totp-admin-generate"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "mfa/method/totp/" + framework.GenericNameRegex("name") + "/admin-destroy$",
+
+			Fields: totpEntityFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleMFATOTPAdminDestroy,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-totp-admin-destroy"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-totp-admin-destroy"][1]),
+		},
+	}
+}
+
+// handleMFAMethodList lists the configured MFA methods along with their type
+func (b *SystemBackend) handleMFAMethodList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	names, err := b.Core.loginMFA.listMethods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		method, err := b.Core.loginMFA.method(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if method == nil {
+			continue
+		}
+		keyInfo[name] = map[string]interface{}{
+			"type": method.Type,
+		}
+	}
+
+	return logical.ListResponseWithInfo(names, keyInfo), nil
+}
+
+// mfaMethodOfType returns the named MFA method, or an error response if it
+// exists with another type
+func (b *SystemBackend) mfaMethodOfType(ctx context.Context, methodType, name string) (*MFAMethod, *logical.Response, error) {
+	method, err := b.Core.loginMFA.method(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if method != nil && method.Type != methodType {
+		return nil, logical.ErrorResponse(fmt.Sprintf("MFA method %q is of type %q", name, method.Type)), nil
+	}
+	return method, nil, nil
+}
+
+func (b *SystemBackend) handleMFAMethodRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	method, errResp, err := b.mfaMethodOfType(ctx, d.Get("type").(string), d.Get("name").(string))
+	if errResp != nil || err != nil || method == nil {
+		return errResp, err
+	}
+
+	data := map[string]interface{}{
+		"name": method.Name,
+		"type": method.Type,
+	}
+
+	// The Duo secret key and Okta API token are not returned
+	switch method.Type {
+	case mfaMethodTypeTOTP:
+		data["issuer"] = method.Issuer
+		data["period"] = int64(method.Period)
+		data["digits"] = int(method.Digits)
+		data["algorithm"] = method.Algorithm.String()
+		data["key_size"] = int(method.KeySize)
+		data["skew"] = int(method.Skew)
+		data["qr_size"] = method.QRSize
+	case mfaMethodTypeDuo:
+		data["username_format"] = method.UsernameFormat
+		data["mount_accessor"] = method.MountAccessor
+		data["integration_key"] = method.IntegrationKey
+		data["api_hostname"] = method.APIHostname
+		data["push_info"] = method.PushInfo
+		data["use_passcode"] = method.UsePasscode
+	case mfaMethodTypeOkta:
+		data["username_format"] = method.UsernameFormat
+		data["mount_accessor"] = method.MountAccessor
+		data["org_name"] = method.OrgName
+		data["base_url"] = method.BaseURL
+		data["primary_email"] = method.PrimaryEmail
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// handleMFAMethodWrite creates or updates an MFA method. Fields not given
+// keep their current value, or take their default when the method is created.
+func (b *SystemBackend) handleMFAMethodWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	methodType := d.Get("type").(string)
+	name := d.Get("name").(string)
+
+	method, errResp, err := b.mfaMethodOfType(ctx, methodType, name)
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+	create := method == nil
+	if create {
+		method = &MFAMethod{
+			Name: name,
+			Type: methodType,
+		}
+	}
+
+	get := func(field string) (interface{}, bool) {
+		if rawVal, ok := d.GetOk(field); ok {
+			return rawVal, true
+		}
+		if create {
+			return d.Get(field), true
+		}
+		return nil, false
+	}
+
+	switch methodType {
+	case mfaMethodTypeTOTP:
+		if rawVal, ok := get("issuer"); ok {
+			method.Issuer = rawVal.(string)
+		}
+		if rawVal, ok := get("period"); ok {
+			if rawVal.(int) <= 0 {
+				return logical.ErrorResponse("period must be greater than zero"), nil
+			}
+			method.Period = uint(rawVal.(int))
+		}
+		if rawVal, ok := get("digits"); ok {
+			switch rawVal.(int) {
+			case 6:
+				method.Digits = otplib.DigitsSix
+			case 8:
+				method.Digits = otplib.DigitsEight
+			default:
+				return logical.ErrorResponse("digits must be 6 or 8"), nil
+			}
+		}
+		if rawVal, ok := get("algorithm"); ok {
+			switch strings.ToUpper(rawVal.(string)) {
+			case "SHA1":
+				method.Algorithm = otplib.AlgorithmSHA1
+			case "SHA256":
+				method.Algorithm = otplib.AlgorithmSHA256
+			case "SHA512":
+				method.Algorithm = otplib.AlgorithmSHA512
+			default:
+				return logical.ErrorResponse("algorithm must be SHA1, SHA256 or SHA512"), nil
+			}
+		}
+		if rawVal, ok := get("key_size"); ok {
+			if rawVal.(int) <= 0 {
+				return logical.ErrorResponse("key_size must be greater than zero"), nil
+			}
+			method.KeySize = uint(rawVal.(int))
+		}
+		if rawVal, ok := get("skew"); ok {
+			if rawVal.(int) != 0 && rawVal.(int) != 1 {
+				return logical.ErrorResponse("skew must be 0 or 1"), nil
+			}
+			method.Skew = uint(rawVal.(int))
+		}
+		if rawVal, ok := get("qr_size"); ok {
+			if rawVal.(int) < 0 {
+				return logical.ErrorResponse("qr_size must not be negative"), nil
+			}
+			method.QRSize = rawVal.(int)
+		}
+		if method.Issuer == "" {
+			return logical.ErrorResponse("issuer is required"), nil
+		}
+
+	case mfaMethodTypeDuo:
+		if rawVal, ok := get("integration_key"); ok {
+			method.IntegrationKey = rawVal.(string)
+		}
+		if rawVal, ok := get("secret_key"); ok {
+			method.SecretKey = rawVal.(string)
+		}
+		if rawVal, ok := get("api_hostname"); ok {
+			method.APIHostname = rawVal.(string)
+		}
+		if rawVal, ok := get("push_info"); ok {
+			method.PushInfo = rawVal.(string)
+		}
+		if rawVal, ok := get("use_passcode"); ok {
+			method.UsePasscode = rawVal.(bool)
+		}
+		if method.IntegrationKey == "" || method.SecretKey == "" || method.APIHostname == "" {
+			return logical.ErrorResponse("integration_key, secret_key and api_hostname are required"), nil
+		}
+
+	case mfaMethodTypeOkta:
+		if rawVal, ok := get("org_name"); ok {
+			method.OrgName = rawVal.(string)
+		}
+		if rawVal, ok := get("api_token"); ok {
+			method.APIToken = rawVal.(string)
+		}
+		if rawVal, ok := get("base_url"); ok {
+			method.BaseURL = rawVal.(string)
+		}
+		if rawVal, ok := get("primary_email"); ok {
+			method.PrimaryEmail = rawVal.(bool)
+		}
+		if method.OrgName == "" || method.APIToken == "" {
+			return logical.ErrorResponse("org_name and api_token are required"), nil
+		}
+	}
+
+	// Duo and Okta map the alias or entity of the user to their username
+	if methodType != mfaMethodTypeTOTP {
+		if rawVal, ok := get("username_format"); ok {
+			method.UsernameFormat = rawVal.(string)
+		}
+		if rawVal, ok := get("mount_accessor"); ok {
+			method.MountAccessor = rawVal.(string)
+		}
+		if method.MountAccessor != "" && b.Core.router.MatchingMountByAccessor(method.MountAccessor) == nil {
+			return logical.ErrorResponse(fmt.Sprintf("unknown mount accessor %q", method.MountAccessor)), nil
+		}
+	}
+
+	if err := b.Core.loginMFA.putMethod(ctx, method); err != nil {
+		return handleError(err)
+	}
+
+	return nil, nil
+}
+
+func (b *SystemBackend) handleMFAMethodDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	method, errResp, err := b.mfaMethodOfType(ctx, d.Get("type").(string), d.Get("name").(string))
+	if errResp != nil || err != nil || method == nil {
+		return errResp, err
+	}
+
+	if err := b.Core.loginMFA.deleteMethod(ctx, method); err != nil {
+		return handleError(err)
+	}
+
+	return nil, nil
+}
+
+// handleMFATOTPGenerate generates a TOTP secret for the entity of the
+// requesting token. An existing secret must be destroyed by an administrator
+// first, so that a stolen token cannot be used to enroll a new device.
+func (b *SystemBackend) handleMFATOTPGenerate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.EntityID == "" {
+		return logical.ErrorResponse("the token is not associated with an entity"), nil
+	}
+
+	return b.generateMFATOTPSecret(ctx, d.Get("name").(string), req.EntityID)
+}
+
+func (b *SystemBackend) handleMFATOTPAdminGenerate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entityID := d.Get("entity_id").(string)
+	if entityID == "" {
+		return logical.ErrorResponse("missing entity_id"), nil
+	}
+
+	return b.generateMFATOTPSecret(ctx, d.Get("name").(string), entityID)
+}
+
+func (b *SystemBackend) handleMFATOTPAdminDestroy(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entityID := d.Get("entity_id").(string)
+	if entityID == "" {
+		return logical.ErrorResponse("missing entity_id"), nil
+	}
+
+	if err := b.Core.loginMFA.deleteTOTPSecret(ctx, d.Get("name").(string), entityID); err != nil {
+		return handleError(err)
+	}
+
+	return nil, nil
+}
+
+func (b *SystemBackend) generateMFATOTPSecret(ctx context.Context, name, entityID string) (*logical.Response, error) {
+	method, errResp, err := b.mfaMethodOfType(ctx, mfaMethodTypeTOTP, name)
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+	if method == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown MFA method %q", name)), nil
+	}
+
+	entity, _, err := b.Core.fetchEntityAndDerivedPolicies(namespace.FromContext(ctx), entityID)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown entity %q", entityID)), nil
+	}
+
+	exists, err := b.Core.loginMFA.hasTOTPSecret(ctx, method.Name, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return logical.ErrorResponse("the entity already has a TOTP secret for the method"), nil
+	}
+
+	accountName := entity.Name
+	if accountName == "" {
+		accountName = entity.ID
+	}
+	resp, err := b.Core.loginMFA.generateTOTPSecret(ctx, method, entity.ID, accountName)
+	if err != nil {
+		return handleError(err)
+	}
+
+	return resp, nil
+}
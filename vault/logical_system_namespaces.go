@@ -0,0 +1,118 @@
+package vault
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// namespacePaths returns the paths used to manage the child namespaces of the
+// request's namespace
+func (b *SystemBackend) namespacePaths() []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "namespaces/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleNamespacesList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["namespaces-list"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["namespaces-list"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "namespaces/(?P<path>.+)",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["namespaces-path"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleNamespacesRead,
+				logical.UpdateOperation: b.handleNamespacesSet,
+				logical.DeleteOperation: b.handleNamespacesDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["namespaces"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["namespaces"][1]),
+		},
+	}
+}
+
+// namespaceResponseData returns the data describing a child namespace, with
+// its path relative to the parent namespace
+func namespaceResponseData(parent, entry *namespace.Namespace) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   entry.ID,
+		"path": parent.TrimmedPath(entry.Path),
+	}
+}
+
+// handleNamespacesList lists the direct children of the request's namespace
+func (b *SystemBackend) handleNamespacesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	parent := namespace.FromContext(ctx)
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	for _, entry := range b.Core.namespaceStore.ListChildren(parent) {
+		key := parent.TrimmedPath(entry.Path)
+		keys = append(keys, key)
+		keyInfo[key] = namespaceResponseData(parent, entry)
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// handleNamespacesRead returns a child namespace of the request's namespace
+func (b *SystemBackend) handleNamespacesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	parent := namespace.FromContext(ctx)
+
+	entry := b.Core.namespaceStore.NamespaceByPath(parent.Path + d.Get("path").(string))
+	if entry == nil || entry.ID == namespace.RootNamespaceID {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: namespaceResponseData(parent, entry),
+	}, nil
+}
+
+// handleNamespacesSet creates a child namespace of the request's namespace.
+// Creating a namespace that already exists returns the existing namespace.
+func (b *SystemBackend) handleNamespacesSet(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	parent := namespace.FromContext(ctx)
+	path := d.Get("path").(string)
+
+	entry := b.Core.namespaceStore.NamespaceByPath(parent.Path + path)
+	if entry == nil || entry.ID == namespace.RootNamespaceID {
+		var err error
+		entry, err = b.Core.createNamespace(ctx, path)
+		if err != nil {
+			b.Backend.Logger().Error("create namespace failed", "path", path, "error", err)
+			return handleError(err)
+		}
+	}
+
+	return &logical.Response{
+		Data: namespaceResponseData(parent, entry),
+	}, nil
+}
+
+// handleNamespacesDelete deletes a child namespace of the request's
+// namespace, revoking its leases and removing its mounts and policies
+func (b *SystemBackend) handleNamespacesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	path := d.Get("path").(string)
+
+	if err := b.Core.deleteNamespace(ctx, path); err != nil {
+		b.Backend.Logger().Error("delete namespace failed", "path", path, "error", err)
+		return handleError(err)
+	}
+	return nil, nil
+}
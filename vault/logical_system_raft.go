@@ -0,0 +1,151 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/physical/raft"
+)
+
+var errRaftNotInUse = errors.New("raft storage is not in use")
+
+// raftStoragePaths returns the paths used to manage the membership of a raft
+// storage cluster
+func (b *SystemBackend) raftStoragePaths() []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "storage/raft/join$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"node_id": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "The raft node ID of the node joining the cluster.",
+				},
+				"address": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "The raft address of the node joining the cluster.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleRaftJoin,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raft-join"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raft-join"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "storage/raft/configuration$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleRaftConfigurationRead,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raft-configuration"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raft-configuration"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "storage/raft/remove-peer$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"node_id": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "The raft node ID of the peer to remove.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleRaftRemovePeer,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raft-remove-peer"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raft-remove-peer"][1]),
+		},
+	}
+}
+
+// raftBackend returns the raft storage backend, if that is the configured
+// physical backend
+func (b *SystemBackend) raftBackend() (*raft.RaftBackend, error) {
+	raftBackend, ok := b.Core.underlyingPhysical.(*raft.RaftBackend)
+	if !ok {
+		return nil, errRaftNotInUse
+	}
+	return raftBackend, nil
+}
+
+// handleRaftJoin adds a new node to the raft cluster
+func (b *SystemBackend) handleRaftJoin(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	raftBackend, err := b.raftBackend()
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	nodeID := data.Get("node_id").(string)
+	if nodeID == "" {
+		return logical.ErrorResponse("node_id is required"), logical.ErrInvalidRequest
+	}
+	address := data.Get("address").(string)
+	if address == "" {
+		return logical.ErrorResponse("address is required"), logical.ErrInvalidRequest
+	}
+
+	if err := raftBackend.AddPeer(ctx, nodeID, address); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// handleRaftConfigurationRead returns the members of the raft cluster
+func (b *SystemBackend) handleRaftConfigurationRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	raftBackend, err := b.raftBackend()
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	peers, err := raftBackend.Peers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]map[string]interface{}, 0, len(peers))
+	for _, peer := range peers {
+		servers = append(servers, map[string]interface{}{
+			"node_id": peer.ID,
+			"address": peer.Address,
+			"leader":  peer.Leader,
+			"voter":   peer.Voter,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"servers": servers,
+		},
+	}, nil
+}
+
+// handleRaftRemovePeer removes a node from the raft cluster
+func (b *SystemBackend) handleRaftRemovePeer(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	raftBackend, err := b.raftBackend()
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	nodeID := data.Get("node_id").(string)
+	if nodeID == "" {
+		return logical.ErrorResponse("node_id is required"), logical.ErrInvalidRequest
+	}
+
+	if err := raftBackend.RemovePeer(ctx, nodeID); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
@@ -14,12 +14,19 @@ import (
 	"testing"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/fatih/structs"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/builtinplugins"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/physical/raft"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -43,6 +50,7 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 		"leases/revoke-prefix/*",
 		"leases/revoke-force/*",
 		"leases/lookup/*",
+		"storage/raft/*",
 	}
 
 	b := testSystemBackend(t)
@@ -2269,3 +2277,185 @@ func TestSystemBackend_InternalUIMounts(t *testing.T) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
 	}
 }
+
+func TestSystemBackend_RaftStorage(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "storage/raft/configuration")
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got %v", err)
+	}
+	if resp == nil || !resp.IsError() || resp.Data["error"] != errRaftNotInUse.Error() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	raftDir, err := ioutil.TempDir("", "vault-raft-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(raftDir)
+
+	backend, err := raft.NewRaftBackend(map[string]string{
+		"path":      raftDir,
+		"address":   "127.0.0.1:0",
+		"bootstrap": "true",
+	}, logging.NewVaultLogger(hclog.Debug))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raftBackend := backend.(*raft.RaftBackend)
+	defer raftBackend.Shutdown()
+
+	// Wait for the single node to elect itself
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		err := raftBackend.Put(context.Background(), &physical.Entry{Key: "test"})
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	c, _, _ := TestCoreUnsealedBackend(t, backend)
+	b = c.systemBackend
+
+	req = logical.TestRequest(t, logical.ReadOperation, "storage/raft/configuration")
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []map[string]interface{}{
+		map[string]interface{}{
+			"node_id": raftBackend.NodeID(),
+			"address": raftBackend.Address(),
+			"leader":  true,
+			"voter":   true,
+		},
+	}
+	if !reflect.DeepEqual(resp.Data["servers"], exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data["servers"], exp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "storage/raft/join")
+	req.Data["node_id"] = "node2"
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestSystemBackend_Metrics(t *testing.T) {
+	b := testSystemBackend(t)
+
+	// Without a metrics helper the endpoint is unavailable
+	req := logical.TestRequest(t, logical.ReadOperation, "metrics")
+	if _, err := b.HandleRequest(context.Background(), req); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request error, got: %v", err)
+	}
+
+	inm := metrics.NewInmemSink(10*time.Second, time.Minute)
+	prom := metricsutil.NewPrometheusSink(time.Hour)
+	b.(*SystemBackend).Core.metricsHelper = metricsutil.NewMetricsHelper(inm, prom)
+
+	fanout := metrics.FanoutSink{inm, prom}
+	fanout.IncrCounter([]string{"vault", "test", "counter"}, 2)
+	fanout.SetGauge([]string{"vault", "test", "gauge"}, 5)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "metrics")
+	req.Data["format"] = "prometheus"
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data[logical.HTTPStatusCode] != 200 {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Data[logical.HTTPContentType] != metricsutil.PrometheusContentType {
+		t.Fatalf("bad content type: %#v", resp.Data[logical.HTTPContentType])
+	}
+	body := string(resp.Data[logical.HTTPRawBody].([]byte))
+	for _, expected := range []string{"vault_test_counter 2\n", "vault_test_gauge 5\n"} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("expected %q in body:\n%s", expected, body)
+		}
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "metrics")
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data[logical.HTTPStatusCode] != 200 || resp.Data[logical.HTTPContentType] != "application/json" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if !strings.Contains(string(resp.Data[logical.HTTPRawBody].([]byte)), "vault.test.gauge") {
+		t.Fatalf("bad body: %s", resp.Data[logical.HTTPRawBody])
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "metrics")
+	req.Data["format"] = "bogus"
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data[logical.HTTPStatusCode] != 400 {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestSystemBackend_OpenAPI(t *testing.T) {
+	_, b, _ := testCoreSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/specs/openapi")
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data[logical.HTTPStatusCode] != 200 || resp.Data[logical.HTTPContentType] != "application/json" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	var doc framework.OASDocument
+	if err := jsonutil.DecodeJSON(resp.Data[logical.HTTPRawBody].([]byte), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "3.0.2" {
+		t.Fatalf("bad version: %q", doc.Version)
+	}
+
+	tests := []struct {
+		path string
+		tag  string
+		sudo bool
+	}{
+		{"/sys/mounts", "system", false},
+		{"/sys/mounts/{path}", "system", false},
+		{"/sys/rotate", "system", true},
+		{"/secret/{path}", "secrets", false},
+		{"/cubbyhole/{path}", "secrets", false},
+		{"/identity/entity", "identity", false},
+		{"/auth/token/create", "auth", false},
+	}
+	for _, test := range tests {
+		item, ok := doc.Paths[test.path]
+		if !ok {
+			t.Fatalf("missing path %q", test.path)
+		}
+		if item.Sudo != test.sudo {
+			t.Fatalf("%s: expected sudo %t", test.path, test.sudo)
+		}
+		for _, op := range []*framework.OASOperation{item.Get, item.Post, item.Delete} {
+			if op != nil && !reflect.DeepEqual(op.Tags, []string{test.tag}) {
+				t.Fatalf("%s: bad tags %v", test.path, op.Tags)
+			}
+		}
+	}
+}
@@ -0,0 +1,902 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/chrismalek/oktasdk-go/okta"
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/authapi"
+	"github.com/hashicorp/errwrap"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/helper/useragent"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	otplib "github.com/pquerna/otp"
+)
+
+const (
+	// loginMFASubPath is the sub-path used for the login MFA method
+	// configurations, the TOTP secrets of entities and the pending logins
+	loginMFASubPath = "login-mfa/"
+
+	// loginMFAPendingPrefix is the storage prefix of the pending logins,
+	// which are indexed by their salted MFA request ID
+	loginMFAPendingPrefix = "pending/"
+
+	// loginMFAValidatePath is the path used to complete logins requiring MFA
+	loginMFAValidatePath = "sys/mfa/validate"
+
+	// loginMFAPendingTTL is how long a login waits for its MFA to be
+	// validated
+	loginMFAPendingTTL = 5 * time.Minute
+
+	// loginMFADefaultUsernameFormat is the name sent to Duo and Okta unless
+	// the method configures another
+	loginMFADefaultUsernameFormat = "{{alias.name}}"
+
+	mfaMethodTypeTOTP = "totp"
+	mfaMethodTypeDuo  = "duo"
+	mfaMethodTypeOkta = "okta"
+)
+
+var (
+	mfaMethodTypes = []string{mfaMethodTypeTOTP, mfaMethodTypeDuo, mfaMethodTypeOkta}
+
+	// loginMFAUsernameFormatRe matches the values to fill in in username
+	// formats, such as {{alias.name}}
+	loginMFAUsernameFormatRe = regexp.MustCompile(`\{\{[^{}]+\}\}`)
+)
+
+// MFAMethod is the configuration of a login MFA method. Methods are
+// referenced by name from the mfa_methods of policies and auth mounts.
+type MFAMethod struct {
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	UsernameFormat string `json:"username_format"`
+	MountAccessor  string `json:"mount_accessor"`
+
+	// TOTP
+	Issuer    string           `json:"issuer"`
+	Period    uint             `json:"period"`
+	Digits    otplib.Digits    `json:"digits"`
+	Algorithm otplib.Algorithm `json:"algorithm"`
+	KeySize   uint             `json:"key_size"`
+	Skew      uint             `json:"skew"`
+	QRSize    int              `json:"qr_size"`
+
+	// Duo
+	IntegrationKey string `json:"integration_key"`
+	SecretKey      string `json:"secret_key"`
+	APIHostname    string `json:"api_hostname"`
+	PushInfo       string `json:"push_info"`
+	UsePasscode    bool   `json:"use_passcode"`
+
+	// Okta
+	OrgName      string `json:"org_name"`
+	APIToken     string `json:"api_token"`
+	BaseURL      string `json:"base_url"`
+	PrimaryEmail bool   `json:"primary_email"`
+}
+
+// usesPasscode returns whether passing the method requires a passcode
+func (m *MFAMethod) usesPasscode() bool {
+	switch m.Type {
+	case mfaMethodTypeTOTP:
+		return true
+	case mfaMethodTypeDuo:
+		return m.UsePasscode
+	default:
+		return false
+	}
+}
+
+// pendingLogin is a login whose token is only created once MFA has been
+// validated. It is kept in storage until it is validated or expires.
+type pendingLogin struct {
+	NamespaceID string            `json:"namespace_id"`
+	Path        string            `json:"path"`
+	Response    *logical.Response `json:"response"`
+	Methods     []string          `json:"methods"`
+	RemoteAddr  string            `json:"remote_addr"`
+	ExpireTime  time.Time         `json:"expire_time"`
+
+	// Passed holds the names of the methods that have been validated while
+	// waiting for push notifications to be approved
+	Passed []string `json:"passed"`
+
+	// Pushes holds the push notifications waiting to be approved, as the
+	// Okta poll link or the Duo transaction ID keyed by method name
+	Pushes map[string]string `json:"pushes"`
+
+	// ExplicitMaxTTL is not serialized with the auth of the response, so it
+	// is kept separately
+	ExplicitMaxTTL time.Duration `json:"explicit_max_ttl"`
+}
+
+// loginMFAManager keeps track of the logins waiting for MFA to be validated
+// and of the TOTP secrets engines holding the secrets of entities
+type loginMFAManager struct {
+	core *Core
+
+	// pendingLock makes reading and deleting a pending login atomic, so that
+	// each can only be attempted once
+	pendingLock sync.Mutex
+
+	// totpBackends are the TOTP secrets engines of the TOTP methods, keyed
+	// by method name. They are kept around since they record the passcodes
+	// already used.
+	totpBackends map[string]logical.Backend
+	totpLock     sync.Mutex
+}
+
+func newLoginMFAManager(c *Core) *loginMFAManager {
+	return &loginMFAManager{
+		core:         c,
+		totpBackends: make(map[string]logical.Backend),
+	}
+}
+
+func (m *loginMFAManager) view() *BarrierView {
+	return m.core.systemBarrierView.SubView(loginMFASubPath)
+}
+
+// method returns the configuration of the named MFA method, or nil if it
+// doesn't exist
+func (m *loginMFAManager) method(ctx context.Context, name string) (*MFAMethod, error) {
+	entry, err := m.view().Get(ctx, "method/"+name)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read MFA method: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var method MFAMethod
+	if err := entry.DecodeJSON(&method); err != nil {
+		return nil, errwrap.Wrapf("failed to decode MFA method: {{err}}", err)
+	}
+	return &method, nil
+}
+
+func (m *loginMFAManager) putMethod(ctx context.Context, method *MFAMethod) error {
+	entry, err := logical.StorageEntryJSON("method/"+method.Name, method)
+	if err != nil {
+		return err
+	}
+	return m.view().Put(ctx, entry)
+}
+
+// deleteMethod deletes an MFA method along with the TOTP secrets generated
+// for it
+func (m *loginMFAManager) deleteMethod(ctx context.Context, method *MFAMethod) error {
+	if method.Type == mfaMethodTypeTOTP {
+		resp, err := m.totpRequest(ctx, method.Name, logical.ListOperation, "keys/", nil)
+		if err != nil {
+			return err
+		}
+		if entityIDs, ok := resp.Data["keys"].([]string); ok {
+			for _, entityID := range entityIDs {
+				if err := m.deleteTOTPSecret(ctx, method.Name, entityID); err != nil {
+					return err
+				}
+			}
+		}
+
+		m.totpLock.Lock()
+		delete(m.totpBackends, method.Name)
+		m.totpLock.Unlock()
+	}
+
+	return m.view().Delete(ctx, "method/"+method.Name)
+}
+
+func (m *loginMFAManager) listMethods(ctx context.Context) ([]string, error) {
+	return m.view().List(ctx, "method/")
+}
+
+// pendingPath returns the storage path of the pending login with the given
+// MFA request ID
+func (m *loginMFAManager) pendingPath(ctx context.Context, requestID string) (string, error) {
+	saltedID, err := m.core.tokenStore.SaltID(ctx, requestID)
+	if err != nil {
+		return "", err
+	}
+	return loginMFAPendingPrefix + saltedID, nil
+}
+
+func (m *loginMFAManager) putPending(ctx context.Context, requestID string, pending *pendingLogin) error {
+	path, err := m.pendingPath(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	entry, err := logical.StorageEntryJSON(path, pending)
+	if err != nil {
+		return err
+	}
+	return m.view().Put(ctx, entry)
+}
+
+// takePending returns and deletes the pending login with the given MFA
+// request ID. It returns nil if the login doesn't exist or has expired.
+func (m *loginMFAManager) takePending(ctx context.Context, requestID string) (*pendingLogin, error) {
+	path, err := m.pendingPath(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	pending, err := m.readPending(ctx, path)
+	if err != nil || pending == nil {
+		return nil, err
+	}
+	if err := m.view().Delete(ctx, path); err != nil {
+		return nil, errwrap.Wrapf("failed to delete pending login: {{err}}", err)
+	}
+	if time.Now().After(pending.ExpireTime) {
+		return nil, nil
+	}
+
+	return pending, nil
+}
+
+func (m *loginMFAManager) readPending(ctx context.Context, path string) (*pendingLogin, error) {
+	entry, err := m.view().Get(ctx, path)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read pending login: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var pending pendingLogin
+	if err := entry.DecodeJSON(&pending); err != nil {
+		return nil, errwrap.Wrapf("failed to decode pending login: {{err}}", err)
+	}
+	if pending.Response != nil && pending.Response.Auth != nil {
+		pending.Response.Auth.ExplicitMaxTTL = pending.ExplicitMaxTTL
+	}
+	return &pending, nil
+}
+
+// tidyPending deletes the pending logins that have expired
+func (m *loginMFAManager) tidyPending(ctx context.Context) error {
+	view := m.view()
+	saltedIDs, err := view.List(ctx, loginMFAPendingPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list pending logins: {{err}}", err)
+	}
+
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	for _, saltedID := range saltedIDs {
+		path := loginMFAPendingPrefix + saltedID
+		pending, err := m.readPending(ctx, path)
+		if err != nil {
+			return err
+		}
+		if pending == nil || time.Now().Before(pending.ExpireTime) {
+			continue
+		}
+		if err := view.Delete(ctx, path); err != nil {
+			return errwrap.Wrapf("failed to delete pending login: {{err}}", err)
+		}
+	}
+
+	return nil
+}
+
+// totpBackend returns the TOTP secrets engine of the named method, which
+// keeps the secrets of the entities keyed by entity ID. The engine is created
+// with the factory of the totp logical backend the core is configured with.
+func (m *loginMFAManager) totpBackend(ctx context.Context, methodName string) (logical.Backend, error) {
+	m.totpLock.Lock()
+	defer m.totpLock.Unlock()
+
+	if b, ok := m.totpBackends[methodName]; ok {
+		return b, nil
+	}
+
+	factory, ok := m.core.logicalBackends["totp"]
+	if !ok {
+		return nil, errors.New("the TOTP secrets engine is not available")
+	}
+	b, err := factory(ctx, &logical.BackendConfig{
+		StorageView: m.totpView(methodName),
+		Logger:      m.core.logger.ResetNamed("login-mfa.totp"),
+		System:      logical.StaticSystemView{},
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to set up TOTP backend: {{err}}", err)
+	}
+	m.totpBackends[methodName] = b
+
+	return b, nil
+}
+
+func (m *loginMFAManager) totpView(methodName string) *BarrierView {
+	return m.view().SubView("totp/" + methodName + "/")
+}
+
+// totpRequest makes a request to the TOTP secrets engine of the named method.
+// Error responses are returned as errors.
+func (m *loginMFAManager) totpRequest(ctx context.Context, methodName string, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	b, err := m.totpBackend(ctx, methodName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: op,
+		Path:      path,
+		Data:      data,
+		Storage:   m.totpView(methodName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
+
+// hasTOTPSecret returns whether a TOTP secret has been generated for the
+// entity
+func (m *loginMFAManager) hasTOTPSecret(ctx context.Context, methodName, entityID string) (bool, error) {
+	resp, err := m.totpRequest(ctx, methodName, logical.ReadOperation, "keys/"+entityID, nil)
+	if err != nil {
+		return false, errwrap.Wrapf("failed to read TOTP secret: {{err}}", err)
+	}
+	return resp != nil, nil
+}
+
+// generateTOTPSecret generates and stores a TOTP key for the entity. The
+// response holds the otpauth URL of the key and its QR code for enrollment
+// in an authenticator.
+func (m *loginMFAManager) generateTOTPSecret(ctx context.Context, method *MFAMethod, entityID, accountName string) (*logical.Response, error) {
+	resp, err := m.totpRequest(ctx, method.Name, logical.UpdateOperation, "keys/"+entityID, map[string]interface{}{
+		"generate":     true,
+		"exported":     true,
+		"issuer":       method.Issuer,
+		"account_name": accountName,
+		"period":       int(method.Period),
+		"digits":       int(method.Digits),
+		"algorithm":    method.Algorithm.String(),
+		"skew":         int(method.Skew),
+		"key_size":     int(method.KeySize),
+		"qr_size":      method.QRSize,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to generate TOTP key: {{err}}", err)
+	}
+	return resp, nil
+}
+
+func (m *loginMFAManager) deleteTOTPSecret(ctx context.Context, methodName, entityID string) error {
+	_, err := m.totpRequest(ctx, methodName, logical.DeleteOperation, "keys/"+entityID, nil)
+	return err
+}
+
+// loginMFARequirements returns the names of the MFA methods that must be
+// passed for the login at path to be issued a token. These are the methods
+// configured on the auth mount and on the policies the token would have,
+// including those granted through the identity store.
+func (c *Core) loginMFARequirements(ctx context.Context, path string, auth *logical.Auth) ([]string, error) {
+	var names []string
+	if entry := c.router.MatchingMountEntry(path); entry != nil {
+		if rawVal, ok := entry.synthesizedConfigCache.Load("mfa_methods"); ok {
+			names = append(names, rawVal.([]string)...)
+		}
+	}
+
+	ns := namespace.FromContext(ctx)
+	_, identityPolicies, err := c.fetchEntityAndDerivedPolicies(ns, auth.EntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := append(policyutil.SanitizePolicies(auth.Policies, true), identityPolicies...)
+	for _, name := range strutil.RemoveDuplicates(policies, true) {
+		policy, err := c.policyStore.GetPolicy(ctx, name, PolicyTypeToken)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			names = append(names, policy.MFAMethods...)
+		}
+	}
+
+	return strutil.RemoveDuplicates(names, false), nil
+}
+
+// createPendingLogin holds on to the response of a login requiring MFA and
+// returns the response telling the client which methods to pass
+func (c *Core) createPendingLogin(ctx context.Context, req *logical.Request, resp *logical.Response, methodNames []string) (*logical.Response, error) {
+	requirement := &logical.MFARequirement{}
+	for _, name := range methodNames {
+		method, err := c.loginMFA.method(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if method == nil {
+			c.logger.Error("login requires an MFA method that does not exist", "request_path", req.Path, "mfa_method", name)
+			return nil, ErrInternalError
+		}
+		requirement.MFAMethods = append(requirement.MFAMethods, &logical.MFAMethodInfo{
+			Name:         method.Name,
+			Type:         method.Type,
+			UsesPasscode: method.usesPasscode(),
+		})
+	}
+
+	requestID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	requirement.MFARequestID = requestID
+
+	pending := &pendingLogin{
+		NamespaceID:    namespace.FromContext(ctx).ID,
+		Path:           req.Path,
+		Response:       resp,
+		Methods:        methodNames,
+		ExpireTime:     time.Now().Add(loginMFAPendingTTL),
+		ExplicitMaxTTL: resp.Auth.ExplicitMaxTTL,
+	}
+	if req.Connection != nil {
+		pending.RemoteAddr = req.Connection.RemoteAddr
+	}
+	if err := c.loginMFA.putPending(ctx, requestID, pending); err != nil {
+		c.logger.Error("failed to store pending login", "request_path", req.Path, "error", err)
+		return nil, ErrInternalError
+	}
+
+	return &logical.Response{
+		Auth: &logical.Auth{
+			MFARequirement: requirement,
+		},
+		Warnings: resp.Warnings,
+	}, nil
+}
+
+// handleLoginMFAValidate completes a login requiring MFA. Each pending login
+// can only be attempted once, so passcodes cannot be guessed without logging
+// in again. Push notifications are not waited on: while they are pending, the
+// methods still to be approved are returned and the client validates the
+// login again to poll them.
+func (c *Core) handleLoginMFAValidate(ctx context.Context, req *logical.Request) (*logical.Response, *logical.Auth, error) {
+	defer metrics.MeasureSince([]string{"core", "handle_login_mfa_validate"}, time.Now())
+
+	req.Unauthenticated = true
+
+	logInput := &audit.LogInput{
+		Request: req,
+	}
+	if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
+		c.logger.Error("failed to audit request", "path", req.Path, "error", err)
+		return nil, nil, ErrInternalError
+	}
+
+	if req.Operation != logical.UpdateOperation && req.Operation != logical.CreateOperation {
+		return logical.ErrorResponse("unsupported operation"), nil, logical.ErrUnsupportedOperation
+	}
+
+	data := &framework.FieldData{
+		Raw: req.Data,
+		Schema: map[string]*framework.FieldSchema{
+			"mfa_request_id": &framework.FieldSchema{Type: framework.TypeString},
+			"mfa_payload":    &framework.FieldSchema{Type: framework.TypeMap},
+		},
+	}
+	requestID := data.Get("mfa_request_id").(string)
+	if requestID == "" {
+		return logical.ErrorResponse("missing mfa_request_id"), nil, logical.ErrInvalidRequest
+	}
+	payload := make(map[string][]string)
+	for name, rawVal := range data.Get("mfa_payload").(map[string]interface{}) {
+		passcodes, err := parseutil.ParseCommaStringSlice(rawVal)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid passcodes for MFA method %q", name)), nil, logical.ErrInvalidRequest
+		}
+		payload[name] = passcodes
+	}
+
+	pending, err := c.loginMFA.takePending(ctx, requestID)
+	if err != nil {
+		c.logger.Error("failed to look up pending login", "error", err)
+		return nil, nil, ErrInternalError
+	}
+	if pending == nil || pending.NamespaceID != namespace.FromContext(ctx).ID {
+		return logical.ErrorResponse("invalid or expired MFA request ID"), nil, logical.ErrPermissionDenied
+	}
+
+	waiting := &logical.MFARequirement{
+		MFARequestID: requestID,
+	}
+	for _, name := range pending.Methods {
+		if strutil.StrListContains(pending.Passed, name) {
+			continue
+		}
+
+		method, err := c.loginMFA.method(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if method == nil {
+			return logical.ErrorResponse(fmt.Sprintf("MFA method %q no longer exists", name)), nil, logical.ErrPermissionDenied
+		}
+		passed, err := c.loginMFA.validate(ctx, method, pending, payload[name])
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("MFA method %q failed: %v", name, err)), nil, logical.ErrPermissionDenied
+		}
+		if !passed {
+			waiting.MFAMethods = append(waiting.MFAMethods, &logical.MFAMethodInfo{
+				Name: method.Name,
+				Type: method.Type,
+			})
+			continue
+		}
+		pending.Passed = append(pending.Passed, name)
+	}
+
+	if len(waiting.MFAMethods) > 0 {
+		if err := c.loginMFA.putPending(ctx, requestID, pending); err != nil {
+			c.logger.Error("failed to store pending login", "request_path", pending.Path, "error", err)
+			return nil, nil, ErrInternalError
+		}
+		return &logical.Response{
+			Auth: &logical.Auth{
+				MFARequirement: waiting,
+			},
+		}, nil, nil
+	}
+
+	if c.router.MatchingMountEntry(pending.Path) == nil {
+		return logical.ErrorResponse("the auth method of the login has been disabled"), nil, logical.ErrPermissionDenied
+	}
+
+	resp, auth, err := c.loginCreateToken(ctx, pending.Path, pending.Response)
+	if err != nil || resp.IsError() {
+		return resp, auth, err
+	}
+
+	// Attach the display name, might be used by audit backends
+	req.DisplayName = auth.DisplayName
+
+	return resp, auth, nil
+}
+
+// validate checks whether the pending login passes the MFA method. It
+// returns false without an error while a push notification is waiting to be
+// approved, in which case the push is recorded on the pending login.
+func (m *loginMFAManager) validate(ctx context.Context, method *MFAMethod, pending *pendingLogin, passcodes []string) (bool, error) {
+	auth := pending.Response.Auth
+	if method.usesPasscode() && len(passcodes) != 1 {
+		return false, errors.New("a single passcode is required")
+	}
+
+	var push string
+	switch method.Type {
+	case mfaMethodTypeTOTP:
+		if auth.EntityID == "" {
+			return false, errors.New("the login is not associated with an entity")
+		}
+		return true, m.validateTOTP(ctx, method, auth.EntityID, passcodes[0])
+
+	case mfaMethodTypeDuo:
+		username, err := m.username(ctx, method, auth)
+		if err != nil {
+			return false, err
+		}
+		var passcode string
+		if method.UsePasscode {
+			passcode = passcodes[0]
+		}
+		push, err = validateDuo(method, username, passcode, pending.RemoteAddr, pending.Pushes[method.Name])
+		if err != nil {
+			return false, err
+		}
+
+	case mfaMethodTypeOkta:
+		username, err := m.username(ctx, method, auth)
+		if err != nil {
+			return false, err
+		}
+		push, err = validateOkta(method, username, pending.Pushes[method.Name])
+		if err != nil {
+			return false, err
+		}
+
+	default:
+		return false, fmt.Errorf("unknown MFA method type %q", method.Type)
+	}
+
+	if push != "" {
+		if pending.Pushes == nil {
+			pending.Pushes = make(map[string]string)
+		}
+		pending.Pushes[method.Name] = push
+		return false, nil
+	}
+	return true, nil
+}
+
+// username returns the name of the user at Duo or Okta, by filling in the
+// username format of the method with the alias and entity of the login. If
+// the method is tied to a mount, the entity's alias on that mount is used
+// instead of the one the user logged in with.
+func (m *loginMFAManager) username(ctx context.Context, method *MFAMethod, auth *logical.Auth) (string, error) {
+	format := method.UsernameFormat
+	if format == "" {
+		format = loginMFADefaultUsernameFormat
+	}
+
+	entity, _, err := m.core.fetchEntityAndDerivedPolicies(namespace.FromContext(ctx), auth.EntityID)
+	if err != nil {
+		return "", err
+	}
+
+	alias := auth.Alias
+	if method.MountAccessor != "" {
+		alias = nil
+		if entity != nil {
+			for _, entityAlias := range entity.Aliases {
+				if entityAlias.MountAccessor == method.MountAccessor {
+					alias = &logical.Alias{
+						Name:     entityAlias.Name,
+						Metadata: entityAlias.Metadata,
+					}
+				}
+			}
+		}
+		if alias == nil {
+			return "", fmt.Errorf("the entity has no alias on mount %q", method.MountAccessor)
+		}
+	}
+
+	var missing []string
+	username := loginMFAUsernameFormatRe.ReplaceAllStringFunc(format, func(match string) string {
+		key := strings.TrimSpace(match[2 : len(match)-2])
+
+		var value string
+		switch {
+		case key == "alias.name" && alias != nil:
+			value = alias.Name
+		case key == "entity.name" && entity != nil:
+			value = entity.Name
+		case strings.HasPrefix(key, "alias.metadata.") && alias != nil:
+			value = alias.Metadata[strings.TrimPrefix(key, "alias.metadata.")]
+		case strings.HasPrefix(key, "entity.metadata.") && entity != nil:
+			value = entity.Metadata[strings.TrimPrefix(key, "entity.metadata.")]
+		}
+		if value == "" {
+			missing = append(missing, key)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("no value for %s in the username format", strings.Join(missing, ", "))
+	}
+
+	return username, nil
+}
+
+// validateTOTP validates the passcode with the TOTP secrets engine of the
+// method, which also rejects passcodes that have already been used
+func (m *loginMFAManager) validateTOTP(ctx context.Context, method *MFAMethod, entityID, passcode string) error {
+	exists, err := m.hasTOTPSecret(ctx, method.Name, entityID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("no TOTP secret has been generated for the entity")
+	}
+
+	resp, err := m.totpRequest(ctx, method.Name, logical.UpdateOperation, "code/"+entityID, map[string]interface{}{
+		"code": passcode,
+	})
+	if err != nil {
+		return err
+	}
+	if valid, _ := resp.Data["valid"].(bool); !valid {
+		return errors.New("invalid passcode")
+	}
+
+	return nil
+}
+
+// validateDuo authenticates the user with Duo. Passcodes are checked right
+// away, while push notifications are sent asynchronously: the ID of the
+// transaction is returned while it is waiting for approval, and is passed
+// back in txID to check on it.
+func validateDuo(method *MFAMethod, username, passcode, remoteAddr, txID string) (string, error) {
+	duoClient := duoapi.NewDuoApi(method.IntegrationKey, method.SecretKey, method.APIHostname, useragent.String())
+	authClient := authapi.NewAuthApi(*duoClient)
+
+	if txID != "" {
+		status, err := authClient.AuthStatus(txID)
+		if err != nil || status == nil {
+			return "", errors.New("could not call Duo auth status")
+		}
+		if status.StatResult.Stat != "OK" {
+			return "", duoStatError("could not check Duo push status", &status.StatResult)
+		}
+		switch status.Response.Result {
+		case "allow":
+			return "", nil
+		case "waiting":
+			return txID, nil
+		default:
+			return "", errors.New(status.Response.Status_Msg)
+		}
+	}
+
+	preauth, err := authClient.Preauth(
+		authapi.PreauthUsername(username),
+		authapi.PreauthIpAddr(remoteAddr),
+	)
+	if err != nil || preauth == nil {
+		return "", errors.New("could not call Duo preauth")
+	}
+	if preauth.StatResult.Stat != "OK" {
+		return "", duoStatError("could not look up Duo user information", &preauth.StatResult)
+	}
+
+	switch preauth.Response.Result {
+	case "allow":
+		return "", nil
+	case "deny":
+		return "", errors.New(preauth.Response.Status_Msg)
+	case "enroll":
+		return "", fmt.Errorf("%s (%s)", preauth.Response.Status_Msg, preauth.Response.Enroll_Portal_Url)
+	case "auth":
+	default:
+		return "", fmt.Errorf("invalid Duo preauth response: %s", preauth.Response.Result)
+	}
+
+	factor := "push"
+	options := []func(*url.Values){authapi.AuthUsername(username), authapi.AuthIpAddr(remoteAddr)}
+	if passcode != "" {
+		factor = "passcode"
+		options = append(options, authapi.AuthPasscode(passcode))
+	} else {
+		options = append(options, authapi.AuthDevice("auto"), authapi.AuthAsync())
+		if method.PushInfo != "" {
+			options = append(options, authapi.AuthPushinfo(method.PushInfo))
+		}
+	}
+
+	result, err := authClient.Auth(factor, options...)
+	if err != nil || result == nil {
+		return "", errors.New("could not call Duo auth")
+	}
+	if result.StatResult.Stat != "OK" {
+		return "", duoStatError("could not authenticate Duo user", &result.StatResult)
+	}
+	if passcode == "" {
+		if result.Response.Txid == "" {
+			return "", errors.New("Duo did not return a push transaction")
+		}
+		return result.Response.Txid, nil
+	}
+	if result.Response.Result != "allow" {
+		return "", errors.New(result.Response.Status_Msg)
+	}
+
+	return "", nil
+}
+
+func duoStatError(msg string, stat *authapi.StatResult) error {
+	if stat.Message != nil {
+		msg = msg + ": " + *stat.Message
+	}
+	if stat.Message_Detail != nil {
+		msg = msg + " (" + *stat.Message_Detail + ")"
+	}
+	return errors.New(msg)
+}
+
+// oktaVerifyResult is the result of verifying an Okta factor
+type oktaVerifyResult struct {
+	FactorResult string `json:"factorResult"`
+	Links        struct {
+		Poll struct {
+			Href string `json:"href"`
+		} `json:"poll"`
+	} `json:"_links"`
+}
+
+// validateOkta sends an Okta Verify push notification to the user, or polls
+// the push already sent with the given link. The link to poll is returned
+// while the push is waiting for approval.
+func validateOkta(method *MFAMethod, username, pollLink string) (string, error) {
+	baseURL := method.BaseURL
+	if baseURL == "" {
+		baseURL = "okta.com"
+	}
+	client, err := okta.NewClientWithDomain(cleanhttp.DefaultClient(), method.OrgName, baseURL, method.APIToken)
+	if err != nil {
+		return "", err
+	}
+
+	var result oktaVerifyResult
+	if pollLink != "" {
+		req, err := client.NewRequest("GET", pollLink, nil)
+		if err != nil {
+			return "", err
+		}
+		if _, err := client.Do(req, &result); err != nil {
+			return "", errwrap.Wrapf("failed to poll Okta Verify push: {{err}}", err)
+		}
+	} else {
+		var user *okta.User
+		if method.PrimaryEmail {
+			users, _, err := client.Users.ListWithFilter(&okta.UserListFilterOptions{
+				EmailEqualTo: username,
+			})
+			if err != nil {
+				return "", errwrap.Wrapf("failed to look up Okta user: {{err}}", err)
+			}
+			if len(users) != 1 {
+				return "", fmt.Errorf("found %d Okta users with primary email %q", len(users), username)
+			}
+			user = &users[0]
+		} else {
+			user, _, err = client.Users.GetByID(username)
+			if err != nil {
+				return "", errwrap.Wrapf("failed to look up Okta user: {{err}}", err)
+			}
+		}
+		if _, err := client.Users.PopulateMFAFactors(user); err != nil {
+			return "", errwrap.Wrapf("failed to look up Okta factors: {{err}}", err)
+		}
+
+		var factorID string
+		for _, factor := range user.MFAFactors {
+			if factor.FactorType == "push" && factor.Provider == "OKTA" {
+				factorID = factor.ID
+				break
+			}
+		}
+		if factorID == "" {
+			return "", errors.New("the user has not enrolled Okta Verify")
+		}
+
+		req, err := client.NewRequest("POST", fmt.Sprintf("users/%s/factors/%s/verify", user.ID, factorID), nil)
+		if err != nil {
+			return "", err
+		}
+		if _, err := client.Do(req, &result); err != nil {
+			return "", errwrap.Wrapf("failed to send Okta Verify push: {{err}}", err)
+		}
+	}
+
+	switch result.FactorResult {
+	case "SUCCESS":
+		return "", nil
+	case "WAITING":
+		if result.Links.Poll.Href == "" {
+			return "", errors.New("Okta did not return a link to poll the push notification")
+		}
+		return result.Links.Poll.Href, nil
+	default:
+		return "", fmt.Errorf("push notification was not approved: %s", strings.ToLower(result.FactorResult))
+	}
+}
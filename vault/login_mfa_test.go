@@ -0,0 +1,509 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/totp"
+	"github.com/hashicorp/vault/logical"
+	otplib "github.com/pquerna/otp"
+	totplib "github.com/pquerna/otp/totp"
+)
+
+func TestLoginMFA_TOTP(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies:    []string{"foo"},
+				DisplayName: "armon",
+				Alias: &logical.Alias{
+					Name: "armon",
+				},
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+	c.logicalBackends["totp"] = totp.Factory
+
+	for _, req := range []*logical.Request{
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/auth/foo",
+			Data: map[string]interface{}{
+				"type": "noop",
+			},
+		},
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/mfa/method/totp/my_totp",
+			Data: map[string]interface{}{
+				"issuer": "Vault",
+			},
+		},
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/auth/foo/tune",
+			Data: map[string]interface{}{
+				"mfa_methods": "my_totp",
+			},
+		},
+	} {
+		req.ClientToken = root
+		if resp, err := c.HandleRequest(req); err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v, resp: %#v", req.Path, err, resp)
+		}
+	}
+
+	login := func() string {
+		resp, err := c.HandleRequest(&logical.Request{
+			Path: "auth/foo/login",
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp == nil || resp.Auth == nil || resp.Auth.ClientToken != "" || resp.Auth.MFARequirement == nil {
+			t.Fatalf("expected an MFA requirement, got %#v", resp)
+		}
+		methods := resp.Auth.MFARequirement.MFAMethods
+		if len(methods) != 1 || methods[0].Name != "my_totp" || methods[0].Type != "totp" || !methods[0].UsesPasscode {
+			t.Fatalf("bad: %#v", methods)
+		}
+		return resp.Auth.MFARequirement.MFARequestID
+	}
+
+	validate := func(requestID, passcode string) (*logical.Response, error) {
+		return c.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/mfa/validate",
+			Data: map[string]interface{}{
+				"mfa_request_id": requestID,
+				"mfa_payload": map[string]interface{}{
+					"my_totp": []interface{}{passcode},
+				},
+			},
+		})
+	}
+
+	// Logging in creates the entity, but no secret has been generated for
+	// it yet
+	requestID := login()
+	if _, err := validate(requestID, "123456"); err == nil {
+		t.Fatal("expected validation without a TOTP secret to fail")
+	}
+
+	alias, err := c.identityStore.MemDBAliasByFactors(c.router.MatchingMountEntry("auth/foo/").Accessor, "armon", false, false)
+	if err != nil || alias == nil {
+		t.Fatalf("alias not found: %v", err)
+	}
+
+	resp, err := c.HandleRequest(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/mfa/method/totp/my_totp/admin-generate",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"entity_id": alias.CanonicalID,
+		},
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["barcode"] == "" {
+		t.Fatal("expected a QR code")
+	}
+	key, err := otplib.NewKeyFromURL(resp.Data["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totplib.GenerateCodeCustom(key.Secret(), time.Now(), totplib.ValidateOpts{
+		Period:    30,
+		Digits:    otplib.DigitsSix,
+		Algorithm: otplib.AlgorithmSHA1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A wrong passcode fails, and the request cannot be attempted again
+	wrongCode := code[:5] + string('0'+(code[5]-'0'+1)%10)
+	requestID = login()
+	if _, err := validate(requestID, wrongCode); err == nil {
+		t.Fatal("expected a wrong passcode to fail")
+	}
+	resp, err = validate(requestID, code)
+	if err == nil || !strings.Contains(resp.Error().Error(), "invalid or expired") {
+		t.Fatalf("expected the request ID to have been used, got %v", err)
+	}
+
+	// The right passcode completes the login
+	resp, err = validate(login(), code)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Auth == nil || resp.Auth.ClientToken == "" {
+		t.Fatalf("expected a token, got %#v", resp)
+	}
+	te, err := c.tokenStore.Lookup(context.Background(), resp.Auth.ClientToken)
+	if err != nil || te == nil {
+		t.Fatalf("token not found: %v", err)
+	}
+	if te.Path != "auth/foo/login" || te.DisplayName != "foo-armon" || te.EntityID != alias.CanonicalID {
+		t.Fatalf("bad: %#v", te)
+	}
+
+	// Passcodes cannot be replayed
+	if _, err := validate(login(), code); err == nil {
+		t.Fatal("expected a used passcode to fail")
+	}
+
+	// Deleting the method deletes the secrets generated for it
+	resp, err = c.HandleRequest(&logical.Request{
+		Operation:   logical.DeleteOperation,
+		Path:        "sys/mfa/method/totp/my_totp",
+		ClientToken: root,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	keys, err := logical.CollectKeys(context.Background(), c.loginMFA.totpView("my_totp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected the TOTP secrets to be deleted, got %v", keys)
+	}
+}
+
+func TestLoginMFA_PolicyRequirement(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies: []string{"foo"},
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	for _, req := range []*logical.Request{
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/auth/foo",
+			Data: map[string]interface{}{
+				"type": "noop",
+			},
+		},
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/mfa/method/totp/my_totp",
+			Data: map[string]interface{}{
+				"issuer": "Vault",
+			},
+		},
+	} {
+		req.ClientToken = root
+		if resp, err := c.HandleRequest(req); err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v, resp: %#v", req.Path, err, resp)
+		}
+	}
+
+	// Without a requirement a token is returned
+	resp, err := c.HandleRequest(&logical.Request{
+		Path: "auth/foo/login",
+	})
+	if err != nil || resp.Auth.ClientToken == "" {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	policy, _ := ParseACLPolicy(`mfa_methods = ["my_totp"]`)
+	policy.Name = "foo"
+	if err := c.policyStore.SetPolicy(context.Background(), policy); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = c.HandleRequest(&logical.Request{
+		Path: "auth/foo/login",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Auth.ClientToken != "" || resp.Auth.MFARequirement == nil {
+		t.Fatalf("expected an MFA requirement, got %#v", resp.Auth)
+	}
+
+	// The type of a method cannot be changed
+	resp, err = c.HandleRequest(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/mfa/method/duo/my_totp",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"integration_key": "foo",
+			"secret_key":      "bar",
+			"api_hostname":    "api.duosecurity.com",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %v, %#v", err, resp)
+	}
+}
+
+func TestLoginMFA_PendingExpiry(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies:       []string{"foo"},
+				ExplicitMaxTTL: time.Hour,
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	for _, req := range []*logical.Request{
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/auth/foo",
+			Data: map[string]interface{}{
+				"type": "noop",
+			},
+		},
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/mfa/method/totp/my_totp",
+			Data: map[string]interface{}{
+				"issuer": "Vault",
+			},
+		},
+		&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/auth/foo/tune",
+			Data: map[string]interface{}{
+				"mfa_methods": "my_totp",
+			},
+		},
+	} {
+		req.ClientToken = root
+		if resp, err := c.HandleRequest(req); err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err: %v, resp: %#v", req.Path, err, resp)
+		}
+	}
+
+	login := func() string {
+		resp, err := c.HandleRequest(&logical.Request{
+			Path: "auth/foo/login",
+		})
+		if err != nil || resp == nil || resp.Auth == nil || resp.Auth.MFARequirement == nil {
+			t.Fatalf("expected an MFA requirement, got %v, %#v", err, resp)
+		}
+		return resp.Auth.MFARequirement.MFARequestID
+	}
+
+	// Pending logins are kept in storage, indexed by their salted ID
+	ctx := context.Background()
+	requestID := login()
+	path, err := c.loginMFA.pendingPath(ctx, requestID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(path, requestID) {
+		t.Fatalf("expected the request ID to be salted, got %q", path)
+	}
+	pending, err := c.loginMFA.readPending(ctx, path)
+	if err != nil || pending == nil {
+		t.Fatalf("pending login not found: %v", err)
+	}
+	if pending.Path != "auth/foo/login" || pending.Response.Auth.ExplicitMaxTTL != time.Hour {
+		t.Fatalf("bad: %#v", pending)
+	}
+
+	// Tidying leaves pending logins until they expire
+	if err := c.loginMFA.tidyPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := c.loginMFA.readPending(ctx, path); err != nil || pending == nil {
+		t.Fatalf("expected the pending login to be kept: %v", err)
+	}
+
+	pending.ExpireTime = time.Now().Add(-time.Second)
+	if err := c.loginMFA.putPending(ctx, requestID, pending); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.loginMFA.tidyPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := c.loginMFA.readPending(ctx, path); err != nil || pending != nil {
+		t.Fatalf("expected the pending login to be deleted: %v, %#v", err, pending)
+	}
+
+	// Expired logins cannot be validated even if they haven't been tidied
+	// yet
+	requestID = login()
+	path, _ = c.loginMFA.pendingPath(ctx, requestID)
+	pending, _ = c.loginMFA.readPending(ctx, path)
+	pending.ExpireTime = time.Now().Add(-time.Second)
+	if err := c.loginMFA.putPending(ctx, requestID, pending); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sys/mfa/validate",
+		Data: map[string]interface{}{
+			"mfa_request_id": requestID,
+		},
+	})
+	if err == nil || !strings.Contains(resp.Error().Error(), "invalid or expired") {
+		t.Fatalf("expected the request ID to have expired, got %v", err)
+	}
+
+	// Methods that passed while a push notification was waiting for approval
+	// are not validated again when the login is polled
+	requestID = login()
+	path, _ = c.loginMFA.pendingPath(ctx, requestID)
+	pending, _ = c.loginMFA.readPending(ctx, path)
+	pending.Passed = []string{"my_totp"}
+	if err := c.loginMFA.putPending(ctx, requestID, pending); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = c.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sys/mfa/validate",
+		Data: map[string]interface{}{
+			"mfa_request_id": requestID,
+		},
+	})
+	if err != nil || resp == nil || resp.Auth == nil || resp.Auth.ClientToken == "" {
+		t.Fatalf("expected a token, got %v, %#v", err, resp)
+	}
+}
+
+func TestLoginMFA_UpgradeLegacy(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies: []string{"foo"},
+				Alias: &logical.Alias{
+					Name: "armon",
+				},
+			},
+		},
+	}
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	resp, err := c.HandleRequest(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/auth/foo",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"type": "noop",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	me := c.router.MatchingMountEntry("auth/foo/")
+
+	ctx := context.Background()
+	view := NewBarrierView(c.barrier, credentialBarrierPrefix+me.UUID+"/")
+	for path, val := range map[string]interface{}{
+		legacyMFAConfigPath: &legacyMFAConfig{
+			Type: "duo",
+		},
+		legacyMFADuoAccessPath: &legacyDuoAccess{
+			IKey: "foo",
+			SKey: "bar",
+			Host: "api.duosecurity.com",
+		},
+		legacyMFADuoConfigPath: &legacyDuoConfig{
+			UsernameFormat: "%s@example.com",
+			PushInfo:       "from=vault",
+		},
+	} {
+		entry, err := logical.StorageEntryJSON(path, val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := view.Put(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.upgradeLegacyMFA(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	name := legacyMFAMethodPrefix + me.Accessor
+	method, err := c.loginMFA.method(ctx, name)
+	if err != nil || method == nil {
+		t.Fatalf("method not found: %v", err)
+	}
+	expected := &MFAMethod{
+		Name:           name,
+		Type:           mfaMethodTypeDuo,
+		MountAccessor:  me.Accessor,
+		UsernameFormat: "{{alias.name}}@example.com",
+		IntegrationKey: "foo",
+		SecretKey:      "bar",
+		APIHostname:    "api.duosecurity.com",
+		PushInfo:       "from=vault",
+	}
+	if !reflect.DeepEqual(method, expected) {
+		t.Fatalf("bad: expected %#v, got %#v", expected, method)
+	}
+
+	keys, err := logical.CollectKeys(ctx, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected the legacy configuration to be deleted, got %v", keys)
+	}
+
+	// The mount requires the method, and upgrading again does nothing
+	if err := c.upgradeLegacyMFA(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(me.Config.MFAMethods, []string{name}) {
+		t.Fatalf("bad: %v", me.Config.MFAMethods)
+	}
+	resp, err = c.HandleRequest(&logical.Request{
+		Path: "auth/foo/login",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Auth.ClientToken != "" || resp.Auth.MFARequirement == nil || resp.Auth.MFARequirement.MFAMethods[0].Name != name {
+		t.Fatalf("expected an MFA requirement, got %#v", resp.Auth)
+	}
+
+	// The method can be switched to passcodes
+	resp, err = c.HandleRequest(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/mfa/method/duo/" + name,
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"use_passcode": true,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if method, _ := c.loginMFA.method(ctx, name); !method.UsePasscode || method.IntegrationKey != "foo" {
+		t.Fatalf("bad: %#v", method)
+	}
+}
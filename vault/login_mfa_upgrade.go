@@ -0,0 +1,136 @@
+package vault
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+const (
+	// legacyMFAConfigPath, legacyMFADuoAccessPath and legacyMFADuoConfigPath
+	// are the storage paths, relative to the view of an auth mount, of the
+	// MFA that auth methods used to wrap their login path with
+	legacyMFAConfigPath    = "mfa_config"
+	legacyMFADuoAccessPath = "duo/access"
+	legacyMFADuoConfigPath = "duo/config"
+
+	// legacyMFAMethodPrefix is the prefix of the names of the login MFA
+	// methods created for the legacy MFA of auth mounts
+	legacyMFAMethodPrefix = "legacy-duo-"
+)
+
+type legacyMFAConfig struct {
+	Type string `json:"type"`
+}
+
+type legacyDuoAccess struct {
+	SKey string `json:"skey"`
+	IKey string `json:"ikey"`
+	Host string `json:"host"`
+}
+
+type legacyDuoConfig struct {
+	UsernameFormat string `json:"username_format"`
+	UserAgent      string `json:"user_agent"`
+	PushInfo       string `json:"push_info"`
+}
+
+// upgradeLegacyMFA replaces the Duo MFA configured through the mfa_config,
+// duo/access and duo/config paths of auth mounts, which auth methods no
+// longer serve, with a login MFA method required by the mount. The username
+// sent to Duo was the login username, which is the name of the alias of the
+// login for every auth method that supported it.
+func (c *Core) upgradeLegacyMFA(ctx context.Context) error {
+	c.authLock.Lock()
+	defer c.authLock.Unlock()
+
+	for _, entry := range c.auth.Entries {
+		// Shared mounts are upgraded by the primary and replicated
+		if !entry.Local && c.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+			continue
+		}
+
+		view := NewBarrierView(c.barrier, credentialBarrierPrefix+entry.UUID+"/")
+		var config legacyMFAConfig
+		found, err := legacyMFAEntry(ctx, view, legacyMFAConfigPath, &config)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		// Duo was the only MFA type; others were ignored on login
+		if config.Type == mfaMethodTypeDuo {
+			var access legacyDuoAccess
+			if _, err := legacyMFAEntry(ctx, view, legacyMFADuoAccessPath, &access); err != nil {
+				return err
+			}
+			var duoConfig legacyDuoConfig
+			if _, err := legacyMFAEntry(ctx, view, legacyMFADuoConfigPath, &duoConfig); err != nil {
+				return err
+			}
+
+			method := &MFAMethod{
+				Name:           legacyMFAMethodPrefix + entry.Accessor,
+				Type:           mfaMethodTypeDuo,
+				MountAccessor:  entry.Accessor,
+				UsernameFormat: legacyMFAUsernameFormat(duoConfig.UsernameFormat),
+				IntegrationKey: access.IKey,
+				SecretKey:      access.SKey,
+				APIHostname:    access.Host,
+				PushInfo:       duoConfig.PushInfo,
+			}
+			if err := c.loginMFA.putMethod(ctx, method); err != nil {
+				return errwrap.Wrapf("failed to store MFA method: {{err}}", err)
+			}
+
+			if !strutil.StrListContains(entry.Config.MFAMethods, method.Name) {
+				oldVal := entry.Config.MFAMethods
+				entry.Config.MFAMethods = append(entry.Config.MFAMethods, method.Name)
+				if err := c.persistAuth(ctx, c.auth, entry.Local); err != nil {
+					entry.Config.MFAMethods = oldVal
+					return err
+				}
+				entry.SyncCache()
+			}
+
+			c.logger.Info("upgraded legacy MFA of auth method", "path", entry.Path, "mfa_method", method.Name)
+		}
+
+		for _, path := range []string{legacyMFAConfigPath, legacyMFADuoAccessPath, legacyMFADuoConfigPath} {
+			if err := view.Delete(ctx, path); err != nil {
+				return errwrap.Wrapf("failed to delete legacy MFA configuration: {{err}}", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func legacyMFAEntry(ctx context.Context, view *BarrierView, path string, out interface{}) (bool, error) {
+	entry, err := view.Get(ctx, path)
+	if err != nil {
+		return false, errwrap.Wrapf("failed to read legacy MFA configuration: {{err}}", err)
+	}
+	if entry == nil {
+		return false, nil
+	}
+	if err := entry.DecodeJSON(out); err != nil {
+		return false, errwrap.Wrapf("failed to decode legacy MFA configuration: {{err}}", err)
+	}
+	return true, nil
+}
+
+// legacyMFAUsernameFormat converts the format string the login username was
+// passed to into a login MFA username format
+func legacyMFAUsernameFormat(format string) string {
+	if format == "" {
+		return loginMFADefaultUsernameFormat
+	}
+	format = strings.Replace(format, "%s", loginMFADefaultUsernameFormat, -1)
+	return strings.Replace(format, "%%", "%", -1)
+}
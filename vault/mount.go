@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/mitchellh/copystructure"
@@ -134,11 +135,12 @@ func (t *MountTable) shallowClone() *MountTable {
 	return mt
 }
 
-// setTaint is used to set the taint on given entry
-func (t *MountTable) setTaint(path string, value bool) *MountEntry {
+// setTaint is used to set the taint on given entry in the namespace of ctx
+func (t *MountTable) setTaint(ctx context.Context, path string, value bool) *MountEntry {
+	ns := namespace.FromContext(ctx)
 	n := len(t.Entries)
 	for i := 0; i < n; i++ {
-		if t.Entries[i].Path == path {
+		if t.Entries[i].Path == path && t.Entries[i].Namespace().ID == ns.ID {
 			t.Entries[i].Tainted = value
 			return t.Entries[i]
 		}
@@ -146,12 +148,13 @@ func (t *MountTable) setTaint(path string, value bool) *MountEntry {
 	return nil
 }
 
-// remove is used to remove a given path entry; returns the entry that was
-// removed
-func (t *MountTable) remove(path string) *MountEntry {
+// remove is used to remove a given path entry in the namespace of ctx;
+// returns the entry that was removed
+func (t *MountTable) remove(ctx context.Context, path string) *MountEntry {
+	ns := namespace.FromContext(ctx)
 	n := len(t.Entries)
 	for i := 0; i < n; i++ {
-		if entry := t.Entries[i]; entry.Path == path {
+		if entry := t.Entries[i]; entry.Path == path && entry.Namespace().ID == ns.ID {
 			t.Entries[i], t.Entries[n-1] = t.Entries[n-1], nil
 			t.Entries = t.Entries[:n-1]
 			return entry
@@ -183,6 +186,10 @@ type MountEntry struct {
 	Local            bool              `json:"local"`              // Local mounts are not replicated or affected by replication
 	SealWrap         bool              `json:"seal_wrap"`          // Whether to wrap CSPs
 	Tainted          bool              `json:"tainted,omitempty"`  // Set as a Write-Ahead flag for unmount/remount
+	NamespaceID      string            `json:"namespace_id"`       // ID of the namespace the mount belongs to
+
+	// namespace is the namespace of the mount, resolved from NamespaceID
+	namespace *namespace.Namespace
 
 	// synthesizedConfigCache is used to cache configuration values. These
 	// particular values are cached since we want to get them at a point-in-time
@@ -201,6 +208,7 @@ type MountConfig struct {
 	AuditNonHMACResponseKeys  []string             `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys" mapstructure:"audit_non_hmac_response_keys"`
 	ListingVisibility         ListingVisiblityType `json:"listing_visibility,omitempty" structs:"listing_visibility" mapstructure:"listing_visibility"`
 	PassthroughRequestHeaders []string             `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
+	MFAMethods                []string             `json:"mfa_methods,omitempty" structs:"mfa_methods" mapstructure:"mfa_methods"`
 }
 
 // APIMountConfig is an embedded struct of api.MountConfigInput
@@ -213,6 +221,7 @@ type APIMountConfig struct {
 	AuditNonHMACResponseKeys  []string             `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys" mapstructure:"audit_non_hmac_response_keys"`
 	ListingVisibility         ListingVisiblityType `json:"listing_visibility,omitempty" structs:"listing_visibility" mapstructure:"listing_visibility"`
 	PassthroughRequestHeaders []string             `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
+	MFAMethods                []string             `json:"mfa_methods,omitempty" structs:"mfa_methods" mapstructure:"mfa_methods"`
 }
 
 // Clone returns a deep copy of the mount entry
@@ -221,7 +230,27 @@ func (e *MountEntry) Clone() (*MountEntry, error) {
 	if err != nil {
 		return nil, err
 	}
-	return cp.(*MountEntry), nil
+	entry := cp.(*MountEntry)
+	entry.namespace = e.namespace
+	return entry, nil
+}
+
+// Namespace returns the namespace the mount belongs to
+func (e *MountEntry) Namespace() *namespace.Namespace {
+	if e == nil || e.namespace == nil {
+		return namespace.RootNamespace
+	}
+	return e.namespace
+}
+
+// APIPath returns the full path requests are routed on for the mount,
+// including the namespace path and, for auth methods, the auth/ prefix
+func (e *MountEntry) APIPath() string {
+	path := e.Path
+	if e.Table == credentialTableType {
+		path = credentialRoutePrefix + path
+	}
+	return e.Namespace().Path + path
 }
 
 // SyncCache syncs tunable configuration values to the cache. In the case of
@@ -245,6 +274,12 @@ func (e *MountEntry) SyncCache() {
 	} else {
 		e.synthesizedConfigCache.Store("passthrough_request_headers", e.Config.PassthroughRequestHeaders)
 	}
+
+	if len(e.Config.MFAMethods) == 0 {
+		e.synthesizedConfigCache.Delete("mfa_methods")
+	} else {
+		e.synthesizedConfigCache.Store("mfa_methods", e.Config.MFAMethods)
+	}
 }
 
 // Mount is used to mount a new backend to the mount table.
@@ -274,10 +309,17 @@ func (c *Core) mountInternal(ctx context.Context, entry *MountEntry) error {
 	c.mountsLock.Lock()
 	defer c.mountsLock.Unlock()
 
+	ns := namespace.FromContext(ctx)
+	entry.NamespaceID = ns.ID
+	entry.namespace = ns
+
 	// Verify there are no conflicting mounts
-	if match := c.router.MountConflict(entry.Path); match != "" {
+	if match := c.router.MountConflict(entry.APIPath()); match != "" {
 		return logical.CodedError(409, fmt.Sprintf("existing mount at %s", match))
 	}
+	if err := c.checkMountNamespace(ns, entry.APIPath()); err != nil {
+		return err
+	}
 
 	// Generate a new UUID and view
 	if entry.UUID == "" {
@@ -342,12 +384,12 @@ func (c *Core) mountInternal(ctx context.Context, entry *MountEntry) error {
 	}
 	c.mounts = newTable
 
-	if err := c.router.Mount(backend, entry.Path, entry, view); err != nil {
+	if err := c.router.Mount(backend, entry.APIPath(), entry, view); err != nil {
 		return err
 	}
 
 	if c.logger.IsInfo() {
-		c.logger.Info("successful mount", "path", entry.Path, "type", entry.Type)
+		c.logger.Info("successful mount", "path", entry.APIPath(), "type", entry.Type)
 	}
 	return nil
 }
@@ -370,40 +412,48 @@ func (c *Core) unmount(ctx context.Context, path string) error {
 }
 
 func (c *Core) unmountInternal(ctx context.Context, path string) error {
+	ns := namespace.FromContext(ctx)
+	fullPath := ns.Path + path
+
 	// Verify exact match of the route
-	match := c.router.MatchingMount(path)
-	if match == "" || path != match {
+	match := c.router.MatchingMount(fullPath)
+	if match == "" || fullPath != match {
 		return fmt.Errorf("no matching mount")
 	}
 
-	// Get the view for this backend
-	view := c.router.MatchingStorageByAPIPath(path)
-
 	// Get the backend/mount entry for this path, used to remove ignored
 	// replication prefixes
-	backend := c.router.MatchingBackend(path)
-	entry := c.router.MatchingMountEntry(path)
+	backend := c.router.MatchingBackend(fullPath)
+	entry := c.router.MatchingMountEntry(fullPath)
+
+	// Mounts of child namespaces can only be removed from within them
+	if entry.Namespace().ID != ns.ID {
+		return fmt.Errorf("no matching mount")
+	}
+
+	// Get the view for this backend
+	view := c.router.MatchingStorageByAPIPath(fullPath)
 
 	// Mark the entry as tainted
 	if err := c.taintMountEntry(ctx, path); err != nil {
-		c.logger.Error("failed to taint mount entry for path being unmounted", "error", err, "path", path)
+		c.logger.Error("failed to taint mount entry for path being unmounted", "error", err, "path", fullPath)
 		return err
 	}
 
 	// Taint the router path to prevent routing. Note that in-flight requests
 	// are uncertain, right now.
-	if err := c.router.Taint(path); err != nil {
+	if err := c.router.Taint(fullPath); err != nil {
 		return err
 	}
 
 	if backend != nil {
 		// Invoke the rollback manager a final time
-		if err := c.rollback.Rollback(path); err != nil {
+		if err := c.rollback.Rollback(fullPath); err != nil {
 			return err
 		}
 
 		// Revoke all the dynamic keys
-		if err := c.expiration.RevokePrefix(path); err != nil {
+		if err := c.expiration.RevokePrefix(fullPath); err != nil {
 			return err
 		}
 
@@ -412,7 +462,7 @@ func (c *Core) unmountInternal(ctx context.Context, path string) error {
 	}
 
 	// Unmount the backend entirely
-	if err := c.router.Unmount(ctx, path); err != nil {
+	if err := c.router.Unmount(ctx, fullPath); err != nil {
 		return err
 	}
 
@@ -427,12 +477,12 @@ func (c *Core) unmountInternal(ctx context.Context, path string) error {
 
 	// Remove the mount table entry
 	if err := c.removeMountEntry(ctx, path); err != nil {
-		c.logger.Error("failed to remove mount entry for path being unmounted", "error", err, "path", path)
+		c.logger.Error("failed to remove mount entry for path being unmounted", "error", err, "path", fullPath)
 		return err
 	}
 
 	if c.logger.IsInfo() {
-		c.logger.Info("successfully unmounted", "path", path)
+		c.logger.Info("successfully unmounted", "path", fullPath)
 	}
 	return nil
 }
@@ -444,7 +494,7 @@ func (c *Core) removeMountEntry(ctx context.Context, path string) error {
 
 	// Remove the entry from the mount table
 	newTable := c.mounts.shallowClone()
-	entry := newTable.remove(path)
+	entry := newTable.remove(ctx, path)
 	if entry == nil {
 		c.logger.Error("nil entry found removing entry in mounts table", "path", path)
 		return logical.CodedError(500, "failed to remove entry in mounts table")
@@ -473,7 +523,7 @@ func (c *Core) taintMountEntry(ctx context.Context, path string) error {
 
 	// As modifying the taint of an entry affects shallow clones,
 	// we simply use the original
-	entry := c.mounts.setTaint(path, true)
+	entry := c.mounts.setTaint(ctx, path, true)
 	if entry == nil {
 		c.logger.Error("nil entry found tainting entry in mounts table", "path", path)
 		return logical.CodedError(500, "failed to taint entry in mounts table")
@@ -491,7 +541,7 @@ func (c *Core) taintMountEntry(ctx context.Context, path string) error {
 // remountForce takes a copy of the mount entry for the path and fully unmounts
 // and remounts the backend to pick up any changes, such as filtered paths
 func (c *Core) remountForce(ctx context.Context, path string) error {
-	me := c.router.MatchingMountEntry(path)
+	me := c.router.MatchingMountEntry(namespace.FromContext(ctx).Path + path)
 	if me == nil {
 		return fmt.Errorf("cannot find mount for path %q", path)
 	}
@@ -524,15 +574,22 @@ func (c *Core) remount(ctx context.Context, src, dst string) error {
 		}
 	}
 
+	ns := namespace.FromContext(ctx)
+	fullSrc := ns.Path + src
+	fullDst := ns.Path + dst
+
 	// Verify exact match of the route
-	match := c.router.MatchingMount(src)
-	if match == "" || src != match {
+	match := c.router.MatchingMount(fullSrc)
+	if match == "" || fullSrc != match || c.router.MatchingMountEntry(fullSrc).Namespace().ID != ns.ID {
 		return fmt.Errorf("no matching mount at %q", src)
 	}
 
-	if match := c.router.MatchingMount(dst); match != "" {
+	if match := c.router.MountConflict(fullDst); match != "" {
 		return fmt.Errorf("existing mount at %q", match)
 	}
+	if err := c.checkMountNamespace(ns, fullDst); err != nil {
+		return err
+	}
 
 	// Mark the entry as tainted
 	if err := c.taintMountEntry(ctx, src); err != nil {
@@ -540,24 +597,25 @@ func (c *Core) remount(ctx context.Context, src, dst string) error {
 	}
 
 	// Taint the router path to prevent routing
-	if err := c.router.Taint(src); err != nil {
+	if err := c.router.Taint(fullSrc); err != nil {
 		return err
 	}
 
 	// Invoke the rollback manager a final time
-	if err := c.rollback.Rollback(src); err != nil {
+	if err := c.rollback.Rollback(fullSrc); err != nil {
 		return err
 	}
 
 	// Revoke all the dynamic keys
-	if err := c.expiration.RevokePrefix(src); err != nil {
+	if err := c.expiration.RevokePrefix(fullSrc); err != nil {
 		return err
 	}
 
 	c.mountsLock.Lock()
 	var entry *MountEntry
-	for _, entry = range c.mounts.Entries {
-		if entry.Path == src {
+	for _, e := range c.mounts.Entries {
+		if e.Path == src && e.Namespace().ID == ns.ID {
+			entry = e
 			entry.Path = dst
 			entry.Tainted = false
 			break
@@ -581,17 +639,17 @@ func (c *Core) remount(ctx context.Context, src, dst string) error {
 	c.mountsLock.Unlock()
 
 	// Remount the backend
-	if err := c.router.Remount(src, dst); err != nil {
+	if err := c.router.Remount(fullSrc, fullDst); err != nil {
 		return err
 	}
 
 	// Un-taint the path
-	if err := c.router.Untaint(dst); err != nil {
+	if err := c.router.Untaint(fullDst); err != nil {
 		return err
 	}
 
 	if c.logger.IsInfo() {
-		c.logger.Info("successful remount", "old_path", src, "new_path", dst)
+		c.logger.Info("successful remount", "old_path", fullSrc, "new_path", fullDst)
 	}
 	return nil
 }
@@ -654,7 +712,7 @@ func (c *Core) loadMounts(ctx context.Context) error {
 	for _, requiredMount := range c.requiredMountTable().Entries {
 		foundRequired := false
 		for _, coreMount := range c.mounts.Entries {
-			if coreMount.Type == requiredMount.Type {
+			if coreMount.Type == requiredMount.Type && isRootNamespaceID(coreMount.NamespaceID) {
 				foundRequired = true
 				break
 			}
@@ -699,6 +757,16 @@ func (c *Core) loadMounts(ctx context.Context) error {
 			entry.BackendAwareUUID = bUUID
 			needPersist = true
 		}
+		if entry.NamespaceID == "" {
+			entry.NamespaceID = namespace.RootNamespaceID
+			needPersist = true
+		}
+		ns := c.namespaceStore.NamespaceByID(entry.NamespaceID)
+		if ns == nil {
+			c.logger.Error("namespace of mount entry not found", "namespace_id", entry.NamespaceID, "path", entry.Path)
+			return errLoadMountsFailed
+		}
+		entry.namespace = ns
 
 		// Sync values to the cache
 		entry.SyncCache()
@@ -798,9 +866,9 @@ func (c *Core) setupMounts(ctx context.Context) error {
 
 	for _, entry := range c.mounts.Entries {
 
-		// Initialize the backend, special casing for system
+		// Initialize the backend, special casing for the root system backend
 		barrierPath := backendBarrierPrefix + entry.UUID + "/"
-		if entry.Type == "system" {
+		if entry.Type == "system" && entry.Namespace().ID == namespace.RootNamespaceID {
 			barrierPath = systemBarrierPrefix
 		}
 
@@ -844,19 +912,19 @@ func (c *Core) setupMounts(ctx context.Context) error {
 
 	ROUTER_MOUNT:
 		// Mount the backend
-		err = c.router.Mount(backend, entry.Path, entry, view)
+		err = c.router.Mount(backend, entry.APIPath(), entry, view)
 		if err != nil {
-			c.logger.Error("failed to mount entry", "path", entry.Path, "error", err)
+			c.logger.Error("failed to mount entry", "path", entry.APIPath(), "error", err)
 			return errLoadMountsFailed
 		}
 
 		if c.logger.IsInfo() {
-			c.logger.Info("successfully mounted backend", "type", entry.Type, "path", entry.Path)
+			c.logger.Info("successfully mounted backend", "type", entry.Type, "path", entry.APIPath())
 		}
 
 		// Ensure the path is tainted if set in the mount table
 		if entry.Tainted {
-			c.router.Taint(entry.Path)
+			c.router.Taint(entry.APIPath())
 		}
 	}
 	return nil
@@ -871,7 +939,7 @@ func (c *Core) unloadMounts(ctx context.Context) error {
 	if c.mounts != nil {
 		mountTable := c.mounts.shallowClone()
 		for _, e := range mountTable.Entries {
-			backend := c.router.MatchingBackend(e.Path)
+			backend := c.router.MatchingBackend(e.APIPath())
 			if backend != nil {
 				backend.Cleanup(ctx)
 			}
@@ -1077,15 +1145,23 @@ func (c *Core) singletonMountTables() (mounts, auth *MountTable) {
 }
 
 func (c *Core) setCoreBackend(entry *MountEntry, backend logical.Backend, view *BarrierView) {
+	// Child namespaces have their own system and identity backends, which
+	// are only reached through the router
+	root := entry.Namespace().ID == namespace.RootNamespaceID
+
 	switch entry.Type {
 	case "system":
-		c.systemBackend = backend.(*SystemBackend)
-		c.systemBarrierView = view
+		if root {
+			c.systemBackend = backend.(*SystemBackend)
+			c.systemBarrierView = view
+		}
 	case "cubbyhole":
 		ch := backend.(*CubbyholeBackend)
 		ch.saltUUID = entry.UUID
 		ch.storageView = view
 	case "identity":
-		c.identityStore = backend.(*IdentityStore)
+		if root {
+			c.identityStore = backend.(*IdentityStore)
+		}
 	}
 }
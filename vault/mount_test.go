@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/compressutil"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -124,6 +125,8 @@ func TestCore_Mount_Local(t *testing.T) {
 				UUID:             "abcd",
 				Accessor:         "kv-abcd",
 				BackendAwareUUID: "abcde",
+				NamespaceID:      namespace.RootNamespaceID,
+				namespace:        namespace.RootNamespace,
 			},
 			&MountEntry{
 				Table:            mountTableType,
@@ -132,6 +135,8 @@ func TestCore_Mount_Local(t *testing.T) {
 				UUID:             "bcde",
 				Accessor:         "kv-bcde",
 				BackendAwareUUID: "bcdea",
+				NamespaceID:      namespace.RootNamespaceID,
+				namespace:        namespace.RootNamespace,
 			},
 		},
 	}
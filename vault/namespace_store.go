@@ -0,0 +1,448 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/armon/go-radix"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// coreNamespaceConfigPath is the barrier path the namespaces are
+	// persisted under
+	coreNamespaceConfigPath = "core/namespaces/"
+
+	// namespaceBarrierPrefix is the barrier prefix used for data that core
+	// keeps on behalf of a child namespace, such as its policies
+	namespaceBarrierPrefix = "namespaces/"
+)
+
+var (
+	// namespaceNameRegex restricts the names of namespaces to a single path
+	// segment
+	namespaceNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+	// namespaceSysPaths are the sys/ paths available in child namespaces; all
+	// other sys/ paths operate on the whole cluster and are only available
+	// in the root namespace
+	namespaceSysPaths = []string{
+		"sys/auth",
+		"sys/capabilities",
+		"sys/internal/specs/openapi",
+		"sys/internal/ui/",
+		"sys/leases/",
+		"sys/mfa/validate",
+		"sys/mounts",
+		"sys/namespaces",
+		"sys/policies/",
+		"sys/policy",
+		"sys/remount",
+		"sys/renew",
+		"sys/revoke",
+		"sys/tools/",
+		"sys/wrapping/",
+	}
+)
+
+// NamespaceStore keeps track of the namespaces. The namespaces themselves
+// only hold an ID and a path; their mounts, policies and tokens are kept by
+// the mount tables, policy store and token store, keyed by namespace.
+type NamespaceStore struct {
+	core *Core
+	view *BarrierView
+
+	// modifyLock serializes the creation and deletion of namespaces
+	modifyLock sync.Mutex
+
+	lock   sync.RWMutex
+	byID   map[string]*namespace.Namespace
+	byPath *radix.Tree
+}
+
+// NewNamespaceStore creates a namespace store and loads the persisted
+// namespaces
+func NewNamespaceStore(ctx context.Context, core *Core) (*NamespaceStore, error) {
+	ns := &NamespaceStore{
+		core:   core,
+		view:   NewBarrierView(core.barrier, coreNamespaceConfigPath),
+		byID:   make(map[string]*namespace.Namespace),
+		byPath: radix.New(),
+	}
+
+	keys, err := logical.CollectKeys(ctx, ns.view)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list namespaces: {{err}}", err)
+	}
+	for _, key := range keys {
+		raw, err := ns.view.Get(ctx, key)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to read namespace: {{err}}", err)
+		}
+		if raw == nil {
+			continue
+		}
+		var entry namespace.Namespace
+		if err := raw.DecodeJSON(&entry); err != nil {
+			return nil, errwrap.Wrapf("failed to decode namespace: {{err}}", err)
+		}
+		ns.byID[entry.ID] = &entry
+		ns.byPath.Insert(entry.Path, &entry)
+	}
+
+	return ns, nil
+}
+
+// setupNamespaceStore is used to load the namespaces when the vault is being
+// unsealed. This must happen before the mount tables are loaded.
+func (c *Core) setupNamespaceStore(ctx context.Context) error {
+	ns, err := NewNamespaceStore(ctx, c)
+	if err != nil {
+		c.logger.Error("failed to load namespaces", "error", err)
+		return err
+	}
+	c.namespaceStore = ns
+	return nil
+}
+
+// teardownNamespaceStore is used to reverse setupNamespaceStore when the
+// vault is being sealed
+func (c *Core) teardownNamespaceStore() error {
+	c.namespaceStore = nil
+	return nil
+}
+
+// isRootNamespaceID returns whether the ID refers to the root namespace;
+// entries written before namespaces existed have an empty ID
+func isRootNamespaceID(id string) bool {
+	return id == "" || id == namespace.RootNamespaceID
+}
+
+// NamespaceByID returns the namespace with the given ID, or nil if it does
+// not exist
+func (ns *NamespaceStore) NamespaceByID(id string) *namespace.Namespace {
+	if isRootNamespaceID(id) {
+		return namespace.RootNamespace
+	}
+	if ns == nil {
+		return nil
+	}
+
+	ns.lock.RLock()
+	defer ns.lock.RUnlock()
+	return ns.byID[id]
+}
+
+// NamespaceByPath returns the namespace with the given path, or nil if it
+// does not exist
+func (ns *NamespaceStore) NamespaceByPath(path string) *namespace.Namespace {
+	path = namespace.Canonicalize(path)
+	if path == "" {
+		return namespace.RootNamespace
+	}
+	if ns == nil {
+		return nil
+	}
+
+	ns.lock.RLock()
+	defer ns.lock.RUnlock()
+	raw, ok := ns.byPath.Get(path)
+	if !ok {
+		return nil
+	}
+	return raw.(*namespace.Namespace)
+}
+
+// LongestPrefix returns the deepest namespace that the given request path
+// is in, which is the root namespace if the path isn't in a child namespace
+func (ns *NamespaceStore) LongestPrefix(path string) *namespace.Namespace {
+	if ns == nil {
+		return namespace.RootNamespace
+	}
+
+	ns.lock.RLock()
+	defer ns.lock.RUnlock()
+	_, raw, ok := ns.byPath.LongestPrefix(path)
+	if !ok {
+		return namespace.RootNamespace
+	}
+	return raw.(*namespace.Namespace)
+}
+
+// ListChildren returns the direct children of the given namespace, sorted by
+// path
+func (ns *NamespaceStore) ListChildren(parent *namespace.Namespace) []*namespace.Namespace {
+	if ns == nil {
+		return nil
+	}
+
+	ns.lock.RLock()
+	defer ns.lock.RUnlock()
+
+	var children []*namespace.Namespace
+	ns.byPath.WalkPrefix(parent.Path, func(path string, raw interface{}) bool {
+		rest := strings.TrimSuffix(strings.TrimPrefix(path, parent.Path), "/")
+		if rest != "" && !strings.Contains(rest, "/") {
+			children = append(children, raw.(*namespace.Namespace))
+		}
+		return false
+	})
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Path < children[j].Path
+	})
+	return children
+}
+
+// all returns all child namespaces
+func (ns *NamespaceStore) all() []*namespace.Namespace {
+	if ns == nil {
+		return nil
+	}
+
+	ns.lock.RLock()
+	defer ns.lock.RUnlock()
+
+	ret := make([]*namespace.Namespace, 0, len(ns.byID))
+	for _, entry := range ns.byID {
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+func (ns *NamespaceStore) persist(ctx context.Context, entry *namespace.Namespace) error {
+	storageEntry, err := logical.StorageEntryJSON(entry.ID, entry)
+	if err != nil {
+		return err
+	}
+	if err := ns.view.Put(ctx, storageEntry); err != nil {
+		return err
+	}
+
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	ns.byID[entry.ID] = entry
+	ns.byPath.Insert(entry.Path, entry)
+	return nil
+}
+
+func (ns *NamespaceStore) remove(ctx context.Context, entry *namespace.Namespace) error {
+	if err := ns.view.Delete(ctx, entry.ID); err != nil {
+		return err
+	}
+
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	delete(ns.byID, entry.ID)
+	ns.byPath.Delete(entry.Path)
+	return nil
+}
+
+// namespaceSysPathAllowed returns whether a sys/ path, relative to its
+// namespace, may be used in a child namespace
+func namespaceSysPathAllowed(path string) bool {
+	for _, p := range namespaceSysPaths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMountNamespace verifies that a mount at the given API path would be
+// in the given namespace rather than in one of its child namespaces, where
+// requests to it would be handled with the child namespace's policies
+func (c *Core) checkMountNamespace(ns *namespace.Namespace, apiPath string) error {
+	if pathNS := c.namespaceStore.LongestPrefix(apiPath); pathNS.ID != ns.ID {
+		return logical.CodedError(409, fmt.Sprintf("path is in namespace %q", pathNS.Path))
+	}
+	return nil
+}
+
+// createNamespace creates a namespace with the given name as a child of the
+// namespace in ctx, along with its system, identity and cubbyhole mounts, its
+// token auth mount and its default policies
+func (c *Core) createNamespace(ctx context.Context, name string) (*namespace.Namespace, error) {
+	parent := namespace.FromContext(ctx)
+
+	name = strings.Trim(name, "/")
+	if !namespaceNameRegex.MatchString(name) {
+		return nil, logical.CodedError(400, fmt.Sprintf("invalid namespace name %q", name))
+	}
+	for _, p := range protectedMounts {
+		if name+"/" == p {
+			return nil, logical.CodedError(400, fmt.Sprintf("cannot create namespace %q", name))
+		}
+	}
+
+	c.namespaceStore.modifyLock.Lock()
+	defer c.namespaceStore.modifyLock.Unlock()
+
+	path := parent.Path + name + "/"
+	if c.namespaceStore.NamespaceByPath(path) != nil {
+		return nil, logical.CodedError(409, fmt.Sprintf("namespace %q already exists", name))
+	}
+	if conflict := c.router.MountConflict(path); conflict != "" {
+		return nil, logical.CodedError(409, fmt.Sprintf("existing mount at %s", conflict))
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	entry := &namespace.Namespace{
+		ID:   id,
+		Path: path,
+	}
+	if err := c.namespaceStore.persist(ctx, entry); err != nil {
+		c.logger.Error("failed to persist namespace", "path", path, "error", err)
+		return nil, logical.CodedError(500, "failed to persist namespace")
+	}
+
+	nsCtx := namespace.ContextWithNamespace(ctx, entry)
+	if err := c.setupNamespace(nsCtx, entry); err != nil {
+		c.logger.Error("failed to set up namespace", "path", path, "error", err)
+		if err := c.teardownNamespace(nsCtx, entry); err != nil {
+			c.logger.Error("failed to clean up namespace", "path", path, "error", err)
+		}
+		return nil, logical.CodedError(500, "failed to set up namespace")
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("created namespace", "path", path)
+	}
+	return entry, nil
+}
+
+// setupNamespace creates the mounts and policies a new namespace starts with
+func (c *Core) setupNamespace(ctx context.Context, entry *namespace.Namespace) error {
+	for _, me := range c.requiredMountTable().Entries {
+		if err := c.mountInternal(ctx, me); err != nil {
+			return err
+		}
+	}
+
+	tokenEntry := c.defaultAuthTable().Entries[0]
+	tokenEntry.NamespaceID = entry.ID
+	tokenEntry.namespace = entry
+	tokenEntry.SyncCache()
+
+	c.authLock.Lock()
+	newTable := c.auth.shallowClone()
+	newTable.Entries = append(newTable.Entries, tokenEntry)
+	if err := c.persistAuth(ctx, newTable, tokenEntry.Local); err != nil {
+		c.authLock.Unlock()
+		return err
+	}
+	c.auth = newTable
+	err := c.mountNamespaceTokenStore(tokenEntry)
+	c.authLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := c.policyStore.loadACLPolicy(ctx, defaultPolicyName, defaultPolicy); err != nil {
+		return err
+	}
+	return c.policyStore.loadACLPolicy(ctx, responseWrappingPolicyName, responseWrappingPolicy)
+}
+
+// mountNamespaceTokenStore routes the token auth mount of a child namespace
+// to the token store, which is shared by all namespaces
+func (c *Core) mountNamespaceTokenStore(entry *MountEntry) error {
+	view := NewBarrierView(c.barrier, credentialBarrierPrefix+entry.UUID+"/")
+	return c.router.Mount(&sharedBackend{Backend: c.tokenStore}, entry.APIPath(), entry, view)
+}
+
+// deleteNamespace deletes the child namespace with the given name of the
+// namespace in ctx. All leases and tokens in the namespace are revoked and
+// its mounts and policies are removed. Namespaces with children cannot be
+// deleted.
+func (c *Core) deleteNamespace(ctx context.Context, name string) error {
+	parent := namespace.FromContext(ctx)
+
+	c.namespaceStore.modifyLock.Lock()
+	defer c.namespaceStore.modifyLock.Unlock()
+
+	entry := c.namespaceStore.NamespaceByPath(parent.Path + strings.Trim(name, "/"))
+	if entry == nil || entry.ID == namespace.RootNamespaceID {
+		return nil
+	}
+	if len(c.namespaceStore.ListChildren(entry)) > 0 {
+		return logical.CodedError(400, fmt.Sprintf("namespace %q has child namespaces", name))
+	}
+
+	if err := c.teardownNamespace(namespace.ContextWithNamespace(ctx, entry), entry); err != nil {
+		c.logger.Error("failed to delete namespace", "path", entry.Path, "error", err)
+		return err
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("deleted namespace", "path", entry.Path)
+	}
+	return nil
+}
+
+// teardownNamespace revokes the leases of a namespace and removes its mounts,
+// policies and entry
+func (c *Core) teardownNamespace(ctx context.Context, entry *namespace.Namespace) error {
+	if err := c.expiration.RevokePrefix(entry.Path); err != nil {
+		return err
+	}
+
+	var mounts, auths []*MountEntry
+	c.mountsLock.RLock()
+	for _, me := range c.mounts.Entries {
+		if me.NamespaceID == entry.ID {
+			mounts = append(mounts, me)
+		}
+	}
+	c.mountsLock.RUnlock()
+	c.authLock.RLock()
+	for _, me := range c.auth.Entries {
+		if me.NamespaceID == entry.ID {
+			auths = append(auths, me)
+		}
+	}
+	c.authLock.RUnlock()
+
+	for _, me := range mounts {
+		if err := c.unmountInternal(ctx, me.Path); err != nil {
+			return err
+		}
+	}
+	for _, me := range auths {
+		if me.Type != "token" {
+			if err := c.disableCredential(ctx, me.Path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.router.Unmount(ctx, me.APIPath()); err != nil {
+			return err
+		}
+		if err := c.removeCredEntry(ctx, me.Path); err != nil {
+			return err
+		}
+	}
+
+	if err := c.policyStore.removeNamespace(ctx, entry); err != nil {
+		return err
+	}
+
+	return c.namespaceStore.remove(ctx, entry)
+}
+
+// sharedBackend wraps a backend that is mounted in several namespaces so
+// that removing one of its mounts does not clean up the backend
+type sharedBackend struct {
+	logical.Backend
+}
+
+func (b *sharedBackend) Cleanup(context.Context) {}
@@ -0,0 +1,325 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func testNamespaceRequest(t *testing.T, c *Core, token string, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+	req := logical.TestRequest(t, op, path)
+	req.ClientToken = token
+	req.Data = data
+	return c.HandleRequest(req)
+}
+
+func TestNamespaceStore_CreateListDelete(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	resp, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["path"] != "team1/" || resp.Data["id"] == "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	id := resp.Data["id"]
+
+	// Creating it again returns the existing namespace
+	resp, err = testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil)
+	if err != nil || resp.Data["id"] != id {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// Create a child of the namespace
+	resp, err = testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/namespaces/sub", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["path"] != "sub/" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if ns := c.namespaceStore.NamespaceByPath("team1/sub"); ns == nil || ns.Path != "team1/sub/" {
+		t.Fatalf("bad: %#v", ns)
+	}
+
+	resp, err = testNamespaceRequest(t, c, root, logical.ListOperation, "sys/namespaces", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"team1/"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = testNamespaceRequest(t, c, root, logical.ReadOperation, "sys/namespaces/team1", nil)
+	if err != nil || resp == nil || resp.Data["id"] != id {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// Namespaces with children cannot be deleted
+	resp, err = testNamespaceRequest(t, c, root, logical.DeleteOperation, "sys/namespaces/team1", nil)
+	if err == nil {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	resp, err = testNamespaceRequest(t, c, root, logical.DeleteOperation, "team1/sys/namespaces/sub", nil)
+	if err != nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	resp, err = testNamespaceRequest(t, c, root, logical.DeleteOperation, "sys/namespaces/team1", nil)
+	if err != nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = testNamespaceRequest(t, c, root, logical.ReadOperation, "sys/namespaces/team1", nil)
+	if err != nil || resp != nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if match := c.router.MatchingMount("team1/sys/"); match != "" {
+		t.Fatalf("mount still routed: %q", match)
+	}
+}
+
+func TestNamespaceStore_Isolation(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mount a secrets engine in the namespace
+	resp, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/mounts/kv", map[string]interface{}{
+		"type": "kv",
+	})
+	if err != nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/kv/foo", map[string]interface{}{
+		"value": "bar",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mount is only listed in its namespace
+	resp, err = testNamespaceRequest(t, c, root, logical.ReadOperation, "team1/sys/mounts", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"kv/", "sys/", "cubbyhole/", "identity/"} {
+		if _, ok := resp.Data[path]; !ok {
+			t.Fatalf("missing mount %q in %#v", path, resp.Data)
+		}
+	}
+	if _, ok := resp.Data["secret/"]; ok {
+		t.Fatalf("unexpected root mount in namespace: %#v", resp.Data)
+	}
+	resp, err = testNamespaceRequest(t, c, root, logical.ReadOperation, "sys/mounts", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.Data["kv/"]; ok {
+		t.Fatalf("unexpected namespace mount in root: %#v", resp.Data)
+	}
+
+	// Policies are kept per namespace
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/policy/reader", map[string]interface{}{
+		"policy": `path "kv/*" { capabilities = ["read"] }`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = testNamespaceRequest(t, c, root, logical.ReadOperation, "sys/policy/reader", nil)
+	if err != nil || resp != nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// Tokens created in the namespace use its policies and can't be used
+	// outside of it
+	resp, err = testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/auth/token/create", map[string]interface{}{
+		"policies": []string{"reader"},
+	})
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	token := resp.Auth.ClientToken
+
+	resp, err = testNamespaceRequest(t, c, token, logical.ReadOperation, "team1/kv/foo", nil)
+	if err != nil || resp == nil || resp.Data["value"] != "bar" {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if _, err := testNamespaceRequest(t, c, token, logical.UpdateOperation, "team1/kv/foo", nil); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	if _, err := testNamespaceRequest(t, c, token, logical.ReadOperation, "secret/foo", nil); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	resp, err = testNamespaceRequest(t, c, token, logical.ReadOperation, "team1/auth/token/lookup-self", nil)
+	if err != nil || resp.Data["path"] != "team1/auth/token/create" {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// Deleting the namespace revokes its tokens
+	if _, err := testNamespaceRequest(t, c, root, logical.DeleteOperation, "sys/namespaces/team1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if te, err := c.tokenStore.Lookup(context.Background(), token); err != nil || te != nil {
+		t.Fatalf("err: %v, token: %#v", err, te)
+	}
+}
+
+func TestNamespaceStore_SysPaths(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths that operate on the whole cluster are only available in the
+	// root namespace
+	if _, err := testNamespaceRequest(t, c, root, logical.ReadOperation, "team1/sys/audit", nil); err == nil || !errwrap.Contains(err, logical.ErrUnsupportedPath.Error()) {
+		t.Fatalf("expected unsupported path, got %v", err)
+	}
+	if _, err := testNamespaceRequest(t, c, root, logical.ReadOperation, "team1/sys/policy", nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNamespaceStore_Persistence(t *testing.T) {
+	c, keys, root := TestCoreUnsealed(t)
+
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/mounts/kv", map[string]interface{}{
+		"type": "kv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/kv/foo", map[string]interface{}{
+		"value": "bar",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &CoreConfig{
+		Physical:     c.physical,
+		DisableMlock: true,
+	}
+	c2, err := NewCore(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c2, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if ns := c2.namespaceStore.NamespaceByPath("team1"); ns == nil {
+		t.Fatal("namespace not loaded")
+	}
+	resp, err := testNamespaceRequest(t, c2, root, logical.ReadOperation, "team1/kv/foo", nil)
+	if err != nil || resp == nil || resp.Data["value"] != "bar" {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if match := c2.router.MatchingMount("team1/auth/token/create"); match != "team1/auth/token/" {
+		t.Fatalf("bad: %q", match)
+	}
+}
+
+func TestNamespaceStore_CrossNamespaceMounts(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Root namespace mounts can't be created inside of a namespace
+	for _, path := range []string{"sys/mounts/team1/kv", "sys/mounts/team1", "sys/auth/team1"} {
+		resp, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, path, map[string]interface{}{
+			"type": "kv",
+		})
+		if err == nil {
+			t.Fatalf("%s: expected error, got %#v", path, resp)
+		}
+	}
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/remount", map[string]interface{}{
+		"from": "secret",
+		"to":   "team1/secret",
+	}); err == nil {
+		t.Fatal("expected error remounting into a namespace")
+	}
+
+	// Requests to a root namespace mount inside of a namespace, as could have
+	// been created before namespaces checked their paths, must not be handled
+	// in the namespace
+	view := NewBarrierView(c.barrier, "logical/")
+	err := c.router.Mount(&NoopBackend{}, "team1/noop/", &MountEntry{Path: "team1/noop/", Type: "noop", UUID: "noop-uuid", Accessor: "noop-accessor"}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNamespaceRequest(t, c, root, logical.ReadOperation, "team1/noop/foo", nil); err == nil || !errwrap.Contains(err, logical.ErrUnsupportedPath.Error()) {
+		t.Fatalf("expected unsupported path, got %v", err)
+	}
+}
+
+func TestNamespaceStore_OpenAPI(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/mounts/team-kv", map[string]interface{}{
+		"type": "kv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/mounts/root-kv", map[string]interface{}{
+		"type": "kv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := func(path string) map[string]*framework.OASPathItem {
+		t.Helper()
+		resp, err := testNamespaceRequest(t, c, root, logical.ReadOperation, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc framework.OASDocument
+		if err := jsonutil.DecodeJSON(resp.Data[logical.HTTPRawBody].([]byte), &doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc.Paths
+	}
+
+	// Each namespace only lists its own mounts, relative to the namespace
+	nsPaths := paths("team1/sys/internal/specs/openapi")
+	for _, path := range []string{"/team-kv/{path}", "/sys/mounts", "/cubbyhole/{path}", "/auth/token/create"} {
+		if _, ok := nsPaths[path]; !ok {
+			t.Fatalf("missing path %q in namespace", path)
+		}
+	}
+	for _, path := range []string{"/root-kv/{path}", "/secret/{path}", "/sys/audit", "/team1/team-kv/{path}"} {
+		if _, ok := nsPaths[path]; ok {
+			t.Fatalf("unexpected path %q in namespace", path)
+		}
+	}
+
+	rootPaths := paths("sys/internal/specs/openapi")
+	for _, path := range []string{"/root-kv/{path}", "/secret/{path}", "/sys/audit"} {
+		if _, ok := rootPaths[path]; !ok {
+			t.Fatalf("missing path %q in root namespace", path)
+		}
+	}
+	for _, path := range []string{"/team-kv/{path}", "/team1/team-kv/{path}"} {
+		if _, ok := rootPaths[path]; ok {
+			t.Fatalf("unexpected path %q in root namespace", path)
+		}
+	}
+}
@@ -84,6 +84,10 @@ type Policy struct {
 	Paths []*PathRules `hcl:"-"`
 	Raw   string
 	Type  PolicyType
+
+	// MFAMethods are the login MFA methods that must be passed to obtain a
+	// token with this policy
+	MFAMethods []string `hcl:"mfa_methods"`
 }
 
 // PathRules represents a policy for a path in the namespace.
@@ -167,6 +171,7 @@ func ParseACLPolicy(rules string) (*Policy, error) {
 	valid := []string{
 		"name",
 		"path",
+		"mfa_methods",
 	}
 	if err := checkHCLKeys(list, valid); err != nil {
 		return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
@@ -12,6 +12,7 @@ import (
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/golang-lru"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 )
@@ -215,6 +216,46 @@ func (c *Core) teardownPolicyStore() error {
 	return nil
 }
 
+// aclViewForNamespace returns the view the ACL policies of the given
+// namespace are stored in
+func (ps *PolicyStore) aclViewForNamespace(ns *namespace.Namespace) *BarrierView {
+	if ns.ID == namespace.RootNamespaceID {
+		return ps.aclView
+	}
+	return NewBarrierView(ps.aclView.barrier, namespaceBarrierPrefix+ns.ID+"/"+systemBarrierPrefix+policyACLSubPath)
+}
+
+// cacheKey returns the key a policy of the given namespace is cached under;
+// policies of child namespaces are prefixed with the namespace ID
+func (ps *PolicyStore) cacheKey(ns *namespace.Namespace, name string) string {
+	if ns.ID == namespace.RootNamespaceID {
+		return name
+	}
+	return ns.ID + "/" + name
+}
+
+// removeNamespace deletes all policies of the given namespace
+func (ps *PolicyStore) removeNamespace(ctx context.Context, ns *namespace.Namespace) error {
+	ps.modifyLock.Lock()
+	defer ps.modifyLock.Unlock()
+
+	if err := logical.ClearView(ctx, ps.aclViewForNamespace(ns)); err != nil {
+		return errwrap.Wrapf("failed to delete namespace policies: {{err}}", err)
+	}
+
+	prefix := ps.cacheKey(ns, "")
+	ps.policyTypeMap.Range(func(key, _ interface{}) bool {
+		if strings.HasPrefix(key.(string), prefix) {
+			ps.policyTypeMap.Delete(key)
+			if ps.tokenPoliciesLRU != nil {
+				ps.tokenPoliciesLRU.Remove(key)
+			}
+		}
+		return true
+	})
+	return nil
+}
+
 func (ps *PolicyStore) invalidate(ctx context.Context, name string, policyType PolicyType) {
 	// This may come with a prefixed "/" due to joining the file path
 	saneName := strings.TrimPrefix(name, "/")
@@ -224,7 +265,7 @@ func (ps *PolicyStore) invalidate(ctx context.Context, name string, policyType P
 	switch policyType {
 	case PolicyTypeACL:
 		if ps.tokenPoliciesLRU != nil {
-			ps.tokenPoliciesLRU.Remove(saneName)
+			ps.tokenPoliciesLRU.Remove(ps.cacheKey(namespace.FromContext(ctx), saneName))
 		}
 
 	default:
@@ -260,6 +301,9 @@ func (ps *PolicyStore) SetPolicy(ctx context.Context, p *Policy) error {
 func (ps *PolicyStore) setPolicyInternal(ctx context.Context, p *Policy) error {
 	ps.modifyLock.Lock()
 	defer ps.modifyLock.Unlock()
+
+	ns := namespace.FromContext(ctx)
+	key := ps.cacheKey(ns, p.Name)
 	// Create the entry
 	entry, err := logical.StorageEntryJSON(p.Name, &PolicyEntry{
 		Version: 2,
@@ -271,14 +315,14 @@ func (ps *PolicyStore) setPolicyInternal(ctx context.Context, p *Policy) error {
 	}
 	switch p.Type {
 	case PolicyTypeACL:
-		if err := ps.aclView.Put(ctx, entry); err != nil {
+		if err := ps.aclViewForNamespace(ns).Put(ctx, entry); err != nil {
 			return errwrap.Wrapf("failed to persist policy: {{err}}", err)
 		}
-		ps.policyTypeMap.Store(p.Name, PolicyTypeACL)
+		ps.policyTypeMap.Store(key, PolicyTypeACL)
 
 		if ps.tokenPoliciesLRU != nil {
 			// Update the LRU cache
-			ps.tokenPoliciesLRU.Add(p.Name, p)
+			ps.tokenPoliciesLRU.Add(key, p)
 		}
 
 	default:
@@ -295,23 +339,31 @@ func (ps *PolicyStore) GetPolicy(ctx context.Context, name string, policyType Po
 	// Policies are normalized to lower-case
 	name = ps.sanitizeName(name)
 
+	ns := namespace.FromContext(ctx)
+	key := ps.cacheKey(ns, name)
+
 	var cache *lru.TwoQueueCache
 	var view *BarrierView
 	switch policyType {
 	case PolicyTypeACL:
 		cache = ps.tokenPoliciesLRU
-		view = ps.aclView
+		view = ps.aclViewForNamespace(ns)
 	case PolicyTypeToken:
 		cache = ps.tokenPoliciesLRU
-		val, ok := ps.policyTypeMap.Load(name)
+		val, ok := ps.policyTypeMap.Load(key)
 		if !ok {
-			// Doesn't exist
-			return nil, nil
+			// The type map is only populated up front for the root
+			// namespace; policies of child namespaces are all ACL policies
+			if ns.ID == namespace.RootNamespaceID {
+				// Doesn't exist
+				return nil, nil
+			}
+			val = PolicyTypeACL
 		}
 		policyType = val.(PolicyType)
 		switch policyType {
 		case PolicyTypeACL:
-			view = ps.aclView
+			view = ps.aclViewForNamespace(ns)
 		default:
 			return nil, fmt.Errorf("invalid type of policy in type map: %q", policyType)
 		}
@@ -319,7 +371,7 @@ func (ps *PolicyStore) GetPolicy(ctx context.Context, name string, policyType Po
 
 	if cache != nil {
 		// Check for cached policy
-		if raw, ok := cache.Get(name); ok {
+		if raw, ok := cache.Get(key); ok {
 			return raw.(*Policy), nil
 		}
 	}
@@ -328,7 +380,7 @@ func (ps *PolicyStore) GetPolicy(ctx context.Context, name string, policyType Po
 	if policyType == PolicyTypeACL && name == "root" {
 		p := &Policy{Name: "root"}
 		if cache != nil {
-			cache.Add(p.Name, p)
+			cache.Add(key, p)
 		}
 		return p, nil
 	}
@@ -338,7 +390,7 @@ func (ps *PolicyStore) GetPolicy(ctx context.Context, name string, policyType Po
 
 	// See if anything has added it since we got the lock
 	if cache != nil {
-		if raw, ok := cache.Get(name); ok {
+		if raw, ok := cache.Get(key); ok {
 			return raw.(*Policy), nil
 		}
 	}
@@ -372,10 +424,11 @@ func (ps *PolicyStore) GetPolicy(ctx context.Context, name string, policyType Po
 			return nil, errwrap.Wrapf("failed to parse policy: {{err}}", err)
 		}
 		policy.Paths = p.Paths
+		policy.MFAMethods = p.MFAMethods
 		// Reset this in case they set the name in the policy itself
 		policy.Name = name
 
-		ps.policyTypeMap.Store(name, PolicyTypeACL)
+		ps.policyTypeMap.Store(key, PolicyTypeACL)
 
 	default:
 		return nil, fmt.Errorf("unknown policy type %q", policyEntry.Type.String())
@@ -383,7 +436,7 @@ func (ps *PolicyStore) GetPolicy(ctx context.Context, name string, policyType Po
 
 	if cache != nil {
 		// Update the LRU cache
-		cache.Add(name, policy)
+		cache.Add(key, policy)
 	}
 
 	return policy, nil
@@ -398,7 +451,7 @@ func (ps *PolicyStore) ListPolicies(ctx context.Context, policyType PolicyType)
 	var err error
 	switch policyType {
 	case PolicyTypeACL:
-		keys, err = logical.CollectKeys(ctx, ps.aclView)
+		keys, err = logical.CollectKeys(ctx, ps.aclViewForNamespace(namespace.FromContext(ctx)))
 	default:
 		return nil, fmt.Errorf("unknown policy type %q", policyType)
 	}
@@ -433,6 +486,9 @@ func (ps *PolicyStore) DeletePolicy(ctx context.Context, name string, policyType
 	// Policies are normalized to lower-case
 	name = ps.sanitizeName(name)
 
+	ns := namespace.FromContext(ctx)
+	key := ps.cacheKey(ns, name)
+
 	switch policyType {
 	case PolicyTypeACL:
 		if strutil.StrListContains(immutablePolicies, name) {
@@ -442,17 +498,17 @@ func (ps *PolicyStore) DeletePolicy(ctx context.Context, name string, policyType
 			return fmt.Errorf("cannot delete default policy")
 		}
 
-		err := ps.aclView.Delete(ctx, name)
+		err := ps.aclViewForNamespace(ns).Delete(ctx, name)
 		if err != nil {
 			return errwrap.Wrapf("failed to delete policy: {{err}}", err)
 		}
 
 		if ps.tokenPoliciesLRU != nil {
 			// Clear the cache
-			ps.tokenPoliciesLRU.Remove(name)
+			ps.tokenPoliciesLRU.Remove(key)
 		}
 
-		ps.policyTypeMap.Delete(name)
+		ps.policyTypeMap.Delete(key)
 
 	}
 	return nil
@@ -249,6 +249,26 @@ func TestPolicy_Parse(t *testing.T) {
 	}
 }
 
+func TestPolicy_ParseMFAMethods(t *testing.T) {
+	p, err := ParseACLPolicy(strings.TrimSpace(`
+mfa_methods = ["my_totp", "my_duo"]
+
+path "secret/*" {
+	capabilities = ["read"]
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !reflect.DeepEqual(p.MFAMethods, []string{"my_totp", "my_duo"}) {
+		t.Fatalf("bad: %#v", p.MFAMethods)
+	}
+	if len(p.Paths) != 1 {
+		t.Fatalf("bad: %#v", p.Paths)
+	}
+}
+
 func TestPolicy_ParseBadRoot(t *testing.T) {
 	_, err := ParseACLPolicy(strings.TrimSpace(`
 name = "test"
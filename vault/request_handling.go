@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/helper/wrapping"
@@ -23,7 +24,9 @@ const (
 	replTimeout = 10 * time.Second
 )
 
-// HandleRequest is used to handle a new incoming request
+// HandleRequest is used to handle a new incoming request. Requests are routed
+// on their full path; the namespace of a request is the deepest namespace its
+// path is in.
 func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err error) {
 	c.stateLock.RLock()
 	defer c.stateLock.RUnlock()
@@ -37,6 +40,22 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 	ctx, cancel := context.WithCancel(c.activeContext)
 	defer cancel()
 
+	ns := c.namespaceStore.LongestPrefix(req.Path)
+	ctx = namespace.ContextWithNamespace(ctx, ns)
+	nsPath := ns.TrimmedPath(req.Path)
+
+	// Operations on the whole cluster are only available in the root
+	// namespace
+	if ns.ID != namespace.RootNamespaceID && strings.HasPrefix(nsPath, "sys/") && !namespaceSysPathAllowed(nsPath) {
+		return logical.ErrorResponse(fmt.Sprintf("path %q is not available in namespace %q", nsPath, ns.Path)), logical.ErrUnsupportedPath
+	}
+
+	// Requests are authorized with the policies of their namespace, so they
+	// must not be routed to a mount of another namespace
+	if me := c.router.MatchingMountEntry(req.Path); me != nil && me.Namespace().ID != ns.ID {
+		return logical.ErrorResponse(fmt.Sprintf("no handler for route '%s'", req.Path)), logical.ErrUnsupportedPath
+	}
+
 	// Allowing writing to a path ending in / makes it extremely difficult to
 	// understand user intent for the filesystem-like backends (kv,
 	// cubbyhole) -- did they want a key named foo/ or did they want to write
@@ -50,9 +69,12 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 	}
 
 	var auth *logical.Auth
-	if c.router.LoginPath(req.Path) {
+	switch {
+	case nsPath == loginMFAValidatePath:
+		resp, auth, err = c.handleLoginMFAValidate(ctx, req)
+	case c.router.LoginPath(req.Path):
 		resp, auth, err = c.handleLoginRequest(ctx, req)
-	} else {
+	default:
 		resp, auth, err = c.handleRequest(ctx, req)
 	}
 
@@ -99,7 +121,7 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 	// When unwrapping we want to log the actual response that will be written
 	// out. We still want to return the raw value to avoid automatic updating
 	// to any of it.
-	if req.Path == "sys/wrapping/unwrap" &&
+	if nsPath == "sys/wrapping/unwrap" &&
 		resp != nil &&
 		resp.Data != nil &&
 		resp.Data[logical.HTTPRawBody] != nil {
@@ -152,6 +174,9 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
 	defer metrics.MeasureSince([]string{"core", "handle_request"}, time.Now())
 
+	ns := namespace.FromContext(ctx)
+	nsPath := ns.TrimmedPath(req.Path)
+
 	var nonHMACReqDataKeys []string
 	entry := c.router.MatchingMountEntry(req.Path)
 	if entry != nil {
@@ -230,6 +255,13 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 	// Attach the display name
 	req.DisplayName = auth.DisplayName
 
+	// Batch tokens can't be revoked, so nothing would ever clean up their
+	// cubbyhole
+	if te != nil && te.Type == TokenTypeBatch && strings.HasPrefix(nsPath, "cubbyhole/") {
+		retErr = multierror.Append(retErr, logical.ErrInvalidRequest)
+		return logical.ErrorResponse("batch tokens cannot access the cubbyhole"), auth, retErr
+	}
+
 	// Create an audit trail of the request
 	logInput := &audit.LogInput{
 		Auth:               auth,
@@ -289,8 +321,8 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 
 	// If there is a secret, we must register it with the expiration manager.
 	// We exclude renewal of a lease, since it does not need to be re-registered
-	if resp != nil && resp.Secret != nil && !strings.HasPrefix(req.Path, "sys/renew") &&
-		!strings.HasPrefix(req.Path, "sys/leases/renew") {
+	if resp != nil && resp.Secret != nil && !strings.HasPrefix(nsPath, "sys/renew") &&
+		!strings.HasPrefix(nsPath, "sys/leases/renew") {
 		// KV mounts should return the TTL but not register
 		// for a lease as this provides a massive slowdown
 		registerLease := true
@@ -360,12 +392,26 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 
 	// If the request was to renew a token, and if there are group aliases set
 	// in the auth object, then the group memberships should be refreshed
-	if strings.HasPrefix(req.Path, "auth/token/renew") &&
+	if strings.HasPrefix(nsPath, "auth/token/renew") &&
 		resp != nil &&
 		resp.Auth != nil &&
 		resp.Auth.EntityID != "" &&
 		resp.Auth.GroupAliases != nil {
-		err := c.identityStore.refreshExternalGroupMembershipsByEntityID(resp.Auth.EntityID, resp.Auth.GroupAliases)
+		// The entity lives in the identity store of the renewed token's
+		// namespace
+		renewed, err := c.tokenStore.Lookup(ctx, resp.Auth.ClientToken)
+		if err != nil || renewed == nil {
+			c.logger.Error("failed to look up renewed token", "error", err)
+			retErr = multierror.Append(retErr, ErrInternalError)
+			return nil, auth, retErr
+		}
+		identityStore := c.namespaceIdentityStore(c.tokenNamespace(renewed))
+		if identityStore == nil {
+			c.logger.Error("identity store of renewed token not found")
+			retErr = multierror.Append(retErr, ErrInternalError)
+			return nil, auth, retErr
+		}
+		err = identityStore.refreshExternalGroupMembershipsByEntityID(resp.Auth.EntityID, resp.Auth.GroupAliases)
 		if err != nil {
 			c.logger.Error("failed to refresh external group memberships", "error", err)
 			retErr = multierror.Append(retErr, ErrInternalError)
@@ -376,8 +422,8 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 	// Only the token store is allowed to return an auth block, for any
 	// other request this is an internal error. We exclude renewal of a token,
 	// since it does not need to be re-registered
-	if resp != nil && resp.Auth != nil && !strings.HasPrefix(req.Path, "auth/token/renew") {
-		if !strings.HasPrefix(req.Path, "auth/token/") {
+	if resp != nil && resp.Auth != nil && !strings.HasPrefix(nsPath, "auth/token/renew") {
+		if !strings.HasPrefix(nsPath, "auth/token/") {
 			c.logger.Error("unexpected Auth response for non-token backend", "request_path", req.Path)
 			retErr = multierror.Append(retErr, ErrInternalError)
 			return nil, auth, retErr
@@ -392,16 +438,25 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 			return nil, auth, retErr
 		}
 
-		if err := c.expiration.RegisterAuth(te.Path, resp.Auth); err != nil {
-			c.tokenStore.Revoke(ctx, te.ID)
-			c.logger.Error("failed to register token lease", "request_path", req.Path, "error", err)
+		if te == nil {
+			c.logger.Error("created token not found", "request_path", req.Path)
 			retErr = multierror.Append(retErr, ErrInternalError)
 			return nil, auth, retErr
 		}
+
+		// Batch tokens are not persisted and don't have a lease
+		if te.Type != TokenTypeBatch {
+			if err := c.expiration.RegisterAuth(te.Path, resp.Auth); err != nil {
+				c.tokenStore.Revoke(ctx, te.ID)
+				c.logger.Error("failed to register token lease", "request_path", req.Path, "error", err)
+				retErr = multierror.Append(retErr, ErrInternalError)
+				return nil, auth, retErr
+			}
+		}
 	}
 
 	if resp != nil &&
-		req.Path == "cubbyhole/response" &&
+		nsPath == "cubbyhole/response" &&
 		len(te.Policies) == 1 &&
 		te.Policies[0] == responseWrappingPolicyName {
 		resp.AddWarning("Reading from 'cubbyhole/response' is deprecated. Please use sys/wrapping/unwrap to unwrap responses, as it provides additional security checks and other benefits.")
@@ -437,7 +492,8 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 
 	// The token store uses authentication even when creating a new token,
 	// so it's handled in handleRequest. It should not be reached here.
-	if strings.HasPrefix(req.Path, "auth/token/") {
+	ns := namespace.FromContext(ctx)
+	if strings.HasPrefix(ns.TrimmedPath(req.Path), "auth/token/") {
 		c.logger.Error("unexpected login request for token backend", "request_path", req.Path)
 		return nil, nil, ErrInternalError
 	}
@@ -508,9 +564,17 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 
 			var err error
 
+			// Entities are kept by the identity store of the namespace of
+			// the auth method
+			identityStore := c.namespaceIdentityStore(ns)
+			if identityStore == nil {
+				c.logger.Error("identity store of namespace not found", "namespace", ns.Path)
+				return nil, nil, ErrInternalError
+			}
+
 			// Fetch the entity for the alias, or create an entity if one
 			// doesn't exist.
-			entity, err = c.identityStore.CreateOrFetchEntity(auth.Alias)
+			entity, err = identityStore.CreateOrFetchEntity(auth.Alias)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -521,7 +585,7 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 
 			auth.EntityID = entity.ID
 			if auth.GroupAliases != nil {
-				err = c.identityStore.refreshExternalGroupMembershipsByEntityID(auth.EntityID, auth.GroupAliases)
+				err = identityStore.refreshExternalGroupMembershipsByEntityID(auth.EntityID, auth.GroupAliases)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -532,70 +596,100 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			return logical.ErrorResponse("auth methods cannot create root tokens"), nil, logical.ErrInvalidRequest
 		}
 
-		// Determine the source of the login
-		source := c.router.MatchingMount(req.Path)
-		source = strings.TrimPrefix(source, credentialRoutePrefix)
-		source = strings.Replace(source, "/", "-", -1)
-
-		// Prepend the source to the display name
-		auth.DisplayName = strings.TrimSuffix(source+auth.DisplayName, "-")
-
-		sysView := c.router.MatchingSystemView(req.Path)
-		if sysView == nil {
-			c.logger.Error("unable to look up sys view for login path", "request_path", req.Path)
-			return nil, nil, ErrInternalError
-		}
-
-		tokenTTL, warnings, err := framework.CalculateTTL(sysView, 0, auth.TTL, auth.Period, auth.MaxTTL, auth.ExplicitMaxTTL, time.Time{})
+		// Hold on to the login until MFA has been validated if the mount or
+		// the policies require it
+		mfaMethods, err := c.loginMFARequirements(ctx, req.Path, auth)
 		if err != nil {
-			return nil, nil, err
+			c.logger.Error("failed to determine the MFA requirements of the login", "request_path", req.Path, "error", err)
+			return nil, nil, ErrInternalError
 		}
-		for _, warning := range warnings {
-			resp.AddWarning(warning)
+		if len(mfaMethods) > 0 {
+			resp, err = c.createPendingLogin(ctx, req, resp, mfaMethods)
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp, nil, routeErr
 		}
 
-		// Generate a token
-		te := TokenEntry{
-			Path:         req.Path,
-			Policies:     auth.Policies,
-			Meta:         auth.Metadata,
-			DisplayName:  auth.DisplayName,
-			CreationTime: time.Now().Unix(),
-			TTL:          tokenTTL,
-			NumUses:      auth.NumUses,
-			EntityID:     auth.EntityID,
+		resp, auth, err = c.loginCreateToken(ctx, req.Path, resp)
+		if err != nil || resp.IsError() {
+			return resp, auth, err
 		}
 
-		te.Policies = policyutil.SanitizePolicies(te.Policies, true)
+		// Attach the display name, might be used by audit backends
+		req.DisplayName = auth.DisplayName
+	}
+
+	return resp, auth, routeErr
+}
 
-		// Prevent internal policies from being assigned to tokens
-		for _, policy := range te.Policies {
-			if strutil.StrListContains(nonAssignablePolicies, policy) {
-				return logical.ErrorResponse(fmt.Sprintf("cannot assign policy %q", policy)), nil, logical.ErrInvalidRequest
-			}
-		}
+// loginCreateToken creates the token for a login at path that returned
+// authentication, and registers its lease
+func (c *Core) loginCreateToken(ctx context.Context, path string, resp *logical.Response) (*logical.Response, *logical.Auth, error) {
+	ns := namespace.FromContext(ctx)
+	auth := resp.Auth
 
-		if err := c.tokenStore.create(ctx, &te); err != nil {
-			c.logger.Error("failed to create token", "error", err)
-			return nil, auth, ErrInternalError
-		}
+	// Determine the source of the login
+	source := ns.TrimmedPath(c.router.MatchingMount(path))
+	source = strings.TrimPrefix(source, credentialRoutePrefix)
+	source = strings.Replace(source, "/", "-", -1)
+
+	// Prepend the source to the display name
+	auth.DisplayName = strings.TrimSuffix(source+auth.DisplayName, "-")
 
-		// Populate the client token, accessor, and TTL
-		auth.ClientToken = te.ID
-		auth.Accessor = te.Accessor
-		auth.Policies = te.Policies
-		auth.TTL = te.TTL
+	sysView := c.router.MatchingSystemView(path)
+	if sysView == nil {
+		c.logger.Error("unable to look up sys view for login path", "request_path", path)
+		return nil, nil, ErrInternalError
+	}
+
+	tokenTTL, warnings, err := framework.CalculateTTL(sysView, 0, auth.TTL, auth.Period, auth.MaxTTL, auth.ExplicitMaxTTL, time.Time{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, warning := range warnings {
+		resp.AddWarning(warning)
+	}
+
+	// Generate a token
+	te := TokenEntry{
+		Path:         path,
+		Policies:     auth.Policies,
+		Meta:         auth.Metadata,
+		DisplayName:  auth.DisplayName,
+		CreationTime: time.Now().Unix(),
+		TTL:          tokenTTL,
+		NumUses:      auth.NumUses,
+		EntityID:     auth.EntityID,
+		BoundCIDRs:   auth.BoundCIDRs,
+	}
 
-		// Register with the expiration manager
-		if err := c.expiration.RegisterAuth(te.Path, auth); err != nil {
-			c.tokenStore.Revoke(ctx, te.ID)
-			c.logger.Error("failed to register token lease", "request_path", req.Path, "error", err)
-			return nil, auth, ErrInternalError
+	te.Policies = policyutil.SanitizePolicies(te.Policies, true)
+
+	// Prevent internal policies from being assigned to tokens
+	for _, policy := range te.Policies {
+		if strutil.StrListContains(nonAssignablePolicies, policy) {
+			return logical.ErrorResponse(fmt.Sprintf("cannot assign policy %q", policy)), nil, logical.ErrInvalidRequest
 		}
+	}
 
-		// Attach the display name, might be used by audit backends
-		req.DisplayName = auth.DisplayName
+	if err := c.tokenStore.create(ctx, &te); err != nil {
+		c.logger.Error("failed to create token", "error", err)
+		return nil, auth, ErrInternalError
 	}
 
-	return resp, auth, routeErr
+	// Populate the client token, accessor, and TTL
+	auth.ClientToken = te.ID
+	auth.Accessor = te.Accessor
+	auth.Policies = te.Policies
+	auth.TTL = te.TTL
+
+	// Register with the expiration manager
+	if err := c.expiration.RegisterAuth(te.Path, auth); err != nil {
+		c.tokenStore.Revoke(ctx, te.ID)
+		c.logger.Error("failed to register token lease", "request_path", path, "error", err)
+		return nil, auth, ErrInternalError
+	}
+
+	return resp, auth, nil
 }
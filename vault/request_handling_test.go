@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-uuid"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 	"github.com/hashicorp/vault/logical"
@@ -141,3 +142,62 @@ func TestRequestHandling_LoginWrapping(t *testing.T) {
 		t.Fatalf("bad: %#v", resp)
 	}
 }
+
+func TestRequestHandling_BoundCIDRs(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	te := &TokenEntry{
+		Path:         "auth/token/create",
+		Policies:     []string{"root"},
+		CreationTime: time.Now().Unix(),
+		BoundCIDRs:   []string{"127.0.0.1/32"},
+	}
+	if err := core.tokenStore.create(context.Background(), te); err != nil {
+		t.Fatal(err)
+	}
+
+	lookupSelf := func(token, remoteAddr string) error {
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        "auth/token/lookup-self",
+			ClientToken: token,
+		}
+		if remoteAddr != "" {
+			req.Connection = &logical.Connection{
+				RemoteAddr: remoteAddr,
+			}
+		}
+		_, err := core.HandleRequest(req)
+		return err
+	}
+
+	if err := lookupSelf(te.ID, "127.0.0.1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, remoteAddr := range []string{"127.0.0.2", ""} {
+		if err := lookupSelf(te.ID, remoteAddr); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+			t.Fatalf("%q: expected permission denied, got %v", remoteAddr, err)
+		}
+	}
+
+	// Unbound tokens can be used from anywhere
+	if err := lookupSelf(root, "127.0.0.2"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Child tokens are bound to the same CIDR blocks
+	resp, err := core.HandleRequest(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "auth/token/create",
+		ClientToken: te.ID,
+		Connection: &logical.Connection{
+			RemoteAddr: "127.0.0.1",
+		},
+	})
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if err := lookupSelf(resp.Auth.ClientToken, "127.0.0.2"); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+}
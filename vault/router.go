@@ -11,6 +11,7 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/armon/go-radix"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
 )
@@ -61,6 +62,8 @@ type validateMountResponse struct {
 	MountType     string `json:"mount_type" structs:"mount_type" mapstructure:"mount_type"`
 	MountAccessor string `json:"mount_accessor" structs:"mount_accessor" mapstructure:"mount_accessor"`
 	MountPath     string `json:"mount_path" structs:"mount_path" mapstructure:"mount_path"`
+
+	namespace *namespace.Namespace
 }
 
 // validateMountByAccessor returns the mount type and ID for a given mount
@@ -84,6 +87,7 @@ func (r *Router) validateMountByAccessor(accessor string) *validateMountResponse
 		MountAccessor: mountEntry.Accessor,
 		MountType:     mountEntry.Type,
 		MountPath:     mountPath,
+		namespace:     mountEntry.Namespace(),
 	}
 }
 
@@ -442,11 +446,15 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 
 	originalEntityID := req.EntityID
 
+	// The internal backends are recognized by their path in the namespace of
+	// the mount
+	nsPath := re.mountEntry.Namespace().TrimmedPath(originalPath)
+
 	// Allow EntityID to passthrough to the system backend. This is required to
 	// allow clients to generate MFA credentials in respective entity objects
 	// in identity store via the system backend.
 	switch {
-	case strings.HasPrefix(originalPath, "sys/"):
+	case strings.HasPrefix(nsPath, "sys/"):
 	default:
 		req.EntityID = ""
 	}
@@ -455,9 +463,9 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	// or system backend.
 	clientToken := req.ClientToken
 	switch {
-	case strings.HasPrefix(originalPath, "auth/token/"):
-	case strings.HasPrefix(originalPath, "sys/"):
-	case strings.HasPrefix(originalPath, "cubbyhole/"):
+	case strings.HasPrefix(nsPath, "auth/token/"):
+	case strings.HasPrefix(nsPath, "sys/"):
+	case strings.HasPrefix(nsPath, "cubbyhole/"):
 		// In order for the token store to revoke later, we need to have the same
 		// salted ID, so we double-salt what's going to the cubbyhole backend
 		salt, err := r.tokenStoreSaltFunc(ctx)
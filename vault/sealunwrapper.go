@@ -181,3 +181,17 @@ func (d *sealUnwrapper) runUnwraps() {
 	// primary
 	atomic.StoreUint32(d.allowUnwraps, 1)
 }
+
+// lockAll takes every key lock, blocking all writes until the returned
+// function is called. Locks are taken in order to avoid deadlocking with
+// transactions.
+func (d *sealUnwrapper) lockAll() func() {
+	for _, l := range d.locks {
+		l.Lock()
+	}
+	return func() {
+		for _, l := range d.locks {
+			l.Unlock()
+		}
+	}
+}
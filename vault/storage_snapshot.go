@@ -0,0 +1,579 @@
+package vault
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// storageSnapshotVersion is the version of the snapshot format written by
+	// this version of Vault
+	storageSnapshotVersion = 1
+
+	// storageSnapshotRestorePrefix holds the state of a restore to a backend
+	// that does not support transactions: the snapshot is staged under
+	// storageSnapshotStagedPrefix, then storageSnapshotRestoreMarker is
+	// written and the staged entries are swapped in. While the marker exists
+	// storage may be partially restored, so the swap is completed before
+	// unsealing.
+	storageSnapshotRestorePrefix = "core/snapshot-restore/"
+	storageSnapshotStagedPrefix  = storageSnapshotRestorePrefix + "staged/"
+	storageSnapshotRestoreMarker = storageSnapshotRestorePrefix + "marker"
+)
+
+var (
+	// storageSnapshotExcludedPaths are never written to or restored from a
+	// snapshot since they describe the running cluster rather than its data.
+	// Paths ending in a slash exclude everything under them.
+	storageSnapshotExcludedPaths = []string{
+		coreLockPath,
+		storageSnapshotRestorePrefix,
+	}
+)
+
+// storageSnapshotRecord is a single line of a snapshot. A snapshot is a
+// gzipped stream of these records: a header, one record per storage entry,
+// and a footer used to verify that the stream is complete.
+type storageSnapshotRecord struct {
+	Header *storageSnapshotHeader `json:"header,omitempty"`
+	Entry  *physical.Entry        `json:"entry,omitempty"`
+	Footer *storageSnapshotFooter `json:"footer,omitempty"`
+}
+
+type storageSnapshotHeader struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type storageSnapshotFooter struct {
+	Entries int    `json:"entries"`
+	SHA256  string `json:"sha256"`
+}
+
+// StorageSnapshot writes a point-in-time copy of the entire physical store to
+// w. Values are copied exactly as stored, so the snapshot is protected by the
+// barrier encryption and can only be used with this cluster's unseal keys.
+// Storage writes are blocked while the snapshot is copied to a temporary
+// file, but not while it is written to w.
+func (c *Core) StorageSnapshot(req *logical.Request, w io.Writer) error {
+	defer metrics.MeasureSince([]string{"core", "storage_snapshot"}, time.Now())
+
+	tmp, err := ioutil.TempFile("", "vault-storage-snapshot")
+	if err != nil {
+		return errwrap.Wrapf("failed to create temporary snapshot file: {{err}}", err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	if err := c.copyStorageSnapshot(req, tmp); err != nil {
+		return err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, tmp)
+	return err
+}
+
+// copyStorageSnapshot writes the snapshot to w with storage writes blocked
+func (c *Core) copyStorageSnapshot(req *logical.Request, w io.Writer) error {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return consts.ErrSealed
+	}
+	if c.standby {
+		return consts.ErrStandby
+	}
+
+	ctx := c.activeContext
+	if err := c.checkRootRequest(ctx, req); err != nil {
+		return err
+	}
+
+	unlock := c.lockStorageWrites()
+	defer unlock()
+
+	gzw := gzip.NewWriter(w)
+	enc := json.NewEncoder(gzw)
+
+	if err := enc.Encode(&storageSnapshotRecord{
+		Header: &storageSnapshotHeader{
+			Version:   storageSnapshotVersion,
+			CreatedAt: time.Now().UTC(),
+		},
+	}); err != nil {
+		return err
+	}
+
+	var count int
+	sum := sha256.New()
+	err := walkPhysical(ctx, c.underlyingPhysical, "", func(key string) error {
+		if storageSnapshotExcluded(key) {
+			return nil
+		}
+
+		entry, err := c.underlyingPhysical.Get(ctx, key)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to read %q: {{err}}", key), err)
+		}
+		if entry == nil {
+			return nil
+		}
+
+		hashEntry(sum, entry)
+		count++
+		return enc.Encode(&storageSnapshotRecord{
+			Entry: entry,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := enc.Encode(&storageSnapshotRecord{
+		Footer: &storageSnapshotFooter{
+			Entries: count,
+			SHA256:  hex.EncodeToString(sum.Sum(nil)),
+		},
+	}); err != nil {
+		return err
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("storage snapshot taken", "entries", count)
+	}
+
+	return gzw.Close()
+}
+
+// StorageSnapshotRestore replaces the entire physical store with the contents
+// of a snapshot read from r. The snapshot is copied to a temporary file and
+// verified in full before anything is written. Transactional backends apply
+// it in a single transaction; other backends stage it first, so storage is
+// either left unchanged or the restore is completed on the next unseal. Once
+// restored, Vault seals itself; it must then be unsealed with the keys of the
+// cluster the snapshot was taken from.
+func (c *Core) StorageSnapshotRestore(req *logical.Request, r io.Reader) error {
+	defer metrics.MeasureSince([]string{"core", "storage_snapshot_restore"}, time.Now())
+
+	c.stateLock.RLock()
+	if c.sealed {
+		c.stateLock.RUnlock()
+		return consts.ErrSealed
+	}
+	if c.standby {
+		c.stateLock.RUnlock()
+		return consts.ErrStandby
+	}
+
+	ctx := c.activeContext
+	if err := c.checkRootRequest(ctx, req); err != nil {
+		c.stateLock.RUnlock()
+		return err
+	}
+
+	tmp, err := ioutil.TempFile("", "vault-storage-snapshot")
+	if err != nil {
+		c.stateLock.RUnlock()
+		return errwrap.Wrapf("failed to create temporary snapshot file: {{err}}", err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	// Only the keys are kept in memory, to find the entries to delete
+	keys := make(map[string]struct{})
+	err = readStorageSnapshot(io.TeeReader(r, tmp), func(entry *physical.Entry) error {
+		keys[entry.Key] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		c.stateLock.RUnlock()
+		return err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		c.stateLock.RUnlock()
+		return err
+	}
+	changed, err := c.restoreStorageEntries(ctx, tmp, keys)
+	switch {
+	case err == nil:
+		if c.logger.IsInfo() {
+			c.logger.Info("storage snapshot restored, sealing", "entries", len(keys))
+		}
+	case changed:
+		// Storage must not be used until the restore is completed on unseal
+		c.logger.Error("storage snapshot partially restored, sealing", "error", err)
+		err = errwrap.Wrapf("storage snapshot partially restored, it will be completed when Vault is unsealed: {{err}}", err)
+	default:
+		c.stateLock.RUnlock()
+		return err
+	}
+
+	// Everything held in memory was loaded from the previous contents of
+	// storage, so seal to force it to be reloaded on unseal
+	if c.activeContextCancelFunc != nil {
+		c.activeContextCancelFunc()
+	}
+	c.stateLock.RUnlock()
+
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+	if sealErr := c.sealInternal(false); sealErr != nil {
+		return multierror.Append(err, sealErr)
+	}
+	return err
+}
+
+// restoreStorageEntries replaces the contents of the physical store with the
+// entries of the verified snapshot read from r, whose keys are given. It
+// reports whether storage was changed when it fails.
+func (c *Core) restoreStorageEntries(ctx context.Context, r io.Reader, keys map[string]struct{}) (bool, error) {
+	unlock := c.lockStorageWrites()
+	defer unlock()
+
+	// The cache still holds the previous contents, even if the restore fails
+	defer c.physicalCache.Purge(ctx)
+
+	txnBackend, ok := c.underlyingPhysical.(physical.Transactional)
+	if !ok {
+		if err := c.stageStorageRestore(ctx, r); err != nil {
+			return false, err
+		}
+		return true, c.completeStorageRestore(ctx)
+	}
+
+	stale, err := c.staleStorageKeys(ctx, keys)
+	if err != nil {
+		return false, err
+	}
+
+	txns := make([]*physical.TxnEntry, 0, len(stale)+len(keys))
+	for _, key := range stale {
+		txns = append(txns, &physical.TxnEntry{
+			Operation: physical.DeleteOperation,
+			Entry: &physical.Entry{
+				Key: key,
+			},
+		})
+	}
+	err = readStorageSnapshot(r, func(entry *physical.Entry) error {
+		txns = append(txns, &physical.TxnEntry{
+			Operation: physical.PutOperation,
+			Entry:     entry,
+		})
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := txnBackend.Transaction(ctx, txns); err != nil {
+		return false, errwrap.Wrapf("failed to restore storage snapshot: {{err}}", err)
+	}
+	return false, nil
+}
+
+// stageStorageRestore writes the entries of the snapshot read from r under
+// the staging prefix, then the restore marker. Storage itself is left
+// unchanged.
+func (c *Core) stageStorageRestore(ctx context.Context, r io.Reader) error {
+	// Remove anything left by an earlier restore that failed while staging
+	if err := c.clearStorageRestore(ctx); err != nil {
+		return err
+	}
+
+	err := readStorageSnapshot(r, func(entry *physical.Entry) error {
+		staged := *entry
+		staged.Key = storageSnapshotStagedPrefix + entry.Key
+		return c.underlyingPhysical.Put(ctx, &staged)
+	})
+	if err == nil {
+		err = c.underlyingPhysical.Put(ctx, &physical.Entry{
+			Key:   storageSnapshotRestoreMarker,
+			Value: []byte(time.Now().UTC().Format(time.RFC3339)),
+		})
+	}
+	if err != nil {
+		if clearErr := c.clearStorageRestore(ctx); clearErr != nil {
+			c.logger.Error("failed to remove staged storage snapshot", "error", clearErr)
+		}
+		return errwrap.Wrapf("failed to stage storage snapshot: {{err}}", err)
+	}
+
+	return nil
+}
+
+// completeStorageRestore swaps the staged snapshot in if the restore marker
+// exists. It can be called again if it fails.
+func (c *Core) completeStorageRestore(ctx context.Context) error {
+	marker, err := c.underlyingPhysical.Get(ctx, storageSnapshotRestoreMarker)
+	if err != nil {
+		return errwrap.Wrapf("failed to read storage snapshot restore marker: {{err}}", err)
+	}
+	if marker == nil {
+		return nil
+	}
+
+	// Anything cached was read from the previous contents
+	defer c.physicalCache.Purge(ctx)
+
+	keys := make(map[string]struct{})
+	err = walkPhysical(ctx, c.underlyingPhysical, storageSnapshotStagedPrefix, func(key string) error {
+		keys[strings.TrimPrefix(key, storageSnapshotStagedPrefix)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	stale, err := c.staleStorageKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	for _, key := range stale {
+		if err := c.underlyingPhysical.Delete(ctx, key); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to delete %q: {{err}}", key), err)
+		}
+	}
+	for key := range keys {
+		entry, err := c.underlyingPhysical.Get(ctx, storageSnapshotStagedPrefix+key)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to read staged %q: {{err}}", key), err)
+		}
+		if entry == nil {
+			return fmt.Errorf("staged %q is missing", key)
+		}
+		entry.Key = key
+		if err := c.underlyingPhysical.Put(ctx, entry); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to restore %q: {{err}}", key), err)
+		}
+	}
+
+	return c.clearStorageRestore(ctx)
+}
+
+// clearStorageRestore removes the staged snapshot, then the restore marker
+func (c *Core) clearStorageRestore(ctx context.Context) error {
+	err := walkPhysical(ctx, c.underlyingPhysical, storageSnapshotStagedPrefix, func(key string) error {
+		return c.underlyingPhysical.Delete(ctx, key)
+	})
+	if err == nil {
+		err = c.underlyingPhysical.Delete(ctx, storageSnapshotRestoreMarker)
+	}
+	if err != nil {
+		return errwrap.Wrapf("failed to remove staged storage snapshot: {{err}}", err)
+	}
+	return nil
+}
+
+// staleStorageKeys returns the keys of the physical store that are not in
+// keys and must be removed by a restore. Keys are collected first since
+// deleting while listing may skip keys.
+func (c *Core) staleStorageKeys(ctx context.Context, keys map[string]struct{}) ([]string, error) {
+	var stale []string
+	err := walkPhysical(ctx, c.underlyingPhysical, "", func(key string) error {
+		if _, ok := keys[key]; ok || storageSnapshotExcluded(key) {
+			return nil
+		}
+		stale = append(stale, key)
+		return nil
+	})
+	return stale, err
+}
+
+// readStorageSnapshot decodes a snapshot, calling fn for each of its entries.
+// The snapshot is only verified once all its entries have been read.
+func readStorageSnapshot(r io.Reader, fn func(*physical.Entry) error) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return errwrap.Wrapf("failed to read storage snapshot: {{err}}", err)
+	}
+	defer gzr.Close()
+
+	dec := json.NewDecoder(gzr)
+
+	var record storageSnapshotRecord
+	if err := dec.Decode(&record); err != nil {
+		return errwrap.Wrapf("failed to read storage snapshot header: {{err}}", err)
+	}
+	if record.Header == nil {
+		return errors.New("storage snapshot is missing its header")
+	}
+	if record.Header.Version != storageSnapshotVersion {
+		return fmt.Errorf("unsupported storage snapshot version %d", record.Header.Version)
+	}
+
+	var count int
+	sum := sha256.New()
+	for {
+		record = storageSnapshotRecord{}
+		if err := dec.Decode(&record); err != nil {
+			if err == io.EOF {
+				return errors.New("storage snapshot is incomplete")
+			}
+			return errwrap.Wrapf("failed to read storage snapshot: {{err}}", err)
+		}
+
+		switch {
+		case record.Entry != nil:
+			if record.Entry.Key == "" {
+				return errors.New("storage snapshot contains an entry without a key")
+			}
+			hashEntry(sum, record.Entry)
+			count++
+			if err := fn(record.Entry); err != nil {
+				return err
+			}
+
+		case record.Footer != nil:
+			if record.Footer.Entries != count {
+				return fmt.Errorf("storage snapshot has %d entries but expected %d", count, record.Footer.Entries)
+			}
+			if record.Footer.SHA256 != hex.EncodeToString(sum.Sum(nil)) {
+				return errors.New("storage snapshot checksum mismatch")
+			}
+			return nil
+
+		default:
+			return errors.New("storage snapshot contains an unknown record")
+		}
+	}
+}
+
+func hashEntry(h hash.Hash, entry *physical.Entry) {
+	h.Write([]byte(entry.Key))
+	h.Write([]byte{0})
+	h.Write(entry.Value)
+}
+
+func storageSnapshotExcluded(key string) bool {
+	for _, excluded := range storageSnapshotExcludedPaths {
+		if key == excluded || (strings.HasSuffix(excluded, "/") && strings.HasPrefix(key, excluded)) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkPhysical calls fn for every key under the given prefix
+func walkPhysical(ctx context.Context, b physical.Backend, prefix string, fn func(key string) error) error {
+	keys, err := b.List(ctx, prefix)
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("failed to list %q: {{err}}", prefix), err)
+	}
+
+	for _, key := range keys {
+		if strings.HasSuffix(key, "/") {
+			if err := walkPhysical(ctx, b, prefix+key, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(prefix + key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockStorageWrites blocks all writes going through the seal unwrapper until
+// the returned function is called
+func (c *Core) lockStorageWrites() func() {
+	if su, ok := c.sealUnwrapper.(interface {
+		lockAll() func()
+	}); ok {
+		return su.lockAll()
+	}
+	return func() {}
+}
+
+// checkRootRequest audits the request, uses the client token and verifies
+// that the token has root or sudo privileges on the request path. The state
+// lock must be held.
+func (c *Core) checkRootRequest(ctx context.Context, req *logical.Request) (retErr error) {
+	if req == nil {
+		return errors.New("nil request")
+	}
+
+	acl, te, entity, err := c.fetchACLTokenEntryAndEntity(req)
+	if err != nil {
+		return err
+	}
+
+	// Audit-log the request before going any further
+	auth := &logical.Auth{
+		ClientToken: req.ClientToken,
+		Policies:    te.Policies,
+		Metadata:    te.Meta,
+		DisplayName: te.DisplayName,
+		EntityID:    te.EntityID,
+	}
+
+	logInput := &audit.LogInput{
+		Auth:    auth,
+		Request: req,
+	}
+	if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
+		c.logger.Error("failed to audit request", "request_path", req.Path, "error", err)
+		return errors.New("failed to audit request, cannot continue")
+	}
+
+	// Attempt to use the token (decrement num_uses)
+	te, err = c.tokenStore.UseToken(ctx, te)
+	if err != nil {
+		c.logger.Error("failed to use token", "error", err)
+		return ErrInternalError
+	}
+	if te == nil {
+		// Token has been revoked
+		return logical.ErrPermissionDenied
+	}
+
+	// Verify that this operation is allowed
+	authResults := c.performPolicyChecks(ctx, acl, te, req, entity, &PolicyCheckOpts{
+		RootPrivsRequired: true,
+	})
+	if authResults.Error.ErrorOrNil() != nil {
+		return authResults.Error
+	}
+	if !authResults.Allowed {
+		return logical.ErrPermissionDenied
+	}
+
+	if te.NumUses == -1 {
+		// The token was used up by this request
+		if err := c.tokenStore.Revoke(ctx, te.ID); err != nil {
+			c.logger.Error("failed to revoke token after final use", "error", err)
+			retErr = multierror.Append(retErr, ErrInternalError)
+		}
+	}
+
+	return retErr
+}
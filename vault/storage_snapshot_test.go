@@ -0,0 +1,419 @@
+package vault
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/physical/inmem"
+)
+
+func TestCore_StorageSnapshot(t *testing.T) {
+	c, keys, root := TestCoreUnsealed(t)
+
+	write := func(path, value string) {
+		t.Helper()
+		req := &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        path,
+			Data:        map[string]interface{}{"value": value},
+			ClientToken: root,
+		}
+		if _, err := c.HandleRequest(req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	read := func(path string) *logical.Response {
+		t.Helper()
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: root,
+		}
+		resp, err := c.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	write("secret/foo", "bar")
+
+	snapReq := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/storage/snapshot",
+		ClientToken: root,
+	}
+
+	// A non-root token must be rejected
+	badReq := *snapReq
+	badReq.ClientToken = "foo"
+	if err := c.StorageSnapshot(&badReq, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	var snap bytes.Buffer
+	if err := c.StorageSnapshot(snapReq, &snap); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Change storage after the snapshot was taken
+	write("secret/foo", "changed")
+	write("secret/new", "value")
+
+	// A corrupted snapshot must be rejected without touching storage
+	corrupt := corruptSnapshot(t, snap.Bytes())
+	snapReq.Operation = logical.UpdateOperation
+	err := c.StorageSnapshotRestore(snapReq, bytes.NewReader(corrupt))
+	if err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+	if sealed, _ := c.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+
+	if err := c.StorageSnapshotRestore(snapReq, bytes.NewReader(snap.Bytes())); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sealed, _ := c.Sealed(); !sealed {
+		t.Fatal("should be sealed after restore")
+	}
+
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c, TestKeyCopy(key)); err != nil {
+			t.Fatalf("unseal err: %v", err)
+		}
+	}
+
+	resp := read("secret/foo")
+	if resp == nil || resp.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp := read("secret/new"); resp != nil {
+		t.Fatalf("entry written after snapshot should be gone: %#v", resp)
+	}
+}
+
+// corruptSnapshot changes the value of an entry in the snapshot without
+// updating the checksum
+func corruptSnapshot(t *testing.T, snap []byte) []byte {
+	gzr, err := gzip.NewReader(bytes.NewReader(snap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(gzr)
+
+	var out bytes.Buffer
+	gzw := gzip.NewWriter(&out)
+	enc := json.NewEncoder(gzw)
+	corrupted := false
+	for {
+		var record storageSnapshotRecord
+		if err := dec.Decode(&record); err != nil {
+			break
+		}
+		if record.Entry != nil && !corrupted {
+			record.Entry.Value = append(record.Entry.Value, 'x')
+			corrupted = true
+		}
+		if err := enc.Encode(&record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.Bytes()
+}
+
+func TestCore_StorageSnapshot_Incomplete(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	var snap bytes.Buffer
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/storage/snapshot",
+		ClientToken: root,
+	}
+	if err := c.StorageSnapshot(req, &snap); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the footer
+	gzr, err := gzip.NewReader(&snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	gzw := gzip.NewWriter(&out)
+	dec := json.NewDecoder(gzr)
+	enc := json.NewEncoder(gzw)
+	for {
+		var record storageSnapshotRecord
+		if err := dec.Decode(&record); err != nil || record.Footer != nil {
+			break
+		}
+		enc.Encode(&record)
+	}
+	gzw.Close()
+
+	err = readStorageSnapshot(&out, func(*physical.Entry) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "incomplete") {
+		t.Fatalf("expected incomplete error, got %v", err)
+	}
+}
+
+// failingBackend fails the puts for which failPut returns true
+type failingBackend struct {
+	physical.Backend
+
+	l       sync.Mutex
+	failPut func(key string) bool
+}
+
+func (b *failingBackend) setFailPut(fn func(key string) bool) {
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.failPut = fn
+}
+
+func (b *failingBackend) Put(ctx context.Context, entry *physical.Entry) error {
+	b.l.Lock()
+	fail := b.failPut != nil && b.failPut(entry.Key)
+	b.l.Unlock()
+	if fail {
+		return fmt.Errorf("failed to put %q", entry.Key)
+	}
+	return b.Backend.Put(ctx, entry)
+}
+
+// failingTxnBackend fails transactions while fail is set and counts the
+// others
+type failingTxnBackend struct {
+	physical.Backend
+
+	l    sync.Mutex
+	fail bool
+	txns int
+}
+
+func (b *failingTxnBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+	if b.fail {
+		return fmt.Errorf("transaction failed")
+	}
+	b.txns++
+	return b.Backend.(physical.Transactional).Transaction(ctx, txns)
+}
+
+// testStorageSnapshotRestore writes entries, takes a snapshot, then writes
+// more entries. It returns the snapshot and a function checking that the
+// storage is restored from it.
+func testStorageSnapshotRestore(t *testing.T, c *Core, root string) ([]byte, func()) {
+	write := func(path string) {
+		t.Helper()
+		req := &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        path,
+			Data:        map[string]interface{}{"value": "bar"},
+			ClientToken: root,
+		}
+		if _, err := c.HandleRequest(req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		write(fmt.Sprintf("secret/foo%d", i))
+	}
+
+	var snap bytes.Buffer
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/storage/snapshot",
+		ClientToken: root,
+	}
+	if err := c.StorageSnapshot(req, &snap); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		write(fmt.Sprintf("secret/bar%d", i))
+	}
+
+	return snap.Bytes(), func() {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			for _, path := range []string{fmt.Sprintf("secret/foo%d", i), fmt.Sprintf("secret/bar%d", i)} {
+				resp, err := c.HandleRequest(&logical.Request{
+					Operation:   logical.ReadOperation,
+					Path:        path,
+					ClientToken: root,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if exists := strings.HasPrefix(path, "secret/foo"); exists != (resp != nil) {
+					t.Fatalf("%s: bad: %#v", path, resp)
+				}
+			}
+		}
+	}
+}
+
+// dumpPhysical returns the contents of b
+func dumpPhysical(t *testing.T, b physical.Backend) map[string]string {
+	t.Helper()
+	ctx := context.Background()
+	out := make(map[string]string)
+	err := walkPhysical(ctx, b, "", func(key string) error {
+		entry, err := b.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		out[key] = string(entry.Value)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestCore_StorageSnapshot_Transaction(t *testing.T) {
+	inm, err := inmem.NewTransactionalInmem(nil, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &failingTxnBackend{Backend: inm}
+	c, keys, root := TestCoreUnsealedBackend(t, b)
+
+	snap, check := testStorageSnapshotRestore(t, c, root)
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/storage/snapshot",
+		ClientToken: root,
+	}
+
+	// A failed restore leaves storage unchanged
+	before := dumpPhysical(t, inm)
+	b.l.Lock()
+	b.fail = true
+	b.l.Unlock()
+	if err := c.StorageSnapshotRestore(req, bytes.NewReader(snap)); err == nil {
+		t.Fatal("expected error")
+	}
+	if sealed, _ := c.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+	if after := dumpPhysical(t, inm); !reflect.DeepEqual(before, after) {
+		t.Fatal("storage changed by the failed restore")
+	}
+
+	// The restore is applied in a single transaction
+	b.l.Lock()
+	b.fail = false
+	b.txns = 0
+	b.l.Unlock()
+	if err := c.StorageSnapshotRestore(req, bytes.NewReader(snap)); err != nil {
+		t.Fatal(err)
+	}
+	b.l.Lock()
+	txns := b.txns
+	b.l.Unlock()
+	if txns != 1 {
+		t.Fatalf("expected a single transaction, got %d", txns)
+	}
+
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c, TestKeyCopy(key)); err != nil {
+			t.Fatalf("unseal err: %v", err)
+		}
+	}
+	check()
+}
+
+func TestCore_StorageSnapshot_Staged(t *testing.T) {
+	inm, err := inmem.NewInmem(nil, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &failingBackend{Backend: inm}
+	c, keys, root := TestCoreUnsealedBackend(t, b)
+
+	snap, check := testStorageSnapshotRestore(t, c, root)
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/storage/snapshot",
+		ClientToken: root,
+	}
+
+	// A failure while staging the snapshot leaves storage unchanged
+	before := dumpPhysical(t, inm)
+	var puts int
+	b.setFailPut(func(key string) bool {
+		if !strings.HasPrefix(key, storageSnapshotStagedPrefix) {
+			return false
+		}
+		puts++
+		return puts > 10
+	})
+	if err := c.StorageSnapshotRestore(req, bytes.NewReader(snap)); err == nil {
+		t.Fatal("expected error")
+	}
+	if sealed, _ := c.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+	if after := dumpPhysical(t, inm); !reflect.DeepEqual(before, after) {
+		t.Fatal("storage changed by the failed restore")
+	}
+
+	// A failure while swapping the snapshot in seals, and the restore is
+	// completed on unseal
+	puts = 0
+	b.setFailPut(func(key string) bool {
+		if strings.HasPrefix(key, storageSnapshotRestorePrefix) {
+			return false
+		}
+		puts++
+		return puts > 10
+	})
+	if err := c.StorageSnapshotRestore(req, bytes.NewReader(snap)); err == nil {
+		t.Fatal("expected error")
+	}
+	if sealed, _ := c.Sealed(); !sealed {
+		t.Fatal("should be sealed after a partial restore")
+	}
+	if entry, err := inm.Get(context.Background(), storageSnapshotRestoreMarker); err != nil || entry == nil {
+		t.Fatalf("expected restore marker, got %v, %v", entry, err)
+	}
+
+	b.setFailPut(nil)
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c, TestKeyCopy(key)); err != nil {
+			t.Fatalf("unseal err: %v", err)
+		}
+	}
+	check()
+
+	restoreKeys, err := inm.List(context.Background(), storageSnapshotRestorePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restoreKeys) != 0 {
+		t.Fatalf("restore state should be removed: %v", restoreKeys)
+	}
+}
@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -17,9 +18,11 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/helper/salt"
@@ -49,6 +52,14 @@ const (
 	// rolesPrefix is the prefix used to store role information
 	rolesPrefix = "roles/"
 
+	// batchTokenPrefix is the prefix of batch tokens, which carry their own
+	// encrypted token entry instead of being persisted
+	batchTokenPrefix = "b."
+
+	// batchTokenEncryptionPath is used as the additional data when
+	// encrypting batch tokens with the barrier keyring
+	batchTokenEncryptionPath = "auth/token/batch"
+
 	// tokenRevocationDeferred indicates that the token should not be used
 	// again but is currently fulfilling its final use
 	tokenRevocationDeferred = -1
@@ -76,7 +87,18 @@ var (
 			// Should only ever happen in testing
 			return nil
 		}
-		return ts.cubbyholeBackend.revoke(ctx, salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash))
+		if err := ts.cubbyholeBackend.revoke(ctx, salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash)); err != nil {
+			return err
+		}
+
+		// A token may have used the cubbyholes of the namespaces beneath its
+		// own, so clear all of them
+		for _, cubbyhole := range ts.namespaceCubbyholes() {
+			if err := cubbyhole.revoke(ctx, salt.SaltID(cubbyhole.saltUUID, saltedID, salt.SHA1Hash)); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 )
 
@@ -86,13 +108,15 @@ var (
 type TokenStore struct {
 	*framework.Backend
 
+	core *Core
+
 	view *BarrierView
 
 	expiration *ExpirationManager
 
 	cubbyholeBackend *CubbyholeBackend
 
-	policyLookupFunc func(string) (*Policy, error)
+	policyLookupFunc func(context.Context, string) (*Policy, error)
 
 	tokenLocks []*locksutil.LockEntry
 
@@ -114,6 +138,7 @@ func NewTokenStore(ctx context.Context, logger log.Logger, c *Core, config *logi
 
 	// Initialize the store
 	t := &TokenStore{
+		core:               c,
 		view:               view,
 		cubbyholeDestroyer: destroyCubbyhole,
 		logger:             logger,
@@ -122,7 +147,7 @@ func NewTokenStore(ctx context.Context, logger log.Logger, c *Core, config *logi
 	}
 
 	if c.policyStore != nil {
-		t.policyLookupFunc = func(name string) (*Policy, error) {
+		t.policyLookupFunc = func(ctx context.Context, name string) (*Policy, error) {
 			return c.policyStore.GetPolicy(ctx, name, PolicyTypeToken)
 		}
 	}
@@ -217,6 +242,16 @@ func NewTokenStore(ctx context.Context, logger log.Logger, c *Core, config *logi
 						Default:     true,
 						Description: tokenRenewableHelp,
 					},
+
+					"token_type": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: tokenTypeHelp,
+					},
+
+					"token_bound_cidrs": &framework.FieldSchema{
+						Type:        framework.TypeCommaStringSlice,
+						Description: tokenBoundCIDRsHelp,
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -515,6 +550,37 @@ func (ts *TokenStore) Salt(ctx context.Context) (*salt.Salt, error) {
 	return salt, nil
 }
 
+// TokenType is the type of a token
+type TokenType string
+
+const (
+	// TokenTypeService tokens are persisted to storage and support renewal,
+	// revocation and child tokens. Token entries without a type are service
+	// tokens.
+	TokenTypeService TokenType = "service"
+
+	// TokenTypeBatch tokens are encrypted with the barrier keyring and are
+	// not persisted. They can't be renewed or revoked, can't create child
+	// tokens and their leases are tracked by their parent.
+	TokenTypeBatch TokenType = "batch"
+)
+
+// parseTokenType parses the type of a token given in a request or role; an
+// empty value is returned as is
+func parseTokenType(s string) (TokenType, error) {
+	switch TokenType(s) {
+	case "", TokenTypeService, TokenTypeBatch:
+		return TokenType(s), nil
+	default:
+		return "", fmt.Errorf("invalid token type %q", s)
+	}
+}
+
+// isBatchToken returns whether the given token ID is a batch token
+func isBatchToken(id string) bool {
+	return strings.HasPrefix(id, batchTokenPrefix)
+}
+
 // TokenEntry is used to represent a given token
 type TokenEntry struct {
 	// ID of this entry, generally a random UUID
@@ -571,6 +637,51 @@ type TokenEntry struct {
 	ExplicitMaxTTLDeprecated time.Duration `json:"ExplicitMaxTTL" mapstructure:"ExplicitMaxTTL" structs:"ExplicitMaxTTL" sentinel:""`
 
 	EntityID string `json:"entity_id" mapstructure:"entity_id" structs:"entity_id"`
+
+	// NamespaceID is the ID of the namespace the token was created in. The
+	// token can only be used in that namespace and the ones beneath it.
+	NamespaceID string `json:"namespace_id" mapstructure:"namespace_id" structs:"namespace_id"`
+
+	// Type is the type of the token; if empty, it is a service token
+	Type TokenType `json:"type" mapstructure:"type" structs:"type"`
+
+	// BoundCIDRs, if set, restricts the use of the token to requests coming
+	// from addresses within these CIDR blocks
+	BoundCIDRs []string `json:"bound_cidrs" mapstructure:"bound_cidrs" structs:"bound_cidrs"`
+}
+
+// tokenType returns the type of the token, defaulting to service tokens
+func (te *TokenEntry) tokenType() TokenType {
+	if te.Type == "" {
+		return TokenTypeService
+	}
+	return te.Type
+}
+
+// expireTime returns the time at which a token with a TTL expires, and the
+// zero time otherwise. This is only authoritative for batch tokens; service
+// tokens are tracked by the expiration manager and can be renewed.
+func (te *TokenEntry) expireTime() time.Time {
+	if te.TTL == 0 {
+		return time.Time{}
+	}
+	return time.Unix(te.CreationTime, 0).Add(te.TTL)
+}
+
+// batchTokenEntry is the part of a token entry that is encoded into a batch
+// token
+type batchTokenEntry struct {
+	Parent       string            `json:"parent,omitempty"`
+	Policies     []string          `json:"policies"`
+	Path         string            `json:"path"`
+	Meta         map[string]string `json:"meta,omitempty"`
+	DisplayName  string            `json:"display_name"`
+	CreationTime int64             `json:"creation_time"`
+	TTL          time.Duration     `json:"ttl"`
+	Role         string            `json:"role,omitempty"`
+	EntityID     string            `json:"entity_id,omitempty"`
+	NamespaceID  string            `json:"namespace_id"`
+	BoundCIDRs   []string          `json:"bound_cidrs,omitempty"`
 }
 
 func (te *TokenEntry) SentinelGet(key string) (interface{}, error) {
@@ -653,6 +764,21 @@ type tsRoleEntry struct {
 	// If set, the token entry will have an explicit maximum TTL set, rather
 	// than deferring to role/mount values
 	ExplicitMaxTTL time.Duration `json:"explicit_max_ttl" mapstructure:"explicit_max_ttl" structs:"explicit_max_ttl"`
+
+	// If set, the type of the tokens created using this role
+	TokenType TokenType `json:"token_type" mapstructure:"token_type" structs:"token_type"`
+
+	// If set, the CIDR blocks the tokens created using this role are bound to
+	BoundCIDRs []string `json:"token_bound_cidrs" mapstructure:"token_bound_cidrs" structs:"token_bound_cidrs"`
+}
+
+// roleBoundCIDRs returns the CIDR blocks tokens created using the role are
+// bound to, if any
+func roleBoundCIDRs(role *tsRoleEntry) []string {
+	if role == nil {
+		return nil
+	}
+	return role.BoundCIDRs
 }
 
 type accessorEntry struct {
@@ -708,9 +834,18 @@ func (ts *TokenStore) tokenStoreAccessorList(ctx context.Context, req *logical.R
 		}
 		if aEntry.TokenID == "" {
 			resp.AddWarning(fmt.Sprintf("Found an accessor entry missing a token: %v", aEntry.AccessorID))
-		} else {
-			ret = append(ret, aEntry.AccessorID)
+			continue
+		}
+
+		// Only list the accessors of tokens in this namespace and the ones
+		// beneath it
+		if ns := namespace.FromContext(ctx); ns.ID != namespace.RootNamespaceID {
+			te, err := ts.lookupTainted(ctx, aEntry.TokenID)
+			if err != nil || te == nil || !ts.tokenInNamespace(te, ns) {
+				continue
+			}
 		}
+		ret = append(ret, aEntry.AccessorID)
 	}
 
 	resp.Data = map[string]interface{}{
@@ -767,6 +902,11 @@ func (ts *TokenStore) create(ctx context.Context, entry *TokenEntry) error {
 		entry.ID = entryUUID
 	}
 
+	// Tokens belong to the namespace they are created in
+	if entry.NamespaceID == "" {
+		entry.NamespaceID = namespace.FromContext(ctx).ID
+	}
+
 	saltedID, err := ts.SaltID(ctx, entry.ID)
 	if err != nil {
 		return err
@@ -786,6 +926,108 @@ func (ts *TokenStore) create(ctx context.Context, entry *TokenEntry) error {
 	return ts.storeCommon(ctx, entry, true)
 }
 
+// createBatchToken is used to create a new batch token. The token entry is
+// encrypted with the barrier keyring and becomes the token ID, so nothing is
+// written to storage.
+func (ts *TokenStore) createBatchToken(ctx context.Context, entry *TokenEntry) error {
+	defer metrics.MeasureSince([]string{"token", "create_batch"}, time.Now())
+
+	if entry.NamespaceID == "" {
+		entry.NamespaceID = namespace.FromContext(ctx).ID
+	}
+	entry.Type = TokenTypeBatch
+	entry.Policies = policyutil.SanitizePolicies(entry.Policies, policyutil.DoNotAddDefaultPolicy)
+
+	enc, err := jsonutil.EncodeJSON(&batchTokenEntry{
+		Parent:       entry.Parent,
+		Policies:     entry.Policies,
+		Path:         entry.Path,
+		Meta:         entry.Meta,
+		DisplayName:  entry.DisplayName,
+		CreationTime: entry.CreationTime,
+		TTL:          entry.TTL,
+		Role:         entry.Role,
+		EntityID:     entry.EntityID,
+		NamespaceID:  entry.NamespaceID,
+		BoundCIDRs:   entry.BoundCIDRs,
+	})
+	if err != nil {
+		return errwrap.Wrapf("failed to encode batch token: {{err}}", err)
+	}
+
+	ciphertext, err := ts.core.barrier.Encrypt(ctx, batchTokenEncryptionPath, enc)
+	if err != nil {
+		return errwrap.Wrapf("failed to encrypt batch token: {{err}}", err)
+	}
+
+	entry.ID = batchTokenPrefix + base64.RawURLEncoding.EncodeToString(ciphertext)
+	return nil
+}
+
+// decodeBatchToken decrypts the token entry of a batch token without checking
+// whether the token is still valid. A nil entry is returned if the token
+// can't be decrypted.
+func (ts *TokenStore) decodeBatchToken(ctx context.Context, id string) (*TokenEntry, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(id, batchTokenPrefix))
+	if err != nil {
+		return nil, nil
+	}
+
+	plaintext, err := ts.core.barrier.Decrypt(ctx, batchTokenEncryptionPath, ciphertext)
+	if err != nil {
+		if err == ErrBarrierSealed {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	var bte batchTokenEntry
+	if err := jsonutil.DecodeJSON(plaintext, &bte); err != nil {
+		return nil, errwrap.Wrapf("failed to decode batch token: {{err}}", err)
+	}
+
+	return &TokenEntry{
+		ID:           id,
+		Parent:       bte.Parent,
+		Policies:     bte.Policies,
+		Path:         bte.Path,
+		Meta:         bte.Meta,
+		DisplayName:  bte.DisplayName,
+		CreationTime: bte.CreationTime,
+		TTL:          bte.TTL,
+		Role:         bte.Role,
+		EntityID:     bte.EntityID,
+		NamespaceID:  bte.NamespaceID,
+		Type:         TokenTypeBatch,
+		BoundCIDRs:   bte.BoundCIDRs,
+	}, nil
+}
+
+// lookupBatchToken is used to find a batch token. Batch tokens are invalid
+// once they have expired or their parent has been revoked.
+func (ts *TokenStore) lookupBatchToken(ctx context.Context, id string) (*TokenEntry, error) {
+	te, err := ts.decodeBatchToken(ctx, id)
+	if err != nil || te == nil {
+		return nil, err
+	}
+
+	if expire := te.expireTime(); !expire.IsZero() && time.Now().After(expire) {
+		return nil, nil
+	}
+
+	if te.Parent != "" {
+		parent, err := ts.Lookup(ctx, te.Parent)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to lookup parent: {{err}}", err)
+		}
+		if parent == nil {
+			return nil, nil
+		}
+	}
+
+	return te, nil
+}
+
 // Store is used to store an updated token entry without writing the
 // secondary index.
 func (ts *TokenStore) store(ctx context.Context, entry *TokenEntry) error {
@@ -929,6 +1171,10 @@ func (ts *TokenStore) Lookup(ctx context.Context, id string) (*TokenEntry, error
 		return nil, fmt.Errorf("cannot lookup blank token")
 	}
 
+	if isBatchToken(id) {
+		return ts.lookupBatchToken(ctx, id)
+	}
+
 	lock := locksutil.LockForKey(ts.tokenLocks, id)
 	lock.RLock()
 	defer lock.RUnlock()
@@ -948,6 +1194,10 @@ func (ts *TokenStore) lookupTainted(ctx context.Context, id string) (*TokenEntry
 		return nil, fmt.Errorf("cannot lookup blank token")
 	}
 
+	if isBatchToken(id) {
+		return ts.lookupBatchToken(ctx, id)
+	}
+
 	lock := locksutil.LockForKey(ts.tokenLocks, id)
 	lock.RLock()
 	defer lock.RUnlock()
@@ -1051,6 +1301,9 @@ func (ts *TokenStore) Revoke(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("cannot revoke blank token")
 	}
+	if isBatchToken(id) {
+		return fmt.Errorf("batch tokens cannot be revoked")
+	}
 
 	saltedID, err := ts.SaltID(ctx, id)
 	if err != nil {
@@ -1220,6 +1473,9 @@ func (ts *TokenStore) RevokeTree(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("cannot tree-revoke blank token")
 	}
+	if isBatchToken(id) {
+		return fmt.Errorf("batch tokens cannot be revoked")
+	}
 
 	// Get the salted ID
 	saltedID, err := ts.SaltID(ctx, id)
@@ -1546,6 +1802,9 @@ func (ts *TokenStore) handleUpdateLookupAccessor(ctx context.Context, req *logic
 	if err != nil {
 		return nil, err
 	}
+	if err := ts.checkAccessorNamespace(ctx, aEntry); err != nil {
+		return nil, err
+	}
 
 	// Prepare the field data required for a lookup call
 	d := &framework.FieldData{
@@ -1600,6 +1859,9 @@ func (ts *TokenStore) handleUpdateRevokeAccessor(ctx context.Context, req *logic
 	if err != nil {
 		return nil, err
 	}
+	if err := ts.checkAccessorNamespace(ctx, aEntry); err != nil {
+		return nil, err
+	}
 
 	// Revoke the token and its children
 	if err := ts.RevokeTree(ctx, aEntry.TokenID); err != nil {
@@ -1642,6 +1904,23 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 			logical.ErrInvalidRequest
 	}
 
+	// Batch tokens can't be revoked, so they can't be the parent of other
+	// tokens either
+	if parent.Type == TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot create child tokens"),
+			logical.ErrInvalidRequest
+	}
+
+	// Policy names only have a meaning within a namespace, so only root
+	// tokens can create tokens in a namespace other than their own
+	ns := namespace.FromContext(ctx)
+	if parentNS := ts.core.tokenNamespace(parent); parentNS == nil || parentNS.ID != ns.ID {
+		if !strutil.StrListContains(parent.Policies, "root") {
+			return logical.ErrorResponse("only root tokens can create tokens in a different namespace"),
+				logical.ErrInvalidRequest
+		}
+	}
+
 	// Check if the client token has sudo/root privileges for the requested path
 	isSudo := ts.System().SudoPrivilege(ctx, req.MountPoint+req.Path, req.ClientToken)
 
@@ -1659,6 +1938,8 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		DisplayName     string `mapstructure:"display_name"`
 		NumUses         int    `mapstructure:"num_uses"`
 		Period          string
+		Type            string
+		BoundCIDRs      interface{} `mapstructure:"token_bound_cidrs"`
 	}
 	if err := mapstructure.WeakDecode(req.Data, &data); err != nil {
 		return logical.ErrorResponse(fmt.Sprintf(
@@ -1675,10 +1956,10 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	te := TokenEntry{
 		Parent: req.ClientToken,
 
-		// The mount point is always the same since we have only one token
-		// store; using req.MountPoint causes trouble in tests since they don't
-		// have an official mount
-		Path: fmt.Sprintf("auth/token/%s", req.Path),
+		// The mount point is always the same within a namespace since we
+		// have only one token store; using req.MountPoint causes trouble in
+		// tests since they don't have an official mount
+		Path: fmt.Sprintf("%sauth/token/%s", ns.Path, req.Path),
 
 		Meta:         data.Metadata,
 		DisplayName:  "token",
@@ -1710,6 +1991,65 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		}
 	}
 
+	if data.BoundCIDRs != nil {
+		te.BoundCIDRs, err = parseutil.ParseCommaStringSlice(data.BoundCIDRs)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid token_bound_cidrs: %v", err)), logical.ErrInvalidRequest
+		}
+	}
+	if len(te.BoundCIDRs) > 0 {
+		valid, err := cidrutil.ValidateCIDRListSlice(te.BoundCIDRs)
+		if err != nil || !valid {
+			return logical.ErrorResponse("invalid token_bound_cidrs"), logical.ErrInvalidRequest
+		}
+	}
+
+	// The CIDR blocks of the role and of the parent token bound the ones the
+	// new token can be given; if none are given, the token inherits them so
+	// it can't be used to escape the restriction
+	for _, bound := range []struct {
+		source string
+		cidrs  []string
+	}{
+		{"role", roleBoundCIDRs(role)},
+		{"parent token", parent.BoundCIDRs},
+	} {
+		if len(bound.cidrs) == 0 {
+			continue
+		}
+		if len(te.BoundCIDRs) == 0 {
+			te.BoundCIDRs = bound.cidrs
+			continue
+		}
+		subset, err := cidrutil.SubsetBlocks(bound.cidrs, te.BoundCIDRs)
+		if err != nil || !subset {
+			return logical.ErrorResponse(fmt.Sprintf("token_bound_cidrs must be within the CIDR blocks of the %s", bound.source)), logical.ErrInvalidRequest
+		}
+	}
+
+	tokenType, err := parseTokenType(data.Type)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if role != nil && role.TokenType != "" {
+		if tokenType != "" && tokenType != role.TokenType {
+			return logical.ErrorResponse(fmt.Sprintf("token type %q is not allowed by this role", tokenType)), logical.ErrInvalidRequest
+		}
+		tokenType = role.TokenType
+	}
+
+	// Batch tokens are never written to storage, so nothing about them can
+	// change after they are created
+	if tokenType == TokenTypeBatch {
+		switch {
+		case data.ID != "":
+			return logical.ErrorResponse("batch tokens cannot have a custom ID"), logical.ErrInvalidRequest
+		case data.NumUses > 0:
+			return logical.ErrorResponse("batch tokens cannot have a limited number of uses"), logical.ErrInvalidRequest
+		}
+		renewable = false
+	}
+
 	// Attach the given display name if any
 	if data.DisplayName != "" {
 		full := "token-" + data.DisplayName
@@ -1991,8 +2331,23 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		renewable = false
 	}
 
+	if tokenType == TokenTypeBatch {
+		switch {
+		case periodToUse > 0:
+			return logical.ErrorResponse("batch tokens cannot be periodic"), logical.ErrInvalidRequest
+		case strutil.StrListContains(te.Policies, "root"):
+			return logical.ErrorResponse("batch tokens cannot be root tokens"), logical.ErrInvalidRequest
+		}
+	}
+
 	// Create the token
-	if err := ts.create(ctx, &te); err != nil {
+	switch tokenType {
+	case TokenTypeBatch:
+		err = ts.createBatchToken(ctx, &te)
+	default:
+		err = ts.create(ctx, &te)
+	}
+	if err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
 	}
 
@@ -2015,7 +2370,7 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 
 	if ts.policyLookupFunc != nil {
 		for _, p := range te.Policies {
-			policy, err := ts.policyLookupFunc(p)
+			policy, err := ts.policyLookupFunc(ctx, p)
 			if err != nil {
 				return logical.ErrorResponse(fmt.Sprintf("could not look up policy %s", p)), nil
 			}
@@ -2135,16 +2490,23 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		return logical.ErrorResponse("missing token ID"), logical.ErrInvalidRequest
 	}
 
-	lock := locksutil.LockForKey(ts.tokenLocks, id)
-	lock.RLock()
-	defer lock.RUnlock()
-
 	// Lookup the token
-	saltedID, err := ts.SaltID(ctx, id)
-	if err != nil {
-		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	var out *TokenEntry
+	var err error
+	if isBatchToken(id) {
+		out, err = ts.lookupBatchToken(ctx, id)
+	} else {
+		lock := locksutil.LockForKey(ts.tokenLocks, id)
+		lock.RLock()
+		defer lock.RUnlock()
+
+		var saltedID string
+		saltedID, err = ts.SaltID(ctx, id)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		out, err = ts.lookupSalted(ctx, saltedID, true)
 	}
-	out, err := ts.lookupSalted(ctx, saltedID, true)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
 	}
@@ -2171,6 +2533,7 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 			"ttl":              int64(0),
 			"explicit_max_ttl": int64(out.ExplicitMaxTTL.Seconds()),
 			"entity_id":        out.EntityID,
+			"type":             string(out.tokenType()),
 		},
 	}
 
@@ -2184,6 +2547,24 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 	if out.Period != 0 {
 		resp.Data["period"] = int64(out.Period.Seconds())
 	}
+	if len(out.BoundCIDRs) > 0 {
+		resp.Data["bound_cidrs"] = out.BoundCIDRs
+	}
+
+	// Batch tokens have no lease, their expiration is part of the token
+	if out.Type == TokenTypeBatch {
+		if expire := out.expireTime(); !expire.IsZero() {
+			resp.Data["expire_time"] = expire
+			resp.Data["ttl"] = int64(time.Until(expire).Seconds())
+		}
+		resp.Data["renewable"] = false
+		resp.Data["issue_time"] = time.Unix(out.CreationTime, 0)
+
+		if urltoken {
+			resp.AddWarning(`Using a token in the path is unsafe as the token can be logged in many places. Please use POST or PUT with the token passed in via the "token" parameter.`)
+		}
+		return resp, nil
+	}
 
 	// Fetch the last renewal time
 	leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(out.Path, out.ID)
@@ -2243,6 +2624,9 @@ func (ts *TokenStore) handleRenew(ctx context.Context, req *logical.Request, dat
 	if te == nil {
 		return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
 	}
+	if te.Type == TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot be renewed"), logical.ErrInvalidRequest
+	}
 
 	// Renew the token and its children
 	resp, err := ts.expiration.RenewToken(req, te.Path, te.ID, increment)
@@ -2262,6 +2646,46 @@ func (ts *TokenStore) destroyCubbyhole(ctx context.Context, saltedID string) err
 	return ts.cubbyholeBackend.revoke(ctx, salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash))
 }
 
+// namespaceCubbyholes returns the cubbyhole backends of the child namespaces
+func (ts *TokenStore) namespaceCubbyholes() []*CubbyholeBackend {
+	if ts.core == nil {
+		return nil
+	}
+
+	var ret []*CubbyholeBackend
+	for _, ns := range ts.core.namespaceStore.all() {
+		if cubbyhole, ok := ts.core.router.MatchingBackend(ns.Path + "cubbyhole/").(*CubbyholeBackend); ok {
+			ret = append(ret, cubbyhole)
+		}
+	}
+	return ret
+}
+
+// tokenInNamespace returns whether the token was created in the given
+// namespace or in one beneath it
+func (ts *TokenStore) tokenInNamespace(te *TokenEntry, ns *namespace.Namespace) bool {
+	tokenNS := ts.core.tokenNamespace(te)
+	return tokenNS != nil && tokenNS.HasParent(ns)
+}
+
+// checkAccessorNamespace returns an error if the token of the accessor is not
+// visible from the namespace in ctx
+func (ts *TokenStore) checkAccessorNamespace(ctx context.Context, aEntry accessorEntry) error {
+	ns := namespace.FromContext(ctx)
+	if ns.ID == namespace.RootNamespaceID {
+		return nil
+	}
+
+	te, err := ts.lookupTainted(ctx, aEntry.TokenID)
+	if err != nil {
+		return err
+	}
+	if te == nil || !ts.tokenInNamespace(te, ns) {
+		return &logical.StatusBadRequest{Err: "invalid accessor"}
+	}
+	return nil
+}
+
 func (ts *TokenStore) authRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	if req.Auth == nil {
 		return nil, fmt.Errorf("request auth is nil")
@@ -2281,7 +2705,12 @@ func (ts *TokenStore) authRenew(ctx context.Context, req *logical.Request, d *fr
 		return &logical.Response{Auth: req.Auth}, nil
 	}
 
-	role, err := ts.tokenStoreRole(ctx, te.Role)
+	// Roles are kept per namespace
+	tokenNS := ts.core.tokenNamespace(te)
+	if tokenNS == nil {
+		return nil, fmt.Errorf("namespace of token could not be found, not renewing")
+	}
+	role, err := ts.tokenStoreRole(namespace.ContextWithNamespace(ctx, tokenNS), te.Role)
 	if err != nil {
 		return nil, errwrap.Wrapf(fmt.Sprintf("error looking up role %q: {{err}}", te.Role), err)
 	}
@@ -2294,8 +2723,18 @@ func (ts *TokenStore) authRenew(ctx context.Context, req *logical.Request, d *fr
 	return &logical.Response{Auth: req.Auth}, nil
 }
 
+// namespaceRolesPrefix returns the storage prefix of the roles of the
+// namespace in ctx
+func namespaceRolesPrefix(ctx context.Context) string {
+	ns := namespace.FromContext(ctx)
+	if ns.ID == namespace.RootNamespaceID {
+		return rolesPrefix
+	}
+	return namespaceBarrierPrefix + ns.ID + "/" + rolesPrefix
+}
+
 func (ts *TokenStore) tokenStoreRole(ctx context.Context, name string) (*tsRoleEntry, error) {
-	entry, err := ts.view.Get(ctx, fmt.Sprintf("%s%s", rolesPrefix, name))
+	entry, err := ts.view.Get(ctx, fmt.Sprintf("%s%s", namespaceRolesPrefix(ctx), name))
 	if err != nil {
 		return nil, err
 	}
@@ -2312,21 +2751,22 @@ func (ts *TokenStore) tokenStoreRole(ctx context.Context, name string) (*tsRoleE
 }
 
 func (ts *TokenStore) tokenStoreRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	entries, err := ts.view.List(ctx, rolesPrefix)
+	prefix := namespaceRolesPrefix(ctx)
+	entries, err := ts.view.List(ctx, prefix)
 	if err != nil {
 		return nil, err
 	}
 
 	ret := make([]string, len(entries))
 	for i, entry := range entries {
-		ret[i] = strings.TrimPrefix(entry, rolesPrefix)
+		ret[i] = strings.TrimPrefix(entry, prefix)
 	}
 
 	return logical.ListResponse(ret), nil
 }
 
 func (ts *TokenStore) tokenStoreRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	err := ts.view.Delete(ctx, fmt.Sprintf("%s%s", rolesPrefix, data.Get("role_name").(string)))
+	err := ts.view.Delete(ctx, fmt.Sprintf("%s%s", namespaceRolesPrefix(ctx), data.Get("role_name").(string)))
 	if err != nil {
 		return nil, err
 	}
@@ -2353,6 +2793,8 @@ func (ts *TokenStore) tokenStoreRoleRead(ctx context.Context, req *logical.Reque
 			"orphan":              role.Orphan,
 			"path_suffix":         role.PathSuffix,
 			"renewable":           role.Renewable,
+			"token_type":          string(role.TokenType),
+			"token_bound_cidrs":   role.BoundCIDRs,
 		},
 	}
 
@@ -2470,8 +2912,35 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 		entry.DisallowedPolicies = strutil.RemoveDuplicates(data.Get("disallowed_policies").([]string), true)
 	}
 
+	tokenTypeRaw, ok := data.GetOk("token_type")
+	if ok {
+		tokenType, err := parseTokenType(tokenTypeRaw.(string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		entry.TokenType = tokenType
+	}
+	if entry.TokenType == TokenTypeBatch && entry.Period != 0 {
+		return logical.ErrorResponse("batch tokens cannot be periodic"), nil
+	}
+
+	boundCIDRsRaw, ok := data.GetOk("token_bound_cidrs")
+	if ok {
+		boundCIDRs := boundCIDRsRaw.([]string)
+		if len(boundCIDRs) > 0 {
+			valid, err := cidrutil.ValidateCIDRListSlice(boundCIDRs)
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid token_bound_cidrs: %v", err)), nil
+			}
+			if !valid {
+				return logical.ErrorResponse("invalid token_bound_cidrs"), nil
+			}
+		}
+		entry.BoundCIDRs = boundCIDRs
+	}
+
 	// Store it
-	jsonEntry, err := logical.StorageEntryJSON(fmt.Sprintf("%s%s", rolesPrefix, name), entry)
+	jsonEntry, err := logical.StorageEntryJSON(fmt.Sprintf("%s%s", namespaceRolesPrefix(ctx), name), entry)
 	if err != nil {
 		return nil, err
 	}
@@ -2538,6 +3007,12 @@ no effect on the token being renewed.`
 	tokenRenewableHelp = `Tokens created via this role will be
 renewable or not according to this value.
 Defaults to "true".`
+	tokenTypeHelp = `The type of the tokens created via this role,
+either "service" or "batch". If not set, the type
+given when creating the token is used.`
+	tokenBoundCIDRsHelp = `Comma separated string or list of CIDR blocks.
+If set, tokens created via this role can only be
+used from addresses within these blocks.`
 	tokenListAccessorsHelp = `List token accessors, which can then be
 be used to iterate and discover their properties
 or revoke them. Because this can be used to
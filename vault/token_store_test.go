@@ -13,9 +13,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/errwrap"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -942,6 +944,7 @@ func TestTokenStore_HandleRequest_CreateToken_DisplayName(t *testing.T) {
 		Path:        "auth/token/create",
 		DisplayName: "token-foo-bar-baz",
 		TTL:         0,
+		NamespaceID: namespace.RootNamespaceID,
 	}
 	out, err := ts.Lookup(context.Background(), resp.Auth.ClientToken)
 	if err != nil {
@@ -974,6 +977,7 @@ func TestTokenStore_HandleRequest_CreateToken_NumUses(t *testing.T) {
 		DisplayName: "token",
 		NumUses:     1,
 		TTL:         0,
+		NamespaceID: namespace.RootNamespaceID,
 	}
 	out, err := ts.Lookup(context.Background(), resp.Auth.ClientToken)
 	if err != nil {
@@ -1037,6 +1041,7 @@ func TestTokenStore_HandleRequest_CreateToken_NoPolicy(t *testing.T) {
 		Path:        "auth/token/create",
 		DisplayName: "token",
 		TTL:         0,
+		NamespaceID: namespace.RootNamespaceID,
 	}
 	out, err := ts.Lookup(context.Background(), resp.Auth.ClientToken)
 	if err != nil {
@@ -1505,6 +1510,7 @@ func TestTokenStore_HandleRequest_Lookup(t *testing.T) {
 		"explicit_max_ttl": int64(0),
 		"expire_time":      nil,
 		"entity_id":        "",
+		"type":             "service",
 	}
 
 	if resp.Data["creation_time"].(int64) == 0 {
@@ -1545,6 +1551,7 @@ func TestTokenStore_HandleRequest_Lookup(t *testing.T) {
 		"explicit_max_ttl": int64(0),
 		"renewable":        true,
 		"entity_id":        "",
+		"type":             "service",
 	}
 
 	if resp.Data["creation_time"].(int64) == 0 {
@@ -1596,6 +1603,7 @@ func TestTokenStore_HandleRequest_Lookup(t *testing.T) {
 		"explicit_max_ttl": int64(0),
 		"renewable":        true,
 		"entity_id":        "",
+		"type":             "service",
 	}
 
 	if resp.Data["creation_time"].(int64) == 0 {
@@ -1678,6 +1686,7 @@ func TestTokenStore_HandleRequest_LookupSelf(t *testing.T) {
 		"ttl":              int64(3600),
 		"explicit_max_ttl": int64(0),
 		"entity_id":        "",
+		"type":             "service",
 	}
 
 	if resp.Data["creation_time"].(int64) == 0 {
@@ -1848,6 +1857,8 @@ func TestTokenStore_RoleCRUD(t *testing.T) {
 		"path_suffix":         "happenin",
 		"explicit_max_ttl":    int64(0),
 		"renewable":           true,
+		"token_type":          "",
+		"token_bound_cidrs":   []string(nil),
 	}
 
 	if !reflect.DeepEqual(expected, resp.Data) {
@@ -1892,6 +1903,8 @@ func TestTokenStore_RoleCRUD(t *testing.T) {
 		"path_suffix":         "happenin",
 		"explicit_max_ttl":    int64(0),
 		"renewable":           false,
+		"token_type":          "",
+		"token_bound_cidrs":   []string(nil),
 	}
 
 	if !reflect.DeepEqual(expected, resp.Data) {
@@ -1929,6 +1942,8 @@ func TestTokenStore_RoleCRUD(t *testing.T) {
 		"path_suffix":         "happenin",
 		"period":              int64(0),
 		"renewable":           false,
+		"token_type":          "",
+		"token_bound_cidrs":   []string(nil),
 	}
 
 	if !reflect.DeepEqual(expected, resp.Data) {
@@ -3846,3 +3861,328 @@ func TestTokenStore_TidyLeaseRevocation(t *testing.T) {
 		t.Fatal("found leases")
 	}
 }
+
+// testCoreMakeBatchTokenParent creates the token "parent", which is allowed
+// to create tokens
+func testCoreMakeBatchTokenParent(t *testing.T, c *Core, root string) {
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/policy/create")
+	req.ClientToken = root
+	req.Data["policy"] = `path "auth/token/create" { capabilities = ["update"] }`
+	resp, err := c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	testCoreMakeToken(t, c, root, "parent", "", []string{"create", "default"})
+}
+
+func TestTokenStore_BatchTokens(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	// Create a service token to act as the parent of the batch token
+	testCoreMakeBatchTokenParent(t, c, root)
+
+	before, err := logical.CollectKeys(context.Background(), ts.view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = "parent"
+	req.Data = map[string]interface{}{
+		"type": "batch",
+		"ttl":  "1h",
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	batch := resp.Auth.ClientToken
+	if !strings.HasPrefix(batch, batchTokenPrefix) {
+		t.Fatalf("bad: %q", batch)
+	}
+	if resp.Auth.Accessor != "" || resp.Auth.Renewable {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+
+	// Nothing should have been written for the batch token
+	after, err := logical.CollectKeys(context.Background(), ts.view)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Fatalf("batch token was persisted: before: %v, after: %v", before, after)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "auth/token/lookup-self")
+	req.ClientToken = batch
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["type"] != "batch" || resp.Data["orphan"] != false || resp.Data["renewable"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if !reflect.DeepEqual(resp.Data["policies"], []string{"create", "default"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if ttl := resp.Data["ttl"].(int64); ttl <= 0 || ttl > 3600 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Batch tokens can't be renewed or revoked, can't create child tokens
+	// and can't use the cubbyhole
+	for _, path := range []string{"auth/token/renew-self", "auth/token/revoke-self", "auth/token/create", "cubbyhole/foo"} {
+		req = logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = batch
+		resp, err = c.HandleRequest(req)
+		if err == nil {
+			t.Fatalf("expected error for %q, got %#v", path, resp)
+		}
+	}
+
+	// A tampered token is not valid
+	te, err := ts.Lookup(context.Background(), batch[:len(batch)-2]+"AA")
+	if err != nil || te != nil {
+		t.Fatalf("err: %v, token: %#v", err, te)
+	}
+
+	// Revoking the parent invalidates the batch token
+	if err := ts.RevokeTree(context.Background(), "parent"); err != nil {
+		t.Fatal(err)
+	}
+	te, err = ts.Lookup(context.Background(), batch)
+	if err != nil || te != nil {
+		t.Fatalf("err: %v, token: %#v", err, te)
+	}
+}
+
+func TestTokenStore_BatchTokens_Invalid(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	cases := []map[string]interface{}{
+		{"type": "batch", "period": "1h"},
+		{"type": "batch", "num_uses": 1},
+		{"type": "batch", "id": "foo"},
+		{"type": "batch"},
+		{"type": "foo", "policies": []string{"default"}},
+	}
+	for _, data := range cases {
+		req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+		req.ClientToken = root
+		req.Data = data
+		resp, err := c.HandleRequest(req)
+		if err == nil {
+			t.Fatalf("expected error for %#v, got %#v", data, resp)
+		}
+	}
+}
+
+func TestTokenStore_BatchTokens_Leases(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	view := NewBarrierView(c.barrier, "noop/")
+	noop := &NoopBackend{}
+	err := ts.expiration.router.Mount(noop, "noop/", &MountEntry{UUID: "noopuuid", Accessor: "noopaccessor"}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCoreMakeBatchTokenParent(t, c, root)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = "parent"
+	req.Data = map[string]interface{}{
+		"type": "batch",
+		"ttl":  "10m",
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	batch := resp.Auth.ClientToken
+
+	// The lease can't outlive the batch token
+	leaseReq := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "noop/foo",
+		ClientToken: batch,
+	}
+	leaseResp := &logical.Response{
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL: time.Hour,
+			},
+		},
+	}
+	leaseID, err := ts.expiration.Register(leaseReq, leaseResp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaseResp.Secret.TTL > 10*time.Minute {
+		t.Fatalf("bad: %v", leaseResp.Secret.TTL)
+	}
+
+	// The lease is revoked along with the parent of the batch token
+	if err := ts.RevokeTree(context.Background(), "parent"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ts.expiration.loadEntry(leaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestTokenStore_RoleBatchTokens(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"token_type": "batch",
+		"period":     "1h",
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	req.Data = map[string]interface{}{
+		"token_type": "batch",
+		"orphan":     true,
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"policies": []string{"default"},
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	te, err := c.tokenStore.Lookup(context.Background(), resp.Auth.ClientToken)
+	if err != nil || te == nil {
+		t.Fatalf("err: %v, token: %#v", err, te)
+	}
+	if te.Type != TokenTypeBatch || te.Parent != "" || te.Role != "test" {
+		t.Fatalf("bad: %#v", te)
+	}
+
+	// The type can't be changed when creating tokens against the role
+	req.Data["type"] = "service"
+	resp, err = c.HandleRequest(req)
+	if err == nil {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+}
+
+func TestTokenStore_RoleBoundCIDRs(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"token_bound_cidrs": "127.0.0.1/8,not-a-cidr",
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	req.Data = map[string]interface{}{
+		"token_bound_cidrs": "127.0.0.1/8",
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// The role is stored under the same name as the API parameter
+	entry, err := c.tokenStore.view.Get(context.Background(), rolesPrefix+"test")
+	if err != nil || entry == nil {
+		t.Fatalf("err: %v, entry: %#v", err, entry)
+	}
+	var raw map[string]interface{}
+	if err := entry.DecodeJSON(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(raw["token_bound_cidrs"], []interface{}{"127.0.0.1/8"}) {
+		t.Fatalf("bad: %#v", raw)
+	}
+
+	// Tokens created against the role without CIDR blocks inherit the role's
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/test")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	te, err := c.tokenStore.Lookup(context.Background(), resp.Auth.ClientToken)
+	if err != nil || te == nil {
+		t.Fatalf("err: %v, token: %#v", err, te)
+	}
+	if !reflect.DeepEqual(te.BoundCIDRs, []string{"127.0.0.1/8"}) {
+		t.Fatalf("bad: %#v", te.BoundCIDRs)
+	}
+
+	// Narrower blocks are allowed
+	req.Data = map[string]interface{}{
+		"token_bound_cidrs": []string{"127.0.0.1/32"},
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	te, err = c.tokenStore.Lookup(context.Background(), resp.Auth.ClientToken)
+	if err != nil || te == nil {
+		t.Fatalf("err: %v, token: %#v", err, te)
+	}
+	if !reflect.DeepEqual(te.BoundCIDRs, []string{"127.0.0.1/32"}) {
+		t.Fatalf("bad: %#v", te.BoundCIDRs)
+	}
+
+	// Blocks outside of the role's are not
+	req.Data = map[string]interface{}{
+		"token_bound_cidrs": "10.0.0.0/8",
+	}
+	resp, err = c.HandleRequest(req)
+	if err == nil {
+		t.Fatalf("expected error, got %#v", resp)
+	}
+
+	// The token can only be used from within its blocks
+	lookupSelf := func(remoteAddr string) error {
+		_, err := c.HandleRequest(&logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        "auth/token/lookup-self",
+			ClientToken: te.ID,
+			Connection: &logical.Connection{
+				RemoteAddr: remoteAddr,
+			},
+		})
+		return err
+	}
+	if err := lookupSelf("127.0.0.1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := lookupSelf("127.0.0.2"); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -104,6 +105,11 @@ DONELISTHANDLING:
 	var err error
 	sealWrap := resp.WrapInfo.SealWrap
 
+	// The wrapping token and the cubbyhole holding the response belong to
+	// the namespace of the request
+	ns := namespace.FromContext(ctx)
+	isRewrap := ns.TrimmedPath(req.Path) == "sys/wrapping/rewrap"
+
 	// If we are wrapping, the first part (performed in this functions) happens
 	// before auditing so that resp.WrapInfo.Token can contain the HMAC'd
 	// wrapping token ID in the audit logs, so that it can be determined from
@@ -127,7 +133,7 @@ DONELISTHANDLING:
 	resp.WrapInfo.Accessor = te.Accessor
 	resp.WrapInfo.CreationTime = creationTime
 	// If this is not a rewrap, store the request path as creation_path
-	if req.Path != "sys/wrapping/rewrap" {
+	if !isRewrap {
 		resp.WrapInfo.CreationPath = req.Path
 	}
 
@@ -171,7 +177,7 @@ DONELISTHANDLING:
 
 	cubbyReq := &logical.Request{
 		Operation:   logical.CreateOperation,
-		Path:        "cubbyhole/response",
+		Path:        ns.Path + "cubbyhole/response",
 		ClientToken: te.ID,
 	}
 	if sealWrap {
@@ -181,7 +187,7 @@ DONELISTHANDLING:
 	}
 
 	// During a rewrap, store the original response, don't wrap it again.
-	if req.Path == "sys/wrapping/rewrap" {
+	if isRewrap {
 		cubbyReq.Data = map[string]interface{}{
 			"response": resp.Data["response"],
 		}
@@ -225,13 +231,13 @@ DONELISTHANDLING:
 
 	// Store info for lookup
 	cubbyReq.WrapInfo = nil
-	cubbyReq.Path = "cubbyhole/wrapinfo"
+	cubbyReq.Path = ns.Path + "cubbyhole/wrapinfo"
 	cubbyReq.Data = map[string]interface{}{
 		"creation_ttl":  resp.WrapInfo.TTL,
 		"creation_time": creationTime,
 	}
 	// Store creation_path if not a rewrap
-	if req.Path != "sys/wrapping/rewrap" {
+	if !isRewrap {
 		cubbyReq.Data["creation_path"] = req.Path
 	} else {
 		cubbyReq.Data["creation_path"] = resp.WrapInfo.CreationPath
@@ -346,3 +352,15 @@ func (c *Core) ValidateWrappingToken(req *logical.Request) (bool, error) {
 
 	return true, nil
 }
+
+// wrappingCubbyholePath returns the path of the given entry in the cubbyhole
+// of the namespace the wrapping token was created in
+func (c *Core) wrappingCubbyholePath(ctx context.Context, token, entry string) string {
+	te, err := c.tokenStore.lookupTainted(ctx, token)
+	if err == nil && te != nil {
+		if ns := c.tokenNamespace(te); ns != nil {
+			return ns.Path + "cubbyhole/" + entry
+		}
+	}
+	return "cubbyhole/" + entry
+}